@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
-	"syscall"
 )
 
 // ResolveAgent resolves an agent name to an executable path.
@@ -46,11 +46,17 @@ type LaunchOptions struct {
 	TicketKey     string
 	TicketSummary string
 	Aliases       map[string]string
+	Env           map[string]string
+	// EnvAllow, when set, limits the environment the agent inherits to
+	// these variables and BaseEnv; Env and the WT_ variables are added.
+	EnvAllow []string
+	// Sandbox, when set, wraps the agent.
+	Sandbox *Sandbox
 }
 
 // LaunchAgent launches an agent using exec syscall to replace the current process.
 func LaunchAgent(opts LaunchOptions) error {
-	agentPath, err := ResolveAgent(opts.Agent, opts.Aliases)
+	argv, env, err := opts.Command(true)
 	if err != nil {
 		return err
 	}
@@ -62,29 +68,90 @@ func LaunchAgent(opts LaunchOptions) error {
 		}
 	}
 
-	// Set environment variables
-	if opts.TaskID != "" {
-		os.Setenv("WT_TASK_ID", opts.TaskID)
-	}
-	if opts.TicketKey != "" {
-		os.Setenv("WT_TICKET_KEY", opts.TicketKey)
+	// Replace the current process where the OS can, so the agent becomes
+	// the direct child of the shell.
+	return execAgent(argv, env)
+}
+
+// Start starts an agent attached to this terminal as a child of wt, so
+// that wt can watch over it while it runs; wait on the returned command.
+// Unlike LaunchAgent, wt keeps running.
+func Start(opts LaunchOptions) (*exec.Cmd, error) {
+	argv, env, err := opts.Command(true)
+	if err != nil {
+		return nil, err
 	}
-	if opts.TicketSummary != "" {
-		os.Setenv("WT_TICKET_SUMMARY", opts.TicketSummary)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = opts.WorkDir
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", argv[0], err)
 	}
+	return cmd, nil
+}
 
-	// Build command arguments
-	args := []string{agentPath}
-	args = append(args, opts.Args...)
+// Spawn starts an agent as a detached background process writing its
+// output to logPath, and returns its PID. Unlike LaunchAgent, the agent has
+// no terminal, so it must be able to run non-interactively.
+func Spawn(opts LaunchOptions, logPath string) (int, error) {
+	argv, env, err := opts.Command(false)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create agent log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = opts.WorkDir
+	cmd.Env = env
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Detach from the terminal so the agent survives the shell exiting.
+	Detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", argv[0], err)
+	}
+	pid := cmd.Process.Pid
+	return pid, cmd.Process.Release()
+}
 
-	// Use exec syscall to replace the current process
-	// This makes the agent the direct child of the shell
-	if err := syscall.Exec(agentPath, args, os.Environ()); err != nil {
-		return fmt.Errorf("failed to exec %s: %w", agentPath, err)
+// IsAlive reports whether the process with the given PID is running and
+// is the one that started at started, as told by StartTime: once a
+// process exits, its PID may be given to another. An empty started, as
+// recorded by older versions, only checks the PID.
+func IsAlive(pid int, started string) bool {
+	if !IsRunning(pid) {
+		return false
+	}
+	if started == "" {
+		return true
 	}
+	now := StartTime(pid)
+	return now == "" || now == started
+}
 
-	// This line will never be reached if exec succeeds
-	return nil
+// IsAncestor reports whether the process with the given PID is this
+// process or one of its ancestors, as when an agent runs wt itself.
+func IsAncestor(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	for p, depth := os.Getpid(), 0; p > 1 && depth < 64; depth++ {
+		if p == pid {
+			return true
+		}
+		p = parentPID(p)
+	}
+	return false
 }
 
 // ParseAgentArgs parses a space-separated string of agent arguments.
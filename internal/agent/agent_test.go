@@ -1,7 +1,15 @@
 package agent
 
 import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
 )
 
 func TestParseAgentArgs(t *testing.T) {
@@ -152,3 +160,272 @@ func TestValidateAgent(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAncestor(t *testing.T) {
+	if !IsAncestor(os.Getpid()) || !IsAncestor(os.Getppid()) {
+		t.Errorf("IsAncestor is false for this process or its parent")
+	}
+	child := exec.Command("sleep", "5")
+	if err := child.Start(); err != nil {
+		t.Skip("cannot start a child process:", err)
+	}
+	defer child.Process.Kill()
+	if IsAncestor(child.Process.Pid) {
+		t.Errorf("IsAncestor(%d) = true for a child process", child.Process.Pid)
+	}
+	if IsAncestor(0) {
+		t.Errorf("IsAncestor(0) = true")
+	}
+}
+
+func TestIsAlive(t *testing.T) {
+	started := StartTime(os.Getpid())
+	if started == "" {
+		t.Skip("cannot tell when processes started")
+	}
+	if !IsAlive(os.Getpid(), started) || !IsAlive(os.Getpid(), "") {
+		t.Errorf("IsAlive is false for this process")
+	}
+	if IsAlive(os.Getpid(), started+"0") {
+		t.Errorf("IsAlive is true for a process that reused the PID")
+	}
+	if IsAlive(0, "") {
+		t.Errorf("IsAlive(0) = true")
+	}
+}
+
+func TestParseClaudeSession(t *testing.T) {
+	session := `{"type":"user","cwd":"/wt/app","message":{"role":"user","content":"hi"}}
+{"type":"assistant","cwd":"/wt/app","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}
+{"type":"assistant","cwd":"/wt/app","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}
+{"type":"assistant","cwd":"/wt/app","requestId":"r2","costUSD":0.5,"message":{"id":"m2","model":"claude-haiku-4-5","usage":{"input_tokens":7,"output_tokens":3}}}
+{"type":"assistant","cwd":"/wt/other","requestId":"r3","message":{"id":"m3","model":"claude-sonnet-4-5","usage":{"input_tokens":99,"output_tokens":99}}}
+{"type":"assistant","cwd":"/wt/app","message":{"id":"m4","model":"<synthetic>","usage":{"input_tokens":0,"output_tokens":0}}}
+`
+	seen := make(map[string]bool)
+	models, err := parseClaudeSession(strings.NewReader(session), "/wt/app", seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]config.Usage{
+		"claude-sonnet-4-5": {InputTokens: 10, OutputTokens: 5, CacheWriteTokens: 100, CacheReadTokens: 1000},
+		"claude-haiku-4-5":  {InputTokens: 7, OutputTokens: 3, CostUSD: 0.5},
+	}
+	if len(models) != len(want) {
+		t.Fatalf("got %d models, want %d: %+v", len(models), len(want), models)
+	}
+	for model, u := range want {
+		if models[model] != u {
+			t.Errorf("%s: got %+v, want %+v", model, models[model], u)
+		}
+	}
+
+	// A resumed session repeats the messages already counted.
+	models, err = parseClaudeSession(strings.NewReader(session), "/wt/app", seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 0 {
+		t.Errorf("resumed session counted again: %+v", models)
+	}
+}
+
+func TestParseCodexSession(t *testing.T) {
+	session := `{"type":"session_meta","payload":{"id":"s1","cwd":"/wt/app"}}
+{"type":"turn_context","payload":{"cwd":"/wt/app","model":"gpt-5-codex"}}
+{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":100,"cached_input_tokens":40,"output_tokens":20}}}}
+{"type":"event_msg","payload":{"type":"token_count","info":null}}
+{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":300,"cached_input_tokens":200,"output_tokens":50}}}}
+`
+	model, u, ok, err := parseCodexSession(strings.NewReader(session), "/wt/app")
+	if err != nil || !ok {
+		t.Fatalf("parseCodexSession() = %v, %v", ok, err)
+	}
+	if want := (config.Usage{InputTokens: 100, OutputTokens: 50, CacheReadTokens: 200}); model != "gpt-5-codex" || u != want {
+		t.Errorf("got %s %+v, want gpt-5-codex %+v", model, u, want)
+	}
+	if _, _, ok, _ := parseCodexSession(strings.NewReader(session), "/wt/other"); ok {
+		t.Error("session of another directory should not count")
+	}
+}
+
+func TestReadUsage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "")
+	dir := "/wt/app.v2"
+
+	project := filepath.Join(home, ".claude", "projects", "-wt-app-v2")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	claude := `{"type":"assistant","cwd":"/wt/app.v2","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":5}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(project, "s1.jsonl"), []byte(claude), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	codexDir := filepath.Join(home, ".codex", "sessions", "2026", "01", "02")
+	if err := os.MkdirAll(codexDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	codex := `{"type":"session_meta","payload":{"cwd":"/wt/app.v2"}}
+{"type":"turn_context","payload":{"model":"gpt-5"}}
+{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":8,"output_tokens":2}}}}
+`
+	if err := os.WriteFile(filepath.Join(codexDir, "rollout-1.jsonl"), []byte(codex), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := ReadUsage(dir, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Sessions != 2 || u.Models["claude-sonnet-4-5"].OutputTokens != 5 || u.Models["gpt-5"].InputTokens != 8 {
+		t.Errorf("ReadUsage() = %+v", u)
+	}
+
+	u, err = ReadUsage(dir, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Sessions != 0 {
+		t.Errorf("sessions before since should not count, got %+v", u)
+	}
+}
+
+func TestKind(t *testing.T) {
+	aliases := map[string]string{"cc": "/opt/bin/claude", "ai": "aider"}
+	tests := []struct {
+		name, want string
+	}{
+		{"claude", KindClaude},
+		{"cc", KindClaude},
+		{"codex", KindCodex},
+		{"ai", ""},
+		{"copilot", KindCopilot},
+	}
+	for _, tt := range tests {
+		if got := Kind(tt.name, aliases); got != tt.want {
+			t.Errorf("Kind(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLatestSession(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "")
+	dir := "/wt/app"
+
+	project := filepath.Join(home, ".claude", "projects", "-wt-app")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	for _, id := range []string{"old-session", "new-session"} {
+		path := filepath.Join(project, id+".jsonl")
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if id == "old-session" {
+			os.Chtimes(path, old, old)
+		}
+	}
+	if id, err := LatestSession(KindClaude, dir, time.Time{}); err != nil || id != "new-session" {
+		t.Errorf("LatestSession(claude) = %q, %v, want new-session", id, err)
+	}
+
+	codexDir := filepath.Join(home, ".codex", "sessions", "2026", "01", "02")
+	if err := os.MkdirAll(codexDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, cwd := range map[string]string{"rollout-a.jsonl": dir, "rollout-b.jsonl": "/wt/other"} {
+		meta := `{"type":"session_meta","payload":{"id":"` + name + `","cwd":"` + cwd + `"}}` + "\n"
+		if err := os.WriteFile(filepath.Join(codexDir, name), []byte(meta), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if id, err := LatestSession(KindCodex, dir, time.Time{}); err != nil || id != "rollout-a.jsonl" {
+		t.Errorf("LatestSession(codex) = %q, %v, want rollout-a.jsonl", id, err)
+	}
+
+	if _, err := LatestSession(KindCodex, "/wt/none", time.Time{}); !errors.Is(err, ErrNoSession) {
+		t.Errorf("LatestSession() without sessions = %v, want ErrNoSession", err)
+	}
+	if _, err := LatestSession("", dir, time.Time{}); err == nil {
+		t.Error("LatestSession() of an unknown agent should fail")
+	}
+}
+
+func TestFilterEnv(t *testing.T) {
+	environ := []string{"PATH=/bin", "AWS_SECRET_ACCESS_KEY=x", "LC_ALL=C", "ANTHROPIC_API_KEY=k", "GITHUB_TOKEN=t", "LCX=1"}
+	got := FilterEnv(environ, []string{"PATH", "LC_*", "ANTHROPIC_API_KEY"})
+	want := []string{"PATH=/bin", "LC_ALL=C", "ANTHROPIC_API_KEY=k"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("FilterEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestLaunchCommand(t *testing.T) {
+	t.Setenv("WT_TEST_SECRET", "s3cret")
+	t.Setenv("WT_TEST_KEEP", "yes")
+	t.Setenv("GIT_AUTHOR_NAME", "Shell User")
+	opts := LaunchOptions{
+		Agent:    "echo",
+		Args:     []string{"hi"},
+		TaskID:   "wt-1",
+		Env:      map[string]string{"GIT_AUTHOR_NAME": "Task Identity"},
+		EnvAllow: []string{"WT_TEST_KEEP", "GIT_*"},
+	}
+	argv, env, err := opts.Command(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(argv[0]) != "echo" || argv[1] != "hi" {
+		t.Errorf("argv = %v", argv)
+	}
+	joined := "\n" + strings.Join(env, "\n") + "\n"
+	for _, want := range []string{"\nWT_TEST_KEEP=yes\n", "\nWT_TASK_ID=wt-1\n", "\nGIT_AUTHOR_NAME=Task Identity\n"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("env lacks %q", strings.TrimSpace(want))
+		}
+	}
+	if strings.Contains(joined, "WT_TEST_SECRET") || strings.Contains(joined, "Shell User") {
+		t.Errorf("env should not pass %v", env)
+	}
+
+	opts.EnvAllow = nil
+	if _, env, _ = opts.Command(false); !strings.Contains(strings.Join(env, "\n"), "WT_TEST_SECRET=s3cret") {
+		t.Error("without an allowlist the whole environment should pass")
+	}
+
+	opts.Sandbox = &Sandbox{Kind: SandboxDocker}
+	if _, _, err := opts.Command(false); err == nil {
+		t.Error("docker sandbox without an image should fail")
+	}
+	opts.Sandbox = &Sandbox{Kind: "jail"}
+	if _, _, err := opts.Command(false); err == nil {
+		t.Error("unknown sandbox should fail")
+	}
+}
+
+func TestConfineArgs(t *testing.T) {
+	dirs := []string{"/scratch/wt-1"}
+	tests := []struct {
+		kind string
+		want string
+		ok   bool
+	}{
+		{KindClaude, "--add-dir /scratch/wt-1", false},
+		{KindCopilot, "--add-dir /scratch/wt-1", false},
+		{KindCodex, "--sandbox workspace-write --add-dir /scratch/wt-1", true},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		args, ok := ConfineArgs(tt.kind, dirs)
+		if ok != tt.ok || strings.Join(args, " ") != tt.want {
+			t.Errorf("ConfineArgs(%q) = %v, %v, want %q, %v", tt.kind, args, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// BaseEnv is the part of the environment agents always keep when it is
+// limited to an allowlist: what a shell and terminal need to work.
+var BaseEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "COLORTERM", "LANG", "LC_*", "TZ", "TMPDIR"}
+
+// FilterEnv returns the variables of environ, in KEY=value form, whose
+// names are in allow. A name ending in "*" allows every variable starting
+// with the rest of it.
+func FilterEnv(environ, allow []string) []string {
+	var kept []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		for _, pattern := range allow {
+			prefix, wildcard := strings.CutSuffix(pattern, "*")
+			if name == pattern || wildcard && strings.HasPrefix(name, prefix) {
+				kept = append(kept, kv)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// Sandboxes an agent can be wrapped in.
+const (
+	SandboxFirejail = "firejail"
+	SandboxExec     = "sandbox-exec"
+	SandboxDocker   = "docker"
+)
+
+// Sandbox wraps an agent so that it can only write to its worktree and a
+// few other paths.
+type Sandbox struct {
+	// Kind is SandboxFirejail, SandboxExec (macOS) or SandboxDocker.
+	Kind string
+	// Image is the container image for SandboxDocker.
+	Image string
+	// Paths the agent may write to besides its working directory, such as
+	// the repository's .git directory and the agent's own state.
+	Paths []string
+}
+
+// ValidateSandbox checks the name of a sandbox; "" means none.
+func ValidateSandbox(kind string) error {
+	switch kind {
+	case "", SandboxFirejail, SandboxExec, SandboxDocker:
+		return nil
+	}
+	return fmt.Errorf("unknown agent sandbox %q (want %s, %s or %s)", kind, SandboxFirejail, SandboxExec, SandboxDocker)
+}
+
+// ConfineArgs returns the flags that point an agent of a kind at its
+// working directory and dirs, to go after its other arguments, and whether
+// the agent then enforces that by itself; agents that don't need a sandbox
+// as well.
+func ConfineArgs(kind string, dirs []string) ([]string, bool) {
+	var args []string
+	enforced := false
+	switch kind {
+	case KindClaude, KindCopilot:
+		// Both only edit files in the working directory and added ones
+		// without asking, but may still be allowed to edit others.
+	case KindCodex:
+		args = append(args, "--sandbox", "workspace-write")
+		enforced = true
+	default:
+		return nil, false
+	}
+	for _, dir := range dirs {
+		args = append(args, "--add-dir", dir)
+	}
+	return args, enforced
+}
+
+// DefaultSandbox returns the sandbox available on this system, or "".
+func DefaultSandbox() string {
+	kind := SandboxFirejail
+	if runtime.GOOS == "darwin" {
+		kind = SandboxExec
+	}
+	if _, err := exec.LookPath(kind); err != nil {
+		return ""
+	}
+	return kind
+}
+
+// StatePaths returns the existing files and directories in which known
+// agents keep their logins, settings and sessions, so that sandboxed
+// agents can still use them.
+func StatePaths() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, name := range []string{".claude", ".claude.json", ".codex", ".copilot", ".config/github-copilot", ".gemini"} {
+		path := filepath.Join(home, name)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// wrap returns argv run inside the sandbox, in dir with env. interactive
+// gives docker a terminal.
+func (s *Sandbox) wrap(argv []string, dir string, env []string, interactive bool) ([]string, error) {
+	paths := append([]string{dir}, s.Paths...)
+	var wrapped []string
+	switch s.Kind {
+	case SandboxFirejail:
+		wrapped = []string{SandboxFirejail, "--quiet"}
+		for _, p := range paths {
+			wrapped = append(wrapped, "--whitelist="+p)
+		}
+		wrapped = append(wrapped, "--")
+	case SandboxExec:
+		wrapped = []string{SandboxExec, "-p", sandboxProfile(paths)}
+	case SandboxDocker:
+		if s.Image == "" {
+			return nil, fmt.Errorf("the docker agent sandbox needs an image; set agent_sandbox_image")
+		}
+		wrapped = []string{SandboxDocker, "run", "--rm", "-i"}
+		if interactive {
+			wrapped = append(wrapped, "-t")
+		}
+		for _, p := range paths {
+			wrapped = append(wrapped, "-v", p+":"+p)
+		}
+		wrapped = append(wrapped, "-w", dir)
+		for _, kv := range env {
+			name, _, _ := strings.Cut(kv, "=")
+			if name != "PATH" && name != "HOME" {
+				wrapped = append(wrapped, "-e", name)
+			}
+		}
+		wrapped = append(wrapped, s.Image)
+		// The agent's host path means nothing inside the image.
+		argv = append([]string{filepath.Base(argv[0])}, argv[1:]...)
+	default:
+		return nil, ValidateSandbox(s.Kind)
+	}
+	path, err := exec.LookPath(wrapped[0])
+	if err != nil {
+		return nil, fmt.Errorf("agent sandbox %s is not installed: %w", s.Kind, err)
+	}
+	wrapped[0] = path
+	return append(wrapped, argv...), nil
+}
+
+// sandboxProfile returns a macOS sandbox profile that allows everything
+// but writing outside paths and the temporary directories.
+func sandboxProfile(paths []string) string {
+	var b strings.Builder
+	b.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n(allow file-write*\n")
+	b.WriteString("  (literal \"/dev/null\") (literal \"/dev/tty\") (subpath \"/private/tmp\") (subpath \"/private/var/folders\")")
+	for _, p := range paths {
+		fmt.Fprintf(&b, "\n  (subpath %q)", p)
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// Command returns the command line and environment that launch the agent
+// described by opts: the environment limited to EnvAllow, if set, and the
+// agent wrapped in Sandbox, if set. interactive means the agent gets a
+// terminal.
+func (opts LaunchOptions) Command(interactive bool) (argv, env []string, err error) {
+	agentPath, err := ResolveAgent(opts.Agent, opts.Aliases)
+	if err != nil {
+		return nil, nil, err
+	}
+	extra := make(map[string]string, len(opts.Env)+3)
+	for k, v := range opts.Env {
+		extra[k] = v
+	}
+	if opts.TaskID != "" {
+		extra["WT_TASK_ID"] = opts.TaskID
+	}
+	if opts.TicketKey != "" {
+		extra["WT_TICKET_KEY"] = opts.TicketKey
+	}
+	if opts.TicketSummary != "" {
+		extra["WT_TICKET_SUMMARY"] = opts.TicketSummary
+	}
+	inherited := os.Environ()
+	if len(opts.EnvAllow) > 0 {
+		inherited = FilterEnv(inherited, append(append([]string{}, BaseEnv...), opts.EnvAllow...))
+	}
+	// Drop inherited variables that are set again, as the first of two
+	// wins for most programs.
+	for _, kv := range inherited {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := extra[name]; !ok {
+			env = append(env, kv)
+		}
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+extra[k])
+	}
+
+	argv = append([]string{agentPath}, opts.Args...)
+	if opts.Sandbox != nil && opts.Sandbox.Kind != "" {
+		if argv, err = opts.Sandbox.wrap(argv, opts.WorkDir, env, interactive); err != nil {
+			return nil, nil, err
+		}
+	}
+	return argv, env, nil
+}
@@ -0,0 +1,85 @@
+//go:build !windows
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// execAgent replaces wt with the agent.
+func execAgent(argv, env []string) error {
+	if err := syscall.Exec(argv[0], argv, env); err != nil {
+		return fmt.Errorf("failed to exec %s: %w", argv[0], err)
+	}
+	// Never reached if exec succeeds.
+	return nil
+}
+
+// Detach makes cmd start in a session of its own, so that it survives
+// the terminal it was started from closing.
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// Stop asks a process started with Detach, and the processes it started
+// in turn, to exit.
+func Stop(pid int) error {
+	if err := syscall.Kill(-pid, syscall.SIGTERM); err == nil {
+		return nil
+	}
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// IsRunning reports whether a process with the given PID is alive.
+func IsRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+// StartTime identifies when a process started, or returns "" if it is
+// unknown. The value only means something compared to another StartTime.
+func StartTime(pid int) string {
+	// starttime is the 22nd field of /proc/<pid>/stat.
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		if i := strings.LastIndexByte(string(data), ')'); i >= 0 {
+			if fields := strings.Fields(string(data[i+1:])); len(fields) > 19 {
+				return fields[19]
+			}
+		}
+	}
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// parentPID returns the parent of a process, or 0 if it is unknown.
+func parentPID(pid int) int {
+	if pid == os.Getpid() {
+		return os.Getppid()
+	}
+	// /proc/<pid>/stat is "pid (comm) state ppid ...", where comm may
+	// hold spaces.
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		if i := strings.LastIndexByte(string(data), ')'); i >= 0 {
+			if fields := strings.Fields(string(data[i+1:])); len(fields) > 1 {
+				ppid, _ := strconv.Atoi(fields[1])
+				return ppid
+			}
+		}
+	}
+	out, err := exec.Command("ps", "-o", "ppid=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0
+	}
+	ppid, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return ppid
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// detachedProcess is DETACHED_PROCESS, which syscall does not define.
+const detachedProcess = 0x00000008
+
+// execAgent runs the agent attached to this console and exits with its
+// status, as Windows cannot replace a running process.
+func execAgent(argv, env []string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %w", argv[0], err)
+	}
+	os.Exit(0)
+	return nil
+}
+
+// Detach makes cmd start without a console, in a process group of its own,
+// so that it survives the console it was started from closing.
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+// Stop ends a process started with Detach. Windows has no signal asking a
+// process to exit, so it is killed.
+func Stop(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+// IsRunning reports whether a process with the given PID is alive.
+func IsRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	// STILL_ACTIVE (259) is the exit code of a running process.
+	return syscall.GetExitCodeProcess(h, &code) == nil && code == 259
+}
+
+// StartTime identifies when a process started, or returns "" if it is
+// unknown. The value only means something compared to another StartTime.
+func StartTime(pid int) string {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(h)
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return ""
+	}
+	return fmt.Sprint(created.Nanoseconds())
+}
+
+// parentPID returns the parent of a process, or 0 if it is unknown.
+func parentPID(pid int) int {
+	snap, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return 0
+	}
+	defer syscall.CloseHandle(snap)
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = syscall.Process32First(snap, &entry); err == nil; err = syscall.Process32Next(snap, &entry) {
+		if int(entry.ProcessID) == pid {
+			return int(entry.ParentProcessID)
+		}
+	}
+	return 0
+}
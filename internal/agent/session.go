@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Agents wt knows the flags of. Sessions of claude and codex can be found
+// and resumed.
+const (
+	KindClaude  = "claude"
+	KindCodex   = "codex"
+	KindCopilot = "copilot"
+)
+
+// ErrNoSession is returned when an agent has no session to resume.
+var ErrNoSession = errors.New("no previous agent session found")
+
+// Kind returns which known agent an agent name or alias runs, judged by
+// the name of its executable, or "" for other agents.
+func Kind(name string, aliases map[string]string) string {
+	exe := name
+	if path, ok := aliases[name]; ok {
+		exe = path
+	}
+	base := filepath.Base(exe)
+	for _, kind := range []string{KindClaude, KindCodex, KindCopilot} {
+		if strings.HasPrefix(base, kind) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// ResumeArgs returns the arguments that make an agent of a kind resume a
+// session, to go before any other arguments.
+func ResumeArgs(kind, session string) []string {
+	switch kind {
+	case KindClaude:
+		return []string{"--resume", session}
+	case KindCodex:
+		return []string{"resume", session}
+	}
+	return nil
+}
+
+// LatestSession returns the ID of the newest session of an agent of a kind
+// run in dir and active since a time, read from the agent's session logs.
+func LatestSession(kind, dir string, since time.Time) (string, error) {
+	var id string
+	var err error
+	switch kind {
+	case KindClaude:
+		id, err = latestClaudeSession(dir, since)
+	case KindCodex:
+		id, err = latestCodexSession(dir, since)
+	default:
+		return "", fmt.Errorf("cannot find sessions of %q; only claude and codex sessions can be resumed", kind)
+	}
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("%w for %s in %s", ErrNoSession, kind, dir)
+	}
+	return id, nil
+}
+
+// latestClaudeSession returns the newest Claude Code session of dir, named
+// by its log file.
+func latestClaudeSession(dir string, since time.Time) (string, error) {
+	claudeDir, err := agentHome("CLAUDE_CONFIG_DIR", ".claude")
+	if err != nil {
+		return "", err
+	}
+	files, err := claudeSessions(claudeDir, dir)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	var newest time.Time
+	for _, path := range files {
+		fi, err := os.Stat(path)
+		if err != nil || fi.ModTime().Before(since) || !fi.ModTime().After(newest) {
+			continue
+		}
+		id, newest = strings.TrimSuffix(filepath.Base(path), ".jsonl"), fi.ModTime()
+	}
+	return id, nil
+}
+
+// latestCodexSession returns the newest Codex session of dir, named by the
+// ID in the first line of its log.
+func latestCodexSession(dir string, since time.Time) (string, error) {
+	codexDir, err := agentHome("CODEX_HOME", ".codex")
+	if err != nil {
+		return "", err
+	}
+	var id string
+	var newest time.Time
+	err = walkCodexSessions(codexDir, since, func(path string, modified time.Time) error {
+		if !modified.After(newest) {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		var meta codexEntry
+		err = eachLine(f, func(line []byte) bool {
+			json.Unmarshal(line, &meta)
+			return false
+		})
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if meta.Type == "session_meta" && meta.Payload.ID != "" && filepath.Clean(meta.Payload.Cwd) == dir {
+			id, newest = meta.Payload.ID, modified
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read Codex sessions: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+// Usage is the token usage of the agent sessions run in a directory.
+type Usage struct {
+	Sessions int
+	// Models holds the tokens used by each model. Cost is set only when
+	// the agent reported it.
+	Models map[string]config.Usage
+}
+
+func (u *Usage) add(model string, m config.Usage) {
+	if u.Models == nil {
+		u.Models = make(map[string]config.Usage)
+	}
+	total := u.Models[model]
+	total.Add(m)
+	u.Models[model] = total
+}
+
+// ReadUsage reads the usage of the Claude Code and Codex sessions run in
+// dir and active since a time from the session logs the agents keep in
+// ~/.claude and ~/.codex (or $CLAUDE_CONFIG_DIR and $CODEX_HOME). Other
+// agents leave no usage behind and count for nothing.
+func ReadUsage(dir string, since time.Time) (Usage, error) {
+	var u Usage
+	claudeDir, err := agentHome("CLAUDE_CONFIG_DIR", ".claude")
+	if err != nil {
+		return u, err
+	}
+	if err := readClaudeUsage(&u, claudeDir, dir, since); err != nil {
+		return u, err
+	}
+	codexDir, err := agentHome("CODEX_HOME", ".codex")
+	if err != nil {
+		return u, err
+	}
+	if err := readCodexUsage(&u, codexDir, dir, since); err != nil {
+		return u, err
+	}
+	return u, nil
+}
+
+// agentHome returns the directory an agent keeps its state in: $env, or
+// name in the home directory.
+func agentHome(env, name string) (string, error) {
+	if dir := os.Getenv(env); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return filepath.Join(home, name), nil
+}
+
+var claudeProjectChars = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// readClaudeUsage adds the usage of the Claude Code sessions of dir, which
+// are kept in projects/<dir with every other character than letters and
+// digits replaced by '-'>/<session>.jsonl.
+func readClaudeUsage(u *Usage, claudeDir, dir string, since time.Time) error {
+	files, err := claudeSessions(claudeDir, dir)
+	if err != nil {
+		return err
+	}
+	// A resumed session starts with the messages of the one it resumes,
+	// so messages are counted once across files.
+	seen := make(map[string]bool)
+	for _, path := range files {
+		if fi, err := os.Stat(path); err != nil || fi.ModTime().Before(since) {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to read Claude session: %w", err)
+		}
+		models, err := parseClaudeSession(f, dir, seen)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read Claude session %s: %w", path, err)
+		}
+		if len(models) > 0 {
+			u.Sessions++
+		}
+		for model, m := range models {
+			u.add(model, m)
+		}
+	}
+	return nil
+}
+
+// claudeSessions returns the session logs of the Claude Code sessions of
+// dir.
+func claudeSessions(claudeDir, dir string) ([]string, error) {
+	project := filepath.Join(claudeDir, "projects", claudeProjectChars.ReplaceAllString(dir, "-"))
+	return filepath.Glob(filepath.Join(project, "*.jsonl"))
+}
+
+type claudeEntry struct {
+	Type      string  `json:"type"`
+	Cwd       string  `json:"cwd"`
+	RequestID string  `json:"requestId"`
+	CostUSD   float64 `json:"costUSD"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// parseClaudeSession sums the usage of the assistant messages of a Claude
+// Code session run in dir by model, skipping messages already in seen.
+// A message split over several entries repeats its usage in each.
+func parseClaudeSession(r io.Reader, dir string, seen map[string]bool) (map[string]config.Usage, error) {
+	models := make(map[string]config.Usage)
+	err := eachLine(r, func(line []byte) bool {
+		if !bytes.Contains(line, []byte(`"usage"`)) {
+			return true
+		}
+		var e claudeEntry
+		if json.Unmarshal(line, &e) != nil || e.Type != "assistant" || e.Message.Usage == nil {
+			return true
+		}
+		if e.Cwd != "" && e.Cwd != dir || e.Message.Model == "<synthetic>" {
+			return true
+		}
+		if e.Message.ID != "" {
+			key := e.Message.ID + "/" + e.RequestID
+			if seen[key] {
+				return true
+			}
+			seen[key] = true
+		}
+		m := models[e.Message.Model]
+		m.Add(config.Usage{
+			InputTokens:      e.Message.Usage.InputTokens,
+			OutputTokens:     e.Message.Usage.OutputTokens,
+			CacheReadTokens:  e.Message.Usage.CacheReadInputTokens,
+			CacheWriteTokens: e.Message.Usage.CacheCreationInputTokens,
+			CostUSD:          e.CostUSD,
+		})
+		models[e.Message.Model] = m
+		return true
+	})
+	return models, err
+}
+
+// readCodexUsage adds the usage of the Codex sessions of dir, which are
+// kept in sessions/YYYY/MM/DD/rollout-*.jsonl.
+func readCodexUsage(u *Usage, codexDir, dir string, since time.Time) error {
+	err := walkCodexSessions(codexDir, since, func(path string, _ time.Time) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		model, m, ok, err := parseCodexSession(f, dir)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read Codex session %s: %w", path, err)
+		}
+		if ok {
+			u.Sessions++
+			u.add(model, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read Codex sessions: %w", err)
+	}
+	return nil
+}
+
+// walkCodexSessions calls fn with the path and modification time of each
+// Codex session log modified since a time.
+func walkCodexSessions(codexDir string, since time.Time, fn func(path string, modified time.Time) error) error {
+	root := filepath.Join(codexDir, "sessions")
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasPrefix(d.Name(), "rollout-") || filepath.Ext(path) != ".jsonl" {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil || fi.ModTime().Before(since) {
+			return nil
+		}
+		return fn(path, fi.ModTime())
+	})
+}
+
+type codexEntry struct {
+	Type    string `json:"type"`
+	Payload struct {
+		Type  string `json:"type"`
+		ID    string `json:"id"`
+		Cwd   string `json:"cwd"`
+		Model string `json:"model"`
+		Info  *struct {
+			Total struct {
+				InputTokens       int64 `json:"input_tokens"`
+				CachedInputTokens int64 `json:"cached_input_tokens"`
+				OutputTokens      int64 `json:"output_tokens"`
+			} `json:"total_token_usage"`
+		} `json:"info"`
+	} `json:"payload"`
+}
+
+// parseCodexSession returns the model and total usage of a Codex session,
+// reporting false when it was not run in dir or used no tokens. Codex
+// logs running totals, so the last one counts.
+func parseCodexSession(r io.Reader, dir string) (string, config.Usage, bool, error) {
+	var model string
+	var u config.Usage
+	inDir, first := false, true
+	err := eachLine(r, func(line []byte) bool {
+		var e codexEntry
+		if json.Unmarshal(line, &e) != nil {
+			return true
+		}
+		if first {
+			first = false
+			inDir = e.Type == "session_meta" && filepath.Clean(e.Payload.Cwd) == dir
+		}
+		if !inDir {
+			return false
+		}
+		switch {
+		case e.Type == "turn_context" && e.Payload.Model != "":
+			model = e.Payload.Model
+		case e.Type == "event_msg" && e.Payload.Type == "token_count" && e.Payload.Info != nil:
+			total := e.Payload.Info.Total
+			u = config.Usage{
+				InputTokens:     total.InputTokens - total.CachedInputTokens,
+				OutputTokens:    total.OutputTokens,
+				CacheReadTokens: total.CachedInputTokens,
+			}
+		}
+		return true
+	})
+	return model, u, inDir && u.Tokens() > 0, err
+}
+
+// eachLine calls fn with each line of r, however long, until fn returns
+// false.
+func eachLine(r io.Reader, fn func(line []byte) bool) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 && !fn(line) {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
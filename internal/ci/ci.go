@@ -0,0 +1,164 @@
+// Package ci reports the CI pipelines of branches on GitHub Actions and
+// GitLab CI, through the gh and glab command-line tools.
+package ci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Status is the state of a pipeline or job, normalized across providers.
+type Status string
+
+const (
+	Pending  Status = "pending"
+	Running  Status = "running"
+	Success  Status = "success"
+	Failed   Status = "failed"
+	Canceled Status = "canceled"
+	Skipped  Status = "skipped"
+)
+
+// Done reports whether a pipeline or job in this state has finished.
+func (s Status) Done() bool {
+	return s != Pending && s != Running
+}
+
+// Job is one job of a pipeline.
+type Job struct {
+	Name     string    `json:"name"`
+	Status   Status    `json:"status"`
+	URL      string    `json:"url,omitempty"`
+	Started  time.Time `json:"started,omitzero"`
+	Finished time.Time `json:"finished,omitzero"`
+}
+
+// Duration returns how long the job ran, or has been running.
+func (j Job) Duration() time.Duration {
+	switch {
+	case j.Started.IsZero():
+		return 0
+	case j.Finished.IsZero():
+		return time.Since(j.Started).Round(time.Second)
+	}
+	return j.Finished.Sub(j.Started).Round(time.Second)
+}
+
+// Pipeline is the CI run for a branch's latest pushed commit. On GitHub it
+// combines the runs of every workflow triggered by the commit.
+type Pipeline struct {
+	Provider string `json:"provider"`
+	Status   Status `json:"status"`
+	Commit   string `json:"commit"`
+	URL      string `json:"url"`
+	Jobs     []Job  `json:"jobs"`
+}
+
+// ErrNoPipeline is returned when a branch has no CI pipeline.
+var ErrNoPipeline = errors.New("no CI pipeline found")
+
+// ErrFailed is returned by 'wt ci --wait' when a pipeline does not succeed.
+var ErrFailed = errors.New("CI pipeline failed")
+
+// Provider looks up the CI pipelines of a hosting service.
+type Provider interface {
+	Name() string
+	// Latest returns the pipeline of the latest commit of branch that CI
+	// ran for. dir is a checkout of the repository.
+	Latest(ctx context.Context, dir, branch string) (*Pipeline, error)
+}
+
+// Providers by the name used in repos.<repo>.ci.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// Detect returns the provider named by override, or else the one hosting
+// remoteURL.
+func Detect(remoteURL, override string) (Provider, error) {
+	name := override
+	if name == "" {
+		switch host := remoteHost(remoteURL); {
+		case strings.Contains(host, "github"):
+			name = GitHub
+		case strings.Contains(host, "gitlab"):
+			name = GitLab
+		default:
+			return nil, fmt.Errorf("cannot tell the CI provider of %s; set repos.<repo>.ci to github or gitlab", remoteURL)
+		}
+	}
+	switch name {
+	case GitHub:
+		return gitHub{}, nil
+	case GitLab:
+		return gitLab{}, nil
+	}
+	return nil, fmt.Errorf("unknown CI provider %q (want github or gitlab)", name)
+}
+
+// remoteHost returns the host of a git remote URL, either a URL or an
+// scp-like address such as git@github.com:acme/app.git.
+func remoteHost(url string) string {
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		host, _, _ := strings.Cut(rest, "/")
+		if _, h, ok := strings.Cut(host, "@"); ok {
+			host = h
+		}
+		return strings.ToLower(host)
+	}
+	host, _, _ := strings.Cut(url, ":")
+	if _, h, ok := strings.Cut(host, "@"); ok {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// Combine returns the state of a pipeline made of jobs or runs in states:
+// running until all are done, then failed if any failed.
+func Combine(states []Status) Status {
+	if len(states) == 0 {
+		return Pending
+	}
+	has := make(map[Status]bool)
+	for _, s := range states {
+		has[s] = true
+	}
+	switch {
+	case has[Running]:
+		return Running
+	case has[Pending]:
+		if has[Success] || has[Failed] {
+			return Running
+		}
+		return Pending
+	case has[Failed]:
+		return Failed
+	case has[Canceled]:
+		return Canceled
+	case has[Success]:
+		return Success
+	}
+	return Skipped
+}
+
+// runCLI runs a provider's command-line tool in dir and returns its stdout.
+func runCLI(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s is not installed: %w", name, err)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
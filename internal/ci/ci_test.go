@@ -0,0 +1,129 @@
+package ci
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		url, override, want string
+		wantErr             bool
+	}{
+		{"git@github.com:acme/app.git", "", GitHub, false},
+		{"https://github.com/acme/app.git", "", GitHub, false},
+		{"ssh://git@gitlab.com:2222/acme/app.git", "", GitLab, false},
+		{"https://user@gitlab.acme.io/team/app", "", GitLab, false},
+		{"git@git.acme.io:team/app.git", "gitlab", GitLab, false},
+		{"git@git.acme.io:team/app.git", "", "", true},
+		{"git@github.com:acme/app.git", "jenkins", "", true},
+	}
+	for _, tt := range tests {
+		p, err := Detect(tt.url, tt.override)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Detect(%q, %q) error = %v, wantErr %v", tt.url, tt.override, err, tt.wantErr)
+			continue
+		}
+		if err == nil && p.Name() != tt.want {
+			t.Errorf("Detect(%q, %q) = %s, want %s", tt.url, tt.override, p.Name(), tt.want)
+		}
+	}
+}
+
+func TestCombine(t *testing.T) {
+	tests := []struct {
+		states []Status
+		want   Status
+	}{
+		{nil, Pending},
+		{[]Status{Success, Success}, Success},
+		{[]Status{Success, Skipped}, Success},
+		{[]Status{Skipped, Skipped}, Skipped},
+		{[]Status{Success, Failed}, Failed},
+		{[]Status{Failed, Running}, Running},
+		{[]Status{Success, Pending}, Running},
+		{[]Status{Pending, Pending}, Pending},
+		{[]Status{Success, Canceled}, Canceled},
+		{[]Status{Canceled, Failed}, Failed},
+	}
+	for _, tt := range tests {
+		if got := Combine(tt.states); got != tt.want {
+			t.Errorf("Combine(%v) = %s, want %s", tt.states, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubStatus(t *testing.T) {
+	tests := []struct {
+		status, conclusion string
+		want               Status
+	}{
+		{"queued", "", Pending},
+		{"in_progress", "", Running},
+		{"completed", "success", Success},
+		{"completed", "neutral", Success},
+		{"completed", "failure", Failed},
+		{"completed", "timed_out", Failed},
+		{"completed", "cancelled", Canceled},
+		{"completed", "skipped", Skipped},
+	}
+	for _, tt := range tests {
+		if got := ghStatus(tt.status, tt.conclusion); got != tt.want {
+			t.Errorf("ghStatus(%q, %q) = %s, want %s", tt.status, tt.conclusion, got, tt.want)
+		}
+	}
+}
+
+func TestLatestRuns(t *testing.T) {
+	data := `{"workflow_runs": [
+		{"id": 5, "name": "CI", "workflow_id": 1, "head_sha": "bbb", "status": "in_progress"},
+		{"id": 4, "name": "Lint", "workflow_id": 2, "head_sha": "bbb", "status": "completed", "conclusion": "success"},
+		{"id": 3, "name": "CI", "workflow_id": 1, "head_sha": "bbb", "status": "completed", "conclusion": "failure"},
+		{"id": 2, "name": "CI", "workflow_id": 1, "head_sha": "aaa", "status": "completed", "conclusion": "success"}
+	]}`
+	var resp struct {
+		Runs []ghRun `json:"workflow_runs"`
+	}
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatal(err)
+	}
+	runs := latestRuns(resp.Runs)
+	if len(runs) != 2 || runs[0].ID != 5 || runs[1].ID != 4 {
+		t.Errorf("latestRuns() = %+v, want runs 5 and 4", runs)
+	}
+	if latestRuns(nil) != nil {
+		t.Error("latestRuns(nil) should be nil")
+	}
+}
+
+func TestGitLabPipeline(t *testing.T) {
+	data := `[
+		{"name": "deploy", "stage": "deploy", "status": "manual", "started_at": null, "finished_at": null},
+		{"name": "unit", "stage": "test", "status": "failed", "web_url": "https://gitlab.com/j/2",
+		 "started_at": "2026-01-02T10:00:00Z", "finished_at": "2026-01-02T10:01:30Z"},
+		{"name": "build", "stage": "build", "status": "success"}
+	]`
+	var jobs []glJob
+	if err := json.Unmarshal([]byte(data), &jobs); err != nil {
+		t.Fatal(err)
+	}
+	p := glToPipeline(glPipeline{ID: 7, SHA: "abc", Status: "failed", URL: "https://gitlab.com/p/7"}, jobs)
+	if p.Status != Failed || p.Commit != "abc" || p.Provider != GitLab {
+		t.Errorf("pipeline = %+v", p)
+	}
+	want := []struct {
+		name   string
+		status Status
+	}{{"build / build", Success}, {"test / unit", Failed}, {"deploy / deploy", Skipped}}
+	if len(p.Jobs) != len(want) {
+		t.Fatalf("got %d jobs, want %d", len(p.Jobs), len(want))
+	}
+	for i, w := range want {
+		if p.Jobs[i].Name != w.name || p.Jobs[i].Status != w.status {
+			t.Errorf("job %d = %s %s, want %s %s", i, p.Jobs[i].Name, p.Jobs[i].Status, w.name, w.status)
+		}
+	}
+	if d := p.Jobs[1].Duration().String(); d != "1m30s" {
+		t.Errorf("Duration() = %s, want 1m30s", d)
+	}
+}
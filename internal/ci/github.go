@@ -0,0 +1,120 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// gitHub reads GitHub Actions runs with 'gh api'.
+type gitHub struct{}
+
+func (gitHub) Name() string { return GitHub }
+
+type ghRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	WorkflowID int64  `json:"workflow_id"`
+	HeadSHA    string `json:"head_sha"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	URL        string `json:"html_url"`
+}
+
+type ghJob struct {
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	URL         string    `json:"html_url"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+func (g gitHub) Latest(ctx context.Context, dir, branch string) (*Pipeline, error) {
+	out, err := runCLI(ctx, dir, "gh", "api", "repos/{owner}/{repo}/actions/runs?per_page=30&branch="+url.QueryEscape(branch))
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Runs []ghRun `json:"workflow_runs"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow runs: %w", err)
+	}
+	runs := latestRuns(resp.Runs)
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("%w for branch %s", ErrNoPipeline, branch)
+	}
+
+	p := &Pipeline{Provider: GitHub, Commit: runs[0].HeadSHA, URL: runs[0].URL}
+	states := make([]Status, len(runs))
+	for i, run := range runs {
+		states[i] = ghStatus(run.Status, run.Conclusion)
+		out, err := runCLI(ctx, dir, "gh", "api", fmt.Sprintf("repos/{owner}/{repo}/actions/runs/%d/jobs?per_page=100", run.ID))
+		if err != nil {
+			return nil, err
+		}
+		var jobs struct {
+			Jobs []ghJob `json:"jobs"`
+		}
+		if err := json.Unmarshal(out, &jobs); err != nil {
+			return nil, fmt.Errorf("failed to decode jobs: %w", err)
+		}
+		for _, j := range jobs.Jobs {
+			name := j.Name
+			if len(runs) > 1 {
+				name = run.Name + " / " + name
+			}
+			p.Jobs = append(p.Jobs, Job{
+				Name:     name,
+				Status:   ghStatus(j.Status, j.Conclusion),
+				URL:      j.URL,
+				Started:  j.StartedAt,
+				Finished: j.CompletedAt,
+			})
+		}
+	}
+	p.Status = Combine(states)
+	return p, nil
+}
+
+// latestRuns returns the newest run of each workflow for the commit of the
+// newest run. The API lists runs newest first.
+func latestRuns(runs []ghRun) []ghRun {
+	if len(runs) == 0 {
+		return nil
+	}
+	sha := runs[0].HeadSHA
+	seen := make(map[int64]bool)
+	var latest []ghRun
+	for _, run := range runs {
+		if run.HeadSHA != sha || seen[run.WorkflowID] {
+			continue
+		}
+		seen[run.WorkflowID] = true
+		latest = append(latest, run)
+	}
+	return latest
+}
+
+// ghStatus maps a GitHub run or job status and conclusion to a Status.
+func ghStatus(status, conclusion string) Status {
+	switch status {
+	case "completed":
+	case "in_progress":
+		return Running
+	default: // queued, requested, waiting, pending
+		return Pending
+	}
+	switch conclusion {
+	case "success", "neutral":
+		return Success
+	case "cancelled":
+		return Canceled
+	case "skipped":
+		return Skipped
+	}
+	return Failed // failure, timed_out, action_required, stale, startup_failure
+}
@@ -0,0 +1,89 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// gitLab reads GitLab CI pipelines with 'glab api'.
+type gitLab struct{}
+
+func (gitLab) Name() string { return GitLab }
+
+type glPipeline struct {
+	ID     int64  `json:"id"`
+	SHA    string `json:"sha"`
+	Status string `json:"status"`
+	URL    string `json:"web_url"`
+}
+
+type glJob struct {
+	Name       string     `json:"name"`
+	Stage      string     `json:"stage"`
+	Status     string     `json:"status"`
+	URL        string     `json:"web_url"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+func (g gitLab) Latest(ctx context.Context, dir, branch string) (*Pipeline, error) {
+	out, err := runCLI(ctx, dir, "glab", "api", "projects/:fullpath/pipelines?per_page=1&ref="+url.QueryEscape(branch))
+	if err != nil {
+		return nil, err
+	}
+	var pipelines []glPipeline
+	if err := json.Unmarshal(out, &pipelines); err != nil {
+		return nil, fmt.Errorf("failed to decode pipelines: %w", err)
+	}
+	if len(pipelines) == 0 {
+		return nil, fmt.Errorf("%w for branch %s", ErrNoPipeline, branch)
+	}
+	pl := pipelines[0]
+
+	out, err = runCLI(ctx, dir, "glab", "api", fmt.Sprintf("projects/:fullpath/pipelines/%d/jobs?per_page=100", pl.ID))
+	if err != nil {
+		return nil, err
+	}
+	var jobs []glJob
+	if err := json.Unmarshal(out, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode jobs: %w", err)
+	}
+	return glToPipeline(pl, jobs), nil
+}
+
+func glToPipeline(pl glPipeline, jobs []glJob) *Pipeline {
+	p := &Pipeline{Provider: GitLab, Status: glStatus(pl.Status), Commit: pl.SHA, URL: pl.URL}
+	// The API lists jobs newest first; show them in pipeline order.
+	for i := len(jobs) - 1; i >= 0; i-- {
+		j := jobs[i]
+		job := Job{Name: j.Stage + " / " + j.Name, Status: glStatus(j.Status), URL: j.URL}
+		if j.StartedAt != nil {
+			job.Started = *j.StartedAt
+		}
+		if j.FinishedAt != nil {
+			job.Finished = *j.FinishedAt
+		}
+		p.Jobs = append(p.Jobs, job)
+	}
+	return p
+}
+
+// glStatus maps a GitLab pipeline or job status to a Status.
+func glStatus(status string) Status {
+	switch status {
+	case "running":
+		return Running
+	case "success":
+		return Success
+	case "failed":
+		return Failed
+	case "canceled", "canceling":
+		return Canceled
+	case "skipped", "manual":
+		return Skipped
+	}
+	return Pending // created, waiting_for_resource, preparing, pending, scheduled
+}
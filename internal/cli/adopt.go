@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// --- adopt-branch ---
+func adoptBranchCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "adopt-branch",
+		Category:  "lifecycle",
+		Usage:     "Track an existing branch as a task, without a worktree yet",
+		ArgsUsage: "<branch>",
+		Description: `Register an existing branch of the current repository, and optionally a
+   ticket, as a branch-only task. No worktree is created: 'wt list' shows the
+   task as branch-only, and its worktree is created the first time you run
+   'wt switch' or 'wt agent' on it. Useful for planning a queue of work
+   cheaply.
+
+   A branch that only exists on origin gets a local branch tracking it.
+   Finishing or removing a branch-only task works as for any other task.
+
+   Examples:
+     wt adopt-branch feature/login-redirect
+     wt adopt-branch --ticket jira:PROJ-123 feature/proj-123-rate-limits
+     wt adopt-branch -d "spike: new cache" spike/cache`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "description", Aliases: []string{"d"}, Usage: "Task description (default: the ticket's summary, or the branch name)"},
+			&cli.StringFlag{Name: "ticket", Usage: "Ticket the branch is for, of the connector given by --connector, or a key like jira:PROJ-123"},
+			&cli.StringFlag{Name: "connector", Usage: "Connector of --ticket"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("please provide the branch to adopt")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			repoPath, err := getRepoPath()
+			if err != nil {
+				return err
+			}
+			opts := task.AdoptOptions{RepoPath: repoPath, Branch: c.Args().First(), Description: c.String("description")}
+			opts.Connector, opts.TicketKey = c.String("connector"), c.String("ticket")
+			if opts.Connector == "" && opts.TicketKey != "" {
+				opts.Connector, opts.TicketKey = splitTicketRef(cfg, opts.TicketKey)
+			}
+			if opts.TicketKey != "" {
+				if opts.Connector == "" {
+					return fmt.Errorf("--ticket requires --connector, or a key prefixed with one, e.g. jira:PROJ-123")
+				}
+				if existing, err := cfg.FindTaskByTicket(opts.Connector, opts.TicketKey); err == nil {
+					return fmt.Errorf("%s already has task %s", opts.TicketKey, existing.ID)
+				}
+				if opts.Description == "" {
+					if ticket := lookupTicket(c.Context, cfg, opts.Connector, opts.TicketKey); ticket != nil {
+						opts.Description = ticket.Summary
+					}
+				}
+			}
+
+			t, err := task.NewManager(cfg).Adopt(c.Context, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ Branch adopted: %s\n", t.ID)
+			fmt.Printf("   Branch:   %s\n", t.Branch)
+			fmt.Printf("   Worktree: %s (created on first 'wt switch' or 'wt agent')\n", t.Worktree)
+			return nil
+		},
+	}
+}
+
+// lookupTicket fetches a ticket to fill in what wasn't given, or returns
+// nil with a warning when it can't be fetched.
+func lookupTicket(ctx context.Context, cfg *config.Config, connName, key string) *connector.Ticket {
+	conn, err := buildRegistry(cfg).Lookup(connName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return nil
+	}
+	ticket, err := conn.GetTicket(ctx, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to fetch ticket: %v\n", err)
+		return nil
+	}
+	return ticket
+}
+
+// materialize creates the worktree of a branch-only task about to be used;
+// other tasks are returned as they are.
+func materialize(ctx context.Context, cfg *config.Config, t *config.Task) (*config.Task, error) {
+	if t.State != config.StateBranchOnly {
+		return t, nil
+	}
+	fmt.Fprintf(os.Stderr, "Creating the worktree of %s in %s...\n", t.ID, t.Worktree)
+	return task.NewManager(cfg).Materialize(ctx, t.ID)
+}
@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// scopeRank orders scopes; a token grants its own scope and those below.
+var scopeRank = map[string]int{
+	config.ScopeRead:      1,
+	config.ScopeTeam:      2,
+	config.ScopeLifecycle: 3,
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiAuth guards the endpoints of 'wt serve' with scoped bearer tokens.
+// Without any tokens, only read access is allowed.
+type apiAuth struct {
+	// tokens returns the current tokens, so tokens added or revoked while
+	// the server runs take effect immediately.
+	tokens   func() []config.APIToken
+	readOnly bool
+}
+
+// scope returns the scope granted to the request's bearer token.
+func (a *apiAuth) scope(r *http.Request, tokens []config.APIToken) (string, bool) {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	hash := []byte(hashToken(bearer))
+	for _, tok := range tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(tok.Hash)) == 1 {
+			return tok.Scope, true
+		}
+	}
+	return "", false
+}
+
+// require wraps h so it only runs for requests granted want.
+func (a *apiAuth) require(want string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.readOnly && want != config.ScopeRead {
+			apiError(w, http.StatusForbidden, "server is read-only")
+			return
+		}
+		tokens := a.tokens()
+		if len(tokens) == 0 {
+			if want != config.ScopeRead {
+				apiError(w, http.StatusForbidden, "no API tokens configured; create one with 'wt serve token add'")
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		have, ok := a.scope(r, tokens)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			apiError(w, http.StatusUnauthorized, "missing or unknown API token")
+			return
+		}
+		if scopeRank[have] < scopeRank[want] {
+			apiError(w, http.StatusForbidden, fmt.Sprintf("token scope %q does not allow %s access", have, want))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func apiError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// taskAPI serves tasks over HTTP:
+//
+//	GET  /api/tasks                  list tasks (read)
+//	GET  /api/tasks/{id}             one task (read)
+//	POST /api/tasks/{id}/{action}    finish, remove, lock or unlock (lifecycle)
+type taskAPI struct {
+	// mu serializes changes; the config is reloaded for every request so
+	// the API sees tasks created by other wt processes.
+	mu sync.Mutex
+}
+
+func (api *taskAPI) register(mux *http.ServeMux, auth *apiAuth) {
+	mux.Handle("GET /api/tasks", auth.require(config.ScopeRead, http.HandlerFunc(api.list)))
+	mux.Handle("GET /api/tasks/{id}", auth.require(config.ScopeRead, http.HandlerFunc(api.get)))
+	mux.Handle("POST /api/tasks/{id}/{action}", auth.require(config.ScopeLifecycle, http.HandlerFunc(api.action)))
+}
+
+func (api *taskAPI) list(w http.ResponseWriter, r *http.Request) {
+	cfg, err := loadConfig()
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg.Tasks)
+}
+
+func (api *taskAPI) get(w http.ResponseWriter, r *http.Request) {
+	cfg, err := loadConfig()
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	t, err := cfg.FindTask(r.PathValue("id"))
+	if err != nil {
+		apiError(w, apiStatus(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (api *taskAPI) action(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	cfg, err := loadConfig()
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := r.PathValue("id")
+	mgr := task.NewManager(cfg)
+	mgr.Force = r.URL.Query().Get("force") == "true"
+	var t *config.Task
+	switch r.PathValue("action") {
+	case "finish":
+		t, err = mgr.Finish(r.Context(), id)
+	case "remove":
+		t, err = mgr.Remove(r.Context(), id)
+	case "lock":
+		if err = cfg.LockTask(id, "api", r.URL.Query().Get("reason")); err == nil {
+			t, err = cfg.FindTask(id)
+		}
+	case "unlock":
+		if err = cfg.UnlockTask(id); err == nil {
+			t, err = cfg.FindTask(id)
+		}
+	default:
+		apiError(w, http.StatusNotFound, fmt.Sprintf("unknown action %q (want finish, remove, lock or unlock)", r.PathValue("action")))
+		return
+	}
+	if err != nil {
+		apiError(w, apiStatus(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// apiStatus maps an error to an HTTP status using the CLI's exit codes.
+func apiStatus(err error) int {
+	switch ExitCode(err) {
+	case ExitTaskNotFound:
+		return http.StatusNotFound
+	case ExitDirty, ExitLocked:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// --- serve token ---
+func serveTokenCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "token",
+		Usage: "Manage API tokens for 'wt serve'",
+		Description: `Tokens authenticate clients of 'wt serve' with a scope:
+     read       list tasks and team state (dashboards)
+     team       also publish team state ('wt team init --url')
+     lifecycle  also finish, remove, lock and unlock tasks
+
+   Without any tokens, the server only allows read access.
+   Tokens are shown once when created; only their hash is stored.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Create a token",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "scope", Value: config.ScopeRead, Usage: "read, team or lifecycle"},
+				},
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						return fmt.Errorf("please provide a token name")
+					}
+					scope := c.String("scope")
+					if scopeRank[scope] == 0 {
+						return fmt.Errorf("unknown scope %q (want read, team or lifecycle)", scope)
+					}
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					b := make([]byte, 24)
+					if _, err := rand.Read(b); err != nil {
+						return err
+					}
+					token := "wt_" + hex.EncodeToString(b)
+					if err := cfg.AddAPIToken(config.APIToken{Name: name, Hash: hashToken(token), Scope: scope, Created: time.Now()}); err != nil {
+						return err
+					}
+					fmt.Fprintf(os.Stderr, "Created %s token %q. Copy it now; it won't be shown again:\n", scope, name)
+					fmt.Println(token)
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List tokens",
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					if len(cfg.APITokens) == 0 {
+						fmt.Println("No API tokens.")
+						return nil
+					}
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "NAME\tSCOPE\tCREATED")
+					for _, tok := range cfg.APITokens {
+						fmt.Fprintf(w, "%s\t%s\t%s\n", tok.Name, tok.Scope, tok.Created.Format("2006-01-02"))
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:      "revoke",
+				Usage:     "Delete a token",
+				ArgsUsage: "<name>",
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					if err := cfg.RemoveAPIToken(c.Args().First()); err != nil {
+						return err
+					}
+					fmt.Printf("Revoked %s\n", c.Args().First())
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestAPIAuth(t *testing.T) {
+	tokens := []config.APIToken{
+		{Name: "grafana", Hash: hashToken("r"), Scope: config.ScopeRead},
+		{Name: "ann", Hash: hashToken("t"), Scope: config.ScopeTeam},
+		{Name: "ops", Hash: hashToken("l"), Scope: config.ScopeLifecycle},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name     string
+		tokens   []config.APIToken
+		readOnly bool
+		want     string
+		bearer   string
+		status   int
+	}{
+		{"no tokens, read", nil, false, config.ScopeRead, "", http.StatusOK},
+		{"no tokens, lifecycle", nil, false, config.ScopeLifecycle, "", http.StatusForbidden},
+		{"missing token", tokens, false, config.ScopeRead, "", http.StatusUnauthorized},
+		{"unknown token", tokens, false, config.ScopeRead, "x", http.StatusUnauthorized},
+		{"read token reads", tokens, false, config.ScopeRead, "r", http.StatusOK},
+		{"read token can't push team state", tokens, false, config.ScopeTeam, "r", http.StatusForbidden},
+		{"team token pushes", tokens, false, config.ScopeTeam, "t", http.StatusOK},
+		{"team token can't finish", tokens, false, config.ScopeLifecycle, "t", http.StatusForbidden},
+		{"lifecycle token finishes", tokens, false, config.ScopeLifecycle, "l", http.StatusOK},
+		{"read-only server", tokens, true, config.ScopeLifecycle, "l", http.StatusForbidden},
+		{"read-only server reads", tokens, true, config.ScopeRead, "r", http.StatusOK},
+	}
+	for _, tt := range tests {
+		auth := &apiAuth{tokens: func() []config.APIToken { return tt.tokens }, readOnly: tt.readOnly}
+		req := httptest.NewRequest("GET", "/api/tasks", nil)
+		if tt.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.bearer)
+		}
+		rec := httptest.NewRecorder()
+		auth.require(tt.want, ok).ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+}
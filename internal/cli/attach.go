@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/urfave/cli/v2"
+)
+
+// attachmentsDir is where ticket attachments are saved inside a worktree.
+const attachmentsDir = ".wt/attachments"
+
+// --- attach ---
+func attachCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "attach",
+		Category: "agent",
+		Usage:    "Work with ticket attachments",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "pull",
+				Usage:     "Download a task's ticket attachments into its worktree",
+				ArgsUsage: "[task-id]",
+				Description: `Download the attachments of a task's ticket (screenshots, specs, logs)
+   into .wt/attachments/ in the task's worktree, so agents and humans have them
+   locally. The folder is ignored by git. Files already downloaded are skipped.
+   Without a task ID, uses the task of the current worktree.
+
+   Examples:
+     wt attach pull
+     wt attach pull wt-a1b2c3d4`,
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					t, err := taskFromArgOrCwd(c, cfg)
+					if err != nil {
+						return err
+					}
+					if t.TicketKey == "" {
+						return fmt.Errorf("task %s was not started from a ticket", t.ID)
+					}
+					reg := buildRegistry(cfg)
+					conn, err := reg.Lookup(t.Connector)
+					if err != nil {
+						return err
+					}
+					d, ok := conn.(connector.AttachmentDownloader)
+					if !ok {
+						return fmt.Errorf("connector %q does not support attachments", t.Connector)
+					}
+					ticket, err := conn.GetTicket(c.Context, t.TicketKey)
+					if err != nil {
+						return fmt.Errorf("failed to fetch ticket: %w", err)
+					}
+					if len(ticket.Attachments) == 0 {
+						fmt.Printf("%s has no attachments.\n", t.TicketKey)
+						return nil
+					}
+
+					dir := filepath.Join(t.Worktree, attachmentsDir)
+					results := connector.SaveAttachments(c.Context, d, ticket.Attachments, dir)
+					if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0o644); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					}
+					failed := 0
+					for _, res := range results {
+						rel, _ := filepath.Rel(t.Worktree, res.Path)
+						switch {
+						case res.Err != nil:
+							fmt.Fprintf(os.Stderr, "❌ %s: %v\n", res.Attachment.Filename, res.Err)
+							failed++
+						case res.Skipped:
+							fmt.Printf("   %s (up to date)\n", rel)
+						default:
+							fmt.Printf("✅ %s\n", rel)
+						}
+					}
+					if failed > 0 {
+						return fmt.Errorf("%d of %d attachments failed to download", failed, len(results))
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// taskFromArgOrCwd returns the task named by the first argument, either a
+// task ID or a path inside a worktree, or the current task (see
+// currentTask) when no argument is given.
+func taskFromArgOrCwd(c *cli.Context, cfg *config.Config) (*config.Task, error) {
+	if arg := c.Args().First(); arg != "" {
+		return taskFromArg(cfg, arg)
+	}
+	t, err := currentTask(cfg)
+	if err != nil {
+		if os.Getenv("WT_TASK_ID") != "" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("not inside a wt-managed worktree; pass a task ID")
+	}
+	return t, nil
+}
+
+// currentTask returns the task whose worktree contains the working
+// directory or, outside any worktree, the task named by WT_TASK_ID, which
+// agents launched by wt inherit.
+func currentTask(cfg *config.Config) (*config.Task, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	t, err := cfg.FindTaskByWorktree(cwd)
+	if err == nil {
+		return t, nil
+	}
+	if id := os.Getenv("WT_TASK_ID"); id != "" {
+		t, err := cfg.FindTask(id)
+		if err != nil {
+			return nil, fmt.Errorf("WT_TASK_ID: %w", err)
+		}
+		return t, nil
+	}
+	return nil, err
+}
+
+// taskFromArg returns the task named by arg, either a task ID or a path
+// inside a worktree.
+func taskFromArg(cfg *config.Config, arg string) (*config.Task, error) {
+	t, err := cfg.FindTask(arg)
+	if err != nil {
+		if _, statErr := os.Stat(arg); statErr == nil {
+			return cfg.FindTaskByWorktree(arg)
+		}
+	}
+	return t, err
+}
+
+// insideWorktree reports whether the current directory is in t's worktree.
+func insideWorktree(cfg *config.Config, t *config.Task) bool {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	here, err := cfg.FindTaskByWorktree(cwd)
+	return err == nil && here.ID == t.ID
+}
+
+// formatSize formats a byte count for display.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
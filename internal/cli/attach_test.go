@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestCurrentTask(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Tasks: []config.Task{
+		{ID: "wt-here", Worktree: dir},
+		{ID: "wt-agent", Worktree: "/elsewhere"},
+	}}
+	t.Chdir(dir)
+	t.Setenv("WT_TASK_ID", "wt-agent")
+	if got, err := currentTask(cfg); err != nil || got.ID != "wt-here" {
+		t.Errorf("currentTask in a worktree = %v, %v; want wt-here", got, err)
+	}
+
+	t.Chdir(t.TempDir())
+	if got, err := currentTask(cfg); err != nil || got.ID != "wt-agent" {
+		t.Errorf("currentTask with WT_TASK_ID = %v, %v; want wt-agent", got, err)
+	}
+	t.Setenv("WT_TASK_ID", "wt-gone")
+	if _, err := currentTask(cfg); !errors.Is(err, config.ErrTaskNotFound) {
+		t.Errorf("currentTask with a finished WT_TASK_ID: got %v, want ErrTaskNotFound", err)
+	}
+	t.Setenv("WT_TASK_ID", "")
+	if _, err := currentTask(cfg); err == nil {
+		t.Errorf("currentTask outside any worktree succeeded")
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// batchTask is one task of a 'wt start --from-file' file. A plain string
+// is a description.
+type batchTask struct {
+	Description string `yaml:"description"`
+	Notes       string `yaml:"notes"`
+	Connector   string `yaml:"connector"`
+	Ticket      string `yaml:"ticket"`
+	Repo        string `yaml:"repo"`
+}
+
+func (b *batchTask) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&b.Description)
+	}
+	type plain batchTask
+	return node.Decode((*plain)(b))
+}
+
+// parseBatch parses a list of tasks in YAML or JSON.
+func parseBatch(data []byte) ([]batchTask, error) {
+	var tasks []batchTask
+	if err := yaml.Unmarshal(data, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to parse task list: %w", err)
+	}
+	for i, t := range tasks {
+		if t.Ticket != "" && t.Connector == "" {
+			return nil, fmt.Errorf("task %d: ticket %s needs a connector", i+1, t.Ticket)
+		}
+		if t.Ticket == "" && strings.TrimSpace(t.Description) == "" {
+			return nil, fmt.Errorf("task %d: needs a description or a ticket", i+1)
+		}
+	}
+	return tasks, nil
+}
+
+// startBatch starts every task listed in path ("-" for stdin). A task that
+// fails to start is reported and skipped.
+func startBatch(c *cli.Context, cfg *config.Config, path string) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read task list: %w", err)
+	}
+	tasks, err := parseBatch(data)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("no tasks in %s", path)
+	}
+
+	mgr := task.NewManager(cfg)
+	checked := make(map[string]bool)
+	failed := 0
+	for i, bt := range tasks {
+		t, err := startBatchTask(c, mgr, bt, checked)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: task %d: %v\n", i+1, err)
+			failed++
+			continue
+		}
+		if c.Bool("background") {
+			if err := task.SpawnCheckout(t.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: task %d: %v\n", i+1, err)
+				if err := mgr.Abort(c.Context, t.ID); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+				}
+				failed++
+				continue
+			}
+		}
+		fmt.Printf("✅ %s  %s  %s\n", t.ID, t.Branch, t.Worktree)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to start %d of %d task(s)", failed, len(tasks))
+	}
+	return nil
+}
+
+// startBatchTask starts one task of a batch. checked records the
+// repositories whose checkout already passed checkStartPoint.
+func startBatchTask(c *cli.Context, mgr *task.Manager, bt batchTask, checked map[string]bool) (*config.Task, error) {
+	dir := bt.Repo
+	if dir == "" {
+		dir = "."
+	} else if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		dir = filepath.Join(home, rest)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repoPath, err := repoRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !checked[repoPath] {
+		if err := checkStartPoint(c.Context, mgr.Config, repoPath, c.Bool("force")); err != nil {
+			return nil, err
+		}
+		checked[repoPath] = true
+	}
+
+	opts := task.StartOptions{
+		RepoPath:    repoPath,
+		Description: strings.TrimSpace(bt.Description),
+		Notes:       strings.TrimSpace(bt.Notes),
+		Background:  c.Bool("background"),
+	}
+	if bt.Ticket != "" {
+		if c.Bool("another") {
+			opts.Variant = anotherVariant(mgr.Config, bt.Connector, bt.Ticket)
+		} else if existing, err := mgr.Config.FindTaskByTicket(bt.Connector, bt.Ticket); err == nil {
+			return nil, fmt.Errorf("%s already has task %s; pass --another to start a second one", existing.TicketKey, existing.ID)
+		}
+		description := opts.Description
+		if _, err := fetchStartTicket(c.Context, mgr.Config, bt.Connector, bt.Ticket, &opts); err != nil {
+			return nil, err
+		}
+		if description != "" {
+			opts.Description = description
+		}
+		if notes := strings.TrimSpace(bt.Notes); notes != "" {
+			opts.Notes = notes
+		}
+	}
+	return mgr.Start(c.Context, opts)
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []batchTask
+		wantErr bool
+	}{
+		{
+			name: "yaml",
+			data: "- rename Foo to Bar in api\n- description: rename Foo in web\n  notes: keep the old JSON field\n  repo: ~/src/web\n- connector: jira\n  ticket: PROJ-1\n",
+			want: []batchTask{
+				{Description: "rename Foo to Bar in api"},
+				{Description: "rename Foo in web", Notes: "keep the old JSON field", Repo: "~/src/web"},
+				{Connector: "jira", Ticket: "PROJ-1"},
+			},
+		},
+		{
+			name: "json",
+			data: `[{"description": "split parser", "notes": "no API change"}, "update docs"]`,
+			want: []batchTask{
+				{Description: "split parser", Notes: "no API change"},
+				{Description: "update docs"},
+			},
+		},
+		{name: "ticket without connector", data: "- ticket: PROJ-1\n", wantErr: true},
+		{name: "empty description", data: "- notes: only notes\n", wantErr: true},
+		{name: "not a list", data: "description: one task\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseBatch([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- checkpoint ---
+func checkpointCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "checkpoint",
+		Category:  "agent",
+		Usage:     "Save the files of a task's worktree to roll back to later",
+		ArgsUsage: "[task-id|path]",
+		Description: `Save a checkpoint of the files in a task's worktree, including untracked
+   files, that 'wt rollback' can bring the worktree back to. Checkpoints
+   work whichever agent (or person) made the changes, and form a stack:
+   rolling back to one drops the checkpoints saved after it.
+
+   Checkpoints are commits kept under refs/wt/checkpoints/<task-id>/, not
+   on the task's branch, and are deleted when the task is finished or
+   removed. 'wt watch-agent' saves them while an agent runs.
+
+   Examples:
+     wt checkpoint -m "tests pass"
+     wt checkpoint wt-abc123
+     wt checkpoint --list wt-abc123`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "message",
+				Aliases: []string{"m"},
+				Value:   "checkpoint",
+				Usage:   "Describe the checkpoint",
+			},
+			&cli.BoolFlag{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "List the task's checkpoints instead of saving one",
+			},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			if c.Bool("list") {
+				f, err := formatter(c)
+				if err != nil {
+					return err
+				}
+				checkpoints, err := worktree.Checkpoints(c.Context, t.Worktree, t.ID)
+				if err != nil {
+					return err
+				}
+				return f.Write(os.Stdout, checkpoints, func(w io.Writer) error {
+					return printCheckpoints(w, t, checkpoints)
+				})
+			}
+
+			cp, saved, err := worktree.SaveCheckpoint(c.Context, t.Worktree, t.ID, c.String("message"))
+			if err != nil {
+				return fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+			if !saved {
+				fmt.Printf("No changes since checkpoint %d of %s\n", cp.N, t.ID)
+				return nil
+			}
+			fmt.Printf("📍 Checkpoint %d of %s saved (%s)\n", cp.N, t.ID, shortHash(cp.Commit))
+			return nil
+		},
+	}
+}
+
+// --- rollback ---
+func rollbackCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "rollback",
+		Category:  "agent",
+		Usage:     "Bring a task's worktree back to a checkpoint",
+		ArgsUsage: "<task-id|path> [checkpoint]",
+		Description: `Bring a task's worktree back to a checkpoint saved by 'wt checkpoint' or
+   'wt watch-agent': its files become those saved, files created since are
+   deleted, and the branch goes back to the commit it was on, undoing
+   commits made since. Ignored files are left alone. The checkpoints after
+   it are dropped.
+
+   Without a checkpoint number, the latest checkpoint is restored, or the
+   one before it when nothing changed since the latest, so running
+   'wt rollback' again steps further back.
+
+   Changes made since the checkpoint are discarded. Rolling back a locked
+   task, or one an agent is running in, needs --force.
+
+   Examples:
+     wt rollback wt-abc123
+     wt rollback wt-abc123 2`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "force",
+				Aliases: []string{"f"},
+				Usage:   "Roll back even if the task is locked or an agent is running in it",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("please provide a task ID (see 'wt list')")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArg(cfg, c.Args().First())
+			if err != nil {
+				return err
+			}
+			if !c.Bool("force") {
+				if t.Lock != nil {
+					return fmt.Errorf("%w: %s is locked by %s; run 'wt unlock %s' or use --force", config.ErrTaskLocked, t.ID, t.Lock, t.ID)
+				}
+				if agent.IsAlive(t.AgentPID, t.AgentStarted) && !agent.IsAncestor(t.AgentPID) {
+					return fmt.Errorf("%w: agent %s is running in %s (pid %d); exit it or use --force", config.ErrTaskLocked, t.Agent, t.Worktree, t.AgentPID)
+				}
+			}
+
+			checkpoints, err := worktree.Checkpoints(c.Context, t.Worktree, t.ID)
+			if err != nil {
+				return err
+			}
+			if len(checkpoints) == 0 {
+				return fmt.Errorf("%s has no checkpoints; save one with 'wt checkpoint'", t.ID)
+			}
+			cp, err := rollbackTarget(c, t, checkpoints)
+			if err != nil {
+				return err
+			}
+			if err := worktree.RestoreCheckpoint(c.Context, t.Worktree, cp); err != nil {
+				return err
+			}
+			if err := worktree.DeleteCheckpoints(c.Context, t.Worktree, t.ID, cp.N); err != nil {
+				return err
+			}
+			fmt.Printf("⏪ Rolled %s back to checkpoint %d (%s)\n", t.ID, cp.N, cp.Message)
+			fmt.Printf("   Worktree: %s\n", t.Worktree)
+			return nil
+		},
+	}
+}
+
+// rollbackTarget returns the checkpoint named by the second argument, or
+// the one 'wt rollback' goes back to without it.
+func rollbackTarget(c *cli.Context, t *config.Task, checkpoints []worktree.Checkpoint) (worktree.Checkpoint, error) {
+	if arg := c.Args().Get(1); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return worktree.Checkpoint{}, fmt.Errorf("invalid checkpoint %q; see 'wt checkpoint --list %s'", arg, t.ID)
+		}
+		for _, cp := range checkpoints {
+			if cp.N == n {
+				return cp, nil
+			}
+		}
+		return worktree.Checkpoint{}, fmt.Errorf("%s has no checkpoint %d; see 'wt checkpoint --list %s'", t.ID, n, t.ID)
+	}
+	last := checkpoints[len(checkpoints)-1]
+	changed, err := worktree.ChangedSince(c.Context, t.Worktree, last)
+	if err != nil {
+		return worktree.Checkpoint{}, err
+	}
+	if changed {
+		return last, nil
+	}
+	if len(checkpoints) == 1 {
+		return worktree.Checkpoint{}, fmt.Errorf("nothing changed since %s's only checkpoint", t.ID)
+	}
+	return checkpoints[len(checkpoints)-2], nil
+}
+
+func printCheckpoints(out io.Writer, t *config.Task, checkpoints []worktree.Checkpoint) error {
+	if len(checkpoints) == 0 {
+		fmt.Fprintf(out, "No checkpoints of %s. Save one with 'wt checkpoint'.\n", t.ID)
+		return nil
+	}
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, "#\tCOMMIT\tSAVED\tMESSAGE")
+	for _, cp := range checkpoints {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", cp.N, shortHash(cp.Commit), cp.Time.Format("2006-01-02 15:04"), output.Truncate(cp.Message, 50))
+	}
+	return w.Flush()
+}
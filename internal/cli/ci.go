@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/ci"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- ci ---
+func ciCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "ci",
+		Category:  "lifecycle",
+		Usage:     "Show the CI pipeline of a task's branch",
+		ArgsUsage: "[task-id|path]",
+		Description: `Show the latest GitHub Actions or GitLab CI pipeline of the task's branch
+   and the result of each of its jobs. GitHub is queried with the gh CLI
+   and GitLab with glab, so either must be installed and logged in.
+
+   The provider is picked from the host of the origin remote; set it for
+   self-hosted instances in ~/.wt/config.yaml:
+
+     repos:
+       api:
+         ci: gitlab
+
+   --wait polls until the pipeline finishes and exits with code 12 when
+   it did not succeed, which is handy before merging.
+
+   Examples:
+     wt ci
+     wt ci wt-a1b2c3d4
+     wt ci --wait --timeout 30m`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "wait",
+				Usage: "Wait for the pipeline to finish and fail unless it succeeds",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Value: 15 * time.Second,
+				Usage: "How often to poll with --wait",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Give up waiting after this long (default: no limit)",
+			},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			if c.Duration("interval") <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			p, err := taskPipeline(c, cfg, t)
+			if err != nil {
+				return err
+			}
+			if err := f.Write(os.Stdout, p, p.printTable); err != nil {
+				return err
+			}
+			if c.Bool("wait") && p.Status != ci.Success {
+				return fmt.Errorf("%w: %s is %s", ci.ErrFailed, t.Branch, p.Status)
+			}
+			return nil
+		},
+	}
+}
+
+// pipeline is a task's CI pipeline as shown by 'wt ci'.
+type pipeline struct {
+	Task   string `json:"task"`
+	Branch string `json:"branch"`
+	*ci.Pipeline
+}
+
+// taskPipeline looks up the latest pipeline of a task's branch, polling
+// until it finishes with --wait.
+func taskPipeline(c *cli.Context, cfg *config.Config, t *config.Task) (*pipeline, error) {
+	ctx := c.Context
+	remote, err := worktree.RemoteURL(ctx, t.RepoPath, "origin")
+	if err != nil {
+		return nil, err
+	}
+	provider, err := ci.Detect(remote, cfg.Repo(t.RepoPath, t.Worktree).CI)
+	if err != nil {
+		return nil, err
+	}
+	dir := t.Worktree
+	if _, err := os.Stat(dir); err != nil {
+		dir = t.RepoPath
+	}
+	if d := c.Duration("timeout"); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	warned := false
+	var last ci.Status
+	for {
+		p, err := provider.Latest(ctx, dir, t.Branch)
+		if err != nil {
+			return nil, err
+		}
+		if !warned {
+			warned = true
+			if commit, err := worktree.LastCommit(ctx, dir); err == nil && dir == t.Worktree && !strings.HasPrefix(p.Commit, commit.Hash) {
+				fmt.Fprintf(os.Stderr, "warning: the pipeline is for %s, not HEAD %s; push to run CI on the latest commit\n", shortHash(p.Commit), commit.Hash)
+			}
+		}
+		if !c.Bool("wait") || p.Status.Done() {
+			return &pipeline{Task: t.ID, Branch: t.Branch, Pipeline: p}, nil
+		}
+		if p.Status != last {
+			last = p.Status
+			fmt.Fprintf(os.Stderr, "⏳ %s: pipeline %s (%s)\n", t.ID, p.Status, jobProgress(p.Jobs))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for CI of %s: %w", t.Branch, ctx.Err())
+		case <-time.After(c.Duration("interval")):
+		}
+	}
+}
+
+// jobProgress summarizes how many jobs have finished, e.g. "3/7 jobs done".
+func jobProgress(jobs []ci.Job) string {
+	done := 0
+	for _, j := range jobs {
+		if j.Status.Done() {
+			done++
+		}
+	}
+	return fmt.Sprintf("%d/%d jobs done", done, len(jobs))
+}
+
+func shortHash(hash string) string {
+	return hash[:min(7, len(hash))]
+}
+
+var ciMarks = map[ci.Status]string{
+	ci.Pending:  "⏸",
+	ci.Running:  "⏳",
+	ci.Success:  "✅",
+	ci.Failed:   "❌",
+	ci.Canceled: "⛔",
+	ci.Skipped:  "-",
+}
+
+func (p *pipeline) printTable(out io.Writer) error {
+	fmt.Fprintf(out, "%s %s: %s pipeline %s for %s\n", ciMarks[p.Status], p.Task, p.Provider, p.Status, shortHash(p.Commit))
+	fmt.Fprintf(out, "   %s\n", p.URL)
+	if len(p.Jobs) == 0 {
+		return nil
+	}
+	fmt.Fprintln(out)
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, "JOB\tSTATUS\tDURATION\tURL")
+	for _, j := range p.Jobs {
+		duration := "-"
+		if d := j.Duration(); d > 0 {
+			duration = d.String()
+		}
+		fmt.Fprintf(w, "%s\t%s %s\t%s\t%s\n", output.Truncate(j.Name, 50), ciMarks[j.Status], j.Status, duration, j.URL)
+	}
+	return w.Flush()
+}
@@ -1,20 +1,38 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
-	"text/tabwriter"
+	"syscall"
+	"time"
 
 	"github.com/bakerweb/wt/internal/agent"
 	"github.com/bakerweb/wt/internal/config"
 	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/connector/basecamp"
 	"github.com/bakerweb/wt/internal/connector/clickup"
+	"github.com/bakerweb/wt/internal/connector/generic"
+	"github.com/bakerweb/wt/internal/connector/gitlab"
+	"github.com/bakerweb/wt/internal/connector/inbox"
+	"github.com/bakerweb/wt/internal/connector/incident"
 	"github.com/bakerweb/wt/internal/connector/jira"
 	"github.com/bakerweb/wt/internal/connector/monday"
+	"github.com/bakerweb/wt/internal/connector/plugin"
+	"github.com/bakerweb/wt/internal/connector/sentry"
+	"github.com/bakerweb/wt/internal/output"
 	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/terminal"
 	"github.com/bakerweb/wt/internal/worktree"
 	"github.com/urfave/cli/v2"
 )
@@ -68,32 +86,128 @@ COPYRIGHT:
 
 func Run(args []string) error {
 	app := &cli.App{
-		Name:                 "wt",
-		Usage:                "Git worktree manager driven by tasks",
-		Version:              Version,
+		Name:                  "wt",
+		Usage:                 "Git worktree manager driven by tasks",
+		Version:               Version,
 		CustomAppHelpTemplate: appHelpTemplate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "json", EnvVars: []string{"WT_JSON"}, Usage: "Print results as JSON and errors as JSON on stderr"},
+			&cli.StringFlag{Name: "worktrees-base", Usage: "Override worktrees_base for this invocation"},
+			&cli.StringFlag{Name: "branch-prefix", Usage: "Override branch_prefix for this invocation"},
+			&cli.StringFlag{Name: "default-branch", Usage: "Override default_branch for this invocation"},
+			&cli.StringFlag{Name: "host", EnvVars: []string{"WT_HOST"}, Usage: "Start tasks in a repository on another machine, over ssh (host or host:/path/to/repo)"},
+			&cli.BoolFlag{Name: "simulate", EnvVars: []string{"WT_SIMULATE"}, Usage: "Work on simulated repositories and tasks, without changing real ones"},
+		},
+		Before: func(c *cli.Context) error {
+			baseOverrides = config.BaseSettings{
+				BranchPrefix:  c.String("branch-prefix"),
+				DefaultBranch: c.String("default-branch"),
+			}
+			if c.IsSet("worktrees-base") {
+				dir, err := configDir(c.String("worktrees-base"))
+				if err != nil {
+					return fmt.Errorf("invalid value for --worktrees-base: %w", err)
+				}
+				baseOverrides.WorktreesBase = dir
+			}
+			if c.Bool("simulate") {
+				return startSimulation()
+			}
+			return nil
+		},
 		Commands: []*cli.Command{
 			startCmd(),
+			adoptBranchCmd(),
 			agentCmd(),
+			watchAgentCmd(),
+			checkpointCmd(),
+			rollbackCmd(),
+			envCmd(),
+			upCmd(),
+			downCmd(),
+			experimentCmd(),
+			compareCmd(),
+			statsCmd(),
 			listCmd(),
+			testCmd(),
+			ciCmd(),
 			finishCmd(),
+			archiveCmd(),
 			removeCmd(),
+			milestoneCmd(),
+			queueCmd(),
+			moveCmd(),
 			switchCmd(),
+			lastCmd(),
+			whichCmd(),
+			shellInitCmd(),
+			promptCmd(),
 			statusCmd(),
+			graphCmd(),
+			showCmd(),
+			attachCmd(),
+			lockCmd(),
+			unlockCmd(),
 			connectCmd(),
+			teamCmd(),
 			syncCmd(),
+			inboxCmd(),
+			triageCmd(),
 			configCmd(),
 			pruneCmd(),
+			gcCmd(),
+			snapshotCmd(),
+			repairCmd(),
+			checkoutWorkerCmd(),
+			uploadWorkerCmd(),
+			defaultsWorkerCmd(),
+			metricsCmd(),
+			serveCmd(),
 		},
 	}
-	return app.Run(args)
+	if path, ok := findExternal(app, args); ok {
+		return runExternal(path, args[2:])
+	}
+
+	// Ctrl-C or SIGTERM cancels the context, which interrupts any running
+	// git command and lets the command undo what it left half done. A
+	// second Ctrl-C quits right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	start := time.Now()
+	stamp, _ := config.Stamp()
+	err := app.RunContext(ctx, args)
+	if simulation != nil {
+		saveSimulation()
+	} else if err == nil {
+		pushTeamStateIfChanged(stamp)
+	}
+	recordUsage(app, args, start, err)
+	refreshDefaults(args)
+	return err
 }
 
+// baseOverrides holds the settings given as global flags, which
+// loadConfig applies on top of the config file.
+var baseOverrides config.BaseSettings
+
 func loadConfig() (*config.Config, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
+	cfg.Override(baseOverrides)
+	if simulation != nil && cfg.DefaultBranch != "" {
+		simulationBranch = cfg.DefaultBranch
+	}
+	if err := worktree.SetBackend(cfg.GitBackend); err != nil {
+		return nil, err
+	}
+	worktree.SetTimeout(cfg.GitTimeout)
 	return cfg, nil
 }
 
@@ -102,15 +216,23 @@ func getRepoPath() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("cannot determine current directory: %w", err)
 	}
+	return repoRoot(cwd)
+}
+
+// repoRoot returns the repository containing dir.
+func repoRoot(start string) (string, error) {
+	if simulation != nil {
+		return simulatedRepoRoot(start), nil
+	}
 	// Walk up to find .git
-	dir := cwd
+	dir := start
 	for {
 		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
 			return dir, nil
 		}
 		parent := filepath.Dir(dir)
 		if parent == dir {
-			return "", fmt.Errorf("not inside a git repository (searched from %s)", cwd)
+			return "", fmt.Errorf("not inside a git repository (searched from %s)", start)
 		}
 		dir = parent
 	}
@@ -118,14 +240,126 @@ func getRepoPath() (string, error) {
 
 func buildRegistry(cfg *config.Config) *connector.Registry {
 	reg := connector.NewRegistry()
+	// Plugins are registered first so built-in connectors take precedence.
+	for name, path := range plugin.Discover() {
+		reg.Register(plugin.New(name, path, cfg.Connectors[name]))
+	}
+	for name, cc := range cfg.Connectors {
+		if cc.Type == config.ConnectorGeneric {
+			reg.Register(generic.New(name, cc))
+		}
+	}
 	if cc, ok := cfg.Connectors["jira"]; ok {
-		reg.Register(jira.New(cc.URL, cc.Email, cc.APIToken))
+		client := jira.New(cc.URL, cc.Email, cc.APIToken)
+		client.APIVersion = cc.APIVersion
+		client.Project = cc.Project
+		reg.Register(client)
+	}
+	if cc, ok := cfg.Connectors["basecamp"]; ok {
+		reg.Register(newBasecamp(cfg, cc))
+	}
+	if cc, ok := cfg.Connectors["sentry"]; ok {
+		reg.Register(sentry.New(cc.URL, cc.APIToken, cc.Organization))
+	}
+	if cc, ok := cfg.Connectors["gitlab"]; ok {
+		client := gitlab.New(cc.URL, cc.APIToken)
+		client.Project = cc.Project
+		reg.Register(client)
+	}
+	if cc, ok := cfg.Connectors["pagerduty"]; ok {
+		reg.Register(incident.NewPagerDuty(cc.URL, cc.APIToken, cc.Email))
+	}
+	if cc, ok := cfg.Connectors["opsgenie"]; ok {
+		reg.Register(incident.NewOpsgenie(cc.URL, cc.APIToken))
+	}
+	if cc, ok := cfg.Connectors["inbox"]; ok {
+		client, err := inbox.New(cc.URL, cc.Email, cc.APIToken)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			reg.Register(client)
+		}
 	}
 	reg.Register(monday.New())
 	reg.Register(clickup.New())
 	return reg
 }
 
+// newBasecamp creates a Basecamp client that persists refreshed OAuth tokens.
+func newBasecamp(cfg *config.Config, cc config.ConnectorConfig) *basecamp.Client {
+	client := basecamp.New(cc.URL, basecamp.OAuthConfig{
+		ClientID:     cc.ClientID,
+		ClientSecret: cc.ClientSecret,
+		RedirectURI:  cc.RedirectURI,
+	}, basecamp.Token{
+		AccessToken:  cc.APIToken,
+		RefreshToken: cc.RefreshToken,
+		Expiry:       cc.TokenExpiry,
+	})
+	client.OnRefresh = func(token basecamp.Token) error {
+		cc.APIToken = token.AccessToken
+		cc.RefreshToken = token.RefreshToken
+		cc.TokenExpiry = token.Expiry
+		return cfg.SetConnector("basecamp", cc)
+	}
+	return client
+}
+
+// setTitle updates the terminal title for a task when terminal_title is enabled.
+func setTitle(cfg *config.Config, t *config.Task) {
+	if !cfg.TerminalTitle {
+		return
+	}
+	if err := terminal.SetTitle(terminal.Title(t.ID, t.TicketKey)); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// warnIfNotReady tells the user when a task's files are not checked out yet.
+func warnIfNotReady(t *config.Task) {
+	switch t.State {
+	case config.StatePreparing:
+		fmt.Fprintf(os.Stderr, "warning: task %s is still checking out files in the background\n", t.ID)
+	case config.StateCheckoutFailed:
+		fmt.Fprintf(os.Stderr, "warning: background checkout of task %s failed; see the log in ~/.wt/logs\n", t.ID)
+	}
+}
+
+// checkStartPoint warns about starting a task from a checkout with
+// uncommitted changes, an unfinished rebase or merge, or a detached HEAD,
+// since the new branch starts from whatever HEAD is. With start_check:
+// block it refuses unless forced.
+func checkStartPoint(ctx context.Context, cfg *config.Config, repoPath string, force bool) error {
+	if cfg.StartCheck == config.StartCheckOff {
+		return nil
+	}
+	problems, err := worktree.CheckoutProblems(ctx, repoPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return nil
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%s has %s", repoPath, strings.Join(problems, ", "))
+	if cfg.StartCheck == config.StartCheckBlock && !force {
+		return fmt.Errorf("%s; the new branch would start from its HEAD. Clean up the checkout or pass --force", msg)
+	}
+	fmt.Fprintf(os.Stderr, "warning: %s; the new branch starts from its HEAD without those changes\n", msg)
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal. It returns false without
+// asking when stdin is not a terminal.
+func confirm(question string) bool {
+	if !terminal.IsTerminal(os.Stdin) {
+		return false
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(answer), "y")
+}
+
 func resolveAgent(explicit, envAgent, defaultAgent string) string {
 	if explicit != "" {
 		return explicit
@@ -142,26 +376,95 @@ func startCmd() *cli.Command {
 		Name:      "start",
 		Category:  "lifecycle",
 		Usage:     "Create a new worktree for a task",
-		ArgsUsage: "<task-description>",
+		ArgsUsage: "<task-description|->",
 		Description: `Create an isolated git worktree for a new task in a separate directory.
 
-   Supports two modes:
+   Supports three modes:
      1. From description: wt start "add user authentication"
-     2. From Jira ticket: wt start --jira PROJ-123
+     2. From a ticket: wt start --jira PROJ-123
+                       wt start --sentry BACKEND-1A2
+                       wt start --gitlab acme/backend#12
+                       wt start --connector basecamp --ticket 1234-5678
+     3. From an incident: wt start --incident Q1ABC2DEF
+
+   With "-" as the description, it is read from stdin: the first line names
+   the task and its branch, and the remaining lines are stored as the task's
+   notes (shown by 'wt status', given to agents as WT_TASK_NOTES).
 
    Can optionally launch an AI agent immediately with --agent flag.
    Use WT_AGENT environment variable or default_agent config for automatic agent launch.
 
+   On huge repositories, --background creates the worktree without files and
+   checks them out in a detached process, so wt start returns immediately.
+
+   Tasks started from tickets are linked to the tasks of their parent and
+   sub-task tickets ('wt list --tree'). With --subtasks, a task is started
+   for each sub-task of the ticket as well. When the ticket already has a
+   task, wt start offers to switch to it; --another starts a second one.
+
+   --incident starts a hotfix from a PagerDuty or Opsgenie incident, from
+   whichever is connected (or --connector). Its branch takes hotfix_prefix
+   (default: hotfix) instead of branch_prefix, also in branch_template, and
+   the incident's severity, status, service and details become the task's
+   notes.
+
+   --sentry starts a fix for a Sentry issue, named after the issue. Its
+   title, culprit and a summary of the latest event's stack trace are
+   written to the task's context file, ~/.wt/scratch/<task-id>/context.md,
+   which agents find through WT_TASK_CONTEXT.
+
+   New branches start from the current checkout's HEAD. wt start warns when
+   that checkout has uncommitted changes, an unfinished rebase or merge, or a
+   detached HEAD; with 'wt config start_check block' it refuses unless given
+   --force, and 'off' skips the check.
+
+   --from-file starts several tasks at once, e.g. to fan a refactor out to
+   parallel agents. The file ("-" for stdin) is a YAML or JSON list; each
+   entry is a description or a map with description, notes, connector and
+   ticket, and repo (defaults to the current repository). A task that fails
+   to start is reported and the rest are still started.
+
    Examples:
      wt start "implement oauth flow"
      wt start --jira PROJ-123
+     wt start --connector basecamp --ticket 1234-5678
+     wt start --ticket jira:PROJ-123       # a key as 'wt triage' lists it
+     wt start --background "bump dependencies"
+     wt start - < task.md
+     wt start --from-file tasks.yaml
      wt start --agent copilot "add user auth"
+     wt start --jira PROJ-123 --subtasks
+     wt start --sentry BACKEND-1A2
+     wt start --gitlab acme/backend#12
+     wt start --incident Q1ABC2DEF
+     wt start --connector opsgenie --incident 42
+     wt start --jira PROJ-123 --another    # a second attempt at the ticket
      wt start --jira PROJ-123 --agent copilot --agent-args "--verbose"`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "jira",
 				Usage: "Create worktree from a Jira issue key (e.g. PROJ-123)",
 			},
+			&cli.StringFlag{
+				Name:  "sentry",
+				Usage: "Create worktree from a Sentry issue ID (e.g. BACKEND-1A2)",
+			},
+			&cli.StringFlag{
+				Name:  "gitlab",
+				Usage: "Create worktree from a GitLab issue (e.g. acme/backend#12, or #12 in the default project)",
+			},
+			&cli.StringFlag{
+				Name:  "ticket",
+				Usage: "Create worktree from a ticket of the connector given by --connector, or a key like jira:PROJ-123",
+			},
+			&cli.StringFlag{
+				Name:  "connector",
+				Usage: "Connector to fetch --ticket or --incident from (e.g. basecamp)",
+			},
+			&cli.StringFlag{
+				Name:  "incident",
+				Usage: "Create a hotfix worktree from a PagerDuty or Opsgenie incident",
+			},
 			&cli.StringFlag{
 				Name:  "agent",
 				Usage: "Launch an agent after creating the worktree (e.g. copilot, claude)",
@@ -170,84 +473,192 @@ func startCmd() *cli.Command {
 				Name:  "agent-args",
 				Usage: "Arguments to pass to the agent",
 			},
+			&cli.BoolFlag{
+				Name:  "background",
+				Usage: "Return immediately and check out files in the background (for huge repos)",
+			},
+			&cli.BoolFlag{
+				Name:  "subtasks",
+				Usage: "Also start tasks for the ticket's sub-tasks (asked interactively when omitted)",
+			},
+			&cli.BoolFlag{
+				Name:    "force",
+				Aliases: []string{"f"},
+				Usage:   "Start even if the checkout is dirty or mid-rebase (with start_check: block)",
+			},
+			&cli.StringFlag{
+				Name:  "from-file",
+				Usage: "Start every task listed in a YAML or JSON file (\"-\" for stdin)",
+			},
+			&cli.BoolFlag{
+				Name:  "another",
+				Usage: "Start a task for the ticket even if it already has one",
+			},
 		},
 		Action: func(c *cli.Context) error {
+			if c.Bool("background") && c.String("agent") != "" {
+				return fmt.Errorf("--agent cannot be combined with --background; run 'wt agent' once the checkout finishes")
+			}
 			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
-			repoPath, err := getRepoPath()
+			if path := c.String("from-file"); path != "" {
+				if c.NArg() > 0 || c.String("jira") != "" || c.String("ticket") != "" || c.String("sentry") != "" || c.String("gitlab") != "" || c.String("incident") != "" || c.String("agent") != "" {
+					return fmt.Errorf("--from-file cannot be combined with a description, --jira, --sentry, --gitlab, --ticket, --incident or --agent")
+				}
+				return startBatch(c, cfg, path)
+			}
+			repoPath, err := startRepo(c.Context, cfg, c.String("host"))
 			if err != nil {
 				return err
 			}
+			if err := checkStartPoint(c.Context, cfg, repoPath, c.Bool("force")); err != nil {
+				return err
+			}
 
 			mgr := task.NewManager(cfg)
-			opts := task.StartOptions{RepoPath: repoPath}
+			opts := task.StartOptions{RepoPath: repoPath, Background: c.Bool("background")}
+			var subtasks []connector.TicketRef
 
+			connName, ticketKey := c.String("connector"), c.String("ticket")
 			if jiraKey := c.String("jira"); jiraKey != "" {
-				cc, ok := cfg.Connectors["jira"]
-				if !ok {
-					return fmt.Errorf("jira is not configured; run 'wt connect jira' first")
+				connName, ticketKey = "jira", jiraKey
+			}
+			if sentryID := c.String("sentry"); sentryID != "" {
+				connName, ticketKey = "sentry", sentryID
+			}
+			if key := c.String("gitlab"); key != "" {
+				connName, ticketKey = "gitlab", key
+			}
+			if key := c.String("incident"); key != "" {
+				if ticketKey != "" {
+					return fmt.Errorf("--incident cannot be combined with --jira, --sentry, --gitlab or --ticket")
 				}
-				client := jira.New(cc.URL, cc.Email, cc.APIToken)
-				ticket, err := client.GetTicket(context.Background(), jiraKey)
+				if connName, err = incidentConnector(cfg, connName); err != nil {
+					return err
+				}
+				ticketKey = key
+			}
+			if connName == "" && ticketKey != "" {
+				connName, ticketKey = splitTicketRef(cfg, ticketKey)
+			}
+			if ticketKey != "" {
+				if connName == "" {
+					return fmt.Errorf("--ticket requires --connector, or a key prefixed with one, e.g. jira:PROJ-123")
+				}
+				if c.Bool("another") {
+					opts.Variant = anotherVariant(cfg, connName, ticketKey)
+				} else if existing, err := cfg.FindTaskByTicket(connName, ticketKey); err == nil {
+					return offerExistingTask(cfg, existing)
+				}
+				ticket, err := fetchStartTicket(c.Context, cfg, connName, ticketKey, &opts)
 				if err != nil {
-					return fmt.Errorf("failed to fetch jira issue: %w", err)
+					return err
 				}
-				opts.Description = ticket.Summary
-				opts.Connector = "jira"
-				opts.TicketKey = ticket.Key
-				opts.TicketTitle = ticket.Summary
-				fmt.Printf("📋 Jira: %s - %s\n", ticket.Key, ticket.Summary)
+				subtasks = ticket.Subtasks
 			} else {
 				if c.NArg() < 1 {
 					return fmt.Errorf("please provide a task description or use --jira <ISSUE-KEY>")
 				}
 				opts.Description = joinArgs(c)
+				if opts.Description == "-" {
+					data, err := io.ReadAll(os.Stdin)
+					if err != nil {
+						return fmt.Errorf("failed to read description from stdin: %w", err)
+					}
+					opts.Description, opts.Notes = splitDescription(string(data))
+					if opts.Description == "" {
+						return fmt.Errorf("no task description on stdin")
+					}
+				}
 			}
 
-			t, err := mgr.Start(opts)
+			t, err := mgr.Start(c.Context, opts)
 			if err != nil {
 				return err
 			}
+			if opts.Background {
+				// Without the checkout, the task would stay preparing forever.
+				if err := task.SpawnCheckout(t.ID); err != nil {
+					if err := mgr.Abort(c.Context, t.ID); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					}
+					return err
+				}
+			}
 
 			fmt.Printf("✅ Task started: %s\n", t.ID)
 			fmt.Printf("   Branch:   %s\n", t.Branch)
 			fmt.Printf("   Worktree: %s\n", t.Worktree)
 
-			// Determine agent to launch
-			agentName := resolveAgent(c.String("agent"), os.Getenv("WT_AGENT"), cfg.DefaultAgent)
-
-			// If no agent specified, just print the cd command
-			if agentName == "" {
-				fmt.Printf("\n   cd %s\n", t.Worktree)
-				return nil
+			if len(subtasks) > 0 {
+				if err := startSubtasks(c, mgr, t, opts, subtasks); err != nil {
+					return err
+				}
 			}
 
-			// Validate and launch agent
-			if err := agent.ValidateAgent(agentName, cfg.AgentAliases); err != nil {
-				fmt.Fprintf(os.Stderr, "⚠️  Agent %q not found: %v\n", agentName, err)
-				fmt.Printf("\n   cd %s\n", t.Worktree)
+			if opts.Background {
+				logPath, _ := task.CheckoutLogPath(t.ID)
+				fmt.Printf("\n⏳ Checking out files in the background (log: %s)\n", logPath)
+				fmt.Printf("   'wt list' shows the task as preparing until it finishes.\n")
 				return nil
 			}
 
-			// Parse agent args
-			agentArgs := agent.ParseAgentArgs(c.String("agent-args"))
-
-			fmt.Printf("\n🚀 Launching agent: %s\n", agentName)
-			return agent.LaunchAgent(agent.LaunchOptions{
-				Agent:         agentName,
-				Args:          agentArgs,
-				WorkDir:       t.Worktree,
-				TaskID:        t.ID,
-				TicketKey:     t.TicketKey,
-				TicketSummary: opts.TicketTitle,
-				Aliases:       cfg.AgentAliases,
-			})
+			agentName := resolveAgent(c.String("agent"), os.Getenv("WT_AGENT"), cfg.DefaultAgent)
+			return enterStarted(cfg, t, agentName, c.String("agent-args"))
 		},
 	}
 }
 
+// enterStarted tells how to enter a task just started or, given an agent,
+// launches the agent in its worktree, replacing this process.
+func enterStarted(cfg *config.Config, t *config.Task, agentName, agentArgs string) error {
+	if host, dir := worktree.SplitHost(t.Worktree); host != "" {
+		// Agents run on this machine, next to their worktree.
+		fmt.Printf("\n   %s\n", sshCommand(host, dir))
+		return nil
+	}
+	if shell := task.WorkspaceShell(t); shell != "" {
+		fmt.Printf("   Workspace: %s %s\n", t.Workspace, t.WorkspaceID)
+		fmt.Printf("\n   %s\n", shell)
+		return nil
+	}
+
+	// If no agent specified, just print the cd command
+	if agentName == "" {
+		fmt.Printf("\n   cd %s\n", t.Worktree)
+		return nil
+	}
+
+	// Validate and launch agent
+	if err := agent.ValidateAgent(agentName, cfg.AgentAliases); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Agent %q not found: %v\n", agentName, err)
+		fmt.Printf("\n   cd %s\n", t.Worktree)
+		return nil
+	}
+
+	// Parse agent args
+	args := agent.ParseAgentArgs(agentArgs)
+
+	env, err := agentEnv(cfg, t)
+	if err != nil {
+		return err
+	}
+	launch, err := launchOptions(cfg, t, agentName, args, env)
+	if err != nil {
+		return err
+	}
+
+	// The agent replaces this process, so our PID becomes the agent's.
+	if err := cfg.RecordAgent(t.ID, agentName, os.Getpid(), agent.StartTime(os.Getpid())); err != nil {
+		return err
+	}
+	setTitle(cfg, t)
+	fmt.Printf("\n🚀 Launching agent: %s\n", agentName)
+	return agent.LaunchAgent(launch)
+}
+
 // --- agent ---
 func agentCmd() *cli.Command {
 	return &cli.Command{
@@ -265,10 +676,16 @@ func agentCmd() *cli.Command {
      2. WT_AGENT environment variable
      3. default_agent config setting
 
+   With --resume, the agent's newest session in the worktree is resumed
+   instead of starting a new conversation, using the agent last launched
+   in the task unless --agent is given. Claude Code and Codex sessions can
+   be resumed.
+
    Examples:
      wt agent wt-abc123                    # Uses WT_AGENT or default_agent
      wt agent --agent copilot wt-abc123    # Explicit agent selection
-     wt agent --agent copilot --agent-args "-y" wt-abc123`,
+     wt agent --agent copilot --agent-args "-y" wt-abc123
+     wt agent --resume wt-abc123           # Continue the last conversation`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "agent",
@@ -278,6 +695,10 @@ func agentCmd() *cli.Command {
 				Name:  "agent-args",
 				Usage: "Arguments to pass to the agent",
 			},
+			&cli.BoolFlag{
+				Name:  "resume",
+				Usage: "Resume the agent's previous session in the task (claude and codex)",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 1 {
@@ -294,14 +715,26 @@ func agentCmd() *cli.Command {
 			if err != nil {
 				return err
 			}
+			if t, err = materialize(c.Context, cfg, t); err != nil {
+				return err
+			}
 
+			if err := checkLocal(t); err != nil {
+				return err
+			}
 			// Verify worktree still exists
 			if _, err := os.Stat(t.Worktree); err != nil {
 				return fmt.Errorf("worktree %s no longer exists: %w", t.Worktree, err)
 			}
+			warnIfNotReady(t)
 
-			// Determine agent to launch
-			agentName := resolveAgent(c.String("agent"), os.Getenv("WT_AGENT"), cfg.DefaultAgent)
+			// Determine agent to launch; a resumed session belongs to the
+			// agent last launched in the task.
+			explicit := c.String("agent")
+			if c.Bool("resume") && explicit == "" {
+				explicit = t.Agent
+			}
+			agentName := resolveAgent(explicit, os.Getenv("WT_AGENT"), cfg.DefaultAgent)
 
 			if agentName == "" {
 				return fmt.Errorf("no agent specified; use --agent flag, set WT_AGENT env var, or configure default_agent")
@@ -315,27 +748,182 @@ func agentCmd() *cli.Command {
 			// Parse agent args
 			agentArgs := agent.ParseAgentArgs(c.String("agent-args"))
 
-			fmt.Printf("🚀 Launching agent %q on task %s\n", agentName, t.ID)
-			fmt.Printf("   Worktree: %s\n", t.Worktree)
+			var session string
+			if c.Bool("resume") {
+				session, err = resumeSession(cfg, t, agentName)
+				if err != nil {
+					return err
+				}
+				kind := agent.Kind(agentName, cfg.AgentAliases)
+				agentArgs = append(agent.ResumeArgs(kind, session), agentArgs...)
+				if err := cfg.SetAgentSession(t.ID, session); err != nil {
+					return err
+				}
+			}
+
+			env, err := agentEnv(cfg, t)
+			if err != nil {
+				return err
+			}
+			launch, err := launchOptions(cfg, t, agentName, agentArgs, env)
+			if err != nil {
+				return err
+			}
 
-			ticketSummary := t.TicketKey
-			if t.Description != "" {
-				ticketSummary = t.Description
+			// The agent replaces this process, so our PID becomes the agent's.
+			if err := cfg.RecordAgent(t.ID, agentName, os.Getpid(), agent.StartTime(os.Getpid())); err != nil {
+				return err
 			}
+			setTitle(cfg, t)
+			if session != "" {
+				fmt.Printf("🚀 Resuming agent %q session %s on task %s\n", agentName, session, t.ID)
+			} else {
+				fmt.Printf("🚀 Launching agent %q on task %s\n", agentName, t.ID)
+			}
+			fmt.Printf("   Worktree: %s\n", t.Worktree)
 
-			return agent.LaunchAgent(agent.LaunchOptions{
-				Agent:         agentName,
-				Args:          agentArgs,
-				WorkDir:       t.Worktree,
-				TaskID:        t.ID,
-				TicketKey:     t.TicketKey,
-				TicketSummary: ticketSummary,
-				Aliases:       cfg.AgentAliases,
-			})
+			return agent.LaunchAgent(launch)
 		},
 	}
 }
 
+// resumeSession returns the session of agentName to resume in a task: the
+// newest one in the agent's session logs, or else the one recorded when the
+// task's agent was last resumed.
+func resumeSession(cfg *config.Config, t *config.Task, agentName string) (string, error) {
+	kind := agent.Kind(agentName, cfg.AgentAliases)
+	session, err := agent.LatestSession(kind, t.Worktree, t.Created)
+	if errors.Is(err, agent.ErrNoSession) && t.AgentSession != "" && agent.Kind(t.Agent, cfg.AgentAliases) == kind {
+		return t.AgentSession, nil
+	}
+	return session, err
+}
+
+// offerExistingTask is run when a task is started for a ticket that
+// already has one: it offers to switch to that task instead, and otherwise
+// fails, pointing at --another.
+func offerExistingTask(cfg *config.Config, existing *config.Task) error {
+	fmt.Fprintf(os.Stderr, "⚠️  %s already has task %s (%s)\n", existing.TicketKey, existing.ID, existing.Worktree)
+	if !confirm("Switch to it instead?") {
+		return fmt.Errorf("%s already has task %s; switch to it with 'wt switch %s', or pass --another to start a second one", existing.TicketKey, existing.ID, existing.ID)
+	}
+	if err := cfg.TouchTask(existing.ID); err != nil {
+		return err
+	}
+	warnIfNotReady(existing)
+	setTitle(cfg, existing)
+	fmt.Printf("\n   cd %s\n", existing.Worktree)
+	return nil
+}
+
+// anotherVariant returns the StartOptions.Variant that keeps the branch of
+// another task for a ticket apart from those of its existing tasks.
+func anotherVariant(cfg *config.Config, connName, key string) string {
+	n := 1
+	for _, t := range cfg.Tasks {
+		if t.Connector == connName && strings.EqualFold(t.TicketKey, key) {
+			n++
+		}
+	}
+	if n == 1 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// fetchStartTicket fetches the ticket a task is started from and fills in
+// the options taken from it.
+func fetchStartTicket(ctx context.Context, cfg *config.Config, connName, key string, opts *task.StartOptions) (*connector.Ticket, error) {
+	conn, ok := buildRegistry(cfg).Get(connName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s; run 'wt connect %s' first", connector.ErrNotConfigured, connName, connName)
+	}
+	ticket, err := conn.GetTicket(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s ticket: %w", connName, err)
+	}
+	opts.Description = ticket.Summary
+	opts.Connector = connName
+	opts.TicketKey = ticket.Key
+	opts.TicketTitle = ticket.Summary
+	opts.EpicKey = ticket.EpicKey
+	if ticket.ParentKey != "" {
+		if parent, err := cfg.FindTaskByTicket(connName, ticket.ParentKey); err == nil {
+			opts.Parent = parent.ID
+		}
+	}
+	fmt.Printf("📋 %s: %s - %s\n", connName, ticket.Key, ticket.Summary)
+	for _, k := range sortedKeys(ticket.Metadata) {
+		if strings.HasPrefix(k, "SLA ") {
+			fmt.Printf("   %s: %s\n", k, ticket.Metadata[k])
+		}
+	}
+	if connName == "sentry" {
+		opts.Context = ticketContext(ticket)
+	}
+	if incident.IsIncident(connName) {
+		opts.BranchPrefix = hotfixPrefix(cfg)
+		opts.Notes = incidentNotes(ticket)
+		if ticket.Priority != "" {
+			fmt.Printf("   Severity: %s\n", ticket.Priority)
+		}
+	}
+	return ticket, nil
+}
+
+// startSubtasks links existing tasks for a ticket's sub-tasks to parent and,
+// when requested or confirmed, starts tasks for the remaining ones.
+func startSubtasks(c *cli.Context, mgr *task.Manager, parent *config.Task, opts task.StartOptions, subtasks []connector.TicketRef) error {
+	var missing []connector.TicketRef
+	for _, sub := range subtasks {
+		existing, err := mgr.Config.FindTaskByTicket(parent.Connector, sub.Key)
+		if err != nil {
+			missing = append(missing, sub)
+			continue
+		}
+		if existing.Parent == "" {
+			if err := mgr.Config.SetTaskParent(existing.ID, parent.ID); err != nil {
+				return err
+			}
+			fmt.Printf("   Linked sub-task %s (%s)\n", sub.Key, existing.ID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	create := c.Bool("subtasks")
+	if !c.IsSet("subtasks") {
+		create = confirm(fmt.Sprintf("\n%s has %d sub-task(s) without a task. Start them too?", parent.TicketKey, len(missing)))
+	}
+	if !create {
+		return nil
+	}
+
+	for _, sub := range missing {
+		child, err := mgr.Start(c.Context, task.StartOptions{
+			Description: sub.Summary,
+			RepoPath:    opts.RepoPath,
+			Connector:   parent.Connector,
+			TicketKey:   sub.Key,
+			TicketTitle: sub.Summary,
+			EpicKey:     opts.EpicKey,
+			Parent:      parent.ID,
+			Background:  opts.Background,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start sub-task %s: %w", sub.Key, err)
+		}
+		fmt.Printf("   ↳ %s: %s (%s)\n", sub.Key, child.ID, child.Branch)
+		if opts.Background {
+			if err := task.SpawnCheckout(child.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // --- list ---
 func listCmd() *cli.Command {
 	return &cli.Command{
@@ -347,136 +935,794 @@ func listCmd() *cli.Command {
 
    Shows task ID, description, branch name, worktree path, and associated ticket.
    Use task IDs from this output with other commands (finish, remove, switch, agent).
+   Tasks are ordered by most recently used; use --sort created for creation order.
+
+   With --git, adds each worktree's git status, the agent last launched in it
+   and the result of its last 'wt test'.
+   With --watch, redraws that table every few seconds until interrupted.
+   With --tree, sub-tasks are listed indented under their parent task.
+   With --tickets, adds the live status of each task's ticket. Tickets are
+   fetched concurrently, rate-limited per connector (ticket_rate_limit) and
+   cached for ticket_cache_ttl (default 5m).
+
+   When tickets are shown, changes between polls ("PROJ-123 moved to Blocked",
+   "new comment on ENG-42") are listed below the table until read with
+   'wt status' in the task's worktree. With --notify, new changes are also
+   sent as desktop notifications.
+
+   With --team, lists the active tasks of everyone sharing state through the
+   team backend (see 'wt team').
+
+   With --milestone, lists only the tasks planned for a milestone (see
+   'wt milestone').
+
+   Tasks whose base branch (origin/<default_branch>) has gained
+   rebase_threshold or more commits (default 50) since the task branched off
+   are marked "needs rebase". Counts are cached until either branch moves.
 
    Example:
-     wt list`,
+     wt list
+     wt list --sort created
+     wt list --git
+     wt list --tree
+     wt list --tickets
+     wt list --watch --interval 5s
+     wt list --watch --tickets --notify
+     wt list --team
+     wt list --milestone v2.1
+     wt list -o json
+     wt list -o 'template={{.id}} {{.worktree}}'`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "sort", Value: "recent", Usage: "Sort order: recent or created"},
+			&cli.BoolFlag{Name: "git", Usage: "Show git status and agent columns"},
+			&cli.BoolFlag{Name: "tree", Usage: "Show sub-tasks under their parent task"},
+			&cli.BoolFlag{Name: "tickets", Usage: "Show the live status of each task's ticket"},
+			&cli.BoolFlag{Name: "watch", Aliases: []string{"w"}, Usage: "Redraw the table periodically (implies --git)"},
+			&cli.DurationFlag{Name: "interval", Value: 2 * time.Second, Usage: "Refresh interval for --watch"},
+			&cli.BoolFlag{Name: "notify", Usage: "Send a desktop notification when a ticket changes (with --tickets)"},
+			&cli.BoolFlag{Name: "team", Usage: "Show the active tasks of your whole team"},
+			&cli.StringFlag{Name: "milestone", Usage: "Show only the tasks of a milestone"},
+			outputFlag(),
+		},
 		Action: func(c *cli.Context) error {
-			cfg, err := loadConfig()
+			sortBy := c.String("sort")
+			if sortBy != "recent" && sortBy != "created" {
+				return fmt.Errorf("unknown sort order %q (want recent or created)", sortBy)
+			}
+			f, err := formatter(c)
 			if err != nil {
 				return err
 			}
-			if len(cfg.Tasks) == 0 {
-				fmt.Println("No active tasks.")
-				return nil
+			if c.Bool("team") {
+				cfg, err := loadConfig()
+				if err != nil {
+					return err
+				}
+				return printTeamList(c.Context, os.Stdout, cfg, f)
+			}
+			opts := listOptions{sortBy: sortBy, git: c.Bool("git"), tree: c.Bool("tree"), tickets: c.Bool("tickets"), notify: c.Bool("notify"), milestone: c.String("milestone"), format: f}
+			if !c.Bool("watch") {
+				return printTaskList(c.Context, os.Stdout, opts)
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "ID\tDESCRIPTION\tBRANCH\tWORKTREE\tTICKET")
-			for _, t := range cfg.Tasks {
-				ticket := t.TicketKey
-				if ticket == "" {
-					ticket = "-"
+			if !f.IsTable() {
+				return fmt.Errorf("--watch only supports table output")
+			}
+			opts.git = true
+			interval := c.Duration("interval")
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				var buf bytes.Buffer
+				if err := printTaskList(c.Context, &buf, opts); err != nil {
+					return err
+				}
+				// Clear the screen and home the cursor before redrawing.
+				fmt.Print("\033[H\033[2J")
+				fmt.Printf("Every %s: %s\n\n", interval, time.Now().Format("15:04:05"))
+				os.Stdout.Write(buf.Bytes())
+				select {
+				case <-c.Context.Done():
+					return nil
+				case <-ticker.C:
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.ID, truncate(t.Description, 40), t.Branch, t.Worktree, ticket)
 			}
-			return w.Flush()
 		},
 	}
 }
 
-// --- finish ---
-func finishCmd() *cli.Command {
-	return &cli.Command{
-		Name:      "finish",
-		Category:  "lifecycle",
-		Usage:     "Complete a task, remove worktree and branch",
-		ArgsUsage: "<task-id>",
-		Description: `Complete a task and clean up all resources.
+// listOptions selects the ordering and optional columns of the task table.
+type listOptions struct {
+	sortBy    string
+	git       bool
+	tree      bool
+	tickets   bool
+	notify    bool
+	milestone string
+	format    *output.Formatter
+}
 
-   This command will:
-     1. Remove the worktree directory
-     2. Delete the git branch
-     3. Remove the task from wt's tracking
+// taskRow is a task with the optional columns of 'wt list'.
+type taskRow struct {
+	config.Task
+	Depth        int    `json:"depth,omitempty"`
+	TicketStatus string `json:"ticket_status,omitempty"`
+	Git          string `json:"git,omitempty"`
+	AgentStatus  string `json:"agent_status,omitempty"`
+	BaseBehind   int    `json:"base_behind,omitempty"`
+	NeedsRebase  bool   `json:"needs_rebase,omitempty"`
+}
 
-   Use this when work is complete and merged. For keeping the branch, use 'wt remove' instead.
+// printTaskList writes the task list, reloading the config so that watch
+// mode picks up tasks started or finished elsewhere.
+func printTaskList(ctx context.Context, out io.Writer, opts listOptions) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(cfg.Tasks) == 0 && opts.format.IsTable() {
+		fmt.Fprintln(out, "No active tasks.")
+		return nil
+	}
 
-   Example:
-     wt finish wt-abc123`,
-		Action: func(c *cli.Context) error {
-			if c.NArg() < 1 {
-				return fmt.Errorf("please provide a task ID (see 'wt list')")
-			}
-			cfg, err := loadConfig()
-			if err != nil {
-				return err
+	tasks := cfg.Tasks
+	if opts.sortBy == "recent" {
+		tasks = cfg.RecentTasks()
+	}
+	if opts.milestone != "" {
+		tasks = milestoneTasks(tasks, opts.milestone)
+		if len(tasks) == 0 && opts.format.IsTable() {
+			fmt.Fprintf(out, "No active tasks in milestone %s.\n", opts.milestone)
+			return nil
+		}
+	}
+	var depths []int
+	if opts.tree {
+		tasks, depths = config.TaskTree(tasks)
+	}
+
+	var statuses []worktree.StatusResult
+	if opts.git {
+		paths := make([]string, len(tasks))
+		for i, t := range tasks {
+			paths[i] = t.Worktree
+		}
+		statuses = worktree.StatusAll(ctx, paths, cfg.Concurrency)
+	}
+	var tickets map[connector.Ref]connector.FetchResult
+	var unread []connector.Change
+	if opts.tickets {
+		tickets = fetchTaskTickets(ctx, cfg, tasks)
+		unread = recordTicketChanges(tickets, opts.notify)
+	}
+	var distances []int
+	if rebaseThreshold(cfg) > 0 {
+		distances = baseDistances(ctx, cfg, tasks)
+	}
+
+	rows := make([]taskRow, len(tasks))
+	for i, t := range tasks {
+		row := taskRow{Task: t}
+		if opts.tree {
+			row.Depth = depths[i]
+		}
+		if opts.tickets && t.TicketKey != "" {
+			res := tickets[connector.Ref{Connector: t.Connector, Key: t.TicketKey}]
+			if res.Err != nil {
+				row.TicketStatus = "?"
+			} else if res.Ticket != nil {
+				row.TicketStatus = res.Ticket.Status
 			}
-			mgr := task.NewManager(cfg)
-			t, err := mgr.Finish(c.Args().First())
-			if err != nil {
-				return err
+		}
+		if opts.git {
+			row.Git = "missing"
+			if statuses[i].Err == nil {
+				row.Git = statuses[i].Info.String()
 			}
-			fmt.Printf("✅ Task finished: %s\n", t.Description)
-			fmt.Printf("   Worktree removed: %s\n", t.Worktree)
-			fmt.Printf("   Branch deleted: %s\n", t.Branch)
-			return nil
-		},
+			row.AgentStatus = agentStatus(t)
+		}
+		if distances != nil && distances[i] > 0 {
+			row.BaseBehind = distances[i]
+			row.NeedsRebase = needsRebase(cfg, distances[i])
+		}
+		rows[i] = row
 	}
+
+	return opts.format.Write(out, rows, func(out io.Writer) error {
+		if err := printTaskTable(out, rows, opts); err != nil {
+			return err
+		}
+		if len(unread) > 0 {
+			fmt.Fprintln(out)
+		}
+		for _, ch := range unread {
+			fmt.Fprintf(out, "🔔 %s  %s\n", ch.Time.Format("15:04"), ch)
+		}
+		return nil
+	})
 }
 
-// --- remove ---
-func removeCmd() *cli.Command {
-	return &cli.Command{
-		Name:      "remove",
+func printTaskTable(out io.Writer, rows []taskRow, opts listOptions) error {
+	w := output.NewTabWriter(out)
+	header := "ID\tDESCRIPTION\tBRANCH\tWORKTREE\tTICKET"
+	if opts.tickets {
+		header += "\tTICKET STATUS"
+	}
+	if opts.git {
+		header += "\tGIT\tAGENT\tTESTS"
+	}
+	fmt.Fprintln(w, header)
+	for _, r := range rows {
+		id, desc, ticket := r.ID, r.Description, r.TicketKey
+		if ticket == "" {
+			ticket = "-"
+		}
+		if r.State != "" {
+			desc = "[" + r.State + "] " + desc
+		}
+		if r.Lock != nil {
+			desc = "🔒 " + desc
+		}
+		if r.Depth > 0 {
+			id = strings.Repeat("  ", r.Depth-1) + "└─ " + id
+		}
+		branch := r.Branch
+		if r.NeedsRebase {
+			branch += fmt.Sprintf(" (needs rebase, %d behind)", r.BaseBehind)
+		}
+		cols := []string{id, output.Truncate(desc, 40), branch, r.Worktree, ticket}
+		if opts.tickets {
+			status := r.TicketStatus
+			if status == "" {
+				status = "-"
+			}
+			cols = append(cols, status)
+		}
+		if opts.git {
+			cols = append(cols, r.Git, r.AgentStatus, testSummary(r.Test))
+		}
+		fmt.Fprintln(w, strings.Join(cols, "\t"))
+	}
+	return w.Flush()
+}
+
+// recordTicketChanges compares fetched tickets with their state at the
+// previous poll, optionally notifying about new changes, and returns all
+// changes not yet read with 'wt status', oldest first.
+func recordTicketChanges(tickets map[connector.Ref]connector.FetchResult, notify bool) []connector.Change {
+	if len(tickets) == 0 {
+		return nil
+	}
+	path, err := cachePath("alerts.json")
+	if err != nil {
+		return nil
+	}
+	alerts := connector.LoadAlerts(path)
+	var unread []connector.Change
+	for ref, res := range tickets {
+		if res.Err != nil || res.Ticket == nil {
+			continue
+		}
+		changes := alerts.Observe(ref, res.Ticket)
+		if notify {
+			for _, ch := range changes {
+				if err := terminal.Notify("wt", ch.String()); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+				}
+			}
+		}
+		unread = append(unread, alerts.Pending(ref)...)
+	}
+	if err := alerts.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	sort.SliceStable(unread, func(i, j int) bool { return unread[i].Time.Before(unread[j].Time) })
+	return unread
+}
+
+// fetchTaskTickets fetches the live tickets of tasks started from tickets,
+// within the configured per-connector rate limit and using the ticket cache.
+func fetchTaskTickets(ctx context.Context, cfg *config.Config, tasks []config.Task) map[connector.Ref]connector.FetchResult {
+	var refs []connector.Ref
+	for _, t := range tasks {
+		if t.TicketKey != "" && t.Connector != "" {
+			refs = append(refs, connector.Ref{Connector: t.Connector, Key: t.TicketKey})
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	fetcher := &connector.Fetcher{Registry: buildRegistry(cfg), RateLimit: cfg.TicketRateLimit}
+	path, err := cachePath("tickets.json")
+	if err == nil {
+		ttl := cfg.TicketCacheTTL
+		if ttl == 0 {
+			ttl = connector.DefaultCacheTTL
+		}
+		fetcher.Cache = connector.LoadCache(path, ttl)
+	}
+	results := fetcher.FetchAll(ctx, refs)
+	if fetcher.Cache != nil {
+		if err := fetcher.Cache.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	return results
+}
+
+// cachePath returns the path of a file in the wt cache directory.
+func cachePath(name string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache", name), nil
+}
+
+// agentStatus describes the agent last launched for a task.
+func agentStatus(t config.Task) string {
+	if t.Agent == "" {
+		return "-"
+	}
+	if agent.IsAlive(t.AgentPID, t.AgentStarted) {
+		return t.Agent + " (running)"
+	}
+	return t.Agent
+}
+
+// checkNames returns the names of finish checks.
+func checkNames(checks []config.FinishCheck) []string {
+	names := make([]string, len(checks))
+	for i, check := range checks {
+		names[i] = check.Name
+	}
+	return names
+}
+
+// parseFinishChecks turns check names into finish checks, keeping the
+// command of custom checks already configured under those names.
+func parseFinishChecks(current []config.FinishCheck, names []string) ([]config.FinishCheck, error) {
+	custom := make(map[string]config.FinishCheck)
+	for _, check := range current {
+		if check.Run != "" {
+			custom[check.Name] = check
+		}
+	}
+	checks := make([]config.FinishCheck, len(names))
+	for i, name := range names {
+		check, ok := custom[name]
+		if !ok {
+			check = config.FinishCheck{Name: name}
+		}
+		if err := check.Validate(); err != nil {
+			return nil, err
+		}
+		checks[i] = check
+	}
+	return checks, nil
+}
+
+// testSummary describes a task's last test result, e.g. "passed 3h ago".
+func testSummary(r *config.TestResult) string {
+	if r == nil {
+		return "-"
+	}
+	return r.String() + " " + timeAgo(r.Time)
+}
+
+// --- finish ---
+func finishCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "finish",
+		Category:  "lifecycle",
+		Usage:     "Complete a task, remove worktree and branch",
+		ArgsUsage: "[task-id|path]",
+		Description: `Complete a task and clean up all resources.
+
+   This command will:
+     1. Remove the worktree directory
+     2. Delete the git branch
+     3. Remove the task from wt's tracking
+
+   Use this when work is complete and merged. For keeping the branch, use 'wt remove' instead.
+   The task can be given by ID or by a path inside its worktree; without one,
+   the task of the current worktree is finished.
+   A worktree with uncommitted changes, a lock (see 'wt lock') or a running
+   agent is kept unless --force is given.
+
+   The task's scratch directory (~/.wt/scratch/<task-id>) is deleted too;
+   --archive moves it to ~/.wt/archive/<task-id> instead (see 'wt archive').
+
+   The branch on origin is kept, unless delete_remote_branch says otherwise:
+   "merged" deletes it once its pull request is merged or origin's default
+   branch contains it, "always" deletes it regardless. --delete-remote and
+   --keep-remote decide for one finish.
+
+   First, the finish_checks configured for the repository must pass: by
+   default the repository's test_command (see 'wt test'), reusing a passing
+   result for an unchanged HEAD. Built-in checks are clean, tests and
+   rebased; others run a shell command in the worktree. --skip-checks
+   finishes anyway.
+
+   Example:
+     wt finish wt-abc123
+     wt finish --archive wt-abc123
+     wt finish --skip-checks wt-abc123
+     wt finish ~/worktrees/repo/login-fix`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "Discard uncommitted changes and ignore locks"},
+			&cli.BoolFlag{Name: "skip-checks", Usage: "Don't run finish_checks"},
+			&cli.BoolFlag{Name: "archive", Usage: "Keep the scratch directory in ~/.wt/archive"},
+			&cli.BoolFlag{Name: "bundle", Usage: "Save the branch as a git bundle in ~/.wt/archive (implies --archive)"},
+			&cli.BoolFlag{Name: "delete-remote", Usage: "Delete the branch on origin too"},
+			&cli.BoolFlag{Name: "keep-remote", Usage: "Keep the branch on origin, whatever delete_remote_branch says"},
+		},
+		Action: func(c *cli.Context) error {
+			return finishTask(c, c.Bool("archive"))
+		},
+	}
+}
+
+// --- archive ---
+func archiveCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "archive",
+		Category:  "lifecycle",
+		Usage:     "Finish a task, keeping its files and optionally its branch",
+		ArgsUsage: "[task-id|path]",
+		Description: `Finish a task like 'wt finish', moving its scratch directory to
+   ~/.wt/archive/<task-id> instead of deleting it.
+
+   With --bundle, the branch is first saved with its history as a git bundle
+   in the same directory, so the work can be resurrected after the branch is
+   deleted:
+
+     git fetch ~/.wt/archive/<task-id>/branch.bundle <branch>:<branch>
+
+   Examples:
+     wt archive wt-abc123
+     wt archive --bundle wt-abc123`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "Discard uncommitted changes and ignore locks"},
+			&cli.BoolFlag{Name: "skip-checks", Usage: "Don't run finish_checks"},
+			&cli.BoolFlag{Name: "bundle", Usage: "Save the branch as a git bundle before deleting it"},
+			&cli.BoolFlag{Name: "delete-remote", Usage: "Delete the branch on origin too"},
+			&cli.BoolFlag{Name: "keep-remote", Usage: "Keep the branch on origin, whatever delete_remote_branch says"},
+		},
+		Action: func(c *cli.Context) error {
+			return finishTask(c, true)
+		},
+	}
+}
+
+// finishTask finishes the task named by the first argument, archiving its
+// scratch directory when archive or --bundle is set.
+func finishTask(c *cli.Context, archive bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if c.Bool("delete-remote") && c.Bool("keep-remote") {
+		return fmt.Errorf("--delete-remote and --keep-remote cannot be used together")
+	}
+	t, err := taskFromArgOrCwd(c, cfg)
+	if err != nil {
+		return err
+	}
+	inside := insideWorktree(cfg, t)
+	mgr := task.NewManager(cfg)
+	mgr.Force = c.Bool("force")
+	mgr.Bundle = c.Bool("bundle")
+	mgr.Archive = archive || mgr.Bundle
+	if !c.Bool("skip-checks") {
+		results, err := mgr.RunChecks(c.Context, t, os.Stdout)
+		if len(results) > 0 {
+			fmt.Println("Finish checks:")
+		}
+		for _, r := range results {
+			fmt.Printf("   %s\n", r)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Decided before the branch is gone, as deciding may need it.
+	deleteRemote, unmerged := shouldDeleteRemote(c, cfg, t)
+	t, err = mgr.Finish(c.Context, t.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Task finished: %s\n", t.Description)
+	if t.State != config.StateBranchOnly {
+		fmt.Printf("   Worktree removed: %s\n", t.Worktree)
+	}
+	if t.Head != "" {
+		// Enough to undo the deletion with 'git branch <branch> <head>'.
+		fmt.Printf("   Branch deleted: %s (was %.12s)\n", t.Branch, t.Head)
+	} else {
+		fmt.Printf("   Branch deleted: %s\n", t.Branch)
+	}
+	if deleteRemote {
+		if err := worktree.DeleteRemoteBranch(c.Context, t.RepoPath, t.Branch); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			fmt.Printf("   Remote branch deleted: origin/%s\n", t.Branch)
+		}
+	} else if unmerged {
+		fmt.Printf("   Remote branch kept: origin/%s is not merged yet\n", t.Branch)
+	}
+	printArchived(t)
+	printLeaveHint(t, inside)
+	return nil
+}
+
+// shouldDeleteRemote decides whether finishing t deletes its branch on
+// origin, from --delete-remote, --keep-remote and delete_remote_branch, and
+// reports whether it is kept only for not being merged. Only a branch
+// origin is known to have, as of the last fetch, is deleted.
+func shouldDeleteRemote(c *cli.Context, cfg *config.Config, t *config.Task) (del, unmerged bool) {
+	mode := cfg.DeleteRemote
+	switch {
+	case c.Bool("keep-remote"):
+		return false, false
+	case c.Bool("delete-remote"):
+		mode = config.DeleteRemoteAlways
+	}
+	if mode != config.DeleteRemoteMerged && mode != config.DeleteRemoteAlways {
+		return false, false
+	}
+	remote := "refs/remotes/origin/" + t.Branch
+	if !worktree.BranchExists(c.Context, t.RepoPath, remote) {
+		return false, false
+	}
+	if mode == config.DeleteRemoteAlways {
+		return true, false
+	}
+	base := "refs/remotes/origin/" + task.DefaultBranch(c.Context, cfg, t.RepoPath)
+	if merged, err := worktree.IsAncestor(c.Context, t.RepoPath, remote, base); err == nil && merged {
+		return true, false
+	}
+	// Squash and rebase merges leave no trace in the history.
+	pr, err := findPullRequest(c.Context, t.Worktree, t.Branch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if pr != nil && pr.State == "MERGED" {
+		return true, false
+	}
+	return false, true
+}
+
+// --- remove ---
+func removeCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "remove",
 		Category:  "lifecycle",
 		Usage:     "Remove a worktree but keep the branch",
 		Aliases:   []string{"rm"},
-		ArgsUsage: "<task-id>",
+		ArgsUsage: "[task-id|path]",
 		Description: `Remove a worktree directory but preserve the git branch.
 
    Use this when you want to free up disk space but keep the branch for later work.
    The branch can be checked out again or a new worktree created from it.
+   A worktree with uncommitted changes, a lock (see 'wt lock') or a running
+   agent is kept unless --force is given. The task's scratch directory is
+   deleted unless --archive is given.
+
+   The task can be given by ID or by a path inside its worktree; without one,
+   the task of the current worktree is removed.
 
    Example:
-     wt remove wt-abc123`,
+     wt remove wt-abc123
+     wt rm .`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "Discard uncommitted changes and ignore locks"},
+			&cli.BoolFlag{Name: "archive", Usage: "Keep the scratch directory in ~/.wt/archive"},
+		},
 		Action: func(c *cli.Context) error {
-			if c.NArg() < 1 {
-				return fmt.Errorf("please provide a task ID (see 'wt list')")
-			}
 			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			inside := insideWorktree(cfg, t)
 			mgr := task.NewManager(cfg)
-			t, err := mgr.Remove(c.Args().First())
+			mgr.Force = c.Bool("force")
+			mgr.Archive = c.Bool("archive")
+			t, err = mgr.Remove(c.Context, t.ID)
 			if err != nil {
 				return err
 			}
-			fmt.Printf("✅ Worktree removed: %s\n", t.Worktree)
+			if t.State == config.StateBranchOnly {
+				fmt.Printf("✅ Task removed: %s (it had no worktree yet)\n", t.ID)
+			} else {
+				fmt.Printf("✅ Worktree removed: %s\n", t.Worktree)
+			}
 			fmt.Printf("   Branch kept: %s\n", t.Branch)
+			printArchived(t)
+			printLeaveHint(t, inside)
 			return nil
 		},
 	}
 }
 
+// printLeaveHint tells the user how to leave a removed worktree their
+// shell is still in.
+func printLeaveHint(t *config.Task, inside bool) {
+	if inside {
+		fmt.Printf("   Your shell is still in the removed worktree; run: cd %s\n", t.RepoPath)
+	}
+}
+
+// printArchived reports a task's archive directory, if anything was
+// archived, and how to restore a bundled branch.
+func printArchived(t *config.Task) {
+	dir, err := task.ArchiveDir(t.ID)
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+	fmt.Printf("   Archived to: %s\n", dir)
+	bundle := filepath.Join(dir, task.BundleFile)
+	if _, err := os.Stat(bundle); err == nil {
+		fmt.Printf("   Restore the branch with: git fetch %s %s:%s\n", bundle, t.Branch, t.Branch)
+	}
+}
+
 // --- switch ---
 func switchCmd() *cli.Command {
 	return &cli.Command{
 		Name:      "switch",
 		Category:  "navigation",
 		Usage:     "Print the path to a task's worktree (use with cd)",
-		ArgsUsage: "<task-id>",
+		ArgsUsage: "<task-id|->",
 		Description: `Print the absolute path to a task's worktree directory.
 
    Designed to be used with command substitution to change directories:
      cd $(wt switch wt-abc123)
 
+   Use "-" to jump back to the previously active task, like 'cd -'.
+
+   With --json (or -o json), prints {"path", "branch", "task"} instead, for
+   editor plugins and shell frameworks.
+
+   For a worktree on another machine (see 'wt --host'), prints the ssh
+   command that opens a shell in it, or with --vscode, the URI that opens it
+   in VS Code with the Remote - SSH extension. For a task worked on in a
+   Devpod or Codespace, prints the command that opens a shell there. JSON
+   output adds "host" or "workspace", "command" and "uri".
+
    Example:
      wt switch wt-abc123              # Prints path only
-     cd $(wt switch wt-abc123)        # Change to task worktree`,
+     cd $(wt switch wt-abc123)        # Change to task worktree
+     cd $(wt switch -)                # Back to the previous task
+     wt switch --json wt-abc123       # {"path":"...","branch":"...","task":"wt-abc123"}
+     code --open-url "$(wt switch --vscode wt-abc123)"`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "json", Usage: "Print the path, branch and task ID as JSON"},
+			&cli.BoolFlag{Name: "vscode", Usage: "For a worktree on another machine, print a VS Code Remote URI instead of an ssh command"},
+			outputFlag(),
+		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 1 {
 				return fmt.Errorf("please provide a task ID (see 'wt list')")
 			}
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			stdout := pathOnlyStdout()
+			defer restoreStdout(stdout)
 			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
-			t, err := cfg.FindTask(c.Args().First())
+			var t *config.Task
+			if id := c.Args().First(); id == "-" {
+				t, err = previousTask(cfg)
+			} else {
+				t, err = cfg.FindTask(id)
+			}
 			if err != nil {
 				return err
 			}
-			// Print just the path so it can be used with: cd $(wt switch <id>)
-			fmt.Print(t.Worktree)
+			if t, err = materialize(c.Context, cfg, t); err != nil {
+				return err
+			}
+			if err := cfg.TouchTask(t.ID); err != nil {
+				return err
+			}
+			warnIfNotReady(t)
+			setTitle(cfg, t)
+			result := newSwitchResult(t, c.Bool("vscode"))
+			return f.Write(stdout, result, func(w io.Writer) error {
+				// Print just the path so it can be used with: cd $(wt switch <id>)
+				_, err := fmt.Fprint(w, result.target())
+				return err
+			})
+		},
+	}
+}
+
+// pathOnlyStdout sends everything written to os.Stdout to stderr until
+// restoreStdout, and returns the real stdout, so that commands used as
+// cd $(wt switch ...) print nothing but their result there, whatever
+// warnings the code they run prints.
+func pathOnlyStdout() *os.File {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return stdout
+}
+
+func restoreStdout(stdout *os.File) {
+	os.Stdout = stdout
+}
+
+// switchResult is what 'wt switch' prints with --json.
+type switchResult struct {
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+	Task   string `json:"task"`
+	// Host, Command and URI are set for a worktree on another machine,
+	// Workspace and Command for a task worked on in a Devpod or Codespace.
+	Host      string `json:"host,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	Command   string `json:"command,omitempty"`
+	URI       string `json:"uri,omitempty"`
+}
+
+// previousTask returns the most recently used task other than the current one.
+// The current task is the one whose worktree contains the working directory
+// or named by WT_TASK_ID (see currentTask), or else the most recently used
+// task.
+func previousTask(cfg *config.Config) (*config.Task, error) {
+	recent := cfg.RecentTasks()
+	current := ""
+	if t, err := currentTask(cfg); err == nil {
+		current = t.ID
+	}
+	if current == "" && len(recent) > 0 {
+		current = recent[0].ID
+	}
+	for _, t := range recent {
+		if t.ID != current {
+			return cfg.FindTask(t.ID)
+		}
+	}
+	return nil, fmt.Errorf("no previous task to switch to")
+}
+
+// --- last ---
+func lastCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "last",
+		Category: "navigation",
+		Usage:    "Print the path to the most recently used task's worktree",
+		Description: `Print the worktree path of the most recently used task.
+
+   Example:
+     cd $(wt last)`,
+		Action: func(c *cli.Context) error {
+			stdout := pathOnlyStdout()
+			defer restoreStdout(stdout)
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			recent := cfg.RecentTasks()
+			if len(recent) == 0 {
+				return fmt.Errorf("no active tasks")
+			}
+			fmt.Fprint(stdout, newSwitchResult(&recent[0], false).target())
 			return nil
 		},
 	}
@@ -490,39 +1736,163 @@ func statusCmd() *cli.Command {
 		Usage:    "Show status of the current worktree task",
 		Description: `Display detailed information about the task in the current directory.
 
-   Shows task ID, description, branch, worktree path, creation time, and ticket info.
-   Only works when run from inside a wt-managed worktree directory.
+   Shows task ID, description, branch, worktree path, creation time, and ticket info,
+   the worktree's uncommitted files, commits ahead of and behind its upstream and
+   the default branch, the last commit, the agent last launched, the ticket's live
+   status (cached like 'wt list --tickets') and the branch's pull request (when the
+   GitHub CLI 'gh' is installed), followed by ticket changes detected by
+   'wt list --tickets' since the last look. --offline skips the ticket and pull
+   request lookups.
+   Only works when run from inside a wt-managed worktree directory, or by
+   an agent wt launched (which gets WT_TASK_ID).
+
+   Use --set to record a local workflow status (e.g. "review") that
+   'wt sync --two-way' can act on through sync_rules.
 
    Example:
      cd ~/worktrees/myrepo/feature-branch
-     wt status`,
+     wt status
+     wt status --set review
+     wt status --offline
+     wt status -o json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "set", Usage: "Set the local workflow status of the task"},
+			&cli.BoolFlag{Name: "offline", Usage: "Skip the live ticket and pull request lookups"},
+			outputFlag(),
+		},
 		Action: func(c *cli.Context) error {
-			cfg, err := loadConfig()
+			f, err := formatter(c)
 			if err != nil {
 				return err
 			}
-			cwd, err := os.Getwd()
+			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
-			t, err := cfg.FindTaskByWorktree(cwd)
+			t, err := currentTask(cfg)
 			if err != nil {
+				if os.Getenv("WT_TASK_ID") != "" {
+					return err
+				}
+				if !f.IsTable() {
+					return fmt.Errorf("not inside a wt-managed worktree")
+				}
 				fmt.Println("Not inside a wt-managed worktree.")
 				return nil
 			}
-			fmt.Printf("Task:      %s\n", t.ID)
-			fmt.Printf("Desc:      %s\n", t.Description)
-			fmt.Printf("Branch:    %s\n", t.Branch)
-			fmt.Printf("Worktree:  %s\n", t.Worktree)
-			fmt.Printf("Created:   %s\n", t.Created.Format("2006-01-02 15:04"))
+			if c.IsSet("set") {
+				if err := cfg.SetTaskStatus(t.ID, c.String("set")); err != nil {
+					return err
+				}
+			}
+
+			var alerts *connector.Alerts
+			var ref connector.Ref
+			status := taskStatus{Task: *t}
+			enrichStatus(c.Context, cfg, &status, c.Bool("offline"))
 			if t.TicketKey != "" {
-				fmt.Printf("Ticket:    %s (%s)\n", t.TicketKey, t.Connector)
+				ref = connector.Ref{Connector: t.Connector, Key: t.TicketKey}
+				if path, err := cachePath("alerts.json"); err == nil {
+					alerts = connector.LoadAlerts(path)
+					status.Changes = alerts.Pending(ref)
+				}
 			}
-			return nil
+			return f.Write(os.Stdout, status, func(out io.Writer) error {
+				printTaskStatus(out, status)
+				if len(status.Changes) > 0 {
+					// Changes shown to a person are marked as read.
+					alerts.Clear(ref)
+					if err := alerts.Save(); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					}
+				}
+				return nil
+			})
 		},
 	}
 }
 
+// taskStatus is the output of 'wt status': the task, the state of its
+// worktree, ticket and pull request, and the unread changes to its ticket
+// recorded by 'wt list --tickets'.
+type taskStatus struct {
+	config.Task
+	Git          *gitStatus         `json:"git,omitempty"`
+	TicketStatus string             `json:"ticket_status,omitempty"`
+	PullRequest  *pullRequest       `json:"pull_request,omitempty"`
+	AgentStatus  string             `json:"agent_status"`
+	Changes      []connector.Change `json:"changes,omitempty"`
+}
+
+func printTaskStatus(out io.Writer, s taskStatus) {
+	fmt.Fprintf(out, "Task:      %s\n", s.ID)
+	fmt.Fprintf(out, "Desc:      %s\n", s.Description)
+	fmt.Fprintf(out, "Branch:    %s\n", s.Branch)
+	fmt.Fprintf(out, "Worktree:  %s\n", s.Worktree)
+	if s.Workspace != "" {
+		ws := s.Workspace + " " + s.WorkspaceID
+		if s.WorkspaceID == "" {
+			ws = s.Workspace + " (not created; run 'wt up')"
+		}
+		fmt.Fprintf(out, "Workspace: %s\n", ws)
+	}
+	fmt.Fprintf(out, "Created:   %s\n", s.Created.Format("2006-01-02 15:04"))
+	if s.TicketKey != "" {
+		ticket := fmt.Sprintf("%s (%s)", s.TicketKey, s.Connector)
+		if s.TicketStatus != "" {
+			ticket += "  " + s.TicketStatus
+		}
+		fmt.Fprintf(out, "Ticket:    %s\n", ticket)
+	}
+	if s.Status != "" {
+		fmt.Fprintf(out, "Status:    %s\n", s.Status)
+	}
+	if s.Lock != nil {
+		fmt.Fprintf(out, "Locked by: %s\n", s.Lock)
+	}
+	if g := s.Git; g != nil {
+		changes := "clean"
+		if g.Changed > 0 {
+			changes = fmt.Sprintf("%d changed file(s)", g.Changed)
+		}
+		if g.HasUpstream {
+			changes += fmt.Sprintf(", %d ahead, %d behind upstream", g.Ahead, g.Behind)
+		} else {
+			changes += ", no upstream"
+		}
+		fmt.Fprintf(out, "Git:       %s\n", changes)
+		if g.Base != "" {
+			base := fmt.Sprintf("%d ahead, %d behind %s", g.BaseAhead, g.BaseBehind, g.Base)
+			if g.NeedsRebase {
+				base += " (needs rebase)"
+			}
+			fmt.Fprintf(out, "Base:      %s\n", base)
+		}
+		if c := g.LastCommit; c != nil {
+			fmt.Fprintf(out, "Commit:    %s %s (%s, %s)\n", c.Hash, c.Subject, c.Author, timeAgo(c.Time))
+		}
+	}
+	if s.Test != nil {
+		fmt.Fprintf(out, "Tests:     %s at %s (took %s)\n", testSummary(s.Test), s.Test.Commit, s.Test.Duration)
+	}
+	if s.PullRequest != nil {
+		fmt.Fprintf(out, "PR:        %s\n", s.PullRequest)
+	}
+	fmt.Fprintf(out, "Agent:     %s\n", s.AgentStatus)
+	if agent.IsRunning(s.WebPID) {
+		fmt.Fprintf(out, "Web:       %s\n", task.WebURL(&s.Task))
+	}
+	if s.Notes != "" {
+		fmt.Fprintf(out, "\nNotes:\n%s\n", s.Notes)
+	}
+	if len(s.Changes) > 0 {
+		fmt.Fprintln(out, "\nChanges:")
+	}
+	for _, ch := range s.Changes {
+		fmt.Fprintf(out, "  %s  %s\n", ch.Time.Format("2006-01-02 15:04"), ch)
+	}
+}
+
 // --- connect ---
 func connectCmd() *cli.Command {
 	return &cli.Command{
@@ -532,43 +1902,318 @@ func connectCmd() *cli.Command {
 		ArgsUsage: "<connector-name>",
 		Description: `Configure integration with external task management systems.
 
-   Currently supports Jira with planned support for Monday.com and ClickUp.
-   Once configured, use 'wt start --jira <KEY>' to create worktrees from tickets.
+   Currently supports Jira, GitLab Issues and Basecamp with planned support for
+   Monday.com and ClickUp, errors from Sentry, and incidents from PagerDuty and Opsgenie.
+   Once configured, use 'wt start --jira <KEY>' or
+   'wt start --connector <name> --ticket <KEY>' to create worktrees from tickets,
+   and 'wt start --incident <ID>' to start hotfixes from incidents.
+
+   Examples:
+     wt connect jira --url https://company.atlassian.net --email user@company.com --token TOKEN
+     wt connect basecamp --client-id ID --client-secret SECRET
+     wt connect gitlab --token TOKEN --project acme/backend
+     wt connect sentry --token TOKEN --org my-org
+     wt connect pagerduty --token TOKEN --email user@company.com
+     wt connect opsgenie --token API_KEY
+     wt connect inbox --url imaps://imap.example.com/INBOX --user me --password APP_PASSWORD`,
+		Subcommands: []*cli.Command{
+			{
+				Name:  "jira",
+				Usage: "Configure Jira integration",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "url", Usage: "Jira base URL (e.g. https://yourco.atlassian.net)", Required: true},
+					&cli.StringFlag{Name: "email", Usage: "Your Jira email address (omit for a Server/DC personal access token)"},
+					&cli.StringFlag{Name: "token", Usage: "Jira API token or personal access token", Required: true},
+					&cli.StringFlag{Name: "project", Usage: "Default Jira project key"},
+					&cli.StringFlag{Name: "api-version", Usage: "REST API version: 3 (Cloud) or 2 (Server/DC); detected when omitted"},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					client := jira.New(c.String("url"), c.String("email"), c.String("token"))
+					switch v := c.String("api-version"); v {
+					case jira.APIv2, jira.APIv3:
+						client.APIVersion = v
+					case "":
+						v, err := client.DetectAPIVersion(c.Context)
+						if err != nil {
+							return fmt.Errorf("failed to detect jira deployment (use --api-version): %w", err)
+						}
+						client.APIVersion = v
+					default:
+						return fmt.Errorf("invalid --api-version %q (must be 2 or 3)", v)
+					}
+					fmt.Printf("Using Jira REST API v%s.\n", client.APIVersion)
+					fmt.Print("Validating Jira credentials... ")
+					if err := client.Validate(c.Context); err != nil {
+						fmt.Println("❌")
+						return fmt.Errorf("validation failed: %w", err)
+					}
+					fmt.Println("✅")
+
+					if err := cfg.SetConnector("jira", config.ConnectorConfig{
+						URL:        c.String("url"),
+						Email:      c.String("email"),
+						APIToken:   c.String("token"),
+						Project:    c.String("project"),
+						APIVersion: client.APIVersion,
+					}); err != nil {
+						return err
+					}
+					fmt.Println("Jira connector configured successfully.")
+					return nil
+				},
+			},
+			{
+				Name:  "inbox",
+				Usage: "Configure email intake from an IMAP or JMAP mailbox",
+				Description: `Turn flagged emails into tickets.
+
+   For IMAP, pass --url imaps://imap.example.com/INBOX with --user and --password
+   (an app password is recommended). For JMAP, pass the session URL, e.g.
+   --url https://api.fastmail.com/jmap/session, and an API token as --password.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "url", Usage: "imaps://host[:port]/mailbox or a JMAP session URL", Required: true},
+					&cli.StringFlag{Name: "user", Usage: "IMAP username"},
+					&cli.StringFlag{Name: "password", Usage: "IMAP password or JMAP API token", Required: true},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					client, err := inbox.New(c.String("url"), c.String("user"), c.String("password"))
+					if err != nil {
+						return err
+					}
+					fmt.Print("Validating mailbox credentials... ")
+					if err := client.Validate(c.Context); err != nil {
+						fmt.Println("❌")
+						return fmt.Errorf("validation failed: %w", err)
+					}
+					fmt.Println("✅")
+
+					if err := cfg.SetConnector("inbox", config.ConnectorConfig{
+						URL:      c.String("url"),
+						Email:    c.String("user"),
+						APIToken: c.String("password"),
+					}); err != nil {
+						return err
+					}
+					fmt.Println("Inbox connector configured successfully. Run 'wt inbox' to list flagged emails.")
+					return nil
+				},
+			},
+			{
+				Name:  "gitlab",
+				Usage: "Configure GitLab Issues",
+				Description: `Start tasks from GitLab issues with 'wt start --gitlab <project>#<iid>',
+   e.g. acme/backend#12, and list the open issues assigned to you with
+   'wt sync --connector gitlab'.
+
+   Create a personal access token with the api scope (read_api is enough
+   if wt never closes issues). Self-hosted GitLab passes its own --url.
+   With --project, "#12" refers to an issue of that project.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "token", Usage: "GitLab personal, group or project access token", Required: true},
+					&cli.StringFlag{Name: "url", Usage: "GitLab URL", Value: gitlab.DefaultURL},
+					&cli.StringFlag{Name: "project", Usage: "Default project path (e.g. acme/backend)"},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					client := gitlab.New(c.String("url"), c.String("token"))
+					client.Project = c.String("project")
+					fmt.Print("Validating GitLab credentials... ")
+					if err := client.Validate(c.Context); err != nil {
+						fmt.Println("❌")
+						return fmt.Errorf("validation failed: %w", err)
+					}
+					fmt.Println("✅")
+
+					if err := cfg.SetConnector("gitlab", config.ConnectorConfig{
+						URL:      c.String("url"),
+						APIToken: c.String("token"),
+						Project:  c.String("project"),
+					}); err != nil {
+						return err
+					}
+					fmt.Println("GitLab connector configured successfully.")
+					return nil
+				},
+			},
+			{
+				Name:  "sentry",
+				Usage: "Configure Sentry issues",
+				Description: `Start fixes from Sentry issues with 'wt start --sentry <ISSUE-ID>'.
+
+   Create an auth token with the event:read and event:write scopes (the
+   latter to resolve issues) in Sentry's settings. Self-hosted Sentry passes
+   its own --url.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "token", Usage: "Sentry auth token", Required: true},
+					&cli.StringFlag{Name: "org", Usage: "Sentry organization slug", Required: true},
+					&cli.StringFlag{Name: "url", Usage: "Sentry URL", Value: sentry.DefaultURL},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					fmt.Print("Validating Sentry credentials... ")
+					if err := sentry.New(c.String("url"), c.String("token"), c.String("org")).Validate(c.Context); err != nil {
+						fmt.Println("❌")
+						return fmt.Errorf("validation failed: %w", err)
+					}
+					fmt.Println("✅")
 
-   Example:
-     wt connect jira --url https://company.atlassian.net --email user@company.com --token TOKEN`,
-		Subcommands: []*cli.Command{
+					if err := cfg.SetConnector("sentry", config.ConnectorConfig{
+						URL:          c.String("url"),
+						APIToken:     c.String("token"),
+						Organization: c.String("org"),
+					}); err != nil {
+						return err
+					}
+					fmt.Println("Sentry connector configured successfully.")
+					return nil
+				},
+			},
 			{
-				Name:  "jira",
-				Usage: "Configure Jira integration",
+				Name:  "pagerduty",
+				Usage: "Configure PagerDuty incidents",
+				Description: `Start hotfixes from PagerDuty incidents with 'wt start --incident <ID>'.
+
+   Pass a user API token, or an account API key with --email: PagerDuty
+   needs a user to act as to acknowledge or resolve incidents, and 'wt sync'
+   lists the incidents assigned to that user.`,
 				Flags: []cli.Flag{
-					&cli.StringFlag{Name: "url", Usage: "Jira base URL (e.g. https://yourco.atlassian.net)", Required: true},
-					&cli.StringFlag{Name: "email", Usage: "Your Jira email address", Required: true},
-					&cli.StringFlag{Name: "token", Usage: "Jira API token", Required: true},
-					&cli.StringFlag{Name: "project", Usage: "Default Jira project key"},
+					&cli.StringFlag{Name: "token", Usage: "PagerDuty user API token or account API key", Required: true},
+					&cli.StringFlag{Name: "email", Usage: "Your PagerDuty login email (required with an account API key)"},
+					&cli.StringFlag{Name: "url", Usage: "PagerDuty API URL", Value: incident.PagerDutyURL},
 				},
 				Action: func(c *cli.Context) error {
 					cfg, err := loadConfig()
 					if err != nil {
 						return err
 					}
-					client := jira.New(c.String("url"), c.String("email"), c.String("token"))
-					fmt.Print("Validating Jira credentials... ")
-					if err := client.Validate(context.Background()); err != nil {
+					fmt.Print("Validating PagerDuty credentials... ")
+					if err := incident.NewPagerDuty(c.String("url"), c.String("token"), c.String("email")).Validate(c.Context); err != nil {
 						fmt.Println("❌")
 						return fmt.Errorf("validation failed: %w", err)
 					}
 					fmt.Println("✅")
 
-					if err := cfg.SetConnector("jira", config.ConnectorConfig{
+					if err := cfg.SetConnector("pagerduty", config.ConnectorConfig{
 						URL:      c.String("url"),
 						Email:    c.String("email"),
 						APIToken: c.String("token"),
-						Project:  c.String("project"),
 					}); err != nil {
 						return err
 					}
-					fmt.Println("Jira connector configured successfully.")
+					fmt.Println("PagerDuty connector configured successfully.")
+					return nil
+				},
+			},
+			{
+				Name:  "opsgenie",
+				Usage: "Configure Opsgenie incidents",
+				Description: `Start hotfixes from Opsgenie incidents with 'wt start --incident <ID>',
+   by tiny ID (e.g. 42) or full ID. Accounts in the EU pass
+   --url https://api.eu.opsgenie.com.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "token", Usage: "Opsgenie API key", Required: true},
+					&cli.StringFlag{Name: "url", Usage: "Opsgenie API URL", Value: incident.OpsgenieURL},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					fmt.Print("Validating Opsgenie credentials... ")
+					if err := incident.NewOpsgenie(c.String("url"), c.String("token")).Validate(c.Context); err != nil {
+						fmt.Println("❌")
+						return fmt.Errorf("validation failed: %w", err)
+					}
+					fmt.Println("✅")
+
+					if err := cfg.SetConnector("opsgenie", config.ConnectorConfig{
+						URL:      c.String("url"),
+						APIToken: c.String("token"),
+					}); err != nil {
+						return err
+					}
+					fmt.Println("Opsgenie connector configured successfully.")
+					return nil
+				},
+			},
+			{
+				Name:  "basecamp",
+				Usage: "Configure Basecamp integration (OAuth)",
+				Description: `Authorize wt against Basecamp using the OAuth web flow.
+
+   Register an integration at https://launchpad.37signals.com/integrations with
+   the redirect URI http://localhost:8976/callback (or pass --redirect-uri),
+   then run this command and open the printed URL in your browser.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "client-id", Usage: "Basecamp integration client ID", Required: true},
+					&cli.StringFlag{Name: "client-secret", Usage: "Basecamp integration client secret", Required: true},
+					&cli.StringFlag{Name: "redirect-uri", Usage: "Redirect URI registered for the integration", Value: basecamp.DefaultRedirectURI},
+					&cli.Int64Flag{Name: "account", Usage: "Basecamp account ID (default: first account)"},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					oauth := basecamp.OAuthConfig{
+						ClientID:     c.String("client-id"),
+						ClientSecret: c.String("client-secret"),
+						RedirectURI:  c.String("redirect-uri"),
+					}
+					token, err := oauth.Authorize(c.Context, os.Stdout)
+					if err != nil {
+						return err
+					}
+
+					accounts, err := basecamp.Accounts(c.Context, http.DefaultClient, token.AccessToken)
+					if err != nil {
+						return err
+					}
+					var account *basecamp.Account
+					for i, a := range accounts {
+						if c.Int64("account") == 0 || a.ID == c.Int64("account") {
+							account = &accounts[i]
+							break
+						}
+					}
+					if account == nil {
+						return fmt.Errorf("no matching Basecamp account found for this login")
+					}
+
+					cc := config.ConnectorConfig{
+						URL:          fmt.Sprintf("%s/%d", basecamp.APIBase, account.ID),
+						APIToken:     token.AccessToken,
+						RefreshToken: token.RefreshToken,
+						TokenExpiry:  token.Expiry,
+						ClientID:     oauth.ClientID,
+						ClientSecret: oauth.ClientSecret,
+						RedirectURI:  oauth.RedirectURI,
+					}
+					fmt.Print("Validating Basecamp credentials... ")
+					if err := newBasecamp(cfg, cc).Validate(c.Context); err != nil {
+						fmt.Println("❌")
+						return fmt.Errorf("validation failed: %w", err)
+					}
+					fmt.Println("✅")
+
+					if err := cfg.SetConnector("basecamp", cc); err != nil {
+						return err
+					}
+					fmt.Printf("Basecamp connector configured for %s.\n", account.Name)
 					return nil
 				},
 			},
@@ -587,44 +2232,219 @@ func syncCmd() *cli.Command {
    Shows ticket key, summary, and current status. Requires a configured connector.
    Use 'wt connect' first to set up integration with Jira, Monday.com, or ClickUp.
 
+   Choose columns with --columns (or the sync_columns config key) from:
+   key, summary, status, assignee, priority, type, updated, due, epic, parent,
+   labels. Sort with --sort (or sync_sort) on any of these; prefix with "-"
+   for descending order. Priorities sort by urgency.
+
+   With --two-way, compares every task's status with its ticket's status and
+   reconciles them using the sync_rules in the config file (default: finish
+   tasks whose ticket is Done). Use --dry-run to preview the changes.
+
    Examples:
      wt sync                    # Defaults to jira
-     wt sync --connector jira   # Explicit connector`,
+     wt sync --connector jira   # Explicit connector
+     wt sync --group-by epic    # Group tickets under their epics
+     wt sync --columns key,priority,summary,due --sort due
+     wt sync --sort -updated    # Most recently updated first
+     wt sync --assignee ana@company.com
+     wt sync --unassigned       # Open tickets nobody has picked up
+     wt sync --two-way --dry-run
+     wt sync -o json            # Machine-readable tickets`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{Name: "connector", Aliases: []string{"c"}, Value: "jira", Usage: "Connector to sync from"},
+			&cli.StringFlag{Name: "group-by", Usage: "Group tickets: epic"},
+			&cli.StringFlag{Name: "columns", Usage: "Comma-separated ticket fields to show"},
+			&cli.StringFlag{Name: "sort", Usage: "Ticket field to sort by, \"-\" prefix for descending"},
+			&cli.StringFlag{Name: "assignee", Usage: "List tickets assigned to someone else (email or user name)"},
+			&cli.BoolFlag{Name: "unassigned", Usage: "List open tickets that are not assigned to anyone"},
+			&cli.BoolFlag{Name: "two-way", Usage: "Reconcile task and ticket statuses using sync_rules"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "With --two-way, only show what would change"},
+			&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "With --two-way, finish or remove tasks without asking"},
+			outputFlag(),
 		},
 		Action: func(c *cli.Context) error {
 			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
+			if c.Bool("two-way") {
+				return twoWaySync(c, cfg)
+			}
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			columns := cfg.SyncColumns
+			if c.IsSet("columns") {
+				columns = splitList(c.String("columns"))
+			}
+			if err := connector.ValidateTicketFields(columns); err != nil {
+				return err
+			}
+			sortBy := cfg.SyncSort
+			if c.IsSet("sort") {
+				sortBy = c.String("sort")
+			}
 			reg := buildRegistry(cfg)
 			name := c.String("connector")
-			conn, ok := reg.Get(name)
-			if !ok {
-				return fmt.Errorf("connector %q not found; available: %v", name, reg.List())
+			conn, err := reg.Lookup(name)
+			if err != nil {
+				return err
 			}
 
-			fmt.Printf("Syncing from %s...\n", name)
-			tickets, err := conn.ListAssigned(context.Background())
+			var tickets []connector.Ticket
+			if c.IsSet("assignee") || c.Bool("unassigned") {
+				if c.IsSet("assignee") && c.Bool("unassigned") {
+					return fmt.Errorf("--assignee and --unassigned cannot be combined")
+				}
+				if c.IsSet("assignee") && c.String("assignee") == "" {
+					return fmt.Errorf("--assignee requires a value; use --unassigned for unassigned tickets")
+				}
+				lister, ok := conn.(connector.AssigneeLister)
+				if !ok {
+					return fmt.Errorf("connector %q does not support --assignee or --unassigned", name)
+				}
+				if f.IsTable() {
+					fmt.Printf("Syncing from %s...\n", name)
+				}
+				tickets, err = lister.ListAssignedTo(c.Context, c.String("assignee"))
+			} else {
+				if f.IsTable() {
+					fmt.Printf("Syncing from %s...\n", name)
+				}
+				tickets, err = conn.ListAssigned(c.Context)
+			}
 			if err != nil {
 				return err
 			}
-			if len(tickets) == 0 {
-				fmt.Println("No assigned tickets found.")
-				return nil
+			if sortBy != "" {
+				if err := connector.SortTickets(tickets, sortBy); err != nil {
+					return err
+				}
 			}
-
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "KEY\tSUMMARY\tSTATUS")
-			for _, t := range tickets {
-				fmt.Fprintf(w, "%s\t%s\t%s\n", t.Key, truncate(t.Summary, 50), t.Status)
+			groupBy := c.String("group-by")
+			if groupBy != "" && groupBy != "epic" {
+				return fmt.Errorf("invalid --group-by %q (must be epic)", groupBy)
 			}
-			return w.Flush()
+
+			return f.Write(os.Stdout, tickets, func(out io.Writer) error {
+				if len(tickets) == 0 {
+					fmt.Fprintln(out, "No assigned tickets found.")
+					return nil
+				}
+				if groupBy == "epic" {
+					return printTicketsByEpic(out, tickets, columns)
+				}
+				return printTickets(out, tickets, columns)
+			})
 		},
 	}
 }
 
+// printTickets writes a ticket table with the given columns. Without
+// columns, it shows key, summary and status, plus an EPIC column when any
+// ticket belongs to an epic.
+func printTickets(out io.Writer, tickets []connector.Ticket, columns []string) error {
+	if len(columns) == 0 {
+		columns = connector.DefaultTicketColumns
+		for _, t := range tickets {
+			if t.EpicKey != "" {
+				columns = append(append([]string(nil), columns...), "epic")
+				break
+			}
+		}
+	}
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, t := range tickets {
+		fmt.Fprintln(w, strings.Join(ticketRow(t, columns), "\t"))
+	}
+	return w.Flush()
+}
+
+func ticketRow(t connector.Ticket, columns []string) []string {
+	row := make([]string, len(columns))
+	for i, col := range columns {
+		row[i] = connector.FieldValue(t, col)
+		if col == "summary" {
+			row[i] = output.Truncate(row[i], 50)
+		}
+	}
+	return row
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// configPath expands a path given to 'wt config' and makes it absolute,
+// relative to the current directory, so that the stored value means the
+// same wherever wt runs later.
+func configPath(value string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return config.AbsPath(value, cwd)
+}
+
+// configDir is configPath for a directory, which need not exist yet.
+func configDir(value string) (string, error) {
+	path, err := configPath(value)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	return path, nil
+}
+
+// printTicketsByEpic writes tickets grouped under their epics, in order of
+// first appearance, followed by tickets without an epic.
+func printTicketsByEpic(out io.Writer, tickets []connector.Ticket, columns []string) error {
+	if len(columns) == 0 {
+		columns = connector.DefaultTicketColumns
+	}
+	var order []string
+	groups := make(map[string][]connector.Ticket)
+	names := make(map[string]string)
+	for _, t := range tickets {
+		if _, ok := groups[t.EpicKey]; !ok && t.EpicKey != "" {
+			order = append(order, t.EpicKey)
+		}
+		groups[t.EpicKey] = append(groups[t.EpicKey], t)
+		names[t.EpicKey] = t.EpicName
+	}
+	if len(groups[""]) > 0 {
+		order = append(order, "")
+	}
+
+	w := output.NewTabWriter(out)
+	for i, epic := range order {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if epic == "" {
+			fmt.Fprintln(w, "No epic")
+		} else {
+			fmt.Fprintf(w, "%s %s\n", epic, names[epic])
+		}
+		for _, t := range groups[epic] {
+			fmt.Fprintf(w, "  %s\n", strings.Join(ticketRow(t, columns), "\t"))
+		}
+	}
+	return w.Flush()
+}
+
 // --- config ---
 func configCmd() *cli.Command {
 	return &cli.Command{
@@ -637,10 +2457,39 @@ func configCmd() *cli.Command {
    Configuration is stored in ~/.wt/config.yaml.
 
    Available keys:
-     worktrees_base  - Base directory for worktrees (default: ~/worktrees)
-     default_branch  - Main branch name (default: main)
+     worktrees_base  - Base directory for worktrees (default: ~/worktrees);
+                       offers to move existing worktrees when changed
+     default_branch  - Main branch name (default: detected per repository from
+                       origin/HEAD, the remote, or a local main/master/trunk/develop)
      branch_prefix   - Prefix for new branches (default: feature)
+     branch_template - Go template for branch names, e.g.
+                       {{.Prefix}}/{{with .Epic}}{{.}}/{{end}}{{.Key}}-{{.Summary}}
+     branch_max_length - Maximum length of the description part of branch names (default: 60)
+     branch_stopwords  - Drop filler words like "the" and "of" from branch names (true/false)
+     hotfix_prefix   - Prefix for branches of tasks started from incidents (default: hotfix)
      default_agent   - Default AI agent to launch
+     agent_env_allow - Comma-separated variables agents inherit (NAME or PREFIX*); empty passes everything
+     agent_sandbox   - Wrap agents in firejail, sandbox-exec or docker (default: none)
+     agent_sandbox_image - Container image for the docker agent sandbox
+     agent_sandbox_paths - Comma-separated extra paths sandboxed agents may write to
+     agent_confine   - Keep agents to the task's worktree and scratch directory (true/false)
+     terminal_title  - Set terminal/tmux title on switch and agent launch (true/false)
+     git_backend     - Backend for read-only git queries: git (default) or go-git
+     git_timeout     - Maximum duration of a single git command, e.g. 2m (default: none)
+     ticket_rate_limit - Requests per second per connector for 'wt list --tickets' (default: 5)
+     ticket_cache_ttl  - How long fetched ticket statuses are reused (default: 5m)
+     start_check       - When 'wt start' runs from a dirty or mid-rebase checkout: warn (default), block or off
+     finish_checks     - Comma-separated checks 'wt finish' requires: clean, tests, rebased or custom ones (default: tests)
+     delete_remote_branch - Whether 'wt finish' deletes the branch on origin: never (default), merged or always
+     rebase_threshold  - Commits the base branch may gain before a task needs a rebase (default: 50, -1 to disable)
+     web_command     - Web editor 'wt up --web' runs; {port}, {dir} and {id} are replaced (default: code-server)
+     web_port_base   - First port allocated to tasks and their web editors (default: 8100)
+     sync_columns    - Comma-separated ticket fields shown by 'wt sync' (default: key,summary,status)
+     sync_sort       - Ticket field 'wt sync' sorts by, "-" prefix for descending
+     telemetry       - Record command usage and durations locally (true/false, default: false)
+     telemetry_url   - Endpoint that recorded usage is uploaded to once a day (default: none)
+     defaults_url    - https URL of org-wide defaults, applied beneath this config and
+                       fetched again once a day (default: none)
 
    Examples:
      wt config                              # Show all settings
@@ -653,11 +2502,38 @@ func configCmd() *cli.Command {
 			}
 			if c.NArg() == 0 {
 				fmt.Printf("worktrees_base: %s\n", cfg.WorktreesBase)
-				fmt.Printf("default_branch: %s\n", cfg.DefaultBranch)
+				if cfg.DefaultBranch != "" {
+					fmt.Printf("default_branch: %s\n", cfg.DefaultBranch)
+				} else {
+					fmt.Printf("default_branch: (detected per repository)\n")
+				}
 				fmt.Printf("branch_prefix:  %s\n", cfg.BranchPrefix)
+				if cfg.BranchTemplate != "" {
+					fmt.Printf("branch_template: %s\n", cfg.BranchTemplate)
+				}
 				if cfg.DefaultAgent != "" {
 					fmt.Printf("default_agent:  %s\n", cfg.DefaultAgent)
 				}
+				if len(cfg.AgentEnvAllow) > 0 {
+					fmt.Printf("agent_env_allow: %s\n", strings.Join(cfg.AgentEnvAllow, ","))
+				}
+				if cfg.AgentSandbox != "" {
+					fmt.Printf("agent_sandbox:  %s\n", cfg.AgentSandbox)
+				}
+				if cfg.AgentConfine {
+					fmt.Printf("agent_confine:  %t\n", cfg.AgentConfine)
+				}
+				fmt.Printf("terminal_title: %t\n", cfg.TerminalTitle)
+				fmt.Printf("telemetry:      %t\n", cfg.Telemetry)
+				if cfg.DefaultsURL != "" {
+					fmt.Printf("defaults_url:   %s\n", cfg.DefaultsURL)
+				}
+				if cfg.GitBackend != "" {
+					fmt.Printf("git_backend:    %s\n", cfg.GitBackend)
+				}
+				if cfg.GitTimeout > 0 {
+					fmt.Printf("git_timeout:    %s\n", cfg.GitTimeout)
+				}
 				if len(cfg.AgentAliases) > 0 {
 					fmt.Printf("agent_aliases:\n")
 					for k, v := range cfg.AgentAliases {
@@ -676,8 +2552,82 @@ func configCmd() *cli.Command {
 					fmt.Println(cfg.DefaultBranch)
 				case "branch_prefix":
 					fmt.Println(cfg.BranchPrefix)
+				case "branch_template":
+					fmt.Println(cfg.BranchTemplate)
+				case "branch_max_length":
+					if cfg.BranchMaxLength > 0 {
+						fmt.Println(cfg.BranchMaxLength)
+					} else {
+						fmt.Println(worktree.DefaultMaxNameLength)
+					}
+				case "branch_stopwords":
+					fmt.Println(cfg.BranchStopwords)
+				case "hotfix_prefix":
+					fmt.Println(hotfixPrefix(cfg))
 				case "default_agent":
 					fmt.Println(cfg.DefaultAgent)
+				case "agent_env_allow":
+					fmt.Println(strings.Join(cfg.AgentEnvAllow, ","))
+				case "agent_sandbox":
+					fmt.Println(cfg.AgentSandbox)
+				case "agent_sandbox_image":
+					fmt.Println(cfg.SandboxImage)
+				case "agent_sandbox_paths":
+					fmt.Println(strings.Join(cfg.SandboxPaths, ","))
+				case "agent_confine":
+					fmt.Println(cfg.AgentConfine)
+				case "terminal_title":
+					fmt.Println(cfg.TerminalTitle)
+				case "git_backend":
+					fmt.Println(cfg.GitBackend)
+				case "git_timeout":
+					fmt.Println(cfg.GitTimeout)
+				case "ticket_rate_limit":
+					fmt.Println(cfg.TicketRateLimit)
+				case "ticket_cache_ttl":
+					fmt.Println(cfg.TicketCacheTTL)
+				case "rebase_threshold":
+					fmt.Println(rebaseThreshold(cfg))
+				case "web_command":
+					if cfg.WebCommand == "" {
+						fmt.Println(task.DefaultWebCommand)
+					} else {
+						fmt.Println(cfg.WebCommand)
+					}
+				case "web_port_base":
+					if cfg.WebPortBase == 0 {
+						fmt.Println(task.DefaultWebPortBase)
+					} else {
+						fmt.Println(cfg.WebPortBase)
+					}
+				case "start_check":
+					if cfg.StartCheck == "" {
+						fmt.Println(config.StartCheckWarn)
+					} else {
+						fmt.Println(cfg.StartCheck)
+					}
+				case "delete_remote_branch":
+					if cfg.DeleteRemote == "" {
+						fmt.Println(config.DeleteRemoteNever)
+					} else {
+						fmt.Println(cfg.DeleteRemote)
+					}
+				case "finish_checks":
+					checks := cfg.FinishChecks
+					if len(checks) == 0 {
+						checks = config.DefaultFinishChecks
+					}
+					fmt.Println(strings.Join(checkNames(checks), ","))
+				case "sync_columns":
+					fmt.Println(strings.Join(cfg.SyncColumns, ","))
+				case "sync_sort":
+					fmt.Println(cfg.SyncSort)
+				case "telemetry":
+					fmt.Println(cfg.Telemetry)
+				case "telemetry_url":
+					fmt.Println(cfg.TelemetryURL)
+				case "defaults_url":
+					fmt.Println(cfg.DefaultsURL)
 				default:
 					return fmt.Errorf("unknown config key: %s", key)
 				}
@@ -686,21 +2636,156 @@ func configCmd() *cli.Command {
 			value := c.Args().Get(1)
 			switch key {
 			case "worktrees_base":
-				// Expand tilde for home directory
-				if strings.HasPrefix(value, "~/") {
-					home, err := os.UserHomeDir()
-					if err != nil {
-						return fmt.Errorf("failed to resolve home directory: %w", err)
-					}
-					value = filepath.Join(home, value[2:])
+				if value, err = configDir(value); err != nil {
+					return fmt.Errorf("invalid value for worktrees_base: %w", err)
 				}
 				cfg.WorktreesBase = value
 			case "default_branch":
 				cfg.DefaultBranch = value
 			case "branch_prefix":
 				cfg.BranchPrefix = value
+			case "branch_template":
+				if value != "" {
+					if _, err := worktree.BranchNameFromTemplate(value, cfg.BranchPrefix, "PROJ-1", "PROJ-0", "example", worktree.NameOptions{}); err != nil {
+						return err
+					}
+				}
+				cfg.BranchTemplate = value
+			case "branch_max_length":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					return fmt.Errorf("invalid value for branch_max_length: %q (want a number of characters, 0 for the default)", value)
+				}
+				cfg.BranchMaxLength = n
+			case "branch_stopwords":
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid value for branch_stopwords: %q (want true or false)", value)
+				}
+				cfg.BranchStopwords = b
+			case "hotfix_prefix":
+				cfg.HotfixPrefix = value
 			case "default_agent":
 				cfg.DefaultAgent = value
+			case "agent_env_allow":
+				cfg.AgentEnvAllow = splitList(value)
+			case "agent_sandbox":
+				if err := agent.ValidateSandbox(value); err != nil {
+					return err
+				}
+				cfg.AgentSandbox = value
+			case "agent_sandbox_image":
+				cfg.SandboxImage = value
+			case "agent_sandbox_paths":
+				paths := splitList(value)
+				for i, p := range paths {
+					if paths[i], err = configPath(p); err != nil {
+						return fmt.Errorf("invalid value for agent_sandbox_paths: %w", err)
+					}
+				}
+				cfg.SandboxPaths = paths
+				value = strings.Join(paths, ",")
+			case "agent_confine":
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid value for agent_confine: %q (want true or false)", value)
+				}
+				cfg.AgentConfine = b
+			case "terminal_title":
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid value for terminal_title: %q (want true or false)", value)
+				}
+				cfg.TerminalTitle = b
+			case "git_backend":
+				if err := worktree.SetBackend(value); err != nil {
+					return err
+				}
+				cfg.GitBackend = value
+			case "git_timeout":
+				d, err := time.ParseDuration(value)
+				if err != nil || d < 0 {
+					return fmt.Errorf("invalid value for git_timeout: %q (want a duration like 30s or 2m, 0 to disable)", value)
+				}
+				cfg.GitTimeout = d
+			case "ticket_rate_limit":
+				r, err := strconv.ParseFloat(value, 64)
+				if err != nil || r < 0 {
+					return fmt.Errorf("invalid value for ticket_rate_limit: %q (want requests per second, e.g. 2.5)", value)
+				}
+				cfg.TicketRateLimit = r
+			case "ticket_cache_ttl":
+				d, err := time.ParseDuration(value)
+				if err != nil || d < 0 {
+					return fmt.Errorf("invalid value for ticket_cache_ttl: %q (want a duration like 30s or 10m)", value)
+				}
+				cfg.TicketCacheTTL = d
+			case "start_check":
+				switch value {
+				case config.StartCheckWarn, config.StartCheckBlock, config.StartCheckOff:
+				default:
+					return fmt.Errorf("invalid value for start_check: %q (want warn, block or off)", value)
+				}
+				cfg.StartCheck = value
+			case "delete_remote_branch":
+				switch value {
+				case config.DeleteRemoteNever, config.DeleteRemoteMerged, config.DeleteRemoteAlways:
+				default:
+					return fmt.Errorf("invalid value for delete_remote_branch: %q (want never, merged or always)", value)
+				}
+				cfg.DeleteRemote = value
+			case "rebase_threshold":
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("invalid value for rebase_threshold: %q (want a number of commits, -1 to disable)", value)
+				}
+				cfg.RebaseThreshold = n
+			case "web_command":
+				if value != "" && !strings.Contains(value, "{port}") {
+					return fmt.Errorf("invalid value for web_command: %q (want a command using {port})", value)
+				}
+				cfg.WebCommand = value
+			case "web_port_base":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 1 || n > 65535 {
+					return fmt.Errorf("invalid value for web_port_base: %q (want a port number)", value)
+				}
+				cfg.WebPortBase = n
+			case "finish_checks":
+				checks, err := parseFinishChecks(cfg.FinishChecks, splitList(value))
+				if err != nil {
+					return err
+				}
+				cfg.FinishChecks = checks
+			case "sync_columns":
+				columns := splitList(value)
+				if err := connector.ValidateTicketFields(columns); err != nil {
+					return err
+				}
+				cfg.SyncColumns = columns
+			case "sync_sort":
+				if value != "" {
+					if err := connector.SortTickets(nil, value); err != nil {
+						return err
+					}
+				}
+				cfg.SyncSort = value
+			case "telemetry":
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid value for telemetry: %q (want true or false)", value)
+				}
+				cfg.Telemetry = b
+			case "telemetry_url":
+				if value != "" && !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+					return fmt.Errorf("invalid value for telemetry_url: %q (want an http(s) URL)", value)
+				}
+				cfg.TelemetryURL = value
+			case "defaults_url":
+				if value != "" && !strings.HasPrefix(value, "https://") {
+					return fmt.Errorf("invalid value for defaults_url: %q (want an https URL)", value)
+				}
+				cfg.DefaultsURL = value
 			default:
 				return fmt.Errorf("unknown config key: %s", key)
 			}
@@ -708,6 +2793,24 @@ func configCmd() *cli.Command {
 				return err
 			}
 			fmt.Printf("Set %s = %s\n", key, value)
+			if key == "defaults_url" && value != "" {
+				ctx, cancel := context.WithTimeout(c.Context, 10*time.Second)
+				defer cancel()
+				if err := config.RefreshDefaults(ctx, value); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+				} else {
+					fmt.Println("Fetched org defaults; they apply from the next command.")
+				}
+			}
+			if key == "worktrees_base" {
+				if n := len(outsideBase(cfg)); n > 0 {
+					if !confirm(fmt.Sprintf("Move %d existing worktree(s) into %s?", n, value)) {
+						fmt.Println("Existing worktrees were left in place; move them later with 'wt move --migrate'.")
+						return nil
+					}
+					return migrateWorktrees(c.Context, task.NewManager(cfg))
+				}
+			}
 			return nil
 		},
 	}
@@ -722,24 +2825,122 @@ func pruneCmd() *cli.Command {
 		Description: `Remove stale git worktree administrative files.
 
    Cleans up references to worktrees that have been manually deleted or moved.
-   This runs 'git worktree prune' in the repository.
+   This runs 'git worktree prune' in the repository, then brings wt's tasks
+   in line: tasks whose branch is now checked out elsewhere are re-linked,
+   and tasks whose worktree is gone are dropped (their branches are kept).
 
-   Example:
-     wt prune`,
+   With --all-repos, every repository wt knows is pruned: those of active
+   tasks, those of worktrees under worktrees_base, and those configured by
+   path under repos.
+
+   Examples:
+     wt prune
+     wt prune --all-repos --dry-run`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "all-repos", Usage: "Prune every repository wt knows, not just the current one"},
+			&cli.BoolFlag{Name: "dry-run", Aliases: []string{"n"}, Usage: "Show which tasks would change without changing anything"},
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			var repos []string
+			if c.Bool("all-repos") {
+				repos = knownRepos(cfg)
+			} else {
+				repoPath, err := getRepoPath()
+				if err != nil {
+					return err
+				}
+				repos = []string{repoPath}
+			}
+			pruned := 0
+			for _, repo := range repos {
+				if _, err := os.Stat(repo); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: repository %s is missing\n", repo)
+					continue
+				}
+				if err := pruneRepo(c.Context, cfg, repo, c.Bool("dry-run")); err != nil {
+					if !c.Bool("all-repos") {
+						return err
+					}
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					continue
+				}
+				pruned++
+			}
+			switch {
+			case c.Bool("dry-run"):
+				fmt.Println("Dry run: no changes made.")
+			case c.Bool("all-repos"):
+				fmt.Printf("✅ Pruned stale worktree references in %d repo(s).\n", pruned)
+			default:
+				fmt.Println("✅ Pruned stale worktree references.")
+			}
+			return nil
+		},
+	}
+}
+
+// --- gc ---
+func gcCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "gc",
+		Category: "maintenance",
+		Usage:    "Remove empty directories left in worktrees_base",
+		Description: `Remove the empty directories under worktrees_base, such as the directory
+   of a repository whose worktrees were all finished. Worktrees and
+   directories holding any file are kept. Finishing, removing and moving
+   tasks already clean up after themselves; wt gc tidies what was left by
+   hand or by older versions.
+
+   Examples:
+     wt gc --dry-run
+     wt gc`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "dry-run", Aliases: []string{"n"}, Usage: "Show what would be removed without removing anything"},
+		},
 		Action: func(c *cli.Context) error {
-			repoPath, err := getRepoPath()
+			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
-			if err := worktree.Prune(repoPath); err != nil {
+			removed, err := task.GC(cfg.WorktreesBase, c.Bool("dry-run"))
+			for _, dir := range removed {
+				fmt.Printf("🗑  %s\n", dir)
+			}
+			if err != nil {
 				return err
 			}
-			fmt.Println("✅ Pruned stale worktree references.")
+			switch {
+			case len(removed) == 0:
+				fmt.Printf("✅ No empty directories in %s.\n", cfg.WorktreesBase)
+			case c.Bool("dry-run"):
+				fmt.Println("Dry run: no changes made.")
+			default:
+				fmt.Printf("✅ Removed %d empty director(ies).\n", len(removed))
+			}
 			return nil
 		},
 	}
 }
 
+// --- __checkout (internal) ---
+func checkoutWorkerCmd() *cli.Command {
+	return &cli.Command{
+		Name:   task.CheckoutCommand,
+		Hidden: true,
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			return task.NewManager(cfg).CompleteCheckout(c.Context, c.Args().First())
+		},
+	}
+}
+
 // --- helpers ---
 
 func joinArgs(c *cli.Context) string {
@@ -750,11 +2951,13 @@ func joinArgs(c *cli.Context) string {
 	return strings.Join(args, " ")
 }
 
-func truncate(s string, max int) string {
-	if len(s) <= max {
-		return s
-	}
-	return s[:max-3] + "..."
+// splitDescription splits a multi-line description into its first
+// non-empty line, without a leading Markdown heading marker, and the rest.
+func splitDescription(text string) (summary, notes string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	summary, notes, _ = strings.Cut(text, "\n")
+	summary = strings.TrimSpace(strings.TrimLeft(summary, "#"))
+	return summary, strings.TrimSpace(notes)
 }
 
 func connectorNames(cfg *config.Config) []string {
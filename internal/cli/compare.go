@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- compare ---
+func compareCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "compare",
+		Category:  "agent",
+		Usage:     "Compare the changes of two sibling tasks",
+		ArgsUsage: "<task-a> <task-b>",
+		Description: `Compare what two tasks of the same repository changed since their common
+   base commit, to pick the better of two candidate branches, e.g. from
+   'wt experiment'. Uncommitted changes to tracked files are included.
+
+   Files are listed as changed by only one task, changed by both with
+   different results, or changed by both to the same content. --patch
+   shows how the two versions of each file changed by both differ. The
+   result of each task's last 'wt test' is shown when there is one.
+
+   Examples:
+     wt compare wt-a1b2c3d4 wt-e5f6a7b8
+     wt compare --patch wt-a1b2c3d4 wt-e5f6a7b8`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "patch",
+				Usage: "Show the diff between the two versions of files both tasks changed",
+			},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return fmt.Errorf("please provide two task IDs (see 'wt list')")
+			}
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			a, err := taskFromArg(cfg, c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+			b, err := taskFromArg(cfg, c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			result, err := compareTasks(c, a, b)
+			if err != nil {
+				return err
+			}
+			return f.Write(os.Stdout, result, result.printTable)
+		},
+	}
+}
+
+// taskChanges is what one task changed since the common base.
+type taskChanges struct {
+	ID      string            `json:"id"`
+	Branch  string            `json:"branch"`
+	Commits int               `json:"commits"`
+	Changes worktree.DiffStat `json:"changes"`
+	// Test is the task's last 'wt test' result, if any.
+	Test *config.TestResult `json:"test,omitempty"`
+}
+
+// fileComparison is a file changed by both tasks.
+type fileComparison struct {
+	Path  string              `json:"path"`
+	A     worktree.FileChange `json:"a"`
+	B     worktree.FileChange `json:"b"`
+	Patch string              `json:"patch,omitempty"`
+}
+
+// comparison is the diff of the diffs of two tasks.
+type comparison struct {
+	Base      string                `json:"base"`
+	A         taskChanges           `json:"a"`
+	B         taskChanges           `json:"b"`
+	OnlyA     []worktree.FileChange `json:"only_a"`
+	OnlyB     []worktree.FileChange `json:"only_b"`
+	Different []fileComparison      `json:"different"`
+	Same      []string              `json:"same"`
+}
+
+func compareTasks(c *cli.Context, a, b *config.Task) (*comparison, error) {
+	if a.ID == b.ID {
+		return nil, fmt.Errorf("cannot compare task %s with itself", a.ID)
+	}
+	if a.RepoPath != b.RepoPath {
+		return nil, fmt.Errorf("tasks %s and %s belong to different repositories", a.ID, b.ID)
+	}
+	base, err := worktree.CommonBase(c.Context, a.RepoPath, a.Branch, b.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		return nil, fmt.Errorf("branches %s and %s share no history", a.Branch, b.Branch)
+	}
+
+	result := &comparison{Base: base}
+	var filesA, filesB []worktree.FileChange
+	for _, side := range []struct {
+		t       *config.Task
+		changes *taskChanges
+		files   *[]worktree.FileChange
+	}{{a, &result.A, &filesA}, {b, &result.B, &filesB}} {
+		files, err := worktree.Diff(c.Context, side.t.Worktree, base)
+		if err != nil {
+			return nil, err
+		}
+		commits, _, err := worktree.CompareCommits(c.Context, side.t.Worktree, "HEAD", base)
+		if err != nil {
+			return nil, err
+		}
+		*side.changes = taskChanges{ID: side.t.ID, Branch: side.t.Branch, Commits: commits, Changes: worktree.Stat(files), Test: side.t.Test}
+		*side.files = files
+	}
+
+	result.OnlyA, result.OnlyB, result.Different, result.Same = compareChanges(filesA, filesB, func(path string) bool {
+		return sameFile(filepath.Join(a.Worktree, path), filepath.Join(b.Worktree, path))
+	})
+	if c.Bool("patch") {
+		for i, d := range result.Different {
+			patch, err := worktree.DiffFiles(c.Context, filepath.Join(a.Worktree, d.Path), filepath.Join(b.Worktree, d.Path))
+			if err != nil {
+				return nil, err
+			}
+			result.Different[i].Patch = patch
+		}
+	}
+	return result, nil
+}
+
+// compareChanges sorts the files changed by two tasks into those changed by
+// only one of them and those changed by both, differently or to the same
+// content as reported by same.
+func compareChanges(a, b []worktree.FileChange, same func(path string) bool) (onlyA, onlyB []worktree.FileChange, different []fileComparison, identical []string) {
+	inB := make(map[string]worktree.FileChange, len(b))
+	for _, f := range b {
+		inB[f.Path] = f
+	}
+	inA := make(map[string]bool, len(a))
+	for _, f := range a {
+		inA[f.Path] = true
+		fb, ok := inB[f.Path]
+		switch {
+		case !ok:
+			onlyA = append(onlyA, f)
+		case same(f.Path):
+			identical = append(identical, f.Path)
+		default:
+			different = append(different, fileComparison{Path: f.Path, A: f, B: fb})
+		}
+	}
+	for _, f := range b {
+		if !inA[f.Path] {
+			onlyB = append(onlyB, f)
+		}
+	}
+	sort.Slice(onlyA, func(i, j int) bool { return onlyA[i].Path < onlyA[j].Path })
+	sort.Slice(onlyB, func(i, j int) bool { return onlyB[i].Path < onlyB[j].Path })
+	sort.Slice(different, func(i, j int) bool { return different[i].Path < different[j].Path })
+	sort.Strings(identical)
+	return onlyA, onlyB, different, identical
+}
+
+// sameFile reports whether two files have the same content; two missing
+// files count as the same.
+func sameFile(a, b string) bool {
+	da, errA := os.ReadFile(a)
+	db, errB := os.ReadFile(b)
+	if os.IsNotExist(errA) && os.IsNotExist(errB) {
+		return true
+	}
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+func (r *comparison) printTable(out io.Writer) error {
+	fmt.Fprintf(out, "Comparing A %s (%s) with B %s (%s) since %s\n\n", r.A.ID, r.A.Branch, r.B.ID, r.B.Branch, r.Base[:min(12, len(r.Base))])
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, "\tA\tB")
+	fmt.Fprintf(w, "Commits\t%d\t%d\n", r.A.Commits, r.B.Commits)
+	fmt.Fprintf(w, "Changes\t%s\t%s\n", r.A.Changes, r.B.Changes)
+	if r.A.Test != nil || r.B.Test != nil {
+		fmt.Fprintf(w, "Tests\t%s\t%s\n", testSummary(r.A.Test), testSummary(r.B.Test))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	sections := []struct {
+		title string
+		files []worktree.FileChange
+	}{{"Only in A", r.OnlyA}, {"Only in B", r.OnlyB}}
+	for _, s := range sections {
+		if len(s.files) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "\n%s:\n", s.title)
+		w := output.NewTabWriter(out)
+		for _, f := range s.files {
+			fmt.Fprintf(w, "  %s\t+%d -%d\n", f.Path, f.Insertions, f.Deletions)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(r.Different) > 0 {
+		fmt.Fprintln(out, "\nChanged differently:")
+		w := output.NewTabWriter(out)
+		for _, d := range r.Different {
+			fmt.Fprintf(w, "  %s\tA +%d -%d\tB +%d -%d\n", d.Path, d.A.Insertions, d.A.Deletions, d.B.Insertions, d.B.Deletions)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(r.Same) > 0 {
+		fmt.Fprintln(out, "\nChanged the same way:")
+		for _, p := range r.Same {
+			fmt.Fprintf(out, "  %s\n", p)
+		}
+	}
+	for _, d := range r.Different {
+		if d.Patch != "" {
+			fmt.Fprintf(out, "\n%s", d.Patch)
+		}
+	}
+	return nil
+}
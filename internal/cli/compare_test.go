@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+func TestCompareChanges(t *testing.T) {
+	a := []worktree.FileChange{
+		{Path: "main.go", Insertions: 3},
+		{Path: "only_a.go", Insertions: 1},
+		{Path: "go.mod", Insertions: 1, Deletions: 1},
+	}
+	b := []worktree.FileChange{
+		{Path: "main.go", Insertions: 5, Deletions: 1},
+		{Path: "go.mod", Insertions: 1, Deletions: 1},
+		{Path: "docs/b.md", Deletions: 2},
+	}
+	same := func(path string) bool { return path == "go.mod" }
+	onlyA, onlyB, different, identical := compareChanges(a, b, same)
+
+	if want := []worktree.FileChange{{Path: "only_a.go", Insertions: 1}}; !reflect.DeepEqual(onlyA, want) {
+		t.Errorf("onlyA = %+v, want %+v", onlyA, want)
+	}
+	if want := []worktree.FileChange{{Path: "docs/b.md", Deletions: 2}}; !reflect.DeepEqual(onlyB, want) {
+		t.Errorf("onlyB = %+v, want %+v", onlyB, want)
+	}
+	wantDifferent := []fileComparison{{Path: "main.go", A: a[0], B: b[0]}}
+	if !reflect.DeepEqual(different, wantDifferent) {
+		t.Errorf("different = %+v, want %+v", different, wantDifferent)
+	}
+	if want := []string{"go.mod"}; !reflect.DeepEqual(identical, want) {
+		t.Errorf("identical = %v, want %v", identical, want)
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultsCommand is the hidden wt subcommand that fetches the org
+// defaults of defaults_url, run detached so that no command waits for it.
+const defaultsCommand = "__refresh-defaults"
+
+// refreshDefaults starts fetching the org defaults of defaults_url again
+// in the background when they are due. Failures never affect the command.
+func refreshDefaults(args []string) {
+	if len(args) > 1 && (args[1] == defaultsCommand || args[1] == uploadCommand) {
+		return
+	}
+	cfg, err := config.LoadSettings()
+	if err != nil || cfg.DefaultsURL == "" || !config.DefaultsDue(cfg.DefaultsURL) {
+		return
+	}
+	if err := config.PostponeDefaults(cfg.DefaultsURL); err != nil {
+		return
+	}
+	spawnWorker(defaultsCommand)
+}
+
+// --- __refresh-defaults (internal) ---
+func defaultsWorkerCmd() *cli.Command {
+	return &cli.Command{
+		Name:   defaultsCommand,
+		Hidden: true,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.LoadSettings()
+			if err != nil || cfg.DefaultsURL == "" {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+			return config.RefreshDefaults(ctx, cfg.DefaultsURL)
+		},
+	}
+}
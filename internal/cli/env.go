@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/direnv"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// --- env ---
+func envCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "env",
+		Category:  "agent",
+		Usage:     "Print the context wt gives agents for a task",
+		ArgsUsage: "[task-id]",
+		Description: `Print everything wt hands to an agent launched on a task: the environment
+   variables, the task's paths and the fields of its ticket. Defaults to the
+   task of the current worktree.
+
+   --export prints only the variables, as shell exports; -o json (or --json)
+   prints everything as one object for hook scripts. The ticket is fetched
+   live through the ticket cache; --offline skips it.
+
+   Examples:
+     wt env
+     eval "$(wt env --export)"
+     wt env --json wt-abc123 | jq -r .ticket.url`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "export", Usage: "Print the variables as shell export statements"},
+			&cli.BoolFlag{Name: "json", Usage: "Print as JSON (same as -o json)"},
+			&cli.BoolFlag{Name: "offline", Usage: "Skip fetching the ticket"},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			env, err := agentEnv(cfg, t)
+			if err != nil {
+				return err
+			}
+			if c.Bool("export") {
+				for _, k := range sortedKeys(env) {
+					fmt.Printf("export %s=%s\n", k, direnv.Quote(env[k]))
+				}
+				return nil
+			}
+
+			out := taskContext{Task: t.ID, Env: env, Paths: map[string]string{
+				"worktree":   t.Worktree,
+				"repository": t.RepoPath,
+			}}
+			if dir, err := config.ConfigDir(); err == nil {
+				out.Paths["config"] = dir
+			}
+			if dir, err := task.ScratchDir(t.ID); err == nil {
+				out.Paths["scratch"] = dir
+			}
+			if path := task.ContextPath(t.ID); path != "" {
+				out.Paths["context"] = path
+			}
+			if !c.Bool("offline") {
+				ref := connector.Ref{Connector: t.Connector, Key: t.TicketKey}
+				if r, ok := fetchTaskTickets(c.Context, cfg, []config.Task{*t})[ref]; ok {
+					if r.Err != nil {
+						fmt.Fprintf(os.Stderr, "warning: %v\n", r.Err)
+					}
+					out.Ticket = r.Ticket
+				}
+			}
+			return f.Write(os.Stdout, out, func(w io.Writer) error {
+				printTaskContext(w, out)
+				return nil
+			})
+		},
+	}
+}
+
+// taskContext is the context wt gives an agent working on a task.
+type taskContext struct {
+	Task   string            `json:"task"`
+	Env    map[string]string `json:"env"`
+	Paths  map[string]string `json:"paths"`
+	Ticket *connector.Ticket `json:"ticket,omitempty"`
+}
+
+// agentEnv returns the environment variables set for an agent launched on
+// a task: the task's variables plus WT_TICKET_SUMMARY and WT_TASK_NOTES.
+func agentEnv(cfg *config.Config, t *config.Task) (map[string]string, error) {
+	env, err := task.NewManager(cfg).Env(t)
+	if err != nil {
+		return nil, err
+	}
+	summary := t.TicketKey
+	if t.Description != "" {
+		summary = t.Description
+	}
+	if summary != "" {
+		env["WT_TICKET_SUMMARY"] = summary
+	}
+	if t.Notes != "" {
+		env["WT_TASK_NOTES"] = t.Notes
+	}
+	return env, nil
+}
+
+// launchOptions returns how to launch agentName in a task with env, with
+// the environment allowlist, sandbox and confinement configured for agents.
+func launchOptions(cfg *config.Config, t *config.Task, agentName string, args []string, env map[string]string) (agent.LaunchOptions, error) {
+	if err := checkLocal(t); err != nil {
+		return agent.LaunchOptions{}, err
+	}
+	opts := agent.LaunchOptions{
+		Agent:         agentName,
+		Args:          args,
+		WorkDir:       t.Worktree,
+		TaskID:        t.ID,
+		TicketKey:     t.TicketKey,
+		TicketSummary: env["WT_TICKET_SUMMARY"],
+		Aliases:       cfg.AgentAliases,
+		Env:           env,
+		EnvAllow:      cfg.AgentEnvAllow,
+	}
+	scratch, err := task.ScratchDir(t.ID)
+	if err != nil {
+		return opts, err
+	}
+	sandbox := cfg.AgentSandbox
+	if cfg.AgentConfine {
+		// Agents that enforce their flags are kept to the worktree and
+		// scratch directory by their own means; others need a sandbox.
+		kind := agent.Kind(agentName, cfg.AgentAliases)
+		confine, enforced := agent.ConfineArgs(kind, []string{scratch})
+		if len(confine) > 0 {
+			opts.Args = append(append([]string{}, args...), confine...)
+		}
+		if !enforced && sandbox == "" {
+			if sandbox = agent.DefaultSandbox(); sandbox == "" {
+				return opts, fmt.Errorf("cannot confine agent %s to its worktree: install firejail, set agent_sandbox, or turn agent_confine off", agentName)
+			}
+		}
+	}
+	if sandbox != "" {
+		// Commits made in a worktree are written to the repository's .git.
+		paths := []string{filepath.Join(t.RepoPath, ".git"), scratch}
+		paths = append(paths, agent.StatePaths()...)
+		paths = append(paths, cfg.SandboxPaths...)
+		opts.Sandbox = &agent.Sandbox{Kind: sandbox, Image: cfg.SandboxImage, Paths: paths}
+	}
+	return opts, nil
+}
+
+func printTaskContext(w io.Writer, tc taskContext) {
+	fmt.Fprintln(w, "Environment:")
+	for _, k := range sortedKeys(tc.Env) {
+		fmt.Fprintf(w, "  %s=%s\n", k, tc.Env[k])
+	}
+	fmt.Fprintln(w, "Paths:")
+	for _, k := range sortedKeys(tc.Paths) {
+		fmt.Fprintf(w, "  %-11s %s\n", k+":", tc.Paths[k])
+	}
+	if t := tc.Ticket; t != nil {
+		fmt.Fprintln(w, "Ticket:")
+		fields := [][2]string{
+			{"key", t.Key},
+			{"summary", t.Summary},
+			{"status", t.Status},
+			{"type", t.Type},
+			{"priority", t.Priority},
+			{"assignee", t.Assignee},
+			{"labels", strings.Join(t.Labels, ", ")},
+			{"parent", t.ParentKey},
+			{"epic", t.EpicKey},
+			{"url", t.URL},
+		}
+		for _, f := range fields {
+			if f[1] != "" {
+				fmt.Fprintf(w, "  %-11s %s\n", f[0]+":", f[1])
+			}
+		}
+		for _, k := range sortedKeys(t.Metadata) {
+			fmt.Fprintf(w, "  %-11s %s\n", k+":", t.Metadata[k])
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
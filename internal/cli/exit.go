@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/ci"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// Exit codes. Scripts can rely on these; add new codes rather than
+// renumbering existing ones.
+const (
+	ExitOK            = 0
+	ExitError         = 1   // any failure not listed below
+	ExitTaskNotFound  = 2   // no task matches the given ID or directory
+	ExitDirty         = 3   // worktree has uncommitted changes
+	ExitConnectorAuth = 4   // tracker rejected or lacks credentials
+	ExitNotConfigured = 5   // connector has not been set up
+	ExitGitAuth       = 6   // git could not authenticate with a remote
+	ExitTimeout       = 7   // git_timeout or another deadline expired
+	ExitLocked        = 8   // task is locked or an agent is running in it
+	ExitBranchExists  = 9   // the new branch collides with an existing one
+	ExitTestsFailed   = 10  // the test command failed
+	ExitChecksFailed  = 11  // finish checks failed
+	ExitCIFailed      = 12  // the CI pipeline failed
+	ExitBranchInUse   = 13  // the branch is checked out in another worktree
+	ExitInterrupted   = 130 // cancelled with Ctrl-C
+)
+
+// errorKind classifies errors for exit codes and structured error output.
+type errorKind struct {
+	code int
+	name string
+	hint string
+}
+
+var errorKinds = []struct {
+	match func(error) bool
+	kind  errorKind
+}{
+	{is(config.ErrTaskNotFound), errorKind{ExitTaskNotFound, "task_not_found", "Run 'wt list' to see task IDs."}},
+	{is(config.ErrTaskLocked), errorKind{ExitLocked, "task_locked", "Run 'wt unlock <task-id>' or pass --force."}},
+	{is(worktree.ErrBranchConflict), errorKind{ExitBranchExists, "branch_exists", "Use a different description, or delete or check out the existing branch."}},
+	{is(task.ErrTestsFailed), errorKind{ExitTestsFailed, "tests_failed", "See the test log in the task's scratch directory."}},
+	{is(task.ErrChecksFailed), errorKind{ExitChecksFailed, "checks_failed", "Fix the failing checks, or pass --skip-checks."}},
+	{is(worktree.ErrBranchCheckedOut), errorKind{ExitBranchInUse, "branch_checked_out", "Switch the other checkout to another branch, or run 'wt remove <task-id>' to keep the branch."}},
+	{is(ci.ErrFailed), errorKind{ExitCIFailed, "ci_failed", "Open the failed jobs with 'wt ci' to see their logs."}},
+	{is(worktree.ErrDirty), errorKind{ExitDirty, "dirty_worktree", "Commit or stash the changes, or pass --force to discard them."}},
+	{is(connector.ErrAuth), errorKind{ExitConnectorAuth, "connector_auth", "Check the connector's credentials and run 'wt connect <name>' again."}},
+	{is(connector.ErrNotConfigured), errorKind{ExitNotConfigured, "connector_not_configured", "Set the connector up with 'wt connect <name>'."}},
+	{is(worktree.ErrAuth), errorKind{ExitGitAuth, "git_auth", "Set up a git credential helper or ssh-agent."}},
+	{is(worktree.ErrTimeout, context.DeadlineExceeded), errorKind{ExitTimeout, "timeout", "Raise the limit with 'wt config git_timeout <duration>'."}},
+	{is(context.Canceled), errorKind{ExitInterrupted, "interrupted", ""}},
+}
+
+func is(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func classify(err error) errorKind {
+	for _, k := range errorKinds {
+		if k.match(err) {
+			return k.kind
+		}
+	}
+	return errorKind{ExitError, "error", ""}
+}
+
+// ExitCode maps an error returned by Run to the process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	return classify(err).code
+}
+
+// ReportError writes an error returned by Run to w: as a JSON object when
+// --json was given (or WT_JSON is set), otherwise as a plain message.
+func ReportError(w io.Writer, args []string, err error) {
+	if !jsonRequested(args) {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return
+	}
+	k := classify(err)
+	out := struct {
+		Error struct {
+			Code    int      `json:"code"`
+			Kind    string   `json:"kind"`
+			Message string   `json:"message"`
+			Hints   []string `json:"hints,omitempty"`
+		} `json:"error"`
+	}{}
+	out.Error.Code = k.code
+	out.Error.Kind = k.name
+	out.Error.Message = err.Error()
+	if k.hint != "" {
+		out.Error.Hints = []string{k.hint}
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// globalValueFlags are the global flags that take a value, which
+// jsonRequested must skip when it is given as the next argument. Keep it in
+// sync with the string flags of the app in Run.
+var globalValueFlags = []string{"worktrees-base", "branch-prefix", "default-branch", "host"}
+
+// jsonRequested reports whether the global --json flag precedes the
+// command name in args, or WT_JSON is set. Flags after the command belong
+// to it (or to an agent it launches) and are not considered.
+func jsonRequested(args []string) bool {
+	if v := os.Getenv("WT_JSON"); v != "" && v != "0" && v != "false" {
+		return true
+	}
+	requested := false
+	rest := args[min(1, len(args)):]
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if !strings.HasPrefix(arg, "-") || arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch {
+		case name == "json" && !hasValue:
+			requested = true
+		case name == "json":
+			requested, _ = strconv.ParseBool(value)
+		case slices.Contains(globalValueFlags, name) && !hasValue:
+			i++
+		}
+	}
+	return requested
+}
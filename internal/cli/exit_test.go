@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/ci"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitError},
+		{fmt.Errorf("%w: %q", config.ErrTaskNotFound, "wt-1"), ExitTaskNotFound},
+		{fmt.Errorf("failed: %w", worktree.ErrDirty), ExitDirty},
+		{fmt.Errorf("%w: wt-1 is locked by ann@laptop", config.ErrTaskLocked), ExitLocked},
+		{fmt.Errorf("%w: %q collides with refs/heads/fix", worktree.ErrBranchConflict, "fix"), ExitBranchExists},
+		{fmt.Errorf("%w in 1 of 2 task(s)", task.ErrTestsFailed), ExitTestsFailed},
+		{fmt.Errorf("%w: 1 of 2 for wt-1", task.ErrChecksFailed), ExitChecksFailed},
+		{fmt.Errorf("%w: wt/fix-login", ci.ErrFailed), ExitCIFailed},
+		{connector.StatusError("jira", 401, nil), ExitConnectorAuth},
+		{connector.StatusError("jira", 500, nil), ExitError},
+		{fmt.Errorf("%w: jira", connector.ErrNotConfigured), ExitNotConfigured},
+		{fmt.Errorf("fetch: %w", worktree.ErrAuth), ExitGitAuth},
+		{fmt.Errorf("git fetch %w after 1s", worktree.ErrTimeout), ExitTimeout},
+		{fmt.Errorf("git fetch: %w", context.Canceled), ExitInterrupted},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestJSONRequested(t *testing.T) {
+	t.Setenv("WT_JSON", "")
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"wt", "--json", "show", "foo"}, true},
+		{[]string{"wt", "-json", "show", "foo"}, true},
+		{[]string{"wt", "show", "--json", "foo"}, false},
+		{[]string{"wt", "--worktrees-base", "/x", "--json", "show", "foo"}, true},
+		{[]string{"wt", "--worktrees-base=/x", "--json", "show", "foo"}, true},
+		{[]string{"wt", "--host", "dev", "--branch-prefix", "f/", "--default-branch", "main", "--json", "list"}, true},
+		{[]string{"wt", "--simulate", "list", "--json"}, false},
+		{[]string{"wt", "--json=true", "show", "foo"}, true},
+		{[]string{"wt", "--json=false", "show", "foo"}, false},
+		{[]string{"wt", "--json", "--json=false", "show", "foo"}, false},
+		{[]string{"wt", "--", "--json"}, false},
+		{[]string{"wt"}, false},
+	}
+	for _, tt := range tests {
+		if got := jsonRequested(tt.args); got != tt.want {
+			t.Errorf("jsonRequested(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	t.Setenv("WT_JSON", "")
+	err := fmt.Errorf("%w: %q", config.ErrTaskNotFound, "wt-1")
+
+	var b strings.Builder
+	ReportError(&b, []string{"wt", "finish", "--json"}, err)
+	if b.String() != "Error: task not found: \"wt-1\"\n" {
+		t.Errorf("flag after the command should not switch to JSON, got %q", b.String())
+	}
+
+	b.Reset()
+	ReportError(&b, []string{"wt", "--json", "finish", "wt-1"}, err)
+	var out struct {
+		Error struct {
+			Code    int      `json:"code"`
+			Kind    string   `json:"kind"`
+			Message string   `json:"message"`
+			Hints   []string `json:"hints"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &out); err != nil {
+		t.Fatalf("invalid JSON %q: %v", b.String(), err)
+	}
+	if out.Error.Code != ExitTaskNotFound || out.Error.Kind != "task_not_found" || len(out.Error.Hints) != 1 {
+		t.Errorf("unexpected error object: %+v", out.Error)
+	}
+}
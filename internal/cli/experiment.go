@@ -0,0 +1,390 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/terminal"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// experimentVariant is one sibling task of an experiment.
+type experimentVariant struct {
+	Agent  string
+	Prompt string
+}
+
+// experimentVariants runs every agent with every prompt, count times each.
+func experimentVariants(agents, prompts []string, count int) []experimentVariant {
+	if len(agents) == 0 {
+		agents = []string{""}
+	}
+	if len(prompts) == 0 {
+		prompts = []string{""}
+	}
+	var variants []experimentVariant
+	for _, a := range agents {
+		for _, p := range prompts {
+			for i := 0; i < count; i++ {
+				variants = append(variants, experimentVariant{Agent: a, Prompt: p})
+			}
+		}
+	}
+	return variants
+}
+
+// --- experiment ---
+func experimentCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "experiment",
+		Category:  "agent",
+		Usage:     "Try a task several ways in parallel sibling worktrees",
+		ArgsUsage: "<task-description>",
+		Description: `Create sibling worktrees for the same task off the same commit and launch
+   an agent in each, to compare different agents or prompts side by side.
+
+   Every agent in --agents is run with every --prompt, --count times each.
+   Each sibling's prompt is its --prompt, or the description when none is
+   given; it is stored as the task's notes (WT_TASK_NOTES) and replaces
+   {prompt} in --agent-args.
+
+   Inside tmux, each agent gets its own window. Otherwise agents run in the
+   background without a terminal, logging to agent.log in the task's scratch
+   directory, so give them non-interactive arguments.
+
+   'wt experiment compare' shows what each sibling changed since the shared
+   base commit and which files they touched; 'wt compare' compares two of
+   them file by file.
+
+   Examples:
+     wt experiment --agents claude,aider "try approach X"
+     wt experiment --agents claude --count 3 --agent-args "-p {prompt}" "fix flaky test"
+     wt experiment --agents claude --prompt "use a mutex" --prompt "use channels" "fix race"
+     wt experiment compare exp-1a2b3c`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "agents",
+				Usage: "Comma-separated agents to run (default: WT_AGENT or default_agent)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "prompt",
+				Usage: "A prompt to try; repeat for several",
+			},
+			&cli.IntFlag{
+				Name:  "count",
+				Value: 1,
+				Usage: "Number of siblings per agent and prompt",
+			},
+			&cli.StringFlag{
+				Name:  "agent-args",
+				Usage: "Arguments to pass to every agent; {prompt} is replaced by the sibling's prompt",
+			},
+			&cli.BoolFlag{
+				Name:  "no-agent",
+				Usage: "Only create the worktrees",
+			},
+			&cli.BoolFlag{
+				Name:    "force",
+				Aliases: []string{"f"},
+				Usage:   "Start even if the checkout is dirty or mid-rebase (with start_check: block)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			description := joinArgs(c)
+			if description == "" {
+				return fmt.Errorf("please provide a task description")
+			}
+			if c.Int("count") < 1 {
+				return fmt.Errorf("--count must be at least 1")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			agents := splitList(c.String("agents"))
+			if c.Bool("no-agent") {
+				agents = nil
+			} else if len(agents) == 0 {
+				if name := resolveAgent("", os.Getenv("WT_AGENT"), cfg.DefaultAgent); name != "" {
+					agents = []string{name}
+				}
+			}
+			for _, name := range agents {
+				if err := agent.ValidateAgent(name, cfg.AgentAliases); err != nil {
+					return fmt.Errorf("agent %q not found: %w", name, err)
+				}
+			}
+			variants := experimentVariants(agents, c.StringSlice("prompt"), c.Int("count"))
+			if len(variants) < 2 {
+				return fmt.Errorf("an experiment needs at least two siblings; use --agents, --prompt or --count")
+			}
+
+			repoPath, err := getRepoPath()
+			if err != nil {
+				return err
+			}
+			if err := checkStartPoint(c.Context, cfg, repoPath, c.Bool("force")); err != nil {
+				return err
+			}
+
+			mgr := task.NewManager(cfg)
+			id := task.NewExperimentID()
+			fmt.Printf("🧪 Experiment %s: %s\n", id, description)
+			failed := 0
+			for i, v := range variants {
+				suffix := fmt.Sprint(i + 1)
+				if v.Agent != "" {
+					suffix = worktree.SanitizeBranchName(v.Agent) + "-" + suffix
+				}
+				t, err := mgr.Start(c.Context, task.StartOptions{
+					RepoPath:    repoPath,
+					Description: description,
+					Notes:       v.Prompt,
+					Experiment:  id,
+					Variant:     suffix,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: sibling %d: %v\n", i+1, err)
+					failed++
+					continue
+				}
+				fmt.Printf("   %s  %s  %s\n", t.ID, t.Branch, t.Worktree)
+				if v.Agent == "" {
+					continue
+				}
+				prompt := v.Prompt
+				if prompt == "" {
+					prompt = description
+				}
+				_, where, err := launchDetachedAgent(cfg, t, v.Agent, c.String("agent-args"), prompt)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %s: %v\n", t.ID, err)
+					continue
+				}
+				fmt.Printf("   🚀 %s %s\n", v.Agent, where)
+			}
+			fmt.Printf("\n   Compare the results with: wt experiment compare %s\n", id)
+			if failed > 0 {
+				return fmt.Errorf("failed to start %d of %d sibling(s)", failed, len(variants))
+			}
+			return nil
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:      "compare",
+				Usage:     "Show what each sibling of an experiment changed",
+				ArgsUsage: "[experiment-id|task-id]",
+				Flags:     []cli.Flag{outputFlag()},
+				Action: func(c *cli.Context) error {
+					f, err := formatter(c)
+					if err != nil {
+						return err
+					}
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					siblings := cfg.ExperimentTasks(c.Args().First())
+					if len(siblings) == 0 {
+						t, err := taskFromArgOrCwd(c, cfg)
+						if err != nil {
+							return err
+						}
+						if t.Experiment == "" {
+							return fmt.Errorf("task %s is not part of an experiment", t.ID)
+						}
+						siblings = cfg.ExperimentTasks(t.Experiment)
+					}
+					result, err := compareExperiment(c, siblings)
+					if err != nil {
+						return err
+					}
+					return f.Write(os.Stdout, result, result.printTable)
+				},
+			},
+		},
+	}
+}
+
+// launchDetachedAgent starts an agent for a task without replacing wt's
+// process, in a tmux window or in the background, and describes where it
+// runs, with the PID of its process. "{prompt}" in agentArgs is replaced by
+// prompt.
+func launchDetachedAgent(cfg *config.Config, t *config.Task, agentName, agentArgs, prompt string) (int, string, error) {
+	env, err := agentEnv(cfg, t)
+	if err != nil {
+		return 0, "", err
+	}
+	args := agent.ParseAgentArgs(agentArgs)
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, "{prompt}", prompt)
+	}
+
+	opts, err := launchOptions(cfg, t, agentName, args, env)
+	if err != nil {
+		return 0, "", err
+	}
+	var pid int
+	var where string
+	if terminal.InTmux() {
+		argv, environ, err := opts.Command(true)
+		if err != nil {
+			return 0, "", err
+		}
+		// The window would inherit the tmux server's environment; env -i
+		// gives the agent exactly its own.
+		title := terminal.Title(t.ID, t.TicketKey)
+		if pid, err = terminal.NewWindow(title, t.Worktree, nil, append(append([]string{"env", "-i"}, environ...), argv...)); err != nil {
+			return 0, "", err
+		}
+		where = "in tmux window " + title
+	} else {
+		scratch, err := task.ScratchDir(t.ID)
+		if err != nil {
+			return 0, "", err
+		}
+		logPath := filepath.Join(scratch, "agent.log")
+		pid, err = agent.Spawn(opts, logPath)
+		if err != nil {
+			return 0, "", err
+		}
+		where = "in the background (log: " + logPath + ")"
+	}
+	if err := cfg.RecordAgent(t.ID, agentName, pid, agent.StartTime(pid)); err != nil {
+		return 0, "", err
+	}
+	return pid, where, nil
+}
+
+// experimentResult is what each sibling of an experiment changed since
+// their common base commit.
+type experimentResult struct {
+	Experiment string            `json:"experiment"`
+	Base       string            `json:"base"`
+	Siblings   []siblingResult   `json:"siblings"`
+	Files      map[string][]bool `json:"files"`
+}
+
+type siblingResult struct {
+	ID      string                `json:"id"`
+	Agent   string                `json:"agent,omitempty"`
+	Running bool                  `json:"running"`
+	Prompt  string                `json:"prompt,omitempty"`
+	Branch  string                `json:"branch"`
+	Commits int                   `json:"commits"`
+	Changes worktree.DiffStat     `json:"changes"`
+	Files   []worktree.FileChange `json:"files"`
+	Error   string                `json:"error,omitempty"`
+}
+
+func compareExperiment(c *cli.Context, siblings []config.Task) (*experimentResult, error) {
+	branches := make([]string, len(siblings))
+	for i, t := range siblings {
+		branches[i] = t.Branch
+	}
+	base, err := worktree.CommonBase(c.Context, siblings[0].RepoPath, branches...)
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		return nil, fmt.Errorf("the siblings of %s share no history", siblings[0].Experiment)
+	}
+
+	result := &experimentResult{
+		Experiment: siblings[0].Experiment,
+		Base:       base,
+		Siblings:   make([]siblingResult, len(siblings)),
+		Files:      make(map[string][]bool),
+	}
+	for i, t := range siblings {
+		s := siblingResult{
+			ID:      t.ID,
+			Agent:   t.Agent,
+			Running: agent.IsAlive(t.AgentPID, t.AgentStarted),
+			Prompt:  t.Notes,
+			Branch:  t.Branch,
+		}
+		files, err := worktree.Diff(c.Context, t.Worktree, base)
+		if err == nil {
+			s.Commits, _, err = worktree.CompareCommits(c.Context, t.Worktree, "HEAD", base)
+		}
+		if err != nil {
+			s.Error = err.Error()
+		}
+		s.Files = files
+		s.Changes = worktree.Stat(files)
+		for _, f := range files {
+			if result.Files[f.Path] == nil {
+				result.Files[f.Path] = make([]bool, len(siblings))
+			}
+			result.Files[f.Path][i] = true
+		}
+		result.Siblings[i] = s
+	}
+	return result, nil
+}
+
+func (r *experimentResult) printTable(out io.Writer) error {
+	fmt.Fprintf(out, "Experiment %s, compared with %s\n\n", r.Experiment, r.Base[:min(12, len(r.Base))])
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, "#\tTASK\tAGENT\tPROMPT\tCOMMITS\tCHANGES")
+	for i, s := range r.Siblings {
+		agentName := s.Agent
+		if agentName == "" {
+			agentName = "-"
+		} else if s.Running {
+			agentName += " (running)"
+		}
+		prompt := s.Prompt
+		if prompt == "" {
+			prompt = "-"
+		}
+		changes := s.Changes.String()
+		if s.Error != "" {
+			changes = "error: " + s.Error
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\n", i+1, s.ID, agentName, output.Truncate(prompt, 30), s.Commits, changes)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(r.Files) == 0 {
+		fmt.Fprintln(out, "\nNo sibling changed any files yet.")
+		return nil
+	}
+
+	// One column per sibling, marking the files it changed.
+	paths := make([]string, 0, len(r.Files))
+	for p := range r.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	fmt.Fprintln(out)
+	w = output.NewTabWriter(out)
+	header := "FILE"
+	for i := range r.Siblings {
+		header += fmt.Sprintf("\t%d", i+1)
+	}
+	fmt.Fprintln(w, header)
+	for _, p := range paths {
+		line := p
+		for _, changed := range r.Files[p] {
+			mark := "."
+			if changed {
+				mark = "x"
+			}
+			line += "\t" + mark
+		}
+		fmt.Fprintln(w, line)
+	}
+	return w.Flush()
+}
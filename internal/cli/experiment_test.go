@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExperimentVariants(t *testing.T) {
+	tests := []struct {
+		agents  []string
+		prompts []string
+		count   int
+		want    []experimentVariant
+	}{
+		{[]string{"claude", "aider"}, nil, 1, []experimentVariant{{Agent: "claude"}, {Agent: "aider"}}},
+		{[]string{"claude"}, []string{"a", "b"}, 1, []experimentVariant{{"claude", "a"}, {"claude", "b"}}},
+		{[]string{"claude", "aider"}, nil, 2, []experimentVariant{{Agent: "claude"}, {Agent: "claude"}, {Agent: "aider"}, {Agent: "aider"}}},
+		{nil, nil, 3, []experimentVariant{{}, {}, {}}},
+	}
+	for _, tt := range tests {
+		if got := experimentVariants(tt.agents, tt.prompts, tt.count); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("experimentVariants(%v, %v, %d) = %+v, want %+v", tt.agents, tt.prompts, tt.count, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// externalPrefix is the executable name prefix of external subcommands:
+// `wt foo args...` runs `wt-foo args...` when foo is not a built-in command.
+const externalPrefix = "wt-"
+
+// findExternal returns the executable implementing an unknown subcommand.
+func findExternal(app *cli.App, args []string) (string, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	name := args[1]
+	if name == "" || strings.HasPrefix(name, "-") || name == "help" || name == "h" || app.Command(name) != nil {
+		return "", false
+	}
+	path, err := exec.LookPath(externalPrefix + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// runExternal runs an external subcommand in place of wt.
+// The subcommand receives the remaining arguments and wt context in the
+// environment: WT_BIN, WT_VERSION, WT_CONFIG_DIR and, when run inside a
+// task worktree, the task variables also given to agents.
+func runExternal(path string, args []string) error {
+	env := map[string]string{"WT_VERSION": Version}
+	if exe, err := os.Executable(); err == nil {
+		env["WT_BIN"] = exe
+	}
+	if dir, err := config.ConfigDir(); err == nil {
+		env["WT_CONFIG_DIR"] = dir
+	}
+	if cfg, err := loadConfig(); err == nil {
+		if t, err := currentTask(cfg); err == nil {
+			taskEnv, err := task.NewManager(cfg).Env(t)
+			if err != nil {
+				return err
+			}
+			for k, v := range taskEnv {
+				env[k] = v
+			}
+		}
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+
+	return execExternal(path, args)
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// execExternal replaces wt with the external subcommand at path.
+func execExternal(path string, args []string) error {
+	argv := append([]string{path}, args...)
+	if err := syscall.Exec(path, argv, os.Environ()); err != nil {
+		return fmt.Errorf("failed to exec %s: %w", path, err)
+	}
+	// Never reached if exec succeeds.
+	return nil
+}
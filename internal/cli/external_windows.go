@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// execExternal runs the external subcommand at path attached to this
+// console and exits with its status, as Windows cannot replace a running
+// process.
+func execExternal(path string, args []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %w", path, err)
+	}
+	os.Exit(0)
+	return nil
+}
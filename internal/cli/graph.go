@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- graph ---
+func graphCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "graph",
+		Category: "navigation",
+		Usage:    "Show tasks as a tree of repositories, base branches and stacks",
+		Description: `Render how in-flight tasks relate to each other.
+
+   Tasks are grouped by repository under the base branch (origin/<default_branch>
+   when it exists). A task whose branch contains another task's unmerged branch
+   is drawn stacked under it. Sub-tasks of another task's ticket are marked,
+   and each task's pull request is shown when the GitHub CLI 'gh' is
+   installed (--offline skips the lookup).
+
+   --dot prints the graph in Graphviz format instead; stacked branches are
+   solid edges and sub-task links dashed ones.
+
+   Examples:
+     wt graph
+     wt graph --offline
+     wt graph --dot | dot -Tsvg > tasks.svg`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "dot", Usage: "Print the graph in Graphviz dot format"},
+			&cli.BoolFlag{Name: "offline", Usage: "Skip the pull request lookups"},
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if len(cfg.Tasks) == 0 {
+				fmt.Println("No active tasks.")
+				return nil
+			}
+			repos := buildGraph(c.Context, cfg, c.Bool("offline"))
+			if c.Bool("dot") {
+				printGraphDot(os.Stdout, repos)
+			} else {
+				printGraphTree(os.Stdout, repos)
+			}
+			return nil
+		},
+	}
+}
+
+// repoGraph is one repository's tasks, rooted at its base branch.
+type repoGraph struct {
+	Name  string
+	Path  string
+	Base  string
+	Roots []*graphNode
+}
+
+// graphNode is a task and the tasks stacked on its branch.
+type graphNode struct {
+	Task     config.Task
+	PR       *pullRequest
+	Children []*graphNode
+}
+
+func (n *graphNode) label() []string {
+	parts := []string{n.Task.ID, n.Task.Branch}
+	if n.Task.TicketKey != "" {
+		parts = append(parts, n.Task.TicketKey)
+	}
+	if n.PR != nil {
+		pr := fmt.Sprintf("PR #%d %s", n.PR.Number, strings.ToLower(n.PR.State))
+		if n.PR.Draft {
+			pr += " (draft)"
+		}
+		parts = append(parts, pr)
+	}
+	if n.Task.Parent != "" {
+		parts = append(parts, "sub-task of "+n.Task.Parent)
+	}
+	return parts
+}
+
+// buildGraph groups tasks by repository and nests stacked tasks under the
+// task they are stacked on.
+func buildGraph(ctx context.Context, cfg *config.Config, offline bool) []repoGraph {
+	byRepo := make(map[string][]config.Task)
+	var paths []string
+	for _, t := range cfg.Tasks {
+		if _, ok := byRepo[t.RepoPath]; !ok {
+			paths = append(paths, t.RepoPath)
+		}
+		byRepo[t.RepoPath] = append(byRepo[t.RepoPath], t)
+	}
+	sort.Strings(paths)
+
+	var prs []*pullRequest
+	if !offline {
+		prs = make([]*pullRequest, len(cfg.Tasks))
+		worktree.RunAll(len(cfg.Tasks), cfg.Concurrency, func(i int) {
+			t := cfg.Tasks[i]
+			pr, err := findPullRequest(ctx, t.Worktree, t.Branch)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s: %v\n", t.ID, err)
+			}
+			prs[i] = pr
+		})
+	}
+	prByID := make(map[string]*pullRequest)
+	for i, pr := range prs {
+		prByID[cfg.Tasks[i].ID] = pr
+	}
+
+	repos := make([]repoGraph, 0, len(paths))
+	for _, path := range paths {
+		tasks := byRepo[path]
+		name, err := task.RepoName(ctx, path)
+		if err != nil {
+			name = filepath.Base(path)
+		}
+		base := worktree.BaseRef(ctx, path, task.DefaultBranch(ctx, cfg, path))
+		contains := func(ancestor, descendant string) bool {
+			ok, err := worktree.IsAncestor(ctx, path, "refs/heads/"+ancestor, "refs/heads/"+descendant)
+			return err == nil && ok
+		}
+		merged := func(branch string) bool {
+			ok, err := worktree.IsAncestor(ctx, path, "refs/heads/"+branch, base)
+			return err == nil && ok
+		}
+		parents := stackParents(tasks, contains, merged)
+
+		nodes := make(map[string]*graphNode, len(tasks))
+		for _, t := range tasks {
+			nodes[t.ID] = &graphNode{Task: t, PR: prByID[t.ID]}
+		}
+		g := repoGraph{Name: name, Path: path, Base: base}
+		for _, t := range tasks {
+			if parent, ok := parents[t.ID]; ok {
+				nodes[parent].Children = append(nodes[parent].Children, nodes[t.ID])
+			} else {
+				g.Roots = append(g.Roots, nodes[t.ID])
+			}
+		}
+		repos = append(repos, g)
+	}
+	return repos
+}
+
+// stackParents finds the task each task's branch is stacked on: the nearest
+// other task whose branch it contains and which isn't merged into the base
+// yet. Of two tasks on the same commit, the older one is the parent.
+func stackParents(tasks []config.Task, contains func(ancestor, descendant string) bool, merged func(branch string) bool) map[string]string {
+	type pair struct{ a, b string }
+	memo := make(map[pair]bool)
+	has := func(a, b string) bool {
+		p := pair{a, b}
+		v, ok := memo[p]
+		if !ok {
+			v = contains(a, b)
+			memo[p] = v
+		}
+		return v
+	}
+	below := func(x, y config.Task) bool {
+		if x.ID == y.ID || x.Branch == y.Branch || !has(x.Branch, y.Branch) {
+			return false
+		}
+		return !has(y.Branch, x.Branch) || x.Created.Before(y.Created)
+	}
+	unmerged := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		unmerged[t.ID] = !merged(t.Branch)
+	}
+
+	parents := make(map[string]string)
+	for _, t := range tasks {
+		var candidates []config.Task
+		for _, c := range tasks {
+			if unmerged[c.ID] && below(c, t) {
+				candidates = append(candidates, c)
+			}
+		}
+	nearest:
+		for _, c := range candidates {
+			for _, other := range candidates {
+				if below(c, other) {
+					continue nearest
+				}
+			}
+			parents[t.ID] = c.ID
+			break
+		}
+	}
+	return parents
+}
+
+func printGraphTree(out io.Writer, repos []repoGraph) {
+	for i, g := range repos {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s (%s)\n", g.Name, g.Path)
+		fmt.Fprintf(out, "└── %s\n", g.Base)
+		printGraphNodes(out, g.Roots, "    ")
+	}
+}
+
+func printGraphNodes(out io.Writer, nodes []*graphNode, indent string) {
+	for i, n := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(out, "%s%s%s\n", indent, branch, strings.Join(n.label(), "  "))
+		printGraphNodes(out, n.Children, indent+next)
+	}
+}
+
+func printGraphDot(out io.Writer, repos []repoGraph) {
+	fmt.Fprintln(out, "digraph wt {")
+	fmt.Fprintln(out, "  rankdir=LR;")
+	fmt.Fprintln(out, "  node [shape=box];")
+	var links []config.Task
+	ids := make(map[string]bool)
+	var walk func(from string, nodes []*graphNode)
+	walk = func(from string, nodes []*graphNode) {
+		for _, n := range nodes {
+			fmt.Fprintf(out, "  %q [label=%q];\n", n.Task.ID, strings.Join(n.label(), "\n"))
+			fmt.Fprintf(out, "  %q -> %q;\n", from, n.Task.ID)
+			ids[n.Task.ID] = true
+			if n.Task.Parent != "" {
+				links = append(links, n.Task)
+			}
+			walk(n.Task.ID, n.Children)
+		}
+	}
+	for _, g := range repos {
+		id := g.Path + ":" + g.Base
+		fmt.Fprintf(out, "  %q [label=%q, shape=ellipse];\n", id, g.Name+"\n"+g.Base)
+		walk(id, g.Roots)
+	}
+	for _, t := range links {
+		if !ids[t.Parent] {
+			continue
+		}
+		fmt.Fprintf(out, "  %q -> %q [style=dashed, label=\"sub-task\"];\n", t.Parent, t.ID)
+	}
+	fmt.Fprintln(out, "}")
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestStackParents(t *testing.T) {
+	now := time.Now()
+	tasks := []config.Task{
+		{ID: "a", Branch: "a", Created: now},
+		{ID: "b", Branch: "b", Created: now.Add(time.Minute)},
+		{ID: "c", Branch: "c", Created: now.Add(2 * time.Minute)},
+		{ID: "d", Branch: "d", Created: now.Add(3 * time.Minute)},
+		{ID: "e", Branch: "e", Created: now.Add(4 * time.Minute)},
+		{ID: "f", Branch: "f", Created: now.Add(5 * time.Minute)},
+	}
+	// a <- b <- c is a stack; d sits on the same commit as c; e is stacked
+	// on m, which is merged; f is independent.
+	ancestors := map[string][]string{
+		"b": {"a", "m"},
+		"c": {"a", "b", "d", "m"},
+		"d": {"a", "b", "c", "m"},
+		"e": {"m"},
+		"m": {},
+	}
+	contains := func(ancestor, descendant string) bool {
+		for _, a := range ancestors[descendant] {
+			if a == ancestor {
+				return true
+			}
+		}
+		return false
+	}
+	merged := func(branch string) bool { return branch == "m" }
+	tasks = append(tasks, config.Task{ID: "m", Branch: "m", Created: now.Add(-time.Hour)})
+
+	got := stackParents(tasks, contains, merged)
+	want := map[string]string{"b": "a", "c": "b", "d": "c"}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for id, parent := range want {
+		if got[id] != parent {
+			t.Errorf("parent of %s = %q, want %q", id, got[id], parent)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/urfave/cli/v2"
+)
+
+// inboxItem is a flagged email and the task started from it, if any.
+type inboxItem struct {
+	connector.Ticket
+	Task string `json:"task,omitempty"`
+}
+
+// --- inbox ---
+func inboxCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "inbox",
+		Category: "config",
+		Usage:    "List flagged emails that can be started as tasks",
+		Description: `Show flagged emails from the mailbox configured with 'wt connect inbox'.
+
+   Each flagged email is a ticket: its subject becomes the task description
+   and its body the ticket description. Start one with
+   'wt start --connector inbox --ticket <KEY>' and unflag it once handled
+   with 'wt inbox done <KEY>'.
+
+   Examples:
+     wt inbox
+     wt start --connector inbox --ticket 4127
+     wt inbox done 4127`,
+		Flags: []cli.Flag{outputFlag()},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			conn, err := inboxConnector(cfg)
+			if err != nil {
+				return err
+			}
+			tickets, err := conn.ListAssigned(c.Context)
+			if err != nil {
+				return err
+			}
+
+			started := make(map[string]string)
+			for _, t := range cfg.Tasks {
+				if t.Connector == "inbox" {
+					started[t.TicketKey] = t.ID
+				}
+			}
+			items := make([]inboxItem, len(tickets))
+			for i, t := range tickets {
+				items[i] = inboxItem{Ticket: t, Task: started[t.Key]}
+			}
+			return f.Write(os.Stdout, items, func(out io.Writer) error {
+				if len(items) == 0 {
+					fmt.Fprintln(out, "No flagged emails.")
+					return nil
+				}
+				w := output.NewTabWriter(out)
+				fmt.Fprintln(w, "KEY\tSUBJECT\tTASK")
+				for _, it := range items {
+					fmt.Fprintf(w, "%s\t%s\t%s\n", it.Key, output.Truncate(it.Summary, 50), it.Task)
+				}
+				return w.Flush()
+			})
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:      "done",
+				Usage:     "Unflag a handled email",
+				ArgsUsage: "<key>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("usage: wt inbox done <key>")
+					}
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					conn, err := inboxConnector(cfg)
+					if err != nil {
+						return err
+					}
+					if err := conn.TransitionTicket(c.Context, c.Args().First(), "done"); err != nil {
+						return err
+					}
+					fmt.Printf("✅ Unflagged %s\n", c.Args().First())
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func inboxConnector(cfg *config.Config) (connector.Connector, error) {
+	conn, ok := buildRegistry(cfg).Get("inbox")
+	if !ok {
+		return nil, fmt.Errorf("%w: inbox; run 'wt connect inbox' first", connector.ErrNotConfigured)
+	}
+	return conn, nil
+}
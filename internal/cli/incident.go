@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/connector/incident"
+)
+
+// incidentConnector returns the connector 'wt start --incident' fetches
+// from: name when given, or else the one incident connector configured.
+func incidentConnector(cfg *config.Config, name string) (string, error) {
+	if name != "" {
+		if !incident.IsIncident(name) {
+			return "", fmt.Errorf("%s is not an incident connector (want %s)", name, strings.Join(incident.Names, " or "))
+		}
+		return name, nil
+	}
+	var configured []string
+	for _, n := range incident.Names {
+		if _, ok := cfg.Connectors[n]; ok {
+			configured = append(configured, n)
+		}
+	}
+	switch len(configured) {
+	case 0:
+		return "", fmt.Errorf("%w: no incident connector; run 'wt connect pagerduty' or 'wt connect opsgenie' first", connector.ErrNotConfigured)
+	case 1:
+		return configured[0], nil
+	}
+	return "", fmt.Errorf("both %s are connected; choose one with --connector", strings.Join(configured, " and "))
+}
+
+// hotfixPrefix returns the branch prefix of tasks started from incidents.
+func hotfixPrefix(cfg *config.Config) string {
+	if cfg.HotfixPrefix != "" {
+		return cfg.HotfixPrefix
+	}
+	return config.DefaultHotfixPrefix
+}
+
+// incidentNotes returns the notes of a task started from an incident: its
+// severity and the rest of what the service reports, so agents get them in
+// WT_TASK_NOTES, followed by its details.
+func incidentNotes(t *connector.Ticket) string {
+	var b strings.Builder
+	line := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", label, value)
+		}
+	}
+	line("Incident", t.Key)
+	line("Severity", t.Priority)
+	line("Status", t.Status)
+	for _, k := range sortedKeys(t.Metadata) {
+		line(k, t.Metadata[k])
+	}
+	line("URL", t.URL)
+	if desc := strings.TrimSpace(t.Description); desc != "" {
+		fmt.Fprintf(&b, "\n%s\n", desc)
+	}
+	return strings.TrimSpace(b.String())
+}
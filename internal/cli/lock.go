@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/urfave/cli/v2"
+)
+
+// --- lock ---
+func lockCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "lock",
+		Category:  "lifecycle",
+		Usage:     "Lock a task so it can't be finished or removed",
+		ArgsUsage: "[task-id]",
+		Description: `Lock a task so 'wt finish' and 'wt remove' refuse it until it is
+   unlocked or they are given --force. Useful when the wt state lives on a
+   shared drive, or while someone else is reviewing a worktree.
+
+   Tasks are also locked automatically while an agent launched with
+   'wt agent' or 'wt start --agent' is running in them.
+
+   Without a task ID, locks the task of the current worktree.
+
+   Examples:
+     wt lock --reason "pairing with Sam" wt-abc123
+     wt lock`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "reason", Aliases: []string{"m"}, Usage: "Why the task is locked, shown to others"},
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			if err := cfg.LockTask(t.ID, lockOwner(), c.String("reason")); err != nil {
+				return err
+			}
+			fmt.Printf("🔒 Locked %s\n", t.ID)
+			return nil
+		},
+	}
+}
+
+// --- unlock ---
+func unlockCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "unlock",
+		Category:  "lifecycle",
+		Usage:     "Remove a task's lock",
+		ArgsUsage: "[task-id]",
+		Description: `Remove a lock set with 'wt lock', whoever set it.
+
+   Without a task ID, unlocks the task of the current worktree.
+
+   Example:
+     wt unlock wt-abc123`,
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			if t.Lock == nil {
+				fmt.Printf("%s is not locked.\n", t.ID)
+				return nil
+			}
+			if owner := t.Lock.Owner; owner != lockOwner() {
+				fmt.Fprintf(os.Stderr, "warning: removing a lock held by %s\n", owner)
+			}
+			if err := cfg.UnlockTask(t.ID); err != nil {
+				return err
+			}
+			fmt.Printf("🔓 Unlocked %s\n", t.ID)
+			return nil
+		},
+	}
+}
+
+// lockOwner identifies the current user and machine, e.g. "ann@laptop".
+func lockOwner() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return name + "@" + host
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/telemetry"
+	"github.com/urfave/cli/v2"
+)
+
+// uploadInterval is how often recorded usage is sent to telemetry_url.
+const uploadInterval = 24 * time.Hour
+
+// uploadCommand is the hidden wt subcommand that sends recorded usage to
+// telemetry_url, run detached so that no command waits for the upload.
+const uploadCommand = "__upload-telemetry"
+
+func telemetryStore() (telemetry.Store, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return telemetry.Store{}, err
+	}
+	return telemetry.Store{Dir: filepath.Join(dir, "telemetry")}, nil
+}
+
+// recordUsage records a finished invocation when telemetry is enabled, and
+// starts uploading recorded usage in the background when telemetry_url is
+// set and an upload is due. Failures never affect the command.
+func recordUsage(app *cli.App, args []string, start time.Time, runErr error) {
+	if len(args) > 1 && args[1] == uploadCommand {
+		return
+	}
+	cfg, err := config.LoadSettings()
+	if err != nil || !cfg.Telemetry {
+		return
+	}
+	store, err := telemetryStore()
+	if err != nil {
+		return
+	}
+	name := telemetry.CommandName(args, func(path string) bool {
+		return lookupCommand(app, path) != nil
+	})
+	store.Record(telemetry.Event{
+		Command:  name,
+		Duration: time.Since(start),
+		Exit:     ExitCode(runErr),
+		Time:     time.Now(),
+		Version:  Version,
+	})
+	if cfg.TelemetryURL != "" && store.UploadDue(uploadInterval) {
+		spawnWorker(uploadCommand)
+	}
+}
+
+// spawnWorker starts 'wt <command>' detached, for a hidden worker command
+// no command should wait for. Failures are ignored.
+func spawnWorker(command string) {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(exe, command)
+	agent.Detach(cmd)
+	if err := cmd.Start(); err == nil {
+		cmd.Process.Release()
+	}
+}
+
+// --- __upload-telemetry (internal) ---
+func uploadWorkerCmd() *cli.Command {
+	return &cli.Command{
+		Name:   uploadCommand,
+		Hidden: true,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.LoadSettings()
+			if err != nil || !cfg.Telemetry || cfg.TelemetryURL == "" {
+				return err
+			}
+			store, err := telemetryStore()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(c.Context, 30*time.Second)
+			defer cancel()
+			_, err = store.Upload(ctx, cfg.TelemetryURL)
+			return err
+		},
+	}
+}
+
+// lookupCommand resolves a space-separated command path such as
+// "attach pull", following aliases.
+func lookupCommand(app *cli.App, path string) *cli.Command {
+	var cmd *cli.Command
+	commands := app.Commands
+	for _, name := range strings.Fields(path) {
+		cmd = nil
+		for _, c := range commands {
+			if c.HasName(name) {
+				cmd = c
+				break
+			}
+		}
+		if cmd == nil {
+			return nil
+		}
+		commands = cmd.Subcommands
+	}
+	return cmd
+}
+
+// --- metrics ---
+func metricsCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "metrics",
+		Category: "maintenance",
+		Usage:    "Show how long wt commands take",
+		Description: `Show per-command run counts, failures and latency (mean, p50, p90 and
+   max) from usage recorded on this machine, slowest overall first.
+
+   Recording is off by default. Enable it with 'wt config telemetry true'.
+   Only the command name, duration, exit code, wt version and OS are kept,
+   never arguments, paths or ticket data. Set telemetry_url to also upload
+   them once a day, tagged with a random installation ID.
+
+   Examples:
+     wt metrics
+     wt metrics --since 168h
+     wt metrics clear`,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{Name: "since", Usage: "Only include commands run within this duration"},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := telemetryStore()
+			if err != nil {
+				return err
+			}
+			events, err := store.Events()
+			if err != nil {
+				return err
+			}
+			if since := c.Duration("since"); since > 0 {
+				cutoff := time.Now().Add(-since)
+				kept := events[:0]
+				for _, e := range events {
+					if e.Time.After(cutoff) {
+						kept = append(kept, e)
+					}
+				}
+				events = kept
+			}
+			stats := telemetry.Summarize(events)
+			return f.Write(os.Stdout, stats, func(w io.Writer) error {
+				if len(stats) == 0 {
+					if !cfg.Telemetry {
+						fmt.Fprintln(w, "Telemetry is off. Enable it with: wt config telemetry true")
+					} else {
+						fmt.Fprintln(w, "No commands recorded yet.")
+					}
+					return nil
+				}
+				printMetrics(w, stats)
+				return nil
+			})
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "clear",
+				Usage: "Delete recorded usage",
+				Action: func(c *cli.Context) error {
+					store, err := telemetryStore()
+					if err != nil {
+						return err
+					}
+					if err := store.Clear(); err != nil {
+						return fmt.Errorf("failed to clear metrics: %w", err)
+					}
+					fmt.Println("Cleared recorded usage.")
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func printMetrics(out io.Writer, stats []telemetry.Stats) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMMAND\tRUNS\tERRORS\tMEAN\tP50\tP90\tMAX")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", s.Command, s.Runs, s.Errors,
+			formatLatency(s.Mean), formatLatency(s.P50), formatLatency(s.P90), formatLatency(s.Max))
+	}
+	w.Flush()
+}
+
+func formatLatency(d time.Duration) string {
+	switch {
+	case d < 10*time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
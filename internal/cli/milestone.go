@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/urfave/cli/v2"
+)
+
+// --- milestone ---
+func milestoneCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "milestone",
+		Category: "lifecycle",
+		Usage:    "Group tasks by the release they are planned for",
+		Description: `Assign tasks to a named milestone, such as a release, to list them
+   together with 'wt list --milestone <name>' and report on them with
+   'wt milestone report <name>'. A task belongs to at most one milestone.
+
+   Without a subcommand, lists the milestones of active tasks.
+
+   Examples:
+     wt milestone set v2.1 wt-abc123 wt-def456
+     wt milestone set v2.1                 # the task of the current worktree
+     wt milestone unset wt-def456
+     wt milestone
+     wt milestone report v2.1
+     wt milestone report -o json v2.1`,
+		Flags: []cli.Flag{outputFlag()},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			milestones := milestoneCounts(cfg.Tasks)
+			return f.Write(os.Stdout, milestones, func(w io.Writer) error {
+				if len(milestones) == 0 {
+					fmt.Fprintln(w, "No milestones. Assign tasks with 'wt milestone set <name> <task-id>...'.")
+					return nil
+				}
+				tw := output.NewTabWriter(w)
+				fmt.Fprintln(tw, "MILESTONE\tTASKS")
+				for _, m := range milestones {
+					fmt.Fprintf(tw, "%s\t%d\n", m.Name, m.Tasks)
+				}
+				return tw.Flush()
+			})
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:      "set",
+				Usage:     "Assign tasks to a milestone",
+				ArgsUsage: "<milestone> [task-id...]",
+				Action: func(c *cli.Context) error {
+					milestone := c.Args().First()
+					if milestone == "" {
+						return fmt.Errorf("please provide a milestone name")
+					}
+					return setMilestone(c, c.Args().Tail(), milestone)
+				},
+			},
+			{
+				Name:      "unset",
+				Usage:     "Remove tasks from their milestone",
+				ArgsUsage: "[task-id...]",
+				Action: func(c *cli.Context) error {
+					return setMilestone(c, c.Args().Slice(), "")
+				},
+			},
+			{
+				Name:      "report",
+				Usage:     "Show the branches, pull requests and tickets of a milestone",
+				ArgsUsage: "<milestone>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "offline", Usage: "Skip the ticket and pull request lookups"},
+					outputFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					milestone := c.Args().First()
+					if milestone == "" {
+						return fmt.Errorf("please provide a milestone name (see 'wt milestone')")
+					}
+					f, err := formatter(c)
+					if err != nil {
+						return err
+					}
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					tasks := milestoneTasks(cfg.Tasks, milestone)
+					if len(tasks) == 0 {
+						return fmt.Errorf("no active tasks in milestone %s", milestone)
+					}
+					report := milestoneReport(c.Context, cfg, milestone, tasks, c.Bool("offline"))
+					return f.Write(os.Stdout, report, report.printTable)
+				},
+			},
+		},
+	}
+}
+
+// setMilestone assigns the tasks named by ids, or the current task when
+// there are none, to milestone.
+func setMilestone(c *cli.Context, ids []string, milestone string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		t, err := currentTask(cfg)
+		if err != nil {
+			return err
+		}
+		ids = []string{t.ID}
+	}
+	if err := cfg.SetTaskMilestone(ids, milestone); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if milestone == "" {
+			fmt.Printf("✅ Removed %s from its milestone\n", id)
+		} else {
+			fmt.Printf("✅ Assigned %s to milestone %s\n", id, milestone)
+		}
+	}
+	return nil
+}
+
+// milestoneTasks returns the tasks of a milestone, in the order given.
+func milestoneTasks(tasks []config.Task, milestone string) []config.Task {
+	var in []config.Task
+	for _, t := range tasks {
+		if t.Milestone == milestone {
+			in = append(in, t)
+		}
+	}
+	return in
+}
+
+type milestoneCount struct {
+	Name  string `json:"name"`
+	Tasks int    `json:"tasks"`
+}
+
+// milestoneCounts returns the milestones of tasks with their number of
+// tasks, by name.
+func milestoneCounts(tasks []config.Task) []milestoneCount {
+	counts := make(map[string]int)
+	for _, t := range tasks {
+		if t.Milestone != "" {
+			counts[t.Milestone]++
+		}
+	}
+	milestones := make([]milestoneCount, 0, len(counts))
+	for name, n := range counts {
+		milestones = append(milestones, milestoneCount{Name: name, Tasks: n})
+	}
+	sort.Slice(milestones, func(i, j int) bool { return milestones[i].Name < milestones[j].Name })
+	return milestones
+}
+
+// milestoneItem is a task of a milestone with its ticket and pull request.
+type milestoneItem struct {
+	Task         string       `json:"task"`
+	Description  string       `json:"description"`
+	Branch       string       `json:"branch"`
+	Ticket       string       `json:"ticket,omitempty"`
+	TicketStatus string       `json:"ticket_status,omitempty"`
+	PullRequest  *pullRequest `json:"pull_request,omitempty"`
+}
+
+type milestoneSummary struct {
+	Milestone string          `json:"milestone"`
+	Tasks     []milestoneItem `json:"tasks"`
+	Tickets   []string        `json:"tickets"`
+	Open      int             `json:"open_pull_requests"`
+	Merged    int             `json:"merged_pull_requests"`
+}
+
+// milestoneReport looks up the ticket and pull request of each task of a
+// milestone. offline skips the lookups.
+func milestoneReport(ctx context.Context, cfg *config.Config, milestone string, tasks []config.Task, offline bool) milestoneSummary {
+	report := milestoneSummary{Milestone: milestone, Tasks: make([]milestoneItem, len(tasks)), Tickets: []string{}}
+	var tickets map[connector.Ref]connector.FetchResult
+	if !offline {
+		tickets = fetchTaskTickets(ctx, cfg, tasks)
+	}
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		item := &report.Tasks[i]
+		*item = milestoneItem{Task: t.ID, Description: t.Description, Branch: t.Branch, Ticket: t.TicketKey}
+		if t.TicketKey != "" {
+			report.Tickets = append(report.Tickets, t.TicketKey)
+			if res := tickets[connector.Ref{Connector: t.Connector, Key: t.TicketKey}]; res.Ticket != nil {
+				item.TicketStatus = res.Ticket.Status
+			}
+		}
+		if offline {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pr, err := findPullRequest(ctx, t.Worktree, t.Branch)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s: %v\n", t.ID, err)
+			}
+			item.PullRequest = pr
+		}()
+	}
+	wg.Wait()
+	for _, item := range report.Tasks {
+		switch {
+		case item.PullRequest == nil:
+		case item.PullRequest.State == "OPEN":
+			report.Open++
+		case item.PullRequest.State == "MERGED":
+			report.Merged++
+		}
+	}
+	return report
+}
+
+func (r milestoneSummary) printTable(out io.Writer) error {
+	fmt.Fprintf(out, "Milestone %s: %d task(s), %d open and %d merged pull request(s)\n\n", r.Milestone, len(r.Tasks), r.Open, r.Merged)
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, "TASK\tBRANCH\tTICKET\tSTATUS\tPULL REQUEST")
+	for _, item := range r.Tasks {
+		ticket, status, pr := "-", "-", "-"
+		if item.Ticket != "" {
+			ticket = item.Ticket
+		}
+		if item.TicketStatus != "" {
+			status = item.TicketStatus
+		}
+		if item.PullRequest != nil {
+			pr = item.PullRequest.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Task, item.Branch, ticket, status, pr)
+	}
+	return w.Flush()
+}
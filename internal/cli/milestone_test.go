@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestMilestoneCounts(t *testing.T) {
+	tasks := []config.Task{
+		{ID: "wt-1", Milestone: "v2.1"},
+		{ID: "wt-2"},
+		{ID: "wt-3", Milestone: "v2.0"},
+		{ID: "wt-4", Milestone: "v2.1"},
+	}
+	want := []milestoneCount{{Name: "v2.0", Tasks: 1}, {Name: "v2.1", Tasks: 2}}
+	if got := milestoneCounts(tasks); !reflect.DeepEqual(got, want) {
+		t.Errorf("milestoneCounts = %v, want %v", got, want)
+	}
+	var ids []string
+	for _, task := range milestoneTasks(tasks, "v2.1") {
+		ids = append(ids, task.ID)
+	}
+	if want := []string{"wt-1", "wt-4"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("milestoneTasks(v2.1) = %v, want %v", ids, want)
+	}
+}
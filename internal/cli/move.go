@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- move ---
+func moveCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "move",
+		Aliases:   []string{"mv"},
+		Category:  "lifecycle",
+		Usage:     "Move a task's worktree to a new path",
+		ArgsUsage: "<task-id> <new-path>",
+		Description: `Move a task's worktree with 'git worktree move' and update wt's records.
+
+   Symlinks the move would break are fixed: absolute links into the worktree
+   and relative links to directories outside it (e.g. a node_modules shared
+   between worktrees). A .envrc generated by wt is regenerated for the new path.
+
+   With --migrate, moves every worktree outside worktrees_base into it, as
+   <worktrees_base>/<repo>/<name>. 'wt config worktrees_base <dir>' offers to
+   do this when the setting changes.
+
+   A locked task or one with a running agent is only moved with --force.
+
+   Examples:
+     wt move wt-abc123 ~/src/worktrees/login-fix
+     wt move --migrate`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "migrate", Usage: "Move all worktrees outside worktrees_base into it"},
+			&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "Move locked tasks too"},
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			mgr := task.NewManager(cfg)
+			mgr.Force = c.Bool("force")
+			if c.Bool("migrate") {
+				return migrateWorktrees(c.Context, mgr)
+			}
+			if c.NArg() < 2 {
+				return fmt.Errorf("please provide a task ID and the new path")
+			}
+			newPath := c.Args().Get(1)
+			if strings.HasPrefix(newPath, "~/") {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return fmt.Errorf("failed to resolve home directory: %w", err)
+				}
+				newPath = filepath.Join(home, newPath[2:])
+			}
+			t, err := mgr.Move(c.Context, c.Args().First(), newPath)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ Moved %s to %s\n", t.ID, t.Worktree)
+			return nil
+		},
+	}
+}
+
+// outsideBase returns the tasks whose worktrees are not under worktrees_base,
+// leaving out those on other machines.
+func outsideBase(cfg *config.Config) []config.Task {
+	base := filepath.Clean(cfg.WorktreesBase) + string(filepath.Separator)
+	var tasks []config.Task
+	for _, t := range cfg.Tasks {
+		if !strings.HasPrefix(t.Worktree, base) && !worktree.IsRemote(t.Worktree) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// migrateWorktrees moves the worktrees outside worktrees_base into it. A
+// task that fails to move is reported and skipped.
+func migrateWorktrees(ctx context.Context, mgr *task.Manager) error {
+	tasks := outsideBase(mgr.Config)
+	if len(tasks) == 0 {
+		fmt.Printf("All worktrees are already in %s.\n", mgr.Config.WorktreesBase)
+		return nil
+	}
+	failed := 0
+	for _, t := range tasks {
+		repo, err := task.RepoName(ctx, t.RepoPath)
+		if err != nil {
+			repo = filepath.Base(t.RepoPath)
+		}
+		moved, err := mgr.Move(ctx, t.ID, filepath.Join(mgr.Config.WorktreesBase, repo, filepath.Base(t.Worktree)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to move %s: %v\n", t.ID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ Moved %s to %s\n", t.ID, moved.Worktree)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to move %d of %d worktree(s)", failed, len(tasks))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/urfave/cli/v2"
+)
+
+// outputFlag selects the output format of commands that print results.
+func outputFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Value:   output.Table,
+		Usage:   "Output format: table, json, yaml or template=<go template>",
+	}
+}
+
+// formatter returns the formatter selected with --output, defaulting to
+// JSON when the global --json flag, or a command's own, is set.
+func formatter(c *cli.Context) (*output.Formatter, error) {
+	if !c.IsSet("output") {
+		for _, ctx := range c.Lineage() {
+			if ctx.Bool("json") {
+				return output.New(output.JSON)
+			}
+		}
+	}
+	return output.New(c.String("output"))
+}
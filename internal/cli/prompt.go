@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// --- prompt ---
+func promptCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "prompt",
+		Category: "navigation",
+		Usage:    "Print the current task for use in a shell prompt",
+		Description: `Print the ticket key (or task ID) of the task whose worktree contains
+   the current directory. Prints nothing outside a wt-managed worktree.
+
+   Reads a small index file instead of the full config so it is cheap
+   enough to run on every prompt.
+
+   Examples:
+     PS1='$(wt prompt) \$ '
+
+     # starship.toml
+     [custom.wt]
+     command = "wt prompt"
+     when = "wt prompt"`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "json", Usage: "Print the task as JSON"},
+		},
+		Action: func(c *cli.Context) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			idx, err := config.IndexPath()
+			if err != nil {
+				return err
+			}
+			e, ok, err := config.LookupIndex(idx, cwd)
+			if errors.Is(err, os.ErrNotExist) {
+				// Index predates this wt version; rebuild it once.
+				var cfg *config.Config
+				cfg, err = loadConfig()
+				if err != nil {
+					return err
+				}
+				if err = cfg.Save(); err != nil {
+					return err
+				}
+				e, ok, err = config.LookupIndex(idx, cwd)
+			}
+			if err != nil {
+				return err
+			}
+			if !ok {
+				// Non-zero exit lets starship's `when` hide the module.
+				return cli.Exit("", 1)
+			}
+			if c.Bool("json") {
+				return json.NewEncoder(os.Stdout).Encode(e)
+			}
+			if e.TicketKey != "" {
+				fmt.Println(e.TicketKey)
+			} else {
+				fmt.Println(e.ID)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPromptWithoutIndex(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("WT_JSON", "")
+	one := filepath.Join(home, "worktrees", "repo", "one")
+	if err := os.MkdirAll(one, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `tasks:
+  - id: wt-1
+    worktree: ` + one + `
+    branch: feature/one
+    ticket_key: PROJ-1
+`
+	os.MkdirAll(filepath.Join(home, ".wt"), 0o755)
+	if err := os.WriteFile(filepath.Join(home, ".wt", "config.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No worktrees.idx yet: the first prompt rebuilds it.
+	t.Chdir(one)
+	stdout, stderr, err := runCaptured(t, "prompt")
+	if err != nil || stdout != "PROJ-1\n" {
+		t.Errorf("prompt printed %q, %q, %v; want PROJ-1", stdout, stderr, err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".wt", "worktrees.idx")); err != nil {
+		t.Errorf("index not rebuilt: %v", err)
+	}
+}
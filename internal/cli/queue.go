@@ -0,0 +1,397 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// --- queue ---
+func queueCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "queue",
+		Category: "lifecycle",
+		Usage:    "Plan tasks and start them one after another",
+		Description: `Keep a backlog of planned tasks, e.g. a day's work, and start them one by
+   one. 'wt queue add' plans a task from a description or a ticket;
+   'wt queue next' starts the most urgent item, the oldest first among
+   equally urgent ones, and launches its agent. Priorities are names such as
+   high or P1; for a ticket, its priority is used unless --priority is given.
+
+   Without a subcommand, lists the queue in the order it will be started.
+
+   Examples:
+     wt queue add "refactor billing" --ticket jira:PROJ-9
+     wt queue add --priority high --agent claude "fix flaky login test"
+     wt queue
+     wt queue next
+     wt queue remove q-1a2b3c`,
+		Flags: []cli.Flag{outputFlag()},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			items, err := config.LoadQueue()
+			if err != nil {
+				return err
+			}
+			sortQueue(items)
+			return f.Write(os.Stdout, items, func(w io.Writer) error {
+				if len(items) == 0 {
+					fmt.Fprintln(w, "The queue is empty. Plan tasks with 'wt queue add'.")
+					return nil
+				}
+				tw := output.NewTabWriter(w)
+				fmt.Fprintln(tw, "#\tID\tPRIORITY\tTICKET\tDESCRIPTION\tREPO\tADDED")
+				for i, item := range items {
+					fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1, item.ID, orDash(item.Priority), orDash(item.TicketKey),
+						output.Truncate(item.Description, 50), filepath.Base(item.RepoPath), timeAgo(item.Added))
+				}
+				return tw.Flush()
+			})
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Plan a task in the current repository",
+				ArgsUsage: "[description]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "ticket", Usage: "Ticket of the connector given by --connector, or a key like jira:PROJ-123"},
+					&cli.StringFlag{Name: "connector", Usage: "Connector of --ticket"},
+					&cli.StringFlag{Name: "priority", Aliases: []string{"p"}, Usage: "Priority, e.g. high or P1 (default: the ticket's)"},
+					&cli.StringFlag{Name: "agent", Usage: "Agent to launch when the task is started"},
+				},
+				Action: queueAdd,
+			},
+			{
+				Name:  "next",
+				Usage: "Start the next queued task and launch its agent",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "agent", Usage: "Agent to launch, instead of the item's, WT_AGENT or default_agent"},
+					&cli.StringFlag{Name: "agent-args", Usage: "Arguments to pass to the agent"},
+					&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "Start even if the checkout is dirty or mid-rebase (with start_check: block)"},
+				},
+				Action: queueNext,
+			},
+			{
+				Name:      "remove",
+				Usage:     "Drop items from the queue",
+				ArgsUsage: "<id>...",
+				Action: func(c *cli.Context) error {
+					ids := c.Args().Slice()
+					if len(ids) == 0 {
+						return fmt.Errorf("please provide the IDs of the items to remove (see 'wt queue')")
+					}
+					err := config.UpdateQueue(func(items []config.QueueItem) ([]config.QueueItem, error) {
+						for _, id := range ids {
+							i := slices.IndexFunc(items, func(item config.QueueItem) bool { return item.ID == id })
+							if i < 0 {
+								return nil, fmt.Errorf("no queued item %q", id)
+							}
+							items = slices.Delete(items, i, i+1)
+						}
+						return items, nil
+					})
+					if err != nil {
+						return err
+					}
+					fmt.Printf("✅ Removed %d item(s) from the queue\n", len(ids))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func queueAdd(c *cli.Context) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	repoPath, err := getRepoPath()
+	if err != nil {
+		return err
+	}
+	item := config.QueueItem{
+		ID:          newQueueID(),
+		Description: joinArgs(c),
+		RepoPath:    repoPath,
+		Priority:    c.String("priority"),
+		Agent:       c.String("agent"),
+		Added:       time.Now(),
+	}
+	item.Connector, item.TicketKey = c.String("connector"), c.String("ticket")
+	if item.Connector == "" && item.TicketKey != "" {
+		item.Connector, item.TicketKey = splitTicketRef(cfg, item.TicketKey)
+	}
+	switch {
+	case item.TicketKey != "":
+		if item.Connector == "" {
+			return fmt.Errorf("--ticket requires --connector, or a key prefixed with one, e.g. jira:PROJ-123")
+		}
+		if existing, err := cfg.FindTaskByTicket(item.Connector, item.TicketKey); err == nil {
+			return fmt.Errorf("%s already has task %s", item.TicketKey, existing.ID)
+		}
+		if item.Description == "" || item.Priority == "" {
+			if ticket := lookupTicket(c.Context, cfg, item.Connector, item.TicketKey); ticket != nil {
+				if item.Description == "" {
+					item.Description = ticket.Summary
+				}
+				if item.Priority == "" {
+					item.Priority = ticket.Priority
+				}
+			}
+		}
+	case item.Description == "":
+		return fmt.Errorf("please provide a task description or --ticket")
+	}
+
+	var position int
+	err = config.UpdateQueue(func(items []config.QueueItem) ([]config.QueueItem, error) {
+		for _, queued := range items {
+			if item.TicketKey != "" && queued.Connector == item.Connector && queued.TicketKey == item.TicketKey {
+				return nil, fmt.Errorf("%s is already queued as %s", item.TicketKey, queued.ID)
+			}
+		}
+		items = append(items, item)
+		sorted := slices.Clone(items)
+		sortQueue(sorted)
+		position = slices.IndexFunc(sorted, func(q config.QueueItem) bool { return q.ID == item.ID }) + 1
+		return items, nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📋 Queued %s: %s (position %d)\n", item.ID, item.Description, position)
+	return nil
+}
+
+func queueNext(c *cli.Context) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	item, err := popQueue(nil)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return fmt.Errorf("the queue is empty; plan tasks with 'wt queue add'")
+	}
+	t, err := startQueued(c.Context, cfg, *item, c.Bool("force"))
+	if err != nil {
+		requeue(*item)
+		return err
+	}
+	fmt.Printf("✅ Task started: %s\n", t.ID)
+	fmt.Printf("   Branch:   %s\n", t.Branch)
+	fmt.Printf("   Worktree: %s\n", t.Worktree)
+
+	explicit := c.String("agent")
+	if explicit == "" {
+		explicit = item.Agent
+	}
+	agentName := resolveAgent(explicit, os.Getenv("WT_AGENT"), cfg.DefaultAgent)
+	return enterStarted(cfg, t, agentName, c.String("agent-args"))
+}
+
+// startQueued starts the task a queue item plans, checking the start point
+// as 'wt start' does. A description given when queuing a ticket replaces
+// the ticket's summary.
+func startQueued(ctx context.Context, cfg *config.Config, item config.QueueItem, force bool) (*config.Task, error) {
+	if _, err := os.Stat(item.RepoPath); err != nil {
+		return nil, fmt.Errorf("repository of %s is missing: %w", item.ID, err)
+	}
+	if err := checkStartPoint(ctx, cfg, item.RepoPath, force); err != nil {
+		return nil, err
+	}
+	opts := task.StartOptions{RepoPath: item.RepoPath, Description: item.Description}
+	if item.TicketKey != "" {
+		if _, err := fetchStartTicket(ctx, cfg, item.Connector, item.TicketKey, &opts); err != nil {
+			return nil, err
+		}
+		if item.Description != "" {
+			opts.Description = item.Description
+		}
+	}
+	return task.NewManager(cfg).Start(ctx, opts)
+}
+
+// popQueue takes the next item off the queue, passing over those skip
+// reports, or returns nil when there is none. Taking it first keeps two
+// processes from starting the same item.
+func popQueue(skip func(config.QueueItem) bool) (*config.QueueItem, error) {
+	var next *config.QueueItem
+	err := config.UpdateQueue(func(items []config.QueueItem) ([]config.QueueItem, error) {
+		next = nil
+		sorted := slices.Clone(items)
+		sortQueue(sorted)
+		for i := range sorted {
+			if skip == nil || !skip(sorted[i]) {
+				next = &sorted[i]
+				break
+			}
+		}
+		if next == nil {
+			return items, nil
+		}
+		return slices.DeleteFunc(items, func(item config.QueueItem) bool { return item.ID == next.ID }), nil
+	})
+	return next, err
+}
+
+// requeue puts back an item that failed to start.
+func requeue(item config.QueueItem) {
+	err := config.UpdateQueue(func(items []config.QueueItem) ([]config.QueueItem, error) {
+		return append(items, item), nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to put %s back in the queue: %v\n", item.ID, err)
+	}
+}
+
+// sortQueue orders items as they are started: the most urgent first, then
+// the oldest.
+func sortQueue(items []config.QueueItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if c := connector.ComparePriority(a.Priority, b.Priority); c != 0 {
+			return c < 0
+		}
+		return a.Added.Before(b.Added)
+	})
+}
+
+// newQueueID returns a new ID for a queue item.
+func newQueueID() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return fmt.Sprintf("q-%x", b)
+}
+
+// queueScheduler keeps up to slots agents busy for 'wt serve
+// --queue-agents': whenever fewer agents run in wt's tasks, it starts the
+// next queued task and launches its agent detached. An item whose task or
+// agent fails to start goes back in the queue, and is passed over until
+// the server restarts.
+type queueScheduler struct {
+	slots int
+	// agent is launched for items queued without one.
+	agent  string
+	failed map[string]bool
+
+	mu sync.Mutex
+	// running holds the PIDs of the agents this server started that it
+	// has not reaped yet.
+	running map[int]bool
+}
+
+// fill starts queued tasks until slots agents run: those this server
+// started and that haven't exited, and the others recorded in tasks whose
+// process is still the agent's.
+func (q *queueScheduler) fill(ctx context.Context, cfg *config.Config) {
+	q.mu.Lock()
+	busy := len(q.running)
+	for _, t := range cfg.Tasks {
+		if !q.running[t.AgentPID] && agent.IsAlive(t.AgentPID, t.AgentStarted) {
+			busy++
+		}
+	}
+	q.mu.Unlock()
+	for ; busy < q.slots; busy++ {
+		item, err := popQueue(func(item config.QueueItem) bool { return q.failed[item.ID] })
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			return
+		}
+		if item == nil {
+			return
+		}
+		if err := q.start(ctx, *item); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: queue: %v\n", err)
+			return
+		}
+	}
+}
+
+// start starts the task of a queue item popped off the queue, and its
+// agent. The item is put back if the task could not be started.
+func (q *queueScheduler) start(ctx context.Context, item config.QueueItem) error {
+	agentName := item.Agent
+	if agentName == "" {
+		agentName = q.agent
+	}
+	cfg, err := loadConfig()
+	if err == nil {
+		agentName = resolveAgent(agentName, "", cfg.DefaultAgent)
+		err = agent.ValidateAgent(agentName, cfg.AgentAliases)
+	}
+	var t *config.Task
+	if err == nil {
+		t, err = startQueued(ctx, cfg, item, false)
+	}
+	if err != nil {
+		q.failed[item.ID] = true
+		requeue(item)
+		return fmt.Errorf("failed to start %s: %w", item.ID, err)
+	}
+	pid, where, err := launchDetachedAgent(cfg, t, agentName, "", "")
+	if err != nil {
+		q.failed[item.ID] = true
+		if abortErr := task.NewManager(cfg).Abort(ctx, t.ID); abortErr != nil {
+			return fmt.Errorf("task %s started from %s, but its agent failed: %w (and removing the task failed: %v)", t.ID, item.ID, err, abortErr)
+		}
+		requeue(item)
+		return fmt.Errorf("agent of %s failed, %s is back in the queue: %w", item.ID, item.ID, err)
+	}
+	q.mu.Lock()
+	if q.running == nil {
+		q.running = make(map[int]bool)
+	}
+	q.running[pid] = true
+	q.mu.Unlock()
+	go q.reap(t.ID, pid)
+	fmt.Printf("Queue: started %s from %s, %s running %s\n", t.ID, item.ID, agentName, where)
+	return nil
+}
+
+// reap waits for an agent process started by this one, so that once it
+// exits it doesn't linger as a zombie that still looks like it's running,
+// and forgets it in its task. Agents in tmux windows are not this
+// process's children; waiting for them fails at once, and fill checks
+// their PID instead.
+func (q *queueScheduler) reap(id string, pid int) {
+	p, err := os.FindProcess(pid)
+	if err == nil {
+		_, err = p.Wait()
+	}
+	q.mu.Lock()
+	delete(q.running, pid)
+	q.mu.Unlock()
+	if err != nil {
+		return
+	}
+	cfg, err := loadConfig()
+	if err == nil {
+		err = cfg.ClearAgent(id, pid)
+	}
+	if err != nil && !errors.Is(err, config.ErrTaskNotFound) {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
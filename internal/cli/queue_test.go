@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestQueue(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Now()
+	items := []config.QueueItem{
+		{ID: "q-old", Added: now.Add(-2 * time.Hour)},
+		{ID: "q-low", Priority: "low", Added: now.Add(-3 * time.Hour)},
+		{ID: "q-urgent", Priority: "P1", Added: now},
+		{ID: "q-new", Added: now.Add(-time.Hour)},
+	}
+	err := config.UpdateQueue(func([]config.QueueItem) ([]config.QueueItem, error) {
+		return items, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"q-urgent", "q-low", "q-old", "q-new"}
+	for _, id := range want {
+		item, err := popQueue(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item == nil || item.ID != id {
+			t.Fatalf("popQueue(nil) = %+v, want %s", item, id)
+		}
+		if id == "q-low" {
+			// Put back after failing to start, it is next again.
+			requeue(*item)
+			if again, _ := popQueue(nil); again == nil || again.ID != id {
+				t.Fatalf("popQueue(nil) after requeue = %+v, want %s", again, id)
+			}
+		}
+	}
+	if item, err := popQueue(nil); item != nil || err != nil {
+		t.Errorf("popQueue(nil) of an empty queue = %+v, %v", item, err)
+	}
+}
+
+func TestQueueSchedulerFull(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	err := config.UpdateQueue(func([]config.QueueItem) ([]config.QueueItem, error) {
+		return []config.QueueItem{{ID: "q-failed"}, {ID: "q-next", Added: time.Now()}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// This process stands in for a running agent, filling the only slot.
+	cfg := &config.Config{Tasks: []config.Task{{ID: "wt-busy", AgentPID: os.Getpid()}}}
+	q := &queueScheduler{slots: 1, failed: map[string]bool{"q-failed": true}}
+	q.fill(context.Background(), cfg)
+	if items, _ := config.LoadQueue(); len(items) != 2 {
+		t.Errorf("queue = %+v, want both items left while the slot is busy", items)
+	}
+
+	item, err := popQueue(func(item config.QueueItem) bool { return q.failed[item.ID] })
+	if err != nil || item == nil || item.ID != "q-next" {
+		t.Fatalf("popQueue() passing over failed items = %+v, %v, want q-next", item, err)
+	}
+	requeue(*item)
+
+	// An agent this server started fills the slot too, until it is
+	// reaped.
+	q.running = map[int]bool{1 << 30: true}
+	q.fill(context.Background(), &config.Config{})
+	if items, _ := config.LoadQueue(); len(items) != 2 {
+		t.Errorf("queue = %+v, want both items left while this server's agent runs", items)
+	}
+
+	// A PID now given to another process leaves the slot free: q-next is
+	// started, and fails for want of a repository.
+	q.running = nil
+	cfg.Tasks[0].AgentStarted = "long ago"
+	q.fill(context.Background(), cfg)
+	if !q.failed["q-next"] {
+		t.Errorf("fill() with a reused agent PID didn't start q-next")
+	}
+	if items, _ := config.LoadQueue(); len(items) != 2 {
+		t.Errorf("queue = %+v, want the item that failed to start back", items)
+	}
+}
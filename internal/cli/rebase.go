@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// defaultRebaseThreshold is how many commits the default branch may gain
+// since a task's merge base before the task is flagged as needing a rebase.
+const defaultRebaseThreshold = 50
+
+// rebaseThreshold returns the configured threshold, or 0 when disabled.
+func rebaseThreshold(cfg *config.Config) int {
+	switch {
+	case cfg.RebaseThreshold < 0:
+		return 0
+	case cfg.RebaseThreshold == 0:
+		return defaultRebaseThreshold
+	}
+	return cfg.RebaseThreshold
+}
+
+// needsRebase reports whether a task behind its base by behind commits
+// should be flagged.
+func needsRebase(cfg *config.Config, behind int) bool {
+	threshold := rebaseThreshold(cfg)
+	return threshold > 0 && behind >= threshold
+}
+
+// baseDistanceCache remembers how many commits the base is ahead of a
+// task's merge base, keyed by the two commits compared. Counting is the
+// slow part on large repositories and only changes when either side moves.
+type baseDistanceCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]baseDistance
+	used    map[string]baseDistance
+}
+
+// baseDistance is a cached count and when it was last used.
+type baseDistance struct {
+	Behind int       `json:"behind"`
+	Used   time.Time `json:"used"`
+}
+
+const (
+	// baseDistanceMaxAge is how long a count nobody uses stays cached.
+	baseDistanceMaxAge = 30 * 24 * time.Hour
+	// baseDistanceMaxEntries bounds the cache, keeping the recently used.
+	baseDistanceMaxEntries = 1000
+)
+
+func loadBaseDistanceCache() *baseDistanceCache {
+	c := &baseDistanceCache{used: make(map[string]baseDistance)}
+	path, err := cachePath("base-distance.json")
+	if err != nil {
+		c.entries = make(map[string]baseDistance)
+		return c
+	}
+	c.path = path
+	c.entries = readBaseDistances(path)
+	return c
+}
+
+// readBaseDistances reads the cache file at path; a missing or unreadable
+// one is an empty cache.
+func readBaseDistances(path string) map[string]baseDistance {
+	entries := make(map[string]baseDistance)
+	if data, err := os.ReadFile(path); err == nil {
+		if json.Unmarshal(data, &entries) != nil {
+			entries = make(map[string]baseDistance)
+		}
+	}
+	return entries
+}
+
+// behind returns how many commits base is ahead of the merge base of a
+// worktree's HEAD.
+func (c *baseDistanceCache) behind(ctx context.Context, worktreePath, base string) (int, error) {
+	head, baseCommit, err := worktree.ResolveBase(ctx, worktreePath, base)
+	if err != nil {
+		return 0, err
+	}
+	key := head + "..." + baseCommit
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	n := e.Behind
+	if !ok {
+		if _, n, err = worktree.CompareCommits(ctx, worktreePath, head, baseCommit); err != nil {
+			return 0, err
+		}
+	}
+	c.mu.Lock()
+	c.used[key] = baseDistance{Behind: n, Used: time.Now()}
+	c.mu.Unlock()
+	return n, nil
+}
+
+// save merges the entries used since loading into the cache file, which
+// other wt processes may have written meanwhile, and drops the entries
+// least recently used beyond baseDistanceMaxAge or baseDistanceMaxEntries.
+func (c *baseDistanceCache) save() error {
+	if c.path == "" || len(c.used) == 0 {
+		return nil
+	}
+	entries := readBaseDistances(c.path)
+	for key, e := range c.used {
+		entries[key] = e
+	}
+	keys := make([]string, 0, len(entries))
+	for key, e := range entries {
+		if time.Since(e.Used) < baseDistanceMaxAge {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return entries[keys[i]].Used.After(entries[keys[j]].Used) })
+	kept := make(map[string]baseDistance, min(len(keys), baseDistanceMaxEntries))
+	for _, key := range keys[:min(len(keys), baseDistanceMaxEntries)] {
+		kept[key] = entries[key]
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	// Written to a temporary file first, so that concurrent lists never
+	// read a partly written cache.
+	f, err := os.CreateTemp(filepath.Dir(c.path), ".base-distance-*")
+	if err != nil {
+		return fmt.Errorf("failed to write base distance cache: %w", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write base distance cache: %w", err)
+	}
+	return nil
+}
+
+// baseDistances returns how far each task's base has moved since its merge
+// base, or -1 when it can't be computed or isn't, for worktrees that are
+// missing or on another machine.
+func baseDistances(ctx context.Context, cfg *config.Config, tasks []config.Task) []int {
+	cache := loadBaseDistanceCache()
+	out := make([]int, len(tasks))
+	worktree.RunAll(len(tasks), cfg.Concurrency, func(i int) {
+		out[i] = -1
+		// Worktrees on another machine would cost an ssh round trip each.
+		wt := tasks[i].Worktree
+		if worktree.IsRemote(wt) {
+			return
+		}
+		if _, err := os.Stat(wt); err != nil {
+			return
+		}
+		if n, err := cache.behind(ctx, wt, task.DefaultBranch(ctx, cfg, tasks[i].RepoPath)); err == nil {
+			out[i] = n
+		}
+	})
+	if err := cache.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestNeedsRebase(t *testing.T) {
+	tests := []struct {
+		threshold int
+		behind    int
+		want      bool
+	}{
+		{0, 49, false},
+		{0, 50, true},
+		{10, 10, true},
+		{10, 9, false},
+		{-1, 1000, false},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{RebaseThreshold: tt.threshold}
+		if got := needsRebase(cfg, tt.behind); got != tt.want {
+			t.Errorf("needsRebase(threshold %d, behind %d) = %v, want %v", tt.threshold, tt.behind, got, tt.want)
+		}
+	}
+}
+
+func TestBaseDistanceCacheSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "base-distance.json")
+	c := &baseDistanceCache{path: path, used: map[string]baseDistance{
+		"a...base": {Behind: 1, Used: time.Now()},
+	}}
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another list, filtered to other tasks, keeps a's count and drops
+	// counts unused for too long.
+	other := &baseDistanceCache{path: path, used: map[string]baseDistance{
+		"b...base": {Behind: 2, Used: time.Now()},
+		"c...base": {Behind: 3, Used: time.Now().Add(-baseDistanceMaxAge)},
+	}}
+	if err := other.save(); err != nil {
+		t.Fatal(err)
+	}
+	got := readBaseDistances(path)
+	if len(got) != 2 || got["a...base"].Behind != 1 || got["b...base"].Behind != 2 {
+		t.Errorf("cache = %+v, want a and b", got)
+	}
+}
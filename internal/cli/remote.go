@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// startRepo returns the repository a new task is started in: the one
+// containing the current directory, or with --host, one on another
+// machine. host is either "devbox", for the repository at the same path
+// relative to the home directory there, or "devbox:/path/to/repo". The
+// worktrees then go to the other machine as well, under worktrees_base
+// mapped the same way, for this invocation only.
+func startRepo(ctx context.Context, cfg *config.Config, host string) (string, error) {
+	if host == "" {
+		return getRepoPath()
+	}
+	host, dir, _ := strings.Cut(host, ":")
+	if host == "" {
+		return "", fmt.Errorf("invalid value for --host: no host name")
+	}
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine current directory: %w", err)
+		}
+		dir = homeRelative(cwd)
+	}
+	dir, err := worktree.HostPath(ctx, host, dir)
+	if err != nil {
+		return "", err
+	}
+	repoPath, err := worktree.TopLevel(ctx, worktree.JoinHost(host, dir))
+	if err != nil {
+		return "", err
+	}
+	base, err := worktree.HostPath(ctx, host, homeRelative(cfg.WorktreesBase))
+	if err != nil {
+		return "", err
+	}
+	cfg.Override(config.BaseSettings{WorktreesBase: worktree.JoinHost(host, base)})
+	return repoPath, nil
+}
+
+// homeRelative returns p relative to the home directory with forward
+// slashes, for the same place on another machine, or p itself when it is
+// outside the home directory.
+func homeRelative(p string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	rel, err := filepath.Rel(home, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// sshCommand returns the command opening a login shell in dir on host.
+func sshCommand(host, dir string) string {
+	return fmt.Sprintf("ssh -t %s %s", worktree.ShellQuote(host), worktree.ShellQuote("cd "+worktree.ShellQuote(dir)+` && exec "$SHELL" -l`))
+}
+
+// vscodeURI returns the URI that opens dir on host in VS Code, with the
+// Remote - SSH extension.
+func vscodeURI(host, dir string) string {
+	return "vscode://vscode-remote/ssh-remote+" + host + path.Clean("/"+dir)
+}
+
+// checkLocal returns an error for a task whose worktree is on another
+// machine, or that is worked on in a Devpod or Codespace: agents run where
+// the task's files are edited, so they are started there.
+func checkLocal(t *config.Task) error {
+	if host, dir := worktree.SplitHost(t.Worktree); host != "" {
+		return fmt.Errorf("%s is on %s; start agents there: %s", t.ID, host, sshCommand(host, dir))
+	}
+	if shell := task.WorkspaceShell(t); shell != "" {
+		return fmt.Errorf("%s is worked on in its %s workspace; start agents there: %s", t.ID, t.Workspace, shell)
+	}
+	return nil
+}
+
+// newSwitchResult describes where to go for a task's worktree: its path,
+// the command opening a shell in its workspace, or on another machine, the
+// ssh command to run or with vscode, the VS Code URI to open.
+func newSwitchResult(t *config.Task, vscode bool) switchResult {
+	host, dir := worktree.SplitHost(t.Worktree)
+	if host == "" {
+		// Clean gives the path native separators, whatever the config holds.
+		result := switchResult{Path: filepath.Clean(t.Worktree), Branch: t.Branch, Task: t.ID}
+		if shell := task.WorkspaceShell(t); shell != "" {
+			result.Workspace, result.Command = t.Workspace, shell
+		}
+		return result
+	}
+	result := switchResult{Path: dir, Branch: t.Branch, Task: t.ID, Host: host, Command: sshCommand(host, dir)}
+	if vscode {
+		result.URI = vscodeURI(host, dir)
+	}
+	return result
+}
+
+// target is what 'wt switch' prints without --json.
+func (r switchResult) target() string {
+	switch {
+	case r.URI != "":
+		return r.URI
+	case r.Command != "":
+		return r.Command
+	}
+	return r.Path
+}
@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- repair ---
+func repairCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "repair",
+		Category: "maintenance",
+		Usage:    "Re-link tasks to their worktrees after manual git changes",
+		Description: `Bring wt's records back in line after worktrees were changed by hand.
+
+   For every repository with tasks, runs 'git worktree repair' to fix the
+   links between the repository and its worktrees, including worktrees moved
+   with plain 'mv' into worktrees_base. Each task is then re-linked to the
+   worktree that has its branch checked out, wherever it now lives.
+
+   Tasks whose branch is no longer checked out anywhere (e.g. after
+   'git worktree remove') are reported; --forget drops them from wt.
+
+   The cached name and default branch of each repository are detected
+   again on next use.
+
+   Examples:
+     wt repair --dry-run
+     wt repair
+     wt repair --forget`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "dry-run", Aliases: []string{"n"}, Usage: "Show what would change without changing anything (skips 'git worktree repair')"},
+			&cli.BoolFlag{Name: "forget", Usage: "Drop tasks whose worktree is gone"},
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			dryRun := c.Bool("dry-run")
+			byRepo := make(map[string][]config.Task)
+			for _, t := range cfg.Tasks {
+				byRepo[t.RepoPath] = append(byRepo[t.RepoPath], t)
+			}
+			repos := make([]string, 0, len(byRepo))
+			for repo := range byRepo {
+				repos = append(repos, repo)
+			}
+			sort.Strings(repos)
+
+			var linked []string
+			changed := false
+			for _, repo := range repos {
+				tasks := byRepo[repo]
+				if _, err := os.Stat(repo); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: repository %s of %d task(s) is missing\n", repo, len(tasks))
+					continue
+				}
+				if !dryRun {
+					if linked == nil {
+						linked = findLinkedWorktrees(cfg.WorktreesBase, 3)
+					}
+					if err := worktree.Repair(c.Context, repo, linkedTo(repo, linked)...); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					}
+					if err := task.ForgetRepo(repo); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					}
+				}
+				wts, err := worktree.List(c.Context, repo)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					continue
+				}
+				moved, missing := relinkTasks(tasks, wts)
+				for _, t := range tasks {
+					path, ok := moved[t.ID]
+					if !ok {
+						continue
+					}
+					changed = true
+					fmt.Printf("🔧 %s: worktree is now %s (was %s)\n", t.ID, path, t.Worktree)
+					if !dryRun {
+						if err := cfg.SetTaskWorktree(t.ID, path); err != nil {
+							return err
+						}
+					}
+				}
+				for _, t := range missing {
+					changed = true
+					if !c.Bool("forget") {
+						fmt.Printf("⚠️  %s: %s is not checked out anywhere (was %s); rerun with --forget to drop the task\n", t.ID, t.Branch, t.Worktree)
+						continue
+					}
+					fmt.Printf("🗑  %s: forgetting task, %s is not checked out anywhere\n", t.ID, t.Branch)
+					if !dryRun {
+						if err := cfg.RemoveTask(t.ID); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			if !changed {
+				fmt.Println("✅ Nothing to repair.")
+			}
+			return nil
+		},
+	}
+}
+
+// relinkTasks matches tasks to worktrees by branch. It returns the new
+// path of each task whose branch is checked out elsewhere, and the tasks
+// whose branch isn't checked out and whose worktree is gone. A branch
+// checked out in the repository itself is never linked, so finishing the
+// task can't remove the main worktree. Branch-only tasks are left alone.
+func relinkTasks(tasks []config.Task, wts []worktree.WorktreeInfo) (map[string]string, []config.Task) {
+	byBranch := make(map[string]string, len(wts))
+	for _, wt := range wts {
+		if wt.Branch != "" {
+			byBranch[strings.TrimPrefix(wt.Branch, "refs/heads/")] = wt.Path
+		}
+	}
+	moved := make(map[string]string)
+	var missing []config.Task
+	for _, t := range tasks {
+		if t.State == config.StateBranchOnly {
+			// It has no worktree yet to link or to miss.
+			continue
+		}
+		path, ok := byBranch[t.Branch]
+		if ok && filepath.Clean(path) == filepath.Clean(t.RepoPath) {
+			ok = false
+		}
+		switch {
+		case ok && path != t.Worktree:
+			moved[t.ID] = path
+		case !ok:
+			if _, err := os.Stat(t.Worktree); err != nil {
+				missing = append(missing, t)
+			}
+		}
+	}
+	return moved, missing
+}
+
+// findLinkedWorktrees returns the directories up to depth levels below root
+// that are linked worktrees, i.e. have a .git file rather than directory.
+func findLinkedWorktrees(root string, depth int) []string {
+	root = filepath.Clean(root)
+	found := []string{}
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if info, err := os.Stat(filepath.Join(path, ".git")); err == nil && !info.IsDir() {
+			found = append(found, path)
+			return filepath.SkipDir
+		}
+		if strings.Count(strings.TrimPrefix(path, root), string(filepath.Separator)) >= depth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return found
+}
+
+// linkedTo returns the worktrees whose .git file points into repo.
+func linkedTo(repo string, worktrees []string) []string {
+	prefix := filepath.Clean(repo) + string(filepath.Separator)
+	var out []string
+	for _, path := range worktrees {
+		data, err := os.ReadFile(filepath.Join(path, ".git"))
+		if err != nil {
+			continue
+		}
+		gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if ok && strings.HasPrefix(filepath.Clean(gitdir), prefix) {
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+// pruneRepo runs 'git worktree prune' in repo, then re-links the tasks of
+// the repository whose branch is checked out elsewhere and drops those
+// whose worktree is gone. dryRun only reports what would change.
+func pruneRepo(ctx context.Context, cfg *config.Config, repo string, dryRun bool) error {
+	if !dryRun {
+		if err := worktree.Prune(ctx, repo); err != nil {
+			return err
+		}
+	}
+	wts, err := worktree.List(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if len(wts) == 0 {
+		return nil
+	}
+	// The first worktree listed is the repository itself, even when repo
+	// is one of its worktrees.
+	main := filepath.Clean(wts[0].Path)
+	if dryRun {
+		// Leave out what 'git worktree prune' would have removed.
+		live := wts[:1]
+		for _, wt := range wts[1:] {
+			if _, err := os.Stat(wt.Path); err == nil {
+				live = append(live, wt)
+			}
+		}
+		wts = live
+	}
+	var tasks []config.Task
+	for _, t := range cfg.Tasks {
+		if filepath.Clean(t.RepoPath) == main {
+			tasks = append(tasks, t)
+		}
+	}
+	moved, missing := relinkTasks(tasks, wts)
+	for _, t := range tasks {
+		path, ok := moved[t.ID]
+		if !ok {
+			continue
+		}
+		fmt.Printf("🔧 %s: worktree is now %s (was %s)\n", t.ID, path, t.Worktree)
+		if !dryRun {
+			if err := cfg.SetTaskWorktree(t.ID, path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, t := range missing {
+		fmt.Printf("🗑  %s: forgetting task, its worktree %s is gone (branch %s is kept)\n", t.ID, t.Worktree, t.Branch)
+		if !dryRun {
+			if err := cfg.RemoveTask(t.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// knownRepos returns the repositories wt knows of: those of tasks, those
+// the linked worktrees under worktrees_base belong to, and those keyed by
+// path in repos.
+func knownRepos(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			repos = append(repos, path)
+		}
+	}
+	for _, t := range cfg.Tasks {
+		add(t.RepoPath)
+	}
+	if cfg.WorktreesBase != "" {
+		for _, path := range findLinkedWorktrees(cfg.WorktreesBase, 3) {
+			if repo := linkedRepo(path); repo != "" {
+				add(repo)
+			}
+		}
+	}
+	home, _ := os.UserHomeDir()
+	for key := range cfg.Repos {
+		path := key
+		if rest, ok := strings.CutPrefix(key, "~/"); ok && home != "" {
+			path = filepath.Join(home, rest)
+		}
+		if !filepath.IsAbs(path) || strings.HasSuffix(key, "/") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			add(path)
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// linkedRepo returns the repository a linked worktree belongs to, read
+// from its .git file, or "".
+func linkedRepo(path string) string {
+	data, err := os.ReadFile(filepath.Join(path, ".git"))
+	if err != nil {
+		return ""
+	}
+	gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return ""
+	}
+	// gitdir is <repo>/.git/worktrees/<name>, or <repo>/worktrees/<name>
+	// for a bare repository.
+	common := filepath.Dir(filepath.Dir(filepath.Clean(gitdir)))
+	if filepath.Base(common) == ".git" {
+		return filepath.Dir(common)
+	}
+	return common
+}
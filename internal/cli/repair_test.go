@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+func TestRelinkTasks(t *testing.T) {
+	dir := t.TempDir()
+	tasks := []config.Task{
+		{ID: "same", Branch: "feature/same", Worktree: dir, RepoPath: "/src/app"},
+		{ID: "moved", Branch: "feature/moved", Worktree: "/old/moved", RepoPath: "/src/app"},
+		{ID: "gone", Branch: "feature/gone", Worktree: "/old/gone", RepoPath: "/src/app"},
+		{ID: "main", Branch: "feature/main", Worktree: "/old/main", RepoPath: "/src/app"},
+		{ID: "kept", Branch: "feature/kept", Worktree: dir, RepoPath: "/src/app"},
+		{ID: "planned", Branch: "feature/planned", Worktree: "/new/planned", RepoPath: "/src/app", State: config.StateBranchOnly},
+	}
+	wts := []worktree.WorktreeInfo{
+		{Path: "/src/app", Branch: "refs/heads/feature/main"},
+		{Path: dir, Branch: "refs/heads/feature/same"},
+		{Path: filepath.Join(dir, "new"), Branch: "refs/heads/feature/moved"},
+	}
+	moved, missing := relinkTasks(tasks, wts)
+	if len(moved) != 1 || moved["moved"] != filepath.Join(dir, "new") {
+		t.Errorf("moved = %v, want only moved", moved)
+	}
+	var ids []string
+	for _, m := range missing {
+		ids = append(ids, m.ID)
+	}
+	if len(ids) != 2 || ids[0] != "gone" || ids[1] != "main" {
+		t.Errorf("missing = %v, want [gone main]", ids)
+	}
+}
+
+func TestKnownRepos(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "worktrees")
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A worktree of a repository without tasks, and one of a bare repository.
+	write(filepath.Join(base, "web", "fix", ".git"), "gitdir: "+filepath.Join(root, "web", ".git", "worktrees", "fix")+"\n")
+	write(filepath.Join(base, "lib", "feat", ".git"), "gitdir: "+filepath.Join(root, "lib.git", "worktrees", "feat")+"\n")
+	os.MkdirAll(filepath.Join(root, "tools", ".git"), 0o755)
+
+	cfg := &config.Config{
+		WorktreesBase: base,
+		Tasks: []config.Task{
+			{ID: "wt-1", RepoPath: filepath.Join(root, "api")},
+			{ID: "wt-2", RepoPath: filepath.Join(root, "api") + "/"},
+		},
+		Repos: map[string]config.RepoConfig{
+			filepath.Join(root, "tools"):   {},
+			filepath.Join(root, "missing"): {},
+			"api":                          {},
+			base + "/":                     {},
+		},
+	}
+	want := []string{
+		filepath.Join(root, "api"),
+		filepath.Join(root, "lib.git"),
+		filepath.Join(root, "tools"),
+		filepath.Join(root, "web"),
+	}
+	if got := knownRepos(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("knownRepos = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,387 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/metrics"
+	"github.com/bakerweb/wt/internal/team"
+	"github.com/urfave/cli/v2"
+)
+
+// --- serve ---
+func serveCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "serve",
+		Aliases:  []string{"daemon"},
+		Category: "maintenance",
+		Usage:    "Poll connectors in the background and expose Prometheus metrics",
+		Description: `Run until interrupted, polling the tickets of active tasks every interval
+   and recording status and comment changes for 'wt status' (and desktop
+   notifications with --notify), like 'wt list --tickets' does.
+
+   Changes to the config made while the server runs, e.g. by 'wt connect'
+   or 'wt start', are picked up within seconds; a changed connector is
+   polled right away.
+
+   Prometheus metrics are served at http://<listen>/metrics:
+     wt_tasks                           active tasks
+     wt_tasks_created_total             tasks created since the server started
+     wt_polls_total                     completed polls
+     wt_poll_errors_total{connector}    tickets that failed to fetch
+     wt_connector_request_seconds{connector,operation}
+                                        connector request latency histogram
+     wt_connector_request_errors_total{connector,operation}
+     wt_last_poll_timestamp_seconds     when the last poll finished
+
+   With --queue-agents N, the server also works through 'wt queue': whenever
+   fewer than N agents run in wt's tasks, because one finished or was
+   stopped, it starts the next queued task and launches the item's agent
+   (or --agent, or default_agent) in a tmux window, or in the background
+   with its output in the task's scratch directory.
+
+   Tasks are served at http://<listen>/api/tasks:
+     GET  /api/tasks                  list tasks
+     GET  /api/tasks/<id>             one task
+     POST /api/tasks/<id>/<action>    finish, remove, lock or unlock
+                                      (?force=true, ?reason=... for lock)
+
+   With --team, also serves shared team state at http://<listen>/team for
+   'wt team init --url', stored in ~/.wt/team.
+
+   Clients authenticate with bearer tokens created by 'wt serve token add',
+   scoped to read, team or lifecycle access. Without tokens, only read access
+   is allowed. --read-only refuses every change, whatever the token.
+   /metrics is always readable.
+
+   Examples:
+     wt serve
+     wt serve --listen :9273 --interval 1m
+     wt serve --team --read-only
+     wt serve --queue-agents 3 --agent claude
+     wt serve token add --scope read grafana`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "listen", Value: "127.0.0.1:9273", Usage: "Address to serve /metrics on"},
+			&cli.DurationFlag{Name: "interval", Value: 5 * time.Minute, Usage: "Time between polls"},
+			&cli.BoolFlag{Name: "notify", Usage: "Send a desktop notification when a ticket's status or comments change"},
+			&cli.BoolFlag{Name: "team", Usage: "Serve shared team state at /team"},
+			&cli.StringFlag{Name: "team-token", EnvVars: []string{"WT_TEAM_TOKEN"}, Usage: "Additional token with team scope"},
+			&cli.BoolFlag{Name: "read-only", Usage: "Refuse all changes through the API"},
+			&cli.IntFlag{Name: "queue-agents", Usage: "Keep this many agents busy with tasks started from 'wt queue'"},
+			&cli.StringFlag{Name: "agent", Usage: "With --queue-agents, the agent for items queued without one (default: default_agent)"},
+		},
+		Subcommands: []*cli.Command{serveTokenCmd()},
+		Action: func(c *cli.Context) error {
+			interval := c.Duration("interval")
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			s := newServer(c.Bool("notify"))
+			watcher := &configWatcher{}
+			cfg, _, err := watcher.reload()
+			if err != nil {
+				return err
+			}
+			var sched *queueScheduler
+			if n := c.Int("queue-agents"); n > 0 {
+				if c.String("agent") == "" && cfg.DefaultAgent == "" {
+					return fmt.Errorf("--queue-agents needs an agent for items queued without one: set default_agent or pass --agent")
+				}
+				sched = &queueScheduler{slots: n, agent: c.String("agent"), failed: make(map[string]bool)}
+			}
+			ln, err := net.Listen("tcp", c.String("listen"))
+			if err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+			var extra []config.APIToken
+			if tok := c.String("team-token"); tok != "" {
+				extra = append(extra, config.APIToken{Name: "team-token", Hash: hashToken(tok), Scope: config.ScopeTeam})
+			}
+			auth := &apiAuth{tokens: watcher.tokens(extra...), readOnly: c.Bool("read-only")}
+
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", s.registry.Handler())
+			(&taskAPI{}).register(mux, auth)
+			if c.Bool("team") {
+				dir, err := config.ConfigDir()
+				if err != nil {
+					return err
+				}
+				store := http.StripPrefix("/team", &team.Server{Dir: filepath.Join(dir, "team")})
+				mux.Handle("GET /team", auth.require(config.ScopeRead, store))
+				mux.Handle("GET /team/", auth.require(config.ScopeRead, store))
+				mux.Handle("PUT /team/", auth.require(config.ScopeTeam, store))
+			}
+			srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			go srv.Serve(ln)
+			defer srv.Close()
+			fmt.Printf("Serving metrics on http://%s/metrics, polling every %s\n", ln.Addr(), interval)
+
+			poll := func() {
+				cfg, _, err := watcher.reload()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+					s.pollErrors.Inc("config")
+					return
+				}
+				s.poll(c.Context, cfg)
+			}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			check := time.NewTicker(configCheckInterval)
+			defer check.Stop()
+			poll()
+			if sched != nil {
+				fmt.Printf("Keeping %d agent(s) busy with queued tasks\n", sched.slots)
+				sched.fill(c.Context, cfg)
+			}
+			for {
+				select {
+				case <-c.Context.Done():
+					return nil
+				case <-ticker.C:
+					poll()
+				case <-check.C:
+					cfg, changes, err := watcher.reload()
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+						continue
+					}
+					if connectorsChanged(changes) {
+						poll()
+					}
+					if sched != nil {
+						sched.fill(c.Context, cfg)
+					}
+				}
+			}
+		},
+	}
+}
+
+// server is the state of 'wt serve' between polls.
+type server struct {
+	notify   bool
+	started  time.Time
+	seen     map[string]bool
+	registry *metrics.Registry
+
+	tasks          *metrics.Gauge
+	tasksCreated   *metrics.Counter
+	polls          *metrics.Counter
+	pollErrors     *metrics.Counter
+	lastPoll       *metrics.Gauge
+	requestLatency *metrics.Histogram
+	requestErrors  *metrics.Counter
+}
+
+func newServer(notify bool) *server {
+	r := metrics.NewRegistry()
+	return &server{
+		notify:         notify,
+		started:        time.Now(),
+		seen:           make(map[string]bool),
+		registry:       r,
+		tasks:          r.NewGauge("wt_tasks", "Active tasks."),
+		tasksCreated:   r.NewCounter("wt_tasks_created_total", "Tasks created since the server started."),
+		polls:          r.NewCounter("wt_polls_total", "Completed polls."),
+		pollErrors:     r.NewCounter("wt_poll_errors_total", "Tickets that failed to fetch during a poll.", "connector"),
+		lastPoll:       r.NewGauge("wt_last_poll_timestamp_seconds", "Unix time the last poll finished."),
+		requestLatency: r.NewHistogram("wt_connector_request_seconds", "Connector request latency.", nil, "connector", "operation"),
+		requestErrors:  r.NewCounter("wt_connector_request_errors_total", "Failed connector requests.", "connector", "operation"),
+	}
+}
+
+// configCheckInterval is how often 'wt serve' checks the config files for
+// changes made by other wt processes.
+const configCheckInterval = 2 * time.Second
+
+// configWatcher holds the config 'wt serve' works from, reloading it when
+// its files change on disk. The config it returns is shared: read it, don't
+// change it.
+type configWatcher struct {
+	mu    sync.Mutex
+	cfg   *config.Config
+	stamp string
+}
+
+// configChange is a difference between the config before and after a
+// reload.
+type configChange struct {
+	// Connector is the connector that was added, removed or changed, if any.
+	Connector string
+	What      string
+}
+
+// reload returns the config, read again first if its files changed since
+// the last call, and what changed. Changes are also printed.
+func (w *configWatcher) reload() (*config.Config, []configChange, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stamp, err := config.Stamp()
+	if err != nil {
+		return nil, nil, err
+	}
+	if w.cfg != nil && stamp == w.stamp {
+		return w.cfg, nil, nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	var changes []configChange
+	if w.cfg != nil {
+		changes = diffConfig(w.cfg, cfg)
+		for _, ch := range changes {
+			fmt.Printf("Config changed: %s\n", ch.What)
+		}
+	}
+	w.cfg, w.stamp = cfg, stamp
+	return cfg, changes, nil
+}
+
+// tokens returns the API tokens in the current config plus extra.
+func (w *configWatcher) tokens(extra ...config.APIToken) func() []config.APIToken {
+	return func() []config.APIToken {
+		cfg, _, err := w.reload()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			return extra
+		}
+		return append(slices.Clip(cfg.APITokens), extra...)
+	}
+}
+
+// diffConfig describes what changed from old to cfg.
+func diffConfig(old, cfg *config.Config) []configChange {
+	var changes []configChange
+	names := make([]string, 0, len(old.Connectors)+len(cfg.Connectors))
+	for name := range old.Connectors {
+		names = append(names, name)
+	}
+	for name := range cfg.Connectors {
+		if _, ok := old.Connectors[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		before, had := old.Connectors[name]
+		after, has := cfg.Connectors[name]
+		switch {
+		case !had:
+			changes = append(changes, configChange{Connector: name, What: "connector " + name + " added"})
+		case !has:
+			changes = append(changes, configChange{Connector: name, What: "connector " + name + " removed"})
+		case !reflect.DeepEqual(before, after):
+			changes = append(changes, configChange{Connector: name, What: "connector " + name + " changed"})
+		}
+	}
+	if !reflect.DeepEqual(old.APITokens, cfg.APITokens) {
+		changes = append(changes, configChange{What: "API tokens changed"})
+	}
+	if len(cfg.Tasks) != len(old.Tasks) {
+		changes = append(changes, configChange{What: fmt.Sprintf("%d task(s) now, was %d", len(cfg.Tasks), len(old.Tasks))})
+	}
+	if len(changes) == 0 {
+		changes = append(changes, configChange{What: "settings or tasks updated"})
+	}
+	return changes
+}
+
+// connectorsChanged reports whether any connector changed.
+func connectorsChanged(changes []configChange) bool {
+	for _, ch := range changes {
+		if ch.Connector != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// poll counts new tasks and fetches the tickets of active tasks. Tasks are
+// created by other wt processes, so a task counts as created when it first
+// appears with a creation time after server start.
+func (s *server) poll(ctx context.Context, cfg *config.Config) {
+	s.tasks.Set(float64(len(cfg.Tasks)))
+	for _, t := range cfg.Tasks {
+		if !s.seen[t.ID] && t.Created.After(s.started) {
+			s.tasksCreated.Inc()
+		}
+		s.seen[t.ID] = true
+	}
+
+	results := fetchLiveTickets(ctx, cfg, s.instrument(buildRegistry(cfg)))
+	for ref, res := range results {
+		if res.Err != nil && !errors.Is(res.Err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", ref, res.Err)
+			s.pollErrors.Inc(ref.Connector)
+		}
+	}
+	recordTicketChanges(results, s.notify)
+	s.polls.Inc()
+	s.lastPoll.Set(float64(time.Now().Unix()))
+}
+
+// fetchLiveTickets fetches the tickets of all tasks started from tickets,
+// bypassing the ticket cache so each poll sees current data.
+func fetchLiveTickets(ctx context.Context, cfg *config.Config, reg *connector.Registry) map[connector.Ref]connector.FetchResult {
+	var refs []connector.Ref
+	for _, t := range cfg.Tasks {
+		if t.TicketKey != "" && t.Connector != "" {
+			refs = append(refs, connector.Ref{Connector: t.Connector, Key: t.TicketKey})
+		}
+	}
+	fetcher := &connector.Fetcher{Registry: reg, RateLimit: cfg.TicketRateLimit}
+	return fetcher.FetchAll(ctx, refs)
+}
+
+// instrument returns a registry whose connectors record request metrics.
+func (s *server) instrument(reg *connector.Registry) *connector.Registry {
+	out := connector.NewRegistry()
+	for _, name := range reg.List() {
+		conn, _ := reg.Get(name)
+		out.Register(&timedConnector{Connector: conn, s: s})
+	}
+	return out
+}
+
+// timedConnector records the latency and failures of ticket requests.
+type timedConnector struct {
+	connector.Connector
+	s *server
+}
+
+func (t *timedConnector) observe(op string, start time.Time, err error) {
+	name := t.Name()
+	t.s.requestLatency.ObserveDuration(time.Since(start), name, op)
+	if err != nil {
+		t.s.requestErrors.Inc(name, op)
+	}
+}
+
+func (t *timedConnector) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	start := time.Now()
+	ticket, err := t.Connector.GetTicket(ctx, key)
+	t.observe("get_ticket", start, err)
+	return ticket, err
+}
+
+func (t *timedConnector) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	start := time.Now()
+	tickets, err := t.Connector.ListAssigned(ctx)
+	t.observe("list_assigned", start, err)
+	return tickets, err
+}
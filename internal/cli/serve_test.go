@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	path := filepath.Join(home, ".wt", "config.yaml")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Make the write visible on filesystems with coarse timestamps.
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write("connectors:\n  jira:\n    url: https://a.example.com\n", now.Add(-time.Minute))
+
+	w := &configWatcher{}
+	first, changes, err := w.reload()
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("first reload() = %v, %v", changes, err)
+	}
+	if cfg, changes, err := w.reload(); err != nil || cfg != first || len(changes) != 0 {
+		t.Fatalf("reload() without changes = %p, %v, %v; want the same config", cfg, changes, err)
+	}
+
+	write("connectors:\n  jira:\n    url: https://b.example.com\n  sentry:\n    url: https://sentry.example.com\n", now)
+	cfg, changes, err := w.reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Connectors["sentry"].URL == "" {
+		t.Error("reload() did not pick up the new connector")
+	}
+	want := []string{"connector jira changed", "connector sentry added"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i, ch := range changes {
+		if ch.What != want[i] {
+			t.Errorf("change %d = %q, want %q", i, ch.What, want[i])
+		}
+	}
+	if !connectorsChanged(changes) {
+		t.Error("connectorsChanged() = false")
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	old := config.DefaultConfig()
+	old.Connectors["jira"] = config.ConnectorConfig{URL: "https://a.example.com"}
+	cfg := config.DefaultConfig()
+	cfg.BranchPrefix = "fix"
+	changes := diffConfig(old, cfg)
+	if len(changes) != 1 || changes[0].What != "connector jira removed" || !connectorsChanged(changes) {
+		t.Errorf("diffConfig() = %v, want jira removed", changes)
+	}
+
+	old = config.DefaultConfig()
+	if changes := diffConfig(old, cfg); len(changes) != 1 || connectorsChanged(changes) {
+		t.Errorf("diffConfig() of settings = %v, want one change without connectors", changes)
+	}
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// shellInits are the scripts 'wt shell-init' prints, keyed by shell. Each
+// wraps wt so that 'wt switch' and 'wt last' change directory, unless they
+// print JSON or another --output. For a worktree on another machine or a
+// Devpod or Codespace workspace they run the printed command opening a
+// shell there instead, and a VS Code URI is just printed.
+var shellInits = map[string]string{
+	"bash": posixInit,
+	"zsh":  posixInit,
+	"fish": `function wt
+    if contains -- "$argv[1]" switch last; and not string match -qr -- '^(--json|-o|--output)' $argv
+        set -l dir (command wt $argv); or return
+        if string match -qr -- '^(ssh -t|devpod ssh|gh codespace ssh) ' $dir
+            eval $dir
+        else if string match -q -- '*://*' $dir
+            echo $dir
+        else
+            cd $dir
+        end
+    else
+        command wt $argv
+    end
+end
+`,
+	"powershell": `$script:WtExe = ` + wtExe + `
+function wt {
+    if ($args.Count -gt 0 -and $args[0] -in 'switch', 'last' -and -not ($args -match '^(--json|-o|--output)')) {
+        $dir = & $script:WtExe @args
+        if ($LASTEXITCODE -ne 0 -or -not $dir) { return }
+        if ($dir -match '^(ssh -t|devpod ssh|gh codespace ssh) ') { Invoke-Expression $dir }
+        elseif ($dir -like '*://*') { $dir }
+        else { Set-Location -LiteralPath $dir }
+    } else {
+        & $script:WtExe @args
+    }
+}
+`,
+	// cmd.exe has no functions; doskey macros are the closest. The script
+	// is run as a batch file, which turns %% into %.
+	"cmd": `@echo off
+doskey wtcd=for /f "usebackq delims=" %%i in (` + "`" + wtExe + ` switch $*` + "`" + `) do @cd /d "%%i"
+doskey wtlast=for /f "usebackq delims=" %%i in (` + "`" + wtExe + ` last` + "`" + `) do @cd /d "%%i"
+`,
+}
+
+// wtExe stands for the path of this wt, quoted for the shell, in the
+// powershell and cmd scripts: on Windows, the wt found on the PATH may be
+// Windows Terminal.
+const wtExe = "{{wt}}"
+
+// shellInit returns the script for shell, with the path exe of wt.
+func shellInit(shell, exe string) (string, bool) {
+	script, ok := shellInits[shell]
+	if !ok {
+		return "", false
+	}
+	quoted := `"` + exe + `"`
+	if shell == "powershell" {
+		quoted = "'" + strings.ReplaceAll(exe, "'", "''") + "'"
+	}
+	return strings.ReplaceAll(script, wtExe, quoted), true
+}
+
+const posixInit = `wt() {
+  case "$1" in
+    switch|last)
+      case " $* " in
+        *" --json "*|*" -o "*|*" --output"*) command wt "$@"; return ;;
+      esac
+      local dir
+      dir="$(command wt "$@")" || return
+      case "$dir" in
+        "ssh -t "*|"devpod ssh "*|"gh codespace ssh "*) eval "$dir" ;;
+        *://*) printf '%s\n' "$dir" ;;
+        *) cd "$dir" ;;
+      esac
+      ;;
+    *) command wt "$@" ;;
+  esac
+}
+`
+
+// --- shell-init ---
+func shellInitCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "shell-init",
+		Category:  "navigation",
+		Usage:     "Print shell code that makes 'wt switch' change directory",
+		ArgsUsage: "<bash|zsh|fish|powershell|cmd>",
+		Description: `Print a wrapper for your shell so that 'wt switch <task-id>', 'wt switch -'
+   and 'wt last' change to the worktree directly, without cd $(...). Other
+   commands, and switch with --json, run unchanged.
+
+   cmd.exe cannot wrap commands, so it gets the doskey macros 'wtcd <task-id>'
+   and 'wtlast' instead. The powershell and cmd scripts call this wt by its
+   path, as wt on the PATH may be Windows Terminal; print them again if wt
+   moves.
+
+   Examples:
+     eval "$(wt shell-init bash)"                     # ~/.bashrc
+     eval "$(wt shell-init zsh)"                      # ~/.zshrc
+     wt shell-init fish | source                      # ~/.config/fish/config.fish
+     wt shell-init powershell | Out-String | Invoke-Expression   # $PROFILE
+     wt shell-init cmd > %USERPROFILE%\wt-init.cmd    # then run it, e.g. from AutoRun`,
+		Action: func(c *cli.Context) error {
+			shell := strings.ToLower(c.Args().First())
+			if shell == "pwsh" {
+				shell = "powershell"
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to locate wt: %w", err)
+			}
+			script, ok := shellInit(shell, exe)
+			if !ok {
+				names := make([]string, 0, len(shellInits))
+				for name := range shellInits {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				return fmt.Errorf("unknown shell %q (want %s)", c.Args().First(), strings.Join(names, ", "))
+			}
+			_, err = fmt.Fprint(os.Stdout, script)
+			return err
+		},
+	}
+}
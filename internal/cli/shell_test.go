@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestShellInits(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell", "cmd"} {
+		script, ok := shellInit(shell, `C:\Program Files\wt's\wt.exe`)
+		if !ok || script == "" {
+			t.Errorf("no shell-init script for %s", shell)
+			continue
+		}
+		if !strings.Contains(script, "switch") || !strings.Contains(script, "last") {
+			t.Errorf("%s script does not handle switch and last", shell)
+		}
+		if strings.Contains(script, wtExe) {
+			t.Errorf("%s script has no path to wt in place of %s", shell, wtExe)
+		}
+	}
+	if script, _ := shellInit("powershell", `C:\Program Files\wt's\wt.exe`); !strings.Contains(script, `$script:WtExe = 'C:\Program Files\wt''s\wt.exe'`) {
+		t.Errorf("powershell script doesn't call wt by its quoted path:\n%s", script)
+	}
+	if script, _ := shellInit("cmd", `C:\wt\wt.exe`); !strings.Contains(script, "`\"C:\\wt\\wt.exe\" switch $*`") {
+		t.Errorf("cmd script doesn't call wt by its quoted path:\n%s", script)
+	}
+	// Check the scripts parse where the shell is installed.
+	for shell, check := range map[string][]string{"bash": {"-n"}, "zsh": {"-n"}, "fish": {"--no-execute"}} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, check...)
+		cmd.Stdin = strings.NewReader(shellInits[shell])
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("%s rejects its shell-init script: %v\n%s", shell, err, out)
+		}
+	}
+}
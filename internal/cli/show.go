@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/markdown"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/terminal"
+	"github.com/urfave/cli/v2"
+)
+
+// --- show ---
+func showCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "show",
+		Category:  "navigation",
+		Usage:     "Show a ticket's details and description",
+		ArgsUsage: "[ticket-key]",
+		Description: `Display a ticket with its description rendered for the terminal.
+
+   Markdown (and Jira's rich text) is shown with styled headings, emphasis,
+   lists and code blocks. Without a key, shows the ticket of the task in the
+   current worktree. Use --raw to print the description unrendered, and
+   --attachments to list the ticket's attachments ('wt attach pull' downloads
+   them into the worktree).
+
+   Examples:
+     wt show PROJ-123
+     wt show --connector tracker 42
+     wt show --attachments
+     wt show PROJ-123 -o json
+     wt show              # ticket of the current task`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "connector", Aliases: []string{"c"}, Usage: "Connector to fetch from (default: the task's connector, or jira)"},
+			&cli.BoolFlag{Name: "raw", Usage: "Print the description without rendering"},
+			&cli.BoolFlag{Name: "attachments", Usage: "List the ticket's attachments"},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			key := c.Args().First()
+			name := c.String("connector")
+			if key == "" {
+				t, err := currentTask(cfg)
+				if err != nil || t.TicketKey == "" {
+					return fmt.Errorf("usage: wt show <ticket-key> (or run inside a worktree started from a ticket)")
+				}
+				key = t.TicketKey
+				if name == "" {
+					name = t.Connector
+				}
+			}
+			if name == "" {
+				name = "jira"
+			}
+
+			reg := buildRegistry(cfg)
+			conn, err := reg.Lookup(name)
+			if err != nil {
+				return err
+			}
+			ticket, err := conn.GetTicket(c.Context, key)
+			if err != nil {
+				return fmt.Errorf("failed to fetch ticket: %w", err)
+			}
+			return f.Write(os.Stdout, ticket, func(out io.Writer) error {
+				printTicket(out, ticket, c.Bool("raw"))
+				if c.Bool("attachments") {
+					printAttachments(out, ticket.Attachments)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// printTicket writes a ticket's fields followed by its description.
+func printTicket(out io.Writer, t *connector.Ticket, raw bool) {
+	color := !raw && terminal.IsTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	title := t.Key + "  " + t.Summary
+	if color {
+		title = "\033[1m" + title + "\033[0m"
+	}
+	fmt.Fprintln(out, title)
+	fields := []struct{ label, field string }{
+		{"Status", "status"},
+		{"Type", "type"},
+		{"Priority", "priority"},
+		{"Assignee", "assignee"},
+		{"Epic", "epic"},
+		{"Parent", "parent"},
+		{"Due", "due"},
+		{"Labels", "labels"},
+	}
+	for _, f := range fields {
+		if v := connector.FieldValue(*t, f.field); v != "-" {
+			fmt.Fprintf(out, "%-10s %s\n", f.label+":", v)
+		}
+	}
+	for _, k := range sortedKeys(t.Metadata) {
+		fmt.Fprintf(out, "%-10s %s\n", k+":", t.Metadata[k])
+	}
+	if t.URL != "" {
+		fmt.Fprintf(out, "%-10s %s\n", "URL:", t.URL)
+	}
+
+	desc := strings.TrimSpace(t.Description)
+	if desc == "" {
+		return
+	}
+	fmt.Fprintln(out)
+	if raw {
+		fmt.Fprintln(out, desc)
+		return
+	}
+	fmt.Fprint(out, markdown.Render(desc, markdown.Options{Width: terminalWidth(), Color: color}))
+}
+
+// ticketContext returns a ticket as the Markdown of a task's context file:
+// its title, fields and description.
+func ticketContext(t *connector.Ticket) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s\n\n", t.Key, t.Summary)
+	if t.URL != "" {
+		fmt.Fprintf(&b, "%s\n\n", t.URL)
+	}
+	for _, f := range [][2]string{{"Status", t.Status}, {"Type", t.Type}, {"Priority", t.Priority}} {
+		if f[1] != "" {
+			fmt.Fprintf(&b, "- %s: %s\n", f[0], f[1])
+		}
+	}
+	for _, k := range sortedKeys(t.Metadata) {
+		fmt.Fprintf(&b, "- %s: %s\n", k, t.Metadata[k])
+	}
+	if desc := strings.TrimSpace(t.Description); desc != "" {
+		fmt.Fprintf(&b, "\n%s\n", desc)
+	}
+	return b.String()
+}
+
+// terminalWidth returns the width to wrap text at: $COLUMNS when set,
+// capped for readability, or 80.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return min(n, 100)
+	}
+	return 80
+}
+
+// printAttachments lists a ticket's attachments.
+func printAttachments(out io.Writer, attachments []connector.Attachment) {
+	fmt.Fprintln(out)
+	if len(attachments) == 0 {
+		fmt.Fprintln(out, "No attachments.")
+		return
+	}
+	fmt.Fprintln(out, "Attachments:")
+	w := output.NewTabWriter(out)
+	for _, a := range attachments {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", a.Filename, formatSize(a.Size), a.MimeType)
+	}
+	w.Flush()
+}
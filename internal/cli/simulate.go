@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// simulation is the fake git of a run with --simulate, or nil.
+var simulation *worktree.Fake
+
+// simulationBranch is the branch checked out in the repositories a
+// simulation adds: the configured default branch, or main.
+var simulationBranch = "main"
+
+// startSimulation makes this run of wt work on simulated repositories and
+// tasks, kept in ~/.wt/simulate across runs. Settings still come from the
+// config file, so a simulation shows what they do without changing any
+// repository or real task: git runs against a worktree.Fake, and worktrees
+// are created, empty, under ~/.wt/simulate/worktrees unless
+// --worktrees-base is given.
+func startSimulation() error {
+	home, err := config.ConfigDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(home, "simulate")
+	if _, err := os.Stat(simulationFile(dir)); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Starting a simulation: repositories are not changed, and tasks are kept in %s until you delete it.\n", dir)
+	}
+	f, err := worktree.LoadFake(simulationFile(dir))
+	if err != nil {
+		return err
+	}
+	config.Simulate(dir)
+	worktree.UseFake(f)
+	simulation = f
+	if baseOverrides.WorktreesBase == "" {
+		baseOverrides.WorktreesBase = filepath.Join(dir, "worktrees")
+	}
+	if baseOverrides.DefaultBranch != "" {
+		simulationBranch = baseOverrides.DefaultBranch
+	}
+	return nil
+}
+
+// saveSimulation keeps the simulated repositories for the next run.
+func saveSimulation() {
+	if simulation == nil {
+		return
+	}
+	dir, err := config.ConfigDir()
+	if err == nil {
+		err = simulation.Save(simulationFile(dir))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+func simulationFile(dir string) string {
+	return filepath.Join(dir, "git.json")
+}
+
+// simulatedRepoRoot returns the simulated repository or worktree containing
+// dir. Any directory can be a simulated repository: outside of those known,
+// the repository containing dir, or else dir itself, is added the first
+// time it is used.
+func simulatedRepoRoot(dir string) string {
+	if top, err := worktree.TopLevel(context.Background(), dir); err == nil {
+		return top
+	}
+	root := dir
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	simulation.AddRepo(root, simulationBranch)
+	return root
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// --- snapshot ---
+func snapshotCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "snapshot",
+		Category: "maintenance",
+		Usage:    "Save all tasks and worktrees, to recreate them later",
+		Description: `Save which tasks exist, with their branches and uncommitted changes
+   (untracked files included), so that 'wt snapshot restore' can recreate
+   their worktrees after the worktrees are deleted or on a rebuilt machine.
+   Handy before a vacation or a reinstall.
+
+   Snapshots are kept in ~/.wt/snapshots/<name>: a snapshot.yaml listing
+   the tasks and a git bundle per task with its branch and changes, so
+   unpushed commits are saved too. Copy the directory along with the
+   config to move them to another machine.
+
+   Restoring needs each task's repository cloned at its old path. Worktrees
+   that still exist are left alone. Missing branches are fetched from the
+   snapshot; existing ones are kept, and get the saved changes only if they
+   have not moved since.
+
+   Examples:
+     wt snapshot create before-vacation
+     wt snapshot list
+     wt snapshot restore before-vacation
+     wt snapshot delete before-vacation`,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "Save every task's branch and uncommitted changes",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "Replace an existing snapshot of the same name"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("please provide a snapshot name")
+					}
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					snap, err := task.NewManager(cfg).CreateSnapshot(c.Context, c.Args().First(), c.Bool("force"))
+					if err != nil {
+						return err
+					}
+					dirty := 0
+					for _, st := range snap.Tasks {
+						if st.Work != "" {
+							dirty++
+						}
+					}
+					dir, _ := task.SnapshotDir(snap.Name)
+					fmt.Printf("📸 Saved %d task(s), %d with uncommitted changes, as snapshot %s\n", len(snap.Tasks), dirty, snap.Name)
+					fmt.Printf("   Directory: %s\n", dir)
+					return nil
+				},
+			},
+			{
+				Name:      "restore",
+				Usage:     "Recreate the worktrees of a snapshot's tasks",
+				ArgsUsage: "<name>",
+				Flags:     []cli.Flag{outputFlag()},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("please provide a snapshot name (see 'wt snapshot list')")
+					}
+					f, err := formatter(c)
+					if err != nil {
+						return err
+					}
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					results, err := task.NewManager(cfg).RestoreSnapshot(c.Context, c.Args().First())
+					if err != nil {
+						return err
+					}
+					if err := f.Write(os.Stdout, results, func(w io.Writer) error {
+						return printRestored(w, results)
+					}); err != nil {
+						return err
+					}
+					failed := 0
+					for _, r := range results {
+						if r.Result == task.RestoreFailed {
+							failed++
+						}
+					}
+					if failed > 0 {
+						return fmt.Errorf("failed to restore %d of %d task(s)", failed, len(results))
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List saved snapshots",
+				Flags: []cli.Flag{outputFlag()},
+				Action: func(c *cli.Context) error {
+					f, err := formatter(c)
+					if err != nil {
+						return err
+					}
+					snaps, err := task.ListSnapshots()
+					if err != nil {
+						return err
+					}
+					return f.Write(os.Stdout, snaps, func(w io.Writer) error {
+						if len(snaps) == 0 {
+							fmt.Fprintln(w, "No snapshots. Save one with 'wt snapshot create <name>'.")
+							return nil
+						}
+						tw := output.NewTabWriter(w)
+						fmt.Fprintln(tw, "NAME\tCREATED\tTASKS")
+						for _, s := range snaps {
+							fmt.Fprintf(tw, "%s\t%s\t%d\n", s.Name, s.Created.Format("2006-01-02 15:04"), len(s.Tasks))
+						}
+						return tw.Flush()
+					})
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a snapshot",
+				ArgsUsage: "<name>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("please provide a snapshot name")
+					}
+					if err := task.DeleteSnapshot(c.Args().First()); err != nil {
+						return err
+					}
+					fmt.Printf("🗑  Deleted snapshot %s\n", c.Args().First())
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func printRestored(out io.Writer, results []task.RestoredTask) error {
+	if len(results) == 0 {
+		fmt.Fprintln(out, "The snapshot has no tasks.")
+		return nil
+	}
+	for _, r := range results {
+		switch r.Result {
+		case task.RestoreCreated:
+			fmt.Fprintf(out, "✅ %s restored at %s\n", r.ID, r.Worktree)
+		case task.RestoreExists:
+			fmt.Fprintf(out, "   %s already exists at %s\n", r.ID, r.Worktree)
+		default:
+			fmt.Fprintf(out, "❌ %s not restored\n", r.ID)
+		}
+		if r.Note != "" {
+			fmt.Fprintf(out, "   %s\n", r.Note)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestSplitDescription(t *testing.T) {
+	tests := []struct {
+		text    string
+		summary string
+		notes   string
+	}{
+		{"fix login\n", "fix login", ""},
+		{"\n\n# Fix login redirect\n\nUsers land on /home after login.\nKeep ?next= working.\n", "Fix login redirect", "Users land on /home after login.\nKeep ?next= working."},
+		{"bump deps\r\nonly minor versions\r\n", "bump deps", "only minor versions"},
+		{"  \n", "", ""},
+	}
+	for _, tt := range tests {
+		summary, notes := splitDescription(tt.text)
+		if summary != tt.summary || notes != tt.notes {
+			t.Errorf("splitDescription(%q) = %q, %q, want %q, %q", tt.text, summary, notes, tt.summary, tt.notes)
+		}
+	}
+}
+
+func TestAnotherVariant(t *testing.T) {
+	cfg := &config.Config{Tasks: []config.Task{
+		{ID: "wt-1", Connector: "jira", TicketKey: "PROJ-1"},
+		{ID: "wt-2", Connector: "jira", TicketKey: "PROJ-1"},
+		{ID: "wt-3", Connector: "github", TicketKey: "PROJ-2"},
+	}}
+	tests := []struct {
+		connector, key, want string
+	}{
+		{"jira", "PROJ-1", "3"},
+		{"jira", "proj-1", "3"},
+		{"jira", "PROJ-2", ""},
+		{"github", "PROJ-2", "2"},
+	}
+	for _, tt := range tests {
+		if got := anotherVariant(cfg, tt.connector, tt.key); got != tt.want {
+			t.Errorf("anotherVariant(%q, %q) = %q, want %q", tt.connector, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// --- stats ---
+func statsCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "stats",
+		Category: "agent",
+		Usage:    "Show the tokens and cost of agents per ticket",
+		Description: `Show how many tokens the agents run in tasks used, and what they cost,
+   per ticket (tasks without one are listed by ID), repository or agent.
+   Active tasks and tasks finished or removed since usage tracking began
+   are counted.
+
+   Usage is read from the session logs Claude Code and Codex keep for each
+   worktree, and recorded in the task ('wt stats' refreshes it). Costs the
+   agent does not report are computed from token_prices, in US dollars per
+   million tokens, keyed by the start of the model name:
+
+     token_prices:
+       claude-sonnet-4: {input: 3, output: 15, cache_read: 0.3, cache_write: 3.75}
+       gpt-5: {input: 1.25, output: 10, cache_read: 0.125}
+
+   Examples:
+     wt stats
+     wt stats --by repo --since 720h
+     wt stats -o json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "by",
+				Value: "ticket",
+				Usage: "Group by ticket, task, repo or agent",
+			},
+			&cli.DurationFlag{Name: "since", Usage: "Only include tasks active within this duration"},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			group, ok := usageGroups[c.String("by")]
+			if !ok {
+				return fmt.Errorf("unknown --by %q (want ticket, task, repo or agent)", c.String("by"))
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := refreshUsage(cfg); err != nil {
+				return err
+			}
+			finished, err := task.FinishedUsage()
+			if err != nil {
+				return err
+			}
+			var cutoff time.Time
+			if since := c.Duration("since"); since > 0 {
+				cutoff = time.Now().Add(-since)
+			}
+			records := usageRecords(cfg.Tasks, finished, cutoff)
+			rows := groupUsage(records, group)
+			return f.Write(os.Stdout, rows, func(w io.Writer) error {
+				return printUsage(w, strings.ToUpper(c.String("by")), rows)
+			})
+		},
+	}
+}
+
+// refreshUsage reads the usage of every active task from the agents'
+// session logs and saves the tasks whose usage changed.
+func refreshUsage(cfg *config.Config) error {
+	changed := false
+	for i := range cfg.Tasks {
+		t := &cfg.Tasks[i]
+		u, err := task.TaskUsage(cfg, t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", t.ID, err)
+			continue
+		}
+		if u.Tokens() == 0 && t.Usage == nil || t.Usage != nil && *t.Usage == u {
+			continue
+		}
+		t.Usage = &u
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return cfg.Save()
+}
+
+// usageRecords returns the usage of active tasks and of finished tasks,
+// leaving out tasks last active before cutoff and tasks without usage.
+func usageRecords(tasks []config.Task, finished []task.UsageRecord, cutoff time.Time) []task.UsageRecord {
+	var records []task.UsageRecord
+	for _, r := range finished {
+		if !r.Finished.Before(cutoff) {
+			records = append(records, r)
+		}
+	}
+	for _, t := range tasks {
+		if t.Usage == nil || t.Usage.Tokens() == 0 || t.LastActive().Before(cutoff) {
+			continue
+		}
+		records = append(records, task.UsageRecord{
+			Task:        t.ID,
+			Description: t.Description,
+			Repo:        t.RepoPath,
+			Connector:   t.Connector,
+			Ticket:      t.TicketKey,
+			Agent:       t.Agent,
+			Usage:       *t.Usage,
+		})
+	}
+	return records
+}
+
+// usageGroups name the key each --by groups usage records under.
+var usageGroups = map[string]func(task.UsageRecord) string{
+	"ticket": func(r task.UsageRecord) string {
+		if r.Ticket == "" {
+			return r.Task
+		}
+		return r.Ticket
+	},
+	"task": func(r task.UsageRecord) string { return r.Task },
+	"repo": func(r task.UsageRecord) string { return filepath.Base(r.Repo) },
+	"agent": func(r task.UsageRecord) string {
+		if r.Agent == "" {
+			return "-"
+		}
+		return r.Agent
+	},
+}
+
+// usageRow is the usage of one group of tasks.
+type usageRow struct {
+	Key   string       `json:"key"`
+	Tasks int          `json:"tasks"`
+	Usage config.Usage `json:"usage"`
+}
+
+// groupUsage sums records by the key group gives them, most expensive
+// first, then most tokens first.
+func groupUsage(records []task.UsageRecord, group func(task.UsageRecord) string) []usageRow {
+	index := make(map[string]int)
+	var rows []usageRow
+	for _, r := range records {
+		key := group(r)
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, usageRow{Key: key})
+		}
+		rows[i].Tasks++
+		rows[i].Usage.Add(r.Usage)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Usage.CostUSD != rows[j].Usage.CostUSD {
+			return rows[i].Usage.CostUSD > rows[j].Usage.CostUSD
+		}
+		return rows[i].Usage.Tokens() > rows[j].Usage.Tokens()
+	})
+	return rows
+}
+
+func printUsage(out io.Writer, by string, rows []usageRow) error {
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No agent usage recorded yet. Usage is read from Claude Code and Codex sessions.")
+		return nil
+	}
+	w := output.NewTabWriter(out)
+	fmt.Fprintf(w, "%s\tTASKS\tSESSIONS\tINPUT\tOUTPUT\tCACHED\tCOST\n", by)
+	var total usageRow
+	for _, r := range rows {
+		printUsageRow(w, r)
+		total.Tasks += r.Tasks
+		total.Usage.Add(r.Usage)
+	}
+	if len(rows) > 1 {
+		total.Key = "TOTAL"
+		printUsageRow(w, total)
+	}
+	return w.Flush()
+}
+
+func printUsageRow(w io.Writer, r usageRow) {
+	u := r.Usage
+	fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", output.Truncate(r.Key, 40), r.Tasks, u.Sessions,
+		formatTokens(u.InputTokens), formatTokens(u.OutputTokens),
+		formatTokens(u.CacheReadTokens+u.CacheWriteTokens), formatCost(u.CostUSD))
+}
+
+// formatTokens abbreviates a token count, e.g. 1234567 as "1.2M".
+func formatTokens(n int64) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprint(n)
+	case n < 1_000_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%.1fM", float64(n)/1e6)
+}
+
+func formatCost(usd float64) string {
+	if usd == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+)
+
+func TestGroupUsage(t *testing.T) {
+	now := time.Now()
+	tasks := []config.Task{
+		{ID: "wt-1", TicketKey: "PROJ-1", RepoPath: "/src/api", Agent: "claude", LastUsed: now, Usage: &config.Usage{Sessions: 1, InputTokens: 100, CostUSD: 1}},
+		{ID: "wt-2", RepoPath: "/src/web", Agent: "codex", LastUsed: now, Usage: &config.Usage{Sessions: 2, InputTokens: 500}},
+		{ID: "wt-3", RepoPath: "/src/web", LastUsed: now},
+		{ID: "wt-4", TicketKey: "PROJ-9", RepoPath: "/src/api", LastUsed: now.Add(-48 * time.Hour), Usage: &config.Usage{InputTokens: 1}},
+	}
+	finished := []task.UsageRecord{
+		{Task: "wt-0", Ticket: "PROJ-1", Repo: "/src/api", Agent: "claude", Finished: now.Add(-time.Hour), Usage: config.Usage{Sessions: 3, InputTokens: 50, CostUSD: 2}},
+		{Task: "wt-old", Ticket: "PROJ-2", Repo: "/src/api", Finished: now.Add(-72 * time.Hour), Usage: config.Usage{InputTokens: 5}},
+	}
+	records := usageRecords(tasks, finished, now.Add(-24*time.Hour))
+
+	rows := groupUsage(records, usageGroups["ticket"])
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2: %+v", len(rows), rows)
+	}
+	if r := rows[0]; r.Key != "PROJ-1" || r.Tasks != 2 || r.Usage.Sessions != 4 || r.Usage.CostUSD != 3 {
+		t.Errorf("rows[0] = %+v, want PROJ-1 with 2 tasks, 4 sessions and $3", r)
+	}
+	if r := rows[1]; r.Key != "wt-2" || r.Usage.InputTokens != 500 {
+		t.Errorf("rows[1] = %+v, want wt-2 listed by task ID", r)
+	}
+
+	rows = groupUsage(records, usageGroups["repo"])
+	if len(rows) != 2 || rows[0].Key != "api" || rows[1].Key != "web" {
+		t.Errorf("by repo = %+v, want api then web", rows)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{12_345, "12.3k"},
+		{1_234_567, "1.2M"},
+	}
+	for _, tt := range tests {
+		if got := formatTokens(tt.n); got != tt.want {
+			t.Errorf("formatTokens(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// gitStatus is the git state of a task's worktree shown by 'wt status'.
+type gitStatus struct {
+	Changed int `json:"changed"`
+	// Ahead and Behind count commits relative to the upstream branch.
+	Ahead       int  `json:"ahead"`
+	Behind      int  `json:"behind"`
+	HasUpstream bool `json:"has_upstream"`
+	// BaseAhead and BaseBehind count commits relative to the default branch.
+	Base       string `json:"base"`
+	BaseAhead  int    `json:"base_ahead"`
+	BaseBehind int    `json:"base_behind"`
+	// NeedsRebase is set when BaseBehind reaches rebase_threshold.
+	NeedsRebase bool                 `json:"needs_rebase"`
+	LastCommit  *worktree.CommitInfo `json:"last_commit,omitempty"`
+}
+
+// pullRequest is the pull request opened for a task's branch.
+type pullRequest struct {
+	Number int    `json:"number"`
+	State  string `json:"state"`
+	Draft  bool   `json:"draft"`
+	URL    string `json:"url"`
+}
+
+func (pr *pullRequest) String() string {
+	s := fmt.Sprintf("#%d %s", pr.Number, strings.ToLower(pr.State))
+	if pr.Draft {
+		s += " (draft)"
+	}
+	return s + "  " + pr.URL
+}
+
+// enrichStatus adds git, agent and, unless offline, live ticket and pull
+// request details to a task's status. Lookups that fail are left out with
+// a warning, so one unreachable service doesn't hide the rest.
+func enrichStatus(ctx context.Context, cfg *config.Config, s *taskStatus, offline bool) {
+	t := &s.Task
+	s.AgentStatus = agentStatus(*t)
+
+	if st, err := worktree.Status(ctx, t.Worktree); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	} else {
+		base := task.DefaultBranch(ctx, cfg, t.RepoPath)
+		g := &gitStatus{Changed: st.Changed, Ahead: st.Ahead, Behind: st.Behind, HasUpstream: st.HasUpstream, Base: base}
+		if ahead, behind, err := worktree.Compare(ctx, t.Worktree, base); err == nil {
+			g.BaseAhead, g.BaseBehind = ahead, behind
+			g.NeedsRebase = needsRebase(cfg, behind)
+		}
+		if c, err := worktree.LastCommit(ctx, t.Worktree); err == nil {
+			g.LastCommit = &c
+		}
+		s.Git = g
+	}
+	if offline {
+		return
+	}
+
+	if t.TicketKey != "" && t.Connector != "" {
+		ref := connector.Ref{Connector: t.Connector, Key: t.TicketKey}
+		res := fetchTaskTickets(ctx, cfg, []config.Task{*t})[ref]
+		if res.Err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to fetch %s: %v\n", t.TicketKey, res.Err)
+		} else if res.Ticket != nil {
+			s.TicketStatus = res.Ticket.Status
+		}
+	}
+	pr, err := findPullRequest(ctx, t.Worktree, t.Branch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	s.PullRequest = pr
+}
+
+// findPullRequest looks up the pull request for branch with the GitHub CLI.
+// It returns nil when gh is not installed, there is no pull request, or dir
+// is on another machine, where gh cannot find the repository.
+func findPullRequest(ctx context.Context, dir, branch string) (*pullRequest, error) {
+	if _, err := exec.LookPath("gh"); err != nil || worktree.IsRemote(dir) {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gh", "pr", "view", branch, "--json", "number,state,isDraft,url")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "no pull requests found") || strings.Contains(msg, "not a git repository") || strings.Contains(msg, "none of the git remotes") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up pull request: %s", msg)
+	}
+	var v struct {
+		Number  int    `json:"number"`
+		State   string `json:"state"`
+		IsDraft bool   `json:"isDraft"`
+		URL     string `json:"url"`
+	}
+	if err := json.Unmarshal(out, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode gh output: %w", err)
+	}
+	return &pullRequest{Number: v.Number, State: v.State, Draft: v.IsDraft, URL: v.URL}, nil
+}
+
+// timeAgo formats how long ago t was, e.g. "3h ago".
+func timeAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
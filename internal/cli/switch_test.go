@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+// runCaptured runs wt with args and returns what it printed to stdout and
+// stderr.
+func runCaptured(t *testing.T, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	origOut, origErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outW, errW
+	read := func(r *os.File) <-chan string {
+		ch := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			ch <- string(data)
+		}()
+		return ch
+	}
+	outCh, errCh := read(outR), read(errR)
+	err = Run(append([]string{"wt"}, args...))
+	os.Stdout, os.Stderr = origOut, origErr
+	outW.Close()
+	errW.Close()
+	return <-outCh, <-errCh, err
+}
+
+func TestSwitchPrintsOnlyThePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("WT_JSON", "")
+	t.Setenv("TMUX", "")
+	t.Chdir(home)
+	one := filepath.Join(home, "worktrees", "repo", "one")
+	two := filepath.Join(home, "worktrees", "repo", "two")
+	// wt-2 is still being checked out, which makes switch warn.
+	config := `tasks:
+  - id: wt-1
+    worktree: ` + one + `
+    branch: feature/one
+    last_used: 2026-01-02T00:00:00Z
+  - id: wt-2
+    worktree: ` + two + `
+    branch: feature/two
+    state: preparing
+    last_used: 2026-01-01T00:00:00Z
+`
+	os.MkdirAll(filepath.Join(home, ".wt"), 0o755)
+	if err := os.WriteFile(filepath.Join(home, ".wt", "config.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runCaptured(t, "switch", "wt-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != two {
+		t.Errorf("switch printed %q to stdout, want exactly %q", stdout, two)
+	}
+	if !strings.Contains(stderr, "still checking out") {
+		t.Errorf("stderr = %q, want the checkout warning", stderr)
+	}
+
+	if stdout, _, err := runCaptured(t, "switch", "-"); err != nil || stdout != one {
+		t.Errorf("switch - printed %q, %v; want exactly %q", stdout, err, one)
+	}
+	if stdout, _, err := runCaptured(t, "last"); err != nil || stdout != one {
+		t.Errorf("last printed %q, %v; want exactly %q", stdout, err, one)
+	}
+
+	stdout, _, err = runCaptured(t, "switch", "--json", "wt-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got switchResult
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("switch --json printed %q: %v", stdout, err)
+	}
+	if want := (switchResult{Path: two, Branch: "feature/two", Task: "wt-2"}); got != want {
+		t.Errorf("switch --json = %+v, want %+v", got, want)
+	}
+
+	if stdout, _, err := runCaptured(t, "switch", "wt-nope"); err == nil || stdout != "" {
+		t.Errorf("switch to an unknown task printed %q, %v; want nothing and an error", stdout, err)
+	}
+}
+
+func TestNewSwitchResultOnAnotherMachine(t *testing.T) {
+	task := &config.Task{ID: "wt-1", Branch: "feature/one", Worktree: "devbox:/home/me/worktrees/repo/one"}
+	got := newSwitchResult(task, false)
+	want := switchResult{
+		Path:    "/home/me/worktrees/repo/one",
+		Branch:  "feature/one",
+		Task:    "wt-1",
+		Host:    "devbox",
+		Command: `ssh -t devbox 'cd /home/me/worktrees/repo/one && exec "$SHELL" -l'`,
+	}
+	if got != want {
+		t.Errorf("newSwitchResult = %+v, want %+v", got, want)
+	}
+	if got.target() != want.Command {
+		t.Errorf("target() = %q, want the ssh command", got.target())
+	}
+	if got := newSwitchResult(task, true).target(); got != "vscode://vscode-remote/ssh-remote+devbox/home/me/worktrees/repo/one" {
+		t.Errorf("target() with vscode = %q", got)
+	}
+}
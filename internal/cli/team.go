@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/team"
+	"github.com/urfave/cli/v2"
+)
+
+// --- team ---
+func teamCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "team",
+		Category: "config",
+		Usage:    "Share active tasks with your team",
+		Description: `Share your active tasks through a remote state backend so everyone on
+   a small team can see who is working on what with 'wt list --team'.
+
+   Backends:
+     git   a branch (default wt-team) of any git remote you can push to
+     http  a server run with 'wt serve --team', or a compatible API
+
+   Each user and machine writes its own file, so updates from different
+   people never conflict. Your state is pushed whenever a command changes your
+   tasks, and on 'wt list --team'. Local paths are never shared.
+
+   Nothing stops a member from writing another's file: anyone who can push
+   to the git remote, or who holds a team token, is trusted with the whole
+   team's state.
+
+   Examples:
+     wt team init --git git@github.com:acme/app.git
+     wt team init --url https://wt.acme.internal:9273/team --token SECRET
+     wt team push`,
+		Subcommands: []*cli.Command{
+			{
+				Name:  "init",
+				Usage: "Configure the team state backend",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "git", Usage: "Git remote URL to keep team state on"},
+					&cli.StringFlag{Name: "branch", Usage: "Branch for the git backend (default: " + team.DefaultBranch + ")"},
+					&cli.StringFlag{Name: "url", Usage: "Team server URL for the http backend"},
+					&cli.StringFlag{Name: "token", Usage: "Bearer token for the team server"},
+					&cli.StringFlag{Name: "user", Usage: "Name shown to your team (default: your login name)"},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					tc := config.TeamConfig{Branch: c.String("branch"), Token: c.String("token"), User: c.String("user")}
+					switch {
+					case c.String("git") != "" && c.String("url") != "":
+						return fmt.Errorf("use either --git or --url, not both")
+					case c.String("git") != "":
+						tc.Backend, tc.URL = config.TeamGit, c.String("git")
+					case c.String("url") != "":
+						tc.Backend, tc.URL = config.TeamHTTP, c.String("url")
+					default:
+						return fmt.Errorf("please provide --git <remote-url> or --url <server-url>")
+					}
+					cfg.Team = tc
+					if err := pushTeamState(c.Context, cfg); err != nil {
+						return err
+					}
+					if err := cfg.Save(); err != nil {
+						return err
+					}
+					fmt.Printf("✅ Sharing tasks as %s via %s\n", teamUser(cfg), tc.URL)
+					return nil
+				},
+			},
+			{
+				Name:  "push",
+				Usage: "Publish your active tasks now",
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig()
+					if err != nil {
+						return err
+					}
+					if err := pushTeamState(c.Context, cfg); err != nil {
+						return err
+					}
+					fmt.Printf("Published %d task(s) as %s\n", len(cfg.Tasks), teamUser(cfg))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// teamBackend returns the configured team backend.
+func teamBackend(cfg *config.Config) (team.Backend, error) {
+	dir, err := cachePath("")
+	if err != nil {
+		return nil, err
+	}
+	return team.New(cfg.Team, dir)
+}
+
+// teamUser is the namespace this user's state is shared under.
+func teamUser(cfg *config.Config) string {
+	if cfg.Team.User != "" {
+		return cfg.Team.User
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// pushTeamState publishes the current tasks to the team backend.
+func pushTeamState(ctx context.Context, cfg *config.Config) error {
+	b, err := teamBackend(cfg)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	snap := team.NewSnapshot(teamUser(cfg), host, cfg.Tasks)
+	if err := b.Push(ctx, snap); err != nil {
+		return err
+	}
+	if path, err := cachePath("team-pushed"); err == nil && os.MkdirAll(filepath.Dir(path), 0o755) == nil {
+		os.WriteFile(path, []byte(snap.Digest()), 0o644)
+	}
+	return nil
+}
+
+// teamStateStale reports whether the shared tasks differ from those last
+// pushed from this machine.
+func teamStateStale(cfg *config.Config) bool {
+	path, err := cachePath("team-pushed")
+	if err != nil {
+		return true
+	}
+	last, _ := os.ReadFile(path)
+	host, _ := os.Hostname()
+	return string(last) != team.NewSnapshot(teamUser(cfg), host, cfg.Tasks).Digest()
+}
+
+// pushTeamStateIfChanged publishes the tasks after a command that changed
+// the config or the tasks, as told by the config.Stamp taken before it,
+// unless the shared state is what was last pushed. Failures are warnings:
+// the command itself succeeded.
+func pushTeamStateIfChanged(before string) {
+	if after, err := config.Stamp(); err != nil || after == before {
+		return
+	}
+	cfg, err := config.Load()
+	if err != nil || cfg.Team.URL == "" {
+		return
+	}
+	if !teamStateStale(cfg) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := pushTeamState(ctx, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// printTeamList publishes this user's tasks if needed and prints everyone's.
+func printTeamList(ctx context.Context, out io.Writer, cfg *config.Config, f *output.Formatter) error {
+	if teamStateStale(cfg) {
+		if err := pushTeamState(ctx, cfg); err != nil {
+			return err
+		}
+	}
+	b, err := teamBackend(cfg)
+	if err != nil {
+		return err
+	}
+	snapshots, err := b.Pull(ctx)
+	if err != nil {
+		return err
+	}
+	members := team.Merge(snapshots)
+	return f.Write(out, members, func(w io.Writer) error {
+		printTeamTable(w, members)
+		return nil
+	})
+}
+
+func printTeamTable(out io.Writer, members []team.Member) {
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, "USER\tID\tDESCRIPTION\tREPO\tBRANCH\tTICKET\tSTATUS")
+	for _, m := range members {
+		for _, t := range m.Tasks {
+			desc := output.Truncate(t.Description, 40)
+			if t.Lock != nil {
+				desc = "🔒 " + desc
+			}
+			cols := []string{m.User, t.ID, desc, orDash(t.Repo), t.Branch, orDash(t.TicketKey), orDash(t.Status)}
+			fmt.Fprintln(w, strings.Join(cols, "\t"))
+		}
+	}
+	w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
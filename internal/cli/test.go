@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- test ---
+func testCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "test",
+		Category:  "lifecycle",
+		Usage:     "Run the repository's test command in a task's worktree",
+		ArgsUsage: "[task-id|path]",
+		Description: `Run the test_command configured for the task's repository inside its
+   worktree, with the task's environment (see 'wt env'), and record whether
+   it passed. Uncommitted changes are tested too.
+
+   The result is shown by 'wt status', 'wt list --git' and 'wt compare', and
+   the output of the last run is kept in test.log in the task's scratch
+   directory.
+
+   With --all, the tests of every task whose repository has a test_command
+   are run; --parallel runs several at once, logging their output instead
+   of printing it. wt test exits with code 10 when any tests fail.
+
+   Configure the command per repository in ~/.wt/config.yaml:
+
+     repos:
+       api:
+         test_command: go test ./...
+
+   Examples:
+     wt test
+     wt test wt-a1b2c3d4
+     wt test --all --parallel 4`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Test every task whose repository has a test_command",
+			},
+			&cli.IntFlag{
+				Name:    "parallel",
+				Aliases: []string{"j"},
+				Value:   1,
+				Usage:   "Number of test runs at once with --all",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if !c.Bool("all") {
+				t, err := taskFromArgOrCwd(c, cfg)
+				if err != nil {
+					return err
+				}
+				warnIfNotReady(t)
+				return testTasks(c, cfg, []config.Task{*t}, 1)
+			}
+			if c.NArg() > 0 {
+				return fmt.Errorf("--all cannot be combined with a task")
+			}
+			var tasks []config.Task
+			for _, t := range cfg.Tasks {
+				if t.State == "" && cfg.TestCommand(t.RepoPath, t.Worktree) != "" {
+					tasks = append(tasks, t)
+				}
+			}
+			if len(tasks) == 0 {
+				return fmt.Errorf("%w for any ready task; set repos.<repo>.test_command", task.ErrNoTestCommand)
+			}
+			return testTasks(c, cfg, tasks, c.Int("parallel"))
+		},
+	}
+}
+
+// testTasks runs the tests of tasks, at most parallel at once, and records
+// the results. Output is printed only when tests run one at a time.
+func testTasks(c *cli.Context, cfg *config.Config, tasks []config.Task, parallel int) error {
+	if parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+	mgr := task.NewManager(cfg)
+	results := make([]config.TestResult, len(tasks))
+	errs := make([]error, len(tasks))
+	var mu sync.Mutex
+	worktree.RunAll(len(tasks), parallel, func(i int) {
+		t := &tasks[i]
+		var out io.Writer = os.Stdout
+		if parallel > 1 {
+			out = io.Discard
+		} else if command := cfg.TestCommand(t.RepoPath, t.Worktree); command != "" {
+			fmt.Printf("🧪 %s: %s\n", t.ID, command)
+		}
+		results[i], errs[i] = mgr.RunTests(c.Context, t, out)
+		if len(tasks) > 1 || errs[i] == nil {
+			mu.Lock()
+			printTestResult(t, results[i], errs[i])
+			mu.Unlock()
+		}
+	})
+
+	if err := c.Context.Err(); err != nil {
+		return err
+	}
+	if len(tasks) == 1 && errs[0] != nil {
+		return errs[0]
+	}
+	failed := 0
+	for i, t := range tasks {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		if !results[i].Passed {
+			failed++
+		}
+		if err := cfg.SetTestResult(t.ID, results[i]); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w in %d of %d task(s)", task.ErrTestsFailed, failed, len(tasks))
+	}
+	return nil
+}
+
+func printTestResult(t *config.Task, result config.TestResult, err error) {
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "warning: %s: %v\n", t.ID, err)
+	case result.Passed:
+		fmt.Printf("✅ %s passed (%s)\n", t.ID, result.Duration)
+	default:
+		logPath, _ := task.TestLogPath(t.ID)
+		fmt.Printf("❌ %s failed (%s, log: %s)\n", t.ID, result.Duration, logPath)
+	}
+}
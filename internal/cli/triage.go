@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/output"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/terminal"
+	"github.com/urfave/cli/v2"
+)
+
+// triageItem is a ticket in 'wt triage': where it came from, and the task
+// started from it, if any.
+type triageItem struct {
+	connector.Ticket
+	// Ref is the ticket's key prefixed with its connector, e.g.
+	// jira:PROJ-123, as 'wt start --ticket' takes it.
+	Ref       string `json:"ref"`
+	Connector string `json:"connector"`
+	Task      string `json:"task,omitempty"`
+}
+
+// --- triage ---
+func triageCmd() *cli.Command {
+	return &cli.Command{
+		Name:     "triage",
+		Category: "config",
+		Usage:    "List assigned tickets from every connector, most urgent first",
+		Description: `Combine the tickets assigned to you in every configured connector into
+   one list: Jira issues, Sentry errors, incidents, flagged emails and so on.
+   Tickets are sorted by priority, and tickets of the same priority by how
+   long they have gone without an update, the oldest first. A ticket that
+   two connectors report, with the same URL, is listed once.
+
+   Keys are prefixed with their connector, e.g. jira:PROJ-123, which
+   'wt start --ticket' takes as is. On a terminal, wt triage then asks for
+   the number of a ticket to start a task from, right away; tickets that
+   already have a task offer to switch to it.
+
+   A connector that fails is reported and the others are still listed.
+
+   Examples:
+     wt triage
+     wt triage --connectors jira,sentry
+     wt start --ticket sentry:BACKEND-1A2   # a key from the list
+     wt triage -o json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "connectors", Usage: "Comma-separated connectors to list (default: all configured)"},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			names, err := triageConnectors(cfg, splitList(c.String("connectors")))
+			if err != nil {
+				return err
+			}
+			items := triageTickets(c, cfg, names)
+			if err := f.Write(os.Stdout, items, func(out io.Writer) error {
+				return printTriage(out, items)
+			}); err != nil {
+				return err
+			}
+			if !f.IsTable() || len(items) == 0 || !terminal.IsTerminal(os.Stdin) || !terminal.IsTerminal(os.Stdout) {
+				return nil
+			}
+			fmt.Print("\nStart a task from # (Enter to skip): ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer = strings.TrimSpace(answer)
+			if answer == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(answer)
+			if err != nil || n < 1 || n > len(items) {
+				return fmt.Errorf("no ticket #%s; pick a number from 1 to %d", answer, len(items))
+			}
+			return startTriaged(c, cfg, items[n-1])
+		},
+	}
+}
+
+// triageConnectors returns the configured connectors to list, in name
+// order, or only those in only when it is given.
+func triageConnectors(cfg *config.Config, only []string) ([]string, error) {
+	reg := buildRegistry(cfg)
+	if len(only) > 0 {
+		for _, name := range only {
+			if _, err := reg.Lookup(name); err != nil {
+				return nil, err
+			}
+		}
+		return only, nil
+	}
+	var names []string
+	for name := range cfg.Connectors {
+		if _, ok := reg.Get(name); ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: no connectors; run 'wt connect' first", connector.ErrNotConfigured)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// triageTickets fetches the assigned tickets of the named connectors in
+// parallel, and returns them without duplicates, most urgent first.
+func triageTickets(c *cli.Context, cfg *config.Config, names []string) []triageItem {
+	reg := buildRegistry(cfg)
+	tickets := make([][]connector.Ticket, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		conn, _ := reg.Get(name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tickets[i], errs[i] = conn.ListAssigned(c.Context)
+		}()
+	}
+	wg.Wait()
+
+	started := make(map[connector.Ref]string)
+	for _, t := range cfg.Tasks {
+		if t.TicketKey != "" {
+			started[connector.Ref{Connector: t.Connector, Key: t.TicketKey}] = t.ID
+		}
+	}
+	items := []triageItem{}
+	seen := make(map[string]bool)
+	for i, name := range names {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", name, errs[i])
+			continue
+		}
+		for _, t := range tickets[i] {
+			id := name + ":" + t.Key
+			if t.URL != "" {
+				id = t.URL
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			items = append(items, triageItem{
+				Ticket:    t,
+				Ref:       name + ":" + t.Key,
+				Connector: name,
+				Task:      started[connector.Ref{Connector: name, Key: t.Key}],
+			})
+		}
+	}
+	sortTriage(items)
+	return items
+}
+
+// sortTriage orders tickets by priority, and tickets of the same priority
+// by their last update, the oldest first and those without one last.
+func sortTriage(items []triageItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if c := connector.ComparePriority(a.Priority, b.Priority); c != 0 {
+			return c < 0
+		}
+		if a.Updated.IsZero() || b.Updated.IsZero() {
+			return !a.Updated.IsZero() && b.Updated.IsZero()
+		}
+		return a.Updated.Before(b.Updated)
+	})
+}
+
+func printTriage(out io.Writer, items []triageItem) error {
+	if len(items) == 0 {
+		fmt.Fprintln(out, "Nothing assigned to you.")
+		return nil
+	}
+	w := output.NewTabWriter(out)
+	fmt.Fprintln(w, "#\tKEY\tPRIORITY\tUPDATED\tSUMMARY\tTASK")
+	for i, it := range items {
+		updated := "-"
+		if !it.Updated.IsZero() {
+			updated = timeAgo(it.Updated)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, it.Ref, connector.FieldValue(it.Ticket, "priority"), updated, output.Truncate(it.Summary, 50), it.Task)
+	}
+	return w.Flush()
+}
+
+// startTriaged starts a task in the current repository from a ticket
+// picked in 'wt triage', or offers to switch to its existing task.
+func startTriaged(c *cli.Context, cfg *config.Config, it triageItem) error {
+	if existing, err := cfg.FindTaskByTicket(it.Connector, it.Key); err == nil {
+		return offerExistingTask(cfg, existing)
+	}
+	repoPath, err := getRepoPath()
+	if err != nil {
+		return err
+	}
+	if err := checkStartPoint(c.Context, cfg, repoPath, false); err != nil {
+		return err
+	}
+	opts := task.StartOptions{RepoPath: repoPath}
+	if _, err := fetchStartTicket(c.Context, cfg, it.Connector, it.Key, &opts); err != nil {
+		return err
+	}
+	t, err := task.NewManager(cfg).Start(c.Context, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Task started: %s\n", t.ID)
+	fmt.Printf("   Branch:   %s\n", t.Branch)
+	fmt.Printf("   Worktree: %s\n", t.Worktree)
+	if r := newSwitchResult(t, false); r.Command != "" {
+		fmt.Printf("\n   %s\n", r.Command)
+	} else {
+		fmt.Printf("\n   cd %s\n", r.Path)
+	}
+	return nil
+}
+
+// splitTicketRef splits a ticket key prefixed with its connector, as 'wt
+// triage' lists it, e.g. jira:PROJ-123. Keys without the prefix of a
+// configured connector are returned as they are.
+func splitTicketRef(cfg *config.Config, ref string) (name, key string) {
+	name, key, ok := strings.Cut(ref, ":")
+	if !ok {
+		return "", ref
+	}
+	if _, configured := cfg.Connectors[name]; !configured {
+		return "", ref
+	}
+	return name, key
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+func TestSortTriage(t *testing.T) {
+	now := time.Now()
+	item := func(ref, priority string, age time.Duration) triageItem {
+		it := triageItem{Ticket: connector.Ticket{Priority: priority}, Ref: ref}
+		if age > 0 {
+			it.Updated = now.Add(-age)
+		}
+		return it
+	}
+	items := []triageItem{
+		item("jira:A", "", time.Hour),
+		item("jira:B", "Low", time.Hour),
+		item("sentry:C", "high", 0),
+		item("jira:D", "High", time.Hour),
+		item("pagerduty:E", "P1", time.Minute),
+		item("jira:F", "High", 24*time.Hour),
+	}
+	sortTriage(items)
+	want := []string{"pagerduty:E", "jira:F", "jira:D", "sentry:C", "jira:B", "jira:A"}
+	for i, it := range items {
+		if it.Ref != want[i] {
+			t.Fatalf("order = %v, want %v", refs(items), want)
+		}
+	}
+}
+
+func refs(items []triageItem) []string {
+	out := make([]string, len(items))
+	for i, it := range items {
+		out[i] = it.Ref
+	}
+	return out
+}
+
+func TestSplitTicketRef(t *testing.T) {
+	cfg := &config.Config{Connectors: map[string]config.ConnectorConfig{"jira": {}}}
+	tests := []struct {
+		ref, name, key string
+	}{
+		{"jira:PROJ-1", "jira", "PROJ-1"},
+		{"PROJ-1", "", "PROJ-1"},
+		{"sentry:BACKEND-1", "", "sentry:BACKEND-1"},
+	}
+	for _, tt := range tests {
+		if name, key := splitTicketRef(cfg, tt.ref); name != tt.name || key != tt.key {
+			t.Errorf("splitTicketRef(%q) = %q, %q, want %q, %q", tt.ref, name, key, tt.name, tt.key)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// twoWaySync reconciles tasks with their tickets according to sync_rules.
+func twoWaySync(c *cli.Context, cfg *config.Config) error {
+	rules := cfg.SyncRules
+	if len(rules) == 0 {
+		rules = config.DefaultSyncRules
+	}
+	if err := task.ValidateSyncRules(rules); err != nil {
+		return err
+	}
+
+	reg := buildRegistry(cfg)
+	var refs []connector.Ref
+	for _, t := range cfg.Tasks {
+		if t.TicketKey != "" && t.Connector != "" {
+			refs = append(refs, connector.Ref{Connector: t.Connector, Key: t.TicketKey})
+		}
+	}
+	if len(refs) == 0 {
+		fmt.Println("No tasks linked to tickets.")
+		return nil
+	}
+
+	fmt.Printf("Checking %d ticket(s)...\n", len(refs))
+	fetcher := &connector.Fetcher{Registry: reg, RateLimit: cfg.TicketRateLimit}
+	results := fetcher.FetchAll(c.Context, refs)
+	remote := make(map[string]string)
+	for _, t := range cfg.Tasks {
+		if t.TicketKey == "" || t.Connector == "" {
+			continue
+		}
+		res := results[connector.Ref{Connector: t.Connector, Key: t.TicketKey}]
+		if res.Err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s (%s): %v\n", t.ID, t.TicketKey, res.Err)
+			continue
+		}
+		remote[t.ID] = res.Ticket.Status
+	}
+
+	actions := task.PlanSync(rules, cfg.Tasks, remote)
+	if len(actions) == 0 {
+		fmt.Println("Everything is in sync.")
+		return nil
+	}
+	for _, a := range actions {
+		fmt.Printf("  %s\n", a)
+	}
+	if c.Bool("dry-run") {
+		fmt.Println("\nDry run: no changes made.")
+		return nil
+	}
+	fmt.Println()
+
+	mgr := task.NewManager(cfg)
+	failed := 0
+	for _, a := range actions {
+		if err := applySyncAction(c, mgr, reg, a); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", a.Task.ID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sync actions failed", failed, len(actions))
+	}
+	return nil
+}
+
+func applySyncAction(c *cli.Context, mgr *task.Manager, reg *connector.Registry, a task.SyncAction) error {
+	t := a.Task
+	switch a.Rule.Action {
+	case config.SyncTransition:
+		conn, err := reg.Lookup(t.Connector)
+		if err != nil {
+			return err
+		}
+		if err := conn.TransitionTicket(c.Context, t.TicketKey, a.Rule.To); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s moved to %s\n", t.TicketKey, a.Rule.To)
+	case config.SyncSetStatus:
+		if err := mgr.Config.SetTaskStatus(t.ID, a.Rule.To); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s status set to %s\n", t.ID, a.Rule.To)
+	case config.SyncFinish:
+		if !confirmSyncDelete(c, "Finish", t) {
+			return nil
+		}
+		if _, err := mgr.Finish(c.Context, t.ID); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Task finished: %s\n", t.ID)
+	case config.SyncRemove:
+		if !confirmSyncDelete(c, "Remove", t) {
+			return nil
+		}
+		if _, err := mgr.Remove(c.Context, t.ID); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Task removed: %s\n", t.ID)
+	}
+	return nil
+}
+
+// confirmSyncDelete asks before a sync rule deletes a worktree, unless --yes
+// was given; deleting work is never done silently.
+func confirmSyncDelete(c *cli.Context, verb string, t config.Task) bool {
+	if c.Bool("yes") || confirm(fmt.Sprintf("%s task %s (%s)?", verb, t.ID, t.Description)) {
+		return true
+	}
+	fmt.Printf("   Skipped; run 'wt %s %s' when ready.\n", strings.ToLower(verb), t.ID)
+	return false
+}
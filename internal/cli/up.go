@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/urfave/cli/v2"
+)
+
+// webStatus is what 'wt up' prints with -o json.
+type webStatus struct {
+	Task    string `json:"task"`
+	URL     string `json:"url"`
+	Port    int    `json:"port"`
+	PID     int    `json:"pid"`
+	Started bool   `json:"started"`
+	Log     string `json:"log"`
+}
+
+// workspaceStatus is what 'wt up' prints with -o json for a workspace.
+type workspaceStatus struct {
+	Task        string `json:"task"`
+	Workspace   string `json:"workspace"`
+	WorkspaceID string `json:"workspace_id"`
+	Shell       string `json:"shell"`
+}
+
+// --- up ---
+func upCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "up",
+		Category:  "lifecycle",
+		Usage:     "Start a task's workspace, or a browser-based editor for it",
+		ArgsUsage: "[--web] [task-id]",
+		Description: `Bring up the workspace of a task, or the task of the current worktree,
+   when its repository sets repos.<repo>.workspace to devpod or codespace:
+   create it if 'wt start' did not (after a background checkout or a
+   failure), or resume it after 'wt down'. Prints the command that opens a
+   shell in it.
+
+   With --web, start code-server in the task's worktree instead, and print
+   its URL. Each task gets a port of its own from web_port_base (default
+   8100), kept for the life of the task, so bookmarks keep working. The
+   editor runs in the background until 'wt down', or until the task is
+   finished or removed.
+
+   Set web_command to run another editor, e.g. openvscode-server or
+   devpod; {port}, {dir} and {id} are replaced with the task's port,
+   worktree and ID. The default is:
+     code-server --bind-addr 127.0.0.1:{port} --disable-telemetry {dir}
+
+   The editor's output goes to ~/.wt/logs/<task-id>-web.log.
+
+   Examples:
+     wt up wt-abc123                  # create or resume its Devpod or Codespace
+     wt up --web wt-abc123
+     wt up --web                      # the task of the current worktree
+     wt down wt-abc123`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "web", Usage: "Start a web editor on the task's port and print its URL"},
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			if !c.Bool("web") {
+				t, err := task.NewManager(cfg).UpWorkspace(c.Context, t.ID)
+				if err != nil {
+					return err
+				}
+				status := workspaceStatus{Task: t.ID, Workspace: t.Workspace, WorkspaceID: t.WorkspaceID, Shell: task.WorkspaceShell(t)}
+				return f.Write(os.Stdout, status, func(w io.Writer) error {
+					fmt.Fprintf(w, "✅ The %s workspace of %s is up: %s\n", t.Workspace, t.ID, t.WorkspaceID)
+					fmt.Fprintf(w, "\n   %s\n", status.Shell)
+					return nil
+				})
+			}
+			warnIfNotReady(t)
+			t, started, err := task.NewManager(cfg).StartWeb(t.ID)
+			if err != nil {
+				return err
+			}
+			logPath, _ := task.WebLogPath(t.ID)
+			status := webStatus{Task: t.ID, URL: task.WebURL(t), Port: t.WebPort, PID: t.WebPID, Started: started, Log: logPath}
+			return f.Write(os.Stdout, status, func(w io.Writer) error {
+				if started {
+					fmt.Fprintf(w, "🌐 Web editor for %s started: %s\n", t.ID, status.URL)
+				} else {
+					fmt.Fprintf(w, "🌐 Web editor for %s already running: %s\n", t.ID, status.URL)
+				}
+				fmt.Fprintf(w, "   Log: %s\n", status.Log)
+				return nil
+			})
+		},
+	}
+}
+
+// --- down ---
+func downCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "down",
+		Category:  "lifecycle",
+		Usage:     "Stop a task's web editor and workspace",
+		ArgsUsage: "[task-id]",
+		Description: `Stop the web editor 'wt up --web' started for a task, or the task of the
+   current worktree, and its Devpod or Codespace. The task keeps its port
+   for the next 'wt up --web', and the workspace its files for 'wt up'.`,
+		Action: func(c *cli.Context) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := taskFromArgOrCwd(c, cfg)
+			if err != nil {
+				return err
+			}
+			mgr := task.NewManager(cfg)
+			running, err := mgr.StopWeb(t.ID)
+			if err != nil {
+				return err
+			}
+			if running {
+				fmt.Printf("✅ Stopped the web editor of %s\n", t.ID)
+			}
+			stopped, err := mgr.DownWorkspace(c.Context, t.ID)
+			if err != nil {
+				return err
+			}
+			if stopped {
+				fmt.Printf("✅ Stopped the %s workspace of %s\n", t.Workspace, t.ID)
+			}
+			if !running && !stopped {
+				fmt.Printf("Nothing running for %s\n", t.ID)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/task"
+	"github.com/bakerweb/wt/internal/worktree"
+	"github.com/urfave/cli/v2"
+)
+
+// --- watch-agent ---
+func watchAgentCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "watch-agent",
+		Category:  "agent",
+		Usage:     "Run an agent in a task, checkpointing the worktree as it works",
+		ArgsUsage: "<task-id>",
+		Description: `Launch an agent in a task's worktree like 'wt agent', but keep wt
+   running alongside it and save a checkpoint of the worktree every
+   --interval while it changes files, and once more when the agent exits.
+   If the agent goes off the rails, 'wt rollback' brings the worktree back
+   to any checkpoint.
+
+   Checkpoints are commits kept under refs/wt/checkpoints/<task-id>/, not
+   on the task's branch: the agent's branch, index and files are never
+   touched, and untracked files are saved too. They are deleted when the
+   task is finished or removed. Checkpoints taken are logged to watch.log
+   in the task's scratch directory.
+
+   Examples:
+     wt watch-agent wt-abc123
+     wt watch-agent --interval 2m --agent claude wt-abc123`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "agent",
+				Usage: "Agent to launch. If omitted, uses WT_AGENT env var or default_agent config",
+			},
+			&cli.StringFlag{
+				Name:  "agent-args",
+				Usage: "Arguments to pass to the agent",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Value: 5 * time.Minute,
+				Usage: "How often to checkpoint the worktree",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("please provide a task ID (see 'wt list')")
+			}
+			interval := c.Duration("interval")
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			t, err := cfg.FindTask(c.Args().First())
+			if err != nil {
+				return err
+			}
+			if err := checkLocal(t); err != nil {
+				return err
+			}
+			if _, err := os.Stat(t.Worktree); err != nil {
+				return fmt.Errorf("worktree %s no longer exists: %w", t.Worktree, err)
+			}
+			warnIfNotReady(t)
+
+			agentName := resolveAgent(c.String("agent"), os.Getenv("WT_AGENT"), cfg.DefaultAgent)
+			if agentName == "" {
+				return fmt.Errorf("no agent specified; use --agent flag, set WT_AGENT env var, or configure default_agent")
+			}
+			if err := agent.ValidateAgent(agentName, cfg.AgentAliases); err != nil {
+				return fmt.Errorf("agent %q not found: %w", agentName, err)
+			}
+			env, err := agentEnv(cfg, t)
+			if err != nil {
+				return err
+			}
+			launch, err := launchOptions(cfg, t, agentName, agent.ParseAgentArgs(c.String("agent-args")), env)
+			if err != nil {
+				return err
+			}
+
+			// Ctrl-C reaches the agent, which decides what it means; the
+			// checkpoints must still be taken after it.
+			ctx := context.WithoutCancel(c.Context)
+			w := &watcher{ctx: ctx, task: t}
+			if dir, err := task.ScratchDir(t.ID); err == nil {
+				w.logPath = filepath.Join(dir, "watch.log")
+			}
+			w.checkpoint("before " + agentName)
+
+			cmd, err := agent.Start(launch)
+			if err != nil {
+				return err
+			}
+			if err := cfg.RecordAgent(t.ID, agentName, cmd.Process.Pid, agent.StartTime(cmd.Process.Pid)); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+			setTitle(cfg, t)
+			fmt.Printf("🚀 Launching agent %q on task %s, checkpointing every %s\n", agentName, t.ID, interval)
+			fmt.Printf("   Worktree: %s\n", t.Worktree)
+
+			done := make(chan error, 1)
+			go func() { done <- cmd.Wait() }()
+			started := time.Now()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			var agentErr error
+		wait:
+			for {
+				select {
+				case agentErr = <-done:
+					break wait
+				case <-ticker.C:
+					w.checkpoint(fmt.Sprintf("%s after %s", agentName, time.Since(started).Round(time.Second)))
+				}
+			}
+			w.checkpoint(fmt.Sprintf("%s exited after %s", agentName, time.Since(started).Round(time.Second)))
+			if err := cfg.ClearAgent(t.ID, cmd.Process.Pid); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+
+			if err := printWatchSummary(ctx, os.Stdout, t, w.saved); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+			if agentErr != nil {
+				return fmt.Errorf("agent %s failed: %w", agentName, agentErr)
+			}
+			return nil
+		},
+	}
+}
+
+// watcher saves the checkpoints of a task while an agent runs in it. The
+// agent owns the terminal, so what happens is logged to a file.
+type watcher struct {
+	ctx     context.Context
+	task    *config.Task
+	logPath string
+	saved   int
+}
+
+func (w *watcher) checkpoint(message string) {
+	cp, saved, err := worktree.SaveCheckpoint(w.ctx, w.task.Worktree, w.task.ID, message)
+	switch {
+	case err != nil:
+		w.log("checkpoint failed: %v", err)
+	case saved:
+		w.saved++
+		w.log("checkpoint %d %s: %s", cp.N, shortHash(cp.Commit), message)
+	default:
+		w.log("no changes since checkpoint %d", cp.N)
+	}
+}
+
+func (w *watcher) log(format string, args ...any) {
+	if w.logPath == "" {
+		return
+	}
+	f, err := os.OpenFile(w.logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// printWatchSummary lists a task's checkpoints after a watched agent
+// exits, with how to go back to one.
+func printWatchSummary(ctx context.Context, out io.Writer, t *config.Task, saved int) error {
+	checkpoints, err := worktree.Checkpoints(ctx, t.Worktree, t.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\n📍 %d new checkpoint(s) of %s\n", saved, t.ID)
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	if err := printCheckpoints(out, t, checkpoints); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "   Roll back with: wt rollback %s <checkpoint>\n", t.ID)
+	return nil
+}
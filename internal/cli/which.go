@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// --- which ---
+func whichCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "which",
+		Category:  "navigation",
+		Usage:     "Print the task and worktree of a ticket or branch",
+		ArgsUsage: "<ticket-key|branch>",
+		Description: `Print the ID and worktree path of the task started from a ticket, or
+   working on a branch, separated by a tab. Ticket keys match in any case.
+   When no task matches, nothing is printed and wt exits with 1, so scripts
+   and editor plugins can map a ticket or branch back to a local checkout.
+
+   Examples:
+     wt which PROJ-123
+     wt which feature/proj-123-fix-login
+     read -r id dir < <(wt which PROJ-123) && cd "$dir"
+     wt which -o json PROJ-123`,
+		Flags: []cli.Flag{
+			outputFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("please provide a ticket key or branch name")
+			}
+			f, err := formatter(c)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			ref := c.Args().First()
+			tasks := whichTasks(cfg, ref)
+			if len(tasks) == 0 {
+				return fmt.Errorf("no task for ticket or branch %q", ref)
+			}
+			return f.Write(os.Stdout, tasks, func(w io.Writer) error {
+				for _, t := range tasks {
+					fmt.Fprintf(w, "%s\t%s\n", t.ID, t.Worktree)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// whichTasks returns the tasks started from the ticket ref, or whose
+// branch is ref, oldest first.
+func whichTasks(cfg *config.Config, ref string) []config.Task {
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	var tasks []config.Task
+	for _, t := range cfg.Tasks {
+		if (t.TicketKey != "" && strings.EqualFold(t.TicketKey, ref)) || (t.Branch != "" && t.Branch == branch) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
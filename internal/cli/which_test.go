@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestWhichTasks(t *testing.T) {
+	cfg := &config.Config{Tasks: []config.Task{
+		{ID: "wt-login", Branch: "feature/proj-1-fix-login", TicketKey: "PROJ-1"},
+		{ID: "wt-login-2", Branch: "feature/proj-1-fix-login-2", TicketKey: "PROJ-1"},
+		{ID: "wt-local", Branch: "feature/cleanup"},
+	}}
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"PROJ-1", []string{"wt-login", "wt-login-2"}},
+		{"proj-1", []string{"wt-login", "wt-login-2"}},
+		{"feature/cleanup", []string{"wt-local"}},
+		{"refs/heads/feature/proj-1-fix-login-2", []string{"wt-login-2"}},
+		{"PROJ-2", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, task := range whichTasks(cfg, tt.ref) {
+			got = append(got, task.ID)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("whichTasks(%q) = %v, want %v", tt.ref, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("whichTasks(%q) = %v, want %v", tt.ref, got, tt.want)
+				break
+			}
+		}
+	}
+}
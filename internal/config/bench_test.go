@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// benchConfig saves a config with n tasks, about the size of a busy user's,
+// in a new home directory.
+func benchConfig(b *testing.B, n int) string {
+	b.Helper()
+	home := b.TempDir()
+	b.Setenv("HOME", home)
+	cfg := DefaultConfig()
+	cfg.path = filepath.Join(home, configDir, configFile)
+	cfg.Connectors["jira"] = ConnectorConfig{URL: "https://acme.atlassian.net", Email: "sam@example.com", APIToken: "token"}
+	for i := 0; i < n; i++ {
+		cfg.Tasks = append(cfg.Tasks, Task{
+			ID:          fmt.Sprintf("wt-%08x", i),
+			Description: "Fix the flaky checkout test on the payments page",
+			Worktree:    fmt.Sprintf("/home/sam/worktrees/app/task-%d", i),
+			Branch:      fmt.Sprintf("feature/PROJ-%d-fix-flaky-checkout", i),
+			RepoPath:    "/home/sam/src/app",
+			Connector:   "jira",
+			TicketKey:   fmt.Sprintf("PROJ-%d", i),
+			Created:     time.Now(),
+			Head:        "0123456789abcdef0123456789abcdef01234567",
+			MergeBase:   "0123456789abcdef0123456789abcdef01234567",
+			Notes:       "Repro: run the suite twice in a row.",
+		})
+	}
+	if err := cfg.Save(); err != nil {
+		b.Fatal(err)
+	}
+	return home
+}
+
+func BenchmarkLoad(b *testing.B) {
+	benchConfig(b, 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Load(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadSettings(b *testing.B) {
+	benchConfig(b, 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadSettings(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPromptPath measures what 'wt prompt' does on every shell
+// prompt: an index lookup, then the settings read to record usage. It
+// fails if that takes 5ms or more.
+func BenchmarkPromptPath(b *testing.B) {
+	home := benchConfig(b, 500)
+	dir := filepath.Join(home, "worktrees", "app", "task-250", "src")
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		idx, err := IndexPath()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, _, err := LookupIndex(idx, dir); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := LoadSettings(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if per := time.Since(start) / time.Duration(b.N); per >= 5*time.Millisecond {
+		b.Fatalf("prompt path takes %v, want under 5ms", per)
+	}
+}
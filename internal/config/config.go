@@ -1,9 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,40 +17,626 @@ import (
 const (
 	configDir  = ".wt"
 	configFile = "config.yaml"
+	// tasksFile keeps the tasks apart from the settings, so that commands
+	// that only need settings don't parse every task.
+	tasksFile = "tasks.yaml"
 )
 
 // Config represents the top-level configuration for wt.
 type Config struct {
-	WorktreesBase string                       `yaml:"worktrees_base"`
-	DefaultBranch string                       `yaml:"default_branch"`
-	BranchPrefix  string                       `yaml:"branch_prefix"`
-	DefaultAgent  string                       `yaml:"default_agent,omitempty"`
-	AgentAliases  map[string]string            `yaml:"agent_aliases,omitempty"`
-	Connectors    map[string]ConnectorConfig   `yaml:"connectors,omitempty"`
-	Tasks         []Task                       `yaml:"tasks,omitempty"`
+	WorktreesBase   string                     `yaml:"worktrees_base"`
+	DefaultBranch   string                     `yaml:"default_branch,omitempty"`
+	BranchPrefix    string                     `yaml:"branch_prefix"`
+	BranchTemplate  string                     `yaml:"branch_template,omitempty"`
+	BranchMaxLength int                        `yaml:"branch_max_length,omitempty"`
+	BranchStopwords bool                       `yaml:"branch_stopwords,omitempty"`
+	HotfixPrefix    string                     `yaml:"hotfix_prefix,omitempty"`
+	DefaultAgent    string                     `yaml:"default_agent,omitempty"`
+	TerminalTitle   bool                       `yaml:"terminal_title,omitempty"`
+	Concurrency     int                        `yaml:"git_concurrency,omitempty"`
+	GitBackend      string                     `yaml:"git_backend,omitempty"`
+	GitTimeout      time.Duration              `yaml:"git_timeout,omitempty"`
+	TicketRateLimit float64                    `yaml:"ticket_rate_limit,omitempty"`
+	TicketCacheTTL  time.Duration              `yaml:"ticket_cache_ttl,omitempty"`
+	RebaseThreshold int                        `yaml:"rebase_threshold,omitempty"`
+	StartCheck      string                     `yaml:"start_check,omitempty"`
+	WebCommand      string                     `yaml:"web_command,omitempty"`
+	WebPortBase     int                        `yaml:"web_port_base,omitempty"`
+	FinishChecks    []FinishCheck              `yaml:"finish_checks,omitempty"`
+	DeleteRemote    string                     `yaml:"delete_remote_branch,omitempty"`
+	SyncColumns     []string                   `yaml:"sync_columns,omitempty"`
+	SyncSort        string                     `yaml:"sync_sort,omitempty"`
+	Telemetry       bool                       `yaml:"telemetry,omitempty"`
+	TelemetryURL    string                     `yaml:"telemetry_url,omitempty"`
+	DefaultsURL     string                     `yaml:"defaults_url,omitempty"`
+	TokenPrices     map[string]TokenPrice      `yaml:"token_prices,omitempty"`
+	AgentAliases    map[string]string          `yaml:"agent_aliases,omitempty"`
+	AgentEnvAllow   []string                   `yaml:"agent_env_allow,omitempty"`
+	AgentSandbox    string                     `yaml:"agent_sandbox,omitempty"`
+	SandboxImage    string                     `yaml:"agent_sandbox_image,omitempty"`
+	SandboxPaths    []string                   `yaml:"agent_sandbox_paths,omitempty"`
+	AgentConfine    bool                       `yaml:"agent_confine,omitempty"`
+	Connectors      map[string]ConnectorConfig `yaml:"connectors,omitempty"`
+	Direnv          DirenvConfig               `yaml:"direnv,omitempty"`
+	BuildCache      BuildCacheConfig           `yaml:"build_cache,omitempty"`
+	GitConfig       map[string]string          `yaml:"git_config,omitempty"`
+	Repos           map[string]RepoConfig      `yaml:"repos,omitempty"`
+	Identities      map[string]Identity        `yaml:"identities,omitempty"`
+	SyncRules       []SyncRule                 `yaml:"sync_rules,omitempty"`
+	Team            TeamConfig                 `yaml:"team,omitempty"`
+	APITokens       []APIToken                 `yaml:"api_tokens,omitempty"`
+	Tasks           []Task                     `yaml:"tasks,omitempty"`
 
 	path string `yaml:"-"`
-	mu   sync.Mutex `yaml:"-"`
+	// settingsOnly marks a config read by LoadSettings, without its tasks.
+	settingsOnly bool       `yaml:"-"`
+	mu           sync.Mutex `yaml:"-"`
+	overrides    *overrides `yaml:"-"`
+	// orgDefaults holds the settings the org defaults of DefaultsURL set,
+	// and userKeys what the user's config file sets; Save leaves out the
+	// former unless the latter sets them.
+	orgDefaults map[string]any `yaml:"-"`
+	userKeys    map[string]any `yaml:"-"`
+}
+
+// BaseSettings are the settings that can be overridden for one invocation,
+// e.g. by wt's --worktrees-base flag. Empty fields are not overridden.
+type BaseSettings struct {
+	WorktreesBase string
+	BranchPrefix  string
+	DefaultBranch string
+}
+
+type overrides struct {
+	original, override BaseSettings
 }
 
 // ConnectorConfig stores settings for a task management connector.
 type ConnectorConfig struct {
-	URL      string `yaml:"url,omitempty"`
-	Email    string `yaml:"email,omitempty"`
-	APIToken string `yaml:"api_token,omitempty"`
-	Project  string `yaml:"project,omitempty"`
+	URL      string `yaml:"url,omitempty" json:"url,omitempty"`
+	Email    string `yaml:"email,omitempty" json:"email,omitempty"`
+	APIToken string `yaml:"api_token,omitempty" json:"api_token,omitempty"`
+	Project  string `yaml:"project,omitempty" json:"project,omitempty"`
+	// Organization is the Sentry organization slug.
+	Organization string `yaml:"organization,omitempty" json:"organization,omitempty"`
+	// APIVersion is the Jira REST API version: "3" for Cloud, "2" for Server/DC.
+	APIVersion string `yaml:"api_version,omitempty" json:"api_version,omitempty"`
+
+	// OAuth settings, for connectors that authenticate with OAuth 2.
+	ClientID     string    `yaml:"client_id,omitempty" json:"client_id,omitempty"`
+	ClientSecret string    `yaml:"client_secret,omitempty" json:"client_secret,omitempty"`
+	RedirectURI  string    `yaml:"redirect_uri,omitempty" json:"redirect_uri,omitempty"`
+	RefreshToken string    `yaml:"refresh_token,omitempty" json:"refresh_token,omitempty"`
+	TokenExpiry  time.Time `yaml:"token_expiry,omitempty" json:"token_expiry,omitzero"`
+
+	// Type selects a built-in connector implementation when it differs from
+	// the connector's name. "generic" describes a REST tracker entirely in config.
+	Type       string        `yaml:"type,omitempty" json:"type,omitempty"`
+	AuthHeader string        `yaml:"auth_header,omitempty" json:"auth_header,omitempty"`
+	Endpoints  RESTEndpoints `yaml:"endpoints,omitempty" json:"endpoints,omitzero"`
+	Fields     RESTFields    `yaml:"fields,omitempty" json:"fields,omitzero"`
+}
+
+// ConnectorGeneric is the connector type of REST trackers described in config.
+const ConnectorGeneric = "generic"
+
+// RESTEndpoints lists the HTTP endpoints of a generic connector. Paths are
+// relative to the connector URL; {key} and {status} are substituted.
+type RESTEndpoints struct {
+	Get        RESTEndpoint `yaml:"get,omitempty" json:"get,omitzero"`
+	List       RESTEndpoint `yaml:"list,omitempty" json:"list,omitzero"`
+	Transition RESTEndpoint `yaml:"transition,omitempty" json:"transition,omitzero"`
+	Validate   RESTEndpoint `yaml:"validate,omitempty" json:"validate,omitzero"`
+}
+
+// RESTEndpoint is a single HTTP request. In YAML it may be written as just
+// the path, in which case the method defaults to GET (POST for transitions).
+type RESTEndpoint struct {
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+	Path   string `yaml:"path,omitempty" json:"path,omitempty"`
+	Body   string `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// UnmarshalYAML accepts either a path string or a mapping.
+func (e *RESTEndpoint) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Path = value.Value
+		return nil
+	}
+	type plain RESTEndpoint
+	return value.Decode((*plain)(e))
+}
+
+// RESTFields maps ticket fields to dotted paths in the JSON responses of a
+// generic connector, e.g. "fields.status.name" or "assignees.0.name".
+type RESTFields struct {
+	// Items is the path of the ticket array in list responses; empty means
+	// the response itself is the array.
+	Items       string `yaml:"items,omitempty" json:"items,omitempty"`
+	Key         string `yaml:"key,omitempty" json:"key,omitempty"`
+	Summary     string `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Status      string `yaml:"status,omitempty" json:"status,omitempty"`
+	Assignee    string `yaml:"assignee,omitempty" json:"assignee,omitempty"`
+	URL         string `yaml:"url,omitempty" json:"url,omitempty"`
+	Labels      string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	ParentKey   string `yaml:"parent_key,omitempty" json:"parent_key,omitempty"`
+	EpicKey     string `yaml:"epic_key,omitempty" json:"epic_key,omitempty"`
+	EpicName    string `yaml:"epic_name,omitempty" json:"epic_name,omitempty"`
+	Priority    string `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"`
+	// Updated and Due are RFC 3339 timestamps or YYYY-MM-DD dates.
+	Updated string `yaml:"updated,omitempty" json:"updated,omitempty"`
+	Due     string `yaml:"due,omitempty" json:"due,omitempty"`
+}
+
+// DirenvConfig controls generation of .envrc files in new worktrees.
+type DirenvConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Template string `yaml:"template,omitempty"`
+}
+
+// RepoConfig holds settings for repositories, keyed in Config.Repos by a
+// repository's path (~ allowed) or directory name, or by a directory ending
+// in "/" that holds repositories or worktrees (e.g. part of worktrees_base).
+type RepoConfig struct {
+	// Identity names an entry of Config.Identities used for commits.
+	Identity string `yaml:"identity,omitempty"`
+	// GitConfig is set in each new worktree of the repository with
+	// 'git config --worktree', on top of Config.GitConfig.
+	GitConfig map[string]string `yaml:"git_config,omitempty"`
+	// TestCommand is the shell command 'wt test' runs in a worktree.
+	TestCommand string `yaml:"test_command,omitempty"`
+	// FinishChecks replaces Config.FinishChecks for the repository.
+	FinishChecks []FinishCheck `yaml:"finish_checks,omitempty"`
+	// CI names the CI provider, "github" or "gitlab", for remotes whose
+	// host does not tell (e.g. self-hosted GitLab).
+	CI string `yaml:"ci,omitempty"`
+	// Workspace names the provider new tasks of the repository are worked
+	// on in: "local" (the default), "devpod" or "codespace".
+	Workspace string `yaml:"workspace,omitempty"`
+}
+
+// Identity is the author of commits made in a worktree.
+type Identity struct {
+	Name       string `yaml:"name,omitempty"`
+	Email      string `yaml:"email,omitempty"`
+	SigningKey string `yaml:"signing_key,omitempty"`
+}
+
+// GitConfig returns the identity as git config values.
+func (id Identity) GitConfig() map[string]string {
+	values := make(map[string]string)
+	if id.Name != "" {
+		values["user.name"] = id.Name
+	}
+	if id.Email != "" {
+		values["user.email"] = id.Email
+	}
+	if id.SigningKey != "" {
+		values["user.signingkey"] = id.SigningKey
+	}
+	return values
+}
+
+// Repo returns the settings for the repository at repoPath whose worktree
+// is at worktreePath, merged from every key that matches it: directory
+// keys containing the repository or the worktree, the deepest last, then
+// the repository's directory name, then its path. Each setting of a more
+// specific key overrides the others'; git_config values are merged.
+func (c *Config) Repo(repoPath, worktreePath string) RepoConfig {
+	home, _ := os.UserHomeDir()
+	type match struct {
+		key   string
+		score int
+	}
+	var matches []match
+	for key := range c.Repos {
+		path := key
+		if rest, ok := strings.CutPrefix(key, "~/"); ok && home != "" {
+			path = filepath.Join(home, rest)
+		}
+		score := 0
+		switch {
+		case filepath.IsAbs(path) && !strings.HasSuffix(key, "/") && filepath.Clean(path) == filepath.Clean(repoPath):
+			score = 1<<30 + 1
+		case key == filepath.Base(repoPath):
+			score = 1 << 30
+		case filepath.IsAbs(path) && strings.HasSuffix(key, "/"):
+			dir := filepath.Clean(path) + string(filepath.Separator)
+			if strings.HasPrefix(filepath.Clean(repoPath)+string(filepath.Separator), dir) ||
+				(worktreePath != "" && strings.HasPrefix(filepath.Clean(worktreePath)+string(filepath.Separator), dir)) {
+				score = len(dir)
+			}
+		}
+		if score > 0 {
+			matches = append(matches, match{key, score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].key < matches[j].key
+	})
+
+	var merged RepoConfig
+	for _, m := range matches {
+		merged.merge(c.Repos[m.key])
+	}
+	return merged
+}
+
+// merge overrides the settings of rc with those o sets.
+func (rc *RepoConfig) merge(o RepoConfig) {
+	if o.Identity != "" {
+		rc.Identity = o.Identity
+	}
+	if len(o.GitConfig) > 0 {
+		values := make(map[string]string, len(rc.GitConfig)+len(o.GitConfig))
+		for k, v := range rc.GitConfig {
+			values[k] = v
+		}
+		for k, v := range o.GitConfig {
+			values[k] = v
+		}
+		rc.GitConfig = values
+	}
+	if o.TestCommand != "" {
+		rc.TestCommand = o.TestCommand
+	}
+	if len(o.FinishChecks) > 0 {
+		rc.FinishChecks = o.FinishChecks
+	}
+	if o.CI != "" {
+		rc.CI = o.CI
+	}
+	if o.Workspace != "" {
+		rc.Workspace = o.Workspace
+	}
+}
+
+// TestCommand returns the test_command configured for a repository.
+func (c *Config) TestCommand(repoPath, worktreePath string) string {
+	return c.Repo(repoPath, worktreePath).TestCommand
+}
+
+// WorktreeGitConfig returns the git config to set in a new worktree:
+// git_config, then the repository's identity, then the repository's own
+// git_config, each overriding the previous.
+func (c *Config) WorktreeGitConfig(repoPath, worktreePath string) (map[string]string, error) {
+	rc := c.Repo(repoPath, worktreePath)
+	values := make(map[string]string)
+	for k, v := range c.GitConfig {
+		values[k] = v
+	}
+	if rc.Identity != "" {
+		id, ok := c.Identities[rc.Identity]
+		if !ok {
+			return nil, fmt.Errorf("unknown identity %q for %s (want one of identities)", rc.Identity, repoPath)
+		}
+		for k, v := range id.GitConfig() {
+			values[k] = v
+		}
+	}
+	for k, v := range rc.GitConfig {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// DefaultHotfixPrefix is the branch prefix of tasks started from incidents
+// when hotfix_prefix is not set.
+const DefaultHotfixPrefix = "hotfix"
+
+// Values of start_check, which guards 'wt start' against branching from a
+// checkout with uncommitted changes or an unfinished rebase or merge.
+const (
+	StartCheckWarn  = "warn" // the default
+	StartCheckBlock = "block"
+	StartCheckOff   = "off"
+)
+
+// Values of delete_remote_branch, which says whether 'wt finish' deletes
+// the task's branch on origin too.
+const (
+	DeleteRemoteNever  = "never" // the default
+	DeleteRemoteMerged = "merged"
+	DeleteRemoteAlways = "always"
+)
+
+// Built-in finish checks.
+const (
+	CheckClean   = "clean"   // no uncommitted changes
+	CheckTests   = "tests"   // test_command passes, when there is one
+	CheckRebased = "rebased" // the branch contains the tip of its base branch
+)
+
+// DefaultFinishChecks are run by 'wt finish' when no finish_checks are
+// configured.
+var DefaultFinishChecks = []FinishCheck{{Name: CheckTests}}
+
+// FinishCheck is a check 'wt finish' runs before removing a worktree: a
+// built-in check, or a shell command run in the worktree. In YAML a
+// built-in may be written as just its name.
+type FinishCheck struct {
+	Name string `yaml:"name" json:"name"`
+	Run  string `yaml:"run,omitempty" json:"run,omitempty"`
+}
+
+// UnmarshalYAML accepts either a check name or a mapping.
+func (f *FinishCheck) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		f.Name = value.Value
+		return nil
+	}
+	type plain FinishCheck
+	return value.Decode((*plain)(f))
+}
+
+// Validate reports a check that is neither built in nor runs a command.
+func (f FinishCheck) Validate() error {
+	switch {
+	case f.Run != "":
+		if f.Name == "" {
+			return fmt.Errorf("finish check %q needs a name", f.Run)
+		}
+		return nil
+	case f.Name == CheckClean || f.Name == CheckTests || f.Name == CheckRebased:
+		return nil
+	}
+	return fmt.Errorf("unknown finish check %q (want clean, tests, rebased or a mapping with name and run)", f.Name)
+}
+
+// FinishChecksFor returns the checks 'wt finish' runs for a repository.
+func (c *Config) FinishChecksFor(repoPath, worktreePath string) []FinishCheck {
+	if rc := c.Repo(repoPath, worktreePath); len(rc.FinishChecks) > 0 {
+		return rc.FinishChecks
+	}
+	if len(c.FinishChecks) > 0 {
+		return c.FinishChecks
+	}
+	return DefaultFinishChecks
+}
+
+// Build cache modes for BuildCacheConfig.Vars.
+const (
+	CacheIsolated = "isolated"
+	CacheShared   = "shared"
+)
+
+// BuildCacheConfig maps build cache environment variables (GOCACHE,
+// CARGO_TARGET_DIR, ...) to a cache mode: isolated per task or shared
+// between all tasks of a repository.
+type BuildCacheConfig struct {
+	Dir  string            `yaml:"dir,omitempty"`
+	Vars map[string]string `yaml:"vars,omitempty"`
 }
 
+// Team state backends.
+const (
+	TeamGit  = "git"  // a branch of a git remote
+	TeamHTTP = "http" // a wt serve --team server or compatible API
+)
+
+// TeamConfig configures the shared state backend that lets a team see each
+// other's active tasks.
+type TeamConfig struct {
+	Backend string `yaml:"backend,omitempty"`
+	URL     string `yaml:"url,omitempty"`
+	// Branch is the branch holding state for the git backend (default wt-team).
+	Branch string `yaml:"branch,omitempty"`
+	// User names this user's namespace (default: the login name).
+	User  string `yaml:"user,omitempty"`
+	Token string `yaml:"token,omitempty"`
+}
+
+// API token scopes for 'wt serve'. Each scope also grants read access.
+const (
+	ScopeRead      = "read"      // list tasks, team state and metrics
+	ScopeTeam      = "team"      // also publish team state
+	ScopeLifecycle = "lifecycle" // also finish, remove, lock and unlock tasks
+)
+
+// APIToken grants a client of 'wt serve' a scope. Only a SHA-256 hash of
+// the token is stored.
+type APIToken struct {
+	Name    string    `yaml:"name"`
+	Hash    string    `yaml:"hash"`
+	Scope   string    `yaml:"scope"`
+	Created time.Time `yaml:"created"`
+}
+
+// Sync rule actions.
+const (
+	SyncFinish     = "finish"     // finish the local task
+	SyncRemove     = "remove"     // remove the worktree, keeping the branch
+	SyncTransition = "transition" // move the ticket to To
+	SyncSetStatus  = "status"     // set the local task status to To
+)
+
+// SyncRule reconciles a task with its ticket in 'wt sync --two-way'. A rule
+// matches when the ticket's status equals Remote or the task's status equals
+// Local (case-insensitively); exactly one of them should be set.
+type SyncRule struct {
+	Remote string `yaml:"remote,omitempty"`
+	Local  string `yaml:"local,omitempty"`
+	Action string `yaml:"action"`
+	To     string `yaml:"to,omitempty"`
+}
+
+// DefaultSyncRules are used when no sync_rules are configured.
+var DefaultSyncRules = []SyncRule{{Remote: "Done", Action: SyncFinish}}
+
 // Task represents an active worktree task.
 type Task struct {
-	ID          string    `yaml:"id"`
-	Description string    `yaml:"description"`
-	Worktree    string    `yaml:"worktree"`
-	Branch      string    `yaml:"branch"`
-	RepoPath    string    `yaml:"repo_path"`
-	Connector   string    `yaml:"connector,omitempty"`
-	TicketKey   string    `yaml:"ticket_key,omitempty"`
-	Created     time.Time `yaml:"created"`
+	ID          string    `yaml:"id" json:"id"`
+	Description string    `yaml:"description" json:"description"`
+	Worktree    string    `yaml:"worktree" json:"worktree"`
+	Branch      string    `yaml:"branch" json:"branch"`
+	RepoPath    string    `yaml:"repo_path" json:"repo_path"`
+	Connector   string    `yaml:"connector,omitempty" json:"connector,omitempty"`
+	TicketKey   string    `yaml:"ticket_key,omitempty" json:"ticket_key,omitempty"`
+	Created     time.Time `yaml:"created" json:"created"`
+	LastUsed    time.Time `yaml:"last_used,omitempty" json:"last_used,omitzero"`
+	Agent       string    `yaml:"agent,omitempty" json:"agent,omitempty"`
+	AgentPID    int       `yaml:"agent_pid,omitempty" json:"agent_pid,omitempty"`
+	State       string    `yaml:"state,omitempty" json:"state,omitempty"`
+	// AgentStarted tells the AgentPID process from a later one given the
+	// same PID; see agent.IsAlive.
+	AgentStarted string `yaml:"agent_started,omitempty" json:"agent_started,omitempty"`
+	// AgentSession is the ID of the agent's last session resumed with
+	// 'wt agent --resume'.
+	AgentSession string `yaml:"agent_session,omitempty" json:"agent_session,omitempty"`
+	// Parent is the ID of the task this one is a sub-task of.
+	Parent string `yaml:"parent,omitempty" json:"parent,omitempty"`
+	// Status is the task's local workflow status (e.g. "review"), set with
+	// 'wt status --set' or by sync rules. State, in contrast, tracks setup.
+	Status string `yaml:"status,omitempty" json:"status,omitempty"`
+	// Lock, when set, makes finish and remove refuse the task until it is
+	// unlocked or they are forced.
+	Lock *Lock `yaml:"lock,omitempty" json:"lock,omitempty"`
+	// Notes is free-form detail given when the task was started, e.g. the
+	// lines after the first of a description read from stdin.
+	Notes string `yaml:"notes,omitempty" json:"notes,omitempty"`
+	// Head and MergeBase are the branch's tip commit and its merge base
+	// with the base branch when wt last changed the task. They stay known
+	// after the worktree or branch is gone.
+	Head      string `yaml:"head,omitempty" json:"head,omitempty"`
+	MergeBase string `yaml:"merge_base,omitempty" json:"merge_base,omitempty"`
+	// Experiment is the ID of the 'wt experiment' run the task belongs to;
+	// its sibling tasks share it.
+	Experiment string `yaml:"experiment,omitempty" json:"experiment,omitempty"`
+	// Milestone is the release the task is planned for, set with
+	// 'wt milestone set'.
+	Milestone string `yaml:"milestone,omitempty" json:"milestone,omitempty"`
+	// Workspace is the provider the task is worked on in when it is not
+	// the local worktree, and WorkspaceID the provider's name for it once
+	// created.
+	Workspace   string `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	WorkspaceID string `yaml:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	// WebPort is the port allocated to the task's web editor by 'wt up
+	// --web', kept across restarts; WebPID is the editor's process while it
+	// runs.
+	WebPort int `yaml:"web_port,omitempty" json:"web_port,omitempty"`
+	WebPID  int `yaml:"web_pid,omitempty" json:"web_pid,omitempty"`
+	// Port is the port allocated to the task, from the same range as web
+	// editors, when its worktree was created with direnv integration on;
+	// it is exported as WT_PORT for the task's dev servers.
+	Port int `yaml:"port,omitempty" json:"port,omitempty"`
+	// Test is the outcome of the last 'wt test' run in the worktree.
+	Test *TestResult `yaml:"test,omitempty" json:"test,omitempty"`
+	// Usage is what agents run in the worktree used, as of 'wt stats'.
+	Usage *Usage `yaml:"usage,omitempty" json:"usage,omitempty"`
+}
+
+// TestResult is the outcome of running a repository's test_command.
+type TestResult struct {
+	Passed bool `yaml:"passed" json:"passed"`
+	// Commit is the worktree's HEAD when the tests ran; uncommitted
+	// changes were tested too.
+	Commit   string        `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Time     time.Time     `yaml:"time" json:"time"`
+	Duration time.Duration `yaml:"duration" json:"duration"`
+}
+
+func (r *TestResult) String() string {
+	if r == nil {
+		return "-"
+	}
+	if r.Passed {
+		return "passed"
+	}
+	return "failed"
+}
+
+// Usage counts the tokens agents used and what they cost.
+type Usage struct {
+	Sessions         int   `yaml:"sessions" json:"sessions"`
+	InputTokens      int64 `yaml:"input_tokens" json:"input_tokens"`
+	OutputTokens     int64 `yaml:"output_tokens" json:"output_tokens"`
+	CacheReadTokens  int64 `yaml:"cache_read_tokens,omitempty" json:"cache_read_tokens"`
+	CacheWriteTokens int64 `yaml:"cache_write_tokens,omitempty" json:"cache_write_tokens"`
+	// CostUSD is reported by the agent or priced with token_prices; it
+	// leaves out tokens of models with neither.
+	CostUSD float64 `yaml:"cost_usd,omitempty" json:"cost_usd"`
+}
+
+// Add adds o to u.
+func (u *Usage) Add(o Usage) {
+	u.Sessions += o.Sessions
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CacheReadTokens += o.CacheReadTokens
+	u.CacheWriteTokens += o.CacheWriteTokens
+	u.CostUSD += o.CostUSD
+}
+
+// Tokens returns the total number of tokens used.
+func (u Usage) Tokens() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// TokenPrice is what a model charges, in US dollars per million tokens.
+type TokenPrice struct {
+	Input      float64 `yaml:"input"`
+	Output     float64 `yaml:"output"`
+	CacheRead  float64 `yaml:"cache_read,omitempty"`
+	CacheWrite float64 `yaml:"cache_write,omitempty"`
+}
+
+// Cost returns what u costs at price p.
+func (p TokenPrice) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheReadTokens)*p.CacheRead +
+		float64(u.CacheWriteTokens)*p.CacheWrite) / 1e6
+}
+
+// TokenPrice returns the price configured for a model under the longest
+// key of token_prices that the model name starts with, so that
+// "claude-sonnet-4" covers every dated release of the model.
+func (c *Config) TokenPrice(model string) (TokenPrice, bool) {
+	var best string
+	found := false
+	for key := range c.TokenPrices {
+		if strings.HasPrefix(model, key) && (!found || len(key) > len(best)) {
+			best, found = key, true
+		}
+	}
+	return c.TokenPrices[best], found
+}
+
+// Lock records who locked a task and why.
+type Lock struct {
+	Owner  string    `yaml:"owner" json:"owner"`
+	Reason string    `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Time   time.Time `yaml:"time" json:"time"`
+}
+
+func (l *Lock) String() string {
+	s := fmt.Sprintf("%s since %s", l.Owner, l.Time.Format("2006-01-02 15:04"))
+	if l.Reason != "" {
+		s += " (" + l.Reason + ")"
+	}
+	return s
+}
+
+// Task states. A task with an empty state is ready to use. A branch-only
+// task has no worktree yet; it is created when the task is first used.
+const (
+	StatePreparing      = "preparing"
+	StateCheckoutFailed = "checkout-failed"
+	StateBranchOnly     = "branch-only"
+)
+
+// LastActive returns when the task was last visited, falling back to its
+// creation time.
+func (t *Task) LastActive() time.Time {
+	if t.LastUsed.IsZero() {
+		return t.Created
+	}
+	return t.LastUsed
 }
 
 // DefaultConfig returns a config with sensible defaults.
@@ -54,7 +644,6 @@ func DefaultConfig() *Config {
 	home, _ := os.UserHomeDir()
 	return &Config{
 		WorktreesBase: filepath.Join(home, "worktrees"),
-		DefaultBranch: "main",
 		BranchPrefix:  "feature",
 		DefaultAgent:  "",
 		AgentAliases:  make(map[string]string),
@@ -63,8 +652,25 @@ func DefaultConfig() *Config {
 	}
 }
 
+// simulationDir holds the tasks and state of a simulation; see Simulate.
+var simulationDir string
+
+// Simulate keeps the tasks and state of this process apart from the real
+// ones, in dir: ConfigDir returns dir, and Load reads the settings from the
+// real config file but the tasks from the one in dir, which Save writes.
+func Simulate(dir string) {
+	simulationDir = dir
+}
+
 // ConfigDir returns the path to the wt config directory.
 func ConfigDir() (string, error) {
+	if simulationDir != "" {
+		return simulationDir, nil
+	}
+	return homeConfigDir()
+}
+
+func homeConfigDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("cannot determine home directory: %w", err)
@@ -74,12 +680,99 @@ func ConfigDir() (string, error) {
 
 // Load reads the config from disk, or returns defaults if none exists.
 func Load() (*Config, error) {
+	cfg, err := LoadSettings()
+	if err != nil {
+		return nil, err
+	}
 	dir, err := ConfigDir()
 	if err != nil {
 		return nil, err
 	}
+	cfg.settingsOnly = false
+	cfg.path = filepath.Join(dir, configFile)
+	data, err := os.ReadFile(filepath.Join(dir, tasksFile))
+	if os.IsNotExist(err) {
+		// Tasks used to be kept in config.yaml; the next Save moves them.
+		if simulationDir != "" {
+			sim, err := loadFile(cfg.path)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Tasks = sim.Tasks
+		}
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	var tasks taskList
+	if err := yaml.Unmarshal(data, &tasks); err != nil {
+		return nil, fmt.Errorf("failed to parse tasks: %w", err)
+	}
+	cfg.Tasks = tasks.Tasks
+	if cfg.Tasks == nil {
+		cfg.Tasks = []Task{}
+	}
+	return cfg, nil
+}
 
-	path := filepath.Join(dir, configFile)
+// Stamp identifies the state of the files Load reads: it changes when
+// any of them is written, so long-running processes can tell when to
+// reload.
+func Stamp() (string, error) {
+	home, err := homeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, path := range []string{filepath.Join(home, configFile), filepath.Join(dir, configFile), filepath.Join(dir, tasksFile)} {
+		stamp, err := fileStamp(path)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(stamp + ";")
+	}
+	return b.String(), nil
+}
+
+// fileStamp identifies the state of a file by its modification time and
+// size, "-" if it doesn't exist.
+func fileStamp(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "-", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check config: %w", err)
+	}
+	return fmt.Sprintf("%d:%d", fi.ModTime().UnixNano(), fi.Size()), nil
+}
+
+// taskList is the content of the tasks file.
+type taskList struct {
+	Tasks []Task `yaml:"tasks"`
+}
+
+// LoadSettings reads the settings from disk, without the tasks, for hot
+// paths that don't need them. The config it returns cannot be saved.
+func LoadSettings() (*Config, error) {
+	dir, err := homeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadFile(filepath.Join(dir, configFile))
+	if err != nil {
+		return nil, err
+	}
+	cfg.settingsOnly = true
+	return cfg, nil
+}
+
+func loadFile(path string) (*Config, error) {
 	cfg := DefaultConfig()
 	cfg.path = path
 
@@ -91,9 +784,26 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
+	// Org defaults go beneath the settings of the file.
+	var head struct {
+		DefaultsURL string `yaml:"defaults_url"`
+	}
+	if err := yaml.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if head.DefaultsURL != "" {
+		if userData, err := cfg.applyDefaults(head.DefaultsURL, data); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			data = userData
+		}
+	}
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	if err := cfg.expandPaths(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
 	if cfg.Connectors == nil {
 		cfg.Connectors = make(map[string]ConnectorConfig)
 	}
@@ -106,46 +816,226 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// Save writes the config to disk.
+// Save writes the config to disk: the settings to config.yaml and the
+// tasks to tasks.yaml next to it. It overwrites what other processes
+// saved since c was loaded; UpdateTask and the other methods changing c
+// keep their changes.
 func (c *Config) Save() error {
+	if c.settingsOnly {
+		return errors.New("cannot save a config loaded without its tasks")
+	}
+	if err := c.setPath(); err != nil {
+		return err
+	}
+	unlock, err := lock(filepath.Dir(c.path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return c.save()
+}
+
+// setPath sets the path c is saved to if it was not loaded from one.
+func (c *Config) setPath() error {
+	if c.path != "" {
+		return nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	c.path = filepath.Join(dir, configFile)
+	return nil
+}
+
+// save is Save with the config lock held.
+func (c *Config) save() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.path == "" {
-		dir, err := ConfigDir()
-		if err != nil {
-			return err
-		}
-		c.path = filepath.Join(dir, configFile)
+	if err := c.setPath(); err != nil {
+		return err
 	}
-
 	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	tasks, err := yaml.Marshal(taskList{Tasks: c.Tasks})
+	if err != nil {
+		return fmt.Errorf("failed to marshal tasks: %w", err)
+	}
+	data, err := c.settingsData()
+	if err != nil {
+		return err
+	}
+
+	// Tasks go first: a config.yaml without tasks is only written once
+	// they are safe in tasks.yaml.
+	if err := writeFile(filepath.Join(filepath.Dir(c.path), tasksFile), tasks); err != nil {
+		return err
+	}
+	if err := writeFile(c.path, data); err != nil {
+		return err
+	}
+	if err := c.writeIndex(); err != nil {
+		return fmt.Errorf("failed to write worktree index: %w", err)
+	}
+	return nil
+}
+
+// settingsData returns the content of config.yaml for c: its settings,
+// without the tasks, the overrides or the org defaults.
+func (c *Config) settingsData() ([]byte, error) {
+	restore := c.unoverride()
+	saved := c.Tasks
+	c.Tasks = nil
 	data, err := yaml.Marshal(c)
+	c.Tasks = saved
+	restore()
+	if err == nil {
+		data, err = c.stripDefaults(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// updateSettings changes the settings with update and persists them. As
+// with UpdateTask, the settings file is read again under the config lock
+// and update is applied to what it holds, so the changes other processes
+// saved since c was loaded are kept; update is then applied to c too.
+// Only config.yaml is written, not the tasks.
+func (c *Config) updateSettings(update func(c *Config) error) error {
+	if c.settingsOnly {
+		return errors.New("cannot save a config loaded without its tasks")
+	}
+	if err := c.setPath(); err != nil {
+		return err
+	}
+	unlock, err := lock(filepath.Dir(c.path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tasks, err := c.tasksPath()
+	if err != nil {
+		return err
+	}
+	split, err := fileStamp(tasks)
+	if err != nil {
+		return err
+	}
+	if simulationDir != "" || split == "-" {
+		// A simulation keeps the settings read from the real config
+		// file, and tasks not split from config.yaml yet are saved with
+		// it: change c and save it all.
+		if err := update(c); err != nil {
+			return err
+		}
+		return c.save()
+	}
+
+	current, err := loadFile(c.path)
+	if err != nil {
+		return err
+	}
+	if err := update(current); err != nil {
+		return err
+	}
+	data, err := current.settingsData()
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
 	}
+	if err := writeFile(c.path, data); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = update(c)
+	return nil
+}
 
-	return os.WriteFile(c.path, data, 0o644)
+// Override replaces base settings with the non-empty fields of s until c
+// is discarded. Save keeps writing the values read from the config file,
+// unless a setting was changed again since.
+func (c *Config) Override(s BaseSettings) {
+	if c.overrides == nil {
+		c.overrides = &overrides{original: BaseSettings{
+			WorktreesBase: c.WorktreesBase,
+			BranchPrefix:  c.BranchPrefix,
+			DefaultBranch: c.DefaultBranch,
+		}}
+	}
+	values := []string{s.WorktreesBase, s.BranchPrefix, s.DefaultBranch}
+	for i, f := range c.overrideFields() {
+		if values[i] != "" {
+			*f.field, *f.override = values[i], values[i]
+		}
+	}
+}
+
+type overrideField struct {
+	field, original, override *string
 }
 
-// AddTask adds a task and persists the config.
+func (c *Config) overrideFields() []overrideField {
+	o := c.overrides
+	return []overrideField{
+		{&c.WorktreesBase, &o.original.WorktreesBase, &o.override.WorktreesBase},
+		{&c.BranchPrefix, &o.original.BranchPrefix, &o.override.BranchPrefix},
+		{&c.DefaultBranch, &o.original.DefaultBranch, &o.override.DefaultBranch},
+	}
+}
+
+// unoverride puts the values read from the config file back in place of
+// the overrides still in effect, and returns a function that undoes it.
+func (c *Config) unoverride() func() {
+	if c.overrides == nil {
+		return func() {}
+	}
+	var undo []func()
+	for _, f := range c.overrideFields() {
+		if *f.override == "" || *f.field != *f.override {
+			continue
+		}
+		field, value := f.field, *f.field
+		*field = *f.original
+		undo = append(undo, func() { *field = value })
+	}
+	return func() {
+		for _, u := range undo {
+			u()
+		}
+	}
+}
+
+// AddTask adds a task and persists it, keeping the tasks other processes
+// saved since c was loaded, as UpdateTask does. If saving fails, the task
+// is not added.
 func (c *Config) AddTask(t Task) error {
-	c.Tasks = append(c.Tasks, t)
-	return c.Save()
+	return c.updateTaskList(func(tasks []Task) ([]Task, error) {
+		return append(slices.Clone(tasks), t), nil
+	})
 }
 
-// RemoveTask removes a task by ID and persists the config.
+// ErrTaskNotFound is returned when no task matches an ID, worktree or ticket.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrTaskLocked is returned when a task is locked by a person or a
+// running agent.
+var ErrTaskLocked = errors.New("task is locked")
+
+// RemoveTask removes a task by ID and persists it, as AddTask does.
 func (c *Config) RemoveTask(id string) error {
-	for i, t := range c.Tasks {
-		if t.ID == id {
-			c.Tasks = append(c.Tasks[:i], c.Tasks[i+1:]...)
-			return c.Save()
+	return c.updateTaskList(func(tasks []Task) ([]Task, error) {
+		i := slices.IndexFunc(tasks, func(t Task) bool { return t.ID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrTaskNotFound, id)
 		}
-	}
-	return fmt.Errorf("task %q not found", id)
+		return slices.Delete(slices.Clone(tasks), i, i+1), nil
+	})
 }
 
 // FindTask finds a task by ID.
@@ -155,21 +1045,329 @@ func (c *Config) FindTask(id string) (*Task, error) {
 			return &c.Tasks[i], nil
 		}
 	}
-	return nil, fmt.Errorf("task %q not found", id)
+	return nil, fmt.Errorf("%w: %q", ErrTaskNotFound, id)
 }
 
-// FindTaskByWorktree finds a task whose worktree path matches the given directory.
+// FindTaskByWorktree finds the task whose worktree is dir or contains it,
+// so it also matches from deep inside the project tree. When no worktree
+// matches the paths as given, symlinks are resolved on both sides and the
+// match is retried. Of nested worktrees, the innermost one wins.
 func (c *Config) FindTaskByWorktree(dir string) (*Task, error) {
+	for _, norm := range []func(string) string{absPath, resolvedPath} {
+		if t := c.taskContaining(norm(dir), norm); t != nil {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for worktree %q", ErrTaskNotFound, dir)
+}
+
+// taskContaining returns the task with the innermost worktree containing
+// dir, comparing worktree paths normalized by norm.
+func (c *Config) taskContaining(dir string, norm func(string) string) *Task {
+	if dir == "" {
+		return nil
+	}
+	var found *Task
+	longest := 0
+	for i := range c.Tasks {
+		wt := norm(c.Tasks[i].Worktree)
+		if wt == "" || len(wt) <= longest {
+			continue
+		}
+		if dir == wt || strings.HasPrefix(dir, wt+string(filepath.Separator)) {
+			found, longest = &c.Tasks[i], len(wt)
+		}
+	}
+	return found
+}
+
+// absPath returns the cleaned absolute path, or "" if it can't be found.
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	return abs
+}
+
+// resolvedPath returns the absolute path with symlinks resolved, or "" if
+// the path doesn't exist.
+func resolvedPath(path string) string {
+	resolved, err := filepath.EvalSymlinks(absPath(path))
+	if err != nil {
+		return ""
+	}
+	return resolved
+}
+
+// FindTaskByTicket finds the task started from a connector's ticket. Keys
+// match in any case, as trackers treat proj-123 and PROJ-123 alike.
+func (c *Config) FindTaskByTicket(connector, key string) (*Task, error) {
 	for i := range c.Tasks {
-		if c.Tasks[i].Worktree == dir {
+		if c.Tasks[i].Connector == connector && strings.EqualFold(c.Tasks[i].TicketKey, key) {
 			return &c.Tasks[i], nil
 		}
 	}
-	return nil, fmt.Errorf("no task found for worktree %q", dir)
+	return nil, fmt.Errorf("%w for %s ticket %s", ErrTaskNotFound, connector, key)
+}
+
+// ExperimentTasks returns the tasks of an experiment in the order they were
+// started.
+func (c *Config) ExperimentTasks(experiment string) []Task {
+	var tasks []Task
+	for _, t := range c.Tasks {
+		if experiment != "" && t.Experiment == experiment {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Created.Before(tasks[j].Created) })
+	return tasks
+}
+
+// SetTaskStatus sets a task's local workflow status and persists the config.
+func (c *Config) SetTaskStatus(id, status string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Status = status
+		return nil
+	})
+}
+
+// SetTaskMilestone assigns tasks to a milestone ("" for none) and persists
+// the config. No task is changed if any of them is not found.
+func (c *Config) SetTaskMilestone(ids []string, milestone string) error {
+	return c.updateTasks(ids, func(t *Task) error {
+		t.Milestone = milestone
+		return nil
+	})
+}
+
+// SetTestResult records the outcome of a task's tests and persists the config.
+func (c *Config) SetTestResult(id string, result TestResult) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Test = &result
+		return nil
+	})
+}
+
+// SetUsage records the agent usage of a task and persists the config.
+func (c *Config) SetUsage(id string, u Usage) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Usage = &u
+		return nil
+	})
+}
+
+// SetTaskParent records that a task is a sub-task of parent and persists the config.
+func (c *Config) SetTaskParent(id, parent string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Parent = parent
+		return nil
+	})
+}
+
+// SetTaskWorktree records a task's new worktree path and persists the config.
+func (c *Config) SetTaskWorktree(id, path string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Worktree = path
+		return nil
+	})
+}
+
+// SetTaskCommits records the tip of a task's branch and its merge base
+// with the base branch and persists the config.
+func (c *Config) SetTaskCommits(id, head, mergeBase string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Head, t.MergeBase = head, mergeBase
+		return nil
+	})
+}
+
+// TouchTask records that a task was just visited and persists the config.
+func (c *Config) TouchTask(id string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.LastUsed = time.Now()
+		return nil
+	})
+}
+
+// RecordAgent stores the agent launched for a task, its PID and when the
+// process started, and persists the config.
+func (c *Config) RecordAgent(id, agent string, pid int, started string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Agent = agent
+		t.AgentPID, t.AgentStarted = pid, started
+		t.LastUsed = time.Now()
+		return nil
+	})
+}
+
+// ClearAgent forgets the agent process of a task once it has exited,
+// unless another agent was recorded since, and persists the config.
+func (c *Config) ClearAgent(id string, pid int) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		if t.AgentPID == pid {
+			t.AgentPID, t.AgentStarted = 0, ""
+		}
+		return nil
+	})
+}
+
+// SetTaskWorkspace records the ID of a task's workspace and persists the
+// config.
+func (c *Config) SetTaskWorkspace(id, workspaceID string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.WorkspaceID = workspaceID
+		return nil
+	})
+}
+
+// SetTaskWeb records the port and process of a task's web editor, with pid
+// 0 once it stopped, and persists the config.
+func (c *Config) SetTaskWeb(id string, port, pid int) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.WebPort, t.WebPID = port, pid
+		return nil
+	})
+}
+
+// SetTaskPort records the port allocated to a task and persists the
+// config.
+func (c *Config) SetTaskPort(id string, port int) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Port = port
+		return nil
+	})
+}
+
+// SetAgentSession records the agent session of a task and persists the
+// config.
+func (c *Config) SetAgentSession(id, session string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.AgentSession = session
+		return nil
+	})
+}
+
+// LockTask locks a task for owner and persists the config. Locking a task
+// already locked by someone else fails with ErrTaskLocked.
+func (c *Config) LockTask(id, owner, reason string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		if t.Lock != nil && t.Lock.Owner != owner {
+			return fmt.Errorf("%w: %s is locked by %s", ErrTaskLocked, t.ID, t.Lock)
+		}
+		t.Lock = &Lock{Owner: owner, Reason: reason, Time: time.Now()}
+		return nil
+	})
+}
+
+// UnlockTask removes a task's lock and persists the config.
+func (c *Config) UnlockTask(id string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.Lock = nil
+		return nil
+	})
+}
+
+// SetTaskState updates a task's state and persists the config.
+func (c *Config) SetTaskState(id, state string) error {
+	return c.UpdateTask(id, func(t *Task) error {
+		t.State = state
+		return nil
+	})
+}
+
+// RecentTasks returns a copy of the tasks ordered by most recently used first.
+func (c *Config) RecentTasks() []Task {
+	tasks := make([]Task, len(c.Tasks))
+	copy(tasks, c.Tasks)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].LastActive().After(tasks[j].LastActive())
+	})
+	return tasks
+}
+
+// TaskTree orders tasks so that sub-tasks follow their parent, returning
+// each task's nesting depth. Tasks whose parent is not in the list are
+// roots; the relative order of siblings is preserved.
+func TaskTree(tasks []Task) ([]Task, []int) {
+	present := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		present[t.ID] = true
+	}
+	children := make(map[string][]Task)
+	var roots []Task
+	for _, t := range tasks {
+		if t.Parent != "" && t.Parent != t.ID && present[t.Parent] {
+			children[t.Parent] = append(children[t.Parent], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+
+	ordered := make([]Task, 0, len(tasks))
+	depths := make([]int, 0, len(tasks))
+	visited := make(map[string]bool, len(tasks))
+	var walk func(t Task, depth int)
+	walk = func(t Task, depth int) {
+		if visited[t.ID] {
+			return
+		}
+		visited[t.ID] = true
+		ordered = append(ordered, t)
+		depths = append(depths, depth)
+		for _, child := range children[t.ID] {
+			walk(child, depth+1)
+		}
+	}
+	for _, t := range roots {
+		walk(t, 0)
+	}
+	// Parent cycles have no root; list them flat rather than dropping them.
+	for _, t := range tasks {
+		walk(t, 0)
+	}
+	return ordered, depths
+}
+
+// AddAPIToken stores a token, replacing any token with the same name, and
+// persists it, keeping the settings other processes saved meanwhile.
+func (c *Config) AddAPIToken(tok APIToken) error {
+	return c.updateSettings(func(c *Config) error {
+		c.deleteAPIToken(tok.Name)
+		c.APITokens = append(c.APITokens, tok)
+		return nil
+	})
+}
+
+// RemoveAPIToken deletes a token by name and persists it, as AddAPIToken
+// does.
+func (c *Config) RemoveAPIToken(name string) error {
+	return c.updateSettings(func(c *Config) error {
+		if !c.deleteAPIToken(name) {
+			return fmt.Errorf("no API token named %q", name)
+		}
+		return nil
+	})
+}
+
+func (c *Config) deleteAPIToken(name string) bool {
+	for i, tok := range c.APITokens {
+		if tok.Name == name {
+			c.APITokens = append(c.APITokens[:i], c.APITokens[i+1:]...)
+			return true
+		}
+	}
+	return false
 }
 
-// SetConnector stores connector configuration.
+// SetConnector stores connector configuration, keeping the settings other
+// processes saved meanwhile.
 func (c *Config) SetConnector(name string, cc ConnectorConfig) error {
-	c.Connectors[name] = cc
-	return c.Save()
+	return c.updateSettings(func(c *Config) error {
+		c.Connectors[name] = cc
+		return nil
+	})
 }
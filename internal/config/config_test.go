@@ -1,15 +1,25 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
-	if cfg.DefaultBranch != "main" {
-		t.Errorf("expected default branch 'main', got %q", cfg.DefaultBranch)
+	if cfg.DefaultBranch != "" {
+		t.Errorf("expected default branch to be detected, got %q", cfg.DefaultBranch)
 	}
 	if cfg.BranchPrefix != "feature" {
 		t.Errorf("expected branch prefix 'feature', got %q", cfg.BranchPrefix)
@@ -90,6 +100,30 @@ func TestAddAndRemoveTask(t *testing.T) {
 	}
 }
 
+func TestLockTask(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.path = filepath.Join(t.TempDir(), "config.yaml")
+	if err := cfg.AddTask(Task{ID: "wt-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.LockTask("wt-1", "ann@laptop", "demo"); err != nil {
+		t.Fatalf("LockTask failed: %v", err)
+	}
+	if err := cfg.LockTask("wt-1", "ann@laptop", "still demo"); err != nil {
+		t.Errorf("relocking by the owner failed: %v", err)
+	}
+	if err := cfg.LockTask("wt-1", "bob@server", ""); !errors.Is(err, ErrTaskLocked) {
+		t.Errorf("locking another owner's task: got %v, want ErrTaskLocked", err)
+	}
+	if err := cfg.UnlockTask("wt-1"); err != nil {
+		t.Fatalf("UnlockTask failed: %v", err)
+	}
+	if cfg.Tasks[0].Lock != nil {
+		t.Error("task still locked after UnlockTask")
+	}
+}
+
 func TestFindTaskNotFound(t *testing.T) {
 	cfg := DefaultConfig()
 	_, err := cfg.FindTask("nonexistent")
@@ -97,3 +131,525 @@ func TestFindTaskNotFound(t *testing.T) {
 		t.Error("expected error for nonexistent task")
 	}
 }
+
+func TestRecentTasks(t *testing.T) {
+	now := time.Now()
+	cfg := DefaultConfig()
+	cfg.path = filepath.Join(t.TempDir(), "config.yaml")
+	cfg.Tasks = []Task{
+		{ID: "old", Created: now.Add(-3 * time.Hour)},
+		{ID: "used", Created: now.Add(-4 * time.Hour), LastUsed: now.Add(-time.Hour)},
+		{ID: "new", Created: now.Add(-2 * time.Hour)},
+	}
+
+	assertOrder := func(want ...string) {
+		t.Helper()
+		got := cfg.RecentTasks()
+		for i, id := range want {
+			if got[i].ID != id {
+				t.Fatalf("position %d: expected %q, got %q", i, id, got[i].ID)
+			}
+		}
+	}
+	assertOrder("used", "new", "old")
+
+	if err := cfg.TouchTask("old"); err != nil {
+		t.Fatalf("TouchTask failed: %v", err)
+	}
+	assertOrder("old", "used", "new")
+}
+
+func TestTaskTree(t *testing.T) {
+	tasks := []Task{
+		{ID: "child-b", Parent: "root"},
+		{ID: "root"},
+		{ID: "orphan", Parent: "gone"},
+		{ID: "grandchild", Parent: "child-a"},
+		{ID: "child-a", Parent: "root"},
+		{ID: "cycle-1", Parent: "cycle-2"},
+		{ID: "cycle-2", Parent: "cycle-1"},
+	}
+	ordered, depths := TaskTree(tasks)
+
+	want := []struct {
+		id    string
+		depth int
+	}{
+		{"root", 0},
+		{"child-b", 1},
+		{"child-a", 1},
+		{"grandchild", 2},
+		{"orphan", 0},
+		{"cycle-1", 0},
+		{"cycle-2", 1},
+	}
+	if len(ordered) != len(want) {
+		t.Fatalf("expected %d tasks, got %d", len(want), len(ordered))
+	}
+	for i, w := range want {
+		if ordered[i].ID != w.id || depths[i] != w.depth {
+			t.Errorf("position %d: expected %s at depth %d, got %s at depth %d", i, w.id, w.depth, ordered[i].ID, depths[i])
+		}
+	}
+}
+
+func TestLookupIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.path = filepath.Join(tmpDir, "config.yaml")
+	cfg.Tasks = []Task{
+		{ID: "wt-1", Worktree: "/w/repo/one", Branch: "feature/one", TicketKey: "PROJ-1"},
+		{ID: "wt-2", Worktree: "/w/repo/one-more", Branch: "feature/one-more"},
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	idx := filepath.Join(tmpDir, indexFile)
+	tests := []struct {
+		dir string
+		id  string
+	}{
+		{"/w/repo/one", "wt-1"},
+		{"/w/repo/one/src/pkg", "wt-1"},
+		{"/w/repo/one-more", "wt-2"},
+		{"/w/repo", ""},
+	}
+	for _, tt := range tests {
+		e, ok, err := LookupIndex(idx, tt.dir)
+		if err != nil {
+			t.Fatalf("LookupIndex(%q) failed: %v", tt.dir, err)
+		}
+		if tt.id == "" {
+			if ok {
+				t.Errorf("LookupIndex(%q) = %q, want no match", tt.dir, e.ID)
+			}
+			continue
+		}
+		if !ok || e.ID != tt.id {
+			t.Errorf("LookupIndex(%q) = %v, want %q", tt.dir, e, tt.id)
+		}
+	}
+}
+
+func TestLookupIndexSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	real := filepath.Join(tmpDir, "worktrees")
+	link := filepath.Join(tmpDir, "link")
+	if err := os.MkdirAll(filepath.Join(real, "one", "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(real, link); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	cfg := DefaultConfig()
+	cfg.path = filepath.Join(tmpDir, "config.yaml")
+	cfg.Tasks = []Task{{ID: "wt-1", Worktree: filepath.Join(real, "one"), Branch: "feature/one"}}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	dir := filepath.Join(link, "one", "src")
+	e, ok, err := LookupIndex(filepath.Join(tmpDir, indexFile), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || e.ID != "wt-1" {
+		t.Errorf("LookupIndex(%q) = %v, want wt-1", dir, e)
+	}
+}
+
+func TestWorktreeGitConfig(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	cfg := &Config{
+		GitConfig: map[string]string{"commit.gpgsign": "true", "user.email": "me@example.com"},
+		Identities: map[string]Identity{
+			"work": {Name: "Ann", Email: "ann@work.example"},
+			"oss":  {Name: "ann", Email: "ann@oss.example", SigningKey: "~/.ssh/oss.pub"},
+		},
+		Repos: map[string]RepoConfig{
+			"app":                {GitConfig: map[string]string{"core.hooksPath": ".githooks"}},
+			"~/work/app":         {Identity: "work"},
+			"/src/oss/":          {Identity: "oss"},
+			"/src/oss/legacy":    {GitConfig: map[string]string{"commit.gpgsign": "false"}},
+			"/trees/client/":     {Identity: "work"},
+			"/trees/client/big/": {Identity: "oss"},
+			"/src/typo":          {Identity: "missing"},
+		},
+	}
+	tests := []struct {
+		repo, worktree string
+		want           map[string]string
+	}{
+		{filepath.Join(home, "work", "app"), "", map[string]string{"commit.gpgsign": "true", "user.name": "Ann", "user.email": "ann@work.example", "core.hooksPath": ".githooks"}},
+		{"/elsewhere/app", "", map[string]string{"commit.gpgsign": "true", "user.email": "me@example.com", "core.hooksPath": ".githooks"}},
+		{"/src/oss/lib", "", map[string]string{"commit.gpgsign": "true", "user.name": "ann", "user.email": "ann@oss.example", "user.signingkey": "~/.ssh/oss.pub"}},
+		{"/src/oss/legacy", "", map[string]string{"commit.gpgsign": "false", "user.name": "ann", "user.email": "ann@oss.example", "user.signingkey": "~/.ssh/oss.pub"}},
+		{"/src/other", "/trees/client/other/x", map[string]string{"commit.gpgsign": "true", "user.name": "Ann", "user.email": "ann@work.example"}},
+		{"/src/other", "/trees/client/big/x", map[string]string{"commit.gpgsign": "true", "user.name": "ann", "user.email": "ann@oss.example", "user.signingkey": "~/.ssh/oss.pub"}},
+		{"/src/ossify", "", map[string]string{"commit.gpgsign": "true", "user.email": "me@example.com"}},
+	}
+	for _, tt := range tests {
+		got, err := cfg.WorktreeGitConfig(tt.repo, tt.worktree)
+		if err != nil {
+			t.Errorf("WorktreeGitConfig(%q) failed: %v", tt.repo, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("WorktreeGitConfig(%q, %q) = %v, want %v", tt.repo, tt.worktree, got, tt.want)
+			continue
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("WorktreeGitConfig(%q, %q)[%q] = %q, want %q", tt.repo, tt.worktree, k, got[k], v)
+			}
+		}
+	}
+	if _, err := cfg.WorktreeGitConfig("/src/typo", ""); err == nil {
+		t.Error("unknown identity: expected an error")
+	}
+}
+
+func TestFinishChecks(t *testing.T) {
+	var cfg Config
+	data := `
+finish_checks:
+  - clean
+  - name: lint
+    run: make lint
+repos:
+  scratch:
+    finish_checks: [rebased]
+`
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []FinishCheck{{Name: CheckClean}, {Name: "lint", Run: "make lint"}}
+	if got := cfg.FinishChecksFor("/src/app", ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("FinishChecksFor(app) = %+v, want %+v", got, want)
+	}
+	if got := cfg.FinishChecksFor("/src/scratch", ""); !reflect.DeepEqual(got, []FinishCheck{{Name: CheckRebased}}) {
+		t.Errorf("FinishChecksFor(scratch) = %+v", got)
+	}
+	if got := (&Config{}).FinishChecksFor("/src/app", ""); !reflect.DeepEqual(got, DefaultFinishChecks) {
+		t.Errorf("FinishChecksFor() without config = %+v, want the defaults", got)
+	}
+
+	for _, check := range []FinishCheck{{Name: "bogus"}, {Run: "make lint"}} {
+		if err := check.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected an error", check)
+		}
+	}
+}
+
+func TestFindTaskByWorktree(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "worktrees", "repo")
+	for _, dir := range []string{"login", "login/nested", "login/src/pkg", "signup"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(repo, link); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Tasks: []Task{
+		{ID: "wt-login", Worktree: filepath.Join(repo, "login")},
+		{ID: "wt-nested", Worktree: filepath.Join(repo, "login", "nested")},
+		{ID: "wt-signup", Worktree: filepath.Join(link, "signup")},
+	}}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{filepath.Join(repo, "login"), "wt-login"},
+		{filepath.Join(repo, "login", "src", "pkg"), "wt-login"},
+		{filepath.Join(repo, "login", "nested"), "wt-nested"},
+		{filepath.Join(link, "login", "src"), "wt-login"},
+		{filepath.Join(repo, "signup"), "wt-signup"},
+		{filepath.Join(repo, "login-other"), ""},
+		{root, ""},
+	}
+	for _, tt := range tests {
+		got, err := cfg.FindTaskByWorktree(tt.dir)
+		if tt.want == "" {
+			if !errors.Is(err, ErrTaskNotFound) {
+				t.Errorf("FindTaskByWorktree(%q) = %v, want ErrTaskNotFound", tt.dir, got)
+			}
+			continue
+		}
+		if err != nil || got.ID != tt.want {
+			t.Errorf("FindTaskByWorktree(%q) = %v, %v, want %s", tt.dir, got, err, tt.want)
+		}
+	}
+}
+
+func TestTokenPrice(t *testing.T) {
+	cfg := &Config{TokenPrices: map[string]TokenPrice{
+		"claude":          {Input: 1, Output: 1},
+		"claude-sonnet-4": {Input: 3, Output: 15, CacheRead: 0.3},
+	}}
+	tests := []struct {
+		model string
+		want  float64
+		ok    bool
+	}{
+		{"claude-sonnet-4-5-20250929", 3, true},
+		{"claude-haiku-4-5", 1, true},
+		{"gpt-5", 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := cfg.TokenPrice(tt.model)
+		if ok != tt.ok || p.Input != tt.want {
+			t.Errorf("TokenPrice(%q) = %+v, %v, want input %v, %v", tt.model, p, ok, tt.want, tt.ok)
+		}
+	}
+
+	p := cfg.TokenPrices["claude-sonnet-4"]
+	if got := p.Cost(Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 2_000_000}); got != 3+1.5+0.6 {
+		t.Errorf("Cost() = %v, want 5.1", got)
+	}
+}
+
+func TestAbsPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("WT_TEST_TREES", "/srv/trees")
+	os.Unsetenv("WT_TEST_UNSET")
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "~", want: home},
+		{path: "~/trees", want: filepath.Join(home, "trees")},
+		{path: "$HOME/trees/", want: filepath.Join(home, "trees")},
+		{path: "${WT_TEST_TREES}/wt", want: "/srv/trees/wt"},
+		{path: "/abs/../trees", want: "/trees"},
+		{path: "trees", want: "/work/trees"},
+		{path: "$WT_TEST_UNSET/trees", wantErr: true},
+		{path: "~bob/trees", wantErr: true},
+		{path: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := AbsPath(tt.path, "/work")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("AbsPath(%q) = %q, want an error", tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AbsPath(%q): %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("AbsPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverrideIsNotSaved(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.path = filepath.Join(t.TempDir(), "config.yaml")
+	cfg.WorktreesBase = "/srv/worktrees"
+	cfg.BranchPrefix = "feature"
+
+	cfg.Override(BaseSettings{WorktreesBase: "/tmp/trees", DefaultBranch: "develop"})
+	if cfg.WorktreesBase != "/tmp/trees" || cfg.DefaultBranch != "develop" || cfg.BranchPrefix != "feature" {
+		t.Fatalf("after override got base %q, branch %q, prefix %q", cfg.WorktreesBase, cfg.DefaultBranch, cfg.BranchPrefix)
+	}
+	cfg.DefaultBranch = "main" // changed again, e.g. by 'wt config'
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if cfg.WorktreesBase != "/tmp/trees" {
+		t.Errorf("Save dropped the override: base %q", cfg.WorktreesBase)
+	}
+
+	data, err := os.ReadFile(cfg.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.WorktreesBase != "/srv/worktrees" {
+		t.Errorf("saved worktrees_base = %q, want the configured /srv/worktrees", saved.WorktreesBase)
+	}
+	if saved.DefaultBranch != "main" {
+		t.Errorf("saved default_branch = %q, want main as set after the override", saved.DefaultBranch)
+	}
+}
+
+func TestTasksFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir := filepath.Join(home, configDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Tasks used to be kept in config.yaml.
+	legacy := "branch_prefix: fix\ntasks:\n  - id: wt-1\n    worktree: /tmp/wt-1\n"
+	if err := os.WriteFile(filepath.Join(dir, configFile), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Tasks) != 1 || cfg.Tasks[0].ID != "wt-1" {
+		t.Fatalf("legacy tasks = %+v, want wt-1", cfg.Tasks)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, configFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Tasks) != 0 || saved.BranchPrefix != "fix" {
+		t.Errorf("config.yaml has tasks %+v and prefix %q, want no tasks and fix", saved.Tasks, saved.BranchPrefix)
+	}
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Tasks) != 1 || cfg.Tasks[0].ID != "wt-1" {
+		t.Errorf("tasks after save = %+v, want wt-1", cfg.Tasks)
+	}
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings.Tasks) != 0 || settings.BranchPrefix != "fix" {
+		t.Errorf("LoadSettings got tasks %+v and prefix %q, want no tasks and fix", settings.Tasks, settings.BranchPrefix)
+	}
+	if err := settings.Save(); err == nil {
+		t.Error("saving a config loaded by LoadSettings succeeded")
+	}
+}
+
+func TestOrgDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	status := http.StatusOK
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, "worktrees_base: ~/code\nbranch_prefix: platform\nconnectors:\n  jira:\n    url: https://jira.example.com\n"+
+			// Ignored: not the org's to set.
+			"api_tokens:\n  - name: org\n    hash: abc\nteam:\n  url: https://evil.example.com\ntelemetry_url: https://evil.example.com\n"+
+			"git_config:\n  user.email: dev@acme.com\n  core.sshCommand: evil\n"+
+			"repos:\n  app:\n    test_command: make test\n    git_config:\n      core.fsmonitor: evil\n")
+	}))
+	defer srv.Close()
+	defaultsClient = srv.Client()
+	t.Cleanup(func() { defaultsClient = http.DefaultClient })
+	if err := RefreshDefaults(context.Background(), "http://"+strings.TrimPrefix(srv.URL, "https://")); err == nil {
+		t.Error("RefreshDefaults fetched from a plain http URL")
+	}
+
+	dir := filepath.Join(home, configDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// worktrees_base as written by Save before defaults_url was set.
+	user := "worktrees_base: " + filepath.Join(home, "worktrees") + "\ndefaults_url: " + srv.URL + "\nbranch_prefix: mine\nconnectors:\n  sentry:\n    organization: acme\n"
+	if err := os.WriteFile(filepath.Join(dir, configFile), []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !DefaultsDue(srv.URL) {
+		t.Fatal("defaults never fetched should be due")
+	}
+	if err := RefreshDefaults(context.Background(), srv.URL); err != nil {
+		t.Fatalf("RefreshDefaults: %v", err)
+	}
+	if DefaultsDue(srv.URL) {
+		t.Error("defaults just fetched should not be due")
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.WorktreesBase != filepath.Join(home, "code") {
+		t.Errorf("worktrees_base = %q, want the org default", cfg.WorktreesBase)
+	}
+	if cfg.BranchPrefix != "mine" {
+		t.Errorf("branch_prefix = %q, want the user's mine over the org default", cfg.BranchPrefix)
+	}
+	if cfg.Connectors["jira"].URL != "https://jira.example.com" || cfg.Connectors["sentry"].Organization != "acme" {
+		t.Errorf("connectors = %+v, want jira from the org defaults and sentry from the user", cfg.Connectors)
+	}
+	if len(cfg.APITokens) > 0 || cfg.Team.URL != "" || cfg.TelemetryURL != "" {
+		t.Errorf("org defaults set api_tokens %v, team %+v or telemetry_url %q", cfg.APITokens, cfg.Team, cfg.TelemetryURL)
+	}
+	if want := map[string]string{"user.email": "dev@acme.com"}; !reflect.DeepEqual(cfg.GitConfig, want) {
+		t.Errorf("git_config = %v, want only %v", cfg.GitConfig, want)
+	}
+	if rc := cfg.Repos["app"]; rc.TestCommand != "make test" || len(rc.GitConfig) > 0 {
+		t.Errorf("repos.app = %+v, want its test_command without git_config", rc)
+	}
+
+	// Saving keeps the org defaults out of the user's file.
+	cfg.DefaultAgent = "claude"
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, configFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]any
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved["worktrees_base"]; ok {
+		t.Errorf("org default worktrees_base was saved:\n%s", data)
+	}
+	connectors, _ := saved["connectors"].(map[string]any)
+	if _, ok := connectors["jira"]; ok {
+		t.Errorf("org default jira connector was saved:\n%s", data)
+	}
+	if saved["branch_prefix"] != "mine" || saved["default_agent"] != "claude" || connectors["sentry"] == nil {
+		t.Errorf("user settings missing from the saved file:\n%s", data)
+	}
+
+	// A failed fetch keeps the cached defaults and waits before retrying.
+	status = http.StatusInternalServerError
+	path, _ := defaultsCachePath(srv.URL)
+	old := time.Now().Add(-2 * defaultsRefresh)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := RefreshDefaults(context.Background(), srv.URL); err == nil {
+		t.Error("expected an error from a failing server")
+	}
+	if DefaultsDue(srv.URL) {
+		t.Error("a failed fetch should postpone the next one")
+	}
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.WorktreesBase != filepath.Join(home, "code") {
+		t.Errorf("worktrees_base = %q after a failed fetch, want the cached org default", cfg.WorktreesBase)
+	}
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultsRefresh is how often the org defaults of defaults_url are
+	// fetched again.
+	defaultsRefresh = 24 * time.Hour
+	// defaultsRetry is how long to wait before fetching again after a
+	// failed fetch.
+	defaultsRetry = time.Hour
+	// maxDefaultsSize bounds the org defaults file.
+	maxDefaultsSize = 1 << 20
+)
+
+// defaultsClient fetches org defaults; tests replace it to trust their
+// server.
+var defaultsClient = http.DefaultClient
+
+// personalKeys are settings org defaults can't set: they hold the user's
+// tasks and credentials, or say where wt sends data.
+var personalKeys = []string{"tasks", "defaults_url", "api_tokens", "team", "telemetry_url"}
+
+// defaultsCachePath is where the org defaults fetched from url are kept.
+func defaultsCachePath(url string) (string, error) {
+	dir, err := homeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, "cache", "defaults-"+hex.EncodeToString(sum[:6])+".yaml"), nil
+}
+
+// DefaultsDue reports whether the org defaults of url should be fetched:
+// they never were, or the last fetch is older than a day.
+func DefaultsDue(url string) bool {
+	path, err := defaultsCachePath(url)
+	if err != nil {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err != nil || time.Since(fi.ModTime()) >= defaultsRefresh
+}
+
+// RefreshDefaults fetches the org defaults of url and caches them for the
+// next load. The defaults must parse as a config. After a failure, the
+// cached defaults are kept and the fetch is retried an hour later.
+func RefreshDefaults(ctx context.Context, url string) (err error) {
+	if !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("refusing to fetch org defaults from %s: defaults_url must be an https URL", url)
+	}
+	path, err := defaultsCachePath(url)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			postponeRefresh(path)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch org defaults: %w", err)
+	}
+	resp, err := defaultsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch org defaults: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch org defaults: %s returned %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDefaultsSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to fetch org defaults: %w", err)
+	}
+	if len(data) > maxDefaultsSize {
+		return fmt.Errorf("org defaults at %s exceed %d bytes", url, maxDefaultsSize)
+	}
+	if err := yaml.Unmarshal(data, DefaultConfig()); err != nil {
+		return fmt.Errorf("invalid org defaults at %s: %w", url, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to cache org defaults: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// PostponeDefaults makes DefaultsDue false for url for defaultsRetry, as
+// after a failed fetch, so that commands run while a fetch is under way
+// don't start another one.
+func PostponeDefaults(url string) error {
+	path, err := defaultsCachePath(url)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	postponeRefresh(path)
+	return nil
+}
+
+// postponeRefresh makes DefaultsDue false for defaultsRetry, keeping what
+// is cached.
+func postponeRefresh(path string) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			return
+		}
+	}
+	t := time.Now().Add(defaultsRetry - defaultsRefresh)
+	_ = os.Chtimes(path, t, t)
+}
+
+// applyDefaults layers the cached org defaults of url onto c, before the
+// user's config file, given as userData, is applied on top. Settings the
+// file leaves at wt's built-in default, as Save writes them, don't hide
+// the org's; applyDefaults returns the file without them. It records the
+// values the defaults set so that Save leaves them out of the file.
+func (c *Config) applyDefaults(url string, userData []byte) ([]byte, error) {
+	path, err := defaultsCachePath(url)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) || len(bytes.TrimSpace(data)) == 0 {
+		return userData, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read org defaults: %w", err)
+	}
+	if data, err = dropPersonal(data); err != nil {
+		return nil, fmt.Errorf("failed to parse org defaults from %s: %w", url, err)
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse org defaults from %s: %w", url, err)
+	}
+	if len(raw) == 0 {
+		return userData, nil
+	}
+
+	org := DefaultConfig()
+	if err := yaml.Unmarshal(data, org); err != nil {
+		return nil, fmt.Errorf("failed to parse org defaults from %s: %w", url, err)
+	}
+	full, err := savedValues(org)
+	if err != nil {
+		return nil, fmt.Errorf("invalid org defaults from %s: %w", url, err)
+	}
+	builtin, err := savedValues(DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(userData, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		filterMapping(doc.Content[0], func(key string, value *yaml.Node) bool {
+			var v any
+			_, inOrg := raw[key]
+			return !inOrg || value.Decode(&v) != nil || !reflect.DeepEqual(v, builtin[key])
+		})
+		if userData, err = yaml.Marshal(&doc); err != nil {
+			return nil, err
+		}
+	}
+	var user map[string]any
+	if err := yaml.Unmarshal(userData, &user); err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse org defaults from %s: %w", url, err)
+	}
+	c.orgDefaults, _ = restrict(full, raw).(map[string]any)
+	c.userKeys = user
+	return userData, nil
+}
+
+// dropPersonal removes from org defaults the personalKeys, and the
+// git_config values other than user.*: others, like core.sshCommand or
+// core.fsmonitor, run commands in every new worktree.
+func dropPersonal(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+	root := doc.Content[0]
+	filterMapping(root, func(key string, value *yaml.Node) bool {
+		switch key {
+		case "git_config":
+			filterMapping(value, func(k string, _ *yaml.Node) bool { return strings.HasPrefix(k, "user.") })
+		case "repos":
+			filterMapping(value, func(_ string, repo *yaml.Node) bool {
+				filterMapping(repo, func(k string, v *yaml.Node) bool {
+					if k == "git_config" {
+						filterMapping(v, func(k string, _ *yaml.Node) bool { return strings.HasPrefix(k, "user.") })
+					}
+					return true
+				})
+				return true
+			})
+		}
+		return !slices.Contains(personalKeys, key)
+	})
+	return yaml.Marshal(&doc)
+}
+
+// filterMapping keeps the pairs of a mapping node keep returns true for.
+func filterMapping(node *yaml.Node, keep func(key string, value *yaml.Node) bool) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	var kept []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if keep(node.Content[i].Value, node.Content[i+1]) {
+			kept = append(kept, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = kept
+}
+
+// savedValues returns the settings of c as Save would write them, paths
+// expanded.
+func savedValues(c *Config) (map[string]any, error) {
+	if err := c.expandPaths(); err != nil {
+		return nil, err
+	}
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := yaml.Unmarshal(out, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// restrict keeps the parts of full that raw sets, recursing into mappings.
+func restrict(full, raw any) any {
+	rawMap, ok := raw.(map[string]any)
+	fullMap, ok2 := full.(map[string]any)
+	if !ok || !ok2 {
+		return full
+	}
+	out := make(map[string]any, len(rawMap))
+	for k, v := range rawMap {
+		if f, ok := fullMap[k]; ok {
+			out[k] = restrict(f, v)
+		}
+	}
+	return out
+}
+
+// stripDefaults removes from the marshaled config data the settings that
+// still have the value of the org defaults and that the user's file
+// doesn't set, so that changes to the org defaults keep applying.
+func (c *Config) stripDefaults(data []byte) ([]byte, error) {
+	if len(c.orgDefaults) == 0 {
+		return data, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	stripNode(doc.Content[0], c.orgDefaults, c.userKeys)
+	return yaml.Marshal(&doc)
+}
+
+func stripNode(node *yaml.Node, org, user map[string]any) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	var kept []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		orgValue, inOrg := org[key.Value]
+		_, inUser := user[key.Value]
+		if inOrg && !inUser {
+			var v any
+			if err := value.Decode(&v); err == nil && reflect.DeepEqual(v, orgValue) {
+				continue
+			}
+		}
+		if orgMap, ok := orgValue.(map[string]any); ok {
+			userMap, _ := user[key.Value].(map[string]any)
+			stripNode(value, orgMap, userMap)
+			if len(value.Content) == 0 && !inUser {
+				continue
+			}
+		}
+		kept = append(kept, key, value)
+	}
+	node.Content = kept
+}
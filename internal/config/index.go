@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const indexFile = "worktrees.idx"
+
+// IndexEntry is one line of the worktree index, a small tab-separated file
+// rewritten on every Save so that hot paths like `wt prompt` can map a
+// directory to a task without parsing the full YAML config.
+type IndexEntry struct {
+	Worktree  string `json:"worktree"`
+	ID        string `json:"id"`
+	TicketKey string `json:"ticket_key,omitempty"`
+	Branch    string `json:"branch"`
+}
+
+// IndexPath returns the path of the worktree index.
+func IndexPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, indexFile), nil
+}
+
+// writeIndex must be called with c.mu held.
+func (c *Config) writeIndex() error {
+	var b strings.Builder
+	for _, t := range c.Tasks {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\n", t.Worktree, t.ID, t.TicketKey, t.Branch)
+	}
+	return os.WriteFile(filepath.Join(filepath.Dir(c.path), indexFile), []byte(b.String()), 0o644)
+}
+
+// LookupIndex finds the index entry whose worktree contains dir, the
+// innermost one if worktrees are nested. Like FindTaskByWorktree, it falls
+// back to comparing paths with symlinks resolved.
+// It returns os.ErrNotExist if the index has not been written yet.
+func LookupIndex(path, dir string) (*IndexEntry, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	var entries []IndexEntry
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		entries = append(entries, IndexEntry{Worktree: fields[0], ID: fields[1], TicketKey: fields[2], Branch: fields[3]})
+	}
+	for _, norm := range []func(string) string{absPath, resolvedPath} {
+		if e := entryContaining(entries, norm(dir), norm); e != nil {
+			return e, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// entryContaining is taskContaining for index entries.
+func entryContaining(entries []IndexEntry, dir string, norm func(string) string) *IndexEntry {
+	if dir == "" {
+		return nil
+	}
+	var found *IndexEntry
+	longest := 0
+	for i := range entries {
+		wt := norm(entries[i].Worktree)
+		if wt == "" || len(wt) <= longest {
+			continue
+		}
+		if dir == wt || strings.HasPrefix(dir, wt+string(filepath.Separator)) {
+			found, longest = &entries[i], len(wt)
+		}
+	}
+	return found
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockFile is taken, next to config.yaml, by the wt process writing the
+// config, the tasks or the queue.
+const lockFile = "config.lock"
+
+const (
+	// lockStale is the age at which a lock file is taken to be left over
+	// by a process that died while holding it.
+	lockStale = 10 * time.Second
+	// lockWait bounds how long to wait for another process's lock.
+	lockWait = 15 * time.Second
+)
+
+// lock takes the exclusive lock on the config files in dir and returns a
+// function that releases it. It waits while another wt process holds it.
+func lock(dir string) (func(), error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	path := filepath.Join(dir, lockFile)
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock config: %w", err)
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock config: %s is held by another wt process", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeFile writes data to a temporary file next to path and renames it
+// over path, so that readers never see a partly written file.
+func writeFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandPath expands a leading ~ in p to the home directory, and $VAR or
+// ${VAR} to the value of the environment variable. A variable that is not
+// set is an error rather than a hole in the path.
+func ExpandPath(p string) (string, error) {
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		p = home + p[1:]
+	} else if strings.HasPrefix(p, "~") {
+		return "", fmt.Errorf("%s: only ~ for your own home directory is supported", p)
+	}
+	if !strings.Contains(p, "$") {
+		return p, nil
+	}
+	var unset []string
+	p = os.Expand(p, func(name string) string {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return v
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(unset, ", "))
+	}
+	return p, nil
+}
+
+// AbsPath expands p like ExpandPath and makes it absolute, relative to dir.
+func AbsPath(p, dir string) (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("path is empty")
+	}
+	p, err := ExpandPath(p)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Clean(p), nil
+}
+
+// expandPaths expands the path-like settings read from the config file.
+// Relative paths are taken relative to the home directory, as the shell
+// that 'wt config' was run from is long gone. Agent aliases that are bare
+// command names are left to be looked up in PATH.
+func (c *Config) expandPaths() error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	if c.WorktreesBase != "" {
+		if c.WorktreesBase, err = AbsPath(c.WorktreesBase, home); err != nil {
+			return fmt.Errorf("worktrees_base: %w", err)
+		}
+	}
+	for i, p := range c.SandboxPaths {
+		if c.SandboxPaths[i], err = AbsPath(p, home); err != nil {
+			return fmt.Errorf("agent_sandbox_paths: %w", err)
+		}
+	}
+	if c.BuildCache.Dir != "" {
+		if c.BuildCache.Dir, err = AbsPath(c.BuildCache.Dir, home); err != nil {
+			return fmt.Errorf("build_cache.dir: %w", err)
+		}
+	}
+	if c.Direnv.Template != "" {
+		if c.Direnv.Template, err = AbsPath(c.Direnv.Template, home); err != nil {
+			return fmt.Errorf("direnv.template: %w", err)
+		}
+	}
+	for name, p := range c.AgentAliases {
+		if !strings.HasPrefix(p, "~") && !strings.Contains(p, "$") {
+			continue
+		}
+		if c.AgentAliases[name], err = ExpandPath(p); err != nil {
+			return fmt.Errorf("agent_aliases.%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// queueFile holds the work planned with 'wt queue', next to config.yaml.
+const queueFile = "queue.yaml"
+
+// QueueItem is a task planned with 'wt queue add', started later by
+// 'wt queue next'.
+type QueueItem struct {
+	ID          string `yaml:"id" json:"id"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	RepoPath    string `yaml:"repo_path" json:"repo_path"`
+	Connector   string `yaml:"connector,omitempty" json:"connector,omitempty"`
+	TicketKey   string `yaml:"ticket_key,omitempty" json:"ticket_key,omitempty"`
+	// Priority is a priority name such as "high" or "P1"; more urgent
+	// items are started first, then older ones.
+	Priority string `yaml:"priority,omitempty" json:"priority,omitempty"`
+	// Agent is launched in the task when it is started.
+	Agent string    `yaml:"agent,omitempty" json:"agent,omitempty"`
+	Added time.Time `yaml:"added" json:"added"`
+}
+
+type queueList struct {
+	Queue []QueueItem `yaml:"queue"`
+}
+
+// LoadQueue reads the queued items, in the order they were added.
+func LoadQueue() ([]QueueItem, error) {
+	path, err := queuePath()
+	if err != nil {
+		return nil, err
+	}
+	return readQueue(path)
+}
+
+// UpdateQueue changes the queue and persists it: update gets the queued
+// items and returns them as they should be saved. If update returns an
+// error, nothing changes. As with UpdateTask, the items are read again
+// under the config lock and, when another process saves the queue
+// meanwhile nonetheless, the update starts over.
+func UpdateQueue(update func(items []QueueItem) ([]QueueItem, error)) error {
+	path, err := queuePath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lock(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for attempt := 1; ; attempt++ {
+		before, err := fileStamp(path)
+		if err != nil {
+			return err
+		}
+		items, err := readQueue(path)
+		if err != nil {
+			return err
+		}
+		items, err = update(items)
+		if err != nil {
+			return err
+		}
+		if after, err := fileStamp(path); err != nil || after != before {
+			if attempt < updateAttempts {
+				continue
+			}
+			return fmt.Errorf("failed to save queue: %w", errConcurrentUpdate)
+		}
+		data, err := yaml.Marshal(queueList{Queue: items})
+		if err != nil {
+			return fmt.Errorf("failed to marshal queue: %w", err)
+		}
+		return writeFile(path, data)
+	}
+}
+
+func queuePath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, queueFile), nil
+}
+
+func readQueue(path string) ([]QueueItem, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue: %w", err)
+	}
+	var list queueList
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse queue: %w", err)
+	}
+	return list.Queue, nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// updateAttempts bounds how often UpdateTask starts over because a
+// process that doesn't take the config lock saved the tasks while it was
+// updating them.
+const updateAttempts = 5
+
+// errConcurrentUpdate is returned when the tasks or the queue kept
+// changing while updating them.
+var errConcurrentUpdate = errors.New("changed by another process while updating; try again")
+
+// UpdateTask changes the task with the given ID and persists it. Other wt
+// processes may have saved tasks since c was loaded, so the tasks are read
+// again and update is applied to the current record: their changes are
+// kept, on disk and in c. If update returns an error, nothing changes.
+//
+// Only the tasks are written, not the settings, under the config lock;
+// when the tasks file changes while updating nonetheless, the update
+// starts over.
+func (c *Config) UpdateTask(id string, update func(t *Task) error) error {
+	return c.updateTasks([]string{id}, update)
+}
+
+// updateTasks is UpdateTask for several tasks: it changes all of them or
+// none.
+func (c *Config) updateTasks(ids []string, update func(t *Task) error) error {
+	return c.updateTaskList(func(tasks []Task) ([]Task, error) {
+		return applyUpdate(tasks, ids, update)
+	})
+}
+
+// updateTaskList is UpdateTask for the list of tasks: change gets the
+// current tasks and returns them as they should be saved, without
+// modifying its argument.
+func (c *Config) updateTaskList(change func(tasks []Task) ([]Task, error)) error {
+	if c.settingsOnly {
+		return errors.New("cannot save a config loaded without its tasks")
+	}
+	path, err := c.tasksPath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lock(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for attempt := 1; ; attempt++ {
+		before, err := fileStamp(path)
+		if err != nil {
+			return err
+		}
+		if before == "-" {
+			// The tasks are not split from config.yaml yet: update them in
+			// c and let save do that.
+			tasks, err := change(c.Tasks)
+			if err != nil {
+				return err
+			}
+			old := slices.Clone(c.Tasks)
+			c.mergeTasks(tasks)
+			if err := c.save(); err != nil {
+				c.mergeTasks(old)
+				return err
+			}
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read tasks: %w", err)
+		}
+		var current taskList
+		if err := yaml.Unmarshal(data, &current); err != nil {
+			return fmt.Errorf("failed to parse tasks: %w", err)
+		}
+		tasks, err := change(current.Tasks)
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		if after, err := fileStamp(path); err != nil || after != before {
+			c.mu.Unlock()
+			if attempt < updateAttempts {
+				continue
+			}
+			return fmt.Errorf("failed to save tasks: %w", errConcurrentUpdate)
+		}
+		err = c.writeTasks(path, tasks)
+		c.mu.Unlock()
+		return err
+	}
+}
+
+// writeTasks saves tasks to the tasks file and makes them c's tasks. It
+// must be called with c.mu held.
+func (c *Config) writeTasks(path string, tasks []Task) error {
+	data, err := yaml.Marshal(taskList{Tasks: tasks})
+	if err != nil {
+		return fmt.Errorf("failed to marshal tasks: %w", err)
+	}
+	if err := writeFile(path, data); err != nil {
+		return err
+	}
+	c.mergeTasks(tasks)
+	if err := c.writeIndex(); err != nil {
+		return fmt.Errorf("failed to write worktree index: %w", err)
+	}
+	return nil
+}
+
+// tasksPath returns the path of the tasks file next to the config file.
+func (c *Config) tasksPath() (string, error) {
+	if c.path != "" {
+		return filepath.Join(filepath.Dir(c.path), tasksFile), nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, tasksFile), nil
+}
+
+// applyUpdate applies update to the tasks with the given IDs in a copy of
+// tasks, all or none.
+func applyUpdate(tasks []Task, ids []string, update func(t *Task) error) ([]Task, error) {
+	out := slices.Clone(tasks)
+	for _, id := range ids {
+		i := slices.IndexFunc(out, func(t Task) bool { return t.ID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrTaskNotFound, id)
+		}
+		if err := update(&out[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// mergeTasks makes tasks c's tasks. When they are the same tasks in the
+// same order, the records are overwritten in place, so pointers returned
+// by FindTask stay valid and see the update.
+func (c *Config) mergeTasks(tasks []Task) {
+	same := len(tasks) == len(c.Tasks)
+	for i := 0; same && i < len(tasks); i++ {
+		same = tasks[i].ID == c.Tasks[i].ID
+	}
+	if same {
+		copy(c.Tasks, tasks)
+		return
+	}
+	c.Tasks = tasks
+}
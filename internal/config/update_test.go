@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUpdateTask(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Tasks = []Task{{ID: "wt-1"}, {ID: "wt-2"}}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two processes loaded the config; each changes a different task.
+	first, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.SetTaskStatus("wt-1", "review"); err != nil {
+		t.Fatal(err)
+	}
+	task2, _ := second.FindTask("wt-2")
+	if err := second.SetTaskStatus("wt-2", "done"); err != nil {
+		t.Fatal(err)
+	}
+	if task2.Status != "done" {
+		t.Errorf("FindTask pointer sees status %q after the update, want done", task2.Status)
+	}
+	if t1, _ := second.FindTask("wt-1"); t1.Status != "review" {
+		t.Errorf("second config has wt-1 status %q, want the other process's review", t1.Status)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]string{"wt-1": "review", "wt-2": "done"} {
+		if task, _ := loaded.FindTask(id); task.Status != want {
+			t.Errorf("saved %s status = %q, want %q", id, task.Status, want)
+		}
+	}
+
+	refuse := errors.New("refused")
+	if err := loaded.UpdateTask("wt-1", func(t *Task) error {
+		t.Status = "changed"
+		return refuse
+	}); !errors.Is(err, refuse) {
+		t.Errorf("UpdateTask() = %v, want the update's error", err)
+	}
+	if task, _ := loaded.FindTask("wt-1"); task.Status != "review" {
+		t.Errorf("refused update changed status to %q", task.Status)
+	}
+	if err := loaded.SetTaskMilestone([]string{"wt-1", "wt-9"}, "v2"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("SetTaskMilestone() with a missing task = %v, want ErrTaskNotFound", err)
+	}
+	if task, _ := loaded.FindTask("wt-1"); task.Milestone != "" {
+		t.Errorf("failed SetTaskMilestone() set milestone %q", task.Milestone)
+	}
+}
+
+func TestConcurrentAddAndSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AddTask(Task{ID: "wt-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two processes loaded the config; each adds a task and a setting.
+	first, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.AddTask(Task{ID: "wt-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.AddTask(Task{ID: "wt-3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.RemoveTask("wt-2"); err != nil {
+		t.Errorf("RemoveTask() of the other process's task = %v", err)
+	}
+	if err := first.SetConnector("jira", ConnectorConfig{URL: "https://acme.atlassian.net"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.AddAPIToken(APIToken{Name: "ci"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.RemoveAPIToken("other"); err == nil {
+		t.Error("RemoveAPIToken() of a missing token succeeded")
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := taskIDs(loaded.Tasks)
+	if !slices.Equal(ids, []string{"wt-1", "wt-3"}) {
+		t.Errorf("saved tasks = %v, want [wt-1 wt-3]", ids)
+	}
+	if !slices.Equal(ids, taskIDs(second.Tasks)) {
+		t.Errorf("second config has tasks %v, want the saved %v", taskIDs(second.Tasks), ids)
+	}
+	if loaded.Connectors["jira"].URL == "" {
+		t.Error("adding an API token dropped the other process's connector")
+	}
+	if len(loaded.APITokens) != 1 {
+		t.Errorf("saved %d API tokens, want 1", len(loaded.APITokens))
+	}
+	if _, err := os.Stat(filepath.Join(home, configDir, lockFile)); !os.IsNotExist(err) {
+		t.Errorf("config lock left behind: %v", err)
+	}
+}
+
+func taskIDs(tasks []Task) []string {
+	var ids []string
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	unlock, err := lock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken := make(chan struct{})
+	go func() {
+		unlock, err := lock(dir)
+		if err != nil {
+			t.Error(err)
+		} else {
+			unlock()
+		}
+		close(taken)
+	}()
+	select {
+	case <-taken:
+		t.Fatal("lock was taken twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-taken
+
+	// A lock left by a process that died is taken over.
+	path := filepath.Join(dir, lockFile)
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	os.Chtimes(path, old, old)
+	unlock, err = lock(dir)
+	if err != nil {
+		t.Fatalf("lock() with a stale lock file = %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,96 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attachment is a file attached to a ticket.
+type Attachment struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	URL      string `json:"url"`
+}
+
+// AttachmentDownloader is implemented by connectors that can download the
+// attachments listed in Ticket.Attachments.
+type AttachmentDownloader interface {
+	// DownloadAttachment writes the content of an attachment to w.
+	DownloadAttachment(ctx context.Context, a Attachment, w io.Writer) error
+}
+
+// SavedAttachment is the outcome of saving one attachment.
+type SavedAttachment struct {
+	Attachment Attachment
+	Path       string
+	// Skipped is set when an identical-size file was already present.
+	Skipped bool
+	Err     error
+}
+
+// SaveAttachments downloads attachments into dir. Files already present
+// with the expected size are skipped; names that collide get the
+// attachment ID as a prefix.
+func SaveAttachments(ctx context.Context, d AttachmentDownloader, attachments []Attachment, dir string) []SavedAttachment {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		err = fmt.Errorf("failed to create attachment directory: %w", err)
+		results := make([]SavedAttachment, len(attachments))
+		for i, a := range attachments {
+			results[i] = SavedAttachment{Attachment: a, Err: err}
+		}
+		return results
+	}
+
+	used := make(map[string]bool)
+	var results []SavedAttachment
+	for _, a := range attachments {
+		name := attachmentFilename(a)
+		if used[name] {
+			name = a.ID + "-" + name
+		}
+		used[name] = true
+		path := filepath.Join(dir, name)
+
+		res := SavedAttachment{Attachment: a, Path: path}
+		if fi, err := os.Stat(path); err == nil && a.Size > 0 && fi.Size() == a.Size {
+			res.Skipped = true
+		} else {
+			res.Err = downloadTo(ctx, d, a, path)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// attachmentFilename returns a safe local file name for an attachment.
+func attachmentFilename(a Attachment) string {
+	name := filepath.Base(strings.ReplaceAll(a.Filename, `\`, "/"))
+	if name == "." || name == "/" || name == ".." || name == "" {
+		name = "attachment-" + a.ID
+	}
+	return name
+}
+
+// downloadTo writes an attachment to a temporary file and renames it into
+// place, so an interrupted download never leaves a partial file.
+func downloadTo(ctx context.Context, d AttachmentDownloader, a Attachment, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := d.DownloadAttachment(ctx, a, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", a.Filename, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,55 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeDownloader struct{ calls int }
+
+func (f *fakeDownloader) DownloadAttachment(ctx context.Context, a Attachment, w io.Writer) error {
+	f.calls++
+	if a.ID == "bad" {
+		return fmt.Errorf("forbidden")
+	}
+	_, err := io.WriteString(w, "content of "+a.ID)
+	return err
+}
+
+func TestSaveAttachments(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "attachments")
+	attachments := []Attachment{
+		{ID: "1", Filename: "screenshot.png", Size: int64(len("content of 1"))},
+		{ID: "2", Filename: "screenshot.png"},
+		{ID: "3", Filename: "../../etc/passwd"},
+		{ID: "bad", Filename: "secret.pdf"},
+	}
+	d := &fakeDownloader{}
+	results := SaveAttachments(context.Background(), d, attachments, dir)
+
+	wantPaths := []string{"screenshot.png", "2-screenshot.png", "passwd", "secret.pdf"}
+	for i, res := range results {
+		if res.Path != filepath.Join(dir, wantPaths[i]) {
+			t.Errorf("attachment %s saved to %s, want %s", res.Attachment.ID, res.Path, wantPaths[i])
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "passwd")); err != nil || string(data) != "content of 3" {
+		t.Errorf("unexpected content %q, err %v", data, err)
+	}
+	if results[3].Err == nil {
+		t.Error("expected error for failed download")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "secret.pdf")); !os.IsNotExist(err) {
+		t.Error("failed download left a file behind")
+	}
+
+	d.calls = 0
+	results = SaveAttachments(context.Background(), d, attachments[:1], dir)
+	if !results[0].Skipped || d.calls != 0 {
+		t.Errorf("expected existing file to be skipped, got %+v after %d downloads", results[0], d.calls)
+	}
+}
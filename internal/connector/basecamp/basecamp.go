@@ -0,0 +1,207 @@
+// Package basecamp implements a connector for Basecamp to-dos.
+//
+// Tickets are to-dos keyed as <project-id>-<todo-id>. Transitioning a ticket
+// to "done" completes the to-do; transitioning it to "open" reopens it.
+package basecamp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// APIBase is the Basecamp API root; the account ID is appended to it.
+const APIBase = "https://3.basecampapi.com"
+
+const userAgent = "wt (https://github.com/bakerweb/wt)"
+
+// Token holds OAuth credentials for the Basecamp API.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// Client implements the connector.Connector interface for Basecamp.
+type Client struct {
+	BaseURL string
+	OAuth   OAuthConfig
+	Token   Token
+	// OnRefresh is called after the access token was refreshed so the new
+	// token can be persisted.
+	OnRefresh func(Token) error
+	client    *http.Client
+}
+
+// New creates a new Basecamp client for an account base URL such as
+// https://3.basecampapi.com/999999.
+func New(baseURL string, oauth OAuthConfig, token Token) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		OAuth:   oauth,
+		Token:   token,
+		client:  &http.Client{},
+	}
+}
+
+func (c *Client) Name() string { return "basecamp" }
+
+func (c *Client) doRequest(ctx context.Context, method, path string) (*http.Response, error) {
+	if !c.Token.Expiry.IsZero() && time.Now().After(c.Token.Expiry.Add(-time.Minute)) && c.Token.RefreshToken != "" {
+		token, err := c.OAuth.Refresh(ctx, c.client, c.Token.RefreshToken)
+		if err != nil {
+			return nil, err
+		}
+		c.Token = token
+		if c.OnRefresh != nil {
+			if err := c.OnRefresh(token); err != nil {
+				return nil, fmt.Errorf("failed to save refreshed basecamp token: %w", err)
+			}
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token.AccessToken)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+	return c.client.Do(req)
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := c.doRequest(ctx, "GET", path)
+	if err != nil {
+		return fmt.Errorf("basecamp request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return connector.StatusError("basecamp", resp.StatusCode, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode basecamp response: %w", err)
+	}
+	return nil
+}
+
+// bcTodo represents the JSON structure of a Basecamp to-do.
+type bcTodo struct {
+	ID          int64  `json:"id"`
+	Content     string `json:"content"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
+	AppURL      string `json:"app_url"`
+	Bucket      struct {
+		ID int64 `json:"id"`
+	} `json:"bucket"`
+	Assignees []struct {
+		Name string `json:"name"`
+	} `json:"assignees"`
+}
+
+func todoToTicket(todo bcTodo) connector.Ticket {
+	t := connector.Ticket{
+		Key:         fmt.Sprintf("%d-%d", todo.Bucket.ID, todo.ID),
+		Summary:     todo.Content,
+		Description: todo.Description,
+		Status:      "open",
+		URL:         todo.AppURL,
+	}
+	if todo.Completed {
+		t.Status = "done"
+	}
+	if len(todo.Assignees) > 0 {
+		t.Assignee = todo.Assignees[0].Name
+	}
+	return t
+}
+
+// parseKey splits a ticket key into project and to-do IDs.
+func parseKey(key string) (project, todo string, err error) {
+	project, todo, ok := strings.Cut(key, "-")
+	if !ok {
+		return "", "", fmt.Errorf("invalid basecamp to-do key %q (want <project-id>-<todo-id>)", key)
+	}
+	for _, part := range []string{project, todo} {
+		if _, err := strconv.ParseInt(part, 10, 64); err != nil {
+			return "", "", fmt.Errorf("invalid basecamp to-do key %q (want <project-id>-<todo-id>)", key)
+		}
+	}
+	return project, todo, nil
+}
+
+func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	project, todo, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var t bcTodo
+	if err := c.getJSON(ctx, "/buckets/"+project+"/todos/"+todo+".json", &t); err != nil {
+		return nil, err
+	}
+	ticket := todoToTicket(t)
+	return &ticket, nil
+}
+
+func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	var result struct {
+		Priorities    []bcTodo `json:"priorities"`
+		NonPriorities []bcTodo `json:"non_priorities"`
+	}
+	if err := c.getJSON(ctx, "/my/assignments.json", &result); err != nil {
+		return nil, err
+	}
+	tickets := make([]connector.Ticket, 0, len(result.Priorities)+len(result.NonPriorities))
+	for _, todo := range append(result.Priorities, result.NonPriorities...) {
+		if !todo.Completed {
+			tickets = append(tickets, todoToTicket(todo))
+		}
+	}
+	return tickets, nil
+}
+
+// TransitionTicket completes ("done", "complete", "completed") or reopens
+// ("open", "todo", "incomplete") a to-do.
+func (c *Client) TransitionTicket(ctx context.Context, key, status string) error {
+	project, todo, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	var method string
+	switch strings.ToLower(status) {
+	case "done", "complete", "completed":
+		method = "POST"
+	case "open", "todo", "incomplete":
+		method = "DELETE"
+	default:
+		return fmt.Errorf("basecamp to-dos can only be completed or reopened (got %q; use done or open)", status)
+	}
+	resp, err := c.doRequest(ctx, method, "/buckets/"+project+"/todos/"+todo+"/completion.json")
+	if err != nil {
+		return fmt.Errorf("basecamp request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("basecamp transition failed: %w", connector.StatusError("basecamp", resp.StatusCode, body))
+	}
+	return nil
+}
+
+func (c *Client) Validate(ctx context.Context) error {
+	var me struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.getJSON(ctx, "/my/profile.json", &me); err != nil {
+		return fmt.Errorf("basecamp authentication failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package basecamp
+
+import "testing"
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		project string
+		todo    string
+		wantErr bool
+	}{
+		{"123-456", "123", "456", false},
+		{"123", "", "", true},
+		{"abc-456", "", "", true},
+		{"123-", "", "", true},
+		{"PROJ-1", "", "", true},
+	}
+	for _, tt := range tests {
+		project, todo, err := parseKey(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if project != tt.project || todo != tt.todo {
+			t.Errorf("parseKey(%q) = %q, %q, want %q, %q", tt.key, project, todo, tt.project, tt.todo)
+		}
+	}
+}
+
+func TestTodoToTicket(t *testing.T) {
+	var todo bcTodo
+	todo.ID = 456
+	todo.Bucket.ID = 123
+	todo.Content = "Write release notes"
+	todo.Completed = true
+
+	ticket := todoToTicket(todo)
+	if ticket.Key != "123-456" {
+		t.Errorf("Key = %q, want 123-456", ticket.Key)
+	}
+	if ticket.Status != "done" {
+		t.Errorf("Status = %q, want done", ticket.Status)
+	}
+	if ticket.Summary != "Write release notes" {
+		t.Errorf("Summary = %q", ticket.Summary)
+	}
+}
@@ -0,0 +1,186 @@
+package basecamp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// LaunchpadURL is the 37signals OAuth server.
+const LaunchpadURL = "https://launchpad.37signals.com"
+
+// DefaultRedirectURI is used when no redirect URI is configured. It must be
+// registered on the Basecamp integration at launchpad.37signals.com.
+const DefaultRedirectURI = "http://localhost:8976/callback"
+
+// OAuthConfig identifies the Basecamp integration used for OAuth.
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+}
+
+func (o OAuthConfig) redirectURI() string {
+	if o.RedirectURI == "" {
+		return DefaultRedirectURI
+	}
+	return o.RedirectURI
+}
+
+// AuthURL returns the URL the user visits to grant access.
+func (o OAuthConfig) AuthURL(state string) string {
+	q := url.Values{
+		"type":         {"web_server"},
+		"client_id":    {o.ClientID},
+		"redirect_uri": {o.redirectURI()},
+		"state":        {state},
+	}
+	return LaunchpadURL + "/authorization/new?" + q.Encode()
+}
+
+// Exchange trades an authorization code for a token.
+func (o OAuthConfig) Exchange(ctx context.Context, client *http.Client, code string) (Token, error) {
+	return o.token(ctx, client, url.Values{"type": {"web_server"}, "code": {code}})
+}
+
+// Refresh obtains a new access token using a refresh token.
+func (o OAuthConfig) Refresh(ctx context.Context, client *http.Client, refreshToken string) (Token, error) {
+	token, err := o.token(ctx, client, url.Values{"type": {"refresh"}, "refresh_token": {refreshToken}})
+	if err != nil {
+		return Token{}, err
+	}
+	// Launchpad does not rotate refresh tokens.
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func (o OAuthConfig) token(ctx context.Context, client *http.Client, q url.Values) (Token, error) {
+	q.Set("client_id", o.ClientID)
+	q.Set("client_secret", o.ClientSecret)
+	q.Set("redirect_uri", o.redirectURI())
+	req, err := http.NewRequestWithContext(ctx, "POST", LaunchpadURL+"/authorization/token?"+q.Encode(), nil)
+	if err != nil {
+		return Token{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("basecamp token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Token{}, fmt.Errorf("basecamp token request returned %d: %s", resp.StatusCode, string(body))
+	}
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Token{}, fmt.Errorf("failed to decode basecamp token: %w", err)
+	}
+	return Token{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// Account is a Basecamp account the token can access.
+type Account struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Href string `json:"href"`
+}
+
+// Accounts lists the Basecamp accounts available to a token.
+func Accounts(ctx context.Context, client *http.Client, accessToken string) ([]Account, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", LaunchpadURL+"/authorization.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("basecamp request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("basecamp authorization lookup failed (status %d)", resp.StatusCode)
+	}
+	var result struct {
+		Accounts []struct {
+			Account
+			Product string `json:"product"`
+		} `json:"accounts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode basecamp accounts: %w", err)
+	}
+	var accounts []Account
+	for _, a := range result.Accounts {
+		if a.Product == "bc3" || a.Product == "bc4" {
+			accounts = append(accounts, a.Account)
+		}
+	}
+	return accounts, nil
+}
+
+// Authorize runs the OAuth web flow: it prints the authorization URL to out,
+// waits for Basecamp to redirect back to the local redirect URI, and
+// exchanges the returned code for a token.
+func (o OAuthConfig) Authorize(ctx context.Context, out io.Writer) (Token, error) {
+	redirect, err := url.Parse(o.redirectURI())
+	if err != nil {
+		return Token{}, fmt.Errorf("invalid redirect URI: %w", err)
+	}
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return Token{}, fmt.Errorf("cannot listen on %s for the OAuth callback: %w", redirect.Host, err)
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	state := hex.EncodeToString(b)
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("state") != state:
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			errs <- fmt.Errorf("OAuth callback had an unexpected state parameter")
+		case q.Get("error") != "":
+			http.Error(w, q.Get("error"), http.StatusBadRequest)
+			errs <- fmt.Errorf("authorization denied: %s", q.Get("error"))
+		default:
+			fmt.Fprintln(w, "wt is now connected to Basecamp. You can close this window.")
+			codes <- q.Get("code")
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(out, "Open this URL in your browser to authorize wt:\n\n  %s\n\n", o.AuthURL(state))
+	select {
+	case code := <-codes:
+		return o.Exchange(ctx, http.DefaultClient, code)
+	case err := <-errs:
+		return Token{}, err
+	case <-ctx.Done():
+		return Token{}, ctx.Err()
+	}
+}
@@ -0,0 +1,136 @@
+package connector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Kinds of ticket change.
+const (
+	ChangeStatus  = "status"
+	ChangeComment = "comment"
+)
+
+// maxAlerts bounds the number of unread changes kept per ticket.
+const maxAlerts = 20
+
+// Change is a difference in a ticket between two polls.
+type Change struct {
+	Ref  Ref       `json:"ref"`
+	Kind string    `json:"kind"`
+	From string    `json:"from,omitempty"`
+	To   string    `json:"to,omitempty"`
+	New  int       `json:"new,omitempty"` // number of new comments
+	Time time.Time `json:"time"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeStatus:
+		return fmt.Sprintf("%s moved to %s", c.Ref.Key, c.To)
+	case ChangeComment:
+		if c.New > 1 {
+			return fmt.Sprintf("%d new comments on %s", c.New, c.Ref.Key)
+		}
+		return fmt.Sprintf("new comment on %s", c.Ref.Key)
+	}
+	return c.Ref.Key + " changed"
+}
+
+// DiffTicket reports how cur differs from prev in status and comments.
+func DiffTicket(ref Ref, prev, cur *Ticket) []Change {
+	if prev == nil || cur == nil {
+		return nil
+	}
+	now := time.Now()
+	var changes []Change
+	if cur.Status != prev.Status {
+		changes = append(changes, Change{Ref: ref, Kind: ChangeStatus, From: prev.Status, To: cur.Status, Time: now})
+	}
+	if cur.Comments > prev.Comments {
+		changes = append(changes, Change{Ref: ref, Kind: ChangeComment, New: cur.Comments - prev.Comments, Time: now})
+	}
+	return changes
+}
+
+// Alerts remembers the last seen state of tickets and the changes detected
+// between polls that have not been read yet.
+type Alerts struct {
+	path  string
+	mu    sync.Mutex
+	state alertState
+}
+
+type alertState struct {
+	Seen    map[string]*Ticket  `json:"seen"`
+	Pending map[string][]Change `json:"pending"`
+}
+
+// LoadAlerts reads the alert file at path; a missing or unreadable file
+// yields an empty set.
+func LoadAlerts(path string) *Alerts {
+	a := &Alerts{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &a.state)
+	}
+	if a.state.Seen == nil {
+		a.state.Seen = make(map[string]*Ticket)
+	}
+	if a.state.Pending == nil {
+		a.state.Pending = make(map[string][]Change)
+	}
+	return a
+}
+
+// Observe records the current state of a ticket and returns the changes
+// since it was last observed. The first observation establishes a baseline.
+func (a *Alerts) Observe(ref Ref, t *Ticket) []Change {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	k := ref.String()
+	changes := DiffTicket(ref, a.state.Seen[k], t)
+	a.state.Seen[k] = &Ticket{Key: t.Key, Status: t.Status, Comments: t.Comments}
+	if len(changes) > 0 {
+		pending := append(a.state.Pending[k], changes...)
+		if len(pending) > maxAlerts {
+			pending = pending[len(pending)-maxAlerts:]
+		}
+		a.state.Pending[k] = pending
+	}
+	return changes
+}
+
+// Pending returns the unread changes of a ticket.
+func (a *Alerts) Pending(ref Ref) []Change {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state.Pending[ref.String()]
+}
+
+// Clear marks the changes of a ticket as read.
+func (a *Alerts) Clear(ref Ref) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.state.Pending, ref.String())
+}
+
+// Save writes the alert file.
+func (a *Alerts) Save() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	data, err := json.Marshal(a.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(a.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write ticket alerts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package connector
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAlertsObserve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	ref := Ref{"jira", "PROJ-123"}
+	a := LoadAlerts(path)
+
+	if changes := a.Observe(ref, &Ticket{Key: "PROJ-123", Status: "In Progress", Comments: 2}); len(changes) != 0 {
+		t.Fatalf("first observation should be a baseline, got %v", changes)
+	}
+	if changes := a.Observe(ref, &Ticket{Key: "PROJ-123", Status: "In Progress", Comments: 2}); len(changes) != 0 {
+		t.Fatalf("unchanged ticket reported changes: %v", changes)
+	}
+
+	changes := a.Observe(ref, &Ticket{Key: "PROJ-123", Status: "Blocked", Comments: 3})
+	want := []string{"PROJ-123 moved to Blocked", "new comment on PROJ-123"}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %v", len(want), changes)
+	}
+	for i, c := range changes {
+		if c.String() != want[i] {
+			t.Errorf("change %d = %q, want %q", i, c, want[i])
+		}
+	}
+
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+	a = LoadAlerts(path)
+	if got := len(a.Pending(ref)); got != 2 {
+		t.Errorf("expected 2 pending changes after reload, got %d", got)
+	}
+	a.Clear(ref)
+	if got := len(a.Pending(ref)); got != 0 {
+		t.Errorf("expected no pending changes after clear, got %d", got)
+	}
+	if changes := a.Observe(ref, &Ticket{Key: "PROJ-123", Status: "Blocked", Comments: 3}); len(changes) != 0 {
+		t.Errorf("reloaded baseline reported changes: %v", changes)
+	}
+}
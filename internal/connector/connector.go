@@ -1,16 +1,47 @@
 package connector
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // Ticket represents a task/issue from an external system.
 type Ticket struct {
-	Key         string
-	Summary     string
-	Description string
-	Status      string
-	Assignee    string
-	URL         string
-	Labels      []string
+	Key         string   `json:"key"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status,omitempty"`
+	Assignee    string   `json:"assignee,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	// ParentKey and ParentSummary identify the ticket's parent, if any.
+	ParentKey     string `json:"parent_key,omitempty"`
+	ParentSummary string `json:"parent_summary,omitempty"`
+	// EpicKey and EpicName identify the epic the ticket belongs to, if any.
+	EpicKey  string `json:"epic_key,omitempty"`
+	EpicName string `json:"epic_name,omitempty"`
+	// Subtasks lists the ticket's sub-tasks, if any.
+	Subtasks []TicketRef `json:"subtasks,omitempty"`
+	// Comments is the number of comments, for connectors that report it.
+	Comments int `json:"comments,omitempty"`
+	// Priority and Type are the tracker's names, e.g. "High" and "Bug".
+	Priority string `json:"priority,omitempty"`
+	Type     string `json:"type,omitempty"`
+	// Updated is when the ticket last changed; Due is its due date, if any.
+	Updated time.Time `json:"updated,omitzero"`
+	Due     time.Time `json:"due,omitzero"`
+	// Attachments lists files attached to the ticket, if any.
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Metadata holds what the tracker reports beyond the fields above, by
+	// label, e.g. a service desk request's type, customer fields and SLAs.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// TicketRef is a lightweight reference to another ticket.
+type TicketRef struct {
+	Key     string `json:"key"`
+	Summary string `json:"summary"`
 }
 
 // Connector defines the interface that all task management integrations must implement.
@@ -31,6 +62,14 @@ type Connector interface {
 	Validate(ctx context.Context) error
 }
 
+// AssigneeLister is implemented by connectors that can list tickets assigned
+// to someone other than the current user.
+type AssigneeLister interface {
+	// ListAssignedTo fetches open tickets assigned to assignee (an email
+	// address or user name), or unassigned tickets when assignee is empty.
+	ListAssignedTo(ctx context.Context, assignee string) ([]Ticket, error)
+}
+
 // Registry holds all registered connectors.
 type Registry struct {
 	connectors map[string]Connector
@@ -54,6 +93,15 @@ func (r *Registry) Get(name string) (Connector, bool) {
 	return c, ok
 }
 
+// Lookup retrieves a connector by name, failing with ErrNotConfigured.
+func (r *Registry) Lookup(name string) (Connector, error) {
+	c, ok := r.connectors[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q; available: %v", ErrNotConfigured, name, r.List())
+	}
+	return c, nil
+}
+
 // List returns the names of all registered connectors.
 func (r *Registry) List() []string {
 	names := make([]string, 0, len(r.connectors))
@@ -0,0 +1,101 @@
+package connectortest
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer(t,
+		Fixture{Path: "/issues?state=open", Body: []byte(`[{"id":1}]`)},
+		Fixture{Method: "POST", Path: "/issues/1", Status: http.StatusCreated, Body: []byte(`{}`)},
+	)
+	tests := []struct {
+		name       string
+		mode       Mode
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"query matches", Replay, "GET", "/issues?state=open&page=1", 200, `[{"id":1}]`},
+		{"query differs", Replay, "GET", "/issues?state=closed", 404, ""},
+		{"method and status", Replay, "POST", "/issues/1", 201, `{}`},
+		{"method differs", Replay, "GET", "/issues/1", 404, ""},
+		{"unauthorized", Unauthorized, "GET", "/issues?state=open", 401, ""},
+		{"failing", Failing, "GET", "/issues?state=open", 500, ""},
+		{"malformed", Malformed, "GET", "/issues?state=open", 200, `{"key": "ABC-1", "fields": {`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.SetMode(tt.mode)
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader("payload"))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("body = %s, want %s", body, tt.wantBody)
+			}
+		})
+	}
+	reqs := srv.Requests()
+	if len(reqs) != len(tests) || reqs[2].Method != "POST" || reqs[2].Body != "payload" {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b.json", `[{"method":"GET","path":"/b"},{"method":"POST","path":"/c","status":204}]`)
+	write("a.json", `{"method":"GET","path":"/a","body":{"ok":true}}`)
+	write("notes.txt", `not a fixture`)
+
+	fixtures := LoadFixtures(t, dir)
+	var paths []string
+	for _, f := range fixtures {
+		paths = append(paths, f.Path)
+	}
+	if got := strings.Join(paths, " "); got != "/a /b /c" {
+		t.Errorf("paths = %s, want /a /b /c", got)
+	}
+	if string(fixtures[0].Body) != `{"ok":true}` || fixtures[2].Status != 204 {
+		t.Errorf("fixtures = %+v", fixtures)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	live := NewServer(t, Fixture{Path: "/issue/1", Body: []byte(`{"key":"ABC-1"}`)})
+	dir := t.TempDir()
+	t.Run("record", func(t *testing.T) {
+		rec := NewRecorder(t, live.URL, dir)
+		resp, err := http.Get(rec.URL + "/issue/1?expand=all")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	})
+
+	fixtures := LoadFixtures(t, dir)
+	if len(fixtures) != 1 {
+		t.Fatalf("recorded %d fixtures, want 1", len(fixtures))
+	}
+	f := fixtures[0]
+	if f.Method != "GET" || f.Path != "/issue/1?expand=all" || f.Status != 200 || !strings.Contains(string(f.Body), `"ABC-1"`) {
+		t.Errorf("fixture = %+v", f)
+	}
+}
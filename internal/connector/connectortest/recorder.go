@@ -0,0 +1,110 @@
+package connectortest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Recorder is a proxy to a live service that records the exchanges passing
+// through it as fixtures. Point a connector at its URL, exercise it, and
+// the fixtures are written to dir when the test ends, one file per
+// exchange. Review them before committing: they hold whatever the service
+// returned, and credentials sent in headers are not recorded.
+type Recorder struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	fixtures []Fixture
+}
+
+// NewRecorder starts a proxy to the service at target, its root URL, e.g.
+// https://acme.atlassian.net, that records into dir.
+func NewRecorder(t testing.TB, target, dir string) *Recorder {
+	t.Helper()
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &Recorder{}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = u.Host
+	}
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		path := resp.Request.URL.Path
+		if resp.Request.URL.RawQuery != "" {
+			path += "?" + resp.Request.URL.RawQuery
+		}
+		f := Fixture{Method: resp.Request.Method, Path: path, Status: resp.StatusCode}
+		if json.Valid(body) {
+			f.Body = body
+		}
+		rec.mu.Lock()
+		rec.fixtures = append(rec.fixtures, f)
+		rec.mu.Unlock()
+		return nil
+	}
+	rec.Server = httptest.NewServer(proxy)
+	t.Cleanup(func() {
+		rec.Close()
+		if err := rec.save(dir); err != nil {
+			t.Error(err)
+		}
+	})
+	return rec
+}
+
+// Fixtures returns the exchanges recorded so far.
+func (rec *Recorder) Fixtures() []Fixture {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Fixture(nil), rec.fixtures...)
+}
+
+func (rec *Recorder) save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	for i, f := range rec.Fixtures() {
+		data, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%02d-%s%s.json", i+1, strings.ToLower(f.Method), fixtureName(f.Path))
+		if err := os.WriteFile(filepath.Join(dir, name), append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write fixture: %w", err)
+		}
+	}
+	return nil
+}
+
+// fixtureName turns a request path into a file name part.
+func fixtureName(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		}
+		return '-'
+	}, strings.TrimRight(path, "/"))
+}
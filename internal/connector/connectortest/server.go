@@ -0,0 +1,204 @@
+// Package connectortest checks connector implementations against the
+// connector.Connector contract, using recorded responses of the service
+// they talk to.
+//
+// A Server replays fixtures: recorded HTTP exchanges, kept as JSON files in
+// the connector's testdata directory and loaded with LoadFixtures. A Suite
+// points a connector at the server and checks what every connector must do,
+// including on the edge cases the server can simulate: rejected
+// credentials, server errors, malformed responses and canceled requests.
+//
+//	func TestContract(t *testing.T) {
+//		connectortest.Suite{
+//			New:      func(url string) connector.Connector { return mytracker.New(url, "token") },
+//			Fixtures: connectortest.LoadFixtures(t, "testdata"),
+//			Key:      "ABC-1",
+//		}.Run(t)
+//	}
+//
+// Record fixtures from a live service with a Recorder; plugins can be
+// checked by running the suite on plugin.New with a plugin that reads its
+// URL from the config.
+package connectortest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Fixture is a recorded HTTP exchange: a request and the response the
+// server replays for it.
+type Fixture struct {
+	// Method and Path match requests; a query in Path, e.g.
+	// "/issues?status=open", must be part of the request's query.
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Status defaults to 200.
+	Status int `json:"status,omitempty"`
+	// Body is the JSON response body.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// matches reports whether the fixture is recorded for r.
+func (f Fixture) matches(r *http.Request) bool {
+	method := f.Method
+	if method == "" {
+		method = "GET"
+	}
+	path, query, _ := strings.Cut(f.Path, "?")
+	if r.Method != method || r.URL.Path != path {
+		return false
+	}
+	want, err := url.ParseQuery(query)
+	if err != nil {
+		return false
+	}
+	got := r.URL.Query()
+	for k, values := range want {
+		if strings.Join(got[k], ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadFixtures reads the fixtures in the JSON files of dir, in file name
+// order. Each file holds one fixture or a list of them.
+func LoadFixtures(t testing.TB, dir string) []Fixture {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	var fixtures []Fixture
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []Fixture
+		if err := json.Unmarshal(data, &list); err != nil {
+			var f Fixture
+			if err := json.Unmarshal(data, &f); err != nil {
+				t.Fatalf("invalid fixture %s: %v", path, err)
+			}
+			list = []Fixture{f}
+		}
+		fixtures = append(fixtures, list...)
+	}
+	return fixtures
+}
+
+// Mode selects how a Server answers requests.
+type Mode int
+
+const (
+	// Replay answers with the recorded fixtures, and 404 for anything else.
+	Replay Mode = iota
+	// Unauthorized answers every request with 401, as for revoked
+	// credentials.
+	Unauthorized
+	// Failing answers every request with 500.
+	Failing
+	// Malformed answers every request with 200 and a truncated JSON body.
+	Malformed
+)
+
+// Request is a request the server received.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+}
+
+// Server is an httptest server replaying fixtures. It is closed when the
+// test that created it ends.
+type Server struct {
+	*httptest.Server
+	t        testing.TB
+	fixtures []Fixture
+
+	mu       sync.Mutex
+	mode     Mode
+	requests []Request
+}
+
+// NewServer starts a server replaying fixtures.
+func NewServer(t testing.TB, fixtures ...Fixture) *Server {
+	s := &Server{t: t, fixtures: fixtures}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// SetMode changes how the server answers requests.
+func (s *Server) SetMode(m Mode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode = m
+}
+
+// Requests returns the requests the server received, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset forgets the requests received so far.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: string(body)})
+	mode := s.mode
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch mode {
+	case Unauthorized:
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":"unauthorized"}`)
+		return
+	case Failing:
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `{"error":"internal error"}`)
+		return
+	case Malformed:
+		io.WriteString(w, `{"key": "ABC-1", "fields": {`)
+		return
+	}
+	for _, f := range s.fixtures {
+		if !f.matches(r) {
+			continue
+		}
+		status := f.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		w.Write(f.Body)
+		return
+	}
+	s.t.Logf("connectortest: no fixture for %s %s", r.Method, r.URL.RequestURI())
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(w, `{"error":"no fixture for %s %s"}`, r.Method, r.URL.Path)
+}
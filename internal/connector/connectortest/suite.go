@@ -0,0 +1,164 @@
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// Suite is the contract every connector satisfies, checked against
+// recorded responses.
+type Suite struct {
+	// New creates the connector under test, talking to the service at
+	// baseURL.
+	New func(baseURL string) connector.Connector
+	// Fixtures are the recorded responses; they must cover GetTicket(Key),
+	// ListAssigned, Validate and, when Transition is set, TransitionTicket.
+	Fixtures []Fixture
+	// Key is a ticket the fixtures hold.
+	Key string
+	// Want holds fields GetTicket(Key) must return; zero fields are not
+	// checked.
+	Want connector.Ticket
+	// MissingKey is a ticket the service does not know; GetTicket must fail
+	// for it. It defaults to "MISSING-0".
+	MissingKey string
+	// Transition is a status TransitionTicket(Key) is recorded for. When
+	// empty, transitions are not checked.
+	Transition string
+}
+
+// Run checks the connector, each part of the contract in a subtest.
+func (s Suite) Run(t *testing.T) {
+	t.Helper()
+	if s.New == nil || s.Key == "" {
+		t.Fatal("connectortest: Suite needs New and Key")
+	}
+	if s.MissingKey == "" {
+		s.MissingKey = "MISSING-0"
+	}
+	srv := NewServer(t, s.Fixtures...)
+	conn := s.New(srv.URL)
+	ctx := context.Background()
+
+	t.Run("Name", func(t *testing.T) {
+		if conn.Name() == "" {
+			t.Error("Name() is empty")
+		}
+	})
+
+	t.Run("GetTicket", func(t *testing.T) {
+		ticket, err := conn.GetTicket(ctx, s.Key)
+		if err != nil {
+			t.Fatalf("GetTicket(%q): %v", s.Key, err)
+		}
+		if ticket == nil || ticket.Key == "" || ticket.Summary == "" {
+			t.Fatalf("GetTicket(%q) = %+v, want a key and a summary", s.Key, ticket)
+		}
+		for _, diff := range compareTicket(*ticket, s.Want) {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("GetTicketMissing", func(t *testing.T) {
+		if ticket, err := conn.GetTicket(ctx, s.MissingKey); err == nil {
+			t.Errorf("GetTicket(%q) = %+v, want an error", s.MissingKey, ticket)
+		}
+	})
+
+	t.Run("ListAssigned", func(t *testing.T) {
+		tickets, err := conn.ListAssigned(ctx)
+		if err != nil {
+			t.Fatalf("ListAssigned: %v", err)
+		}
+		seen := make(map[string]bool)
+		for i, ticket := range tickets {
+			if ticket.Key == "" || ticket.Summary == "" {
+				t.Errorf("ticket %d = %+v, want a key and a summary", i, ticket)
+			}
+			if seen[ticket.Key] {
+				t.Errorf("ticket %s is listed twice", ticket.Key)
+			}
+			seen[ticket.Key] = true
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		if err := conn.Validate(ctx); err != nil {
+			t.Errorf("Validate: %v", err)
+		}
+	})
+
+	if s.Transition != "" {
+		t.Run("TransitionTicket", func(t *testing.T) {
+			srv.Reset()
+			if err := conn.TransitionTicket(ctx, s.Key, s.Transition); err != nil {
+				t.Fatalf("TransitionTicket(%q, %q): %v", s.Key, s.Transition, err)
+			}
+			for _, r := range srv.Requests() {
+				if r.Method != "GET" {
+					return
+				}
+			}
+			t.Error("TransitionTicket sent no request that changes the ticket")
+		})
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		srv.SetMode(Unauthorized)
+		defer srv.SetMode(Replay)
+		if err := conn.Validate(ctx); !errors.Is(err, connector.ErrAuth) {
+			t.Errorf("Validate with rejected credentials = %v, want an error wrapping connector.ErrAuth", err)
+		}
+		if _, err := conn.GetTicket(ctx, s.Key); err == nil {
+			t.Error("GetTicket with rejected credentials succeeded")
+		}
+		if _, err := conn.ListAssigned(ctx); err == nil {
+			t.Error("ListAssigned with rejected credentials succeeded")
+		}
+	})
+
+	for _, mode := range []struct {
+		name string
+		mode Mode
+	}{{"ServerError", Failing}, {"MalformedResponse", Malformed}} {
+		t.Run(mode.name, func(t *testing.T) {
+			srv.SetMode(mode.mode)
+			defer srv.SetMode(Replay)
+			if _, err := conn.GetTicket(ctx, s.Key); err == nil {
+				t.Error("GetTicket succeeded")
+			}
+			if _, err := conn.ListAssigned(ctx); err == nil {
+				t.Error("ListAssigned succeeded")
+			}
+		})
+	}
+
+	t.Run("Canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := conn.GetTicket(canceled, s.Key); err == nil {
+			t.Error("GetTicket with a canceled context succeeded")
+		}
+	})
+}
+
+// compareTicket describes how got differs from the non-zero fields of want.
+func compareTicket(got, want connector.Ticket) []string {
+	var diffs []string
+	g, w := reflect.ValueOf(got), reflect.ValueOf(want)
+	for i := 0; i < w.NumField(); i++ {
+		if w.Field(i).IsZero() {
+			continue
+		}
+		if !reflect.DeepEqual(g.Field(i).Interface(), w.Field(i).Interface()) {
+			name := w.Type().Field(i).Name
+			diffs = append(diffs, fmt.Sprintf("GetTicket %s = %v, want %v", name, g.Field(i).Interface(), w.Field(i).Interface()))
+		}
+	}
+	return diffs
+}
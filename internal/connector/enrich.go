@@ -0,0 +1,214 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Defaults for fetching many tickets at once.
+const (
+	DefaultFetchConcurrency = 8
+	DefaultRateLimit        = 5 // requests per second per connector
+	DefaultCacheTTL         = 5 * time.Minute
+)
+
+// Ref identifies a ticket of a connector.
+type Ref struct {
+	Connector string `json:"connector"`
+	Key       string `json:"key"`
+}
+
+func (r Ref) String() string { return r.Connector + ":" + r.Key }
+
+// FetchResult is the outcome of fetching one ticket.
+type FetchResult struct {
+	Ticket *Ticket
+	Err    error
+}
+
+// Fetcher retrieves many tickets concurrently while keeping each connector
+// under a request budget, serving recent results from a cache.
+type Fetcher struct {
+	Registry *Registry
+	// Concurrency bounds in-flight requests across all connectors.
+	Concurrency int
+	// RateLimit is the maximum requests per second sent to each connector.
+	RateLimit float64
+	// Cache, if set, is consulted before and updated after fetching.
+	Cache *Cache
+}
+
+// FetchAll fetches the given tickets, returning a result for every ref.
+func (f *Fetcher) FetchAll(ctx context.Context, refs []Ref) map[Ref]FetchResult {
+	results := make(map[Ref]FetchResult, len(refs))
+	var pending []Ref
+	for _, ref := range refs {
+		if _, seen := results[ref]; seen {
+			continue
+		}
+		if f.Cache != nil {
+			if t, ok := f.Cache.Get(ref); ok {
+				results[ref] = FetchResult{Ticket: t}
+				continue
+			}
+		}
+		results[ref] = FetchResult{}
+		pending = append(pending, ref)
+	}
+
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultFetchConcurrency
+	}
+	rate := f.RateLimit
+	if rate <= 0 {
+		rate = DefaultRateLimit
+	}
+	limiters := make(map[string]*limiter)
+	for _, ref := range pending {
+		if limiters[ref.Connector] == nil {
+			limiters[ref.Connector] = &limiter{interval: time.Duration(float64(time.Second) / rate)}
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, ref := range pending {
+		wg.Add(1)
+		go func(ref Ref) {
+			defer wg.Done()
+			res := f.fetch(ctx, ref, sem, limiters[ref.Connector])
+			mu.Lock()
+			results[ref] = res
+			mu.Unlock()
+		}(ref)
+	}
+	wg.Wait()
+
+	if f.Cache != nil {
+		for _, ref := range pending {
+			if res := results[ref]; res.Err == nil && res.Ticket != nil {
+				f.Cache.Put(ref, res.Ticket)
+			}
+		}
+	}
+	return results
+}
+
+func (f *Fetcher) fetch(ctx context.Context, ref Ref, sem chan struct{}, lim *limiter) FetchResult {
+	conn, err := f.Registry.Lookup(ref.Connector)
+	if err != nil {
+		return FetchResult{Err: err}
+	}
+	if err := lim.wait(ctx); err != nil {
+		return FetchResult{Err: err}
+	}
+	select {
+	case sem <- struct{}{}:
+		defer func() { <-sem }()
+	case <-ctx.Done():
+		return FetchResult{Err: ctx.Err()}
+	}
+	t, err := conn.GetTicket(ctx, ref.Key)
+	return FetchResult{Ticket: t, Err: err}
+}
+
+// limiter spaces requests to one connector at least interval apart.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cache stores fetched tickets in a JSON file for a limited time.
+type Cache struct {
+	path    string
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	Ticket  *Ticket   `json:"ticket"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// LoadCache reads the cache file at path; a missing or unreadable file
+// yields an empty cache.
+func LoadCache(path string, ttl time.Duration) *Cache {
+	c := &Cache{path: path, ttl: ttl, entries: make(map[string]cacheEntry)}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &c.entries)
+	}
+	return c
+}
+
+// Get returns a cached ticket that has not expired.
+func (c *Cache) Get(ref Ref) (*Ticket, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[ref.String()]
+	if !ok || time.Since(e.Fetched) > c.ttl {
+		return nil, false
+	}
+	return e.Ticket, true
+}
+
+// Put records a freshly fetched ticket.
+func (c *Cache) Put(ref Ref, t *Ticket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[ref.String()] = cacheEntry{Ticket: t, Fetched: time.Now()}
+}
+
+// Save writes the cache file, dropping expired entries.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if time.Since(e.Fetched) > c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write ticket cache: %w", err)
+	}
+	return nil
+}
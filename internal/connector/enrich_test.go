@@ -0,0 +1,98 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeConnector struct {
+	name     string
+	calls    atomic.Int32
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (f *fakeConnector) Name() string { return f.name }
+
+func (f *fakeConnector) GetTicket(ctx context.Context, key string) (*Ticket, error) {
+	f.calls.Add(1)
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		max := f.maxSeen.Load()
+		if n <= max || f.maxSeen.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	if key == "BAD-1" {
+		return nil, fmt.Errorf("not found")
+	}
+	return &Ticket{Key: key, Status: "In Progress"}, nil
+}
+
+func (f *fakeConnector) ListAssigned(ctx context.Context) ([]Ticket, error)      { return nil, nil }
+func (f *fakeConnector) TransitionTicket(ctx context.Context, k, s string) error { return nil }
+func (f *fakeConnector) Validate(ctx context.Context) error                      { return nil }
+
+func TestFetchAll(t *testing.T) {
+	conn := &fakeConnector{name: "fake"}
+	reg := NewRegistry()
+	reg.Register(conn)
+	cache := LoadCache(filepath.Join(t.TempDir(), "tickets.json"), time.Minute)
+	f := &Fetcher{Registry: reg, Concurrency: 2, RateLimit: 1000, Cache: cache}
+
+	var refs []Ref
+	for i := 0; i < 10; i++ {
+		refs = append(refs, Ref{"fake", fmt.Sprintf("T-%d", i)})
+	}
+	refs = append(refs, Ref{"fake", "T-0"}, Ref{"fake", "BAD-1"}, Ref{"missing", "X-1"})
+
+	results := f.FetchAll(context.Background(), refs)
+	if got := conn.calls.Load(); got != 11 {
+		t.Errorf("expected 11 requests (duplicates collapsed), got %d", got)
+	}
+	if got := conn.maxSeen.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent requests, saw %d", got)
+	}
+	if r := results[Ref{"fake", "T-3"}]; r.Err != nil || r.Ticket.Status != "In Progress" {
+		t.Errorf("unexpected result for T-3: %+v", r)
+	}
+	if results[Ref{"fake", "BAD-1"}].Err == nil {
+		t.Error("expected error for BAD-1")
+	}
+	if results[Ref{"missing", "X-1"}].Err == nil {
+		t.Error("expected error for unconfigured connector")
+	}
+
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	f.Cache = LoadCache(cache.path, time.Minute)
+	f.FetchAll(context.Background(), refs[:10])
+	if got := conn.calls.Load(); got != 11 {
+		t.Errorf("expected cached tickets to be reused, got %d requests", got)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := &limiter{interval: 20 * time.Millisecond}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.wait(context.Background())
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("4 requests at 20ms spacing finished in %s, want >= 60ms", elapsed)
+	}
+}
@@ -0,0 +1,27 @@
+package connector
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrAuth marks failures caused by missing or rejected credentials.
+	ErrAuth = errors.New("connector authentication failed")
+	// ErrNotConfigured is returned when a connector has not been set up.
+	ErrNotConfigured = errors.New("connector not configured")
+)
+
+// StatusError describes an unexpected HTTP response from a tracker. 401 and
+// 403 responses wrap ErrAuth.
+func StatusError(service string, status int, body []byte) error {
+	msg := fmt.Sprintf("%s returned %d", service, status)
+	if len(body) > 0 {
+		msg += ": " + string(body)
+	}
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return fmt.Errorf("%s: %w", msg, ErrAuth)
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,141 @@
+package connector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TicketFields lists the ticket fields that can be shown as columns or
+// sorted on, in the order they are documented.
+var TicketFields = []string{"key", "summary", "status", "assignee", "priority", "type", "updated", "due", "epic", "parent", "labels"}
+
+// DefaultTicketColumns are the columns of ticket tables unless configured.
+var DefaultTicketColumns = []string{"key", "summary", "status"}
+
+// priorityRank orders common priority names from most to least urgent.
+// Unknown names sort after all of these.
+var priorityRank = map[string]int{
+	"blocker": 0, "highest": 0, "urgent": 0, "critical": 1,
+	"high": 2, "major": 2, "medium": 3, "normal": 3,
+	"low": 4, "minor": 4, "lowest": 5, "trivial": 5,
+	// Incident priorities, as PagerDuty and Opsgenie name them.
+	"p1": 1, "p2": 2, "p3": 3, "p4": 4, "p5": 5,
+}
+
+// ValidateTicketFields checks that every name is a known ticket field.
+func ValidateTicketFields(names []string) error {
+	for _, name := range names {
+		if !isTicketField(name) {
+			return fmt.Errorf("unknown ticket field %q (want one of %s)", name, strings.Join(TicketFields, ", "))
+		}
+	}
+	return nil
+}
+
+func isTicketField(name string) bool {
+	for _, f := range TicketFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldValue formats a ticket field for display; empty values are "-".
+func FieldValue(t Ticket, field string) string {
+	var v string
+	switch field {
+	case "key":
+		v = t.Key
+	case "summary":
+		v = t.Summary
+	case "status":
+		v = t.Status
+	case "assignee":
+		v = t.Assignee
+	case "priority":
+		v = t.Priority
+	case "type":
+		v = t.Type
+	case "updated":
+		if !t.Updated.IsZero() {
+			v = t.Updated.Local().Format("2006-01-02 15:04")
+		}
+	case "due":
+		if !t.Due.IsZero() {
+			v = t.Due.Format("2006-01-02")
+		}
+	case "epic":
+		v = t.EpicKey
+	case "parent":
+		v = t.ParentKey
+	case "labels":
+		v = strings.Join(t.Labels, ",")
+	}
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// SortTickets sorts tickets by a field, prefixed with "-" for descending
+// order. Priorities sort by urgency, dates chronologically, and tickets
+// missing the field always sort last.
+func SortTickets(tickets []Ticket, by string) error {
+	field, desc := strings.TrimPrefix(by, "-"), strings.HasPrefix(by, "-")
+	if !isTicketField(field) {
+		return fmt.Errorf("unknown sort field %q (want one of %s)", field, strings.Join(TicketFields, ", "))
+	}
+	sort.SliceStable(tickets, func(i, j int) bool {
+		a, b := tickets[i], tickets[j]
+		aMissing, bMissing := FieldValue(a, field) == "-", FieldValue(b, field) == "-"
+		if aMissing || bMissing {
+			return !aMissing && bMissing
+		}
+		c := compareField(a, b, field)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	return nil
+}
+
+func compareField(a, b Ticket, field string) int {
+	switch field {
+	case "priority":
+		return rank(a.Priority) - rank(b.Priority)
+	case "updated":
+		return a.Updated.Compare(b.Updated)
+	case "due":
+		return a.Due.Compare(b.Due)
+	}
+	return strings.Compare(strings.ToLower(FieldValue(a, field)), strings.ToLower(FieldValue(b, field)))
+}
+
+// ComparePriority compares two priority names by urgency: it is negative
+// when a is more urgent than b. Unknown names are the least urgent.
+func ComparePriority(a, b string) int {
+	return rank(a) - rank(b)
+}
+
+func rank(priority string) int {
+	if r, ok := priorityRank[strings.ToLower(priority)]; ok {
+		return r
+	}
+	return len(priorityRank)
+}
+
+// ParseTime parses the timestamp formats trackers commonly return: RFC 3339,
+// Jira's "2006-01-02T15:04:05.000-0700" and plain dates. Unparseable values
+// yield the zero time.
+func ParseTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000-0700", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,60 @@
+package connector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortTickets(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	tickets := []Ticket{
+		{Key: "A", Priority: "Low", Due: day(3)},
+		{Key: "B", Priority: "Highest"},
+		{Key: "C", Priority: "Medium", Due: day(1)},
+		{Key: "D", Due: day(2)},
+	}
+	tests := []struct {
+		by   string
+		want string
+	}{
+		{"priority", "BCAD"},
+		{"-priority", "ACBD"},
+		{"due", "CDAB"},
+		{"-due", "ADCB"},
+		{"-key", "DCBA"},
+	}
+	for _, tt := range tests {
+		sorted := append([]Ticket(nil), tickets...)
+		if err := SortTickets(sorted, tt.by); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for _, tk := range sorted {
+			got += tk.Key
+		}
+		if got != tt.want {
+			t.Errorf("SortTickets(%q) = %s, want %s", tt.by, got, tt.want)
+		}
+	}
+	if err := SortTickets(tickets, "color"); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-03-04T05:06:07Z", time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"2026-03-04T05:06:07.000+0000", time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"2026-03-04", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"soon", time.Time{}},
+		{"", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := ParseTime(tt.in); !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package generic implements a connector for REST trackers that is described
+// entirely in config: endpoints, the auth header and where ticket fields live
+// in the JSON responses.
+package generic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// Client implements the connector.Connector interface for a REST tracker.
+type Client struct {
+	name   string
+	cc     config.ConnectorConfig
+	client *http.Client
+}
+
+// New creates a client for the connector configured under name.
+func New(name string, cc config.ConnectorConfig) *Client {
+	cc.URL = strings.TrimRight(cc.URL, "/")
+	return &Client{name: name, cc: cc, client: &http.Client{}}
+}
+
+func (c *Client) Name() string { return c.name }
+
+// authHeader parses the auth_header setting ("Name: value"). The value may
+// reference {api_token} and environment variables such as $TRACKER_TOKEN.
+func (c *Client) authHeader() (name, value string, ok bool) {
+	if c.cc.AuthHeader == "" {
+		return "", "", false
+	}
+	name, value, ok = strings.Cut(c.cc.AuthHeader, ":")
+	if !ok {
+		return "", "", false
+	}
+	value = strings.ReplaceAll(value, "{api_token}", c.cc.APIToken)
+	return strings.TrimSpace(name), strings.TrimSpace(os.ExpandEnv(value)), true
+}
+
+func (c *Client) do(ctx context.Context, ep config.RESTEndpoint, defaultMethod string, vars map[string]string, v any) error {
+	if ep.Path == "" {
+		return fmt.Errorf("%s: endpoint not configured", c.name)
+	}
+	method := ep.Method
+	if method == "" {
+		method = defaultMethod
+	}
+	path := ep.Path
+	body := ep.Body
+	for k, val := range vars {
+		path = strings.ReplaceAll(path, "{"+k+"}", url.PathEscape(val))
+		quoted, _ := json.Marshal(val)
+		body = strings.ReplaceAll(body, "{"+k+"}", string(quoted[1:len(quoted)-1]))
+	}
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = bytes.NewBufferString(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), c.cc.URL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if name, value, ok := c.authHeader(); ok {
+		req.Header.Set(name, value)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return connector.StatusError(c.name, resp.StatusCode, respBody)
+	}
+	if v == nil {
+		return nil
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", c.name, err)
+	}
+	return nil
+}
+
+// lookup resolves a dotted path such as "fields.status.name" or
+// "assignees.0.name" in a decoded JSON value.
+func lookup(v any, path string) any {
+	if path == "" {
+		return v
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[part]
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func lookupString(v any, path string) string {
+	if path == "" {
+		return ""
+	}
+	return stringify(lookup(v, path))
+}
+
+func stringify(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number, bool:
+		return fmt.Sprint(val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+func (c *Client) toTicket(v any) connector.Ticket {
+	f := c.cc.Fields
+	t := connector.Ticket{
+		Key:         lookupString(v, f.Key),
+		Summary:     lookupString(v, f.Summary),
+		Description: lookupString(v, f.Description),
+		Status:      lookupString(v, f.Status),
+		Assignee:    lookupString(v, f.Assignee),
+		URL:         lookupString(v, f.URL),
+		ParentKey:   lookupString(v, f.ParentKey),
+		EpicKey:     lookupString(v, f.EpicKey),
+		EpicName:    lookupString(v, f.EpicName),
+		Priority:    lookupString(v, f.Priority),
+		Type:        lookupString(v, f.Type),
+		Updated:     connector.ParseTime(lookupString(v, f.Updated)),
+		Due:         connector.ParseTime(lookupString(v, f.Due)),
+	}
+	if f.Labels != "" {
+		if labels, ok := lookup(v, f.Labels).([]any); ok {
+			for _, l := range labels {
+				t.Labels = append(t.Labels, stringify(l))
+			}
+		}
+	}
+	return t
+}
+
+func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	var v any
+	if err := c.do(ctx, c.cc.Endpoints.Get, "GET", map[string]string{"key": key}, &v); err != nil {
+		return nil, err
+	}
+	t := c.toTicket(v)
+	if t.Key == "" {
+		t.Key = key
+	}
+	return &t, nil
+}
+
+func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	var v any
+	if err := c.do(ctx, c.cc.Endpoints.List, "GET", nil, &v); err != nil {
+		return nil, err
+	}
+	items, ok := lookup(v, c.cc.Fields.Items).([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: list response has no ticket array at %q", c.name, c.cc.Fields.Items)
+	}
+	tickets := make([]connector.Ticket, 0, len(items))
+	for _, item := range items {
+		tickets = append(tickets, c.toTicket(item))
+	}
+	return tickets, nil
+}
+
+func (c *Client) TransitionTicket(ctx context.Context, key, status string) error {
+	return c.do(ctx, c.cc.Endpoints.Transition, "POST", map[string]string{"key": key, "status": status}, nil)
+}
+
+// Validate requests the validate endpoint, falling back to the list endpoint.
+func (c *Client) Validate(ctx context.Context) error {
+	ep := c.cc.Endpoints.Validate
+	if ep.Path == "" {
+		ep = c.cc.Endpoints.List
+	}
+	if err := c.do(ctx, ep, "GET", nil, nil); err != nil {
+		return fmt.Errorf("%s validation failed: %w", c.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package generic
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const testConfig = `
+type: generic
+api_token: secret
+auth_header: "X-Token: {api_token}"
+endpoints:
+  get: /issues/{key}
+  list: /issues?mine=1
+  transition:
+    method: PATCH
+    path: /issues/{key}
+    body: '{"state": "{status}"}'
+fields:
+  items: data
+  key: id
+  summary: title
+  status: state.name
+  assignee: owners.0.name
+  labels: tags
+`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	var cc config.ConnectorConfig
+	if err := yaml.Unmarshal([]byte(testConfig), &cc); err != nil {
+		t.Fatal(err)
+	}
+	cc.URL = srv.URL + "/"
+	return New("tracker", cc)
+}
+
+func TestGetTicket(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/issues/T-7" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"id": 7, "title": "Fix login", "state": {"name": "open"}, "owners": [{"name": "ana"}], "tags": ["bug", "auth"]}`)
+	})
+
+	ticket, err := c.GetTicket(context.Background(), "T-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.Key != "7" || ticket.Summary != "Fix login" || ticket.Status != "open" || ticket.Assignee != "ana" {
+		t.Errorf("unexpected ticket: %+v", ticket)
+	}
+	if len(ticket.Labels) != 2 || ticket.Labels[0] != "bug" {
+		t.Errorf("Labels = %v, want [bug auth]", ticket.Labels)
+	}
+}
+
+func TestListAssigned(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data": [{"id": "A-1", "title": "one"}, {"id": "A-2", "title": "two"}]}`)
+	})
+
+	tickets, err := c.ListAssigned(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 2 || tickets[1].Key != "A-2" || tickets[1].Summary != "two" {
+		t.Errorf("unexpected tickets: %+v", tickets)
+	}
+}
+
+func TestTransitionTicket(t *testing.T) {
+	var method, body string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := c.TransitionTicket(context.Background(), "A-1", `in "review"`); err != nil {
+		t.Fatal(err)
+	}
+	if method != "PATCH" {
+		t.Errorf("method = %s, want PATCH", method)
+	}
+	if want := `{"state": "in \"review\""}`; body != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+}
@@ -0,0 +1,245 @@
+// Package gitlab implements a connector for GitLab issues, on gitlab.com or
+// a self-hosted instance.
+//
+// Tickets are issues keyed by their project's path and their IID, as GitLab
+// references them across projects, e.g. acme/backend#12. With a default
+// project configured, "#12" or "12" refer to its issues. Transitioning a
+// ticket to "closed" (or "done") closes the issue and "opened" reopens it;
+// a scoped label such as "workflow::in review" is added to the issue,
+// replacing the other labels of its scope.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// DefaultURL is gitlab.com; self-hosted GitLab is configured with its own URL.
+const DefaultURL = "https://gitlab.com"
+
+// Client implements the connector.Connector interface for GitLab.
+type Client struct {
+	BaseURL string
+	Token   string
+	// Project is the path of the project, e.g. acme/backend, that keys
+	// without one refer to.
+	Project string
+	client  *http.Client
+}
+
+// New creates a GitLab client authenticating with a personal, group or
+// project access token. An empty baseURL means DefaultURL.
+func New(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		client:  &http.Client{},
+	}
+}
+
+func (c *Client) Name() string { return "gitlab" }
+
+// do sends a request to the REST API with an optional JSON body, and
+// decodes the JSON response into v unless v is nil.
+func (c *Client) do(ctx context.Context, method, path string, body, v any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+"/api/v4"+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitlab request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return connector.StatusError("gitlab", resp.StatusCode, data)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode gitlab response: %w", err)
+	}
+	return nil
+}
+
+// issuePath returns the API path of the issue key refers to.
+func (c *Client) issuePath(key string) (string, error) {
+	project, iid, ok := strings.Cut(key, "#")
+	if !ok {
+		project, iid = "", key
+	}
+	if project == "" {
+		project = c.Project
+	}
+	if project == "" {
+		return "", fmt.Errorf("invalid gitlab issue %q: want <project>#<iid>, e.g. acme/backend#12, or configure a default project", key)
+	}
+	if _, err := strconv.Atoi(iid); err != nil {
+		return "", fmt.Errorf("invalid gitlab issue %q: %q is not an issue number", key, iid)
+	}
+	return "/projects/" + url.PathEscape(project) + "/issues/" + iid, nil
+}
+
+// issue represents the JSON structure of a GitLab issue.
+type issue struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	State       string   `json:"state"`
+	WebURL      string   `json:"web_url"`
+	Labels      []string `json:"labels"`
+	IssueType   string   `json:"issue_type"`
+	Severity    string   `json:"severity"`
+	UpdatedAt   string   `json:"updated_at"`
+	DueDate     string   `json:"due_date"`
+	Notes       int      `json:"user_notes_count"`
+	Assignees   []struct {
+		Name string `json:"name"`
+	} `json:"assignees"`
+	Author struct {
+		Name string `json:"name"`
+	} `json:"author"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	Epic *struct {
+		IID   int    `json:"iid"`
+		Title string `json:"title"`
+	} `json:"epic"`
+	References struct {
+		Full string `json:"full"`
+	} `json:"references"`
+}
+
+func (i issue) ticket() connector.Ticket {
+	t := connector.Ticket{
+		Key:         i.References.Full,
+		Summary:     i.Title,
+		Description: i.Description,
+		Status:      i.State,
+		URL:         i.WebURL,
+		Labels:      i.Labels,
+		Type:        i.IssueType,
+		Comments:    i.Notes,
+		Updated:     connector.ParseTime(i.UpdatedAt),
+		Due:         connector.ParseTime(i.DueDate),
+		Metadata:    map[string]string{},
+	}
+	if t.Key == "" {
+		t.Key = "#" + strconv.Itoa(i.IID)
+	}
+	if len(i.Assignees) > 0 {
+		t.Assignee = i.Assignees[0].Name
+	}
+	// GitLab has no priority field; teams use scoped labels for it.
+	for _, label := range i.Labels {
+		if scope, value, ok := strings.Cut(label, "::"); ok && strings.EqualFold(scope, "priority") {
+			t.Priority = value
+		}
+	}
+	if i.Epic != nil {
+		t.EpicKey = "&" + strconv.Itoa(i.Epic.IID)
+		t.EpicName = i.Epic.Title
+	}
+	if i.Milestone != nil {
+		t.Metadata["Milestone"] = i.Milestone.Title
+	}
+	if i.Author.Name != "" {
+		t.Metadata["Author"] = i.Author.Name
+	}
+	if i.Severity != "" && i.Severity != "UNKNOWN" {
+		t.Metadata["Severity"] = strings.ToLower(i.Severity)
+	}
+	return t
+}
+
+func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	path, err := c.issuePath(key)
+	if err != nil {
+		return nil, err
+	}
+	var i issue
+	if err := c.do(ctx, "GET", path, nil, &i); err != nil {
+		return nil, err
+	}
+	t := i.ticket()
+	return &t, nil
+}
+
+// ListAssigned lists the open issues assigned to the current user, across
+// all projects.
+func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	q := url.Values{"scope": {"assigned_to_me"}, "state": {"opened"}, "order_by": {"updated_at"}, "per_page": {"50"}}
+	var issues []issue
+	if err := c.do(ctx, "GET", "/issues?"+q.Encode(), nil, &issues); err != nil {
+		return nil, err
+	}
+	tickets := make([]connector.Ticket, 0, len(issues))
+	for _, i := range issues {
+		tickets = append(tickets, i.ticket())
+	}
+	return tickets, nil
+}
+
+// TransitionTicket closes ("closed", "done") or reopens ("opened", "open")
+// an issue, or adds a scoped label given as the status, e.g.
+// "workflow::in review".
+func (c *Client) TransitionTicket(ctx context.Context, key, status string) error {
+	path, err := c.issuePath(key)
+	if err != nil {
+		return err
+	}
+	var body map[string]string
+	switch strings.ToLower(status) {
+	case "closed", "close", "done", "resolved":
+		body = map[string]string{"state_event": "close"}
+	case "opened", "open", "reopen", "reopened":
+		body = map[string]string{"state_event": "reopen"}
+	default:
+		if !strings.Contains(status, "::") {
+			return fmt.Errorf("gitlab issues can only be closed, reopened or given a scoped label like workflow::doing (got %q)", status)
+		}
+		body = map[string]string{"add_labels": status}
+	}
+	if err := c.do(ctx, "PUT", path, body, nil); err != nil {
+		return fmt.Errorf("gitlab transition failed: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) Validate(ctx context.Context) error {
+	if err := c.do(ctx, "GET", "/user", nil, nil); err != nil {
+		return fmt.Errorf("gitlab authentication failed: %w", err)
+	}
+	if c.Project != "" {
+		if err := c.do(ctx, "GET", "/projects/"+url.PathEscape(c.Project), nil, nil); err != nil {
+			return fmt.Errorf("cannot access gitlab project %s: %w", c.Project, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/connector/connectortest"
+)
+
+func TestIssuePath(t *testing.T) {
+	tests := []struct {
+		key     string
+		project string
+		want    string
+		wantErr bool
+	}{
+		{"acme/backend#12", "", "/projects/acme%2Fbackend/issues/12", false},
+		{"acme/web/storefront#7", "acme/backend", "/projects/acme%2Fweb%2Fstorefront/issues/7", false},
+		{"311#12", "", "/projects/311/issues/12", false},
+		{"#12", "acme/backend", "/projects/acme%2Fbackend/issues/12", false},
+		{"12", "acme/backend", "/projects/acme%2Fbackend/issues/12", false},
+		{"#12", "", "", true},
+		{"acme/backend#login", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			c := New("", "token")
+			c.Project = tt.project
+			got, err := c.issuePath(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("issuePath(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("issuePath(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionTicket(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/api/v4/projects/acme/backend/issues/12" {
+			http.NotFound(w, r)
+			return
+		}
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "token")
+
+	tests := []struct {
+		status  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"done", map[string]string{"state_event": "close"}, false},
+		{"reopen", map[string]string{"state_event": "reopen"}, false},
+		{"workflow::in review", map[string]string{"add_labels": "workflow::in review"}, false},
+		{"In Progress", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			got = nil
+			err := c.TransitionTicket(context.Background(), "acme/backend#12", tt.status)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransitionTicket(%q) error = %v, wantErr %v", tt.status, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) || got["state_event"] != tt.want["state_event"] || got["add_labels"] != tt.want["add_labels"] {
+				t.Errorf("TransitionTicket(%q) sent %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContract(t *testing.T) {
+	connectortest.Suite{
+		New:      func(url string) connector.Connector { return New(url, "token") },
+		Fixtures: connectortest.LoadFixtures(t, "testdata"),
+		Key:      "acme/backend#12",
+		Want: connector.Ticket{
+			Key:         "acme/backend#12",
+			Summary:     "Login redirect drops the return URL",
+			Description: "After signing in, users land on the dashboard instead of the page they asked for.",
+			Status:      "opened",
+			Assignee:    "Sam Lee",
+			Priority:    "high",
+			EpicKey:     "&4",
+			EpicName:    "Auth overhaul",
+			Comments:    4,
+			Due:         time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC),
+			Metadata:    map[string]string{"Milestone": "2026.03", "Author": "Dana Smith"},
+		},
+		MissingKey: "acme/backend#999",
+		Transition: "closed",
+	}.Run(t)
+}
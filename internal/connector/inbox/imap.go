@@ -0,0 +1,239 @@
+package inbox
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// imapSource reads flagged messages over IMAP4rev1 with TLS. It implements
+// only the handful of commands the connector needs, opening one connection
+// per operation.
+type imapSource struct {
+	addr     string
+	user     string
+	password string
+	mailbox  string
+}
+
+// imapConn is a logged-in IMAP session.
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse is one untagged response line with its literals inlined.
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+func (s *imapSource) dial(ctx context.Context) (*imapConn, error) {
+	host, _, _ := net.SplitHostPort(s.addr)
+	d := tls.Dialer{Config: &tls.Config{ServerName: host}}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", s.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(2 * time.Minute))
+	}
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	if _, err := c.readLine(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read IMAP greeting: %w", err)
+	}
+	if _, err := c.command("LOGIN " + quote(s.user) + " " + quote(s.password)); err != nil {
+		conn.Close()
+		var se *imapStatusError
+		if errors.As(err, &se) {
+			err = fmt.Errorf("%w: %w", connector.ErrAuth, err)
+		}
+		return nil, err
+	}
+	if _, err := c.command("SELECT " + quote(s.mailbox)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *imapConn) close() {
+	c.command("LOGOUT")
+	c.conn.Close()
+}
+
+func (c *imapConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+var literalRe = regexp.MustCompile(`\{(\d+)\}$`)
+
+// command sends a command and collects untagged responses until the tagged
+// completion, failing unless the server answers OK.
+// imapStatusError is a NO or BAD response to a command.
+type imapStatusError struct {
+	verb   string
+	status string
+}
+
+func (e *imapStatusError) Error() string {
+	return fmt.Sprintf("IMAP %s failed: %s", e.verb, e.status)
+}
+
+func (c *imapConn) command(cmd string) ([]imapResponse, error) {
+	c.tag++
+	tag := "w" + strconv.Itoa(c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return nil, fmt.Errorf("IMAP write failed: %w", err)
+	}
+
+	var responses []imapResponse
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("IMAP read failed: %w", err)
+		}
+		if rest, ok := strings.CutPrefix(line, tag+" "); ok {
+			if !strings.HasPrefix(rest, "OK") {
+				verb, _, _ := strings.Cut(cmd, " ")
+				return nil, &imapStatusError{verb: verb, status: rest}
+			}
+			return responses, nil
+		}
+		resp := imapResponse{text: line}
+		// A line ending in {n} is followed by n bytes of literal data and
+		// then the remainder of the response.
+		for {
+			m := literalRe.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			n, _ := strconv.Atoi(m[1])
+			lit := make([]byte, n)
+			if _, err := io.ReadFull(c.r, lit); err != nil {
+				return nil, fmt.Errorf("IMAP read failed: %w", err)
+			}
+			resp.literals = append(resp.literals, lit)
+			if line, err = c.readLine(); err != nil {
+				return nil, fmt.Errorf("IMAP read failed: %w", err)
+			}
+			resp.text += " " + line
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// quote returns s as an IMAP quoted string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+var (
+	uidRe   = regexp.MustCompile(`\bUID (\d+)`)
+	flagsRe = regexp.MustCompile(`\bFLAGS \(([^)]*)\)`)
+)
+
+// fetch retrieves full messages for a UID set without marking them read.
+func (c *imapConn) fetch(uids string) ([]message, error) {
+	responses, err := c.command("UID FETCH " + uids + " (UID FLAGS BODY.PEEK[])")
+	if err != nil {
+		return nil, err
+	}
+	var msgs []message
+	for _, resp := range responses {
+		if !strings.Contains(resp.text, " FETCH ") || len(resp.literals) == 0 {
+			continue
+		}
+		m, err := parseMessage(resp.literals[0])
+		if err != nil {
+			return nil, err
+		}
+		if uid := uidRe.FindStringSubmatch(resp.text); uid != nil {
+			m.ID = uid[1]
+		}
+		if flags := flagsRe.FindStringSubmatch(resp.text); flags != nil {
+			m.Flagged = strings.Contains(flags[1], `\Flagged`)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+func (s *imapSource) flagged(ctx context.Context) ([]message, error) {
+	c, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	responses, err := c.command("UID SEARCH FLAGGED UNDELETED")
+	if err != nil {
+		return nil, err
+	}
+	var uids []string
+	for _, resp := range responses {
+		if rest, ok := strings.CutPrefix(resp.text, "* SEARCH"); ok {
+			uids = append(uids, strings.Fields(rest)...)
+		}
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	return c.fetch(strings.Join(uids, ","))
+}
+
+func (s *imapSource) get(ctx context.Context, id string) (*message, error) {
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return nil, fmt.Errorf("invalid IMAP message UID %q", id)
+	}
+	c, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	msgs, err := c.fetch(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("message %s not found in %s", id, s.mailbox)
+	}
+	return &msgs[0], nil
+}
+
+func (s *imapSource) setFlagged(ctx context.Context, id string, flagged bool) error {
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return fmt.Errorf("invalid IMAP message UID %q", id)
+	}
+	c, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	op := "-FLAGS.SILENT"
+	if flagged {
+		op = "+FLAGS.SILENT"
+	}
+	_, err = c.command("UID STORE " + id + " " + op + ` (\Flagged)`)
+	return err
+}
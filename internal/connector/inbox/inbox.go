@@ -0,0 +1,125 @@
+// Package inbox implements a connector that turns flagged emails into
+// tickets, for work that arrives by email rather than through a tracker.
+//
+// The mailbox is reached over IMAP (imaps://host[:port][/mailbox]) or JMAP
+// (https:// session URL). Flagged messages are the assigned tickets: the
+// subject becomes the summary and the plain-text body the description.
+// Transitioning a ticket to "done" removes the flag; "open" sets it again.
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// message is an email as seen by the connector.
+type message struct {
+	ID      string
+	Subject string
+	From    string
+	Body    string
+	Flagged bool
+}
+
+// source is a mail protocol backend.
+type source interface {
+	flagged(ctx context.Context) ([]message, error)
+	get(ctx context.Context, id string) (*message, error)
+	setFlagged(ctx context.Context, id string, flagged bool) error
+}
+
+// Client implements the connector.Connector interface for a mailbox.
+type Client struct {
+	src source
+}
+
+// New creates an inbox client. rawURL selects the protocol: imaps:// for
+// IMAP over TLS and https:// for a JMAP session resource. For IMAP, user and
+// secret are the login credentials; for JMAP, secret is a bearer token.
+func New(rawURL, user, secret string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inbox URL: %w", err)
+	}
+	switch u.Scheme {
+	case "imaps":
+		host := u.Host
+		if u.Port() == "" {
+			host += ":993"
+		}
+		mailbox := strings.Trim(u.Path, "/")
+		if mailbox == "" {
+			mailbox = "INBOX"
+		}
+		return &Client{src: &imapSource{addr: host, user: user, password: secret, mailbox: mailbox}}, nil
+	case "https":
+		return &Client{src: newJMAPSource(rawURL, secret)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported inbox URL %q: use imaps://host/mailbox or a https:// JMAP session URL", rawURL)
+	}
+}
+
+func (c *Client) Name() string { return "inbox" }
+
+func messageToTicket(m message) connector.Ticket {
+	t := connector.Ticket{
+		Key:         m.ID,
+		Summary:     strings.TrimSpace(m.Subject),
+		Description: strings.TrimSpace(m.Body),
+		Status:      "done",
+	}
+	if m.Flagged {
+		t.Status = "flagged"
+	}
+	if t.Summary == "" {
+		t.Summary = "(no subject)"
+	}
+	if m.From != "" {
+		t.Description = "From: " + m.From + "\n\n" + t.Description
+	}
+	return t
+}
+
+func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	m, err := c.src.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	t := messageToTicket(*m)
+	return &t, nil
+}
+
+func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	msgs, err := c.src.flagged(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tickets := make([]connector.Ticket, 0, len(msgs))
+	for _, m := range msgs {
+		tickets = append(tickets, messageToTicket(m))
+	}
+	return tickets, nil
+}
+
+// TransitionTicket unflags ("done") or flags ("open") a message.
+func (c *Client) TransitionTicket(ctx context.Context, key, status string) error {
+	switch strings.ToLower(status) {
+	case "done", "complete", "completed":
+		return c.src.setFlagged(ctx, key, false)
+	case "open", "flagged", "todo":
+		return c.src.setFlagged(ctx, key, true)
+	default:
+		return fmt.Errorf("inbox messages can only be marked done or open (got %q)", status)
+	}
+}
+
+func (c *Client) Validate(ctx context.Context) error {
+	if _, err := c.src.flagged(ctx); err != nil {
+		return fmt.Errorf("inbox authentication failed: %w", err)
+	}
+	return nil
+}
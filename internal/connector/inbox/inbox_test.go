@@ -0,0 +1,101 @@
+package inbox
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		subject string
+		from    string
+		body    string
+	}{
+		{
+			name: "plain",
+			raw: "From: Ana Client <ana@example.com>\r\n" +
+				"Subject: Update the pricing page\r\n" +
+				"\r\n" +
+				"Please change the prices.\r\n",
+			subject: "Update the pricing page",
+			from:    "Ana Client",
+			body:    "Please change the prices.\n",
+		},
+		{
+			name: "encoded subject and quoted-printable",
+			raw: "From: ana@example.com\r\n" +
+				"Subject: =?UTF-8?Q?Caf=C3=A9_menu?=\r\n" +
+				"Content-Type: text/plain; charset=utf-8\r\n" +
+				"Content-Transfer-Encoding: quoted-printable\r\n" +
+				"\r\n" +
+				"Add the cr=C3=A8me br=C3=BBl=C3=A9e.\r\n",
+			subject: "Café menu",
+			from:    "ana@example.com",
+			body:    "Add the crème brûlée.\n",
+		},
+		{
+			name: "multipart prefers text/plain",
+			raw: "From: ana@example.com\r\n" +
+				"Subject: Fix the footer\r\n" +
+				"Content-Type: multipart/alternative; boundary=XYZ\r\n" +
+				"\r\n" +
+				"--XYZ\r\n" +
+				"Content-Type: text/html\r\n" +
+				"\r\n" +
+				"<p>html</p>\r\n" +
+				"--XYZ\r\n" +
+				"Content-Type: text/plain\r\n" +
+				"Content-Transfer-Encoding: base64\r\n" +
+				"\r\n" +
+				"VGhlIGZvb3RlciBpcyBicm9rZW4u\r\n" +
+				"--XYZ--\r\n",
+			subject: "Fix the footer",
+			from:    "ana@example.com",
+			body:    "The footer is broken.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseMessage([]byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Subject != tt.subject || m.From != tt.from || m.Body != tt.body {
+				t.Errorf("got subject=%q from=%q body=%q", m.Subject, m.From, m.Body)
+			}
+		})
+	}
+}
+
+func TestIMAPFetch(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	raw := "Subject: Call me back\r\n\r\nAbout the invoice.\r\n"
+
+	go func() {
+		defer server.Close()
+		r := bufio.NewReader(server)
+		line, _ := r.ReadString('\n')
+		tag, _, _ := strings.Cut(line, " ")
+		server.Write([]byte("* 1 FETCH (UID 42 FLAGS (\\Seen \\Flagged) BODY[] {" + strconv.Itoa(len(raw)) + "}\r\n" + raw + ")\r\n"))
+		server.Write([]byte(tag + " OK FETCH completed\r\n"))
+	}()
+
+	c := &imapConn{conn: client, r: bufio.NewReader(client)}
+	msgs, err := c.fetch("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	m := msgs[0]
+	if m.ID != "42" || !m.Flagged || m.Subject != "Call me back" || m.Body != "About the invoice.\n" {
+		t.Errorf("unexpected message: %+v", m)
+	}
+}
@@ -0,0 +1,235 @@
+package inbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+var jmapCapabilities = []string{"urn:ietf:params:jmap:core", "urn:ietf:params:jmap:mail"}
+
+// jmapSource reads flagged messages over JMAP (RFC 8620/8621).
+type jmapSource struct {
+	sessionURL string
+	token      string
+	client     *http.Client
+
+	apiURL    string
+	accountID string
+}
+
+func newJMAPSource(sessionURL, token string) *jmapSource {
+	return &jmapSource{sessionURL: sessionURL, token: token, client: &http.Client{}}
+}
+
+func (s *jmapSource) do(ctx context.Context, method, url string, body any, v any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("JMAP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return connector.StatusError("JMAP server", resp.StatusCode, b)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode JMAP response: %w", err)
+	}
+	return nil
+}
+
+// session fetches the API URL and mail account on first use.
+func (s *jmapSource) session(ctx context.Context) error {
+	if s.apiURL != "" {
+		return nil
+	}
+	var session struct {
+		APIURL          string            `json:"apiUrl"`
+		PrimaryAccounts map[string]string `json:"primaryAccounts"`
+	}
+	if err := s.do(ctx, "GET", s.sessionURL, nil, &session); err != nil {
+		return err
+	}
+	s.accountID = session.PrimaryAccounts["urn:ietf:params:jmap:mail"]
+	if session.APIURL == "" || s.accountID == "" {
+		return fmt.Errorf("JMAP session has no mail account")
+	}
+	s.apiURL = session.APIURL
+	return nil
+}
+
+// call runs method calls and returns the arguments of each response by
+// call ID, failing on any method-level error.
+func (s *jmapSource) call(ctx context.Context, calls ...[]any) (map[string]json.RawMessage, error) {
+	if err := s.session(ctx); err != nil {
+		return nil, err
+	}
+	var result struct {
+		MethodResponses [][]json.RawMessage `json:"methodResponses"`
+	}
+	req := map[string]any{"using": jmapCapabilities, "methodCalls": calls}
+	if err := s.do(ctx, "POST", s.apiURL, req, &result); err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage)
+	for _, r := range result.MethodResponses {
+		if len(r) != 3 {
+			continue
+		}
+		var name, id string
+		json.Unmarshal(r[0], &name)
+		json.Unmarshal(r[2], &id)
+		if name == "error" {
+			var e struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal(r[1], &e)
+			return nil, fmt.Errorf("JMAP call %s failed: %s", id, e.Type)
+		}
+		out[id] = r[1]
+	}
+	return out, nil
+}
+
+type jmapEmail struct {
+	ID       string          `json:"id"`
+	Subject  string          `json:"subject"`
+	Keywords map[string]bool `json:"keywords"`
+	From     []struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"from"`
+	TextBody []struct {
+		PartID string `json:"partId"`
+	} `json:"textBody"`
+	BodyValues map[string]struct {
+		Value string `json:"value"`
+	} `json:"bodyValues"`
+}
+
+func (e jmapEmail) message() message {
+	m := message{ID: e.ID, Subject: e.Subject, Flagged: e.Keywords["$flagged"]}
+	if len(e.From) > 0 {
+		m.From = e.From[0].Name
+		if m.From == "" {
+			m.From = e.From[0].Email
+		}
+	}
+	for _, part := range e.TextBody {
+		m.Body += e.BodyValues[part.PartID].Value
+	}
+	return m
+}
+
+func (s *jmapSource) getArgs(ids any) map[string]any {
+	args := map[string]any{
+		"accountId":           s.accountID,
+		"properties":          []string{"id", "subject", "from", "keywords", "textBody", "bodyValues"},
+		"fetchTextBodyValues": true,
+	}
+	if ref, ok := ids.(map[string]any); ok {
+		args["#ids"] = ref
+	} else {
+		args["ids"] = ids
+	}
+	return args
+}
+
+func decodeEmails(raw json.RawMessage) ([]message, error) {
+	var result struct {
+		List []jmapEmail `json:"list"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode JMAP emails: %w", err)
+	}
+	msgs := make([]message, 0, len(result.List))
+	for _, e := range result.List {
+		msgs = append(msgs, e.message())
+	}
+	return msgs, nil
+}
+
+func (s *jmapSource) flagged(ctx context.Context) ([]message, error) {
+	if err := s.session(ctx); err != nil {
+		return nil, err
+	}
+	query := map[string]any{
+		"accountId": s.accountID,
+		"filter":    map[string]any{"hasKeyword": "$flagged"},
+		"sort":      []map[string]any{{"property": "receivedAt", "isAscending": false}},
+	}
+	ref := map[string]any{"resultOf": "q", "name": "Email/query", "path": "/ids"}
+	out, err := s.call(ctx,
+		[]any{"Email/query", query, "q"},
+		[]any{"Email/get", s.getArgs(ref), "g"},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return decodeEmails(out["g"])
+}
+
+func (s *jmapSource) get(ctx context.Context, id string) (*message, error) {
+	if err := s.session(ctx); err != nil {
+		return nil, err
+	}
+	out, err := s.call(ctx, []any{"Email/get", s.getArgs([]string{id}), "g"})
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := decodeEmails(out["g"])
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("email %s not found", id)
+	}
+	return &msgs[0], nil
+}
+
+func (s *jmapSource) setFlagged(ctx context.Context, id string, flagged bool) error {
+	if err := s.session(ctx); err != nil {
+		return err
+	}
+	var value any
+	if flagged {
+		value = true
+	}
+	update := map[string]any{
+		"accountId": s.accountID,
+		"update":    map[string]any{id: map[string]any{"keywords/$flagged": value}},
+	}
+	out, err := s.call(ctx, []any{"Email/set", update, "s"})
+	if err != nil {
+		return err
+	}
+	var result struct {
+		NotUpdated map[string]struct {
+			Type string `json:"type"`
+		} `json:"notUpdated"`
+	}
+	json.Unmarshal(out["s"], &result)
+	if e, ok := result.NotUpdated[id]; ok {
+		return fmt.Errorf("failed to update email %s: %s", id, e.Type)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package inbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+)
+
+var wordDecoder = new(mime.WordDecoder)
+
+// parseMessage extracts the subject, sender and plain-text body of a raw
+// RFC 5322 message.
+func parseMessage(raw []byte) (message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return message{}, fmt.Errorf("failed to parse email: %w", err)
+	}
+	m := message{Subject: decodeHeader(msg.Header.Get("Subject"))}
+	if from, err := msg.Header.AddressList("From"); err == nil && len(from) > 0 {
+		m.From = from[0].Name
+		if m.From == "" {
+			m.From = from[0].Address
+		}
+	}
+	body, err := textBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return message{}, err
+	}
+	m.Body = body
+	return m, nil
+}
+
+func decodeHeader(s string) string {
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+// textBody returns the first text/plain part of a message body.
+func textBody(contentType, encoding string, r io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to read email part: %w", err)
+			}
+			// multipart.Reader already decodes quoted-printable parts.
+			body, err := textBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+			if body != "" {
+				return body, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+	switch strings.ToLower(encoding) {
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode email body: %w", err)
+	}
+	return strings.ReplaceAll(string(b), "\r\n", "\n"), nil
+}
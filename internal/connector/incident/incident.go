@@ -0,0 +1,72 @@
+// Package incident implements connectors for incident management services,
+// PagerDuty and Opsgenie, so that hotfix tasks can be started from active
+// incidents.
+//
+// Incidents are tickets whose Priority is their severity, e.g. "P1" or, for
+// PagerDuty incidents without a priority, their urgency. Transitioning a
+// ticket acknowledges, resolves or closes the incident.
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// Names lists the incident connectors, in the order 'wt start --incident'
+// looks for a configured one.
+var Names = []string{"pagerduty", "opsgenie"}
+
+// IsIncident reports whether the connector name is an incident connector.
+func IsIncident(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Type is the ticket type of incidents.
+const Type = "Incident"
+
+// doJSON sends a request with an optional JSON body to one of the services
+// and decodes a JSON response into v, unless v is nil. auth sets the
+// service's credentials on the request.
+func doJSON(ctx context.Context, client *http.Client, service, method, url string, auth func(*http.Request), body, v any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return err
+	}
+	auth(req)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(resp.Body)
+		return connector.StatusError(service, resp.StatusCode, data)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", service, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package incident
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagerDutyGetTicket(t *testing.T) {
+	tests := []struct {
+		name         string
+		priority     string
+		wantPriority string
+	}{
+		{"with priority", `{"summary":"P1"}`, "P1"},
+		{"urgency only", `null`, "high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Token token=key" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if r.URL.Path != "/incidents/Q1ABC" {
+					http.NotFound(w, r)
+					return
+				}
+				io.WriteString(w, `{"incident":{"id":"Q1ABC","incident_number":812,"title":"Checkout 500s","description":"Checkout 500s",
+					"status":"triggered","urgency":"high","html_url":"https://co.pagerduty.com/incidents/Q1ABC",
+					"priority":`+tt.priority+`,"service":{"summary":"checkout"},"assignments":[{"assignee":{"summary":"Ana"}}]}}`)
+			}))
+			defer srv.Close()
+
+			ticket, err := NewPagerDuty(srv.URL, "key", "").GetTicket(context.Background(), "Q1ABC")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ticket.Summary != "Checkout 500s" || ticket.Description != "" || ticket.Assignee != "Ana" || ticket.Type != Type {
+				t.Errorf("unexpected ticket: %+v", ticket)
+			}
+			if ticket.Priority != tt.wantPriority {
+				t.Errorf("priority = %q, want %q", ticket.Priority, tt.wantPriority)
+			}
+			if ticket.Metadata["Service"] != "checkout" || ticket.Metadata["Number"] != "#812" {
+				t.Errorf("metadata = %v", ticket.Metadata)
+			}
+		})
+	}
+}
+
+func TestOpsgenieIncidentPath(t *testing.T) {
+	tests := []struct {
+		key, suffix, want string
+	}{
+		{"42", "", "/v1/incidents/42?identifierType=tiny"},
+		{"70413a06-38d6-4c85-92b8-5ebc900d42e2", "/resolve", "/v1/incidents/70413a06-38d6-4c85-92b8-5ebc900d42e2/resolve?identifierType=id"},
+	}
+	for _, tt := range tests {
+		if got := incidentPath(tt.key, tt.suffix); got != tt.want {
+			t.Errorf("incidentPath(%q, %q) = %s, want %s", tt.key, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func TestOpsgenieTransitionTicket(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "GenieKey key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		got = r.Method + " " + r.URL.Path
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"result":"Request will be processed"}`)
+	}))
+	defer srv.Close()
+	c := NewOpsgenie(srv.URL, "key")
+
+	if err := c.TransitionTicket(context.Background(), "42", "Done"); err != nil {
+		t.Fatal(err)
+	}
+	if got != "POST /v1/incidents/42/resolve" {
+		t.Errorf("request = %s", got)
+	}
+	if err := c.TransitionTicket(context.Background(), "42", "in progress"); err == nil {
+		t.Error("expected an error for a status incidents cannot move to")
+	}
+}
@@ -0,0 +1,149 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// OpsgenieURL is the Opsgenie REST API root; accounts in the EU use
+// https://api.eu.opsgenie.com.
+const OpsgenieURL = "https://api.opsgenie.com"
+
+// Opsgenie implements the connector.Connector interface for Opsgenie
+// incidents, keyed by their tiny IDs, e.g. 42, or their full IDs.
+type Opsgenie struct {
+	BaseURL string
+	APIKey  string
+	client  *http.Client
+}
+
+// NewOpsgenie creates an Opsgenie client. An empty baseURL means
+// OpsgenieURL.
+func NewOpsgenie(baseURL, apiKey string) *Opsgenie {
+	if baseURL == "" {
+		baseURL = OpsgenieURL
+	}
+	return &Opsgenie{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+func (c *Opsgenie) Name() string { return "opsgenie" }
+
+func (c *Opsgenie) auth(req *http.Request) {
+	req.Header.Set("Authorization", "GenieKey "+c.APIKey)
+	req.Header.Set("Accept", "application/json")
+}
+
+func (c *Opsgenie) do(ctx context.Context, method, path string, body, v any) error {
+	return doJSON(ctx, c.client, "opsgenie", method, c.BaseURL+path, c.auth, body, v)
+}
+
+// ogIncident represents the JSON structure of an Opsgenie incident.
+type ogIncident struct {
+	ID          string   `json:"id"`
+	TinyID      string   `json:"tinyId"`
+	Message     string   `json:"message"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Priority    string   `json:"priority"`
+	Tags        []string `json:"tags"`
+	UpdatedAt   string   `json:"updatedAt"`
+	Links       struct {
+		Web string `json:"web"`
+	} `json:"links"`
+}
+
+func (i ogIncident) ticket() connector.Ticket {
+	key := i.TinyID
+	if key == "" {
+		key = i.ID
+	}
+	return connector.Ticket{
+		Key:         key,
+		Summary:     i.Message,
+		Description: i.Description,
+		Status:      i.Status,
+		URL:         i.Links.Web,
+		Labels:      i.Tags,
+		Type:        Type,
+		Priority:    i.Priority,
+		Updated:     connector.ParseTime(i.UpdatedAt),
+	}
+}
+
+// incidentPath returns the API path of an incident by its tiny or full ID.
+func incidentPath(key, suffix string) string {
+	idType := "id"
+	if _, err := strconv.Atoi(key); err == nil {
+		idType = "tiny"
+	}
+	return "/v1/incidents/" + url.PathEscape(key) + suffix + "?identifierType=" + idType
+}
+
+func (c *Opsgenie) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	var result struct {
+		Data ogIncident `json:"data"`
+	}
+	if err := c.do(ctx, "GET", incidentPath(key, ""), nil, &result); err != nil {
+		return nil, err
+	}
+	t := result.Data.ticket()
+	return &t, nil
+}
+
+// ListAssigned lists the open incidents. Opsgenie incidents go to teams
+// rather than people, so these are all the ones the API key can see.
+func (c *Opsgenie) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	q := url.Values{
+		"query": {"status:open"},
+		"sort":  {"createdAt"},
+		"order": {"desc"},
+		"limit": {"50"},
+	}
+	var result struct {
+		Data []ogIncident `json:"data"`
+	}
+	if err := c.do(ctx, "GET", "/v1/incidents?"+q.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	tickets := make([]connector.Ticket, 0, len(result.Data))
+	for _, i := range result.Data {
+		tickets = append(tickets, i.ticket())
+	}
+	return tickets, nil
+}
+
+// TransitionTicket resolves ("resolved", "done") or closes ("closed") an
+// incident.
+func (c *Opsgenie) TransitionTicket(ctx context.Context, key, status string) error {
+	var action string
+	switch strings.ToLower(status) {
+	case "resolved", "resolve", "done":
+		action = "/resolve"
+	case "closed", "close":
+		action = "/close"
+	default:
+		return fmt.Errorf("opsgenie incidents can only be resolved or closed (got %q)", status)
+	}
+	body := map[string]string{"note": "Updated by wt"}
+	if err := c.do(ctx, "POST", incidentPath(key, action), body, nil); err != nil {
+		return fmt.Errorf("opsgenie transition failed: %w", err)
+	}
+	return nil
+}
+
+func (c *Opsgenie) Validate(ctx context.Context) error {
+	if err := c.do(ctx, "GET", "/v2/account", nil, nil); err != nil {
+		return fmt.Errorf("opsgenie authentication failed: %w", err)
+	}
+	return nil
+}
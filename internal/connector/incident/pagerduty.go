@@ -0,0 +1,201 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// PagerDutyURL is the PagerDuty REST API root.
+const PagerDutyURL = "https://api.pagerduty.com"
+
+// PagerDuty implements the connector.Connector interface for PagerDuty
+// incidents, keyed by their IDs, e.g. Q2A3BC4DEFGHIJ.
+type PagerDuty struct {
+	BaseURL  string
+	APIToken string
+	// Email identifies the user wt acts as. PagerDuty requires it to
+	// change incidents with an account API key, and it selects whose
+	// incidents ListAssigned returns; a user token's own user otherwise.
+	Email  string
+	client *http.Client
+}
+
+// NewPagerDuty creates a PagerDuty client. An empty baseURL means
+// PagerDutyURL.
+func NewPagerDuty(baseURL, apiToken, email string) *PagerDuty {
+	if baseURL == "" {
+		baseURL = PagerDutyURL
+	}
+	return &PagerDuty{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		APIToken: apiToken,
+		Email:    email,
+		client:   &http.Client{},
+	}
+}
+
+func (c *PagerDuty) Name() string { return "pagerduty" }
+
+func (c *PagerDuty) auth(req *http.Request) {
+	req.Header.Set("Authorization", "Token token="+c.APIToken)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	if c.Email != "" {
+		req.Header.Set("From", c.Email)
+	}
+}
+
+func (c *PagerDuty) do(ctx context.Context, method, path string, body, v any) error {
+	return doJSON(ctx, c.client, "pagerduty", method, c.BaseURL+path, c.auth, body, v)
+}
+
+// pdIncident represents the JSON structure of a PagerDuty incident.
+type pdIncident struct {
+	ID                 string `json:"id"`
+	IncidentNumber     int    `json:"incident_number"`
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	Status             string `json:"status"`
+	Urgency            string `json:"urgency"`
+	HTMLURL            string `json:"html_url"`
+	LastStatusChangeAt string `json:"last_status_change_at"`
+	Priority           *struct {
+		Summary string `json:"summary"`
+	} `json:"priority"`
+	Service struct {
+		Summary string `json:"summary"`
+	} `json:"service"`
+	Assignments []struct {
+		Assignee struct {
+			Summary string `json:"summary"`
+		} `json:"assignee"`
+	} `json:"assignments"`
+}
+
+func (i pdIncident) ticket() connector.Ticket {
+	t := connector.Ticket{
+		Key:      i.ID,
+		Summary:  i.Title,
+		Status:   i.Status,
+		URL:      i.HTMLURL,
+		Type:     Type,
+		Priority: i.Urgency,
+		Updated:  connector.ParseTime(i.LastStatusChangeAt),
+		Metadata: map[string]string{},
+	}
+	if i.Description != i.Title {
+		t.Description = i.Description
+	}
+	if i.Priority != nil && i.Priority.Summary != "" {
+		t.Priority = i.Priority.Summary
+	}
+	if len(i.Assignments) > 0 {
+		t.Assignee = i.Assignments[0].Assignee.Summary
+	}
+	if i.IncidentNumber > 0 {
+		t.Metadata["Number"] = fmt.Sprintf("#%d", i.IncidentNumber)
+	}
+	if i.Urgency != "" {
+		t.Metadata["Urgency"] = i.Urgency
+	}
+	if i.Service.Summary != "" {
+		t.Metadata["Service"] = i.Service.Summary
+	}
+	return t
+}
+
+func (c *PagerDuty) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	var result struct {
+		Incident pdIncident `json:"incident"`
+	}
+	if err := c.do(ctx, "GET", "/incidents/"+url.PathEscape(key), nil, &result); err != nil {
+		return nil, err
+	}
+	t := result.Incident.ticket()
+	return &t, nil
+}
+
+// ListAssigned lists the triggered and acknowledged incidents assigned to
+// the user.
+func (c *PagerDuty) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	userID, err := c.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{
+		"statuses[]": {"triggered", "acknowledged"},
+		"user_ids[]": {userID},
+		"sort_by":    {"created_at:desc"},
+		"limit":      {"50"},
+	}
+	var result struct {
+		Incidents []pdIncident `json:"incidents"`
+	}
+	if err := c.do(ctx, "GET", "/incidents?"+q.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	tickets := make([]connector.Ticket, 0, len(result.Incidents))
+	for _, i := range result.Incidents {
+		tickets = append(tickets, i.ticket())
+	}
+	return tickets, nil
+}
+
+// userID returns the ID of the PagerDuty user with the configured email,
+// or of the user owning the API token.
+func (c *PagerDuty) userID(ctx context.Context) (string, error) {
+	type user struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}
+	if c.Email == "" {
+		var me struct {
+			User user `json:"user"`
+		}
+		if err := c.do(ctx, "GET", "/users/me", nil, &me); err != nil {
+			return "", fmt.Errorf("failed to look up your pagerduty user (an account API key needs --email): %w", err)
+		}
+		return me.User.ID, nil
+	}
+	var result struct {
+		Users []user `json:"users"`
+	}
+	if err := c.do(ctx, "GET", "/users?query="+url.QueryEscape(c.Email), nil, &result); err != nil {
+		return "", err
+	}
+	for _, u := range result.Users {
+		if strings.EqualFold(u.Email, c.Email) {
+			return u.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no pagerduty user with email %s", c.Email)
+}
+
+// TransitionTicket acknowledges ("acknowledged", "ack") or resolves
+// ("resolved", "done") an incident.
+func (c *PagerDuty) TransitionTicket(ctx context.Context, key, status string) error {
+	switch strings.ToLower(status) {
+	case "acknowledged", "acknowledge", "ack":
+		status = "acknowledged"
+	case "resolved", "resolve", "done":
+		status = "resolved"
+	default:
+		return fmt.Errorf("pagerduty incidents can only be acknowledged or resolved (got %q)", status)
+	}
+	body := map[string]any{"incident": map[string]string{"type": "incident_reference", "status": status}}
+	if err := c.do(ctx, "PUT", "/incidents/"+url.PathEscape(key), body, nil); err != nil {
+		return fmt.Errorf("pagerduty transition failed: %w", err)
+	}
+	return nil
+}
+
+func (c *PagerDuty) Validate(ctx context.Context) error {
+	if err := c.do(ctx, "GET", "/abilities", nil, nil); err != nil {
+		return fmt.Errorf("pagerduty authentication failed: %w", err)
+	}
+	return nil
+}
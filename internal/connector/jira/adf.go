@@ -0,0 +1,135 @@
+package jira
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// descriptionText converts an issue description to text. API v2 returns
+// wiki-markup strings, which are kept as-is; v3 returns Atlassian Document
+// Format, which is converted to Markdown.
+func descriptionText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var doc adfNode
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(adfBlocks(doc.Content))
+}
+
+// adfNode is a node of an Atlassian Document Format document.
+type adfNode struct {
+	Type    string    `json:"type"`
+	Text    string    `json:"text"`
+	Content []adfNode `json:"content"`
+	Attrs   struct {
+		Level    int    `json:"level"`
+		Language string `json:"language"`
+		Order    int    `json:"order"`
+		Text     string `json:"text"`
+		URL      string `json:"url"`
+	} `json:"attrs"`
+	Marks []struct {
+		Type  string `json:"type"`
+		Attrs struct {
+			Href string `json:"href"`
+		} `json:"attrs"`
+	} `json:"marks"`
+}
+
+// adfBlocks renders block nodes separated by blank lines.
+func adfBlocks(nodes []adfNode) string {
+	var blocks []string
+	for _, n := range nodes {
+		if s := n.block(); s != "" {
+			blocks = append(blocks, s)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func (n adfNode) block() string {
+	switch n.Type {
+	case "paragraph":
+		return n.inline()
+	case "heading":
+		level := min(max(n.Attrs.Level, 1), 6)
+		return strings.Repeat("#", level) + " " + n.inline()
+	case "bulletList", "orderedList":
+		return n.list()
+	case "codeBlock":
+		return "```" + n.Attrs.Language + "\n" + n.inline() + "\n```"
+	case "blockquote":
+		return "> " + strings.ReplaceAll(adfBlocks(n.Content), "\n", "\n> ")
+	case "rule":
+		return "---"
+	case "text", "hardBreak", "mention", "emoji", "inlineCard":
+		return n.inline()
+	}
+	return adfBlocks(n.Content)
+}
+
+// list renders list items, indenting their continuation lines and nested
+// lists under the marker.
+func (n adfNode) list() string {
+	num := max(n.Attrs.Order, 1)
+	var items []string
+	for _, item := range n.Content {
+		marker := "- "
+		if n.Type == "orderedList" {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+		var parts []string
+		for _, child := range item.Content {
+			if s := child.block(); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		body := strings.Join(parts, "\n")
+		items = append(items, marker+strings.ReplaceAll(body, "\n", "\n"+strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (n adfNode) inline() string {
+	switch n.Type {
+	case "text":
+		return n.marked()
+	case "hardBreak":
+		return "\n"
+	case "mention", "emoji":
+		return n.Attrs.Text
+	case "inlineCard":
+		return n.Attrs.URL
+	}
+	var b strings.Builder
+	for _, child := range n.Content {
+		b.WriteString(child.inline())
+	}
+	return b.String()
+}
+
+// marked wraps text in the Markdown for its marks.
+func (n adfNode) marked() string {
+	s := n.Text
+	for _, m := range n.Marks {
+		switch m.Type {
+		case "code":
+			s = "`" + s + "`"
+		case "strong":
+			s = "**" + s + "**"
+		case "em":
+			s = "_" + s + "_"
+		case "strike":
+			s = "~~" + s + "~~"
+		case "link":
+			s = "[" + s + "](" + m.Attrs.Href + ")"
+		}
+	}
+	return s
+}
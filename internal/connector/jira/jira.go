@@ -6,17 +6,29 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/bakerweb/wt/internal/connector"
 )
 
+// REST API versions. Jira Cloud serves v3, which uses the Atlassian Document
+// Format for rich text; Jira Server and Data Center only serve v2.
+const (
+	APIv2 = "2"
+	APIv3 = "3"
+)
+
 // Client implements the connector.Connector interface for Jira.
 type Client struct {
 	BaseURL  string
 	Email    string
 	APIToken string
-	client   *http.Client
+	// Project restricts team queries (ListAssignedTo) to one project key.
+	Project string
+	// APIVersion is APIv2 or APIv3; empty means APIv3.
+	APIVersion string
+	client     *http.Client
 }
 
 // New creates a new Jira client.
@@ -31,24 +43,48 @@ func New(baseURL, email, apiToken string) *Client {
 
 func (c *Client) Name() string { return "jira" }
 
+// api returns the path of a REST API resource for the configured version.
+func (c *Client) api(path string) string {
+	version := c.APIVersion
+	if version == "" {
+		version = APIv3
+	}
+	return "/rest/api/" + version + path
+}
+
 func (c *Client) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// newRequest builds an authenticated JSON request to the Jira site.
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
 	url := c.BaseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(c.Email, c.APIToken)
+	if c.Email != "" {
+		req.SetBasicAuth(c.Email, c.APIToken)
+	} else {
+		// Jira Server/DC personal access tokens are bearer tokens.
+		req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	return c.client.Do(req)
+	return req, nil
 }
 
 // jiraIssue represents the JSON structure of a Jira issue.
 type jiraIssue struct {
 	Key    string `json:"key"`
 	Fields struct {
-		Summary     string `json:"summary"`
-		Description string `json:"description"`
+		Summary string `json:"summary"`
+		// Description is a string in API v2 and an ADF document in v3.
+		Description json.RawMessage `json:"description"`
 		Status      struct {
 			Name string `json:"name"`
 		} `json:"status"`
@@ -56,27 +92,103 @@ type jiraIssue struct {
 			DisplayName  string `json:"displayName"`
 			EmailAddress string `json:"emailAddress"`
 		} `json:"assignee"`
-		Labels []string `json:"labels"`
+		Labels   []string    `json:"labels"`
+		Parent   *jiraParent `json:"parent"`
+		Subtasks []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string `json:"summary"`
+			} `json:"fields"`
+		} `json:"subtasks"`
+		Comment *struct {
+			Total int `json:"total"`
+		} `json:"comment"`
+		Priority *struct {
+			Name string `json:"name"`
+		} `json:"priority"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+		Project struct {
+			ProjectTypeKey string `json:"projectTypeKey"`
+		} `json:"project"`
+		Updated    string `json:"updated"`
+		DueDate    string `json:"duedate"`
+		Attachment []struct {
+			ID       string `json:"id"`
+			Filename string `json:"filename"`
+			Size     int64  `json:"size"`
+			MimeType string `json:"mimeType"`
+			Content  string `json:"content"`
+		} `json:"attachment"`
 	} `json:"fields"`
 }
 
+// jiraParent is the parent of an issue: an epic for standard issues, or the
+// parent issue of a sub-task.
+type jiraParent struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary   string `json:"summary"`
+		IssueType struct {
+			Name           string `json:"name"`
+			HierarchyLevel int    `json:"hierarchyLevel"`
+		} `json:"issuetype"`
+	} `json:"fields"`
+}
+
+// isEpic reports whether the parent is an epic. Epics sit at hierarchy
+// level 1; the name check covers servers that omit the level.
+func (p *jiraParent) isEpic() bool {
+	return p.Fields.IssueType.HierarchyLevel == 1 || strings.EqualFold(p.Fields.IssueType.Name, "Epic")
+}
+
 func issueToTicket(issue jiraIssue, baseURL string) *connector.Ticket {
 	t := &connector.Ticket{
 		Key:         issue.Key,
 		Summary:     issue.Fields.Summary,
-		Description: issue.Fields.Description,
+		Description: descriptionText(issue.Fields.Description),
 		Status:      issue.Fields.Status.Name,
 		Labels:      issue.Fields.Labels,
 		URL:         baseURL + "/browse/" + issue.Key,
+		Type:        issue.Fields.IssueType.Name,
+		Updated:     connector.ParseTime(issue.Fields.Updated),
+		Due:         connector.ParseTime(issue.Fields.DueDate),
+	}
+	if issue.Fields.Priority != nil {
+		t.Priority = issue.Fields.Priority.Name
+	}
+	for _, a := range issue.Fields.Attachment {
+		t.Attachments = append(t.Attachments, connector.Attachment{
+			ID:       a.ID,
+			Filename: a.Filename,
+			Size:     a.Size,
+			MimeType: a.MimeType,
+			URL:      a.Content,
+		})
 	}
 	if issue.Fields.Assignee != nil {
 		t.Assignee = issue.Fields.Assignee.DisplayName
 	}
+	if p := issue.Fields.Parent; p != nil {
+		t.ParentKey = p.Key
+		t.ParentSummary = p.Fields.Summary
+		if p.isEpic() {
+			t.EpicKey = p.Key
+			t.EpicName = p.Fields.Summary
+		}
+	}
+	for _, sub := range issue.Fields.Subtasks {
+		t.Subtasks = append(t.Subtasks, connector.TicketRef{Key: sub.Key, Summary: sub.Fields.Summary})
+	}
+	if issue.Fields.Comment != nil {
+		t.Comments = issue.Fields.Comment.Total
+	}
 	return t
 }
 
 func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
-	resp, err := c.doRequest(ctx, "GET", "/rest/api/3/issue/"+key, nil)
+	resp, err := c.doRequest(ctx, "GET", c.api("/issue/"+key), nil)
 	if err != nil {
 		return nil, fmt.Errorf("jira request failed: %w", err)
 	}
@@ -84,19 +196,47 @@ func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket,
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("jira returned %d: %s", resp.StatusCode, string(body))
+		return nil, connector.StatusError("jira", resp.StatusCode, body)
 	}
 
 	var issue jiraIssue
 	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
 		return nil, fmt.Errorf("failed to decode jira response: %w", err)
 	}
-	return issueToTicket(issue, c.BaseURL), nil
+	t := issueToTicket(issue, c.BaseURL)
+	if issue.Fields.Project.ProjectTypeKey == serviceDeskProject {
+		if err := c.addRequestDetails(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
 }
 
 func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
-	jql := "assignee=currentUser() AND statusCategory != Done ORDER BY updated DESC"
-	resp, err := c.doRequest(ctx, "GET", "/rest/api/3/search?jql="+jql+"&maxResults=50", nil)
+	return c.search(ctx, "assignee=currentUser() AND statusCategory != Done ORDER BY updated DESC")
+}
+
+// ListAssignedTo lists open issues assigned to assignee, or unassigned
+// issues when assignee is empty. Results are limited to the configured
+// project when one is set.
+func (c *Client) ListAssignedTo(ctx context.Context, assignee string) ([]connector.Ticket, error) {
+	clause := "assignee is EMPTY"
+	if assignee != "" {
+		clause = "assignee = " + jqlQuote(assignee)
+	}
+	if c.Project != "" {
+		clause = "project = " + jqlQuote(c.Project) + " AND " + clause
+	}
+	return c.search(ctx, clause+" AND statusCategory != Done ORDER BY updated DESC")
+}
+
+// jqlQuote returns s as a JQL string literal.
+func jqlQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (c *Client) search(ctx context.Context, jql string) ([]connector.Ticket, error) {
+	resp, err := c.doRequest(ctx, "GET", c.api("/search?jql="+url.QueryEscape(jql)+"&maxResults=50"), nil)
 	if err != nil {
 		return nil, fmt.Errorf("jira request failed: %w", err)
 	}
@@ -104,7 +244,7 @@ func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("jira returned %d: %s", resp.StatusCode, string(body))
+		return nil, connector.StatusError("jira", resp.StatusCode, body)
 	}
 
 	var result struct {
@@ -121,6 +261,31 @@ func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
 	return tickets, nil
 }
 
+// DownloadAttachment fetches an attachment's content. Credentials are only
+// sent to the configured Jira site.
+func (c *Client) DownloadAttachment(ctx context.Context, a connector.Attachment, w io.Writer) error {
+	if !strings.HasPrefix(a.URL, c.BaseURL+"/") {
+		return fmt.Errorf("attachment %s is not hosted on %s", a.Filename, c.BaseURL)
+	}
+	req, err := c.newRequest(ctx, "GET", strings.TrimPrefix(a.URL, c.BaseURL), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "*/*")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %w", a.Filename, connector.StatusError("jira", resp.StatusCode, nil))
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download %s: %w", a.Filename, err)
+	}
+	return nil
+}
+
 // jiraTransition represents a Jira status transition.
 type jiraTransition struct {
 	ID   string `json:"id"`
@@ -132,7 +297,7 @@ type jiraTransition struct {
 
 func (c *Client) TransitionTicket(ctx context.Context, key, status string) error {
 	// First, get available transitions
-	resp, err := c.doRequest(ctx, "GET", "/rest/api/3/issue/"+key+"/transitions", nil)
+	resp, err := c.doRequest(ctx, "GET", c.api("/issue/"+key+"/transitions"), nil)
 	if err != nil {
 		return fmt.Errorf("failed to get transitions: %w", err)
 	}
@@ -164,7 +329,7 @@ func (c *Client) TransitionTicket(ctx context.Context, key, status string) error
 
 	// Execute transition
 	body := fmt.Sprintf(`{"transition":{"id":"%s"}}`, transitionID)
-	resp2, err := c.doRequest(ctx, "POST", "/rest/api/3/issue/"+key+"/transitions", strings.NewReader(body))
+	resp2, err := c.doRequest(ctx, "POST", c.api("/issue/"+key+"/transitions"), strings.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to transition issue: %w", err)
 	}
@@ -172,19 +337,42 @@ func (c *Client) TransitionTicket(ctx context.Context, key, status string) error
 
 	if resp2.StatusCode != http.StatusNoContent && resp2.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp2.Body)
-		return fmt.Errorf("jira transition failed with %d: %s", resp2.StatusCode, string(respBody))
+		return fmt.Errorf("jira transition failed: %w", connector.StatusError("jira", resp2.StatusCode, respBody))
 	}
 	return nil
 }
 
 func (c *Client) Validate(ctx context.Context) error {
-	resp, err := c.doRequest(ctx, "GET", "/rest/api/3/myself", nil)
+	resp, err := c.doRequest(ctx, "GET", c.api("/myself"), nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to jira: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("jira authentication failed (status %d)", resp.StatusCode)
+		return fmt.Errorf("jira authentication failed (status %d): %w", resp.StatusCode, connector.ErrAuth)
 	}
 	return nil
 }
+
+// DetectAPIVersion asks the server whether it is Jira Cloud or Jira
+// Server/Data Center and returns the REST API version to use.
+func (c *Client) DetectAPIVersion(ctx context.Context) (string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/rest/api/2/serverInfo", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to jira: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("jira server info request failed (status %d)", resp.StatusCode)
+	}
+	var info struct {
+		DeploymentType string `json:"deploymentType"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", fmt.Errorf("failed to decode jira server info: %w", err)
+	}
+	if strings.EqualFold(info.DeploymentType, "Cloud") {
+		return APIv3, nil
+	}
+	return APIv2, nil
+}
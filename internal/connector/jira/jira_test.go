@@ -0,0 +1,244 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/connector/connectortest"
+)
+
+func TestDescriptionText(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"v2 string", `"Fix the *login* page"`, "Fix the *login* page"},
+		{"null", `null`, ""},
+		{
+			"v3 document",
+			`{"type":"doc","content":[
+				{"type":"paragraph","content":[{"type":"text","text":"First line"},{"type":"hardBreak"},{"type":"text","text":"second"}]},
+				{"type":"bulletList","content":[
+					{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"one"}]}]},
+					{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"two"}]}]}
+				]}
+			]}`,
+			"First line\nsecond\n\n- one\n- two",
+		},
+		{
+			"v3 markup",
+			`{"type":"doc","content":[
+				{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Steps"}]},
+				{"type":"orderedList","content":[
+					{"type":"listItem","content":[
+						{"type":"paragraph","content":[{"type":"text","text":"Run "},{"type":"text","text":"make","marks":[{"type":"code"}]}]},
+						{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"see ","marks":[]},{"type":"text","text":"docs","marks":[{"type":"link","attrs":{"href":"https://x.test"}}]}]}]}]}
+					]},
+					{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"Done","marks":[{"type":"strong"}]}]}]}
+				]},
+				{"type":"codeBlock","attrs":{"language":"sh"},"content":[{"type":"text","text":"go test ./..."}]}
+			]}`,
+			"## Steps\n\n1. Run `make`\n   - see [docs](https://x.test)\n2. **Done**\n\n```sh\ngo test ./...\n```",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := descriptionText(json.RawMessage(tt.raw)); got != tt.want {
+				t.Errorf("descriptionText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectAPIVersion(t *testing.T) {
+	tests := []struct {
+		deployment string
+		want       string
+	}{
+		{"Cloud", APIv3},
+		{"Server", APIv2},
+		{"DataCenter", APIv2},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/rest/api/2/serverInfo" {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, `{"deploymentType":"`+tt.deployment+`"}`)
+		}))
+		got, err := New(srv.URL, "", "token").DetectAPIVersion(context.Background())
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("DetectAPIVersion() for %s = %s, want %s", tt.deployment, got, tt.want)
+		}
+	}
+}
+
+func TestAPIPath(t *testing.T) {
+	c := New("https://jira.example.com", "", "token")
+	if got := c.api("/myself"); got != "/rest/api/3/myself" {
+		t.Errorf("default api path = %s", got)
+	}
+	c.APIVersion = APIv2
+	if got := c.api("/issue/ABC-1"); got != "/rest/api/2/issue/ABC-1" {
+		t.Errorf("v2 api path = %s", got)
+	}
+}
+
+func TestListAssignedTo(t *testing.T) {
+	tests := []struct {
+		assignee string
+		project  string
+		want     string
+	}{
+		{"ana@example.com", "", `assignee = "ana@example.com" AND statusCategory != Done ORDER BY updated DESC`},
+		{"", "PROJ", `project = "PROJ" AND assignee is EMPTY AND statusCategory != Done ORDER BY updated DESC`},
+	}
+	for _, tt := range tests {
+		var jql string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			jql = r.URL.Query().Get("jql")
+			io.WriteString(w, `{"issues":[{"key":"PROJ-1","fields":{"summary":"one"}}]}`)
+		}))
+		c := New(srv.URL, "", "token")
+		c.Project = tt.project
+		tickets, err := c.ListAssignedTo(context.Background(), tt.assignee)
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jql != tt.want {
+			t.Errorf("jql = %q, want %q", jql, tt.want)
+		}
+		if len(tickets) != 1 || tickets[0].Key != "PROJ-1" {
+			t.Errorf("unexpected tickets: %+v", tickets)
+		}
+	}
+}
+
+func TestDownloadAttachment(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, "PNG")
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "", "token")
+
+	var b strings.Builder
+	a := connector.Attachment{Filename: "shot.png", URL: srv.URL + "/rest/api/3/attachment/content/1"}
+	if err := c.DownloadAttachment(context.Background(), a, &b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "PNG" {
+		t.Errorf("downloaded %q, want PNG", b.String())
+	}
+
+	a.URL = "https://elsewhere.example.com/file.png"
+	if err := c.DownloadAttachment(context.Background(), a, &b); err == nil {
+		t.Error("expected credentials to be withheld from another host")
+	}
+}
+
+func TestGetTicketServiceDeskRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/3/issue/OPS-1":
+			io.WriteString(w, `{"key":"OPS-1","fields":{"summary":"Checkout is down","project":{"projectTypeKey":"service_desk"}}}`)
+		case "/rest/servicedeskapi/request/OPS-1":
+			io.WriteString(w, `{
+				"requestType":{"name":"Report an incident"},
+				"reporter":{"displayName":"Dana Customer"},
+				"requestFieldValues":[
+					{"fieldId":"summary","label":"What's wrong?","value":"Checkout is down"},
+					{"fieldId":"customfield_10050","label":"Impact","value":{"value":"High"}},
+					{"fieldId":"customfield_10051","label":"Affected services","value":[{"name":"checkout"},{"name":"payments"}]},
+					{"fieldId":"customfield_10052","label":"Region","value":{"value":"EU","child":{"value":"Frankfurt"}}},
+					{"fieldId":"customfield_10053","label":"Notes","value":null}
+				],
+				"sla":{"values":[
+					{"name":"Time to resolution","ongoingCycle":{"breached":false,"paused":false,"remainingTime":{"friendly":"3h 20m"}}},
+					{"name":"Time to first response","completedCycles":[{"breached":true}]},
+					{"name":"Time to close"}
+				]}
+			}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ticket, err := New(srv.URL, "", "token").GetTicket(context.Background(), "OPS-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"Request type":               "Report an incident",
+		"Customer":                   "Dana Customer",
+		"Impact":                     "High",
+		"Affected services":          "checkout, payments",
+		"Region":                     "EU / Frankfurt",
+		"SLA Time to resolution":     "3h 20m left",
+		"SLA Time to first response": "breached",
+	}
+	if len(ticket.Metadata) != len(want) {
+		t.Errorf("metadata = %v, want %v", ticket.Metadata, want)
+	}
+	for k, v := range want {
+		if ticket.Metadata[k] != v {
+			t.Errorf("metadata[%q] = %q, want %q", k, ticket.Metadata[k], v)
+		}
+	}
+}
+
+func TestGetTicketNotARequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/3/issue/OPS-2" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"key":"OPS-2","fields":{"summary":"Internal task","project":{"projectTypeKey":"service_desk"}}}`)
+	}))
+	defer srv.Close()
+
+	ticket, err := New(srv.URL, "", "token").GetTicket(context.Background(), "OPS-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.Metadata != nil {
+		t.Errorf("metadata = %v, want none", ticket.Metadata)
+	}
+}
+
+func TestContract(t *testing.T) {
+	connectortest.Suite{
+		New:      func(url string) connector.Connector { return New(url, "sam@example.com", "token") },
+		Fixtures: connectortest.LoadFixtures(t, "testdata"),
+		Key:      "PROJ-123",
+		Want: connector.Ticket{
+			Key:         "PROJ-123",
+			Summary:     "Checkout fails for guest users",
+			Description: "Guests get a 500 on the payment step.",
+			Status:      "In Progress",
+			Assignee:    "Sam Lee",
+			Labels:      []string{"checkout", "payments"},
+			Priority:    "High",
+			Type:        "Bug",
+			Comments:    2,
+		},
+		Transition: "In Review",
+	}.Run(t)
+}
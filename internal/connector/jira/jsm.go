@@ -0,0 +1,169 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// serviceDeskProject is the project type of Jira Service Management
+// projects, whose issues are also customer requests.
+const serviceDeskProject = "service_desk"
+
+// jsmRequest is a customer request from the Jira Service Management API,
+// with its request type and SLAs expanded.
+type jsmRequest struct {
+	RequestType struct {
+		Name string `json:"name"`
+	} `json:"requestType"`
+	Reporter *struct {
+		DisplayName string `json:"displayName"`
+	} `json:"reporter"`
+	RequestFieldValues []struct {
+		FieldID string          `json:"fieldId"`
+		Label   string          `json:"label"`
+		Value   json.RawMessage `json:"value"`
+	} `json:"requestFieldValues"`
+	SLA struct {
+		Values []jsmSLA `json:"values"`
+	} `json:"sla"`
+}
+
+// jsmSLA is one of a request's SLAs: its current cycle, if it is running,
+// and the cycles already completed.
+type jsmSLA struct {
+	Name         string `json:"name"`
+	OngoingCycle *struct {
+		Breached      bool `json:"breached"`
+		Paused        bool `json:"paused"`
+		RemainingTime struct {
+			Friendly string `json:"friendly"`
+		} `json:"remainingTime"`
+	} `json:"ongoingCycle"`
+	CompletedCycles []struct {
+		Breached bool `json:"breached"`
+	} `json:"completedCycles"`
+}
+
+// status describes the SLA as it stands, e.g. "2h 15m left" or "breached",
+// or returns "" when it has not started.
+func (s jsmSLA) status() string {
+	if c := s.OngoingCycle; c != nil {
+		remaining := c.RemainingTime.Friendly
+		switch {
+		case c.Breached:
+			return "breached (" + remaining + ")"
+		case c.Paused:
+			return "paused, " + remaining + " left"
+		}
+		return remaining + " left"
+	}
+	if n := len(s.CompletedCycles); n > 0 {
+		if s.CompletedCycles[n-1].Breached {
+			return "breached"
+		}
+		return "met"
+	}
+	return ""
+}
+
+// addRequestDetails adds what the service desk knows of a customer request
+// to the ticket's metadata: its request type, the customer, the fields of
+// the request form and the SLAs. Issues that are not customer requests,
+// such as ones agents create without a request type, are left alone.
+func (c *Client) addRequestDetails(ctx context.Context, t *connector.Ticket) error {
+	resp, err := c.doRequest(ctx, "GET", "/rest/servicedeskapi/request/"+t.Key+"?expand=requestType,sla", nil)
+	if err != nil {
+		return fmt.Errorf("jira request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return connector.StatusError("jira", resp.StatusCode, body)
+	}
+
+	var req jsmRequest
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode jira service desk response: %w", err)
+	}
+	meta := map[string]string{}
+	if req.RequestType.Name != "" {
+		meta["Request type"] = req.RequestType.Name
+	}
+	if req.Reporter != nil && req.Reporter.DisplayName != "" {
+		meta["Customer"] = req.Reporter.DisplayName
+	}
+	for _, f := range req.RequestFieldValues {
+		switch f.FieldID {
+		case "summary", "description", "attachment":
+			// Already on the ticket.
+			continue
+		}
+		if v := fieldText(f.Value); v != "" && f.Label != "" {
+			meta[f.Label] = v
+		}
+	}
+	for _, s := range req.SLA.Values {
+		if v := s.status(); v != "" {
+			meta["SLA "+s.Name] = v
+		}
+	}
+	if len(meta) > 0 {
+		t.Metadata = meta
+	}
+	return nil
+}
+
+// fieldText returns the value of a request form field as text: options by
+// their names, lists joined with commas and rich text as markdown.
+func fieldText(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	return valueText(v)
+}
+
+func valueText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		var parts []string
+		for _, item := range v {
+			if s := valueText(item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		if v["type"] == "doc" {
+			raw, _ := json.Marshal(v)
+			return descriptionText(raw)
+		}
+		for _, key := range []string{"value", "name", "displayName", "label"} {
+			if s, ok := v[key].(string); ok && s != "" {
+				// Cascading selects hold the second level as a child.
+				if child := valueText(v["child"]); child != "" {
+					return s + " / " + child
+				}
+				return s
+			}
+		}
+	}
+	return ""
+}
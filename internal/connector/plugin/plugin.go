@@ -0,0 +1,136 @@
+// Package plugin runs connectors implemented as external executables.
+//
+// A plugin is any executable on PATH named wt-connector-<name>. For every
+// Connector method wt starts the plugin, writes one JSON request to its
+// stdin and reads one JSON response from its stdout:
+//
+//	request:  {"method": "get_ticket", "params": {"key": "ABC-1"}, "config": {...}}
+//	response: {"result": {...}} or {"error": "message"}
+//
+// Methods are get_ticket (params: key; result: ticket), list_assigned
+// (result: array of tickets), transition_ticket (params: key, status) and
+// validate. Tickets use the JSON field names of connector.Ticket; config
+// carries the plugin's entry from the connectors section of the config file.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// Prefix is the executable name prefix that identifies connector plugins.
+const Prefix = "wt-connector-"
+
+// Client implements connector.Connector by invoking a plugin executable.
+type Client struct {
+	name     string
+	path     string
+	settings any
+}
+
+// New creates a client for the plugin executable at path. Settings are sent
+// to the plugin as the "config" field of every request.
+func New(name, path string, settings any) *Client {
+	return &Client{name: name, path: path, settings: settings}
+}
+
+func (c *Client) Name() string { return c.name }
+
+type request struct {
+	Method string         `json:"method"`
+	Params map[string]any `json:"params,omitempty"`
+	Config any            `json:"config,omitempty"`
+}
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error"`
+}
+
+func (c *Client) call(ctx context.Context, method string, params map[string]any, result any) error {
+	in, err := json.Marshal(request{Method: method, Params: params, Config: c.settings})
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("plugin %s failed: %s\n%s", c.name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("plugin %s returned invalid JSON: %w", c.name, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%s: %s", c.name, resp.Error)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s response from plugin %s: %w", method, c.name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	var t connector.Ticket
+	if err := c.call(ctx, "get_ticket", map[string]any{"key": key}, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	var tickets []connector.Ticket
+	if err := c.call(ctx, "list_assigned", nil, &tickets); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (c *Client) TransitionTicket(ctx context.Context, key, status string) error {
+	return c.call(ctx, "transition_ticket", map[string]any{"key": key, "status": status}, nil)
+}
+
+func (c *Client) Validate(ctx context.Context) error {
+	return c.call(ctx, "validate", nil, nil)
+}
+
+// Discover finds connector plugins on PATH, returning plugin names mapped to
+// executable paths. Earlier PATH entries win, as with command lookup.
+func Discover() map[string]string {
+	found := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), Prefix)
+			if !ok || name == "" {
+				continue
+			}
+			if _, seen := found[name]; seen {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if fi, err := os.Stat(path); err != nil || fi.IsDir() || fi.Mode()&0o111 == 0 {
+				continue
+			}
+			found[name] = path
+		}
+	}
+	return found
+}
@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const fakePlugin = `#!/bin/sh
+req=$(cat)
+case "$req" in
+  *'"method":"get_ticket"'*'"url":"https://tracker.example"'*)
+    echo '{"result":{"key":"T-1","summary":"Fix the thing","status":"Open"}}' ;;
+  *'"method":"list_assigned"'*)
+    echo '{"result":[{"key":"T-1","summary":"a"},{"key":"T-2","summary":"b"}]}' ;;
+  *)
+    echo '{"error":"unsupported request"}' ;;
+esac
+`
+
+func writePlugin(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, Prefix+name)
+	if err := os.WriteFile(path, []byte(fakePlugin), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClientCalls(t *testing.T) {
+	path := writePlugin(t, t.TempDir(), "fake")
+	c := New("fake", path, map[string]string{"url": "https://tracker.example"})
+	ctx := context.Background()
+
+	ticket, err := c.GetTicket(ctx, "T-1")
+	if err != nil {
+		t.Fatalf("GetTicket failed: %v", err)
+	}
+	if ticket.Key != "T-1" || ticket.Summary != "Fix the thing" || ticket.Status != "Open" {
+		t.Errorf("unexpected ticket: %+v", ticket)
+	}
+
+	tickets, err := c.ListAssigned(ctx)
+	if err != nil {
+		t.Fatalf("ListAssigned failed: %v", err)
+	}
+	if len(tickets) != 2 {
+		t.Errorf("expected 2 tickets, got %d", len(tickets))
+	}
+
+	if err := c.Validate(ctx); err == nil || err.Error() != "fake: unsupported request" {
+		t.Errorf("expected plugin error, got %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	want := writePlugin(t, first, "acme")
+	writePlugin(t, second, "acme")
+	writePlugin(t, second, "other")
+	// Not executable: ignored.
+	if err := os.WriteFile(filepath.Join(second, Prefix+"noexec"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+
+	found := Discover()
+	if len(found) != 2 {
+		t.Fatalf("expected 2 plugins, got %v", found)
+	}
+	if found["acme"] != want {
+		t.Errorf("expected first PATH entry to win, got %q", found["acme"])
+	}
+}
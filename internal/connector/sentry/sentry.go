@@ -0,0 +1,299 @@
+// Package sentry implements a connector for Sentry issues.
+//
+// Tickets are issues keyed by their short IDs, e.g. BACKEND-1A2, or their
+// numeric IDs. A ticket's description summarizes the error: where it was
+// raised and the stack trace of its latest event. Transitioning a ticket to
+// "resolved" (or "done") resolves the issue; "unresolved" reopens it.
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/connector"
+)
+
+// DefaultURL is sentry.io; self-hosted Sentry is configured with its own URL.
+const DefaultURL = "https://sentry.io"
+
+// maxFrames is how many stack frames a stack trace summary shows.
+const maxFrames = 10
+
+// Client implements the connector.Connector interface for Sentry.
+type Client struct {
+	BaseURL string
+	Token   string
+	// Organization is the slug of the organization the issues belong to.
+	Organization string
+	client       *http.Client
+}
+
+// New creates a Sentry client. An empty baseURL means DefaultURL.
+func New(baseURL, token, organization string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		Token:        token,
+		Organization: organization,
+		client:       &http.Client{},
+	}
+}
+
+func (c *Client) Name() string { return "sentry" }
+
+// do sends a request to the organization's API with an optional JSON body,
+// and decodes the JSON response into v unless v is nil.
+func (c *Client) do(ctx context.Context, method, path string, body, v any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	u := c.BaseURL + "/api/0/organizations/" + url.PathEscape(c.Organization) + path
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sentry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return connector.StatusError("sentry", resp.StatusCode, data)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode sentry response: %w", err)
+	}
+	return nil
+}
+
+// issue represents the JSON structure of a Sentry issue.
+type issue struct {
+	ID         string `json:"id"`
+	ShortID    string `json:"shortId"`
+	Title      string `json:"title"`
+	Culprit    string `json:"culprit"`
+	Permalink  string `json:"permalink"`
+	Status     string `json:"status"`
+	Level      string `json:"level"`
+	Priority   string `json:"priority"`
+	Count      string `json:"count"`
+	UserCount  int    `json:"userCount"`
+	FirstSeen  string `json:"firstSeen"`
+	LastSeen   string `json:"lastSeen"`
+	AssignedTo *struct {
+		Name string `json:"name"`
+	} `json:"assignedTo"`
+	Project struct {
+		Slug string `json:"slug"`
+	} `json:"project"`
+}
+
+func (i issue) ticket() connector.Ticket {
+	t := connector.Ticket{
+		Key:      i.ShortID,
+		Summary:  i.Title,
+		Status:   i.Status,
+		URL:      i.Permalink,
+		Type:     i.Level,
+		Priority: i.Priority,
+		Updated:  connector.ParseTime(i.LastSeen),
+		Metadata: map[string]string{},
+	}
+	if t.Key == "" {
+		t.Key = i.ID
+	}
+	if i.AssignedTo != nil {
+		t.Assignee = i.AssignedTo.Name
+	}
+	for label, v := range map[string]string{
+		"Culprit":    i.Culprit,
+		"Project":    i.Project.Slug,
+		"Events":     i.Count,
+		"First seen": i.FirstSeen,
+	} {
+		if v != "" {
+			t.Metadata[label] = v
+		}
+	}
+	if i.UserCount > 0 {
+		t.Metadata["Users"] = strconv.Itoa(i.UserCount)
+	}
+	return t
+}
+
+// event is the part of a Sentry event that describes its exception.
+type event struct {
+	Entries []struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	} `json:"entries"`
+}
+
+type exception struct {
+	Values []struct {
+		Type       string `json:"type"`
+		Value      string `json:"value"`
+		Stacktrace *struct {
+			Frames []frame `json:"frames"`
+		} `json:"stacktrace"`
+	} `json:"values"`
+}
+
+type frame struct {
+	Filename string `json:"filename"`
+	Function string `json:"function"`
+	LineNo   int    `json:"lineNo"`
+	InApp    bool   `json:"inApp"`
+}
+
+func (f frame) String() string {
+	loc := f.Filename
+	if f.LineNo > 0 {
+		loc += ":" + strconv.Itoa(f.LineNo)
+	}
+	if f.Function == "" {
+		return loc
+	}
+	return f.Function + " (" + loc + ")"
+}
+
+// stackTrace summarizes the exception of an event: the exception raised
+// last, then its innermost frames first, leaving out library frames when
+// the application's own are known.
+func (e event) stackTrace() string {
+	for _, entry := range e.Entries {
+		if entry.Type != "exception" {
+			continue
+		}
+		var exc exception
+		if err := json.Unmarshal(entry.Data, &exc); err != nil || len(exc.Values) == 0 {
+			return ""
+		}
+		// Chained exceptions are listed in the order they were raised.
+		v := exc.Values[len(exc.Values)-1]
+		var b strings.Builder
+		b.WriteString(v.Type)
+		if v.Value != "" {
+			b.WriteString(": " + v.Value)
+		}
+		b.WriteString("\n")
+		if v.Stacktrace == nil {
+			return b.String()
+		}
+		frames := v.Stacktrace.Frames
+		var inApp []frame
+		for _, f := range frames {
+			if f.InApp {
+				inApp = append(inApp, f)
+			}
+		}
+		if len(inApp) > 0 {
+			frames = inApp
+		}
+		// Frames run from the outermost call to the one that raised.
+		shown := 0
+		for i := len(frames) - 1; i >= 0 && shown < maxFrames; i-- {
+			fmt.Fprintf(&b, "  at %s\n", frames[i])
+			shown++
+		}
+		if rest := len(frames) - shown; rest > 0 {
+			fmt.Fprintf(&b, "  ... %d more\n", rest)
+		}
+		return b.String()
+	}
+	return ""
+}
+
+// description returns a ticket description summarizing the issue and the
+// stack trace of its latest event.
+func description(i issue, trace string) string {
+	var b strings.Builder
+	if i.Culprit != "" {
+		fmt.Fprintf(&b, "Raised in `%s`.\n", i.Culprit)
+	}
+	if trace != "" {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "```\n%s```\n", trace)
+	}
+	return b.String()
+}
+
+func (c *Client) GetTicket(ctx context.Context, key string) (*connector.Ticket, error) {
+	var i issue
+	if err := c.do(ctx, "GET", "/issues/"+url.PathEscape(key)+"/", nil, &i); err != nil {
+		return nil, err
+	}
+	var e event
+	if err := c.do(ctx, "GET", "/issues/"+url.PathEscape(i.ID)+"/events/latest/", nil, &e); err != nil {
+		return nil, fmt.Errorf("failed to fetch the latest event of %s: %w", key, err)
+	}
+	t := i.ticket()
+	t.Description = description(i, e.stackTrace())
+	return &t, nil
+}
+
+// ListAssigned lists the unresolved issues assigned to the current user.
+func (c *Client) ListAssigned(ctx context.Context) ([]connector.Ticket, error) {
+	q := url.Values{"query": {"is:unresolved assigned:me"}, "sort": {"date"}, "limit": {"50"}}
+	var issues []issue
+	if err := c.do(ctx, "GET", "/issues/?"+q.Encode(), nil, &issues); err != nil {
+		return nil, err
+	}
+	tickets := make([]connector.Ticket, 0, len(issues))
+	for _, i := range issues {
+		tickets = append(tickets, i.ticket())
+	}
+	return tickets, nil
+}
+
+// TransitionTicket resolves ("resolved", "done"), reopens ("unresolved",
+// "open") or ignores ("ignored") an issue.
+func (c *Client) TransitionTicket(ctx context.Context, key, status string) error {
+	switch strings.ToLower(status) {
+	case "resolved", "resolve", "done":
+		status = "resolved"
+	case "unresolved", "open", "reopen":
+		status = "unresolved"
+	case "ignored", "ignore", "archived":
+		status = "ignored"
+	default:
+		return fmt.Errorf("sentry issues can only be resolved, reopened or ignored (got %q)", status)
+	}
+	if err := c.do(ctx, "PUT", "/issues/"+url.PathEscape(key)+"/", map[string]string{"status": status}, nil); err != nil {
+		return fmt.Errorf("sentry transition failed: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) Validate(ctx context.Context) error {
+	if c.Organization == "" {
+		return fmt.Errorf("no sentry organization configured")
+	}
+	if err := c.do(ctx, "GET", "/", nil, nil); err != nil {
+		return fmt.Errorf("sentry authentication failed: %w", err)
+	}
+	return nil
+}
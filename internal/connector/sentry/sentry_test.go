@@ -0,0 +1,106 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/connector"
+	"github.com/bakerweb/wt/internal/connector/connectortest"
+)
+
+func TestStackTrace(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  string
+	}{
+		{
+			"in-app frames, innermost first",
+			`{"entries":[{"type":"message","data":{}},{"type":"exception","data":{"values":[
+				{"type":"KeyError","value":"'id'"},
+				{"type":"ValueError","value":"bad input","stacktrace":{"frames":[
+					{"filename":"django/core/handlers.py","function":"get_response","lineNo":10,"inApp":false},
+					{"filename":"app/views.py","function":"checkout","lineNo":42,"inApp":true},
+					{"filename":"app/cart.py","function":"total","lineNo":7,"inApp":true}
+				]}}
+			]}}]}`,
+			"ValueError: bad input\n  at total (app/cart.py:7)\n  at checkout (app/views.py:42)\n",
+		},
+		{
+			"no stack trace",
+			`{"entries":[{"type":"exception","data":{"values":[{"type":"Timeout"}]}}]}`,
+			"Timeout\n",
+		},
+		{"no exception", `{"entries":[{"type":"message","data":{}}]}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e event
+			if err := json.Unmarshal([]byte(tt.event), &e); err != nil {
+				t.Fatal(err)
+			}
+			if got := e.stackTrace(); got != tt.want {
+				t.Errorf("stackTrace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTicket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/0/organizations/acme/issues/BACKEND-1A2/":
+			io.WriteString(w, `{"id":"4321","shortId":"BACKEND-1A2","title":"ValueError: bad input","culprit":"app.views in checkout",
+				"permalink":"https://acme.sentry.io/issues/4321/","status":"unresolved","level":"error","count":"120","userCount":3}`)
+		case "/api/0/organizations/acme/issues/4321/events/latest/":
+			io.WriteString(w, `{"entries":[{"type":"exception","data":{"values":[{"type":"ValueError","value":"bad input"}]}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ticket, err := New(srv.URL, "token", "acme").GetTicket(context.Background(), "BACKEND-1A2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.Key != "BACKEND-1A2" || ticket.Summary != "ValueError: bad input" || ticket.Type != "error" {
+		t.Errorf("unexpected ticket: %+v", ticket)
+	}
+	if ticket.Metadata["Culprit"] != "app.views in checkout" || ticket.Metadata["Users"] != "3" {
+		t.Errorf("metadata = %v", ticket.Metadata)
+	}
+	want := "Raised in `app.views in checkout`.\n\n```\nValueError: bad input\n```\n"
+	if ticket.Description != want {
+		t.Errorf("description = %q, want %q", ticket.Description, want)
+	}
+	if !strings.HasPrefix(ticket.URL, "https://acme.sentry.io/") {
+		t.Errorf("url = %s", ticket.URL)
+	}
+}
+
+func TestContract(t *testing.T) {
+	connectortest.Suite{
+		New:      func(url string) connector.Connector { return New(url, "token", "acme") },
+		Fixtures: connectortest.LoadFixtures(t, "testdata"),
+		Key:      "BACKEND-1A2",
+		Want: connector.Ticket{
+			Key:         "BACKEND-1A2",
+			Summary:     "ValueError: bad input",
+			Description: "Raised in `app.views in checkout`.\n\n```\nValueError: bad input\n  at checkout (app/views.py:42)\n```\n",
+			Priority:    "high",
+			Assignee:    "Sam Lee",
+		},
+		MissingKey: "BACKEND-0",
+		Transition: "resolved",
+	}.Run(t)
+}
@@ -0,0 +1,98 @@
+// Package direnv generates .envrc files so task environments activate
+// automatically when entering a worktree.
+package direnv
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// FileName is the name of the file direnv loads.
+const FileName = ".envrc"
+
+// DefaultTemplate exports every task environment variable.
+const DefaultTemplate = `# Generated by wt for task {{ .TaskID }}
+{{- range $key, $value := .Env }}
+export {{ $key }}={{ quote $value }}
+{{- end }}
+`
+
+// Data is the value passed to the .envrc template.
+type Data struct {
+	TaskID      string
+	Description string
+	Branch      string
+	Worktree    string
+	TicketKey   string
+	// Port is the port allocated to the task, or 0 if none was; Env has
+	// it as WT_PORT.
+	Port int
+	Env  map[string]string
+}
+
+// Render executes the template with the given data.
+// An empty template falls back to DefaultTemplate.
+func Render(tmpl string, data Data) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	t, err := template.New(FileName).Funcs(template.FuncMap{"quote": Quote}).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse envrc template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render envrc template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// LoadTemplate reads a template file. An empty path returns DefaultTemplate.
+func LoadTemplate(path string) (string, error) {
+	if path == "" {
+		return DefaultTemplate, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read envrc template: %w", err)
+	}
+	return string(data), nil
+}
+
+// Write renders the template into dir/.envrc. An existing .envrc (for example
+// one tracked by the repository) is left untouched and false is returned.
+func Write(dir, tmpl string, data Data) (bool, error) {
+	path := filepath.Join(dir, FileName)
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+	content, err := Render(tmpl, data)
+	if err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// Allow runs `direnv allow` in dir. It is a no-op if direnv is not installed.
+func Allow(dir string) error {
+	if _, err := exec.LookPath("direnv"); err != nil {
+		return nil
+	}
+	cmd := exec.Command("direnv", "allow", dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("direnv allow failed: %s\n%s", err, string(out))
+	}
+	return nil
+}
+
+// Quote returns s as a single-quoted shell word.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
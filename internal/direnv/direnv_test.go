@@ -0,0 +1,46 @@
+package direnv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderDefaultTemplate(t *testing.T) {
+	got, err := Render("", Data{
+		TaskID: "wt-abc12345",
+		Env: map[string]string{
+			"WT_TASK_ID":        "wt-abc12345",
+			"WT_TICKET_SUMMARY": "it's done",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	expected := "# Generated by wt for task wt-abc12345\n" +
+		"export WT_TASK_ID='wt-abc12345'\n" +
+		"export WT_TICKET_SUMMARY='it'\\''s done'\n"
+	if got != expected {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestWriteKeepsExistingEnvrc(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte("use flake\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := Write(dir, "", Data{TaskID: "wt-1"})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if written {
+		t.Error("expected existing .envrc to be left alone")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "use flake\n" {
+		t.Errorf("existing .envrc was modified: %q", data)
+	}
+}
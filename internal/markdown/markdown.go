@@ -0,0 +1,223 @@
+// Package markdown renders Markdown for display in a terminal.
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI styles used when rendering in color.
+const (
+	reset     = "\033[0m"
+	bold      = "\033[1m"
+	dim       = "\033[2m"
+	italic    = "\033[3m"
+	underline = "\033[4m"
+	strike    = "\033[9m"
+	cyan      = "\033[36m"
+	magenta   = "\033[35m"
+)
+
+// Options controls rendering.
+type Options struct {
+	// Width wraps paragraphs and list items; 0 disables wrapping.
+	Width int
+	// Color enables ANSI styling; without it markup is reduced to plain text.
+	Color bool
+}
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	listRe    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	ruleRe    = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	fenceRe   = regexp.MustCompile("^\\s*(```|~~~)\\s*(\\S*)")
+	linkRe    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	strongRe  = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	emRe      = regexp.MustCompile(`\*([^*\s][^*]*)\*|(^|[^\w])_([^_\s][^_]*)_([^\w]|$)`)
+	strikeRe  = regexp.MustCompile(`~~([^~]+)~~`)
+	ansiRe    = regexp.MustCompile("\033\\[[0-9;]*m")
+)
+
+// Render formats Markdown source: headings, emphasis, inline code, fenced
+// code blocks, lists, block quotes, rules and links.
+func Render(src string, opts Options) string {
+	r := renderer{opts: opts}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			r.codeBlock(m[2], code)
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			r.blank()
+		case ruleRe.MatchString(line):
+			r.block(r.style(dim, strings.Repeat("─", r.ruleWidth())))
+		case headingRe.MatchString(trimmed):
+			m := headingRe.FindStringSubmatch(trimmed)
+			r.heading(len(m[1]), m[2])
+		case strings.HasPrefix(trimmed, ">"):
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			r.wrapped(r.style(dim, "│ "), "  ", r.style(italic, r.inline(text)))
+		case listRe.MatchString(line):
+			m := listRe.FindStringSubmatch(line)
+			indent := strings.Repeat(" ", len(strings.ReplaceAll(m[1], "\t", "    ")))
+			marker := m[2]
+			if _, err := strconv.Atoi(strings.TrimRight(marker, ".)")); err != nil {
+				marker = "•"
+			}
+			prefix := "  " + indent + r.style(cyan, marker) + " "
+			r.wrapped(prefix, strings.Repeat(" ", 3+len(indent)+utf8.RuneCountInString(marker)), r.inline(m[3]))
+		default:
+			r.wrapped("", "", r.inline(trimmed))
+		}
+	}
+	return strings.TrimRight(r.b.String(), "\n") + "\n"
+}
+
+type renderer struct {
+	opts      Options
+	b         strings.Builder
+	lastBlank bool
+}
+
+func (r *renderer) style(code, s string) string {
+	if !r.opts.Color || s == "" {
+		return s
+	}
+	return code + s + reset
+}
+
+func (r *renderer) ruleWidth() int {
+	if r.opts.Width > 0 && r.opts.Width < 80 {
+		return r.opts.Width
+	}
+	return 40
+}
+
+func (r *renderer) blank() {
+	if r.b.Len() > 0 && !r.lastBlank {
+		r.b.WriteString("\n")
+		r.lastBlank = true
+	}
+}
+
+func (r *renderer) block(s string) {
+	r.b.WriteString(s)
+	r.b.WriteString("\n")
+	r.lastBlank = false
+}
+
+func (r *renderer) heading(level int, text string) {
+	r.blank()
+	text = r.inline(text)
+	switch {
+	case !r.opts.Color && level <= 2:
+		r.block(strings.ToUpper(text))
+	case level == 1:
+		r.block(bold + underline + magenta + text + reset)
+	case level == 2:
+		r.block(bold + magenta + text + reset)
+	default:
+		r.block(r.style(bold, text))
+	}
+	r.blank()
+}
+
+func (r *renderer) codeBlock(lang string, code []string) {
+	r.blank()
+	if lang != "" && r.opts.Color {
+		r.block("    " + r.style(dim, lang))
+	}
+	for _, line := range code {
+		r.block("    " + r.style(cyan, strings.ReplaceAll(line, "\t", "    ")))
+	}
+	r.blank()
+}
+
+// wrapped writes text after prefix, wrapping at the configured width and
+// indenting continuation lines with indent.
+func (r *renderer) wrapped(prefix, indent, text string) {
+	width := r.opts.Width
+	if width <= 0 {
+		r.block(prefix + text)
+		return
+	}
+	line := prefix
+	lineLen := visibleLen(prefix)
+	empty := true
+	for _, word := range strings.Fields(text) {
+		n := visibleLen(word)
+		if !empty && lineLen+1+n > width {
+			r.block(line)
+			line, lineLen, empty = indent, len(indent), true
+		}
+		if !empty {
+			line += " "
+			lineLen++
+		}
+		line += word
+		lineLen += n
+		empty = false
+	}
+	r.block(line)
+}
+
+// inline styles the spans of a line, leaving code spans untouched.
+func (r *renderer) inline(s string) string {
+	parts := strings.Split(s, "`")
+	var b strings.Builder
+	for i, part := range parts {
+		// Odd parts are code spans; an unmatched trailing backtick is literal.
+		if i%2 == 1 && i < len(parts)-1 {
+			if r.opts.Color {
+				b.WriteString(cyan + part + reset)
+			} else {
+				b.WriteString("`" + part + "`")
+			}
+			continue
+		}
+		if i%2 == 1 {
+			b.WriteString("`")
+		}
+		b.WriteString(r.spans(part))
+	}
+	return b.String()
+}
+
+func (r *renderer) spans(s string) string {
+	s = linkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := linkRe.FindStringSubmatch(m)
+		if sub[1] == sub[2] {
+			return r.style(underline, sub[2])
+		}
+		return sub[1] + " (" + r.style(underline, sub[2]) + ")"
+	})
+	s = strongRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := strongRe.FindStringSubmatch(m)
+		return r.style(bold, sub[1]+sub[2])
+	})
+	s = emRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := emRe.FindStringSubmatch(m)
+		if sub[1] != "" {
+			return r.style(italic, sub[1])
+		}
+		return sub[2] + r.style(italic, sub[3]) + sub[4]
+	})
+	return strikeRe.ReplaceAllStringFunc(s, func(m string) string {
+		return r.style(strike, strikeRe.FindStringSubmatch(m)[1])
+	})
+}
+
+// visibleLen counts the runes of s that take up space on screen.
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiRe.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPlain(t *testing.T) {
+	src := "# Login fails\n\nUsers see **an error** after _submitting_ the form; see [the logs](https://logs.example.com).\n\n" +
+		"Steps:\n\n1. Open `/login`\n2. Submit\n\n- nested:\n  - snake_case_name stays\n\n> quoted\n\n```go\nfunc main() {}\n```\n\n---\n"
+	want := `LOGIN FAILS
+
+Users see an error after submitting the form; see the logs (https://logs.example.com).
+
+Steps:
+
+  1. Open ` + "`/login`" + `
+  2. Submit
+
+  • nested:
+    • snake_case_name stays
+
+│ quoted
+
+    func main() {}
+
+────────────────────────────────────────
+`
+	if got := Render(src, Options{}); got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderWrap(t *testing.T) {
+	got := Render("- one two three four five six", Options{Width: 16})
+	want := "  • one two\n    three four\n    five six\n"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderColor(t *testing.T) {
+	got := Render("use `go test` and **care**", Options{Color: true})
+	if !strings.Contains(got, cyan+"go test"+reset) || !strings.Contains(got, bold+"care"+reset) {
+		t.Errorf("Render() = %q, missing styled spans", got)
+	}
+	if strings.Contains(got, "`") || strings.Contains(got, "**") {
+		t.Errorf("Render() = %q, markup left behind", got)
+	}
+}
@@ -0,0 +1,247 @@
+// Package metrics keeps counters, gauges and histograms in memory and
+// serves them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds, suited to HTTP
+// requests against ticket trackers.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds metrics in registration order.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all metrics in the Prometheus text format.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.metrics {
+		m.write(w)
+	}
+}
+
+// Handler serves the registry, typically at /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// family is the state shared by all metric types: a name, help text, label
+// names and one series per label value combination.
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string][]string // key -> label values
+}
+
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	k := strings.Join(values, "\xff")
+	if _, ok := f.series[k]; !ok {
+		f.series[k] = append([]string(nil), values...)
+	}
+	return k
+}
+
+// sortedKeys returns series keys in a stable order for output.
+func (f *family) sortedKeys() []string {
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *family) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+}
+
+// labelString formats label pairs, with extra pairs (such as le) appended.
+func (f *family) labelString(values []string, extra ...string) string {
+	var pairs []string
+	for i, l := range f.labels {
+		pairs = append(pairs, l+"="+strconv.Quote(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+"="+strconv.Quote(extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{name: name, help: help, kind: kind, labels: labels, series: make(map[string][]string)}
+}
+
+// Counter is a monotonically increasing value per label combination.
+type Counter struct {
+	family
+	values map[string]float64
+}
+
+// NewCounter registers a counter.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: newFamily(name, help, "counter", labels), values: make(map[string]float64)}
+	if len(labels) == 0 {
+		// Unlabeled counters start at zero rather than being absent.
+		c.values[c.key(nil)] = 0
+	}
+	r.register(c)
+	return c
+}
+
+// Add increases the counter for the given label values.
+func (c *Counter) Add(v float64, labels ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[c.key(labels)] += v
+}
+
+// Inc increases the counter by one.
+func (c *Counter) Inc(labels ...string) { c.Add(1, labels...) }
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, k := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(c.series[k]), formatFloat(c.values[k]))
+	}
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	family
+	values map[string]float64
+}
+
+// NewGauge registers a gauge.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{family: newFamily(name, help, "gauge", labels), values: make(map[string]float64)}
+	r.register(g)
+	return g
+}
+
+// Set sets the gauge for the given label values.
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.key(labels)] = v
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, k := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelString(g.series[k]), formatFloat(g.values[k]))
+	}
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	family
+	buckets []float64
+	data    map[string]*histogramData
+}
+
+type histogramData struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds,
+// or DefaultBuckets when buckets is nil.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	h := &Histogram{family: newFamily(name, help, "histogram", labels), buckets: buckets, data: make(map[string]*histogramData)}
+	r.register(h)
+	return h
+}
+
+// Observe records a value for the given label values.
+func (h *Histogram) Observe(v float64, labels ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := h.key(labels)
+	d := h.data[k]
+	if d == nil {
+		d = &histogramData{counts: make([]uint64, len(h.buckets))}
+		h.data[k] = d
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			d.counts[i]++
+			break
+		}
+	}
+	d.count++
+	d.sum += v
+}
+
+// ObserveDuration records a duration in seconds.
+func (h *Histogram) ObserveDuration(d time.Duration, labels ...string) {
+	h.Observe(d.Seconds(), labels...)
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, k := range h.sortedKeys() {
+		values, d := h.series[k], h.data[k]
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += d.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", formatFloat(b)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", "+Inf"), d.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(values), formatFloat(d.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(values), d.count)
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
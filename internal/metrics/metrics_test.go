@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	polls := r.NewCounter("wt_polls_total", "Polls run.")
+	r.NewCounter("wt_created_total", "Tasks created.")
+	errs := r.NewCounter("wt_poll_errors_total", "Failed ticket fetches.", "connector")
+	tasks := r.NewGauge("wt_tasks", "Active tasks.")
+	latency := r.NewHistogram("wt_request_seconds", "Request latency.", []float64{0.1, 1}, "connector")
+
+	polls.Inc()
+	polls.Inc()
+	errs.Inc("jira")
+	tasks.Set(3)
+	latency.Observe(0.05, "jira")
+	latency.Observe(0.5, "jira")
+	latency.Observe(2, "jira")
+
+	var b strings.Builder
+	r.Write(&b)
+	want := `# HELP wt_polls_total Polls run.
+# TYPE wt_polls_total counter
+wt_polls_total 2
+# HELP wt_created_total Tasks created.
+# TYPE wt_created_total counter
+wt_created_total 0
+# HELP wt_poll_errors_total Failed ticket fetches.
+# TYPE wt_poll_errors_total counter
+wt_poll_errors_total{connector="jira"} 1
+# HELP wt_tasks Active tasks.
+# TYPE wt_tasks gauge
+wt_tasks 3
+# HELP wt_request_seconds Request latency.
+# TYPE wt_request_seconds histogram
+wt_request_seconds_bucket{connector="jira",le="0.1"} 1
+wt_request_seconds_bucket{connector="jira",le="1"} 2
+wt_request_seconds_bucket{connector="jira",le="+Inf"} 3
+wt_request_seconds_sum{connector="jira"} 2.55
+wt_request_seconds_count{connector="jira"} 3
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
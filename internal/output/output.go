@@ -0,0 +1,124 @@
+// Package output renders command results as tables or machine-readable
+// JSON, YAML or Go templates.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats accepted by New.
+const (
+	Table    = "table"
+	JSON     = "json"
+	YAML     = "yaml"
+	Template = "template"
+)
+
+// Formatter writes values in one output format.
+type Formatter struct {
+	Format string
+	tmpl   *template.Template
+}
+
+// New parses a format spec: "table", "json", "yaml" or
+// "template=<go template>". An empty spec means table.
+func New(spec string) (*Formatter, error) {
+	switch spec {
+	case "", Table:
+		return &Formatter{Format: Table}, nil
+	case JSON, YAML:
+		return &Formatter{Format: spec}, nil
+	}
+	if text, ok := strings.CutPrefix(spec, Template+"="); ok {
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %w", err)
+		}
+		return &Formatter{Format: Template, tmpl: tmpl}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (want table, json, yaml or template=<go template>)", spec)
+}
+
+// IsTable reports whether the formatter writes human-readable tables.
+func (f *Formatter) IsTable() bool { return f.Format == Table }
+
+// Write renders v. Tables are drawn by the table callback; other formats
+// encode v using its JSON field names, so JSON, YAML and templates all see
+// the same keys. Templates run once per element when v is a slice.
+func (f *Formatter) Write(w io.Writer, v any, table func(io.Writer) error) error {
+	switch f.Format {
+	case Table:
+		return table(w)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nonNil(v))
+	}
+
+	data, err := json.Marshal(nonNil(v))
+	if err != nil {
+		return err
+	}
+	if f.Format == YAML {
+		// Decoding the JSON into a node keeps the field order of the structs.
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return err
+		}
+		clearStyle(&node)
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+	items, ok := generic.([]any)
+	if !ok {
+		items = []any{generic}
+	}
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := f.tmpl.Execute(&buf, item); err != nil {
+			return fmt.Errorf("failed to execute output template: %w", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nonNil turns nil slices into empty ones so they encode as [] not null.
+func nonNil(v any) any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	return v
+}
+
+// clearStyle resets the JSON flow and quoting styles of a node tree so it
+// is written as block YAML.
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
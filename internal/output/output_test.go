@@ -0,0 +1,54 @@
+package output
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	ID     string   `json:"id"`
+	Branch string   `json:"branch"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+func TestWrite(t *testing.T) {
+	items := []item{{ID: "wt-1", Branch: "feature/a", Tags: []string{"x"}}, {ID: "wt-2", Branch: "feature/b"}}
+	tests := []struct {
+		spec string
+		v    any
+		want string
+	}{
+		{"table", items, "TABLE\n"},
+		{"json", items[1], "{\n  \"id\": \"wt-2\",\n  \"branch\": \"feature/b\"\n}\n"},
+		{"json", []item(nil), "[]\n"},
+		{"yaml", items, "- id: wt-1\n  branch: feature/a\n  tags:\n    - x\n- id: wt-2\n  branch: feature/b\n"},
+		{"template={{.id}} {{.branch}}", items, "wt-1 feature/a\nwt-2 feature/b\n"},
+		{"template={{.id}}", items[0], "wt-1\n"},
+	}
+	for _, tt := range tests {
+		f, err := New(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		err = f.Write(&b, tt.v, func(w io.Writer) error {
+			_, err := io.WriteString(w, "TABLE\n")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.spec, b.String(), tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, spec := range []string{"xml", "template={{.id", "template"} {
+		if _, err := New(spec); err == nil {
+			t.Errorf("New(%q): expected error", spec)
+		}
+	}
+}
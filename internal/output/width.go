@@ -0,0 +1,178 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges are the code points a terminal draws two columns wide: the
+// East Asian Wide and Fullwidth blocks and emoji with emoji presentation.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF}, {0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// runeWidth returns the number of terminal columns r occupies.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0x200D || r == 0xFE0F || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r):
+		// Joiners, variation selectors and combining marks attach to the
+		// previous character.
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	}
+	for _, wr := range wideRanges {
+		if r < wr.lo {
+			break
+		}
+		if r <= wr.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+// Width returns the number of terminal columns s occupies. ANSI escape
+// sequences take no space.
+func Width(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w += runeWidth(r)
+		i += size
+	}
+	return w
+}
+
+// escapeLen returns the length of the ANSI CSI sequence at the start of s,
+// or 0 if s doesn't start with one.
+func escapeLen(s string) int {
+	if !strings.HasPrefix(s, "\033[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7E {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// Truncate shortens s to at most max terminal columns, ending it with "..."
+// when anything was cut. It never splits a character.
+func Truncate(s string, max int) string {
+	if Width(s) <= max {
+		return s
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if w+rw > max-3 {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String() + "..."
+}
+
+// TabWriter aligns tab-separated cells into columns like
+// tabwriter.NewWriter(w, 0, 0, 2, ' ', 0), but measures cells by their
+// width in the terminal, so wide characters and emoji line up. Output is
+// written on Flush.
+type TabWriter struct {
+	out io.Writer
+	buf bytes.Buffer
+}
+
+// tabPadding is the space between columns.
+const tabPadding = 2
+
+// NewTabWriter returns a TabWriter writing to out.
+func NewTabWriter(out io.Writer) *TabWriter {
+	return &TabWriter{out: out}
+}
+
+func (t *TabWriter) Write(p []byte) (int, error) {
+	return t.buf.Write(p)
+}
+
+// Flush aligns and writes everything written so far.
+func (t *TabWriter) Flush() error {
+	text := t.buf.String()
+	t.buf.Reset()
+	if text == "" {
+		return nil
+	}
+	rows := strings.Split(text, "\n")
+	lines := make([][]string, len(rows))
+	for i, row := range rows {
+		lines[i] = strings.Split(row, "\t")
+	}
+	var b strings.Builder
+	formatColumns(&b, lines, nil, 0, len(lines))
+	_, err := io.WriteString(t.out, b.String())
+	return err
+}
+
+// formatColumns writes lines[line0:line1], whose first len(widths) cells are
+// already measured. As with text/tabwriter, a column spans a block of
+// consecutive lines that all have a tab-terminated cell in it.
+func formatColumns(b *strings.Builder, lines [][]string, widths []int, line0, line1 int) {
+	column := len(widths)
+	for this := line0; this < line1; this++ {
+		if column >= len(lines[this])-1 {
+			continue
+		}
+		writeLines(b, lines, widths, line0, this)
+		line0 = this
+		width := 0
+		for ; this < line1; this++ {
+			if column >= len(lines[this])-1 {
+				break
+			}
+			width = max(width, Width(lines[this][column])+tabPadding)
+		}
+		formatColumns(b, lines, append(widths, width), line0, this)
+		line0 = this
+	}
+	writeLines(b, lines, widths, line0, line1)
+}
+
+func writeLines(b *strings.Builder, lines [][]string, widths []int, line0, line1 int) {
+	for i := line0; i < line1; i++ {
+		for j, cell := range lines[i] {
+			b.WriteString(cell)
+			if j < len(widths) {
+				b.WriteString(strings.Repeat(" ", widths[j]-Width(cell)))
+			}
+		}
+		// The text after the last newline has none of its own.
+		if i < len(lines)-1 {
+			b.WriteByte('\n')
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/tabwriter"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"login fix", 9},
+		{"ログイン修正", 12},
+		{"🔒 locked", 9},
+		{"café", 4},
+		{"cafe\u0301", 4},
+		{"\033[1mbold\033[0m", 4},
+		{"한국어", 6},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.s); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"a description that is long", 10, "a descr..."},
+		{"ログイン画面の修正をする", 10, "ログイ..."},
+		{"ログイン画面の修正をする", 11, "ログイン..."},
+		{"fix 🔒🔒🔒🔒", 9, "fix 🔒..."},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.max)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+		if Width(got) > tt.max {
+			t.Errorf("Truncate(%q, %d) is %d columns wide", tt.s, tt.max, Width(got))
+		}
+	}
+}
+
+func TestTabWriterMatchesTabwriter(t *testing.T) {
+	inputs := []string{
+		"ID\tDESCRIPTION\tBRANCH\nwt-1\tfix\tfeature/fix\nwt-22\tadd login page\tfeature/login\n",
+		"EPIC-1 Payments\nKEY\tSUMMARY\nA-1\tone\n\nNo epic\nB-22\ttwo\n",
+		"a\tb\tc\nlonger\t\tx\nno tabs here\nx\ty\n",
+		"trailing\tpartial",
+		"",
+	}
+	for _, in := range inputs {
+		var want, got strings.Builder
+		tw := tabwriter.NewWriter(&want, 0, 0, 2, ' ', 0)
+		fmt.Fprint(tw, in)
+		tw.Flush()
+		w := NewTabWriter(&got)
+		fmt.Fprint(w, in)
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("input %q:\ngot:\n%s\nwant:\n%s", in, got.String(), want.String())
+		}
+	}
+}
+
+func TestTabWriterWideCharacters(t *testing.T) {
+	var out strings.Builder
+	w := NewTabWriter(&out)
+	fmt.Fprintln(w, "ID\tSUMMARY\tSTATUS")
+	fmt.Fprintln(w, "A-1\tログイン修正\topen")
+	fmt.Fprintln(w, "A-2\t🔒 fix\tdone")
+	w.Flush()
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	for _, line := range lines {
+		status := strings.LastIndex(line, "  ") + 2
+		if got := Width(line[:status]); got != 19 {
+			t.Errorf("status column of %q starts at %d, want 19", line, got)
+		}
+	}
+}
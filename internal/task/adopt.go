@@ -0,0 +1,138 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// AdoptOptions describes an existing branch to track as a task.
+type AdoptOptions struct {
+	RepoPath string
+	Branch   string
+	// Description defaults to the branch name.
+	Description string
+	Connector   string
+	TicketKey   string
+	Notes       string
+}
+
+// Adopt registers an existing branch as a branch-only task: the task is
+// saved without a worktree, which Materialize creates when the task is
+// first used. A branch that only exists on origin gets a local branch
+// tracking it.
+func (m *Manager) Adopt(ctx context.Context, opts AdoptOptions) (*config.Task, error) {
+	for _, t := range m.Config.Tasks {
+		if t.Branch == opts.Branch && filepath.Clean(t.RepoPath) == filepath.Clean(opts.RepoPath) {
+			return nil, fmt.Errorf("branch %s already belongs to task %s", opts.Branch, t.ID)
+		}
+	}
+	if !worktree.BranchExists(ctx, opts.RepoPath, "refs/heads/"+opts.Branch) {
+		if !worktree.BranchExists(ctx, opts.RepoPath, "refs/remotes/origin/"+opts.Branch) {
+			return nil, fmt.Errorf("branch %q not found, locally or on origin; run 'git fetch' if it was pushed recently", opts.Branch)
+		}
+		if err := worktree.TrackBranch(ctx, opts.RepoPath, opts.Branch); err != nil {
+			return nil, err
+		}
+	}
+	repoName, err := RepoName(ctx, opts.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Description == "" {
+		opts.Description = opts.Branch
+	}
+
+	// Named like the worktrees of started tasks, after the branch without
+	// branch_prefix.
+	name := strings.TrimPrefix(opts.Branch, m.Config.BranchPrefix+"/")
+	dirName := worktree.SanitizeBranchNameWith(name, worktree.NameOptions{MaxLength: m.Config.BranchMaxLength})
+	wtPath := filepath.Join(m.Config.WorktreesBase, repoName, dirName)
+	if host, base := worktree.SplitHost(m.Config.WorktreesBase); host != "" {
+		wtPath = worktree.JoinHost(host, path.Join(base, repoName, dirName))
+	}
+
+	id := generateID()
+	if err := createScratch(id); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	t := config.Task{
+		ID:          id,
+		Description: opts.Description,
+		Worktree:    wtPath,
+		Branch:      opts.Branch,
+		RepoPath:    opts.RepoPath,
+		Connector:   opts.Connector,
+		TicketKey:   opts.TicketKey,
+		Created:     time.Now(),
+		Notes:       opts.Notes,
+		State:       config.StateBranchOnly,
+	}
+	m.updateCommits(ctx, &t)
+	if err := m.Config.AddTask(t); err != nil {
+		if err := cleanScratch(id, false); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Materialize creates the worktree of a branch-only task, checking out its
+// branch, and marks the task ready. Other tasks are returned unchanged.
+func (m *Manager) Materialize(ctx context.Context, id string) (_ *config.Task, err error) {
+	t, err := m.Config.FindTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.State != config.StateBranchOnly {
+		return t, nil
+	}
+	if _, err := os.Stat(t.Worktree); err == nil {
+		return nil, fmt.Errorf("cannot create the worktree of %s: %s already exists", t.ID, t.Worktree)
+	}
+	if !worktree.IsRemote(t.Worktree) {
+		if err := os.MkdirAll(filepath.Dir(t.Worktree), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+		}
+	}
+
+	var tx transaction
+	defer func() {
+		if err != nil {
+			tx.rollback(ctx)
+		}
+	}()
+	tx.add(m.undoWorktree(t.RepoPath, t.Worktree))
+	if err := worktree.CreateFromExistingBranch(ctx, t.RepoPath, t.Worktree, t.Branch); err != nil {
+		return nil, err
+	}
+	if err := m.setGitConfig(ctx, t.RepoPath, t.Worktree); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if m.Config.Direnv.Enabled && !worktree.IsRemote(t.Worktree) {
+		if t.Port == 0 {
+			port, err := m.allocatePort()
+			if err == nil {
+				err = m.Config.SetTaskPort(t.ID, port)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+		}
+		if err := m.setupDirenv(t); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if err := m.Config.SetTaskState(t.ID, ""); err != nil {
+		return nil, err
+	}
+	return m.Config.FindTask(t.ID)
+}
@@ -0,0 +1,77 @@
+package task
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+func TestAdopt(t *testing.T) {
+	m, _, repo := newFakeManager(t)
+	cfg := m.Config
+	ctx := context.Background()
+
+	// Leave a branch without a worktree behind.
+	started, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Remove(ctx, started.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Adopt(ctx, AdoptOptions{RepoPath: repo, Branch: "feature/nope"}); err == nil {
+		t.Error("Adopt() of a missing branch succeeded")
+	}
+	adopted, err := m.Adopt(ctx, AdoptOptions{RepoPath: repo, Branch: started.Branch, TicketKey: "APP-7", Connector: "jira"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adopted.State != config.StateBranchOnly || adopted.Description != started.Branch || adopted.Head == "" {
+		t.Errorf("adopted %+v", adopted)
+	}
+	if _, err := os.Stat(adopted.Worktree); !os.IsNotExist(err) {
+		t.Errorf("worktree created by Adopt(): %v", err)
+	}
+	if _, err := m.Adopt(ctx, AdoptOptions{RepoPath: repo, Branch: started.Branch}); err == nil {
+		t.Error("adopting a branch twice succeeded")
+	}
+
+	ready, err := m.Materialize(ctx, adopted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ready.State != "" {
+		t.Errorf("state = %q after Materialize()", ready.State)
+	}
+	if _, err := os.Stat(adopted.Worktree); err != nil {
+		t.Errorf("worktree not created: %v", err)
+	}
+	if _, err := m.Finish(ctx, adopted.ID); err != nil {
+		t.Fatal(err)
+	}
+	if worktree.BranchExists(ctx, repo, started.Branch) {
+		t.Error("branch still exists")
+	}
+
+	// A branch-only task is finished without a worktree to remove.
+	if _, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix logout"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Remove(ctx, cfg.Tasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	planned, err := m.Adopt(ctx, AdoptOptions{RepoPath: repo, Branch: "feature/fix-logout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Finish(ctx, planned.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Tasks) != 0 || worktree.BranchExists(ctx, repo, "feature/fix-logout") {
+		t.Errorf("tasks = %+v after finishing a branch-only task", cfg.Tasks)
+	}
+}
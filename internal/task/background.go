@@ -0,0 +1,99 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/workspace"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// CheckoutCommand is the hidden wt subcommand that completes a background checkout.
+const CheckoutCommand = "__checkout"
+
+// CheckoutLogPath returns the log file of a task's background checkout.
+func CheckoutLogPath(id string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "logs", id+"-checkout.log"), nil
+}
+
+// SpawnCheckout starts a detached `wt __checkout <id>` process that
+// populates a worktree created with StartOptions.Background.
+func SpawnCheckout(id string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate wt executable: %w", err)
+	}
+	logPath, err := CheckoutLogPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to create checkout log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, CheckoutCommand, id)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Detach from the terminal so the checkout survives the shell exiting.
+	agent.Detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start background checkout: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// CompleteCheckout populates a preparing task's worktree and marks it ready.
+func (m *Manager) CompleteCheckout(ctx context.Context, id string) error {
+	t, err := m.Config.FindTask(id)
+	if err != nil {
+		return err
+	}
+	if t.State != config.StatePreparing {
+		return fmt.Errorf("task %s is not preparing (state %q)", id, t.State)
+	}
+
+	checkoutErr := worktree.Checkout(ctx, t.Worktree)
+
+	// The checkout can take minutes; reload so concurrent changes aren't lost.
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	m.Config = cfg
+	if checkoutErr != nil {
+		if err := m.Config.SetTaskState(id, config.StateCheckoutFailed); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		return checkoutErr
+	}
+	t, err = m.Config.FindTask(id)
+	if err != nil {
+		return err
+	}
+	if m.Config.Direnv.Enabled {
+		if err := m.setupDirenv(t); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if !workspace.IsLocal(t.Workspace) && t.WorkspaceID == "" {
+		if err := m.createWorkspace(ctx, t); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v; run 'wt up %s' to try again\n", err, t.ID)
+		}
+	}
+	m.saveCommits(ctx, t)
+	return m.Config.SetTaskState(id, "")
+}
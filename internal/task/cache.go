@@ -0,0 +1,69 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+// cacheBase returns the root directory for build caches.
+func cacheBase(bc config.BuildCacheConfig) (string, error) {
+	if bc.Dir != "" {
+		return bc.Dir, nil
+	}
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache"), nil
+}
+
+// taskCacheDir returns the directory holding a task's isolated caches.
+func taskCacheDir(bc config.BuildCacheConfig, id string) (string, error) {
+	base, err := cacheBase(bc)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "tasks", id), nil
+}
+
+// CacheEnv returns the build cache environment variables for a task.
+// Isolated caches live under <dir>/tasks/<task-id>, shared caches under
+// <dir>/shared/<repo-name>, one subdirectory per variable.
+func CacheEnv(bc config.BuildCacheConfig, t *config.Task) (map[string]string, error) {
+	env := make(map[string]string, len(bc.Vars))
+	if len(bc.Vars) == 0 {
+		return env, nil
+	}
+	base, err := cacheBase(bc)
+	if err != nil {
+		return nil, err
+	}
+	for name, mode := range bc.Vars {
+		sub := strings.ToLower(name)
+		switch mode {
+		case config.CacheIsolated:
+			env[name] = filepath.Join(base, "tasks", t.ID, sub)
+		case config.CacheShared:
+			env[name] = filepath.Join(base, "shared", filepath.Base(t.RepoPath), sub)
+		default:
+			return nil, fmt.Errorf("invalid build_cache mode %q for %s (want %q or %q)", mode, name, config.CacheIsolated, config.CacheShared)
+		}
+	}
+	return env, nil
+}
+
+// removeTaskCache deletes a task's isolated build caches.
+func removeTaskCache(bc config.BuildCacheConfig, id string) error {
+	if len(bc.Vars) == 0 {
+		return nil
+	}
+	dir, err := taskCacheDir(bc, id)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
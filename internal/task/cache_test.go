@@ -0,0 +1,37 @@
+package task
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestCacheEnv(t *testing.T) {
+	bc := config.BuildCacheConfig{
+		Dir: "/cache",
+		Vars: map[string]string{
+			"GOCACHE":          config.CacheShared,
+			"CARGO_TARGET_DIR": config.CacheIsolated,
+		},
+	}
+	task := &config.Task{ID: "wt-1234abcd", RepoPath: "/src/myrepo"}
+
+	env, err := CacheEnv(bc, task)
+	if err != nil {
+		t.Fatalf("CacheEnv failed: %v", err)
+	}
+	if got, want := env["GOCACHE"], filepath.Join("/cache", "shared", "myrepo", "gocache"); got != want {
+		t.Errorf("GOCACHE = %q, want %q", got, want)
+	}
+	if got, want := env["CARGO_TARGET_DIR"], filepath.Join("/cache", "tasks", "wt-1234abcd", "cargo_target_dir"); got != want {
+		t.Errorf("CARGO_TARGET_DIR = %q, want %q", got, want)
+	}
+}
+
+func TestCacheEnvInvalidMode(t *testing.T) {
+	bc := config.BuildCacheConfig{Vars: map[string]string{"GOCACHE": "sometimes"}}
+	if _, err := CacheEnv(bc, &config.Task{ID: "wt-1"}); err == nil {
+		t.Error("expected error for invalid cache mode")
+	}
+}
@@ -0,0 +1,149 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// ErrChecksFailed is returned when a task fails its finish checks.
+var ErrChecksFailed = errors.New("finish checks failed")
+
+// CheckResult is the outcome of one finish check.
+type CheckResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func (r CheckResult) String() string {
+	mark := "✓"
+	switch {
+	case r.Skipped:
+		mark = "-"
+	case !r.Passed:
+		mark = "✗"
+	}
+	if r.Detail == "" {
+		return mark + " " + r.Name
+	}
+	return mark + " " + r.Name + ": " + r.Detail
+}
+
+// RunChecks runs the finish checks configured for a task's repository,
+// writing the output of commands they run to out. Every check runs even
+// after one fails, so all problems are reported at once; the returned
+// error wraps ErrChecksFailed when any failed. A task whose worktree is
+// gone has nothing to check.
+func (m *Manager) RunChecks(ctx context.Context, t *config.Task, out io.Writer) ([]CheckResult, error) {
+	if _, err := os.Stat(t.Worktree); err != nil {
+		return nil, nil
+	}
+	checks := m.Config.FinishChecksFor(t.RepoPath, t.Worktree)
+	for _, check := range checks {
+		if err := check.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	results := make([]CheckResult, len(checks))
+	failed := 0
+	for i, check := range checks {
+		r, err := m.runCheck(ctx, t, check, out)
+		if err != nil {
+			return nil, err
+		}
+		if !r.Passed && !r.Skipped {
+			failed++
+		}
+		results[i] = r
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%w: %d of %d for %s", ErrChecksFailed, failed, len(checks), t.ID)
+	}
+	return results, nil
+}
+
+func (m *Manager) runCheck(ctx context.Context, t *config.Task, check config.FinishCheck, out io.Writer) (CheckResult, error) {
+	r := CheckResult{Name: check.Name}
+	if check.Run != "" {
+		env, err := m.Env(t)
+		if err != nil {
+			return r, err
+		}
+		r.Passed, err = runShell(ctx, t, env, check.Run, out)
+		if err != nil {
+			return r, fmt.Errorf("failed to run check %s: %w", check.Name, err)
+		}
+		if !r.Passed {
+			r.Detail = check.Run + " failed"
+		}
+		return r, nil
+	}
+
+	switch check.Name {
+	case config.CheckClean:
+		if m.Force {
+			r.Skipped, r.Detail = true, "changes are discarded with --force"
+			return r, nil
+		}
+		st, err := worktree.Status(ctx, t.Worktree)
+		if err != nil {
+			return r, err
+		}
+		n := m.changes(st)
+		r.Passed = n == 0
+		if !r.Passed {
+			r.Detail = fmt.Sprintf("%d uncommitted change(s)", n)
+		}
+	case config.CheckRebased:
+		base := DefaultBranch(ctx, m.Config, t.RepoPath)
+		_, behind, err := worktree.Compare(ctx, t.Worktree, base)
+		if err != nil {
+			return r, err
+		}
+		r.Passed = behind == 0
+		if !r.Passed {
+			r.Detail = fmt.Sprintf("%d commit(s) behind %s; run 'wt rebase'", behind, base)
+		}
+	case config.CheckTests:
+		return m.checkTests(ctx, t, out)
+	}
+	return r, nil
+}
+
+// checkTests runs the task's tests, unless its last test run passed on the
+// current, clean HEAD.
+func (m *Manager) checkTests(ctx context.Context, t *config.Task, out io.Writer) (CheckResult, error) {
+	r := CheckResult{Name: config.CheckTests}
+	if m.Config.TestCommand(t.RepoPath, t.Worktree) == "" {
+		r.Skipped, r.Detail = true, "no test_command"
+		return r, nil
+	}
+	if last := t.Test; last != nil && last.Passed {
+		commit, err := worktree.LastCommit(ctx, t.Worktree)
+		st, statusErr := worktree.Status(ctx, t.Worktree)
+		if err == nil && statusErr == nil && commit.Hash == last.Commit && m.changes(st) == 0 {
+			r.Passed, r.Detail = true, "passed at "+last.Commit
+			return r, nil
+		}
+	}
+	result, err := m.RunTests(ctx, t, out)
+	if err != nil {
+		return r, err
+	}
+	if err := m.Config.SetTestResult(t.ID, result); err != nil {
+		return r, err
+	}
+	r.Passed = result.Passed
+	if !r.Passed {
+		logPath, _ := TestLogPath(t.ID)
+		r.Detail = "see " + logPath
+	}
+	return r, nil
+}
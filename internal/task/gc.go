@@ -0,0 +1,89 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// removeEmptyParents removes dir and then its parents as long as they are
+// empty, stopping at base, which is kept. Directories outside base are
+// left alone.
+func removeEmptyParents(dir, base string) error {
+	if base == "" {
+		return nil
+	}
+	base = filepath.Clean(base)
+	dir = filepath.Clean(dir)
+	for dir != base && strings.HasPrefix(dir, base+string(filepath.Separator)) {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			dir = filepath.Dir(dir)
+			continue
+		}
+		if err != nil || len(entries) > 0 {
+			return nil
+		}
+		if err := os.Remove(dir); err != nil {
+			return fmt.Errorf("failed to remove empty directory %s: %w", dir, err)
+		}
+		dir = filepath.Dir(dir)
+	}
+	return nil
+}
+
+// GC removes the empty directories under base, such as those of
+// repositories whose worktrees were all deleted, and returns them, deepest
+// first. base itself is kept, and worktrees are not looked into. dryRun
+// only returns what would be removed.
+func GC(base string, dryRun bool) ([]string, error) {
+	entries, err := os.ReadDir(base)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var empty []string
+	for _, e := range entries {
+		if e.IsDir() {
+			collectEmpty(filepath.Join(base, e.Name()), &empty)
+		}
+	}
+	if dryRun {
+		return empty, nil
+	}
+	for i, dir := range empty {
+		if err := os.Remove(dir); err != nil {
+			return empty[:i], fmt.Errorf("failed to remove empty directory %s: %w", dir, err)
+		}
+	}
+	return empty, nil
+}
+
+// collectEmpty reports whether dir holds nothing but empty directories,
+// appending those and then dir to empty.
+func collectEmpty(dir string, empty *[]string) bool {
+	if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+		return false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	isEmpty := true
+	for _, e := range entries {
+		if !e.IsDir() {
+			isEmpty = false
+			continue
+		}
+		if !collectEmpty(filepath.Join(dir, e.Name()), empty) {
+			isEmpty = false
+		}
+	}
+	if isEmpty {
+		*empty = append(*empty, dir)
+	}
+	return isEmpty
+}
@@ -0,0 +1,74 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGC(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{"old/a/b", "old/c", "api/fix", "web/feat/empty", "web/notes"} {
+		if err := os.MkdirAll(filepath.Join(base, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A worktree whose empty directories must survive, and a directory
+	// holding a file.
+	os.WriteFile(filepath.Join(base, "web", "feat", ".git"), []byte("gitdir: /src/web/.git/worktrees/feat\n"), 0o644)
+	os.WriteFile(filepath.Join(base, "web", "notes", "todo.txt"), []byte("x"), 0o644)
+
+	rel := func(dirs []string) []string {
+		var out []string
+		for _, d := range dirs {
+			r, _ := filepath.Rel(base, d)
+			out = append(out, filepath.ToSlash(r))
+		}
+		return out
+	}
+	want := []string{"api/fix", "api", "old/a/b", "old/a", "old/c", "old"}
+	got, err := GC(base, true)
+	if err != nil || !reflect.DeepEqual(rel(got), want) {
+		t.Fatalf("GC dry run = %v, %v; want %v", rel(got), err, want)
+	}
+	if _, err := os.Stat(filepath.Join(base, "old")); err != nil {
+		t.Errorf("dry run removed old: %v", err)
+	}
+	if _, err := GC(base, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"old", "api"} {
+		if _, err := os.Stat(filepath.Join(base, dir)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", dir)
+		}
+	}
+	for _, dir := range []string{"web/feat/empty", "web/notes"} {
+		if _, err := os.Stat(filepath.Join(base, dir)); err != nil {
+			t.Errorf("%s was removed: %v", dir, err)
+		}
+	}
+}
+
+func TestRemoveEmptyParents(t *testing.T) {
+	base := t.TempDir()
+	os.MkdirAll(filepath.Join(base, "repo", "group"), 0o755)
+	os.MkdirAll(filepath.Join(base, "other"), 0o755)
+	os.WriteFile(filepath.Join(base, "other", "keep"), nil, 0o644)
+
+	if err := removeEmptyParents(filepath.Join(base, "repo", "group"), base); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "repo")); !os.IsNotExist(err) {
+		t.Errorf("repo still exists")
+	}
+	if _, err := os.Stat(base); err != nil {
+		t.Errorf("base was removed: %v", err)
+	}
+	if err := removeEmptyParents(filepath.Join(base, "other"), base); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "other")); err != nil {
+		t.Errorf("non-empty other was removed: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package task
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// idAlphabet is Crockford's base32, lowercased: it sorts in the same order
+// as the values it encodes and has no letters easily mistaken for digits.
+const idAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// idState keeps IDs generated by one process increasing, even within a
+// millisecond.
+var idState struct {
+	sync.Mutex
+	ms   uint64
+	rand uint32
+}
+
+// generateID returns a new task ID such as "wt-01m4z2q0ehah9j": the
+// creation time in milliseconds, then 20 random bits, both in base32. IDs
+// sort in creation order, as strings, and stay unique across machines
+// sharing state. Tasks created before keep their "wt-" and 8 hex digit IDs.
+func generateID() string {
+	return newID(time.Now())
+}
+
+func newID(now time.Time) string {
+	var b [4]byte
+	rand.Read(b[:])
+	r := binary.BigEndian.Uint32(b[:]) & (1<<20 - 1)
+	ms := uint64(now.UnixMilli())
+
+	s := &idState
+	s.Lock()
+	if ms <= s.ms {
+		// Same millisecond, or the clock went back: count up from the
+		// last ID instead.
+		ms, r = s.ms, s.rand+1
+		if r >= 1<<20 {
+			ms, r = ms+1, 0
+		}
+	}
+	s.ms, s.rand = ms, r
+	s.Unlock()
+
+	id := make([]byte, 0, 17)
+	id = append(id, "wt-"...)
+	id = appendBase32(id, ms, 10)
+	id = appendBase32(id, uint64(r), 4)
+	return string(id)
+}
+
+// appendBase32 appends the n low base32 digits of v, most significant
+// first.
+func appendBase32(dst []byte, v uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		dst = append(dst, idAlphabet[(v>>(5*uint(i)))&31])
+	}
+	return dst
+}
@@ -0,0 +1,33 @@
+package task
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewID(t *testing.T) {
+	idState.ms, idState.rand = 0, 0
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	times := []time.Time{now, now, now, now.Add(time.Millisecond), now.Add(-time.Hour), now.Add(time.Hour)}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, ts := range times {
+		id := newID(ts)
+		if len(id) != 17 || !strings.HasPrefix(id, "wt-") || strings.Trim(id[3:], idAlphabet) != "" {
+			t.Errorf("newID() = %q, want wt- and 14 base32 digits", id)
+		}
+		if seen[id] {
+			t.Errorf("newID() returned %q twice", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("IDs %v are not in creation order", ids)
+	}
+	if got, want := ids[0][3:13], appendBase32(nil, uint64(now.UnixMilli()), 10); got != string(want) {
+		t.Errorf("timestamp part = %q, want %q", got, want)
+	}
+}
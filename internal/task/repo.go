@@ -0,0 +1,146 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// defaultBranchTTL is how long a detected default branch is reused.
+// Detection may ask the remote, so it is too slow to repeat per command.
+const defaultBranchTTL = 24 * time.Hour
+
+// repoMeta is what is cached about a repository, keyed by its path.
+type repoMeta struct {
+	// Name is the repository name; it can only change with the path, so it
+	// is kept until the repository is gone.
+	Name string `json:"name,omitempty"`
+	// Branch is the default branch detected at Detected.
+	Branch   string    `json:"branch,omitempty"`
+	Detected time.Time `json:"detected,omitempty"`
+}
+
+// repoMetaCache holds the cached metadata of every repository, read from
+// disk on first use.
+type repoMetaCache struct {
+	sync.Mutex
+	entries map[string]repoMeta
+}
+
+var repoCache repoMetaCache
+
+// RepoName returns the name of the repository at repoPath, as
+// worktree.RepoName does, cached per repository in ~/.wt/cache/repos.json.
+func RepoName(ctx context.Context, repoPath string) (string, error) {
+	c := &repoCache
+	c.Lock()
+	defer c.Unlock()
+	entries := c.load(repoPath)
+	if e := entries[repoPath]; e.Name != "" {
+		return e.Name, nil
+	}
+	name, err := worktree.RepoName(ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
+	e := entries[repoPath]
+	e.Name = name
+	c.put(repoPath, e)
+	return name, nil
+}
+
+// DefaultBranch returns the base branch of a repository: default_branch
+// when configured, otherwise the branch detected by worktree.DefaultBranch,
+// cached per repository in ~/.wt/cache/repos.json for a day.
+func DefaultBranch(ctx context.Context, cfg *config.Config, repoPath string) string {
+	if cfg.DefaultBranch != "" {
+		return cfg.DefaultBranch
+	}
+	c := &repoCache
+	c.Lock()
+	defer c.Unlock()
+	entries := c.load(repoPath)
+	if e := entries[repoPath]; e.Branch != "" && time.Since(e.Detected) < defaultBranchTTL {
+		return e.Branch
+	}
+	branch := worktree.DefaultBranch(ctx, repoPath)
+	e := entries[repoPath]
+	e.Branch, e.Detected = branch, time.Now()
+	c.put(repoPath, e)
+	return branch
+}
+
+// ForgetRepo drops what is cached about the repository at repoPath, so it
+// is detected again, e.g. after its remote changed its default branch.
+func ForgetRepo(repoPath string) error {
+	c := &repoCache
+	c.Lock()
+	defer c.Unlock()
+	entries := c.load("")
+	if _, ok := entries[repoPath]; !ok {
+		return nil
+	}
+	delete(entries, repoPath)
+	return c.save()
+}
+
+// load reads the cache once, and drops the entry of repoPath if the
+// repository is no longer there. Call with the lock held.
+func (c *repoMetaCache) load(repoPath string) map[string]repoMeta {
+	if c.entries == nil {
+		c.entries = make(map[string]repoMeta)
+		if path, err := repoCachePath(); err == nil {
+			if data, err := os.ReadFile(path); err == nil {
+				json.Unmarshal(data, &c.entries)
+			}
+		}
+	}
+	if _, ok := c.entries[repoPath]; ok {
+		if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
+			delete(c.entries, repoPath)
+		}
+	}
+	return c.entries
+}
+
+// put caches e for repoPath and writes the cache; failing to write it is a
+// warning. Call with the lock held.
+func (c *repoMetaCache) put(repoPath string, e repoMeta) {
+	c.entries[repoPath] = e
+	if err := c.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+func (c *repoMetaCache) save() error {
+	path, err := repoCachePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write repository cache: %w", err)
+	}
+	return nil
+}
+
+func repoCachePath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cache", "repos.json"), nil
+}
@@ -0,0 +1,58 @@
+package task
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+func TestRepoCache(t *testing.T) {
+	_, f, repo := newFakeManager(t)
+	repoCache = repoMetaCache{}
+	f.AddRepo(repo, "trunk")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+
+	if name, err := RepoName(ctx, repo); err != nil || name != "app" {
+		t.Fatalf("RepoName() = %q, %v, want app", name, err)
+	}
+	if branch := DefaultBranch(ctx, cfg, repo); branch != "trunk" {
+		t.Fatalf("DefaultBranch() = %q, want trunk", branch)
+	}
+
+	// Cached, even across processes: git is not asked again.
+	repoCache = repoMetaCache{}
+	worktree.UseFake(worktree.NewFake())
+	if name, err := RepoName(ctx, repo); err != nil || name != "app" {
+		t.Errorf("cached RepoName() = %q, %v, want app", name, err)
+	}
+	if branch := DefaultBranch(ctx, cfg, repo); branch != "trunk" {
+		t.Errorf("cached DefaultBranch() = %q, want trunk", branch)
+	}
+
+	if err := ForgetRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RepoName(ctx, repo); err == nil {
+		t.Error("RepoName() after ForgetRepo used the cache")
+	}
+
+	worktree.UseFake(f)
+	if _, err := RepoName(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	worktree.UseFake(worktree.NewFake())
+	if err := os.RemoveAll(filepath.Join(repo, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RepoName(ctx, repo); err == nil {
+		t.Error("RepoName() of a removed repository used the cache")
+	}
+}
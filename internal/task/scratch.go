@@ -0,0 +1,130 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// BundleFile is the name of the git bundle of a task's branch in its
+// archive directory.
+const BundleFile = "branch.bundle"
+
+// ScratchDir returns a task's scratch directory for logs, agent artifacts
+// and notes. It lives under ~/.wt/scratch, outside the worktree, so nothing
+// in it is ever committed.
+func ScratchDir(id string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "scratch", id), nil
+}
+
+// ContextFile is the file in a task's scratch directory describing what the
+// task was started from, e.g. a Sentry issue with its stack trace, for
+// agents to read. They find it through WT_TASK_CONTEXT.
+const ContextFile = "context.md"
+
+// ContextPath returns the path of a task's context file, or "" when the
+// task has none.
+func ContextPath(id string) string {
+	dir, err := ScratchDir(id)
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, ContextFile)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// writeContext writes a task's context file.
+func writeContext(id, text string) error {
+	dir, err := ScratchDir(id)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ContextFile), []byte(text), 0o644); err != nil {
+		return fmt.Errorf("failed to write task context: %w", err)
+	}
+	return nil
+}
+
+// ArchiveDir returns the directory a finished task's files are archived to.
+func ArchiveDir(id string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "archive", id), nil
+}
+
+// createScratch creates a task's scratch directory.
+func createScratch(id string) error {
+	dir, err := ScratchDir(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	return nil
+}
+
+// cleanScratch deletes a task's scratch directory, or moves it into the
+// task's archive directory when archive is set. An empty scratch directory
+// is never archived.
+func cleanScratch(id string, archive bool) error {
+	dir, err := ScratchDir(id)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if !archive || len(entries) == 0 {
+		return os.RemoveAll(dir)
+	}
+	dest, err := ArchiveDir(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.Rename(dir, filepath.Join(dest, "scratch")); err != nil {
+		return fmt.Errorf("failed to archive scratch directory: %w", err)
+	}
+	return nil
+}
+
+// bundleBranch saves a task's branch as a git bundle in its archive
+// directory, next to a task.json recording where the branch came from.
+func bundleBranch(ctx context.Context, t *config.Task) error {
+	dir, err := ArchiveDir(t.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := worktree.Bundle(ctx, t.RepoPath, filepath.Join(dir, BundleFile), t.Branch); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "task.json"), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write archived task: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanScratch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		name     string
+		file     bool
+		archive  bool
+		archived bool
+	}{
+		{name: "delete", file: true},
+		{name: "archive", file: true, archive: true, archived: true},
+		{name: "empty is not archived", archive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := "wt-" + filepath.Base(t.Name())
+			if err := createScratch(id); err != nil {
+				t.Fatal(err)
+			}
+			dir, _ := ScratchDir(id)
+			if tt.file {
+				if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("notes"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := cleanScratch(id, tt.archive); err != nil {
+				t.Fatalf("cleanScratch failed: %v", err)
+			}
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("scratch directory still exists: %v", err)
+			}
+			archive, _ := ArchiveDir(id)
+			_, err := os.Stat(filepath.Join(archive, "scratch", "notes.md"))
+			if archived := err == nil; archived != tt.archived {
+				t.Errorf("archived = %v, want %v", archived, tt.archived)
+			}
+		})
+	}
+}
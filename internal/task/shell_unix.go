@@ -0,0 +1,13 @@
+//go:build !windows
+
+package task
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellCommand returns the command running command with sh.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,21 @@
+package task
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand returns the command running command with cmd.exe. The
+// command line is passed as is: cmd does not follow the quoting rules
+// exec applies to arguments.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	shell := os.Getenv("ComSpec")
+	if shell == "" {
+		shell = "cmd.exe"
+	}
+	cmd := exec.CommandContext(ctx, shell)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `"` + shell + `" /S /C "` + command + `"`}
+	return cmd
+}
@@ -0,0 +1,305 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotFile is the name of the file describing a snapshot in its
+// directory, next to a git bundle per task.
+const snapshotFile = "snapshot.yaml"
+
+// snapshotRef is the ref a task's uncommitted changes are saved under while
+// they are bundled.
+const snapshotRef = "refs/wt/snapshot/"
+
+// Snapshot records the tasks that existed at one point in time, so that
+// 'wt snapshot restore' can recreate their worktrees later, on this
+// machine or another.
+type Snapshot struct {
+	Name    string         `yaml:"name" json:"name"`
+	Created time.Time      `yaml:"created" json:"created"`
+	Tasks   []SnapshotTask `yaml:"tasks" json:"tasks"`
+}
+
+// SnapshotTask is a task of a snapshot. Its branch, and Work when set, are
+// kept in a git bundle named after the task.
+type SnapshotTask struct {
+	Task config.Task `yaml:"task" json:"task"`
+	// Base is the branch the task's branch is compared against.
+	Base string `yaml:"base,omitempty" json:"base,omitempty"`
+	// Work is a commit on top of the branch holding the worktree's
+	// uncommitted changes, including untracked files.
+	Work string `yaml:"work,omitempty" json:"work,omitempty"`
+}
+
+// SnapshotDir returns the directory a snapshot is kept in.
+func SnapshotDir(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "snapshots", name), nil
+}
+
+// CreateSnapshot saves every task's branch and uncommitted changes as the
+// snapshot name. An existing snapshot of that name is replaced only with
+// replace. Tasks whose branch is gone are left out with a warning.
+func (m *Manager) CreateSnapshot(ctx context.Context, name string, replace bool) (*Snapshot, error) {
+	dir, err := SnapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		if !replace {
+			return nil, fmt.Errorf("snapshot %s already exists", name)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, fmt.Errorf("failed to remove snapshot %s: %w", name, err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	snap := &Snapshot{Name: name, Created: time.Now()}
+	for _, t := range m.Config.Tasks {
+		st, err := m.snapshotTask(ctx, dir, t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s left out of the snapshot: %v\n", t.ID, err)
+			continue
+		}
+		snap.Tasks = append(snap.Tasks, st)
+	}
+
+	data, err := yaml.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotFile), data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// snapshotTask bundles a task's branch and uncommitted changes into dir.
+func (m *Manager) snapshotTask(ctx context.Context, dir string, t config.Task) (SnapshotTask, error) {
+	if worktree.IsRemote(t.RepoPath) {
+		return SnapshotTask{}, fmt.Errorf("its repository is on another machine")
+	}
+	if !worktree.BranchExists(ctx, t.RepoPath, t.Branch) {
+		return SnapshotTask{}, fmt.Errorf("branch %s no longer exists", t.Branch)
+	}
+	st := SnapshotTask{Task: t, Base: DefaultBranch(ctx, m.Config, t.RepoPath)}
+	st.Task.AgentPID, st.Task.AgentStarted, st.Task.WebPID = 0, "", 0
+	m.updateCommits(ctx, &st.Task)
+
+	refs := []string{"refs/heads/" + t.Branch}
+	if _, err := os.Stat(t.Worktree); err == nil {
+		ref := snapshotRef + t.ID
+		work, err := worktree.SaveWork(ctx, t.Worktree, ref, "wt snapshot of "+t.ID)
+		if err != nil {
+			return SnapshotTask{}, fmt.Errorf("failed to save uncommitted changes: %w", err)
+		}
+		if work != "" {
+			st.Work = work
+			refs = append(refs, ref)
+			defer worktree.DeleteRef(ctx, t.RepoPath, ref)
+		}
+	}
+	if err := worktree.BundleRefs(ctx, t.RepoPath, filepath.Join(dir, t.ID+".bundle"), refs...); err != nil {
+		return SnapshotTask{}, err
+	}
+	return st, nil
+}
+
+// LoadSnapshot reads the snapshot name.
+func LoadSnapshot(name string) (*Snapshot, error) {
+	dir, err := SnapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no snapshot named %s (see 'wt snapshot list')", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snap Snapshot
+	if err := yaml.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", name, err)
+	}
+	return &snap, nil
+}
+
+// ListSnapshots returns the saved snapshots, oldest first.
+func ListSnapshots() ([]Snapshot, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "snapshots"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		snap, err := LoadSnapshot(e.Name())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			continue
+		}
+		snaps = append(snaps, *snap)
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Created.Before(snaps[j].Created) })
+	return snaps, nil
+}
+
+// DeleteSnapshot deletes the snapshot name.
+func DeleteSnapshot(name string) error {
+	if _, err := LoadSnapshot(name); err != nil {
+		return err
+	}
+	dir, err := SnapshotDir(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// Restore outcomes of a snapshot's tasks.
+const (
+	RestoreCreated = "restored"
+	RestoreExists  = "exists"
+	RestoreFailed  = "failed"
+)
+
+// RestoredTask is what RestoreSnapshot did with one task of a snapshot.
+type RestoredTask struct {
+	ID       string `json:"id"`
+	Worktree string `json:"worktree"`
+	Result   string `json:"result"`
+	// Note explains a failure, or why uncommitted changes were not put back.
+	Note string `json:"note,omitempty"`
+}
+
+// RestoreSnapshot recreates the worktrees of a snapshot's tasks at their
+// old paths, with their uncommitted changes, and adds the tasks back. Tasks
+// whose worktree still exists are left alone. A branch missing from its
+// repository is fetched from the snapshot; one that exists is kept as it
+// is, and gets the saved changes only if it has not moved since.
+func (m *Manager) RestoreSnapshot(ctx context.Context, name string) ([]RestoredTask, error) {
+	snap, err := LoadSnapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := SnapshotDir(name)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]RestoredTask, len(snap.Tasks))
+	for i, st := range snap.Tasks {
+		results[i] = m.restoreTask(ctx, dir, st)
+	}
+	return results, nil
+}
+
+func (m *Manager) restoreTask(ctx context.Context, dir string, st SnapshotTask) RestoredTask {
+	t := st.Task
+	res := RestoredTask{ID: t.ID, Worktree: t.Worktree, Result: RestoreFailed}
+	if existing, err := m.Config.FindTask(t.ID); err == nil {
+		if _, err := os.Stat(existing.Worktree); err == nil {
+			res.Result, res.Worktree = RestoreExists, existing.Worktree
+			return res
+		}
+	}
+	if _, err := os.Stat(filepath.Join(t.RepoPath, ".git")); err != nil {
+		res.Note = fmt.Sprintf("repository %s not found; clone it there and restore again", t.RepoPath)
+		return res
+	}
+	if _, err := os.Stat(t.Worktree); err == nil {
+		res.Note = fmt.Sprintf("%s is in the way", t.Worktree)
+		return res
+	}
+
+	bundle := filepath.Join(dir, t.ID+".bundle")
+	branchRef := "refs/heads/" + t.Branch
+	if !worktree.BranchExists(ctx, t.RepoPath, t.Branch) {
+		if err := worktree.FetchBundle(ctx, t.RepoPath, bundle, branchRef+":"+branchRef); err != nil {
+			res.Note = err.Error()
+			return res
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(t.Worktree), 0o755); err != nil {
+		res.Note = fmt.Sprintf("failed to create worktree directory: %v", err)
+		return res
+	}
+	if err := worktree.CreateFromExistingBranch(ctx, t.RepoPath, t.Worktree, t.Branch); err != nil {
+		res.Note = err.Error()
+		return res
+	}
+	res.Result = RestoreCreated
+
+	if st.Work != "" {
+		res.Note = m.restoreWork(ctx, bundle, st)
+	}
+	if err := m.setGitConfig(ctx, t.RepoPath, t.Worktree); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if m.Config.Direnv.Enabled {
+		if err := m.setupDirenv(&t); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if err := createScratch(t.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	t.AgentPID, t.AgentStarted, t.WebPID, t.State = 0, "", 0, ""
+	if _, err := m.Config.FindTask(t.ID); err == nil {
+		if err := m.Config.SetTaskWorktree(t.ID, t.Worktree); err != nil {
+			res.Note = err.Error()
+		}
+		return res
+	}
+	if err := m.Config.AddTask(t); err != nil {
+		res.Result, res.Note = RestoreFailed, err.Error()
+	}
+	return res
+}
+
+// restoreWork puts a task's saved uncommitted changes back into its new
+// worktree, and returns why it did not when it could not.
+func (m *Manager) restoreWork(ctx context.Context, bundle string, st SnapshotTask) string {
+	t := st.Task
+	head, _, err := worktree.BranchCommits(ctx, t.RepoPath, t.Branch, st.Base)
+	if err != nil || head != t.Head {
+		return fmt.Sprintf("%s moved since the snapshot; its uncommitted changes are in %s (%s)", t.Branch, bundle, snapshotRef+t.ID)
+	}
+	if err := worktree.FetchBundle(ctx, t.RepoPath, bundle, snapshotRef+t.ID); err != nil {
+		return err.Error()
+	}
+	if err := worktree.RestoreWork(ctx, t.Worktree, st.Work); err != nil {
+		return fmt.Sprintf("failed to restore uncommitted changes: %v", err)
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+// SyncAction is a reconciliation step proposed by PlanSync.
+type SyncAction struct {
+	Task         config.Task
+	RemoteStatus string
+	Rule         config.SyncRule
+}
+
+// String describes the action for previews.
+func (a SyncAction) String() string {
+	var cause string
+	if a.Rule.Remote != "" {
+		cause = fmt.Sprintf("ticket is %q", a.RemoteStatus)
+	} else {
+		cause = fmt.Sprintf("task is %q", a.Task.Status)
+	}
+	var effect string
+	switch a.Rule.Action {
+	case config.SyncFinish:
+		effect = "finish task"
+	case config.SyncRemove:
+		effect = "remove worktree"
+	case config.SyncTransition:
+		effect = fmt.Sprintf("move ticket to %q", a.Rule.To)
+	case config.SyncSetStatus:
+		effect = fmt.Sprintf("set task status to %q", a.Rule.To)
+	}
+	return fmt.Sprintf("%s (%s): %s → %s", a.Task.ID, a.Task.TicketKey, cause, effect)
+}
+
+// ValidateSyncRules checks that every rule has a known action, one condition
+// and a target where the action needs one.
+func ValidateSyncRules(rules []config.SyncRule) error {
+	for i, r := range rules {
+		if (r.Remote == "") == (r.Local == "") {
+			return fmt.Errorf("sync rule %d: set exactly one of remote or local", i+1)
+		}
+		switch r.Action {
+		case config.SyncFinish, config.SyncRemove:
+		case config.SyncTransition, config.SyncSetStatus:
+			if r.To == "" {
+				return fmt.Errorf("sync rule %d: action %q needs a 'to' status", i+1, r.Action)
+			}
+		default:
+			return fmt.Errorf("sync rule %d: unknown action %q (want finish, remove, transition or status)", i+1, r.Action)
+		}
+	}
+	return nil
+}
+
+// PlanSync compares tasks with the current status of their tickets, keyed
+// by task ID, and returns the actions of the first matching rule per task.
+// Rules whose effect is already in place are skipped.
+func PlanSync(rules []config.SyncRule, tasks []config.Task, remote map[string]string) []SyncAction {
+	var actions []SyncAction
+	for _, t := range tasks {
+		status, ok := remote[t.ID]
+		if !ok {
+			continue
+		}
+		for _, r := range rules {
+			matched := (r.Remote != "" && strings.EqualFold(r.Remote, status)) ||
+				(r.Local != "" && strings.EqualFold(r.Local, t.Status))
+			if !matched {
+				continue
+			}
+			if r.Action == config.SyncTransition && strings.EqualFold(r.To, status) {
+				continue
+			}
+			if r.Action == config.SyncSetStatus && strings.EqualFold(r.To, t.Status) {
+				continue
+			}
+			actions = append(actions, SyncAction{Task: t, RemoteStatus: status, Rule: r})
+			break
+		}
+	}
+	return actions
+}
@@ -0,0 +1,66 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestPlanSync(t *testing.T) {
+	rules := []config.SyncRule{
+		{Remote: "Done", Action: config.SyncFinish},
+		{Local: "review", Action: config.SyncTransition, To: "In Review"},
+		{Remote: "Blocked", Action: config.SyncSetStatus, To: "blocked"},
+	}
+	tasks := []config.Task{
+		{ID: "done", TicketKey: "P-1"},
+		{ID: "review", TicketKey: "P-2", Status: "review"},
+		{ID: "already-in-review", TicketKey: "P-3", Status: "review"},
+		{ID: "blocked", TicketKey: "P-4"},
+		{ID: "already-blocked", TicketKey: "P-5", Status: "blocked"},
+		{ID: "untouched", TicketKey: "P-6"},
+		{ID: "no-ticket"},
+	}
+	remote := map[string]string{
+		"done":              "done",
+		"review":            "In Progress",
+		"already-in-review": "In Review",
+		"blocked":           "Blocked",
+		"already-blocked":   "Blocked",
+		"untouched":         "In Progress",
+	}
+
+	actions := PlanSync(rules, tasks, remote)
+	want := map[string]string{
+		"done":    config.SyncFinish,
+		"review":  config.SyncTransition,
+		"blocked": config.SyncSetStatus,
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("expected %d actions, got %d: %v", len(want), len(actions), actions)
+	}
+	for _, a := range actions {
+		if want[a.Task.ID] != a.Rule.Action {
+			t.Errorf("task %s: expected action %q, got %q", a.Task.ID, want[a.Task.ID], a.Rule.Action)
+		}
+	}
+}
+
+func TestValidateSyncRules(t *testing.T) {
+	tests := []struct {
+		rule    config.SyncRule
+		wantErr bool
+	}{
+		{config.SyncRule{Remote: "Done", Action: config.SyncFinish}, false},
+		{config.SyncRule{Local: "review", Action: config.SyncTransition, To: "In Review"}, false},
+		{config.SyncRule{Local: "review", Action: config.SyncTransition}, true},
+		{config.SyncRule{Remote: "Done", Local: "x", Action: config.SyncFinish}, true},
+		{config.SyncRule{Remote: "Done", Action: "explode"}, true},
+	}
+	for _, tt := range tests {
+		err := ValidateSyncRules([]config.SyncRule{tt.rule})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSyncRules(%+v) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+		}
+	}
+}
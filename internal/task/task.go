@@ -1,19 +1,34 @@
 package task
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bakerweb/wt/internal/agent"
 	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/direnv"
+	"github.com/bakerweb/wt/internal/workspace"
 	"github.com/bakerweb/wt/internal/worktree"
 )
 
 // Manager handles task lifecycle operations.
 type Manager struct {
 	Config *config.Config
+	// Force lets Finish and Remove discard uncommitted changes.
+	Force bool
+	// Archive makes Finish and Remove keep the task's scratch directory
+	// under ~/.wt/archive/<task-id> instead of deleting it.
+	Archive bool
+	// Bundle makes Finish save the task's branch as a git bundle in
+	// ~/.wt/archive/<task-id> before deleting it.
+	Bundle bool
 }
 
 // NewManager creates a new task manager.
@@ -24,47 +39,145 @@ func NewManager(cfg *config.Config) *Manager {
 // StartOptions configures a new task.
 type StartOptions struct {
 	Description string
+	// Notes is stored on the task as its longer description.
+	Notes string
+	// Context is written to the task's context file, for details too long
+	// for notes, such as stack traces.
+	Context     string
 	RepoPath    string
 	Connector   string
 	TicketKey   string
 	TicketTitle string
+	// EpicKey is the ticket's epic, available to branch_template.
+	EpicKey string
+	// BranchPrefix replaces the configured branch_prefix, e.g. for the
+	// hotfixes started from incidents.
+	BranchPrefix string
+	// Parent is the ID of the task this one is a sub-task of.
+	Parent string
+	// Background creates the worktree without checking out files; the
+	// caller starts the checkout with SpawnCheckout.
+	Background bool
+	// Experiment groups sibling tasks started by 'wt experiment'.
+	Experiment string
+	// Variant is appended to the branch and worktree names, so sibling
+	// tasks with the same description don't collide.
+	Variant string
 }
 
-// Start creates a new task with an associated worktree.
-func (m *Manager) Start(opts StartOptions) (*config.Task, error) {
-	repoName, err := worktree.RepoName(opts.RepoPath)
+// Start creates a new task with an associated worktree. It is a
+// transaction: when a step fails, or ctx is canceled, before the task is
+// saved, what was created is removed again.
+func (m *Manager) Start(ctx context.Context, opts StartOptions) (_ *config.Task, err error) {
+	repoName, err := RepoName(ctx, opts.RepoPath)
 	if err != nil {
 		return nil, err
 	}
 
 	id := generateID()
 	prefix := m.Config.BranchPrefix
+	if opts.BranchPrefix != "" {
+		prefix = opts.BranchPrefix
+	}
+	nameOpts := worktree.NameOptions{MaxLength: m.Config.BranchMaxLength, DropStopwords: m.Config.BranchStopwords}
 
 	var branch string
-	if opts.TicketKey != "" {
+	if m.Config.BranchTemplate != "" {
+		summary := opts.TicketTitle
+		if summary == "" {
+			summary = opts.Description
+		}
+		branch, err = worktree.BranchNameFromTemplate(m.Config.BranchTemplate, prefix, opts.TicketKey, opts.EpicKey, summary, nameOpts)
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.TicketKey != "" {
 		title := opts.TicketTitle
 		if title == "" {
 			title = opts.Description
 		}
-		branch = worktree.BranchNameFromTicket(prefix, opts.TicketKey, title)
+		branch = worktree.BranchNameFromTicket(prefix, opts.TicketKey, title, nameOpts)
 	} else {
-		branch = worktree.BranchName(prefix, opts.Description)
+		branch = worktree.BranchName(prefix, opts.Description, nameOpts)
 	}
-
-	// Check if branch already exists
-	if worktree.BranchExists(opts.RepoPath, branch) {
-		return nil, fmt.Errorf("branch %q already exists; use a different description or remove the existing branch", branch)
+	dirName := worktree.SanitizeBranchNameWith(opts.Description, nameOpts)
+	if opts.Variant != "" && branch != "" {
+		branch += "-" + opts.Variant
+		dirName += "-" + opts.Variant
 	}
 
-	wtPath := filepath.Join(m.Config.WorktreesBase, repoName, worktree.SanitizeBranchName(opts.Description))
+	if err := worktree.CheckBranchName(ctx, opts.RepoPath, branch); err != nil {
+		return nil, err
+	}
+	ref, err := worktree.ConflictingRef(ctx, opts.RepoPath, branch)
+	if err != nil {
+		return nil, err
+	}
+	if ref != "" {
+		return nil, fmt.Errorf("%w: %q collides with %s; use a different description or remove the existing branch", worktree.ErrBranchConflict, branch, ref)
+	}
 
-	if err := os.MkdirAll(filepath.Dir(wtPath), 0o755); err != nil {
+	wtPath := filepath.Join(m.Config.WorktreesBase, repoName, dirName)
+	if host, base := worktree.SplitHost(m.Config.WorktreesBase); host != "" {
+		// Paths on the other machine are POSIX paths, whatever this one
+		// uses, and git creates the directories there.
+		wtPath = worktree.JoinHost(host, path.Join(base, repoName, dirName))
+	} else if err := os.MkdirAll(filepath.Dir(wtPath), 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
 	}
+	provider := m.Config.Repo(opts.RepoPath, wtPath).Workspace
+	if _, err := workspace.Get(provider); err != nil {
+		return nil, err
+	}
+	// Simulated worktrees are empty, so there is nothing to run remotely.
+	if workspace.IsLocal(provider) || worktree.IsRemote(wtPath) || worktree.UsingFake() {
+		provider = ""
+	}
+
+	if !opts.Background && worktree.IsPartialClone(ctx, opts.RepoPath) {
+		fmt.Fprintln(os.Stderr, "note: partial clone detected; checkout may download missing objects (use --background to return immediately)")
+	}
 
-	if err := worktree.Create(opts.RepoPath, wtPath, branch); err != nil {
+	var tx transaction
+	defer func() {
+		if err != nil && len(tx.undo) > 0 {
+			fmt.Fprintln(os.Stderr, "Start failed; removing what it created...")
+			tx.rollback(ctx)
+		}
+	}()
+	// Git may create the branch, and part of the worktree, even when
+	// creating the worktree fails. A directory that was there before is
+	// only removed once it is known to be the new worktree, and a branch
+	// that appeared since the check above, say from a concurrent start,
+	// is someone else's and is never deleted.
+	if !worktree.BranchExists(ctx, opts.RepoPath, branch) {
+		tx.add(m.undoBranch(opts.RepoPath, branch))
+	}
+	_, statErr := os.Stat(wtPath)
+	newDir := os.IsNotExist(statErr) && !worktree.IsRemote(wtPath)
+	if newDir {
+		tx.add(m.undoWorktree(opts.RepoPath, wtPath))
+	}
+	if err := worktree.Create(ctx, opts.RepoPath, wtPath, branch, worktree.CreateOptions{NoCheckout: opts.Background}); err != nil {
 		return nil, err
 	}
+	if !newDir {
+		tx.add(m.undoWorktree(opts.RepoPath, wtPath))
+	}
+	if err := m.setGitConfig(ctx, opts.RepoPath, wtPath); err != nil {
+		// Non-fatal: the worktree still works with the repository's config
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := createScratch(id); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	} else {
+		tx.add(undoScratch(id))
+		if opts.Context != "" {
+			if err := writeContext(id, opts.Context); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+		}
+	}
 
 	task := config.Task{
 		ID:          id,
@@ -75,8 +188,42 @@ func (m *Manager) Start(opts StartOptions) (*config.Task, error) {
 		Connector:   opts.Connector,
 		TicketKey:   opts.TicketKey,
 		Created:     time.Now(),
+		Parent:      opts.Parent,
+		Notes:       opts.Notes,
+		Experiment:  opts.Experiment,
+		Workspace:   provider,
+	}
+	m.updateCommits(ctx, &task)
+	if m.Config.Direnv.Enabled && !worktree.IsRemote(wtPath) {
+		if port, err := m.allocatePort(); err != nil {
+			// Non-fatal: the .envrc just has no WT_PORT
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			task.Port = port
+		}
+	}
+	if opts.Background {
+		// Files are populated later by CompleteCheckout, which also sets up direnv.
+		task.State = config.StatePreparing
+	} else if m.Config.Direnv.Enabled && !worktree.IsRemote(wtPath) {
+		if err := m.setupDirenv(&task); err != nil {
+			// Non-fatal: the worktree is usable without .envrc
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
 	}
 
+	if provider != "" && !opts.Background {
+		if err := m.createWorkspace(ctx, &task); err != nil {
+			// Non-fatal: 'wt up' creates it later
+			fmt.Fprintf(os.Stderr, "warning: %v; run 'wt up %s' to try again\n", err, task.ID)
+		} else {
+			tx.add(undoWorkspace(&task))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("start interrupted: %w", err)
+	}
 	if err := m.Config.AddTask(task); err != nil {
 		return nil, fmt.Errorf("task created but failed to save: %w", err)
 	}
@@ -84,49 +231,386 @@ func (m *Manager) Start(opts StartOptions) (*config.Task, error) {
 	return &task, nil
 }
 
+// setGitConfig applies the configured identity and git config to a new
+// worktree. A leading ~/ in values is expanded for keys like
+// user.signingkey that git reads as plain strings.
+func (m *Manager) setGitConfig(ctx context.Context, repoPath, wtPath string) error {
+	values, err := m.Config.WorktreeGitConfig(repoPath, wtPath)
+	if err != nil {
+		return err
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for k, v := range values {
+			if rest, ok := strings.CutPrefix(v, "~/"); ok {
+				values[k] = filepath.Join(home, rest)
+			}
+		}
+	}
+	return worktree.SetConfig(ctx, repoPath, wtPath, values)
+}
+
+// updateCommits records the tip of a task's branch and its merge base with
+// the base branch on t; the caller persists the config. Failures are only
+// reported, since the task is usable without them.
+func (m *Manager) updateCommits(ctx context.Context, t *config.Task) {
+	head, mergeBase, err := worktree.BranchCommits(ctx, t.RepoPath, t.Branch, DefaultBranch(ctx, m.Config, t.RepoPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return
+	}
+	t.Head, t.MergeBase = head, mergeBase
+}
+
+// saveCommits is updateCommits for a task already in the config: the
+// commits are persisted right away, so a later update of the task doesn't
+// drop them.
+func (m *Manager) saveCommits(ctx context.Context, t *config.Task) {
+	head, mergeBase, err := worktree.BranchCommits(ctx, t.RepoPath, t.Branch, DefaultBranch(ctx, m.Config, t.RepoPath))
+	if err == nil {
+		err = m.Config.SetTaskCommits(t.ID, head, mergeBase)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// Env returns the environment variables describing a task, including its
+// scratch directory and any configured build cache locations.
+func (m *Manager) Env(t *config.Task) (map[string]string, error) {
+	env, err := CacheEnv(m.Config.BuildCache, t)
+	if err != nil {
+		return nil, err
+	}
+	env["WT_TASK_ID"] = t.ID
+	env["WT_BRANCH"] = t.Branch
+	env["WT_WORKTREE"] = t.Worktree
+	env["WT_REPO_PATH"] = t.RepoPath
+	scratch, err := ScratchDir(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	env["WT_SCRATCH_DIR"] = scratch
+	if path := ContextPath(t.ID); path != "" {
+		env["WT_TASK_CONTEXT"] = path
+	}
+	if t.TicketKey != "" {
+		env["WT_TICKET_KEY"] = t.TicketKey
+	}
+	if t.Port != 0 {
+		env["WT_PORT"] = strconv.Itoa(t.Port)
+	}
+	return env, nil
+}
+
+func (m *Manager) setupDirenv(t *config.Task) error {
+	tmpl, err := direnv.LoadTemplate(m.Config.Direnv.Template)
+	if err != nil {
+		return err
+	}
+	env, err := m.Env(t)
+	if err != nil {
+		return err
+	}
+	written, err := direnv.Write(t.Worktree, tmpl, direnv.Data{
+		TaskID:      t.ID,
+		Description: t.Description,
+		Branch:      t.Branch,
+		Worktree:    t.Worktree,
+		TicketKey:   t.TicketKey,
+		Port:        t.Port,
+		Env:         env,
+	})
+	if err != nil || !written {
+		return err
+	}
+	return direnv.Allow(t.Worktree)
+}
+
 // Finish removes the worktree and cleans up the task.
-func (m *Manager) Finish(id string) (*config.Task, error) {
+func (m *Manager) Finish(ctx context.Context, id string) (*config.Task, error) {
 	task, err := m.Config.FindTask(id)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := worktree.Remove(task.RepoPath, task.Worktree); err != nil {
-		return nil, fmt.Errorf("failed to remove worktree: %w", err)
+	if err := m.checkUnlocked(task); err != nil {
+		return nil, err
+	}
+	if err := m.checkClean(ctx, task); err != nil {
+		return nil, err
+	}
+	if err := checkBranchFree(ctx, task); err != nil {
+		return nil, err
+	}
+	m.updateCommits(ctx, task)
+	if _, err := stopWeb(task); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := m.deleteWorkspace(ctx, task); err != nil {
+		return nil, err
+	}
+	if m.Bundle {
+		if err := bundleBranch(ctx, task); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.removeWorktree(ctx, task); err != nil {
+		return nil, err
 	}
 
-	if err := worktree.DeleteBranch(task.RepoPath, task.Branch); err != nil {
+	if err := worktree.DeleteBranch(ctx, task.RepoPath, task.Branch); err != nil {
 		// Non-fatal: branch might have been merged/deleted already
 		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
 	}
 
+	if err := removeTaskCache(m.Config.BuildCache, task.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to remove build cache: %v\n", err)
+	}
+	if err := cleanScratch(task.ID, m.Archive); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := recordUsage(m.Config, task); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := worktree.DeleteCheckpoints(ctx, task.RepoPath, task.ID, 0); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	// RemoveTask shifts the tasks after this one into its slot.
+	removed := *task
 	if err := m.Config.RemoveTask(id); err != nil {
 		return nil, err
 	}
 
-	return task, nil
+	return &removed, nil
+}
+
+// checkUnlocked refuses to delete a worktree that is locked, or that an
+// agent launched by wt is still running in, unless Force is set. The agent
+// itself may finish its task.
+func (m *Manager) checkUnlocked(t *config.Task) error {
+	if m.Force {
+		return nil
+	}
+	if t.Lock != nil {
+		return fmt.Errorf("%w: %s is locked by %s; run 'wt unlock %s' or use --force", config.ErrTaskLocked, t.ID, t.Lock, t.ID)
+	}
+	if agent.IsAlive(t.AgentPID, t.AgentStarted) && !agent.IsAncestor(t.AgentPID) {
+		return fmt.Errorf("%w: agent %s is running in %s (pid %d); exit it or use --force", config.ErrTaskLocked, t.Agent, t.Worktree, t.AgentPID)
+	}
+	return nil
+}
+
+// checkClean refuses to delete a worktree with uncommitted changes unless
+// Force is set. A missing worktree has nothing to lose, and files wt
+// generated itself don't count.
+func (m *Manager) checkClean(ctx context.Context, t *config.Task) error {
+	if m.Force {
+		return nil
+	}
+	st, err := worktree.Status(ctx, t.Worktree)
+	if err != nil {
+		return nil
+	}
+	if n := m.changes(st); n > 0 {
+		return fmt.Errorf("%w: %d changed file(s) in %s; commit them or use --force", worktree.ErrDirty, n, t.Worktree)
+	}
+	return nil
+}
+
+// changes counts the uncommitted changes of a worktree, leaving out the
+// untracked files wt writes itself, such as the .envrc of direnv
+// integration.
+func (m *Manager) changes(st worktree.StatusInfo) int {
+	n := st.Changed
+	for _, path := range st.Untracked {
+		if m.Config.Direnv.Enabled && filepath.ToSlash(path) == direnv.FileName {
+			n--
+		}
+	}
+	return n
+}
+
+// checkBranchFree refuses to finish a task whose branch is also checked
+// out outside its worktree, e.g. in a worktree wt doesn't manage: deleting
+// the branch would leave that checkout on a branch that no longer exists.
+// Force doesn't override this; the task can still be removed, keeping the
+// branch. If the worktrees can't be listed, the branch deletion itself is
+// left to fail.
+func checkBranchFree(ctx context.Context, t *config.Task) error {
+	paths, err := worktree.CheckedOut(ctx, t.RepoPath, t.Branch)
+	if err != nil {
+		return nil
+	}
+	// git lists worktrees with symlinks resolved.
+	own := filepath.Clean(t.Worktree)
+	if resolved, err := filepath.EvalSymlinks(own); err == nil {
+		own = resolved
+	}
+	for _, p := range paths {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			p = resolved
+		}
+		if filepath.Clean(p) != own {
+			return fmt.Errorf("%w: %s is also checked out in %s; switch that checkout to another branch, or run 'wt remove %s' to keep the branch", worktree.ErrBranchCheckedOut, t.Branch, p, t.ID)
+		}
+	}
+	return nil
+}
+
+// removeWorktree removes a task's worktree and the directories left empty
+// above it. A branch-only task has none yet.
+func (m *Manager) removeWorktree(ctx context.Context, t *config.Task) error {
+	if t.State == config.StateBranchOnly {
+		return nil
+	}
+	if err := worktree.Remove(ctx, t.RepoPath, t.Worktree); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	if err := removeEmptyParents(filepath.Dir(t.Worktree), m.Config.WorktreesBase); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return nil
 }
 
 // Remove removes a worktree but keeps the branch.
-func (m *Manager) Remove(id string) (*config.Task, error) {
+func (m *Manager) Remove(ctx context.Context, id string) (*config.Task, error) {
 	task, err := m.Config.FindTask(id)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := worktree.Remove(task.RepoPath, task.Worktree); err != nil {
-		return nil, fmt.Errorf("failed to remove worktree: %w", err)
+	if err := m.checkUnlocked(task); err != nil {
+		return nil, err
+	}
+	if err := m.checkClean(ctx, task); err != nil {
+		return nil, err
+	}
+	m.updateCommits(ctx, task)
+	if _, err := stopWeb(task); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := m.deleteWorkspace(ctx, task); err != nil {
+		return nil, err
+	}
+	if err := m.removeWorktree(ctx, task); err != nil {
+		return nil, err
 	}
 
+	if err := removeTaskCache(m.Config.BuildCache, task.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to remove build cache: %v\n", err)
+	}
+	if err := cleanScratch(task.ID, m.Archive); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := recordUsage(m.Config, task); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := worktree.DeleteCheckpoints(ctx, task.RepoPath, task.ID, 0); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	// RemoveTask shifts the tasks after this one into its slot.
+	removed := *task
 	if err := m.Config.RemoveTask(id); err != nil {
 		return nil, err
 	}
 
+	return &removed, nil
+}
+
+// Move moves a task's worktree to newPath, fixing symlinks that the move
+// would break and regenerating a .envrc written by wt.
+func (m *Manager) Move(ctx context.Context, id, newPath string) (*config.Task, error) {
+	task, err := m.Config.FindTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkUnlocked(task); err != nil {
+		return nil, err
+	}
+	if task.State == config.StatePreparing {
+		return nil, fmt.Errorf("task %s is still checking out files in the background", task.ID)
+	}
+	if worktree.IsRemote(task.Worktree) {
+		return nil, fmt.Errorf("cannot move %s: its worktree is on another machine", task.ID)
+	}
+	newPath, err = filepath.Abs(newPath)
+	if err != nil {
+		return nil, err
+	}
+	if newPath == task.Worktree {
+		return task, nil
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return nil, fmt.Errorf("%s already exists", newPath)
+	}
+	if task.State == config.StateBranchOnly {
+		// Materialize creates the worktree where the task says.
+		return task, m.Config.SetTaskWorktree(id, newPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	old := *task
+	if err := worktree.Move(ctx, task.RepoPath, task.Worktree, newPath); err != nil {
+		return nil, err
+	}
+	if err := m.Config.SetTaskWorktree(id, newPath); err != nil {
+		return nil, fmt.Errorf("worktree moved but failed to save: %w", err)
+	}
+	m.saveCommits(ctx, task)
+	if _, err := worktree.RelinkSymlinks(old.Worktree, newPath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if err := removeEmptyParents(filepath.Dir(old.Worktree), m.Config.WorktreesBase); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if m.Config.Direnv.Enabled {
+		if err := m.refreshDirenv(&old, task); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
 	return task, nil
 }
 
-func generateID() string {
-	b := make([]byte, 4)
+// refreshDirenv regenerates the .envrc of a moved task if it is still the
+// one wt wrote for the old path; edited files are left alone.
+func (m *Manager) refreshDirenv(old, t *config.Task) error {
+	tmpl, err := direnv.LoadTemplate(m.Config.Direnv.Template)
+	if err != nil {
+		return err
+	}
+	env, err := m.Env(old)
+	if err != nil {
+		return err
+	}
+	want, err := direnv.Render(tmpl, direnv.Data{
+		TaskID:      old.ID,
+		Description: old.Description,
+		Branch:      old.Branch,
+		Worktree:    old.Worktree,
+		TicketKey:   old.TicketKey,
+		Port:        old.Port,
+		Env:         env,
+	})
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(t.Worktree, direnv.FileName)
+	if got, err := os.ReadFile(path); err != nil || string(got) != want {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return m.setupDirenv(t)
+}
+
+// NewExperimentID returns a new ID for a group of sibling tasks.
+func NewExperimentID() string {
+	b := make([]byte, 3)
 	rand.Read(b)
-	return fmt.Sprintf("wt-%x", b)
+	return fmt.Sprintf("exp-%x", b)
 }
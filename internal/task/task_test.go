@@ -0,0 +1,237 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/direnv"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// newFakeManager returns a Manager on fake git holding a repository, app,
+// on main, with HOME and worktrees_base in temporary directories.
+func newFakeManager(t *testing.T) (*Manager, *worktree.Fake, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	f := worktree.NewFake()
+	worktree.UseFake(f)
+	t.Cleanup(func() { worktree.UseFake(nil) })
+	repo := filepath.Join(t.TempDir(), "app")
+	f.AddRepo(repo, "main")
+
+	cfg := config.DefaultConfig()
+	cfg.WorktreesBase = t.TempDir()
+	return NewManager(cfg), f, repo
+}
+
+func TestStartFinish(t *testing.T) {
+	m, f, repo := newFakeManager(t)
+	cfg := m.Config
+	ctx := context.Background()
+
+	started, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login", TicketKey: "APP-7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Branch != "feature/app-7-fix-login" || started.Worktree != filepath.Join(cfg.WorktreesBase, "app", "fix-login") {
+		t.Errorf("started %+v", started)
+	}
+	if started.Head == "" || started.Head != started.MergeBase {
+		t.Errorf("head %q, merge base %q; want the commit of main", started.Head, started.MergeBase)
+	}
+	if _, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login", TicketKey: "APP-7"}); err == nil {
+		t.Error("starting the same task twice succeeded")
+	}
+
+	f.Commit(started.Worktree, "Fix login")
+	f.SetChanged(started.Worktree, 1)
+	if _, err := m.Finish(ctx, started.ID); !errors.Is(err, worktree.ErrDirty) {
+		t.Fatalf("Finish() with changes = %v, want ErrDirty", err)
+	}
+	f.SetChanged(started.Worktree, 0)
+	finished, err := m.Finish(ctx, started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finished.Head == started.Head {
+		t.Error("Finish() did not record the branch's last commit")
+	}
+	if _, err := os.Stat(started.Worktree); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists: %v", err)
+	}
+	if worktree.BranchExists(ctx, repo, started.Branch) {
+		t.Error("branch still exists")
+	}
+	if len(cfg.Tasks) != 0 {
+		t.Errorf("tasks = %+v", cfg.Tasks)
+	}
+}
+
+func TestFinishBranchCheckedOut(t *testing.T) {
+	m, f, repo := newFakeManager(t)
+	cfg := m.Config
+
+	// git lists worktrees with symlinks resolved; a task's own worktree
+	// under a symlinked base is not another checkout.
+	base := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "real"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(base, "real"), filepath.Join(base, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	cfg.WorktreesBase = filepath.Join(base, "link")
+	m.Force = true
+	ctx := context.Background()
+
+	started, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkBranchFree(ctx, started); err != nil {
+		t.Fatalf("checkBranchFree() = %v for a branch only checked out in the task's worktree", err)
+	}
+	if err := f.Switch(repo, started.Branch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Finish(ctx, started.ID); !errors.Is(err, worktree.ErrBranchCheckedOut) {
+		t.Fatalf("Finish() = %v, want ErrBranchCheckedOut", err)
+	}
+	if _, err := os.Stat(started.Worktree); err != nil {
+		t.Errorf("worktree removed: %v", err)
+	}
+	if len(cfg.Tasks) != 1 {
+		t.Errorf("tasks = %+v", cfg.Tasks)
+	}
+
+	if err := f.Switch(repo, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Finish(ctx, started.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFinishDirenv(t *testing.T) {
+	m, _, repo := newFakeManager(t)
+	m.Config.Direnv.Enabled = true
+	ctx := context.Background()
+
+	started, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envrc, err := os.ReadFile(filepath.Join(started.Worktree, direnv.FileName))
+	if err != nil {
+		t.Fatalf("no .envrc written: %v", err)
+	}
+	if started.Port == 0 || !strings.Contains(string(envrc), fmt.Sprintf("export WT_PORT='%d'", started.Port)) {
+		t.Errorf("port %d not exported by .envrc:\n%s", started.Port, envrc)
+	}
+	// The .envrc wt wrote is not work to lose; other untracked files are.
+	notes := filepath.Join(started.Worktree, "notes.md")
+	if err := os.WriteFile(notes, []byte("todo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Finish(ctx, started.ID); !errors.Is(err, worktree.ErrDirty) {
+		t.Fatalf("Finish() with an untracked file = %v, want ErrDirty", err)
+	}
+	if err := os.Remove(notes); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Finish(ctx, started.ID); err != nil {
+		t.Fatalf("Finish() with only the generated .envrc = %v", err)
+	}
+}
+
+func TestStartRollback(t *testing.T) {
+	m, _, repo := newFakeManager(t)
+	cfg := m.Config
+	// Saving the new task fails: ~/.wt is not a directory.
+	if err := os.WriteFile(filepath.Join(os.Getenv("HOME"), ".wt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login"}); err == nil {
+		t.Fatal("Start() succeeded without saving the task")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.WorktreesBase, "app")); !os.IsNotExist(err) {
+		t.Errorf("worktree directory still exists: %v", err)
+	}
+	if worktree.BranchExists(ctx, repo, "feature/fix-login") {
+		t.Error("branch still exists")
+	}
+	if len(cfg.Tasks) != 0 {
+		t.Errorf("tasks = %+v, want none", cfg.Tasks)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	m, f, repo := newFakeManager(t)
+	cfg := m.Config
+	ctx := context.Background()
+	started, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scratch, err := ScratchDir(started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetChanged(started.Worktree, 1)
+
+	if err := m.Abort(ctx, started.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{started.Worktree, scratch} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s still exists: %v", dir, err)
+		}
+	}
+	if worktree.BranchExists(ctx, repo, started.Branch) {
+		t.Error("branch still exists")
+	}
+	if len(cfg.Tasks) != 0 {
+		t.Errorf("tasks = %+v, want none", cfg.Tasks)
+	}
+}
+
+func TestMoveRecordsCommits(t *testing.T) {
+	m, f, repo := newFakeManager(t)
+	ctx := context.Background()
+	started, err := m.Start(ctx, StartOptions{RepoPath: repo, Description: "Fix login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := f.Commit(started.Worktree, "Fix login")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPath := filepath.Join(m.Config.WorktreesBase, "moved")
+	moved, err := m.Move(ctx, started.ID, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Worktree != newPath || moved.Head != head {
+		t.Errorf("moved task at %s with head %.12s, want %s with head %.12s", moved.Worktree, moved.Head, newPath, head)
+	}
+	saved, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := saved.FindTask(started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Worktree != newPath || got.Head != head {
+		t.Errorf("saved task at %s with head %.12s, want %s with head %.12s", got.Worktree, got.Head, newPath, head)
+	}
+}
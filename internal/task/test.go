@@ -0,0 +1,97 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// ErrTestsFailed is returned when a task's test command fails.
+var ErrTestsFailed = errors.New("tests failed")
+
+// ErrNoTestCommand is returned when a task's repository has no test_command.
+var ErrNoTestCommand = errors.New("no test_command configured")
+
+// TestLogPath returns the log of a task's last test run, kept in its
+// scratch directory.
+func TestLogPath(id string) (string, error) {
+	dir, err := ScratchDir(id)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "test.log"), nil
+}
+
+// RunTests runs the repository's test_command in a task's worktree with the
+// task's environment, writing the output to out and to the test log. A
+// failing command is reported in the result, not as an error; the caller
+// records the result with Config.SetTestResult.
+func (m *Manager) RunTests(ctx context.Context, t *config.Task, out io.Writer) (config.TestResult, error) {
+	command := m.Config.TestCommand(t.RepoPath, t.Worktree)
+	if command == "" {
+		return config.TestResult{}, fmt.Errorf("%w for %s; set repos.<repo>.test_command", ErrNoTestCommand, t.RepoPath)
+	}
+	if _, err := os.Stat(t.Worktree); err != nil {
+		return config.TestResult{}, fmt.Errorf("worktree %s no longer exists: %w", t.Worktree, err)
+	}
+	env, err := m.Env(t)
+	if err != nil {
+		return config.TestResult{}, err
+	}
+	logPath, err := TestLogPath(t.ID)
+	if err != nil {
+		return config.TestResult{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return config.TestResult{}, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return config.TestResult{}, fmt.Errorf("failed to create test log: %w", err)
+	}
+	defer logFile.Close()
+
+	result := config.TestResult{Time: time.Now()}
+	if commit, err := worktree.LastCommit(ctx, t.Worktree); err == nil {
+		result.Commit = commit.Hash
+	}
+	passed, err := runShell(ctx, t, env, command, io.MultiWriter(out, logFile))
+	if err != nil {
+		return config.TestResult{}, fmt.Errorf("failed to run test command: %w", err)
+	}
+	result.Passed = passed
+	result.Duration = time.Since(result.Time).Round(time.Millisecond)
+	return result, nil
+}
+
+// runShell runs a shell command in a task's worktree with env added to the
+// environment, and reports whether it exited successfully. The shell is sh,
+// or cmd.exe on Windows. An error means the command could not be run at
+// all.
+func runShell(ctx context.Context, t *config.Task, env map[string]string, command string, out io.Writer) (bool, error) {
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = t.Worktree
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return false, err
+	}
+	return err == nil, nil
+}
@@ -0,0 +1,107 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/workspace"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// rollbackTimeout bounds undoing a failed operation.
+const rollbackTimeout = 30 * time.Second
+
+// transaction records how to undo each step of an operation as it is
+// done, so that an operation failing part way leaves nothing behind.
+type transaction struct {
+	undo []func(ctx context.Context) error
+}
+
+// add records how to undo the step just done.
+func (tx *transaction) add(undo func(ctx context.Context) error) {
+	tx.undo = append(tx.undo, undo)
+}
+
+// rollback undoes the recorded steps, last first. It runs on a context of
+// its own, as ctx may be what was canceled; failures are warnings.
+func (tx *transaction) rollback(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: rollback: %v\n", err)
+		}
+	}
+	tx.undo = nil
+}
+
+// The steps of starting a task, each undone by the function returned.
+// They tolerate the step not having been done, or only partly.
+
+func (m *Manager) undoBranch(repoPath, branch string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if !worktree.BranchExists(ctx, repoPath, branch) {
+			return nil
+		}
+		return worktree.DeleteBranch(ctx, repoPath, branch)
+	}
+}
+
+func (m *Manager) undoWorktree(repoPath, wtPath string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if _, err := os.Stat(wtPath); err == nil || worktree.IsRemote(wtPath) {
+			if err := worktree.Remove(ctx, repoPath, wtPath); err != nil {
+				return err
+			}
+		}
+		if !worktree.IsRemote(wtPath) {
+			if err := removeEmptyParents(filepath.Dir(wtPath), m.Config.WorktreesBase); err != nil {
+				return err
+			}
+		}
+		// Interrupted git removes a worktree it was adding, but may leave
+		// its administrative files.
+		return worktree.Prune(ctx, repoPath)
+	}
+}
+
+func undoScratch(id string) func(context.Context) error {
+	return func(context.Context) error {
+		return cleanScratch(id, false)
+	}
+}
+
+func undoWorkspace(t *config.Task) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if workspace.IsLocal(t.Workspace) || t.WorkspaceID == "" {
+			return nil
+		}
+		p, err := workspace.Get(t.Workspace)
+		if err != nil {
+			return err
+		}
+		// Just created, the workspace holds no work of its own.
+		return p.Delete(ctx, t.WorkspaceID, true)
+	}
+}
+
+// Abort undoes a task that was just started, when setting it up further
+// failed: it deletes its workspace, worktree, branch and scratch directory,
+// and forgets the task. Unlike Remove, it doesn't check for changes.
+func (m *Manager) Abort(ctx context.Context, id string) error {
+	t, err := m.Config.FindTask(id)
+	if err != nil {
+		return err
+	}
+	var tx transaction
+	tx.add(m.undoBranch(t.RepoPath, t.Branch))
+	tx.add(m.undoWorktree(t.RepoPath, t.Worktree))
+	tx.add(undoScratch(t.ID))
+	tx.add(undoWorkspace(t))
+	tx.rollback(ctx)
+	return m.Config.RemoveTask(id)
+}
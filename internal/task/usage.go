@@ -0,0 +1,125 @@
+package task
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+)
+
+// UsageRecord is the agent usage of a task that was finished or removed.
+type UsageRecord struct {
+	Task        string       `json:"task"`
+	Description string       `json:"description"`
+	Repo        string       `json:"repo"`
+	Connector   string       `json:"connector,omitempty"`
+	Ticket      string       `json:"ticket,omitempty"`
+	Agent       string       `json:"agent,omitempty"`
+	Finished    time.Time    `json:"finished"`
+	Usage       config.Usage `json:"usage"`
+}
+
+// UsageLogPath returns the log of the usage of finished tasks.
+func UsageLogPath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "usage.jsonl"), nil
+}
+
+// TaskUsage reads what the agents run in a task's worktree since the task
+// was created used. Tokens the agent reported no cost for are priced with
+// token_prices.
+func TaskUsage(cfg *config.Config, t *config.Task) (config.Usage, error) {
+	u, err := agent.ReadUsage(t.Worktree, t.Created)
+	if err != nil {
+		return config.Usage{}, err
+	}
+	total := config.Usage{Sessions: u.Sessions}
+	for model, m := range u.Models {
+		if m.CostUSD == 0 {
+			if price, ok := cfg.TokenPrice(model); ok {
+				m.CostUSD = price.Cost(m)
+			}
+		}
+		m.Sessions = 0
+		total.Add(m)
+	}
+	return total, nil
+}
+
+// recordUsage appends the agent usage of a task that is going away to the
+// usage log, so 'wt stats' still counts it. Tasks without usage are left
+// out.
+func recordUsage(cfg *config.Config, t *config.Task) error {
+	u, err := TaskUsage(cfg, t)
+	if err != nil {
+		return err
+	}
+	if u.Tokens() == 0 {
+		return nil
+	}
+	path, err := UsageLogPath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(UsageRecord{
+		Task:        t.ID,
+		Description: t.Description,
+		Repo:        t.RepoPath,
+		Connector:   t.Connector,
+		Ticket:      t.TicketKey,
+		Agent:       t.Agent,
+		Finished:    time.Now(),
+		Usage:       u,
+	})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage log: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// FinishedUsage returns the usage log, oldest first.
+func FinishedUsage() ([]UsageRecord, error) {
+	path, err := UsageLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage log: %w", err)
+	}
+	defer f.Close()
+	var records []UsageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r UsageRecord
+		if json.Unmarshal(scanner.Bytes(), &r) == nil {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage log: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,169 @@
+package task
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/agent"
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// DefaultWebCommand is the web editor 'wt up --web' runs when web_command
+// is not set. {port}, {dir} and {id} are replaced with the task's port,
+// worktree and ID.
+const DefaultWebCommand = "code-server --bind-addr 127.0.0.1:{port} --disable-telemetry {dir}"
+
+// DefaultWebPortBase is the first port allocated to web editors when
+// web_port_base is not set.
+const DefaultWebPortBase = 8100
+
+// webPortRange is how many ports from the base are tried.
+const webPortRange = 1000
+
+// WebLogPath returns the log file of a task's web editor.
+func WebLogPath(id string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "logs", id+"-web.log"), nil
+}
+
+// WebURL returns the address of a task's web editor.
+func WebURL(t *config.Task) string {
+	return fmt.Sprintf("http://127.0.0.1:%d/", t.WebPort)
+}
+
+// StartWeb starts a browser-based editor, code-server unless web_command
+// says otherwise, in a task's worktree on the port allocated to the task.
+// The editor runs detached, logging to WebLogPath, until StopWeb or until
+// the task is finished or removed. It reports whether the editor was
+// started, rather than found running.
+func (m *Manager) StartWeb(id string) (*config.Task, bool, error) {
+	t, err := m.Config.FindTask(id)
+	if err != nil {
+		return nil, false, err
+	}
+	if agent.IsRunning(t.WebPID) {
+		return t, false, nil
+	}
+	if worktree.IsRemote(t.Worktree) {
+		return nil, false, fmt.Errorf("%s is on another machine; run 'wt up --web' there", t.ID)
+	}
+	if _, err := os.Stat(t.Worktree); err != nil {
+		return nil, false, fmt.Errorf("worktree %s no longer exists: %w", t.Worktree, err)
+	}
+	port := t.WebPort
+	if port == 0 {
+		if port, err = m.allocatePort(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	command := m.Config.WebCommand
+	if command == "" {
+		command = DefaultWebCommand
+	}
+	argv := agent.ParseAgentArgs(command)
+	r := strings.NewReplacer("{port}", strconv.Itoa(port), "{dir}", t.Worktree, "{id}", t.ID)
+	for i := range argv {
+		argv[i] = r.Replace(argv[i])
+	}
+	env, err := m.Env(t)
+	if err != nil {
+		return nil, false, err
+	}
+
+	logPath, err := WebLogPath(t.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return nil, false, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create web editor log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = t.Worktree
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	agent.Detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, false, fmt.Errorf("failed to start %s: %w", argv[0], err)
+	}
+	pid := cmd.Process.Pid
+	if err := cmd.Process.Release(); err != nil {
+		return nil, false, err
+	}
+	if err := m.Config.SetTaskWeb(t.ID, port, pid); err != nil {
+		return nil, false, err
+	}
+	return t, true, nil
+}
+
+// StopWeb stops a task's web editor. The task keeps its port for the next
+// start. It reports whether an editor was running.
+func (m *Manager) StopWeb(id string) (bool, error) {
+	t, err := m.Config.FindTask(id)
+	if err != nil {
+		return false, err
+	}
+	running, err := stopWeb(t)
+	if err != nil {
+		return running, err
+	}
+	if t.WebPID == 0 {
+		return running, nil
+	}
+	return running, m.Config.SetTaskWeb(t.ID, t.WebPort, 0)
+}
+
+// stopWeb stops a task's web editor, if it runs, without saving the task.
+func stopWeb(t *config.Task) (bool, error) {
+	if !agent.IsRunning(t.WebPID) {
+		return false, nil
+	}
+	if err := agent.Stop(t.WebPID); err != nil {
+		return true, fmt.Errorf("failed to stop the web editor of %s (pid %d): %w", t.ID, t.WebPID, err)
+	}
+	return true, nil
+}
+
+// allocatePort returns the first port from web_port_base that no task
+// holds, for its web editor or its own use, and nothing listens on.
+func (m *Manager) allocatePort() (int, error) {
+	base := m.Config.WebPortBase
+	if base == 0 {
+		base = DefaultWebPortBase
+	}
+	held := make(map[int]bool)
+	for _, t := range m.Config.Tasks {
+		held[t.WebPort], held[t.Port] = true, true
+	}
+	for port := base; port < base+webPortRange; port++ {
+		if held[port] {
+			continue
+		}
+		l, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+		if err != nil {
+			continue
+		}
+		l.Close()
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port between %d and %d; set web_port_base", base, base+webPortRange-1)
+}
@@ -0,0 +1,33 @@
+package task
+
+import (
+	"net"
+	"testing"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func TestAllocatePort(t *testing.T) {
+	// Something already listens on the base port, and tasks hold the next
+	// two, which may be free right now.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	base := l.Addr().(*net.TCPAddr).Port
+	if base+3 > 65535 {
+		t.Skip("no room above the listening port")
+	}
+	cfg := &config.Config{
+		WebPortBase: base,
+		Tasks:       []config.Task{{ID: "wt-1", WebPort: base + 1}, {ID: "wt-2", Port: base + 2}},
+	}
+	port, err := NewManager(cfg).allocatePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port <= base+2 {
+		t.Errorf("allocatePort() = %d, want a port after %d to %d", port, base, base+2)
+	}
+}
@@ -0,0 +1,109 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bakerweb/wt/internal/config"
+	"github.com/bakerweb/wt/internal/workspace"
+)
+
+// createWorkspace creates the workspace of a task that is not worked on
+// in its local worktree, and records its ID on t; the caller persists it.
+func (m *Manager) createWorkspace(ctx context.Context, t *config.Task) error {
+	p, err := workspace.Get(t.Workspace)
+	if err != nil {
+		return err
+	}
+	id, err := p.Create(ctx, workspace.Spec{Task: t.ID, Dir: t.Worktree, Branch: t.Branch})
+	if err != nil {
+		return fmt.Errorf("failed to create the %s workspace of %s: %w", p.Name(), t.ID, err)
+	}
+	t.WorkspaceID = id
+	return nil
+}
+
+// UpWorkspace brings up the workspace of a task: it creates it when
+// starting the task did not, e.g. after a background checkout or a failed
+// attempt, and otherwise resumes it.
+func (m *Manager) UpWorkspace(ctx context.Context, id string) (*config.Task, error) {
+	t, err := m.Config.FindTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if workspace.IsLocal(t.Workspace) {
+		return nil, fmt.Errorf("%s is worked on in its local worktree; there is no workspace to start (set repos.<repo>.workspace for new tasks)", t.ID)
+	}
+	if t.State == config.StatePreparing {
+		return nil, fmt.Errorf("task %s is still checking out files in the background", t.ID)
+	}
+	if t.WorkspaceID == "" {
+		if err := m.createWorkspace(ctx, t); err != nil {
+			return nil, err
+		}
+		return t, m.Config.SetTaskWorkspace(t.ID, t.WorkspaceID)
+	}
+	p, err := workspace.Get(t.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Start(ctx, t.WorkspaceID); err != nil {
+		return nil, fmt.Errorf("failed to start the %s workspace of %s: %w", p.Name(), t.ID, err)
+	}
+	return t, nil
+}
+
+// DownWorkspace stops the workspace of a task, keeping its files. It
+// reports whether the task has one.
+func (m *Manager) DownWorkspace(ctx context.Context, id string) (bool, error) {
+	t, err := m.Config.FindTask(id)
+	if err != nil {
+		return false, err
+	}
+	if workspace.IsLocal(t.Workspace) || t.WorkspaceID == "" {
+		return false, nil
+	}
+	p, err := workspace.Get(t.Workspace)
+	if err != nil {
+		return true, err
+	}
+	if err := p.Stop(ctx, t.WorkspaceID); err != nil {
+		return true, fmt.Errorf("failed to stop the %s workspace of %s: %w", p.Name(), t.ID, err)
+	}
+	return true, nil
+}
+
+// deleteWorkspace deletes the workspace of a task being finished or
+// removed. Providers refuse to delete work found nowhere else unless Force
+// is set, which also turns a failure into a warning.
+func (m *Manager) deleteWorkspace(ctx context.Context, t *config.Task) error {
+	if workspace.IsLocal(t.Workspace) || t.WorkspaceID == "" {
+		return nil
+	}
+	p, err := workspace.Get(t.Workspace)
+	if err == nil {
+		err = p.Delete(ctx, t.WorkspaceID, m.Force)
+	}
+	switch {
+	case err == nil:
+		return nil
+	case m.Force:
+		fmt.Fprintf(os.Stderr, "warning: failed to delete the %s workspace of %s: %v\n", t.Workspace, t.ID, err)
+		return nil
+	}
+	return fmt.Errorf("failed to delete the %s workspace of %s: %w; use --force to delete it anyway", t.Workspace, t.ID, err)
+}
+
+// WorkspaceShell returns the command that opens a shell in a task's
+// workspace, or "" when the task is worked on in its local worktree.
+func WorkspaceShell(t *config.Task) string {
+	if workspace.IsLocal(t.Workspace) || t.WorkspaceID == "" {
+		return ""
+	}
+	p, err := workspace.Get(t.Workspace)
+	if err != nil {
+		return ""
+	}
+	return p.Shell(t.WorkspaceID)
+}
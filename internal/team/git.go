@@ -0,0 +1,85 @@
+package team
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// pushAttempts bounds retries when another user pushes at the same time.
+const pushAttempts = 3
+
+// GitBackend keeps one JSON file per user and machine on a branch of a git
+// remote, typically the team's main repository.
+type GitBackend struct {
+	URL    string
+	Branch string
+	// Dir is a local bare repository used to fetch and build commits.
+	Dir string
+}
+
+func (b *GitBackend) fetch(ctx context.Context) (string, error) {
+	if err := worktree.InitBare(ctx, b.Dir); err != nil {
+		return "", err
+	}
+	return worktree.FetchBranch(ctx, b.Dir, b.URL, b.Branch)
+}
+
+// Pull reads all snapshots on the branch. Unreadable files are skipped
+// with a warning so one bad client can't hide everyone else.
+func (b *GitBackend) Pull(ctx context.Context) ([]Snapshot, error) {
+	head, err := b.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := worktree.ReadFiles(ctx, b.Dir, head)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for path, data := range files {
+		if !strings.HasSuffix(path, ".json") {
+			continue
+		}
+		s, err := decode(path, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// Push commits the snapshot's file on top of the latest branch head. Only
+// that file changes, so when the push races with another user's it is
+// simply rebuilt on their commit and retried.
+func (b *GitBackend) Push(ctx context.Context, s Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	var pushErr error
+	for range pushAttempts {
+		head, err := b.fetch(ctx)
+		if err != nil {
+			return err
+		}
+		commit, err := worktree.CommitFile(ctx, b.Dir, head, s.Path(), append(data, '\n'), "Update "+s.Path())
+		if err != nil {
+			return err
+		}
+		if pushErr = worktree.PushCommit(ctx, b.Dir, b.URL, commit, b.Branch); pushErr == nil {
+			return nil
+		}
+		if latest, err := b.fetch(ctx); err != nil || latest == head {
+			// The branch did not move, so retrying won't help.
+			break
+		}
+	}
+	return fmt.Errorf("failed to push team state: %w", pushErr)
+}
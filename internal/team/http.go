@@ -0,0 +1,77 @@
+package team
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPBackend stores snapshots on a server such as 'wt serve --team':
+//
+//	GET {url}               -> JSON array of snapshots
+//	PUT {url}/<user>/<host>  <- one snapshot
+type HTTPBackend struct {
+	URL   string
+	Token string
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+}
+
+func (b *HTTPBackend) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(b.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+	client := b.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("team server request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("team server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// Pull fetches all snapshots from the server.
+func (b *HTTPBackend) Pull(ctx context.Context) ([]Snapshot, error) {
+	data, err := b.do(ctx, "GET", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, fmt.Errorf("failed to decode team state: %w", err)
+	}
+	return snapshots, nil
+}
+
+// Push uploads the snapshot to its namespaced path.
+func (b *HTTPBackend) Push(ctx context.Context, s Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = b.do(ctx, "PUT", "/"+strings.TrimSuffix(s.Path(), ".json"), data)
+	return err
+}
@@ -0,0 +1,89 @@
+package team
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSnapshotSize bounds uploads to the team server.
+const maxSnapshotSize = 1 << 20
+
+// Server stores snapshots as files under Dir and serves the API used by
+// HTTPBackend. Each snapshot is stored at the path of the user and host it
+// names, so that clients don't overwrite each other by mistake. Tokens
+// aren't bound to users: any client with one can write a snapshot under
+// any name, so everyone holding a token must be trusted.
+type Server struct {
+	Dir string
+	// Token, if set, must be sent as a bearer token.
+	Token string
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		s.list(w)
+	case r.Method == http.MethodPut && path != "":
+		s.put(w, r, path)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	snapshots := []Snapshot{}
+	matches, _ := filepath.Glob(filepath.Join(s.Dir, "*", "*.json"))
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if snap, err := decode(path, data); err == nil {
+			snapshots = append(snapshots, snap)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshots)
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request, path string) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxSnapshotSize+1))
+	if err != nil || len(data) > maxSnapshotSize {
+		http.Error(w, "snapshot too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	snap, err := decode(path, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if want := strings.TrimSuffix(snap.Path(), ".json"); path != want {
+		http.Error(w, fmt.Sprintf("snapshot of %s@%s must be stored at /%s", snap.User, snap.Host, want), http.StatusForbidden)
+		return
+	}
+	file := filepath.Join(s.Dir, filepath.FromSlash(snap.Path()))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
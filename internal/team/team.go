@@ -0,0 +1,173 @@
+// Package team shares each user's active tasks through a remote state
+// backend so a team can see what everyone is working on.
+//
+// Every user and machine writes only its own snapshot, so concurrent
+// updates never conflict: merging is a union of snapshots.
+package team
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+// DefaultBranch holds team state for the git backend.
+const DefaultBranch = "wt-team"
+
+// Task is the part of a task shared with the team. Local paths are left out.
+type Task struct {
+	ID          string       `json:"id"`
+	Description string       `json:"description"`
+	Branch      string       `json:"branch"`
+	Repo        string       `json:"repo,omitempty"`
+	Connector   string       `json:"connector,omitempty"`
+	TicketKey   string       `json:"ticket_key,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	Lock        *config.Lock `json:"lock,omitempty"`
+	Created     time.Time    `json:"created"`
+}
+
+// Snapshot is one user's tasks on one machine.
+type Snapshot struct {
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	Updated time.Time `json:"updated"`
+	Tasks   []Task    `json:"tasks"`
+}
+
+// NewSnapshot captures the shareable state of tasks.
+func NewSnapshot(user, host string, tasks []config.Task) Snapshot {
+	s := Snapshot{User: user, Host: host, Updated: time.Now().UTC(), Tasks: []Task{}}
+	for _, t := range tasks {
+		repo := ""
+		if t.RepoPath != "" {
+			repo = filepath.Base(t.RepoPath)
+		}
+		s.Tasks = append(s.Tasks, Task{
+			ID:          t.ID,
+			Description: t.Description,
+			Branch:      t.Branch,
+			Repo:        repo,
+			Connector:   t.Connector,
+			TicketKey:   t.TicketKey,
+			Status:      t.Status,
+			Lock:        t.Lock,
+			Created:     t.Created,
+		})
+	}
+	return s
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._@-]+`)
+
+// Path is the snapshot's namespaced location in a backend: "<user>/<host>.json".
+func (s Snapshot) Path() string {
+	return clean(s.User) + "/" + clean(s.Host) + ".json"
+}
+
+func clean(name string) string {
+	name = unsafeName.ReplaceAllString(name, "_")
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// Digest identifies the shared content of a snapshot, ignoring when it
+// was taken, so unchanged state need not be pushed again.
+func (s Snapshot) Digest() string {
+	s.Updated = time.Time{}
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Validate checks a snapshot received from a backend or client.
+func (s Snapshot) Validate() error {
+	if s.User == "" || s.Host == "" {
+		return fmt.Errorf("snapshot needs a user and host")
+	}
+	return nil
+}
+
+// Backend stores snapshots.
+type Backend interface {
+	// Pull returns all users' snapshots.
+	Pull(ctx context.Context) ([]Snapshot, error)
+	// Push stores a snapshot, replacing the previous one at its path.
+	Push(ctx context.Context, s Snapshot) error
+}
+
+// New returns the backend described by cfg. dir is a local directory the
+// backend may use as a cache.
+func New(cfg config.TeamConfig, dir string) (Backend, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("no team backend configured; run 'wt team init'")
+	}
+	switch cfg.Backend {
+	case config.TeamGit:
+		branch := cfg.Branch
+		if branch == "" {
+			branch = DefaultBranch
+		}
+		return &GitBackend{URL: cfg.URL, Branch: branch, Dir: filepath.Join(dir, "team.git")}, nil
+	case config.TeamHTTP:
+		return &HTTPBackend{URL: cfg.URL, Token: cfg.Token}, nil
+	}
+	return nil, fmt.Errorf("unknown team backend %q (want %s or %s)", cfg.Backend, config.TeamGit, config.TeamHTTP)
+}
+
+// Member is a user's tasks merged across their machines.
+type Member struct {
+	User    string    `json:"user"`
+	Updated time.Time `json:"updated"`
+	Tasks   []Task    `json:"tasks"`
+}
+
+// Merge combines snapshots per user, sorted by user name. A task reported
+// by several machines is taken from the most recently updated snapshot.
+func Merge(snapshots []Snapshot) []Member {
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Updated.After(snapshots[j].Updated) })
+	byUser := make(map[string]*Member)
+	seen := make(map[string]bool)
+	var users []string
+	for _, s := range snapshots {
+		m := byUser[s.User]
+		if m == nil {
+			m = &Member{User: s.User, Updated: s.Updated, Tasks: []Task{}}
+			byUser[s.User] = m
+			users = append(users, s.User)
+		}
+		for _, t := range s.Tasks {
+			key := s.User + "\x00" + t.ID
+			if !seen[key] {
+				seen[key] = true
+				m.Tasks = append(m.Tasks, t)
+			}
+		}
+	}
+	sort.Strings(users)
+	members := make([]Member, 0, len(users))
+	for _, u := range users {
+		m := byUser[u]
+		sort.SliceStable(m.Tasks, func(i, j int) bool { return m.Tasks[i].Created.Before(m.Tasks[j].Created) })
+		members = append(members, *m)
+	}
+	return members
+}
+
+func decode(path string, data []byte) (Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("invalid team snapshot %s: %w", path, err)
+	}
+	return s, s.Validate()
+}
@@ -0,0 +1,94 @@
+package team
+
+import (
+	"context"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakerweb/wt/internal/config"
+)
+
+func snapshot(user, host string, updated time.Time, ids ...string) Snapshot {
+	s := Snapshot{User: user, Host: host, Updated: updated}
+	for _, id := range ids {
+		s.Tasks = append(s.Tasks, Task{ID: id, Description: host + " " + id})
+	}
+	return s
+}
+
+func TestMerge(t *testing.T) {
+	now := time.Now()
+	members := Merge([]Snapshot{
+		snapshot("bob", "laptop", now, "wt-3"),
+		snapshot("ann", "laptop", now.Add(-time.Hour), "wt-1", "wt-2"),
+		snapshot("ann", "desktop", now, "wt-2"),
+	})
+	if len(members) != 2 || members[0].User != "ann" || members[1].User != "bob" {
+		t.Fatalf("got %+v, want ann then bob", members)
+	}
+	ann := members[0]
+	if len(ann.Tasks) != 2 {
+		t.Fatalf("ann has %d tasks, want 2", len(ann.Tasks))
+	}
+	for _, task := range ann.Tasks {
+		if task.ID == "wt-2" && task.Description != "desktop wt-2" {
+			t.Errorf("wt-2 taken from %q, want the newer desktop snapshot", task.Description)
+		}
+	}
+}
+
+func TestSnapshotPath(t *testing.T) {
+	s := Snapshot{User: "../ann", Host: "my host"}
+	if got := s.Path(); got != ".._ann/my_host.json" {
+		t.Errorf("Path() = %q", got)
+	}
+	s = Snapshot{User: "..", Host: "x"}
+	if got := s.Path(); got != "_/x.json" {
+		t.Errorf("Path() = %q", got)
+	}
+}
+
+func testBackend(t *testing.T, b Backend) {
+	t.Helper()
+	ctx := context.Background()
+	ann := NewSnapshot("ann", "laptop", []config.Task{{ID: "wt-1", RepoPath: "/src/app"}})
+	bob := NewSnapshot("bob", "laptop", []config.Task{{ID: "wt-2"}})
+	for _, s := range []Snapshot{ann, bob, ann} {
+		if err := b.Push(ctx, s); err != nil {
+			t.Fatalf("Push(%s) failed: %v", s.User, err)
+		}
+	}
+	snapshots, err := b.Pull(ctx)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	members := Merge(snapshots)
+	if len(members) != 2 || members[0].Tasks[0].Repo != "app" || members[1].Tasks[0].ID != "wt-2" {
+		t.Errorf("got %+v", members)
+	}
+}
+
+func TestHTTPBackend(t *testing.T) {
+	srv := httptest.NewServer(&Server{Dir: t.TempDir(), Token: "secret"})
+	defer srv.Close()
+	testBackend(t, &HTTPBackend{URL: srv.URL, Token: "secret"})
+
+	if _, err := (&HTTPBackend{URL: srv.URL}).Pull(context.Background()); err == nil {
+		t.Error("Pull without token succeeded")
+	}
+}
+
+func TestGitBackend(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	testBackend(t, &GitBackend{URL: remote, Branch: DefaultBranch, Dir: filepath.Join(dir, "cache.git")})
+}
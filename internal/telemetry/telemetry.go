@@ -0,0 +1,276 @@
+// Package telemetry records anonymous command usage locally and optionally
+// uploads it. Nothing is recorded unless the user opts in.
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxEvents bounds the local event log; older events are dropped.
+const maxEvents = 5000
+
+// Event is one command invocation. It holds no arguments, paths or names.
+type Event struct {
+	Command  string        `json:"command"`
+	Duration time.Duration `json:"duration_ns"`
+	Exit     int           `json:"exit"`
+	Time     time.Time     `json:"time"`
+	Version  string        `json:"version"`
+	OS       string        `json:"os"`
+}
+
+// Store is the local event log in a directory such as ~/.wt/telemetry.
+type Store struct {
+	Dir string
+}
+
+func (s Store) eventsPath() string { return filepath.Join(s.Dir, "events.jsonl") }
+func (s Store) statePath() string  { return filepath.Join(s.Dir, "state.json") }
+
+// Record appends an event to the log.
+func (s Store) Record(e Event) error {
+	if e.OS == "" {
+		e.OS = runtime.GOOS
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create telemetry directory: %w", err)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.eventsPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open telemetry log: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(s.eventsPath()); err == nil && fi.Size() > maxEvents*200 {
+		return s.trim()
+	}
+	return nil
+}
+
+// trim keeps the newest maxEvents/2 events.
+func (s Store) trim() error {
+	events, err := s.Events()
+	if err != nil {
+		return err
+	}
+	if len(events) <= maxEvents/2 {
+		return nil
+	}
+	return s.write(events[len(events)-maxEvents/2:])
+}
+
+func (s Store) write(events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(s.eventsPath(), buf.Bytes(), 0o600)
+}
+
+// Events returns the recorded events, oldest first.
+func (s Store) Events() ([]Event, error) {
+	f, err := os.Open(s.eventsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read telemetry log: %w", err)
+	}
+	defer f.Close()
+	var events []Event
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Event
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			events = append(events, e)
+		}
+	}
+	return events, sc.Err()
+}
+
+// Clear deletes the event log and upload state.
+func (s Store) Clear() error {
+	for _, p := range []string{s.eventsPath(), s.statePath()} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats summarizes the invocations of one command.
+type Stats struct {
+	Command string        `json:"command"`
+	Runs    int           `json:"runs"`
+	Errors  int           `json:"errors"`
+	Mean    time.Duration `json:"mean_ns"`
+	P50     time.Duration `json:"p50_ns"`
+	P90     time.Duration `json:"p90_ns"`
+	Max     time.Duration `json:"max_ns"`
+}
+
+// Summarize groups events by command, slowest total time first.
+func Summarize(events []Event) []Stats {
+	byCommand := make(map[string][]Event)
+	for _, e := range events {
+		byCommand[e.Command] = append(byCommand[e.Command], e)
+	}
+	var stats []Stats
+	for cmd, evs := range byCommand {
+		durations := make([]time.Duration, len(evs))
+		var total time.Duration
+		st := Stats{Command: cmd, Runs: len(evs)}
+		for i, e := range evs {
+			durations[i] = e.Duration
+			total += e.Duration
+			if e.Exit != 0 {
+				st.Errors++
+			}
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		st.Mean = total / time.Duration(len(evs))
+		st.P50 = percentile(durations, 50)
+		st.P90 = percentile(durations, 90)
+		st.Max = durations[len(durations)-1]
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		ti := stats[i].Mean * time.Duration(stats[i].Runs)
+		tj := stats[j].Mean * time.Duration(stats[j].Runs)
+		if ti != tj {
+			return ti > tj
+		}
+		return stats[i].Command < stats[j].Command
+	})
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	return sorted[max(i, 0)]
+}
+
+// state tracks uploads between runs.
+type state struct {
+	InstallID    string    `json:"install_id"`
+	Uploaded     time.Time `json:"uploaded"`
+	LastUploaded time.Time `json:"last_upload"`
+}
+
+func (s Store) loadState() state {
+	var st state
+	if data, err := os.ReadFile(s.statePath()); err == nil {
+		json.Unmarshal(data, &st)
+	}
+	if st.InstallID == "" {
+		b := make([]byte, 8)
+		rand.Read(b)
+		st.InstallID = hex.EncodeToString(b)
+	}
+	return st
+}
+
+func (s Store) saveState(st state) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.statePath(), data, 0o600)
+}
+
+// UploadDue reports whether a periodic upload should run.
+func (s Store) UploadDue(interval time.Duration) bool {
+	return time.Since(s.loadState().LastUploaded) >= interval
+}
+
+// Upload posts events not uploaded yet to url as a JSON batch tagged with a
+// random installation ID, and returns how many were sent.
+func (s Store) Upload(ctx context.Context, url string) (int, error) {
+	st := s.loadState()
+	events, err := s.Events()
+	if err != nil {
+		return 0, err
+	}
+	var pending []Event
+	for _, e := range events {
+		if e.Time.After(st.Uploaded) {
+			pending = append(pending, e)
+		}
+	}
+	// Record the attempt up front so a failing endpoint is retried on the
+	// next interval rather than on every command.
+	st.LastUploaded = time.Now()
+	if err := s.saveState(st); err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	body, err := json.Marshal(struct {
+		InstallID string  `json:"install_id"`
+		Events    []Event `json:"events"`
+	}{st.InstallID, pending})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("telemetry upload failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("telemetry upload returned %d", resp.StatusCode)
+	}
+	st.Uploaded = pending[len(pending)-1].Time
+	return len(pending), s.saveState(st)
+}
+
+// CommandName returns the command path of a wt invocation, e.g. "sync" or
+// "attach pull", given the names of commands with subcommands. Arguments
+// that are not command names are never included.
+func CommandName(args []string, isCommand func(path string) bool) string {
+	var path []string
+	for _, arg := range args[min(1, len(args)):] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		next := strings.TrimSpace(strings.Join(append(path, arg), " "))
+		if !isCommand(next) {
+			break
+		}
+		path = append(path, arg)
+	}
+	if len(path) == 0 {
+		return "(none)"
+	}
+	return strings.Join(path, " ")
+}
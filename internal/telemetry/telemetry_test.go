@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	ms := time.Millisecond
+	var events []Event
+	for _, d := range []time.Duration{10, 20, 30, 40, 100} {
+		events = append(events, Event{Command: "list", Duration: d * ms})
+	}
+	events = append(events, Event{Command: "sync", Duration: 900 * ms, Exit: 4})
+
+	stats := Summarize(events)
+	if len(stats) != 2 || stats[0].Command != "sync" {
+		t.Fatalf("got %+v, want sync first", stats)
+	}
+	list := stats[1]
+	if list.Runs != 5 || list.Errors != 0 || list.Mean != 40*ms || list.P50 != 30*ms || list.P90 != 100*ms || list.Max != 100*ms {
+		t.Errorf("list stats = %+v", list)
+	}
+	if stats[0].Errors != 1 {
+		t.Errorf("sync errors = %d, want 1", stats[0].Errors)
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	commands := map[string]bool{"list": true, "attach": true, "attach pull": true}
+	isCommand := func(path string) bool { return commands[path] }
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"wt", "list", "--tickets"}, "list"},
+		{[]string{"wt", "--json", "attach", "pull", "wt-1234"}, "attach pull"},
+		{[]string{"wt", "PROJ-123"}, "(none)"},
+		{[]string{"wt"}, "(none)"},
+	}
+	for _, tt := range tests {
+		if got := CommandName(tt.args, isCommand); got != tt.want {
+			t.Errorf("CommandName(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var received []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			InstallID string  `json:"install_id"`
+			Events    []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.InstallID == "" {
+			t.Error("missing install_id")
+		}
+		received = append(received, body.Events...)
+	}))
+	defer srv.Close()
+
+	store := Store{Dir: t.TempDir()}
+	now := time.Now()
+	store.Record(Event{Command: "list", Time: now.Add(-time.Minute)})
+	store.Record(Event{Command: "sync", Time: now})
+
+	if n, err := store.Upload(context.Background(), srv.URL); err != nil || n != 2 {
+		t.Fatalf("Upload = %d, %v; want 2", n, err)
+	}
+	store.Record(Event{Command: "status", Time: now.Add(time.Second)})
+	if n, err := store.Upload(context.Background(), srv.URL); err != nil || n != 1 {
+		t.Fatalf("second Upload = %d, %v; want 1", n, err)
+	}
+	if len(received) != 3 || received[2].Command != "status" {
+		t.Errorf("received %+v", received)
+	}
+	if store.UploadDue(time.Hour) {
+		t.Error("upload due right after uploading")
+	}
+}
@@ -0,0 +1,80 @@
+// Package terminal sets terminal and tmux window titles and sends desktop
+// notifications.
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IsTerminal reports whether f is connected to a terminal.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Title formats a window title for a task.
+func Title(taskID, ticketKey string) string {
+	if ticketKey == "" {
+		return taskID
+	}
+	return ticketKey + " · " + taskID
+}
+
+// SetTitle renames the tmux window when running inside tmux, and otherwise
+// writes an OSC 0 escape sequence to the controlling terminal. The sequence
+// is never written to stdout so that `cd $(wt switch ...)` keeps working.
+func SetTitle(title string) error {
+	if InTmux() {
+		cmd := exec.Command("tmux", "rename-window", title)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to rename tmux window: %s\n%s", err, string(out))
+		}
+		return nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		// No controlling terminal (CI, pipes); nothing to title.
+		return nil
+	}
+	defer tty.Close()
+	_, err = fmt.Fprintf(tty, "\033]0;%s\007", title)
+	return err
+}
+
+// InTmux reports whether wt runs inside a tmux session.
+func InTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// NewWindow runs argv in a new tmux window named title, in dir and with env
+// added to its environment, and returns the PID of the window's process.
+func NewWindow(title, dir string, env map[string]string, argv []string) (int, error) {
+	args := []string{"new-window", "-d", "-P", "-F", "#{pane_pid}", "-n", title, "-c", dir}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-e", k+"="+env[k])
+	}
+	args = append(args, "--")
+	args = append(args, argv...)
+	out, err := exec.Command("tmux", args...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open tmux window: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected tmux output %q", out)
+	}
+	return pid, nil
+}
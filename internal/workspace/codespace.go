@@ -0,0 +1,60 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bakerweb/wt/internal/worktree"
+)
+
+// codespace runs a task in a GitHub Codespace of its branch, with the gh
+// CLI. The branch is pushed first, since codespaces clone it from GitHub.
+type codespace struct{}
+
+func (codespace) Name() string { return Codespace }
+
+func (codespace) Create(ctx context.Context, s Spec) (string, error) {
+	out, err := runCLI(ctx, s.Dir, "gh", "repo", "view", "--json", "nameWithOwner", "--jq", ".nameWithOwner")
+	if err != nil {
+		return "", err
+	}
+	repo := strings.TrimSpace(string(out))
+	if err := worktree.Push(ctx, s.Dir, s.Branch); err != nil {
+		return "", fmt.Errorf("failed to push %s for its codespace: %w", s.Branch, err)
+	}
+	out, err = runCLI(ctx, s.Dir, "gh", "codespace", "create", "--repo", repo, "--branch", s.Branch, "--display-name", s.Task)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(string(out))
+	if name == "" {
+		return "", fmt.Errorf("gh codespace create did not print the codespace's name")
+	}
+	return lastLine(name), nil
+}
+
+// Start has nothing to run: a codespace starts when it is connected to.
+func (codespace) Start(ctx context.Context, id string) error {
+	return nil
+}
+
+func (codespace) Stop(ctx context.Context, id string) error {
+	_, err := runCLI(ctx, "", "gh", "codespace", "stop", "--codespace", id)
+	return err
+}
+
+// Delete leaves gh to refuse deleting a codespace with unpushed or
+// uncommitted changes, unless forced.
+func (codespace) Delete(ctx context.Context, id string, force bool) error {
+	args := []string{"codespace", "delete", "--codespace", id}
+	if force {
+		args = append(args, "--force")
+	}
+	_, err := runCLI(ctx, "", "gh", args...)
+	return err
+}
+
+func (codespace) Shell(id string) string {
+	return "gh codespace ssh --codespace " + id
+}
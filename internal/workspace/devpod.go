@@ -0,0 +1,39 @@
+package workspace
+
+import "context"
+
+// devpod runs a task in a Devpod built from the dev container of its
+// worktree, with the devpod CLI and its default provider.
+type devpod struct{}
+
+func (devpod) Name() string { return Devpod }
+
+// Create names the workspace after the task, which keeps IDs unique and
+// within Devpod's lowercase letters, digits and dashes.
+func (devpod) Create(ctx context.Context, s Spec) (string, error) {
+	if _, err := runCLI(ctx, s.Dir, "devpod", "up", s.Dir, "--id", s.Task, "--ide", "none"); err != nil {
+		return "", err
+	}
+	return s.Task, nil
+}
+
+func (devpod) Start(ctx context.Context, id string) error {
+	_, err := runCLI(ctx, "", "devpod", "up", id, "--ide", "none")
+	return err
+}
+
+func (devpod) Stop(ctx context.Context, id string) error {
+	_, err := runCLI(ctx, "", "devpod", "stop", id)
+	return err
+}
+
+// Delete always deletes: the workspace's files are the worktree's, mounted
+// or synced from it, so nothing is lost with it.
+func (devpod) Delete(ctx context.Context, id string, _ bool) error {
+	_, err := runCLI(ctx, "", "devpod", "delete", id)
+	return err
+}
+
+func (devpod) Shell(id string) string {
+	return "devpod ssh " + id
+}
@@ -0,0 +1,102 @@
+// Package workspace runs the workspace a task is worked on in: its local
+// worktree, a Devpod built from the worktree's dev container, or a GitHub
+// Codespace of its branch. Tasks go through the same lifecycle whatever
+// the provider; the worktree keeps the branch either way.
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Spec describes the task a workspace is created for.
+type Spec struct {
+	// Task is the task's ID, which providers may use to name the workspace.
+	Task string
+	// Dir is the task's worktree on this machine.
+	Dir    string
+	Branch string
+}
+
+// Provider creates and runs the workspaces of tasks.
+type Provider interface {
+	Name() string
+	// Create brings up a new workspace for a task and returns its ID, ""
+	// when the provider keeps none.
+	Create(ctx context.Context, s Spec) (string, error)
+	// Start resumes a stopped workspace, and Stop stops it, keeping its
+	// files.
+	Start(ctx context.Context, id string) error
+	Stop(ctx context.Context, id string) error
+	// Delete deletes a workspace. Unless force is set, providers refuse to
+	// delete one holding work that exists nowhere else.
+	Delete(ctx context.Context, id string, force bool) error
+	// Shell returns the command that opens a shell in the workspace, ""
+	// for the local worktree.
+	Shell(id string) string
+}
+
+// Providers by the name used in repos.<repo>.workspace.
+const (
+	Local     = "local"
+	Devpod    = "devpod"
+	Codespace = "codespace"
+)
+
+// Get returns the provider named name; "" is the local worktree.
+func Get(name string) (Provider, error) {
+	switch name {
+	case "", Local:
+		return local{}, nil
+	case Devpod:
+		return devpod{}, nil
+	case Codespace:
+		return codespace{}, nil
+	}
+	return nil, fmt.Errorf("unknown workspace provider %q (want local, devpod or codespace)", name)
+}
+
+// IsLocal reports whether name is the local worktree provider.
+func IsLocal(name string) bool {
+	return name == "" || name == Local
+}
+
+// local works on the task's worktree directly; there is nothing to run.
+type local struct{}
+
+func (local) Name() string                                        { return Local }
+func (local) Create(ctx context.Context, s Spec) (string, error)  { return "", nil }
+func (local) Start(ctx context.Context, id string) error          { return nil }
+func (local) Stop(ctx context.Context, id string) error           { return nil }
+func (local) Delete(ctx context.Context, id string, _ bool) error { return nil }
+func (local) Shell(id string) string                              { return "" }
+
+// runCLI runs a provider's command-line tool in dir and returns its stdout.
+// Its progress output goes to stderr as it runs, since creating a
+// workspace can take minutes.
+func runCLI(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s is not installed: %w", name, err)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, lastLine(stderr.String()))
+	}
+	return out, nil
+}
+
+// lastLine returns the last non-empty line of s, where tools usually put
+// the reason they failed.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
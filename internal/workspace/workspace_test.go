@@ -0,0 +1,43 @@
+package workspace
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name, want string
+		wantErr    bool
+	}{
+		{"", Local, false},
+		{"local", Local, false},
+		{"devpod", Devpod, false},
+		{"codespace", Codespace, false},
+		{"gitpod", "", true},
+	}
+	for _, tt := range tests {
+		p, err := Get(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Get(%q) = %s, want an error", tt.name, p.Name())
+			}
+			continue
+		}
+		if err != nil || p.Name() != tt.want {
+			t.Errorf("Get(%q) = %v, %v; want %s", tt.name, p, err, tt.want)
+		}
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"", ""},
+		{"one line\n", "one line"},
+		{"[info] building\n[fatal] no dev container found\n\n", "[fatal] no dev container found"},
+	}
+	for _, tt := range tests {
+		if got := lastLine(tt.input); got != tt.want {
+			t.Errorf("lastLine(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+)
+
+// Backend performs read-only repository queries. Commands that modify the
+// repository (worktree add/remove, branch deletion) always use the git binary.
+type Backend interface {
+	List(ctx context.Context, repoPath string) ([]WorktreeInfo, error)
+	BranchExists(ctx context.Context, repoPath, branch string) bool
+	Status(ctx context.Context, worktreePath string) (StatusInfo, error)
+}
+
+// Backend names accepted by SetBackend.
+const (
+	BackendExec  = "git"
+	BackendGoGit = "go-git"
+)
+
+// ExecBackend shells out to the git binary.
+type ExecBackend struct{}
+
+var backend Backend = ExecBackend{}
+
+// SetBackend selects the backend used for read operations by name.
+// An empty name selects the git binary.
+func SetBackend(name string) error {
+	switch name {
+	case "", BackendExec:
+		backend = ExecBackend{}
+	case BackendGoGit:
+		backend = GoGitBackend{}
+	default:
+		return fmt.Errorf("unknown git backend %q (want %q or %q)", name, BackendExec, BackendGoGit)
+	}
+	return nil
+}
+
+// backendFor returns the backend for a repository or worktree. go-git
+// cannot reach other machines, so their paths always use the git binary,
+// and it cannot see the repositories of a Fake.
+func backendFor(path string) Backend {
+	if IsRemote(path) || fake != nil {
+		return ExecBackend{}
+	}
+	return backend
+}
@@ -0,0 +1,141 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func gitTest(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=wt", "GIT_AUTHOR_EMAIL=wt@example.com",
+		"GIT_COMMITTER_NAME=wt", "GIT_COMMITTER_EMAIL=wt@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func TestBackendsAgree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", "init")
+	wtPath := filepath.Join(root, "feature")
+	gitTest(t, repo, "worktree", "add", "-q", "-b", "feature/x", wtPath)
+	if err := os.WriteFile(filepath.Join(wtPath, "new.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	execB, goGitB := ExecBackend{}, GoGitBackend{}
+
+	want, err := execB.List(ctx, repo)
+	if err != nil {
+		t.Fatalf("exec List failed: %v", err)
+	}
+	got, err := goGitB.List(ctx, wtPath)
+	if err != nil {
+		t.Fatalf("go-git List failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("go-git List() = %+v, want %+v", got, want)
+	}
+
+	for _, branch := range []string{"main", "feature/x", "missing"} {
+		if g, w := goGitB.BranchExists(ctx, repo, branch), execB.BranchExists(ctx, repo, branch); g != w {
+			t.Errorf("BranchExists(%q): go-git %v, git %v", branch, g, w)
+		}
+	}
+
+	wantSt, err := execB.Status(ctx, wtPath)
+	if err != nil {
+		t.Fatalf("exec Status failed: %v", err)
+	}
+	gotSt, err := goGitB.Status(ctx, wtPath)
+	if err != nil {
+		t.Fatalf("go-git Status failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotSt, wantSt) {
+		t.Errorf("go-git Status() = %+v, want %+v", gotSt, wantSt)
+	}
+}
+
+func TestGoGitAheadBehind(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, sameTime := range []bool{false, true} {
+		repo := filepath.Join(t.TempDir(), "repo")
+		gitTest(t, filepath.Dir(repo), "init", "-q", "-b", "main", repo)
+		day := 0
+		commit := func(msg string) {
+			t.Helper()
+			if !sameTime {
+				day++
+			}
+			date := fmt.Sprintf("2026-01-%02dT12:00:00Z", day+1)
+			t.Setenv("GIT_COMMITTER_DATE", date)
+			t.Setenv("GIT_AUTHOR_DATE", date)
+			gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", msg)
+		}
+		for i := range 5 {
+			commit(fmt.Sprintf("base %d", i))
+		}
+		gitTest(t, repo, "branch", "stale")
+		gitTest(t, repo, "checkout", "-q", "-b", "feature")
+		commit("feature 1")
+		gitTest(t, repo, "checkout", "-q", "main")
+		commit("main 1")
+		commit("main 2")
+		gitTest(t, repo, "checkout", "-q", "feature")
+		gitTest(t, repo, "merge", "-q", "--no-edit", "main~1")
+		commit("feature 2")
+		gitTest(t, repo, "checkout", "-q", "main")
+		for i := range 3 {
+			commit(fmt.Sprintf("main %d", i+3))
+		}
+
+		r, err := openRepo(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pair := range [][2]string{{"feature", "main"}, {"stale", "main"}, {"main", "stale"}, {"feature", "stale"}} {
+			out, err := exec.Command("git", "-C", repo, "rev-list", "--left-right", "--count", pair[0]+"..."+pair[1]).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wantAhead, wantBehind int
+			fmt.Sscan(string(out), &wantAhead, &wantBehind)
+			local, err := r.ResolveRevision(plumbing.Revision(pair[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			upstream, err := r.ResolveRevision(plumbing.Revision(pair[1]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ahead, behind, err := aheadBehind(context.Background(), r, *local, *upstream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ahead != wantAhead || behind != wantBehind {
+				t.Errorf("same time %v: aheadBehind(%s, %s) = %d, %d, want %d, %d", sameTime, pair[0], pair[1], ahead, behind, wantAhead, wantBehind)
+			}
+		}
+	}
+}
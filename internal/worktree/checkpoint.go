@@ -0,0 +1,285 @@
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckpointRefs is the namespace of the refs that keep the checkpoints of
+// tasks, as refs/wt/checkpoints/<task-id>/<n>. Refs are shared by all
+// worktrees of a repository.
+const CheckpointRefs = "refs/wt/checkpoints/"
+
+// Checkpoint is a snapshot of the files of a task's worktree.
+type Checkpoint struct {
+	N       int       `json:"n"`
+	Ref     string    `json:"ref"`
+	Commit  string    `json:"commit"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Checkpoints returns the checkpoints of a task, oldest first.
+func Checkpoints(ctx context.Context, dir, id string) ([]Checkpoint, error) {
+	prefix := CheckpointRefs + id + "/"
+	out, err := gitOutput(ctx, dir, "for-each-ref", "--format=%(refname)%00%(objectname)%00%(creatordate:unix)%00%(subject)", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	var checkpoints []Checkpoint
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(fields[0], prefix))
+		if err != nil {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[2], 10, 64)
+		checkpoints = append(checkpoints, Checkpoint{
+			N:       n,
+			Ref:     fields[0],
+			Commit:  fields[1],
+			Message: fields[3],
+			Time:    time.Unix(unix, 0),
+		})
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].N < checkpoints[j].N })
+	return checkpoints, nil
+}
+
+// SaveCheckpoint saves the files of the worktree at dir, including
+// untracked files that are not ignored, as the next checkpoint of task id.
+// The worktree's files, index and HEAD are left alone. Nothing is saved,
+// and false is returned with the last checkpoint, when the files are the
+// same as in it.
+func SaveCheckpoint(ctx context.Context, dir, id, message string) (Checkpoint, bool, error) {
+	checkpoints, err := Checkpoints(ctx, dir, id)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	tree, head, err := snapshot(ctx, dir)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	git := checkpointGit(ctx, dir, "")
+	if n := len(checkpoints); n > 0 {
+		last := checkpoints[n-1]
+		if lastTree, err := git("rev-parse", last.Commit+"^{tree}"); err == nil && lastTree == tree {
+			return last, false, nil
+		}
+	}
+
+	args := []string{"commit-tree", tree, "-m", message}
+	if head != "" {
+		args = append(args, "-p", head)
+	}
+	commit, err := git(args...)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	cp := Checkpoint{N: 1, Commit: commit, Message: message, Time: time.Now()}
+	if n := len(checkpoints); n > 0 {
+		cp.N = checkpoints[n-1].N + 1
+	}
+	cp.Ref = CheckpointRefs + id + "/" + strconv.Itoa(cp.N)
+	if _, err := git("update-ref", cp.Ref, commit, ""); err != nil {
+		return Checkpoint{}, false, err
+	}
+	return cp, true, nil
+}
+
+// ChangedSince reports whether the files of the worktree at dir differ
+// from those saved in a checkpoint.
+func ChangedSince(ctx context.Context, dir string, cp Checkpoint) (bool, error) {
+	tree, _, err := snapshot(ctx, dir)
+	if err != nil {
+		return false, err
+	}
+	cpTree, err := checkpointGit(ctx, dir, "")("rev-parse", cp.Commit+"^{tree}")
+	if err != nil {
+		return false, err
+	}
+	return tree != cpTree, nil
+}
+
+// RestoreCheckpoint brings the worktree at dir back to a checkpoint: its
+// files become those saved, untracked files that were not saved are
+// deleted, and the branch is reset to the commit it was on, keeping files
+// that were untracked then untracked. Ignored files are left alone.
+func RestoreCheckpoint(ctx context.Context, dir string, cp Checkpoint) error {
+	if err := RestoreWork(ctx, dir, cp.Commit); err != nil {
+		return fmt.Errorf("failed to restore checkpoint %d: %w", cp.N, err)
+	}
+	return nil
+}
+
+// SaveWork saves the files of the worktree at dir like SaveCheckpoint, as
+// a commit on top of HEAD that ref points to. The branch is left alone.
+// It returns "" and sets no ref when nothing changed since HEAD.
+func SaveWork(ctx context.Context, dir, ref, message string) (string, error) {
+	tree, head, err := snapshot(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	git := checkpointGit(ctx, dir, "")
+	args := []string{"commit-tree", tree, "-m", message}
+	if head != "" {
+		if headTree, err := git("rev-parse", head+"^{tree}"); err == nil && headTree == tree {
+			return "", nil
+		}
+		args = append(args, "-p", head)
+	}
+	commit, err := git(args...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := git("update-ref", ref, commit); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// RestoreWork brings the files of the worktree at dir back to those saved
+// in commit by SaveWork or SaveCheckpoint: untracked files that were not
+// saved are deleted, and the branch is reset to commit's parent, keeping
+// files that were untracked then untracked. Ignored files are left alone.
+func RestoreWork(ctx context.Context, dir, commit string) error {
+	steps := [][]string{
+		{"read-tree", "-u", "--reset", commit},
+		{"clean", "-f", "-d", "-q"},
+	}
+	if parent, err := gitOutput(ctx, dir, "rev-parse", "--verify", "-q", commit+"^"); err == nil {
+		steps = append(steps, []string{"reset", "-q", strings.TrimSpace(string(parent))})
+	}
+	for _, args := range steps {
+		if out, err := gitCombined(ctx, dir, args...); err != nil {
+			return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// DeleteCheckpoints deletes the checkpoints of a task numbered above
+// after; 0 deletes them all.
+func DeleteCheckpoints(ctx context.Context, repoPath, id string, after int) error {
+	checkpoints, err := Checkpoints(ctx, repoPath, id)
+	if err != nil {
+		return err
+	}
+	for _, cp := range checkpoints {
+		if cp.N <= after {
+			continue
+		}
+		if out, err := gitCombined(ctx, repoPath, "update-ref", "-d", cp.Ref); err != nil {
+			return fmt.Errorf("failed to delete checkpoint %s: %s", cp.Ref, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// DeleteRef deletes a ref that wt keeps, such as one set by SaveWork.
+func DeleteRef(ctx context.Context, repoPath, ref string) error {
+	if out, err := gitCombined(ctx, repoPath, "update-ref", "-d", ref); err != nil {
+		return fmt.Errorf("failed to delete %s: %s", ref, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// snapshot writes the files of the worktree at dir as a tree, through a
+// temporary index, and returns it with the commit HEAD is on ("" when the
+// branch has none yet).
+func snapshot(ctx context.Context, dir string) (tree, head string, err error) {
+	if IsRemote(dir) {
+		return "", "", fmt.Errorf("cannot save the files of %s: checkpoints and snapshots need the worktree on this machine", dir)
+	}
+	index, err := os.CreateTemp("", "wt-index-*")
+	if err != nil {
+		return "", "", err
+	}
+	index.Close()
+	defer os.Remove(index.Name())
+	git := checkpointGit(ctx, dir, index.Name())
+
+	// Starting from a copy of the worktree's index lets git skip hashing
+	// files that did not change.
+	head, _ = git("rev-parse", "--verify", "-q", "HEAD")
+	realIndex, err := gitOutput(ctx, dir, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find the index: %w", err)
+	}
+	if err := copyFile(strings.TrimSpace(string(realIndex)), index.Name()); err != nil {
+		os.Remove(index.Name())
+		if head != "" {
+			if _, err := git("read-tree", head); err != nil {
+				return "", "", err
+			}
+		}
+	}
+	if _, err := git("add", "-A", "."); err != nil {
+		return "", "", err
+	}
+	tree, err = git("write-tree")
+	if err != nil {
+		return "", "", err
+	}
+	return tree, head, nil
+}
+
+// checkpointGit returns a function running git in dir as wt, with index
+// as the index file unless it is "".
+func checkpointGit(ctx context.Context, dir, index string) func(args ...string) (string, error) {
+	return func(args ...string) (string, error) {
+		if fake != nil {
+			var out, stderr bytes.Buffer
+			if err := runFake(ctx, dir, args, &out, &stderr); err != nil {
+				return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+			}
+			return strings.TrimSpace(out.String()), nil
+		}
+		env := []string{
+			"GIT_AUTHOR_NAME=wt", "GIT_AUTHOR_EMAIL=wt@localhost",
+			"GIT_COMMITTER_NAME=wt", "GIT_COMMITTER_EMAIL=wt@localhost",
+		}
+		if index != "" {
+			env = append(env, "GIT_INDEX_FILE="+index)
+		}
+		cmd := gitCommand(ctx, dir, env, args...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,157 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveCheckpoint(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0o644)
+	gitTest(t, repo, "add", "a.txt")
+	gitTest(t, repo, "commit", "-q", "-m", "init")
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("two\n"), 0o644)
+	os.WriteFile(filepath.Join(repo, "new.txt"), []byte("new\n"), 0o644)
+	cp, saved, err := SaveCheckpoint(ctx, repo, "wt-1", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved || cp.N != 1 || cp.Ref != "refs/wt/checkpoints/wt-1/1" {
+		t.Fatalf("SaveCheckpoint = %+v, %v, want checkpoint 1 saved", cp, saved)
+	}
+	if out, _ := gitOutput(ctx, repo, "show", cp.Commit+":new.txt"); string(out) != "new\n" {
+		t.Errorf("checkpoint new.txt = %q, want untracked file saved", out)
+	}
+	if out, _ := gitOutput(ctx, repo, "status", "--porcelain"); !strings.Contains(string(out), "?? new.txt") {
+		t.Errorf("status = %q, want the index left alone", out)
+	}
+
+	if _, saved, err := SaveCheckpoint(ctx, repo, "wt-1", "again"); err != nil || saved {
+		t.Errorf("SaveCheckpoint without changes saved = %v, err = %v; want nothing saved", saved, err)
+	}
+	os.Remove(filepath.Join(repo, "new.txt"))
+	if cp, saved, err := SaveCheckpoint(ctx, repo, "wt-1", "second"); err != nil || !saved || cp.N != 2 {
+		t.Errorf("SaveCheckpoint = %+v, %v, %v; want checkpoint 2", cp, saved, err)
+	}
+
+	checkpoints, err := Checkpoints(ctx, repo, "wt-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 2 || checkpoints[0].Message != "first" || checkpoints[1].Message != "second" {
+		t.Errorf("Checkpoints = %+v, want first then second", checkpoints)
+	}
+	if err := DeleteCheckpoints(ctx, repo, "wt-1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoints, _ := Checkpoints(ctx, repo, "wt-1"); len(checkpoints) != 0 {
+		t.Errorf("Checkpoints after delete = %+v, want none", checkpoints)
+	}
+}
+
+func TestRestoreCheckpoint(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "one\n")
+	write(".gitignore", "*.log\n")
+	gitTest(t, repo, "add", ".")
+	gitTest(t, repo, "commit", "-q", "-m", "init")
+	head := revParse(t, repo, "HEAD")
+	ctx := context.Background()
+
+	write("a.txt", "good\n")
+	write("notes.txt", "keep\n")
+	cp, _, err := SaveCheckpoint(ctx, repo, "wt-1", "good")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := ChangedSince(ctx, repo, cp); err != nil || changed {
+		t.Errorf("ChangedSince right after saving = %v, %v; want false", changed, err)
+	}
+
+	// The agent goes off the rails: it commits, deletes and adds files.
+	write("a.txt", "bad\n")
+	os.Remove(filepath.Join(repo, "notes.txt"))
+	gitTest(t, repo, "commit", "-q", "-am", "bad")
+	write("junk.txt", "junk\n")
+	write("build.log", "ignored\n")
+	if changed, err := ChangedSince(ctx, repo, cp); err != nil || !changed {
+		t.Errorf("ChangedSince after changes = %v, %v; want true", changed, err)
+	}
+
+	if err := RestoreCheckpoint(ctx, repo, cp); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"a.txt": "good\n", "notes.txt": "keep\n", "build.log": "ignored\n"} {
+		if got, err := os.ReadFile(filepath.Join(repo, name)); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repo, "junk.txt")); !os.IsNotExist(err) {
+		t.Errorf("junk.txt still exists after rollback")
+	}
+	if got := revParse(t, repo, "HEAD"); got != head {
+		t.Errorf("HEAD = %s, want the commit of the checkpoint %s", got, head)
+	}
+	if out, _ := gitOutput(ctx, repo, "status", "--porcelain"); string(out) != " M a.txt\n?? notes.txt\n" {
+		t.Errorf("status = %q, want a.txt modified and notes.txt untracked", out)
+	}
+}
+
+func TestSaveWork(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0o644)
+	gitTest(t, repo, "add", "a.txt")
+	gitTest(t, repo, "commit", "-q", "-m", "init")
+	ctx := context.Background()
+
+	if work, err := SaveWork(ctx, repo, "refs/wt/snapshot/wt-1", "clean"); err != nil || work != "" {
+		t.Fatalf("SaveWork of a clean worktree = %q, %v; want nothing saved", work, err)
+	}
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("two\n"), 0o644)
+	os.WriteFile(filepath.Join(repo, "new.txt"), []byte("new\n"), 0o644)
+	work, err := SaveWork(ctx, repo, "refs/wt/snapshot/wt-1", "dirty")
+	if err != nil || work == "" {
+		t.Fatalf("SaveWork = %q, %v; want a commit", work, err)
+	}
+
+	gitTest(t, repo, "reset", "-q", "--hard")
+	os.Remove(filepath.Join(repo, "new.txt"))
+	if err := RestoreWork(ctx, repo, work); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "a.txt")); string(data) != "two\n" {
+		t.Errorf("a.txt = %q, want the saved change", data)
+	}
+	if out, _ := gitOutput(ctx, repo, "status", "--porcelain"); string(out) != " M a.txt\n?? new.txt\n" {
+		t.Errorf("status = %q, want a.txt modified and new.txt untracked", out)
+	}
+	if err := DeleteRef(ctx, repo, "refs/wt/snapshot/wt-1"); err != nil {
+		t.Fatal(err)
+	}
+}
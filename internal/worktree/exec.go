@@ -0,0 +1,104 @@
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// timeout bounds every git command run by this package; zero means no limit.
+var timeout time.Duration
+
+// SetTimeout sets the maximum duration of a single git command.
+func SetTimeout(d time.Duration) {
+	timeout = d
+}
+
+// stopDelay is how long a canceled git command gets to exit after being
+// interrupted, before it is killed.
+const stopDelay = 5 * time.Second
+
+// interruptOnCancel makes cmd interrupt the command when its context is
+// canceled, instead of killing it, so git can remove its lock files, and
+// kill it only if it doesn't exit within stopDelay. Where processes can't
+// be interrupted, as on Windows, it is killed right away.
+func interruptOnCancel(cmd *exec.Cmd) *exec.Cmd {
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = stopDelay
+	return cmd
+}
+
+// gitOutput runs git in dir and returns its stdout.
+func gitOutput(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := runGit(ctx, dir, args, &stdout, nil)
+	return stdout.Bytes(), err
+}
+
+// gitCombined runs git in dir and returns its combined stdout and stderr.
+func gitCombined(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var out bytes.Buffer
+	err := runGit(ctx, dir, args, &out, &out)
+	return out.Bytes(), err
+}
+
+func runGit(ctx context.Context, dir string, args []string, stdout, stderr *bytes.Buffer) error {
+	if fake != nil {
+		return runFake(ctx, dir, args, stdout, stderr)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := gitCommand(ctx, dir, nil, args...)
+	cmd.Stdout = stdout
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+	err := cmd.Run()
+	if cerr := contextError(ctx, args); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// runFake runs a git command against the fake git like runGit does
+// against the binary. Like git, a failing command writes its message to
+// stderr, if captured.
+func runFake(ctx context.Context, dir string, args []string, stdout, stderr *bytes.Buffer) error {
+	if ctx.Err() != nil {
+		return contextError(ctx, args)
+	}
+	out, err := fake.run(dir, args)
+	stdout.WriteString(out)
+	if err == nil || err == errExit || stderr == nil {
+		return err
+	}
+	stderr.WriteString(err.Error() + "\n")
+	return errExit
+}
+
+// ErrTimeout is returned when a git command exceeds the configured timeout.
+var ErrTimeout = errors.New("timed out")
+
+// contextError describes why a git command was interrupted, if it was.
+func contextError(ctx context.Context, args []string) error {
+	switch {
+	case timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("git %s %w after %s", strings.Join(args, " "), ErrTimeout, timeout)
+	case ctx.Err() != nil:
+		return fmt.Errorf("git %s: %w", strings.Join(args, " "), ctx.Err())
+	}
+	return nil
+}
@@ -0,0 +1,739 @@
+package worktree
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fake is an in-memory git that stands in for the git binary once passed
+// to UseFake. It understands the commands this package runs for the task
+// lifecycle (creating, listing, inspecting and removing worktrees and
+// branches), so callers exercise their usual code paths, output parsing
+// included, without a repository on disk. Worktree directories are
+// created and removed, empty; files are never read, so changes are
+// simulated with SetChanged and commits with Commit. Commands it does not
+// understand fail.
+//
+// A Fake is JSON-serializable, so a simulation can span several runs of
+// wt; see LoadFake and Save.
+type Fake struct {
+	mu    sync.Mutex
+	Repos map[string]*fakeRepo `json:"repos"`
+	// Seq numbers the commits created, which keeps their hashes unique.
+	Seq int `json:"seq"`
+}
+
+type fakeRepo struct {
+	// Refs maps full ref names, e.g. refs/heads/main, to commits.
+	Refs    map[string]string     `json:"refs"`
+	Commits map[string]fakeCommit `json:"commits"`
+	// Worktrees lists the main worktree first.
+	Worktrees []*fakeWorktree   `json:"worktrees"`
+	Config    map[string]string `json:"config,omitempty"`
+}
+
+type fakeWorktree struct {
+	Path string `json:"path"`
+	// Branch is empty for a detached HEAD at Head.
+	Branch  string            `json:"branch,omitempty"`
+	Head    string            `json:"head,omitempty"`
+	Changed int               `json:"changed,omitempty"`
+	Config  map[string]string `json:"config,omitempty"`
+}
+
+type fakeCommit struct {
+	Parent  string `json:"parent,omitempty"`
+	Subject string `json:"subject"`
+	Author  string `json:"author"`
+	Time    int64  `json:"time"`
+}
+
+// fake replaces the git binary when set; see UseFake.
+var fake *Fake
+
+// UseFake makes this package run git commands against f instead of the git
+// binary, for every repository; nil restores the git binary.
+func UseFake(f *Fake) {
+	fake = f
+}
+
+// UsingFake reports whether git commands run against a Fake.
+func UsingFake() bool {
+	return fake != nil
+}
+
+// NewFake returns a Fake without repositories.
+func NewFake() *Fake {
+	return &Fake{Repos: make(map[string]*fakeRepo)}
+}
+
+// LoadFake reads a Fake saved with Save, or returns a new one if path does
+// not exist.
+func LoadFake(path string) (*Fake, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewFake(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulated repositories: %w", err)
+	}
+	f := NewFake()
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse simulated repositories %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Save writes f to path as JSON.
+func (f *Fake) Save(path string) error {
+	f.mu.Lock()
+	data, err := json.MarshalIndent(f, "", "  ")
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save simulated repositories: %w", err)
+	}
+	return nil
+}
+
+// HasRepo reports whether path is a repository of f.
+func (f *Fake) HasRepo(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.Repos[filepath.Clean(path)]
+	return ok
+}
+
+// AddRepo adds a repository at path, with one commit on branch checked out
+// in its main worktree. The directory itself is left alone.
+func (f *Fake) AddRepo(path, branch string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	r := &fakeRepo{
+		Refs:      make(map[string]string),
+		Commits:   make(map[string]fakeCommit),
+		Worktrees: []*fakeWorktree{{Path: path, Branch: branch}},
+	}
+	r.Refs["refs/heads/"+branch] = f.newCommit(r, "", "Initial commit")
+	f.Repos[path] = r
+}
+
+// Commit records a commit on the branch checked out in the worktree at
+// dir, which leaves it without changes, and returns the commit's hash.
+func (f *Fake) Commit(dir, subject string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, wt, err := f.locate(dir)
+	if err != nil {
+		return "", err
+	}
+	commit := f.newCommit(r, r.head(wt), subject)
+	if wt.Branch == "" {
+		wt.Head = commit
+	} else {
+		r.Refs["refs/heads/"+wt.Branch] = commit
+	}
+	wt.Changed = 0
+	return commit, nil
+}
+
+// SetChanged sets the number of uncommitted changes in the worktree at dir.
+func (f *Fake) SetChanged(dir string, n int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, wt, err := f.locate(dir)
+	if err != nil {
+		return err
+	}
+	wt.Changed = n
+	return nil
+}
+
+// Switch checks out branch in the worktree at dir, even when another
+// worktree has it checked out, as 'git checkout --ignore-other-worktrees'
+// does.
+func (f *Fake) Switch(dir, branch string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, wt, err := f.locate(dir)
+	if err != nil {
+		return err
+	}
+	if r.Refs["refs/heads/"+branch] == "" {
+		return fmt.Errorf("error: pathspec '%s' did not match any file(s) known to git", branch)
+	}
+	wt.Branch = branch
+	return nil
+}
+
+func (f *Fake) newCommit(r *fakeRepo, parent, subject string) string {
+	f.Seq++
+	sum := sha1.Sum([]byte(strconv.Itoa(f.Seq) + "\x00" + parent + "\x00" + subject))
+	hash := hex.EncodeToString(sum[:])
+	r.Commits[hash] = fakeCommit{Parent: parent, Subject: subject, Author: "wt", Time: time.Now().Unix()}
+	return hash
+}
+
+// locate finds the repository and worktree containing dir.
+func (f *Fake) locate(dir string) (*fakeRepo, *fakeWorktree, error) {
+	var repo *fakeRepo
+	var found *fakeWorktree
+	for _, r := range f.Repos {
+		for _, wt := range r.Worktrees {
+			if _, ok := within(wt.Path, dir); ok && (found == nil || len(wt.Path) > len(found.Path)) {
+				repo, found = r, wt
+			}
+		}
+	}
+	if found == nil {
+		return nil, nil, fmt.Errorf("fatal: not a git repository: %s", dir)
+	}
+	return repo, found, nil
+}
+
+func (r *fakeRepo) head(wt *fakeWorktree) string {
+	if wt.Branch == "" {
+		return wt.Head
+	}
+	return r.Refs["refs/heads/"+wt.Branch]
+}
+
+// worktree returns the worktree at path, if any.
+func (r *fakeRepo) worktree(path string) (int, *fakeWorktree) {
+	path = filepath.Clean(path)
+	for i, wt := range r.Worktrees {
+		if wt.Path == path {
+			return i, wt
+		}
+	}
+	return -1, nil
+}
+
+// checkedOut returns the worktree that has branch checked out, if any.
+func (r *fakeRepo) checkedOut(branch string) *fakeWorktree {
+	for _, wt := range r.Worktrees {
+		if wt.Branch == branch {
+			return wt
+		}
+	}
+	return nil
+}
+
+// resolve returns the commit a revision names: HEAD, a ref, a branch, a
+// remote-tracking branch or a commit, optionally followed by ^ for parents.
+func (r *fakeRepo) resolve(wt *fakeWorktree, rev string) (string, error) {
+	name := strings.TrimRight(rev, "^")
+	parents := len(rev) - len(name)
+	var commit string
+	switch {
+	case name == "HEAD":
+		commit = r.head(wt)
+	case r.Refs[name] != "":
+		commit = r.Refs[name]
+	case r.Refs["refs/heads/"+name] != "":
+		commit = r.Refs["refs/heads/"+name]
+	case r.Refs["refs/remotes/"+name] != "":
+		commit = r.Refs["refs/remotes/"+name]
+	default:
+		if len(name) >= 4 {
+			for hash := range r.Commits {
+				if strings.HasPrefix(hash, name) {
+					commit = hash
+				}
+			}
+		}
+	}
+	for ; commit != "" && parents > 0; parents-- {
+		commit = r.Commits[commit].Parent
+	}
+	if commit == "" {
+		return "", fmt.Errorf("fatal: ambiguous argument '%s': unknown revision", rev)
+	}
+	return commit, nil
+}
+
+// ancestors returns commit and the commits before it, nearest first.
+func (r *fakeRepo) ancestors(commit string) []string {
+	var out []string
+	for ; commit != ""; commit = r.Commits[commit].Parent {
+		out = append(out, commit)
+	}
+	return out
+}
+
+func (r *fakeRepo) mergeBase(commits ...string) string {
+	if len(commits) == 0 {
+		return ""
+	}
+	for _, c := range r.ancestors(commits[0]) {
+		common := true
+		for _, other := range commits[1:] {
+			if !contains(r.ancestors(other), c) {
+				common = false
+				break
+			}
+		}
+		if common {
+			return c
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// errExit is the error of a failed command, whose message, if any, went
+// to stderr.
+var errExit = errors.New("exit status 1")
+
+// run runs the git command args in dir and returns its output.
+func (f *Fake) run(dir string, args []string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: git <command>")
+	}
+	r, wt, err := f.locate(dir)
+	if err != nil {
+		return "", err
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "rev-parse":
+		return f.revParse(r, wt, args)
+	case "check-ref-format":
+		for _, ref := range args {
+			name := strings.TrimPrefix(ref, "refs/heads/")
+			if name == "" || CleanRefName(name) != name {
+				return "", fmt.Errorf("fatal: '%s' is not a valid ref name", ref)
+			}
+		}
+		return "", nil
+	case "remote":
+		if len(args) > 0 {
+			return "", fmt.Errorf("error: No such remote '%s'", args[len(args)-1])
+		}
+		return "", nil
+	case "ls-remote":
+		return "", fmt.Errorf("fatal: 'origin' does not appear to be a git repository")
+	case "for-each-ref":
+		return r.forEachRef(args), nil
+	case "config":
+		return r.config(wt, args)
+	case "worktree":
+		return f.worktreeCommand(r, wt, args)
+	case "branch":
+		if len(args) == 3 && args[0] == "--track" {
+			if r.Refs["refs/heads/"+args[1]] != "" {
+				return "", fmt.Errorf("fatal: a branch named '%s' already exists", args[1])
+			}
+			c, err := r.resolve(wt, args[2])
+			if err != nil {
+				return "", err
+			}
+			r.Refs["refs/heads/"+args[1]] = c
+			return "", nil
+		}
+		if len(args) != 2 || (args[0] != "-D" && args[0] != "-d") {
+			break
+		}
+		if other := r.checkedOut(args[1]); other != nil {
+			return "", fmt.Errorf("error: cannot delete branch '%s' used by worktree at '%s'", args[1], other.Path)
+		}
+		if r.Refs["refs/heads/"+args[1]] == "" {
+			return "", fmt.Errorf("error: branch '%s' not found", args[1])
+		}
+		delete(r.Refs, "refs/heads/"+args[1])
+		return "", nil
+	case "symbolic-ref":
+		if len(args) > 0 && args[len(args)-1] == "HEAD" {
+			if wt.Branch == "" {
+				return "", errExit
+			}
+			return "refs/heads/" + wt.Branch + "\n", nil
+		}
+		return "", fmt.Errorf("fatal: ref %s is not a symbolic ref", args[len(args)-1])
+	case "status":
+		return r.status(wt), nil
+	case "rev-list":
+		if len(args) != 3 || args[0] != "--left-right" || args[1] != "--count" {
+			break
+		}
+		left, right, ok := strings.Cut(args[2], "...")
+		if !ok {
+			break
+		}
+		a, err := r.resolve(wt, left)
+		if err != nil {
+			return "", err
+		}
+		b, err := r.resolve(wt, right)
+		if err != nil {
+			return "", err
+		}
+		ahead, behind := r.ancestors(a), r.ancestors(b)
+		return fmt.Sprintf("%d\t%d\n", countMissing(ahead, behind), countMissing(behind, ahead)), nil
+	case "merge-base":
+		isAncestor := len(args) > 0 && args[0] == "--is-ancestor"
+		var commits []string
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "--") {
+				continue
+			}
+			c, err := r.resolve(wt, arg)
+			if err != nil {
+				return "", err
+			}
+			commits = append(commits, c)
+		}
+		if isAncestor {
+			if len(commits) != 2 || !contains(r.ancestors(commits[1]), commits[0]) {
+				return "", errExit
+			}
+			return "", nil
+		}
+		base := r.mergeBase(commits...)
+		if base == "" {
+			return "", errExit
+		}
+		return base + "\n", nil
+	case "log":
+		return r.log(wt, args)
+	case "diff":
+		if contains(args, "--no-index") {
+			break
+		}
+		// Worktrees hold no files, so there is never a difference.
+		return "", nil
+	case "fetch", "reset":
+		return "", nil
+	case "push":
+		if len(args) > 0 && slices.Contains(args, "--delete") {
+			delete(r.Refs, "refs/remotes/origin/"+args[len(args)-1])
+		} else if len(args) > 0 {
+			branch := args[len(args)-1]
+			if c := r.Refs["refs/heads/"+branch]; c != "" {
+				r.Refs["refs/remotes/origin/"+branch] = c
+			}
+		}
+		return "", nil
+	case "update-ref":
+		if len(args) == 2 && args[0] == "-d" {
+			delete(r.Refs, args[1])
+			return "", nil
+		}
+		if len(args) >= 2 {
+			c, err := r.resolve(wt, args[1])
+			if err != nil {
+				return "", err
+			}
+			r.Refs[args[0]] = c
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("git %s is not supported by the fake git", strings.Join(append([]string{cmd}, args...), " "))
+}
+
+// countMissing counts the commits of a that are not in b.
+func countMissing(a, b []string) int {
+	n := 0
+	for _, c := range a {
+		if !contains(b, c) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *Fake) revParse(r *fakeRepo, wt *fakeWorktree, args []string) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--verify" || arg == "-q" || arg == "--quiet" || strings.HasPrefix(arg, "--path-format="):
+		case arg == "--show-toplevel":
+			out.WriteString(wt.Path + "\n")
+		case arg == "--git-path" && i+1 < len(args):
+			i++
+			out.WriteString(filepath.Join(wt.Path, ".git", args[i]) + "\n")
+		case strings.HasPrefix(arg, "-"):
+			return "", fmt.Errorf("git rev-parse %s is not supported by the fake git", arg)
+		default:
+			c, err := r.resolve(wt, arg)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(c + "\n")
+		}
+	}
+	return out.String(), nil
+}
+
+func (r *fakeRepo) forEachRef(args []string) string {
+	format := "%(objectname) commit\t%(refname)"
+	var patterns []string
+	for _, arg := range args {
+		if v, ok := strings.CutPrefix(arg, "--format="); ok {
+			format = v
+		} else {
+			patterns = append(patterns, strings.TrimSuffix(arg, "/"))
+		}
+	}
+	refs := make([]string, 0, len(r.Refs))
+	for ref := range r.Refs {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	var out strings.Builder
+	for _, ref := range refs {
+		match := len(patterns) == 0
+		for _, p := range patterns {
+			if ref == p || strings.HasPrefix(ref, p+"/") {
+				match = true
+			}
+		}
+		if !match {
+			continue
+		}
+		commit := r.Commits[r.Refs[ref]]
+		out.WriteString(strings.NewReplacer(
+			"%(refname)", ref,
+			"%(objectname)", r.Refs[ref],
+			"%(creatordate:unix)", strconv.FormatInt(commit.Time, 10),
+			"%(subject)", commit.Subject,
+			"%00", "\x00",
+		).Replace(format) + "\n")
+	}
+	return out.String()
+}
+
+func (r *fakeRepo) config(wt *fakeWorktree, args []string) (string, error) {
+	values := &r.Config
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "--worktree":
+			values = &wt.Config
+		case "--get", "--bool":
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	switch len(rest) {
+	case 1:
+		v, ok := (*values)[rest[0]]
+		if !ok && values == &wt.Config {
+			v, ok = r.Config[rest[0]]
+		}
+		if !ok {
+			return "", errExit
+		}
+		return v + "\n", nil
+	case 2:
+		if *values == nil {
+			*values = make(map[string]string)
+		}
+		(*values)[rest[0]] = rest[1]
+		return "", nil
+	}
+	return "", fmt.Errorf("git config %s is not supported by the fake git", strings.Join(args, " "))
+}
+
+func (f *Fake) worktreeCommand(r *fakeRepo, wt *fakeWorktree, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: git worktree <command>")
+	}
+	sub, args := args[0], args[1:]
+	switch sub {
+	case "add":
+		var branch string
+		var rest []string
+		for i := 0; i < len(args); i++ {
+			switch args[i] {
+			case "-b":
+				if i+1 < len(args) {
+					i++
+					branch = args[i]
+				}
+			case "--no-checkout", "-q", "--quiet":
+			default:
+				rest = append(rest, args[i])
+			}
+		}
+		if len(rest) == 0 {
+			return "", fmt.Errorf("usage: git worktree add <path> [<commit-ish>]")
+		}
+		path := filepath.Clean(rest[0])
+		if _, existing := r.worktree(path); existing != nil {
+			return "", fmt.Errorf("fatal: '%s' is already registered as a worktree", path)
+		}
+		added := &fakeWorktree{Path: path}
+		if branch != "" {
+			if r.Refs["refs/heads/"+branch] != "" {
+				return "", fmt.Errorf("fatal: a branch named '%s' already exists", branch)
+			}
+			start := "HEAD"
+			if len(rest) > 1 {
+				start = rest[1]
+			}
+			c, err := r.resolve(wt, start)
+			if err != nil {
+				return "", err
+			}
+			r.Refs["refs/heads/"+branch] = c
+			added.Branch = branch
+		} else {
+			if len(rest) < 2 || r.Refs["refs/heads/"+rest[1]] == "" {
+				return "", fmt.Errorf("fatal: invalid reference: %s", strings.Join(rest[1:], " "))
+			}
+			if other := r.checkedOut(rest[1]); other != nil {
+				return "", fmt.Errorf("fatal: '%s' is already checked out at '%s'", rest[1], other.Path)
+			}
+			added.Branch = rest[1]
+		}
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return "", fmt.Errorf("fatal: could not create directory of '%s': %w", path, err)
+		}
+		r.Worktrees = append(r.Worktrees, added)
+		return "", nil
+	case "remove":
+		force := contains(args, "--force") || contains(args, "-f")
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			i, target := r.worktree(arg)
+			if i <= 0 {
+				return "", fmt.Errorf("fatal: '%s' is not a working tree", arg)
+			}
+			if target.Changed > 0 && !force {
+				return "", fmt.Errorf("fatal: '%s' contains modified or untracked files, use --force to delete it", arg)
+			}
+			if err := os.RemoveAll(target.Path); err != nil {
+				return "", err
+			}
+			r.Worktrees = append(r.Worktrees[:i], r.Worktrees[i+1:]...)
+		}
+		return "", nil
+	case "list":
+		var out strings.Builder
+		for _, w := range r.Worktrees {
+			// Like git, list paths with symlinks resolved.
+			path := w.Path
+			if resolved, err := filepath.EvalSymlinks(path); err == nil {
+				path = resolved
+			}
+			fmt.Fprintf(&out, "worktree %s\nHEAD %s\n", path, r.head(w))
+			if w.Branch == "" {
+				out.WriteString("detached\n\n")
+			} else {
+				fmt.Fprintf(&out, "branch refs/heads/%s\n\n", w.Branch)
+			}
+		}
+		return out.String(), nil
+	case "prune":
+		kept := r.Worktrees[:1]
+		for _, w := range r.Worktrees[1:] {
+			if _, err := os.Stat(w.Path); err == nil {
+				kept = append(kept, w)
+			}
+		}
+		r.Worktrees = kept
+		return "", nil
+	case "repair":
+		return "", nil
+	case "move":
+		if len(args) != 2 {
+			break
+		}
+		i, target := r.worktree(args[0])
+		if i <= 0 {
+			return "", fmt.Errorf("fatal: '%s' is not a working tree", args[0])
+		}
+		if err := os.Rename(target.Path, args[1]); err != nil {
+			return "", fmt.Errorf("fatal: failed to move '%s' to '%s': %w", args[0], args[1], err)
+		}
+		target.Path = filepath.Clean(args[1])
+		return "", nil
+	}
+	return "", fmt.Errorf("git worktree %s is not supported by the fake git", sub)
+}
+
+func (r *fakeRepo) status(wt *fakeWorktree) string {
+	var out strings.Builder
+	head := r.head(wt)
+	fmt.Fprintf(&out, "# branch.oid %s\n", head)
+	if wt.Branch == "" {
+		out.WriteString("# branch.head (detached)\n")
+	} else {
+		fmt.Fprintf(&out, "# branch.head %s\n", wt.Branch)
+		if upstream := r.Refs["refs/remotes/origin/"+wt.Branch]; upstream != "" {
+			ahead, behind := r.ancestors(head), r.ancestors(upstream)
+			fmt.Fprintf(&out, "# branch.upstream origin/%s\n# branch.ab +%d -%d\n", wt.Branch, countMissing(ahead, behind), countMissing(behind, ahead))
+		}
+	}
+	for i := 1; i <= wt.Changed; i++ {
+		fmt.Fprintf(&out, "? file%d\n", i)
+	}
+	// Nothing is ever committed to disk, so files written into the
+	// worktree are untracked.
+	entries, _ := os.ReadDir(wt.Path)
+	for _, e := range entries {
+		if !e.IsDir() {
+			fmt.Fprintf(&out, "? %s\n", e.Name())
+		}
+	}
+	return out.String()
+}
+
+func (r *fakeRepo) log(wt *fakeWorktree, args []string) (string, error) {
+	format := "%H %s"
+	rev := "HEAD"
+	for _, arg := range args {
+		switch {
+		case arg == "-1" || arg == "-n1":
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "-"):
+			return "", fmt.Errorf("git log %s is not supported by the fake git", arg)
+		default:
+			rev = arg
+		}
+	}
+	hash, err := r.resolve(wt, rev)
+	if err != nil {
+		return "", err
+	}
+	c := r.Commits[hash]
+	return strings.NewReplacer(
+		"%H", hash,
+		"%h", hash[:7],
+		"%s", c.Subject,
+		"%an", c.Author,
+		"%ct", strconv.FormatInt(c.Time, 10),
+		"%x00", "\x00",
+	).Replace(format) + "\n", nil
+}
@@ -0,0 +1,131 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFake(t *testing.T) {
+	f := NewFake()
+	UseFake(f)
+	t.Cleanup(func() { UseFake(nil) })
+	ctx := context.Background()
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	wtPath := filepath.Join(root, "worktrees", "feature")
+	f.AddRepo(repo, "main")
+
+	if name, err := RepoName(ctx, repo); err != nil || name != "repo" {
+		t.Fatalf("RepoName() = %q, %v", name, err)
+	}
+	if branch := DefaultBranch(ctx, repo); branch != "main" {
+		t.Errorf("DefaultBranch() = %q, want main", branch)
+	}
+	if err := CheckBranchName(ctx, repo, "feature/x"); err != nil {
+		t.Errorf("CheckBranchName() = %v", err)
+	}
+	if err := Create(ctx, repo, wtPath, "feature/x", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Errorf("worktree directory was not created: %v", err)
+	}
+	if ref, err := ConflictingRef(ctx, repo, "feature"); err != nil || ref != "refs/heads/feature/x" {
+		t.Errorf("ConflictingRef() = %q, %v", ref, err)
+	}
+	if err := Create(ctx, repo, filepath.Join(root, "other"), "feature/x", CreateOptions{}); err == nil {
+		t.Error("creating an existing branch succeeded")
+	}
+
+	wts, err := List(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, wt := range wts {
+		got = append(got, wt.Path+" "+wt.Branch)
+	}
+	want := []string{repo + " refs/heads/main", wtPath + " refs/heads/feature/x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	if !BranchExists(ctx, repo, "feature/x") || BranchExists(ctx, repo, "missing") {
+		t.Error("BranchExists() is wrong")
+	}
+
+	f.Commit(wtPath, "Add feature")
+	f.Commit(wtPath, "Fix feature")
+	f.Commit(repo, "Unrelated")
+	if ahead, behind, err := Compare(ctx, wtPath, "main"); err != nil || ahead != 2 || behind != 1 {
+		t.Errorf("Compare() = %d, %d, %v; want 2, 1", ahead, behind, err)
+	}
+	if c, err := LastCommit(ctx, wtPath); err != nil || c.Subject != "Fix feature" {
+		t.Errorf("LastCommit() = %+v, %v", c, err)
+	}
+	if err := SetConfig(ctx, repo, wtPath, map[string]string{"user.email": "me@work.example"}); err != nil {
+		t.Errorf("SetConfig() = %v", err)
+	}
+
+	f.SetChanged(wtPath, 2)
+	if st, err := Status(ctx, wtPath); err != nil || st.Changed != 2 {
+		t.Errorf("Status() = %+v, %v", st, err)
+	}
+	if problems, err := CheckoutProblems(ctx, wtPath); err != nil || len(problems) != 1 {
+		t.Errorf("CheckoutProblems() = %v, %v", problems, err)
+	}
+	if out, err := gitCombined(ctx, repo, "worktree", "remove", wtPath); err == nil {
+		t.Error("removing a worktree with changes succeeded")
+	} else if !strings.Contains(string(out), "use --force") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if err := DeleteBranch(ctx, repo, "feature/x"); err == nil {
+		t.Error("DeleteBranch() of a checked out branch succeeded")
+	}
+	if err := Push(ctx, wtPath, "feature/x"); err != nil || !BranchExists(ctx, repo, "refs/remotes/origin/feature/x") {
+		t.Errorf("Push() = %v, want origin/feature/x", err)
+	}
+	if err := Remove(ctx, repo, wtPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteBranch(ctx, repo, "feature/x"); err != nil {
+		t.Errorf("DeleteBranch() = %v", err)
+	}
+	if err := DeleteRemoteBranch(ctx, repo, "feature/x"); err != nil || BranchExists(ctx, repo, "refs/remotes/origin/feature/x") {
+		t.Errorf("DeleteRemoteBranch() = %v, want origin/feature/x gone", err)
+	}
+	if _, err := os.Stat(wtPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("worktree directory still exists: %v", err)
+	}
+
+	if _, err := RepoName(ctx, root); err == nil {
+		t.Error("RepoName() outside a repository succeeded")
+	}
+	if _, err := gitOutput(ctx, repo, "gc"); err == nil {
+		t.Error("unsupported command succeeded")
+	}
+}
+
+func TestFakeSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "git.json")
+	f := NewFake()
+	f.AddRepo("/src/app", "main")
+	f.Commit("/src/app", "Second")
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFake(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.HasRepo("/src/app") || loaded.Seq != 2 || len(loaded.Repos["/src/app"].Commits) != 2 {
+		t.Errorf("loaded %+v", loaded.Repos["/src/app"])
+	}
+	if f, err := LoadFake(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(f.Repos) != 0 {
+		t.Errorf("LoadFake() of a missing file = %+v, %v", f, err)
+	}
+}
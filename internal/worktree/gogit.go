@@ -0,0 +1,306 @@
+package worktree
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GoGitBackend answers read queries in-process with go-git, avoiding a git
+// process per call. Useful on Windows and in containers without git.
+type GoGitBackend struct{}
+
+func openRepo(path string) (*git.Repository, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository %s: %w", path, err)
+	}
+	return repo, nil
+}
+
+func (GoGitBackend) BranchExists(ctx context.Context, repoPath, branch string) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	repo, err := openRepo(repoPath)
+	if err != nil {
+		return false
+	}
+	_, err = repo.ResolveRevision(plumbing.Revision(branch))
+	return err == nil
+}
+
+func (GoGitBackend) Status(ctx context.Context, worktreePath string) (StatusInfo, error) {
+	var st StatusInfo
+	if err := ctx.Err(); err != nil {
+		return st, err
+	}
+	repo, err := openRepo(worktreePath)
+	if err != nil {
+		return st, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return st, fmt.Errorf("failed to get status of %s: %w", worktreePath, err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return st, fmt.Errorf("failed to get status of %s: %w", worktreePath, err)
+	}
+	for path, fs := range status {
+		if fs.Staging != git.Unmodified || fs.Worktree != git.Unmodified {
+			st.Changed++
+		}
+		if fs.Worktree == git.Untracked {
+			st.Untracked = append(st.Untracked, path)
+		}
+	}
+	sort.Strings(st.Untracked)
+
+	head, err := repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return st, nil
+	}
+	branch, err := repo.Branch(head.Name().Short())
+	if err != nil || branch.Remote == "" || branch.Merge == "" {
+		return st, nil
+	}
+	upstream, err := repo.Reference(plumbing.NewRemoteReferenceName(branch.Remote, branch.Merge.Short()), true)
+	if err != nil {
+		return st, nil
+	}
+	st.HasUpstream = true
+	st.Ahead, st.Behind, err = aheadBehind(ctx, repo, head.Hash(), upstream.Hash())
+	return st, err
+}
+
+// aheadBehind counts commits reachable from only one of local and upstream.
+// Like git, it walks both histories at once, newest commit first, and stops
+// once the commits left to walk are reachable from both and older than any
+// counted: the walk covers the commits since the merge base, not the whole
+// history. A commit found to be reachable from both sides after it was
+// counted is walked again. As in git, a committer clock that went backwards
+// can make the counts slightly off.
+func aheadBehind(ctx context.Context, repo *git.Repository, local, upstream plumbing.Hash) (int, int, error) {
+	if local == upstream {
+		return 0, 0, nil
+	}
+	const (
+		fromLocal    = 1
+		fromUpstream = 2
+		fromBoth     = fromLocal | fromUpstream
+	)
+	counts := make(map[int]int)
+	flags := make(map[plumbing.Hash]int)
+	queued := make(map[plumbing.Hash]bool)
+	walked := make(map[plumbing.Hash]bool)
+	commits := make(map[plumbing.Hash]*object.Commit)
+	var queue commitQueue
+	// pending counts the queued commits not known to be reachable from
+	// both sides. oldest is the time of the oldest commit counted, as
+	// only commits at least as old can lead to it.
+	pending := 0
+	var oldest time.Time
+	mark := func(h plumbing.Hash, f int) error {
+		old := flags[h]
+		if old|f == old {
+			return nil
+		}
+		flags[h] = old | f
+		if queued[h] {
+			if old|f == fromBoth {
+				pending--
+			}
+			return nil
+		}
+		if walked[h] {
+			counts[old]--
+		}
+		c, ok := commits[h]
+		if !ok {
+			var err error
+			if c, err = repo.CommitObject(h); err != nil {
+				return fmt.Errorf("failed to walk history from %s: %w", h, err)
+			}
+			commits[h] = c
+		}
+		heap.Push(&queue, c)
+		queued[h] = true
+		if old|f != fromBoth {
+			pending++
+		}
+		return nil
+	}
+	if err := mark(local, fromLocal); err != nil {
+		return 0, 0, err
+	}
+	if err := mark(upstream, fromUpstream); err != nil {
+		return 0, 0, err
+	}
+
+	for pending > 0 || queue.Len() > 0 && !queue.newest().Before(oldest) {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		c := heap.Pop(&queue).(*object.Commit)
+		queued[c.Hash], walked[c.Hash] = false, true
+		f := flags[c.Hash]
+		if f != fromBoth {
+			counts[f]++
+			pending--
+			if oldest.IsZero() || c.Committer.When.Before(oldest) {
+				oldest = c.Committer.When
+			}
+		}
+		for _, parent := range c.ParentHashes {
+			if err := mark(parent, f); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	return counts[fromLocal], counts[fromUpstream], nil
+}
+
+// commitQueue is a heap of commits, newest committed first, and of those
+// with the same time the first queued first, so that with no clock skew a
+// commit comes after the commits that led to it, as in git.
+type commitQueue struct {
+	items []queuedCommit
+	seq   int
+}
+
+type queuedCommit struct {
+	*object.Commit
+	seq int
+}
+
+func (q *commitQueue) Len() int { return len(q.items) }
+func (q *commitQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if !a.Committer.When.Equal(b.Committer.When) {
+		return a.Committer.When.After(b.Committer.When)
+	}
+	return a.seq < b.seq
+}
+func (q *commitQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *commitQueue) Push(x any) {
+	q.seq++
+	q.items = append(q.items, queuedCommit{x.(*object.Commit), q.seq})
+}
+
+// newest returns the time of the commit Pop returns next.
+func (q *commitQueue) newest() time.Time { return q.items[0].Committer.When }
+
+func (q *commitQueue) Pop() any {
+	c := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return c.Commit
+}
+
+// List reads worktree metadata directly from the repository's common git
+// directory, mirroring `git worktree list --porcelain`.
+func (GoGitBackend) List(ctx context.Context, repoPath string) ([]WorktreeInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo, err := openRepo(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	common, err := commonDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository config: %w", err)
+	}
+	main := WorktreeInfo{Path: filepath.Dir(common)}
+	if cfg.Core.IsBare {
+		main = WorktreeInfo{Path: common, Bare: true}
+	} else if err := readHead(repo, common, &main); err != nil {
+		return nil, err
+	}
+	worktrees := []WorktreeInfo{main}
+
+	entries, err := os.ReadDir(filepath.Join(common, "worktrees"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, e := range entries {
+		adminDir := filepath.Join(common, "worktrees", e.Name())
+		gitdir, err := os.ReadFile(filepath.Join(adminDir, "gitdir"))
+		if err != nil {
+			continue
+		}
+		info := WorktreeInfo{Path: filepath.Dir(strings.TrimSpace(string(gitdir)))}
+		if err := readHead(repo, adminDir, &info); err != nil {
+			return nil, err
+		}
+		worktrees = append(worktrees, info)
+	}
+	return worktrees, nil
+}
+
+// readHead fills HEAD and Branch from the HEAD file in gitDir.
+func readHead(repo *git.Repository, gitDir string, info *WorktreeInfo) error {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, "ref: ") {
+		info.HEAD = head
+		return nil
+	}
+	info.Branch = strings.TrimPrefix(head, "ref: ")
+	if ref, err := repo.Reference(plumbing.ReferenceName(info.Branch), true); err == nil {
+		info.HEAD = ref.Hash().String()
+	}
+	return nil
+}
+
+// commonDir locates the git directory shared by all worktrees of a repo.
+func commonDir(repoPath string) (string, error) {
+	dotGit := filepath.Join(repoPath, ".git")
+	fi, err := os.Stat(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", repoPath)
+	}
+	if fi.IsDir() {
+		return dotGit, nil
+	}
+	// Linked worktree: .git is a file pointing at its admin directory,
+	// which in turn records the common directory.
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	gitdir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(repoPath, gitdir)
+	}
+	common, err := os.ReadFile(filepath.Join(gitdir, "commondir"))
+	if err != nil {
+		return gitdir, nil
+	}
+	dir := strings.TrimSpace(string(common))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitdir, dir)
+	}
+	return filepath.Clean(dir), nil
+}
@@ -0,0 +1,94 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// SplitHost splits the path of a repository or worktree on another
+// machine, written host:/path as scp and git do, into the ssh host and the
+// path there. Local paths, including Windows ones like C:\src, have no
+// host.
+func SplitHost(p string) (host, dir string) {
+	i := strings.Index(p, ":")
+	if i < 2 || strings.ContainsAny(p[:i], `/\`) {
+		return "", p
+	}
+	return p[:i], p[i+1:]
+}
+
+// JoinHost returns the path of dir on host, as SplitHost reads it.
+func JoinHost(host, dir string) string {
+	if host == "" {
+		return dir
+	}
+	return host + ":" + dir
+}
+
+// IsRemote reports whether p is on another machine.
+func IsRemote(p string) bool {
+	host, _ := SplitHost(p)
+	return host != ""
+}
+
+// HostPath returns the absolute path of dir on host, where relative paths
+// are taken from the home directory of the ssh user. It asks the host for
+// its home directory unless dir is absolute.
+func HostPath(ctx context.Context, host, dir string) (string, error) {
+	if path.IsAbs(dir) {
+		return path.Clean(dir), nil
+	}
+	out, err := exec.CommandContext(ctx, "ssh", host, "--", "pwd").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to reach %s over ssh: %w", host, err)
+	}
+	return path.Join(strings.TrimSpace(string(out)), dir), nil
+}
+
+// gitCommand returns the git command run in dir with the extra environment
+// variables env. In a directory on another machine, git runs there over
+// ssh, and the host: prefix is dropped from arguments naming paths on the
+// same machine.
+func gitCommand(ctx context.Context, dir string, env []string, args ...string) *exec.Cmd {
+	host, dir := SplitHost(dir)
+	if host == "" {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+		return interruptOnCancel(cmd)
+	}
+	words := append([]string{"git", "-C", dir}, args...)
+	if len(env) > 0 {
+		words = append(append([]string{"env"}, env...), words...)
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = ShellQuote(strings.TrimPrefix(w, host+":"))
+	}
+	return interruptOnCancel(exec.CommandContext(ctx, "ssh", host, "--", strings.Join(quoted, " ")))
+}
+
+// ShellQuote quotes s for a POSIX shell, such as the one ssh runs commands
+// with on the other end.
+func ShellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@,+%^") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// TopLevel returns the root of the working tree containing dir, on the
+// same machine as dir.
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	out, err := gitOutput(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s: %w", dir, err)
+	}
+	host, _ := SplitHost(dir)
+	return JoinHost(host, strings.TrimSpace(string(out))), nil
+}
@@ -0,0 +1,43 @@
+package worktree
+
+import "testing"
+
+func TestSplitHost(t *testing.T) {
+	tests := []struct {
+		path, host, dir string
+	}{
+		{"/home/me/src/app", "", "/home/me/src/app"},
+		{"devbox:/home/me/src/app", "devbox", "/home/me/src/app"},
+		{"me@devbox.local:src/app", "me@devbox.local", "src/app"},
+		{`C:\src\app`, "", `C:\src\app`},
+		{"./weird:name", "", "./weird:name"},
+		{"relative/dir:x", "", "relative/dir:x"},
+	}
+	for _, tt := range tests {
+		host, dir := SplitHost(tt.path)
+		if host != tt.host || dir != tt.dir {
+			t.Errorf("SplitHost(%q) = %q, %q; want %q, %q", tt.path, host, dir, tt.host, tt.dir)
+		}
+		if got := JoinHost(host, dir); got != tt.path {
+			t.Errorf("JoinHost(%q, %q) = %q, want %q", host, dir, got, tt.path)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"/home/me/src/app", "/home/me/src/app"},
+		{"GIT_INDEX_FILE=/tmp/x", "GIT_INDEX_FILE=/tmp/x"},
+		{"", "''"},
+		{"with space", "'with space'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.input); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Move moves a worktree to a new path, updating git's records of it.
+func Move(ctx context.Context, repoPath, from, to string) error {
+	if out, err := gitCombined(ctx, repoPath, "worktree", "move", from, to); err != nil {
+		return fmt.Errorf("failed to move worktree: %s\n%s", err, string(out))
+	}
+	return nil
+}
+
+// RelinkSymlinks fixes the symlinks in a worktree moved from oldPath to
+// newPath: absolute links into the old location are pointed at the new one,
+// and relative links that reach outside the worktree (e.g. to a directory
+// shared between worktrees) are recomputed for the new depth. It returns
+// the number of links changed.
+func RelinkSymlinks(oldPath, newPath string) (int, error) {
+	fixed := 0
+	err := filepath.WalkDir(newPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(newPath, path)
+		if err != nil {
+			return err
+		}
+		newTarget, ok := relink(target, filepath.Join(oldPath, rel), path, oldPath, newPath)
+		if !ok {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		if err := os.Symlink(newTarget, path); err != nil {
+			return err
+		}
+		fixed++
+		return nil
+	})
+	if err != nil {
+		return fixed, fmt.Errorf("failed to fix symlinks: %w", err)
+	}
+	return fixed, nil
+}
+
+// relink returns the target a symlink at newLink should have, given it
+// pointed at target from oldLink, and whether that differs from target.
+func relink(target, oldLink, newLink, oldPath, newPath string) (string, bool) {
+	if filepath.IsAbs(target) {
+		rest, ok := within(oldPath, target)
+		if !ok {
+			return "", false
+		}
+		return filepath.Join(newPath, rest), true
+	}
+	abs := filepath.Join(filepath.Dir(oldLink), target)
+	if _, ok := within(oldPath, abs); ok {
+		return "", false
+	}
+	newTarget, err := filepath.Rel(filepath.Dir(newLink), abs)
+	if err != nil || newTarget == target {
+		return "", false
+	}
+	return newTarget, true
+}
+
+// within returns path relative to dir if path is dir or inside it.
+func within(dir, path string) (string, bool) {
+	dir, path = filepath.Clean(dir), filepath.Clean(path)
+	if path == dir {
+		return ".", true
+	}
+	rest, ok := strings.CutPrefix(path, dir+string(filepath.Separator))
+	return rest, ok
+}
@@ -0,0 +1,56 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelinkSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old", "task")
+	newPath := filepath.Join(dir, "new", "repo", "task")
+	shared := filepath.Join(dir, "shared")
+	for _, d := range []string{filepath.Join(oldPath, "src"), shared, filepath.Dir(newPath)} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"node_modules": "../../shared",                // relative, outside: recomputed
+		"abs-inside":   filepath.Join(oldPath, "src"), // absolute, inside: moved
+		"abs-outside":  shared,                        // absolute, outside: kept
+		"src/up":       "..",                          // relative, inside: kept
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(oldPath, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	fixed, err := RelinkSymlinks(oldPath, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed != 2 {
+		t.Errorf("fixed %d links, want 2", fixed)
+	}
+	want := map[string]string{
+		"node_modules": "../../../shared",
+		"abs-inside":   filepath.Join(newPath, "src"),
+		"abs-outside":  shared,
+		"src/up":       "..",
+	}
+	for name, target := range want {
+		got, err := os.Readlink(filepath.Join(newPath, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != target {
+			t.Errorf("%s -> %q, want %q", name, got, target)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package worktree
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// DefaultConcurrency is the number of git processes run at once by
+// multi-worktree operations when no limit is configured.
+var DefaultConcurrency = runtime.NumCPU()
+
+// RunAll calls fn for every index in [0, n) using at most limit concurrent
+// goroutines. A limit <= 0 uses DefaultConcurrency. RunAll returns once all
+// calls have completed.
+func RunAll(n, limit int, fn func(i int)) {
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	if limit > n {
+		limit = n
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// StatusResult is the outcome of collecting one worktree's status.
+type StatusResult struct {
+	Info StatusInfo
+	Err  error
+}
+
+// StatusAll collects the status of each worktree concurrently. Results are
+// returned in the same order as paths.
+func StatusAll(ctx context.Context, paths []string, limit int) []StatusResult {
+	results := make([]StatusResult, len(paths))
+	RunAll(len(paths), limit, func(i int) {
+		results[i].Info, results[i].Err = Status(ctx, paths[i])
+	})
+	return results
+}
@@ -0,0 +1,43 @@
+package worktree
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunAllRespectsLimit(t *testing.T) {
+	var running, peak int32
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+
+	RunAll(20, 3, func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		mu.Lock()
+		seen[i] = true
+		mu.Unlock()
+	})
+
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", peak)
+	}
+	if len(seen) != 20 {
+		t.Errorf("expected 20 calls, got %d", len(seen))
+	}
+}
+
+func TestRunAllEmpty(t *testing.T) {
+	RunAll(0, 4, func(i int) {
+		t.Errorf("unexpected call with %d", i)
+	})
+}
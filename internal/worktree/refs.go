@@ -0,0 +1,98 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrBranchConflict is returned when a new branch would collide with an
+// existing branch, locally or on a remote.
+var ErrBranchConflict = errors.New("branch conflicts with an existing ref")
+
+// ErrBranchCheckedOut is returned when a branch can't be deleted because
+// another worktree has it checked out.
+var ErrBranchCheckedOut = errors.New("branch is checked out in another worktree")
+
+// CheckBranchName validates a new branch name with 'git check-ref-format'.
+// It also rejects names git accepts but that are ambiguous as branches:
+// HEAD, names starting with "-", and names starting with a remote's name,
+// which read like remote-tracking branches.
+func CheckBranchName(ctx context.Context, repoPath, branch string) error {
+	switch {
+	case branch == "":
+		return fmt.Errorf("branch name is empty; use a description with letters or digits")
+	case branch == "HEAD" || strings.HasPrefix(branch, "-"):
+		return fmt.Errorf("%q is not allowed as a branch name", branch)
+	}
+	if out, err := gitCombined(ctx, repoPath, "check-ref-format", "refs/heads/"+branch); err != nil {
+		return fmt.Errorf("%q is not a valid branch name: %s%s", branch, err, string(out))
+	}
+	remotes, err := Remotes(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	for _, remote := range remotes {
+		if strings.HasPrefix(branch, remote+"/") {
+			return fmt.Errorf("branch %q starts with the name of remote %q; change branch_prefix or the description", branch, remote)
+		}
+	}
+	return nil
+}
+
+// Remotes returns the names of a repository's remotes.
+func Remotes(ctx context.Context, repoPath string) ([]string, error) {
+	out, err := gitOutput(ctx, repoPath, "remote")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// ConflictingRef returns the existing ref a new branch would collide with,
+// or "" if there is none: a local branch of the same name, a local branch
+// that is a path prefix of it or has it as one (git can't store both
+// "fix" and "fix/login"), or a remote-tracking branch of the same name.
+// Remote branches are those last fetched; the remotes aren't contacted.
+func ConflictingRef(ctx context.Context, repoPath, branch string) (string, error) {
+	remotes, err := Remotes(ctx, repoPath)
+	if err != nil {
+		return "", err
+	}
+	out, err := gitOutput(ctx, repoPath, "for-each-ref", "--format=%(refname)", "refs/heads/", "refs/remotes/")
+	if err != nil {
+		return "", fmt.Errorf("failed to list branches: %w", err)
+	}
+	for _, ref := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			if name == branch || strings.HasPrefix(name, branch+"/") || strings.HasPrefix(branch, name+"/") {
+				return ref, nil
+			}
+			continue
+		}
+		rest := strings.TrimPrefix(ref, "refs/remotes/")
+		for _, remote := range remotes {
+			if name, ok := strings.CutPrefix(rest, remote+"/"); ok && name == branch {
+				return ref, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// CheckedOut returns the paths of the repository's worktrees, including
+// the main one, that have branch checked out.
+func CheckedOut(ctx context.Context, repoPath, branch string) ([]string, error) {
+	wts, err := List(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, wt := range wts {
+		if strings.TrimPrefix(wt.Branch, "refs/heads/") == branch {
+			paths = append(paths, wt.Path)
+		}
+	}
+	return paths, nil
+}
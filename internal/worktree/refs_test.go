@@ -0,0 +1,52 @@
+package worktree
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestConflictingRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", "init")
+	gitTest(t, repo, "remote", "add", "origin", "https://example.com/repo.git")
+	gitTest(t, repo, "branch", "feature/login")
+	gitTest(t, repo, "branch", "fix")
+	gitTest(t, repo, "update-ref", "refs/remotes/origin/feature/signup", "HEAD")
+	ctx := context.Background()
+
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"feature/login", "refs/heads/feature/login"},
+		{"feature/signup", "refs/remotes/origin/feature/signup"},
+		{"fix/crash", "refs/heads/fix"},
+		{"feature", "refs/heads/feature/login"},
+		{"feature/search", ""},
+	}
+	for _, tt := range tests {
+		got, err := ConflictingRef(ctx, repo, tt.branch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("ConflictingRef(%q) = %q, want %q", tt.branch, got, tt.want)
+		}
+	}
+
+	for _, branch := range []string{"", "HEAD", "-x", "origin/fix", "a..b", "x.lock"} {
+		if err := CheckBranchName(ctx, repo, branch); err == nil {
+			t.Errorf("CheckBranchName(%q) succeeded, want error", branch)
+		}
+	}
+	if err := CheckBranchName(ctx, repo, "feature/search"); err != nil {
+		t.Errorf("CheckBranchName(feature/search) = %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bakerweb/wt/internal/terminal"
+)
+
+// ErrAuth is returned when a git command talking to a remote fails because
+// credentials were required but unavailable.
+var ErrAuth = errors.New("git authentication failed")
+
+var authFailureMarkers = []string{
+	"Authentication failed",
+	"could not read Username",
+	"could not read Password",
+	"terminal prompts disabled",
+	"Permission denied (publickey",
+	"Host key verification failed",
+}
+
+// gitRemote runs a git command that may contact a remote. On a terminal,
+// git's output and credential prompts pass straight through to the user.
+// Otherwise prompts are disabled so git fails fast instead of hanging, and
+// authentication failures are reported as ErrAuth with guidance.
+func gitRemote(ctx context.Context, dir string, args ...string) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if fake != nil {
+		var stderr bytes.Buffer
+		if err := runFake(ctx, dir, args, &bytes.Buffer{}, &stderr); err != nil {
+			return fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	cmd := gitCommand(ctx, dir, nil, args...)
+
+	var stderr bytes.Buffer
+	interactive := terminal.IsTerminal(os.Stdin) && terminal.IsTerminal(os.Stderr)
+	if interactive {
+		cmd.Stdin = os.Stdin
+		// Keep stdout clean for callers that print paths.
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	} else {
+		cmd.Stdout = &stderr
+		cmd.Stderr = &stderr
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+		if os.Getenv("GIT_SSH_COMMAND") == "" {
+			cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+		}
+	}
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if cerr := contextError(ctx, args); cerr != nil {
+		return cerr
+	}
+	if isAuthFailure(stderr.String()) {
+		hint := "configure a credential helper (git config --global credential.helper) or load your SSH key into ssh-agent"
+		if !interactive {
+			hint = "run the command from a terminal to enter credentials, or " + hint
+		}
+		return fmt.Errorf("%w for 'git %s': %s", ErrAuth, strings.Join(args, " "), hint)
+	}
+	if interactive {
+		return fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return fmt.Errorf("git %s failed: %s\n%s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+}
+
+func isAuthFailure(output string) bool {
+	for _, marker := range authFailureMarkers {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Push pushes branch to origin and sets it as the branch's upstream.
+func Push(ctx context.Context, dir, branch string) error {
+	return gitRemote(ctx, dir, "push", "-u", "origin", branch)
+}
+
+// DeleteRemoteBranch deletes branch on origin.
+func DeleteRemoteBranch(ctx context.Context, repoPath, branch string) error {
+	return gitRemote(ctx, repoPath, "push", "origin", "--delete", branch)
+}
+
+// remoteHeadTimeout bounds the query for the remote's default branch, which
+// is only a fallback and shouldn't stall commands on a slow network.
+const remoteHeadTimeout = 10 * time.Second
+
+// RemoteHead asks origin which branch its HEAD points to. Credential
+// prompts are disabled; a remote that needs them simply fails.
+func RemoteHead(ctx context.Context, repoPath string) (string, error) {
+	if fake != nil {
+		return "", fmt.Errorf("failed to query origin HEAD: no remote")
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteHeadTimeout)
+	defer cancel()
+	cmd := gitCommand(ctx, repoPath, nil, "ls-remote", "--symref", "origin", "HEAD")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to query origin HEAD: %w", err)
+	}
+	if branch := parseSymref(string(out)); branch != "" {
+		return branch, nil
+	}
+	return "", fmt.Errorf("origin has no HEAD branch")
+}
+
+// parseSymref extracts the branch from 'git ls-remote --symref' output,
+// e.g. "ref: refs/heads/main\tHEAD".
+func parseSymref(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		ref, ok := strings.CutPrefix(line, "ref: refs/heads/")
+		if !ok {
+			continue
+		}
+		if branch, _, ok := strings.Cut(ref, "\t"); ok {
+			return branch
+		}
+	}
+	return ""
+}
+
+// RemoteURL returns the URL of a repository's remote.
+func RemoteURL(ctx context.Context, repoPath, remote string) (string, error) {
+	out, err := gitOutput(ctx, repoPath, "remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL of remote %s: %w", remote, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
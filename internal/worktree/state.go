@@ -0,0 +1,123 @@
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The functions below keep small files on a branch of a remote without a
+// working tree, using a local bare repository as scratch space.
+
+// InitBare creates a bare repository at path if none exists.
+func InitBare(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+	if out, err := gitCombined(ctx, path, "init", "--bare", "-q"); err != nil {
+		return fmt.Errorf("failed to init %s: %s", path, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// FetchBranch fetches branch from url into the bare repository and returns
+// its commit, or "" when the remote has no such branch yet.
+func FetchBranch(ctx context.Context, repoPath, url, branch string) (string, error) {
+	// A pattern refspec matches nothing rather than failing when the branch
+	// does not exist yet.
+	spec := "+refs/heads/" + branch + "*:refs/remotes/state/" + branch + "*"
+	if err := gitRemote(ctx, repoPath, "fetch", "-q", "--no-tags", url, spec); err != nil {
+		return "", err
+	}
+	commit, err := gitOutput(ctx, repoPath, "rev-parse", "--verify", "-q", "refs/remotes/state/"+branch)
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(string(commit)), nil
+}
+
+// ReadFiles returns the contents of all files in a commit, by path.
+func ReadFiles(ctx context.Context, repoPath, commit string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	if commit == "" {
+		return files, nil
+	}
+	out, err := gitOutput(ctx, repoPath, "ls-tree", "-r", "-z", "--name-only", commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", commit, err)
+	}
+	for _, name := range strings.Split(strings.TrimRight(string(out), "\x00"), "\x00") {
+		if name == "" {
+			continue
+		}
+		data, err := gitOutput(ctx, repoPath, "cat-file", "blob", commit+":"+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+// CommitFile creates a commit on top of parent (or a root commit when
+// parent is "") that sets one file, leaving all other files unchanged.
+func CommitFile(ctx context.Context, repoPath, parent, name string, data []byte, message string) (string, error) {
+	index, err := os.CreateTemp("", "wt-index-*")
+	if err != nil {
+		return "", err
+	}
+	index.Close()
+	os.Remove(index.Name())
+	defer os.Remove(index.Name())
+
+	git := func(stdin []byte, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_INDEX_FILE="+index.Name(),
+			"GIT_AUTHOR_NAME=wt", "GIT_AUTHOR_EMAIL=wt@localhost",
+			"GIT_COMMITTER_NAME=wt", "GIT_COMMITTER_EMAIL=wt@localhost",
+		)
+		cmd.Stdin = bytes.NewReader(stdin)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	if parent != "" {
+		if _, err := git(nil, "read-tree", parent); err != nil {
+			return "", err
+		}
+	}
+	blob, err := git(data, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	if _, err := git(nil, "update-index", "--add", "--cacheinfo", "100644,"+blob+","+name); err != nil {
+		return "", err
+	}
+	tree, err := git(nil, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", tree, "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	return git(nil, args...)
+}
+
+// PushCommit updates branch on url to commit. Pushes that are not a
+// fast-forward fail; fetch, commit again and retry.
+func PushCommit(ctx context.Context, repoPath, url, commit, branch string) error {
+	return gitRemote(ctx, repoPath, "push", "-q", url, commit+":refs/heads/"+branch)
+}
@@ -1,94 +1,342 @@
 package worktree
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
+	"unicode"
 )
 
-// SanitizeBranchName converts a description into a valid git branch name.
+// DefaultMaxNameLength is the default maximum length of the sanitized part
+// of a branch name.
+const DefaultMaxNameLength = 60
+
+// NameOptions tunes how descriptions are turned into branch names.
+type NameOptions struct {
+	// MaxLength caps the sanitized description or ticket key and summary;
+	// 0 means DefaultMaxNameLength.
+	MaxLength int
+	// DropStopwords removes filler words like "the" and "of", unless the
+	// description consists of nothing else.
+	DropStopwords bool
+}
+
+func (o NameOptions) maxLength() int {
+	if o.MaxLength > 0 {
+		return o.MaxLength
+	}
+	return DefaultMaxNameLength
+}
+
+// stopwords are dropped from branch names with NameOptions.DropStopwords.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "into": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "so": true,
+	"that": true, "the": true, "this": true, "to": true, "with": true,
+}
+
+// transliterations spell letters that don't decompose into a base letter
+// and combining marks in ASCII.
+var transliterations = map[rune]string{
+	'æ': "ae", 'ð': "d", 'đ': "d", 'ħ': "h", 'ı': "i", 'ł': "l", 'ø': "o",
+	'œ': "oe", 'ß': "ss", 'þ': "th", 'ŧ': "t",
+}
+
+// latinBase maps precomposed accented letters to their base letter.
+var latinBase = map[rune]rune{}
+
+func init() {
+	for base, accented := range map[rune]string{
+		'a': "àáâãäåāăą", 'c': "çćĉċč", 'd': "ď", 'e': "èéêëēĕėęě",
+		'g': "ĝğġģ", 'h': "ĥ", 'i': "ìíîïĩīĭįǐ", 'j': "ĵ", 'k': "ķ",
+		'l': "ĺļľŀ", 'n': "ñńņňŉ", 'o': "òóôõöōŏőǒ", 'r': "ŕŗř",
+		's': "śŝşšș", 't': "ţťț", 'u': "ùúûüũūŭůűųǔ", 'w': "ŵ",
+		'y': "ýÿŷ", 'z': "źżž",
+	} {
+		for _, r := range accented {
+			latinBase[r] = base
+		}
+	}
+}
+
+// transliterate lower-cases s and spells accented Latin letters in ASCII
+// (é→e, ß→ss). Combining marks are dropped; other scripts are kept.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case latinBase[r] != 0:
+			b.WriteRune(latinBase[r])
+		case transliterations[r] != "":
+			b.WriteString(transliterations[r])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SanitizeBranchName converts a description into a valid git branch name
+// with the default NameOptions.
 func SanitizeBranchName(description string) string {
-	s := strings.ToLower(strings.TrimSpace(description))
-	// Replace any non-alphanumeric characters (except hyphens) with hyphens
-	re := regexp.MustCompile(`[^a-z0-9]+`)
-	s = re.ReplaceAllString(s, "-")
-	s = strings.Trim(s, "-")
-	// Limit length
-	if len(s) > 60 {
-		s = s[:60]
-		s = strings.TrimRight(s, "-")
+	return SanitizeBranchNameWith(description, NameOptions{})
+}
+
+// SanitizeBranchNameWith converts a description into a valid git branch
+// name: accented letters are transliterated, anything else that isn't a
+// letter or digit becomes a hyphen, and the result is cut to the maximum
+// length.
+func SanitizeBranchNameWith(description string, opts NameOptions) string {
+	words := strings.Fields(nonAlphanumeric.ReplaceAllString(transliterate(description), " "))
+	if opts.DropStopwords {
+		var kept []string
+		for _, w := range words {
+			if !stopwords[w] {
+				kept = append(kept, w)
+			}
+		}
+		if len(kept) > 0 {
+			words = kept
+		}
+	}
+	return cutName(strings.Join(words, "-"), opts.maxLength())
+}
+
+// cutName shortens a sanitized name to max bytes without a dangling hyphen.
+func cutName(s string, max int) string {
+	if len(s) > max {
+		s = strings.TrimRight(s[:max], "-")
 	}
 	return s
 }
 
 // BranchName generates a full branch name from a prefix and description.
-func BranchName(prefix, description string) string {
-	sanitized := SanitizeBranchName(description)
-	if prefix == "" {
-		return sanitized
+func BranchName(prefix, description string, opts NameOptions) string {
+	sanitized := SanitizeBranchNameWith(description, opts)
+	if prefix == "" || sanitized == "" {
+		return CleanRefName(sanitized)
 	}
-	return prefix + "/" + sanitized
+	return CleanRefName(prefix + "/" + sanitized)
 }
 
 // BranchNameFromTicket generates a branch name from a ticket key and summary.
-func BranchNameFromTicket(prefix, ticketKey, summary string) string {
-	sanitized := SanitizeBranchName(summary)
+// Keys that name a path, like GitLab's group/project#12, are flattened to
+// group-project-12.
+func BranchNameFromTicket(prefix, ticketKey, summary string, opts NameOptions) string {
+	sanitized := SanitizeBranchNameWith(summary, opts)
 	key := strings.ToLower(ticketKey)
-	name := key + "-" + sanitized
-	if len(name) > 60 {
-		name = name[:60]
-		name = strings.TrimRight(name, "-")
+	if strings.ContainsAny(key, "/#") {
+		key = strings.Join(strings.Fields(nonAlphanumeric.ReplaceAllString(key, " ")), "-")
 	}
+	name := cutName(key+"-"+sanitized, opts.maxLength())
 	if prefix == "" {
-		return name
+		return CleanRefName(name)
+	}
+	return CleanRefName(prefix + "/" + name)
+}
+
+// refForbidden matches the characters git never allows in ref names.
+var refForbidden = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]+`)
+
+// CleanRefName makes name acceptable to 'git check-ref-format --branch':
+// characters git forbids become hyphens, and ".." sequences, empty
+// components, leading dots and trailing ".lock" or "." are removed.
+func CleanRefName(name string) string {
+	name = refForbidden.ReplaceAllString(name, "-")
+	name = strings.ReplaceAll(name, "@{", "-")
+	for strings.Contains(name, "..") {
+		name = strings.ReplaceAll(name, "..", ".")
+	}
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		part = strings.TrimLeft(part, ".")
+		for strings.HasSuffix(part, ".lock") || strings.HasSuffix(part, ".") {
+			part = strings.TrimSuffix(strings.TrimSuffix(part, ".lock"), ".")
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	name = strings.Join(parts, "/")
+	if name == "@" {
+		return ""
+	}
+	return name
+}
+
+// BranchTemplateData holds the values available to a branch_template. All
+// values except Prefix are lower-cased and sanitized; empty when unknown.
+type BranchTemplateData struct {
+	Prefix  string
+	Key     string
+	Summary string
+	Epic    string
+}
+
+// BranchNameFromTemplate renders a text/template branch name such as
+// "{{.Prefix}}/{{with .Epic}}{{.}}/{{end}}{{.Key}}-{{.Summary}}". Separators
+// left dangling by empty values are removed.
+func BranchNameFromTemplate(tmpl, prefix, ticketKey, epicKey, summary string, opts NameOptions) (string, error) {
+	t, err := template.New("branch").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid branch_template: %w", err)
+	}
+	data := BranchTemplateData{
+		Prefix:  prefix,
+		Key:     SanitizeBranchNameWith(ticketKey, opts),
+		Summary: SanitizeBranchNameWith(summary, opts),
+		Epic:    SanitizeBranchNameWith(epicKey, opts),
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid branch_template: %w", err)
+	}
+	name := regexp.MustCompile(`/{2,}`).ReplaceAllString(b.String(), "/")
+	name = regexp.MustCompile(`-{2,}`).ReplaceAllString(name, "-")
+	name = regexp.MustCompile(`/-+|-+/`).ReplaceAllString(name, "/")
+	name = CleanRefName(strings.Trim(name, "-/"))
+	if name == "" {
+		return "", fmt.Errorf("branch_template %q produced an empty branch name", tmpl)
 	}
-	return prefix + "/" + name
+	return name, nil
 }
 
 // RepoName extracts the repository name from a git repo path.
-func RepoName(repoPath string) (string, error) {
-	cmd := exec.Command("git", "-C", repoPath, "rev-parse", "--show-toplevel")
-	out, err := cmd.Output()
+func RepoName(ctx context.Context, repoPath string) (string, error) {
+	out, err := gitOutput(ctx, repoPath, "rev-parse", "--show-toplevel")
 	if err != nil {
+		// Surface timeouts and a missing git binary as-is.
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", err
+		}
 		return "", fmt.Errorf("not a git repository: %s", repoPath)
 	}
 	return filepath.Base(strings.TrimSpace(string(out))), nil
 }
 
+// CreateOptions configures worktree creation.
+type CreateOptions struct {
+	// NoCheckout creates the worktree without populating files; call
+	// Checkout later to fill it in.
+	NoCheckout bool
+}
+
 // Create creates a new git worktree at the specified path with the given branch.
-func Create(repoPath, worktreePath, branch string) error {
+// On a terminal, git's checkout progress is streamed to stderr. Checkout may
+// contact the remote in partial clones, so it runs with credential handling.
+func Create(ctx context.Context, repoPath, worktreePath, branch string, opts CreateOptions) error {
 	// Create the new branch and worktree in one step
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "add", "-b", branch, worktreePath)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to create worktree: %s\n%s", err, string(out))
+	args := []string{"worktree", "add", "-b", branch}
+	if opts.NoCheckout {
+		args = append(args, "--no-checkout")
+	}
+	args = append(args, worktreePath)
+	if err := gitRemote(ctx, repoPath, args...); err != nil {
+		return fmt.Errorf("failed to create worktree: %w", err)
+	}
+	return nil
+}
+
+// SetConfig sets git config values that apply only to one worktree, such
+// as a work email or signing key, with 'git config --worktree'. This needs
+// the repository's extensions.worktreeConfig, which is enabled on first use
+// unless the repository sets core.bare or core.worktree: those would then
+// apply to every worktree, so moving them is left to the user.
+func SetConfig(ctx context.Context, repoPath, worktreePath string, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if out, _ := gitOutput(ctx, repoPath, "config", "--bool", "extensions.worktreeConfig"); strings.TrimSpace(string(out)) != "true" {
+		for _, key := range []string{"core.bare", "core.worktree"} {
+			if out, err := gitOutput(ctx, repoPath, "config", "--get", key); err == nil && strings.TrimSpace(string(out)) != "false" {
+				return fmt.Errorf("cannot set per-worktree git config: the repository sets %s; move it to the main worktree and enable extensions.worktreeConfig (see 'git help worktree')", key)
+			}
+		}
+		if out, err := gitCombined(ctx, repoPath, "config", "extensions.worktreeConfig", "true"); err != nil {
+			return fmt.Errorf("failed to enable extensions.worktreeConfig: %s\n%s", err, string(out))
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if out, err := gitCombined(ctx, worktreePath, "config", "--worktree", k, values[k]); err != nil {
+			return fmt.Errorf("failed to set %s: %s\n%s", k, err, string(out))
+		}
+	}
+	return nil
+}
+
+// Checkout populates a worktree created with NoCheckout.
+func Checkout(ctx context.Context, worktreePath string) error {
+	if err := gitRemote(ctx, worktreePath, "reset", "--hard", "--quiet"); err != nil {
+		return fmt.Errorf("failed to check out worktree: %w", err)
 	}
 	return nil
 }
 
+// IsPartialClone reports whether a repository was cloned with a filter
+// (e.g. --filter=blob:none), in which case checkouts download missing objects.
+func IsPartialClone(ctx context.Context, repoPath string) bool {
+	out, err := gitOutput(ctx, repoPath, "config", "--get", "extensions.partialClone")
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
 // CreateFromExistingBranch creates a worktree from an existing branch.
-func CreateFromExistingBranch(repoPath, worktreePath, branch string) error {
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "add", worktreePath, branch)
-	if out, err := cmd.CombinedOutput(); err != nil {
+func CreateFromExistingBranch(ctx context.Context, repoPath, worktreePath, branch string) error {
+	if out, err := gitCombined(ctx, repoPath, "worktree", "add", worktreePath, branch); err != nil {
 		return fmt.Errorf("failed to create worktree: %s\n%s", err, string(out))
 	}
 	return nil
 }
 
+// TrackBranch creates a local branch tracking the branch of the same name
+// on origin, as last fetched.
+func TrackBranch(ctx context.Context, repoPath, branch string) error {
+	if out, err := gitCombined(ctx, repoPath, "branch", "--track", branch, "origin/"+branch); err != nil {
+		return fmt.Errorf("failed to track branch %q: %s\n%s", branch, err, string(out))
+	}
+	return nil
+}
+
 // Remove removes a git worktree.
-func Remove(repoPath, worktreePath string) error {
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "remove", worktreePath, "--force")
-	if out, err := cmd.CombinedOutput(); err != nil {
+func Remove(ctx context.Context, repoPath, worktreePath string) error {
+	if out, err := gitCombined(ctx, repoPath, "worktree", "remove", worktreePath, "--force"); err != nil {
 		return fmt.Errorf("failed to remove worktree: %s\n%s", err, string(out))
 	}
 	return nil
 }
 
-// List lists all worktrees for a repository.
-func List(repoPath string) ([]WorktreeInfo, error) {
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "list", "--porcelain")
-	out, err := cmd.Output()
+// List lists all worktrees for a repository. The paths of worktrees on
+// another machine carry its host, like repoPath.
+func List(ctx context.Context, repoPath string) ([]WorktreeInfo, error) {
+	wts, err := backendFor(repoPath).List(ctx, repoPath)
+	if host, _ := SplitHost(repoPath); host != "" {
+		for i := range wts {
+			wts[i].Path = JoinHost(host, wts[i].Path)
+		}
+	}
+	return wts, err
+}
+
+func (ExecBackend) List(ctx context.Context, repoPath string) ([]WorktreeInfo, error) {
+	out, err := gitOutput(ctx, repoPath, "worktree", "list", "--porcelain")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
@@ -96,39 +344,407 @@ func List(repoPath string) ([]WorktreeInfo, error) {
 }
 
 // DeleteBranch deletes a local git branch.
-func DeleteBranch(repoPath, branch string) error {
-	cmd := exec.Command("git", "-C", repoPath, "branch", "-D", branch)
-	if out, err := cmd.CombinedOutput(); err != nil {
+func DeleteBranch(ctx context.Context, repoPath, branch string) error {
+	if out, err := gitCombined(ctx, repoPath, "branch", "-D", branch); err != nil {
 		return fmt.Errorf("failed to delete branch %q: %s\n%s", branch, err, string(out))
 	}
 	return nil
 }
 
+// Bundle writes a branch with its full history to a git bundle file, from
+// which it can be fetched again after the branch is deleted.
+func Bundle(ctx context.Context, repoPath, file, branch string) error {
+	if out, err := gitCombined(ctx, repoPath, "bundle", "create", file, "refs/heads/"+branch); err != nil {
+		return fmt.Errorf("failed to bundle branch %q: %s\n%s", branch, err, string(out))
+	}
+	return nil
+}
+
+// BundleRefs writes refs with their full history to a git bundle file.
+func BundleRefs(ctx context.Context, repoPath, file string, refs ...string) error {
+	args := append([]string{"bundle", "create", file}, refs...)
+	if out, err := gitCombined(ctx, repoPath, args...); err != nil {
+		return fmt.Errorf("failed to bundle %s: %s\n%s", strings.Join(refs, ", "), err, string(out))
+	}
+	return nil
+}
+
+// FetchBundle fetches refspecs from a git bundle file into a repository.
+func FetchBundle(ctx context.Context, repoPath, file string, refspecs ...string) error {
+	args := append([]string{"fetch", "-q", file}, refspecs...)
+	if out, err := gitCombined(ctx, repoPath, args...); err != nil {
+		return fmt.Errorf("failed to fetch from %s: %s\n%s", file, err, string(out))
+	}
+	return nil
+}
+
 // BranchExists checks if a branch already exists.
-func BranchExists(repoPath, branch string) bool {
-	cmd := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", branch)
-	return cmd.Run() == nil
+func BranchExists(ctx context.Context, repoPath, branch string) bool {
+	return backendFor(repoPath).BranchExists(ctx, repoPath, branch)
+}
+
+func (ExecBackend) BranchExists(ctx context.Context, repoPath, branch string) bool {
+	_, err := gitOutput(ctx, repoPath, "rev-parse", "--verify", branch)
+	return err == nil
 }
 
 // Prune removes stale worktree administrative files.
-func Prune(repoPath string) error {
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "prune")
-	if out, err := cmd.CombinedOutput(); err != nil {
+func Prune(ctx context.Context, repoPath string) error {
+	if out, err := gitCombined(ctx, repoPath, "worktree", "prune"); err != nil {
 		return fmt.Errorf("failed to prune worktrees: %s\n%s", err, string(out))
 	}
 	return nil
 }
 
-// DefaultBranch detects the default branch of a repository.
-func DefaultBranch(repoPath string) string {
-	cmd := exec.Command("git", "-C", repoPath, "symbolic-ref", "refs/remotes/origin/HEAD")
-	out, err := cmd.Output()
+// Repair fixes the administrative links between a repository and its
+// worktrees after they were moved without git, including those at paths.
+func Repair(ctx context.Context, repoPath string, paths ...string) error {
+	args := append([]string{"worktree", "repair"}, paths...)
+	if out, err := gitCombined(ctx, repoPath, args...); err != nil {
+		return fmt.Errorf("failed to repair worktrees: %s\n%s", err, string(out))
+	}
+	return nil
+}
+
+// defaultBranchCandidates are tried, in order, when the remote doesn't say
+// which branch is the default.
+var defaultBranchCandidates = []string{"main", "master", "trunk", "develop"}
+
+// DefaultBranch detects the default branch of a repository. It tries, in
+// order: origin/HEAD, the HEAD branch reported by origin itself, and the
+// first of main, master, trunk and develop that exists locally or on
+// origin. It falls back to "main".
+func DefaultBranch(ctx context.Context, repoPath string) string {
+	if out, err := gitOutput(ctx, repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(out)), "origin/")
+	}
+	if branch, err := RemoteHead(ctx, repoPath); err == nil {
+		return branch
+	}
+	for _, branch := range defaultBranchCandidates {
+		for _, ref := range []string{"refs/heads/" + branch, "refs/remotes/origin/" + branch} {
+			if _, err := gitOutput(ctx, repoPath, "rev-parse", "--verify", "-q", ref); err == nil {
+				return branch
+			}
+		}
+	}
+	return "main"
+}
+
+// StatusInfo summarizes the state of a worktree.
+type StatusInfo struct {
+	Changed int
+	// Untracked lists the untracked files among the changed ones.
+	Untracked   []string
+	Ahead       int
+	Behind      int
+	HasUpstream bool
+}
+
+// ErrDirty is returned when a worktree with uncommitted changes would be
+// removed without force.
+var ErrDirty = errors.New("worktree has uncommitted changes")
+
+// Dirty reports whether the worktree has uncommitted changes.
+func (s StatusInfo) Dirty() bool {
+	return s.Changed > 0
+}
+
+// String formats the status compactly, e.g. "3 changed ↑1 ↓2".
+func (s StatusInfo) String() string {
+	out := "clean"
+	if s.Dirty() {
+		out = fmt.Sprintf("%d changed", s.Changed)
+	}
+	if s.Ahead > 0 {
+		out += fmt.Sprintf(" ↑%d", s.Ahead)
+	}
+	if s.Behind > 0 {
+		out += fmt.Sprintf(" ↓%d", s.Behind)
+	}
+	return out
+}
+
+// Status returns the working tree and upstream status of a worktree.
+func Status(ctx context.Context, worktreePath string) (StatusInfo, error) {
+	return backendFor(worktreePath).Status(ctx, worktreePath)
+}
+
+func (ExecBackend) Status(ctx context.Context, worktreePath string) (StatusInfo, error) {
+	out, err := gitOutput(ctx, worktreePath, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return StatusInfo{}, fmt.Errorf("failed to get status of %s: %w", worktreePath, err)
+	}
+	return parseStatus(string(out)), nil
+}
+
+func parseStatus(output string) StatusInfo {
+	var st StatusInfo
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.ab "):
+			st.HasUpstream = true
+			fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &st.Ahead, &st.Behind)
+		case strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "? "):
+			st.Changed++
+			st.Untracked = append(st.Untracked, strings.TrimPrefix(line, "? "))
+		default:
+			st.Changed++
+		}
+	}
+	return st
+}
+
+// Compare counts the commits HEAD of a worktree is ahead of and behind
+// base. The remote-tracking branch origin/<base> is preferred when it
+// exists, since the local base branch is often stale.
+func Compare(ctx context.Context, worktreePath, base string) (ahead, behind int, err error) {
+	head, baseCommit, err := ResolveBase(ctx, worktreePath, base)
+	if err != nil {
+		return 0, 0, err
+	}
+	return CompareCommits(ctx, worktreePath, head, baseCommit)
+}
+
+// ResolveBase returns the commits at HEAD of a worktree and at base,
+// preferring origin/<base> like Compare. The pair identifies the result of
+// Compare, so callers can cache it.
+func ResolveBase(ctx context.Context, worktreePath, base string) (head, baseCommit string, err error) {
+	out, err := gitOutput(ctx, worktreePath, "rev-parse", "HEAD", "refs/remotes/origin/"+base)
+	if err != nil {
+		out, err = gitOutput(ctx, worktreePath, "rev-parse", "HEAD", base)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", base, err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("failed to parse rev-parse output %q", out)
+	}
+	return fields[0], fields[1], nil
+}
+
+// CompareCommits counts the commits head is ahead of and behind base.
+func CompareCommits(ctx context.Context, worktreePath, head, base string) (ahead, behind int, err error) {
+	out, err := gitOutput(ctx, worktreePath, "rev-list", "--left-right", "--count", head+"..."+base)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", base, err)
+	}
+	if _, err := fmt.Sscanf(string(out), "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse rev-list output %q: %w", out, err)
+	}
+	return ahead, behind, nil
+}
+
+// BaseRef returns origin/<base> when that remote-tracking branch exists in
+// a repository, and base otherwise.
+func BaseRef(ctx context.Context, repoPath, base string) string {
+	if _, err := gitOutput(ctx, repoPath, "rev-parse", "--verify", "-q", "refs/remotes/origin/"+base); err == nil {
+		return "origin/" + base
+	}
+	return base
+}
+
+// BranchCommits returns the tip commit of a branch and its merge base with
+// base, preferring origin/<base> like Compare. The merge base is empty when
+// the branch and base share no history.
+func BranchCommits(ctx context.Context, repoPath, branch, base string) (head, mergeBase string, err error) {
+	out, err := gitOutput(ctx, repoPath, "rev-parse", "--verify", "-q", "refs/heads/"+branch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve branch %q: %w", branch, err)
+	}
+	head = strings.TrimSpace(string(out))
+	out, err = gitOutput(ctx, repoPath, "merge-base", head, BaseRef(ctx, repoPath, base))
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return head, "", nil
+	}
+	if err != nil {
+		return head, "", fmt.Errorf("failed to find merge base of %s and %s: %w", branch, base, err)
+	}
+	return head, strings.TrimSpace(string(out)), nil
+}
+
+// CommonBase returns the best common ancestor of commits, or "" when they
+// share no history.
+func CommonBase(ctx context.Context, repoPath string, commits ...string) (string, error) {
+	out, err := gitOutput(ctx, repoPath, append([]string{"merge-base", "--octopus"}, commits...)...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find common base: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// FileChange is a file changed in a diff. Binary files count no lines.
+type FileChange struct {
+	Path       string `json:"path"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+}
+
+// DiffStat summarizes the changes of a diff.
+type DiffStat struct {
+	Files      int `json:"files"`
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// Stat sums up changed files.
+func Stat(files []FileChange) DiffStat {
+	d := DiffStat{Files: len(files)}
+	for _, f := range files {
+		d.Insertions += f.Insertions
+		d.Deletions += f.Deletions
+	}
+	return d
+}
+
+func (d DiffStat) String() string {
+	return fmt.Sprintf("%d files +%d -%d", d.Files, d.Insertions, d.Deletions)
+}
+
+// Diff returns the files changed in a worktree since base, including
+// uncommitted changes to tracked files.
+func Diff(ctx context.Context, worktreePath, base string) ([]FileChange, error) {
+	out, err := gitOutput(ctx, worktreePath, "diff", "--numstat", "--no-renames", base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff against %s: %w", base, err)
+	}
+	return parseNumstat(string(out)), nil
+}
+
+// DiffFiles returns the unified diff between two files, which don't have to
+// be in a repository. A missing file diffs as empty.
+func DiffFiles(ctx context.Context, a, b string) (string, error) {
+	if _, err := os.Stat(a); os.IsNotExist(err) {
+		a = os.DevNull
+	}
+	if _, err := os.Stat(b); os.IsNotExist(err) {
+		b = os.DevNull
+	}
+	out, err := gitOutput(ctx, ".", "diff", "--no-index", "--no-color", "--", a, b)
+	// Exit status 1 means the files differ.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s and %s: %w", a, b, err)
+	}
+	return string(out), nil
+}
+
+func parseNumstat(output string) []FileChange {
+	var files []FileChange
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files show "-" instead of line counts.
+		ins, _ := strconv.Atoi(fields[0])
+		del, _ := strconv.Atoi(fields[1])
+		files = append(files, FileChange{Path: fields[2], Insertions: ins, Deletions: del})
+	}
+	return files
+}
+
+// IsAncestor reports whether commit ancestor is reachable from descendant.
+func IsAncestor(ctx context.Context, repoPath, ancestor, descendant string) (bool, error) {
+	_, err := gitOutput(ctx, repoPath, "merge-base", "--is-ancestor", ancestor, descendant)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to compare %s with %s: %w", ancestor, descendant, err)
+	}
+	return true, nil
+}
+
+// inProgress maps files git keeps in the git directory during an
+// unfinished operation to a description of it.
+var inProgress = []struct{ file, op string }{
+	{"rebase-merge", "a rebase"},
+	{"rebase-apply", "a rebase or am"},
+	{"MERGE_HEAD", "a merge"},
+	{"CHERRY_PICK_HEAD", "a cherry-pick"},
+	{"REVERT_HEAD", "a revert"},
+	{"BISECT_LOG", "a bisect"},
+}
+
+// CheckoutProblems describes what makes a checkout a poor starting point for
+// a new branch: uncommitted changes, an unfinished rebase, merge or similar,
+// or a detached HEAD. It returns nil for a clean checkout on a branch.
+func CheckoutProblems(ctx context.Context, path string) ([]string, error) {
+	var problems []string
+	st, err := Status(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if st.Dirty() {
+		problems = append(problems, fmt.Sprintf("%d uncommitted change(s)", st.Changed))
+	}
+	args := []string{"rev-parse"}
+	for _, p := range inProgress {
+		args = append(args, "--git-path", p.file)
+	}
+	out, err := gitOutput(ctx, path, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate git directory: %w", err)
+	}
+	files := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for i, p := range inProgress {
+		if i >= len(files) {
+			break
+		}
+		file := files[i]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(path, file)
+		}
+		if _, err := os.Stat(file); err == nil {
+			problems = append(problems, p.op+" is in progress")
+		}
+	}
+	if _, err := gitOutput(ctx, path, "symbolic-ref", "-q", "HEAD"); err != nil {
+		problems = append(problems, "HEAD is detached")
+	}
+	return problems, nil
+}
+
+// CommitInfo describes a commit.
+type CommitInfo struct {
+	Hash    string    `json:"hash"`
+	Subject string    `json:"subject"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+}
+
+// LastCommit returns the commit at HEAD of a worktree.
+func LastCommit(ctx context.Context, worktreePath string) (CommitInfo, error) {
+	out, err := gitOutput(ctx, worktreePath, "log", "-1", "--format=%h%x00%s%x00%an%x00%ct")
+	if err != nil {
+		return CommitInfo{}, fmt.Errorf("failed to read last commit: %w", err)
+	}
+	return parseCommit(string(out))
+}
+
+func parseCommit(output string) (CommitInfo, error) {
+	parts := strings.Split(strings.TrimSpace(output), "\x00")
+	if len(parts) != 4 {
+		return CommitInfo{}, fmt.Errorf("unexpected git log output %q", output)
+	}
+	sec, err := strconv.ParseInt(parts[3], 10, 64)
 	if err != nil {
-		return "main"
+		return CommitInfo{}, fmt.Errorf("invalid commit time %q", parts[3])
 	}
-	ref := strings.TrimSpace(string(out))
-	parts := strings.Split(ref, "/")
-	return parts[len(parts)-1]
+	return CommitInfo{Hash: parts[0], Subject: parts[1], Author: parts[2], Time: time.Unix(sec, 0)}, nil
 }
 
 // WorktreeInfo holds parsed worktree information.
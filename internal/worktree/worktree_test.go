@@ -1,6 +1,14 @@
 package worktree
 
-import "testing"
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestSanitizeBranchName(t *testing.T) {
 	tests := []struct {
@@ -15,6 +23,9 @@ func TestSanitizeBranchName(t *testing.T) {
 		{"a-very-long-description-that-exceeds-the-sixty-character-limit-by-quite-a-bit", "a-very-long-description-that-exceeds-the-sixty-character-lim"},
 		{"---leading-trailing---", "leading-trailing"},
 		{"simple", "simple"},
+		{"Résumé für Größe", "resume-fur-grosse"},
+		{"Łódź café", "lodz-cafe"},
+		{"cafe\u0301 crème", "cafe-creme"},
 	}
 
 	for _, tt := range tests {
@@ -27,6 +38,54 @@ func TestSanitizeBranchName(t *testing.T) {
 	}
 }
 
+func TestSanitizeBranchNameWith(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     NameOptions
+		expected string
+	}{
+		{"fix the bug in the parser", NameOptions{DropStopwords: true}, "fix-bug-parser"},
+		{"the of and", NameOptions{DropStopwords: true}, "the-of-and"},
+		{"fix the bug", NameOptions{}, "fix-the-bug"},
+		{"add user authentication", NameOptions{MaxLength: 12}, "add-user-aut"},
+		{"add user authentication", NameOptions{MaxLength: 100}, "add-user-authentication"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			got := SanitizeBranchNameWith(tt.input, tt.opts)
+			if got != tt.expected {
+				t.Errorf("SanitizeBranchNameWith(%q, %+v) = %q, want %q", tt.input, tt.opts, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCleanRefName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"feature/add-login", "feature/add-login"},
+		{"feature.lock/add-login", "feature/add-login"},
+		{"feature/add-login.lock", "feature/add-login"},
+		{"feat..ure/x", "feat.ure/x"},
+		{".hidden/x.", "hidden/x"},
+		{"a//b", "a/b"},
+		{"my branch~1^2:x?*[y]\\z", "my-branch-1-2-x-y]-z"},
+		{"fix@{now}", "fix-now}"},
+		{"@", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := CleanRefName(tt.input); got != tt.expected {
+				t.Errorf("CleanRefName(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestBranchName(t *testing.T) {
 	tests := []struct {
 		prefix      string
@@ -36,11 +95,12 @@ func TestBranchName(t *testing.T) {
 		{"feature", "add login", "feature/add-login"},
 		{"", "add login", "add-login"},
 		{"fix", "memory leak in parser", "fix/memory-leak-in-parser"},
+		{"feature.lock", "add login", "feature/add-login"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.expected, func(t *testing.T) {
-			got := BranchName(tt.prefix, tt.description)
+			got := BranchName(tt.prefix, tt.description, NameOptions{})
 			if got != tt.expected {
 				t.Errorf("BranchName(%q, %q) = %q, want %q", tt.prefix, tt.description, got, tt.expected)
 			}
@@ -57,14 +117,224 @@ func TestBranchNameFromTicket(t *testing.T) {
 	}{
 		{"feature", "PROJ-123", "implement oauth flow", "feature/proj-123-implement-oauth-flow"},
 		{"", "BUG-456", "fix crash on startup", "bug-456-fix-crash-on-startup"},
+		{"feature", "acme/backend#12", "fix login redirect", "feature/acme-backend-12-fix-login-redirect"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.expected, func(t *testing.T) {
-			got := BranchNameFromTicket(tt.prefix, tt.ticket, tt.summary)
+			got := BranchNameFromTicket(tt.prefix, tt.ticket, tt.summary, NameOptions{})
 			if got != tt.expected {
 				t.Errorf("BranchNameFromTicket(%q, %q, %q) = %q, want %q", tt.prefix, tt.ticket, tt.summary, got, tt.expected)
 			}
 		})
 	}
 }
+
+func TestBranchNameFromTemplate(t *testing.T) {
+	const tmpl = "{{.Prefix}}/{{with .Epic}}{{.}}/{{end}}{{.Key}}-{{.Summary}}"
+	tests := []struct {
+		prefix   string
+		ticket   string
+		epic     string
+		summary  string
+		expected string
+	}{
+		{"feature", "PROJ-123", "PROJ-100", "implement oauth flow", "feature/proj-100/proj-123-implement-oauth-flow"},
+		{"feature", "PROJ-123", "", "implement oauth flow", "feature/proj-123-implement-oauth-flow"},
+		{"", "", "", "fix crash", "fix-crash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			got, err := BranchNameFromTemplate(tmpl, tt.prefix, tt.ticket, tt.epic, tt.summary, NameOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expected {
+				t.Errorf("BranchNameFromTemplate() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := BranchNameFromTemplate("{{.Nope}}", "", "", "", "x", NameOptions{}); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	output := `# branch.oid 1234567890abcdef
+# branch.head feature/x
+# branch.upstream origin/feature/x
+# branch.ab +2 -1
+1 .M N... 100644 100644 100644 abc abc main.go
+? new.txt
+`
+	st := parseStatus(output)
+	if st.Changed != 2 || st.Ahead != 2 || st.Behind != 1 || !st.HasUpstream {
+		t.Errorf("parseStatus() = %+v", st)
+	}
+	if got, want := st.String(), "2 changed ↑2 ↓1"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	clean := parseStatus("# branch.oid abc\n# branch.head main\n")
+	if clean.Dirty() || clean.HasUpstream || clean.String() != "clean" {
+		t.Errorf("expected clean status without upstream, got %+v", clean)
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	files := parseNumstat("10\t2\tmain.go\n-\t-\tlogo.png\n0\t5\tdocs/old name.md\n")
+	want := []FileChange{
+		{Path: "main.go", Insertions: 10, Deletions: 2},
+		{Path: "logo.png"},
+		{Path: "docs/old name.md", Deletions: 5},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("parseNumstat() = %+v, want %+v", files, want)
+	}
+	if got, want := Stat(files), (DiffStat{Files: 3, Insertions: 10, Deletions: 7}); got != want {
+		t.Errorf("Stat() = %+v, want %+v", got, want)
+	}
+	if files := parseNumstat(""); files != nil {
+		t.Errorf("parseNumstat(\"\") = %+v, want nil", files)
+	}
+}
+
+func TestParseCommit(t *testing.T) {
+	c, err := parseCommit("a1b2c3d\x00Fix login redirect\x00Ann\x001760000000\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Hash != "a1b2c3d" || c.Subject != "Fix login redirect" || c.Author != "Ann" || c.Time.Unix() != 1760000000 {
+		t.Errorf("parseCommit() = %+v", c)
+	}
+	if _, err := parseCommit("garbage"); err == nil {
+		t.Error("expected error for malformed output")
+	}
+}
+
+func TestIsAuthFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", true},
+		{"git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.", true},
+		{"remote: Invalid username or password.\nfatal: Authentication failed for 'https://example.com/repo.git/'", true},
+		{"fatal: 'origin' does not appear to be a git repository", false},
+	}
+	for _, tt := range tests {
+		if got := isAuthFailure(tt.output); got != tt.want {
+			t.Errorf("isAuthFailure(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestParseSymref(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"ref: refs/heads/trunk\tHEAD\n3f2a1b0c\tHEAD\n", "trunk"},
+		{"ref: refs/heads/release/2.x\tHEAD\n", "release/2.x"},
+		{"3f2a1b0c\tHEAD\n", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseSymref(tt.output); got != tt.want {
+			t.Errorf("parseSymref(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestCheckoutProblems(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", "init")
+	wtPath := filepath.Join(root, "feature")
+	gitTest(t, repo, "worktree", "add", "-q", "-b", "feature/x", wtPath)
+	ctx := context.Background()
+
+	if problems, err := CheckoutProblems(ctx, wtPath); err != nil || len(problems) != 0 {
+		t.Fatalf("clean worktree: got %v, %v", problems, err)
+	}
+	if err := os.WriteFile(filepath.Join(wtPath, "new.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".git", "worktrees", "feature", "MERGE_HEAD"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitTest(t, repo, "checkout", "-q", "--detach")
+
+	want := []string{"1 uncommitted change(s)", "a merge is in progress"}
+	if problems, err := CheckoutProblems(ctx, wtPath); err != nil || !reflect.DeepEqual(problems, want) {
+		t.Errorf("worktree: got %v, %v, want %v", problems, err, want)
+	}
+	want = []string{"HEAD is detached"}
+	if problems, err := CheckoutProblems(ctx, repo); err != nil || !reflect.DeepEqual(problems, want) {
+		t.Errorf("repo: got %v, %v, want %v", problems, err, want)
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", "init")
+	wtPath := filepath.Join(root, "feature")
+	gitTest(t, repo, "worktree", "add", "-q", "-b", "feature/x", wtPath)
+	ctx := context.Background()
+
+	if err := SetConfig(ctx, repo, wtPath, map[string]string{"user.email": "me@work.example"}); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := gitOutput(ctx, wtPath, "config", "user.email"); string(out) != "me@work.example\n" {
+		t.Errorf("worktree user.email = %q", out)
+	}
+	if out, _ := gitOutput(ctx, repo, "config", "--worktree", "user.email"); len(out) != 0 {
+		t.Errorf("main worktree user.email = %q, want unset", out)
+	}
+}
+
+func TestBranchCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	gitTest(t, root, "init", "-q", "-b", "main", repo)
+	gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", "init")
+	gitTest(t, repo, "branch", "feature/x")
+	gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", "base moves on")
+	gitTest(t, repo, "checkout", "-q", "feature/x")
+	gitTest(t, repo, "commit", "-q", "--allow-empty", "-m", "work")
+	ctx := context.Background()
+
+	head, mergeBase, err := BranchCommits(ctx, repo, "feature/x", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := revParse(t, repo, "feature/x"); head != want {
+		t.Errorf("head = %q, want %q", head, want)
+	}
+	if want := revParse(t, repo, "main~1"); mergeBase != want {
+		t.Errorf("merge base = %q, want %q", mergeBase, want)
+	}
+}
+
+func revParse(t *testing.T, repo, rev string) string {
+	t.Helper()
+	out, err := gitOutput(context.Background(), repo, "rev-parse", rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(out))
+}